@@ -27,6 +27,7 @@ import (
 	"github.com/memodb-io/Acontext/internal/infra/cache"
 	dbpkg "github.com/memodb-io/Acontext/internal/infra/db"
 	"github.com/memodb-io/Acontext/internal/modules/handler"
+	"github.com/memodb-io/Acontext/internal/modules/service"
 	"github.com/memodb-io/Acontext/internal/pkg/tokenizer"
 	"github.com/memodb-io/Acontext/internal/router"
 	"github.com/memodb-io/Acontext/internal/telemetry"
@@ -93,6 +94,10 @@ func main() {
 	agentSkillsHandler := do.MustInvoke[*handler.AgentSkillsHandler](inj)
 	userHandler := do.MustInvoke[*handler.UserHandler](inj)
 	sandboxHandler := do.MustInvoke[*handler.SandboxHandler](inj)
+	storageHandler := do.MustInvoke[*handler.StorageHandler](inj)
+	shareHandler := do.MustInvoke[*handler.ShareHandler](inj)
+	adminHandler := do.MustInvoke[*handler.AdminHandler](inj)
+	blobHandler := do.MustInvoke[*handler.BlobHandler](inj)
 
 	engine := router.NewRouter(router.RouterDeps{
 		Config:             cfg,
@@ -108,8 +113,19 @@ func main() {
 		AgentSkillsHandler: agentSkillsHandler,
 		UserHandler:        userHandler,
 		SandboxHandler:     sandboxHandler,
+		StorageHandler:     storageHandler,
+		ShareHandler:       shareHandler,
+		AdminHandler:       adminHandler,
+		BlobHandler:        blobHandler,
 	})
 
+	// collect orphaned assets in the background; one instance does it each interval
+	gcCtx, stopGC := context.WithCancel(context.Background())
+	defer stopGC()
+	if cfg.AssetGC.Enabled {
+		go do.MustInvoke[service.AssetGCService](inj).Run(gcCtx)
+	}
+
 	addr := fmt.Sprintf("%s:%d", cfg.App.Host, cfg.App.Port)
 	srv := &http.Server{
 		Addr:              addr,
@@ -132,6 +148,7 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
+	stopGC()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -15,6 +15,61 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/gc/assets": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the stored files of every project that the asset garbage collector would delete: files that nothing has referenced for the grace period, oldest first. Nothing is deleted. Requires the root API token, without the project token prefix.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Dry-run asset garbage collection",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 86400,
+                        "description": "Grace period in seconds, defaults to the configured one",
+                        "name": "grace_sec",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of assets to list, default 100. Max 1000.",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/service.AssetGCReport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
         "/agent_skills": {
             "get": {
                 "security": [
@@ -490,6 +545,64 @@ const docTemplate = `{
             }
         },
         "/disk/{disk_id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update the settings of a disk. version_retention is how many previous versions are kept per artifact (0 disables versioning); a lower value is applied to an artifact the next time it changes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disk"
+                ],
+                "summary": "Update disk",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "UpdateDisk payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdateDiskReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Disk"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
             "delete": {
                 "security": [
                     {
@@ -547,7 +660,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get artifact information by path and filename. Optionally include a presigned URL for downloading and parsed file content.",
+                "description": "Get artifact information by path and filename. Optionally include a presigned URL for downloading and parsed file content. Use offset and limit to read a window of lines from large text files; total_lines and has_more in the content tell how far to page. The ETag header identifies the version read, for If-Match on later writes.",
                 "consumes": [
                     "application/json"
                 ],
@@ -596,6 +709,27 @@ const docTemplate = `{
                         "description": "Expire time in seconds for presigned URL (default: 3600)",
                         "name": "expire",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 0,
+                        "description": "Number of content lines to skip (default: 0)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 200,
+                        "description": "Maximum number of content lines to return (default: all)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "example": false,
+                        "description": "Whether to prefix content lines with line numbers",
+                        "name": "line_numbers",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -615,6 +749,12 @@ const docTemplate = `{
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the artifact, taken from the SHA256 of its content and its version"
+                            }
                         }
                     }
                 },
@@ -637,7 +777,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update an artifact's metadata (user-defined metadata only)",
+                "description": "Update an artifact's metadata (user-defined metadata only). Send its ETag in If-Match to only update the version you have seen.",
                 "consumes": [
                     "application/json"
                 ],
@@ -666,6 +806,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/handler.UpdateArtifactReq"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only update the artifact if it has one of these ETags",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only update the artifact if it has none of these ETags",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -685,6 +837,18 @@ const docTemplate = `{
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the updated artifact"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "The artifact does not match If-Match or If-None-Match",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
                         }
                     }
                 },
@@ -707,7 +871,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Upload a file and create or update an artifact record under a disk. File size must not exceed the configured maximum upload size limit (default: 16MB).",
+                "description": "Upload a file and create or update an artifact record under a disk. File size must not exceed the configured maximum upload size limit (default: 16MB). Send the ETag of the artifact in If-Match to only overwrite the version you have seen, or If-None-Match: * to only create it.",
                 "consumes": [
                     "multipart/form-data"
                 ],
@@ -746,6 +910,18 @@ const docTemplate = `{
                         "description": "Custom metadata as JSON string (optional, system metadata will be stored under '__artifact_info__' key)",
                         "name": "meta",
                         "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only overwrite the artifact if it has one of these ETags",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only write if the artifact has none of these ETags; * to only create the artifact",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -765,6 +941,18 @@ const docTemplate = `{
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the written artifact"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "The artifact does not match If-Match or If-None-Match",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
                         }
                     },
                     "413": {
@@ -793,7 +981,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete an artifact by path and filename",
+                "description": "Delete an artifact by path and filename. Send its ETag in If-Match to only delete the version you have seen.",
                 "consumes": [
                     "application/json"
                 ],
@@ -821,6 +1009,18 @@ const docTemplate = `{
                         "name": "file_path",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only delete the artifact if it has one of these ETags",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only delete the artifact if it has none of these ETags",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -829,6 +1029,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/serializer.Response"
                         }
+                    },
+                    "412": {
+                        "description": "The artifact does not match If-Match or If-None-Match",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
+                        }
                     }
                 },
                 "x-code-samples": [
@@ -845,24 +1051,25 @@ const docTemplate = `{
                 ]
             }
         },
-        "/disk/{disk_id}/artifact/download_to_sandbox": {
-            "post": {
+        "/disk/{disk_id}/artifact/archive": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Download an artifact from disk storage to a sandbox environment",
+                "description": "Stream a zip or tar.gz archive of a directory with everything below it. Paths in the archive are relative to the directory.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/zip",
+                    "application/gzip"
                 ],
                 "tags": [
                     "artifact"
                 ],
-                "summary": "Download artifact to sandbox",
+                "summary": "Download directory archive",
                 "parameters": [
                     {
                         "type": "string",
@@ -874,57 +1081,41 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Download to sandbox request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handler.DownloadToSandboxReq"
-                        }
+                        "type": "string",
+                        "example": "/out/",
+                        "description": "Directory to archive, defaults to the disk root",
+                        "name": "path",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "zip",
+                            "tar.gz"
+                        ],
+                        "type": "string",
+                        "description": "Archive format, zip (default) or tar.gz",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/serializer.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/handler.DownloadToSandboxResp"
-                                        }
-                                    }
-                                }
-                            ]
+                            "type": "file"
                         }
                     }
-                },
-                "x-code-samples": [
-                    {
-                        "label": "Python",
-                        "lang": "python",
-                        "source": "from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Download artifact to sandbox\nresult = client.disks.artifacts.download_to_sandbox(\n    disk_id='disk-uuid',\n    file_path='/documents/',\n    filename='report.pdf',\n    sandbox_id='sandbox-uuid',\n    sandbox_path='/home/user/'\n)\nprint(f\"Success: {result.success}\")\n"
-                    },
-                    {
-                        "label": "JavaScript",
-                        "lang": "javascript",
-                        "source": "import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Download artifact to sandbox\nconst result = await client.disks.artifacts.downloadToSandbox('disk-uuid', {\n  filePath: '/documents/',\n  filename: 'report.pdf',\n  sandboxId: 'sandbox-uuid',\n  sandboxPath: '/home/user/'\n});\nconsole.log(` + "`" + `Success: ${result.success}` + "`" + `);\n"
-                    }
-                ]
+                }
             }
         },
-        "/disk/{disk_id}/artifact/glob": {
-            "get": {
+        "/disk/{disk_id}/artifact/copy": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Search through artifact file paths using glob patterns (*, ?, etc.)",
+                "description": "Copy a file, or a directory with everything below it. A destination ending with / keeps the filename. Copies share the stored file and start without version history.",
                 "consumes": [
                     "application/json"
                 ],
@@ -934,28 +1125,25 @@ const docTemplate = `{
                 "tags": [
                     "artifact"
                 ],
-                "summary": "Search artifact paths with glob patterns",
+                "summary": "Copy artifacts",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
                         "description": "Disk ID",
                         "name": "disk_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Glob pattern (e.g., '**/*.py', '*.txt')",
-                        "name": "query",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Maximum number of results (default 100, max 1000)",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "Copy artifacts request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.TransferArtifactsReq"
+                        }
                     }
                 ],
                 "responses": {
@@ -970,10 +1158,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/model.Artifact"
-                                            }
+                                            "$ref": "#/definitions/service.TransferArtifactsOutput"
                                         }
                                     }
                                 }
@@ -983,14 +1168,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/disk/{disk_id}/artifact/grep": {
+        "/disk/{disk_id}/artifact/diff": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Search through text-based artifact content using regex patterns",
+                "description": "Get a unified diff between two versions of a text artifact",
                 "consumes": [
                     "application/json"
                 ],
@@ -1000,11 +1185,12 @@ const docTemplate = `{
                 "tags": [
                     "artifact"
                 ],
-                "summary": "Search artifact content with regex",
+                "summary": "Diff artifact versions",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
                         "description": "Disk ID",
                         "name": "disk_id",
                         "in": "path",
@@ -1012,15 +1198,25 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Regex pattern to search for",
-                        "name": "query",
+                        "example": "/documents/report.md",
+                        "description": "File path including filename",
+                        "name": "file_path",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "Maximum number of results (default 100, max 1000)",
-                        "name": "limit",
+                        "example": 1,
+                        "description": "Version to diff from",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "example": 2,
+                        "description": "Version to diff to (default: current)",
+                        "name": "to",
                         "in": "query"
                     }
                 ],
@@ -1036,10 +1232,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/model.Artifact"
-                                            }
+                                            "$ref": "#/definitions/service.ArtifactDiff"
                                         }
                                     }
                                 }
@@ -1049,14 +1242,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/disk/{disk_id}/artifact/ls": {
-            "get": {
+        "/disk/{disk_id}/artifact/download_to_sandbox": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "List artifacts in a specific path or all artifacts in a disk",
+                "description": "Download an artifact from disk storage to a sandbox environment",
                 "consumes": [
                     "application/json"
                 ],
@@ -1066,7 +1259,7 @@ const docTemplate = `{
                 "tags": [
                     "artifact"
                 ],
-                "summary": "List artifacts",
+                "summary": "Download artifact to sandbox",
                 "parameters": [
                     {
                         "type": "string",
@@ -1078,10 +1271,13 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Path filter (optional, defaults to root '/')",
-                        "name": "path",
-                        "in": "query"
+                        "description": "Download to sandbox request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.DownloadToSandboxReq"
+                        }
                     }
                 ],
                 "responses": {
@@ -1096,7 +1292,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/handler.ListArtifactsResp"
+                                            "$ref": "#/definitions/handler.DownloadToSandboxResp"
                                         }
                                     }
                                 }
@@ -1108,24 +1304,24 @@ const docTemplate = `{
                     {
                         "label": "Python",
                         "lang": "python",
-                        "source": "from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# List artifacts in a path\nresult = client.disks.list_artifacts(\n    disk_id='disk-uuid',\n    path='/documents/'\n)\nprint(f\"Found {len(result.artifacts)} artifacts\")\nfor artifact in result.artifacts:\n    print(f\"  - {artifact.path}{artifact.filename}\")\nprint(f\"Subdirectories: {', '.join(result.directories)}\")\n"
+                        "source": "from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Download artifact to sandbox\nresult = client.disks.artifacts.download_to_sandbox(\n    disk_id='disk-uuid',\n    file_path='/documents/',\n    filename='report.pdf',\n    sandbox_id='sandbox-uuid',\n    sandbox_path='/home/user/'\n)\nprint(f\"Success: {result.success}\")\n"
                     },
                     {
                         "label": "JavaScript",
                         "lang": "javascript",
-                        "source": "import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// List artifacts in a path\nconst result = await client.disks.listArtifacts('disk-uuid', {\n  path: '/documents/'\n});\nconsole.log(` + "`" + `Found ${result.artifacts.length} artifacts` + "`" + `);\nfor (const artifact of result.artifacts) {\n  console.log(` + "`" + `  - ${artifact.path}${artifact.filename}` + "`" + `);\n}\nconsole.log(` + "`" + `Subdirectories: ${result.directories.join(', ')}` + "`" + `);\n"
+                        "source": "import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Download artifact to sandbox\nconst result = await client.disks.artifacts.downloadToSandbox('disk-uuid', {\n  filePath: '/documents/',\n  filename: 'report.pdf',\n  sandboxId: 'sandbox-uuid',\n  sandboxPath: '/home/user/'\n});\nconsole.log(` + "`" + `Success: ${result.success}` + "`" + `);\n"
                     }
                 ]
             }
         },
-        "/disk/{disk_id}/artifact/upload_from_sandbox": {
+        "/disk/{disk_id}/artifact/edit": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Upload a file from a sandbox environment to disk storage as an artifact",
+                "description": "Edit a text file in place with str_replace, insert_at_line, delete_lines or apply_patch, without uploading it again. The previous content is kept as a version. Returns the changed lines with some context. Send the ETag of the artifact in If-Match to only edit the version you have seen; without it, an edit that races with another write is applied again to the new content.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1135,7 +1331,7 @@ const docTemplate = `{
                 "tags": [
                     "artifact"
                 ],
-                "summary": "Upload file from sandbox to disk",
+                "summary": "Edit artifact",
                 "parameters": [
                     {
                         "type": "string",
@@ -1147,47 +1343,1745 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Upload from sandbox request",
+                        "description": "Edit artifact request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.UploadFromSandboxReq"
+                            "$ref": "#/definitions/handler.EditArtifactReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only edit the artifact if it has one of these ETags",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only edit the artifact if it has none of these ETags",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/service.EditArtifactOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the edited artifact"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "The artifact kept changing while the edit was applied",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
+                        }
+                    },
+                    "412": {
+                        "description": "The artifact does not match If-Match or If-None-Match",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/artifact/extract": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upload a zip or tar.gz archive and unpack its files into a directory. Existing files get a new version. Either every file is written or none is. Archives with unsafe paths are rejected, and so are archives expanding beyond the configured size or file count.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artifact"
+                ],
+                "summary": "Extract archive",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "/project/",
+                        "description": "Directory to extract into, defaults to the disk root",
+                        "name": "path",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "Archive ending in .zip, .tar.gz or .tgz",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/service.ExtractArchiveOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/artifact/finalize": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Finish a direct upload started with upload_url. The uploaded file must match the declared size and SHA256. Creates the artifact, or a new version of it if the file already exists.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artifact"
+                ],
+                "summary": "Finalize artifact upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Finalize upload request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.FinalizeUploadReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Artifact"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "410": {
+                        "description": "Upload expired",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
+                        }
+                    },
+                    "507": {
+                        "description": "Storage quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/artifact/glob": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Search artifact file paths with a glob pattern matched against the full path. * and ? stay within one directory, ** crosses directories, {a,b} matches either alternative and [abc], [a-z], [!abc] match one character. Patterns without a leading / start at the root. Results are sorted by path.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artifact"
+                ],
+                "summary": "Search artifact paths with glob patterns",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Glob pattern (e.g., '**/*.py', '*.txt', '/docs/**/*.{md,txt}')",
+                        "name": "query",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "{\"status\":\"reviewed\"}",
+                        "description": "JSON object the user meta must contain",
+                        "name": "meta",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Keys the user meta must have, whatever their value",
+                        "name": "meta_key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "text/*",
+                        "description": "Exact MIME type, or a family such as text/*",
+                        "name": "mime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum size in bytes",
+                        "name": "min_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum size in bytes",
+                        "name": "max_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-01-01T00:00:00Z",
+                        "description": "Only artifacts updated after this RFC 3339 time",
+                        "name": "updated_after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Artifact"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     }
+                }
+            }
+        },
+        "/disk/{disk_id}/artifact/glob/entries": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Like the glob search, but also returns the directories matching the pattern as full paths ending with /, so 'src/*' lists both the files and the subdirectories of /src/. Both lists are sorted by path.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artifact"
+                ],
+                "summary": "Search artifact and directory paths with glob patterns",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Glob pattern (e.g., '/src/*', '/docs/**')",
+                        "name": "query",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of artifacts and of directories (default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "{\"status\":\"reviewed\"}",
+                        "description": "JSON object the user meta must contain",
+                        "name": "meta",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Keys the user meta must have, whatever their value",
+                        "name": "meta_key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "text/*",
+                        "description": "Exact MIME type, or a family such as text/*",
+                        "name": "mime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum size in bytes",
+                        "name": "min_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum size in bytes",
+                        "name": "max_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-01-01T00:00:00Z",
+                        "description": "Only artifacts updated after this RFC 3339 time",
+                        "name": "updated_after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/service.GlobEntries"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/artifact/grep": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Search through text-based artifact content using regex patterns, including the text extracted from PDF and Office documents. Artifacts can be filtered by user meta, MIME type, size and modification time.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artifact"
+                ],
+                "summary": "Search artifact content with regex",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Regex pattern to search for",
+                        "name": "query",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "{\"status\":\"reviewed\"}",
+                        "description": "JSON object the user meta must contain",
+                        "name": "meta",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Keys the user meta must have, whatever their value",
+                        "name": "meta_key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "text/*",
+                        "description": "Exact MIME type, or a family such as text/*",
+                        "name": "mime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum size in bytes",
+                        "name": "min_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum size in bytes",
+                        "name": "max_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-01-01T00:00:00Z",
+                        "description": "Only artifacts updated after this RFC 3339 time",
+                        "name": "updated_after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Artifact"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/artifact/grep/matches": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Search through text-based artifact content like ripgrep, including the text extracted from PDF and Office documents. Returns the matching lines with their line numbers and optional context for each file, the total number of matching lines, and the same result rendered as grep output (path:line:text for matches, path-line-text for context).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artifact"
+                ],
+                "summary": "Search artifact content lines with regex",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Regex pattern to search for",
+                        "name": "query",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Match case-insensitively",
+                        "name": "ignore_case",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only search files whose full path matches this glob (e.g., '/src/**/*.py')",
+                        "name": "glob",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Context lines before and after each match (max 50)",
+                        "name": "context",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Context lines before each match, overrides context (max 50)",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Context lines after each match, overrides context (max 50)",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum matching lines returned per file (default no limit)",
+                        "name": "max_count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of files (default 100, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "{\"status\":\"reviewed\"}",
+                        "description": "JSON object the user meta must contain",
+                        "name": "meta",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Keys the user meta must have, whatever their value",
+                        "name": "meta_key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "text/*",
+                        "description": "Exact MIME type, or a family such as text/*",
+                        "name": "mime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum size in bytes",
+                        "name": "min_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum size in bytes",
+                        "name": "max_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-01-01T00:00:00Z",
+                        "description": "Only artifacts updated after this RFC 3339 time",
+                        "name": "updated_after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/service.GrepArtifactMatchesOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/artifact/ls": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the artifacts in a directory, oldest first, with its subdirectories. Artifacts can be filtered by user meta, MIME type, size and modification time. Pages hold up to limit artifacts; pass next_cursor to get the next one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artifact"
+                ],
+                "summary": "List artifacts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Path filter (optional, defaults to root '/')",
+                        "name": "path",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of artifacts per page (default 1000, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "{\"status\":\"reviewed\"}",
+                        "description": "JSON object the user meta must contain",
+                        "name": "meta",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Keys the user meta must have, whatever their value",
+                        "name": "meta_key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "text/*",
+                        "description": "Exact MIME type, or a family such as text/*",
+                        "name": "mime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum size in bytes",
+                        "name": "min_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum size in bytes",
+                        "name": "max_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-01-01T00:00:00Z",
+                        "description": "Only artifacts updated after this RFC 3339 time",
+                        "name": "updated_after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handler.ListArtifactsResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                },
+                "x-code-samples": [
+                    {
+                        "label": "Python",
+                        "lang": "python",
+                        "source": "from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# List artifacts in a path\nresult = client.disks.list_artifacts(\n    disk_id='disk-uuid',\n    path='/documents/'\n)\nprint(f\"Found {len(result.artifacts)} artifacts\")\nfor artifact in result.artifacts:\n    print(f\"  - {artifact.path}{artifact.filename}\")\nprint(f\"Subdirectories: {', '.join(result.directories)}\")\n"
+                    },
+                    {
+                        "label": "JavaScript",
+                        "lang": "javascript",
+                        "source": "import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// List artifacts in a path\nconst result = await client.disks.listArtifacts('disk-uuid', {\n  path: '/documents/'\n});\nconsole.log(` + "`" + `Found ${result.artifacts.length} artifacts` + "`" + `);\nfor (const artifact of result.artifacts) {\n  console.log(` + "`" + `  - ${artifact.path}${artifact.filename}` + "`" + `);\n}\nconsole.log(` + "`" + `Subdirectories: ${result.directories.join(', ')}` + "`" + `);\n"
+                    }
+                ]
+            }
+        },
+        "/disk/{disk_id}/artifact/move": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move or rename a file, or a directory with everything below it. A destination ending with / keeps the filename. Versions move with their artifact.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artifact"
+                ],
+                "summary": "Move artifacts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Move artifacts request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.TransferArtifactsReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/service.TransferArtifactsOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/artifact/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Make a previous version the current content of an artifact. The replaced content is kept as a new version.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artifact"
+                ],
+                "summary": "Restore artifact version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Restore artifact version request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.RestoreArtifactVersionReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handler.UpdateArtifactResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/artifact/upload_from_sandbox": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upload a file from a sandbox environment to disk storage as an artifact",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artifact"
+                ],
+                "summary": "Upload file from sandbox to disk",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Upload from sandbox request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UploadFromSandboxReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Artifact"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                },
+                "x-code-samples": [
+                    {
+                        "label": "Python",
+                        "lang": "python",
+                        "source": "from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Upload file from sandbox to disk\nartifact = client.disks.artifacts.upload_from_sandbox(\n    disk_id='disk-uuid',\n    sandbox_id='sandbox-uuid',\n    sandbox_path='/home/user/',\n    sandbox_filename='output.txt',\n    file_path='/results/'\n)\nprint(f\"Created: {artifact.path}{artifact.filename}\")\n"
+                    },
+                    {
+                        "label": "JavaScript",
+                        "lang": "javascript",
+                        "source": "import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Upload file from sandbox to disk\nconst artifact = await client.disks.artifacts.uploadFromSandbox('disk-uuid', {\n  sandboxId: 'sandbox-uuid',\n  sandboxPath: '/home/user/',\n  sandboxFilename: 'output.txt',\n  filePath: '/results/'\n});\nconsole.log(` + "`" + `Created: ${artifact.path}${artifact.filename}` + "`" + `);\n"
+                    }
+                ]
+            }
+        },
+        "/disk/{disk_id}/artifact/upload_url": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Start a direct upload of a large file to storage. PUT the file to the returned upload_url with the returned headers, then call finalize with the upload_id to create the artifact. The file is not sent through the API, so it can be up to the configured maximum direct upload size (default: 5GB).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artifact"
+                ],
+                "summary": "Create artifact upload URL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create upload URL request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateUploadURLReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/service.CreateUploadURLOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "413": {
+                        "description": "File size exceeds maximum allowed size",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
+                        }
+                    },
+                    "507": {
+                        "description": "Storage quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/artifact/version": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get an artifact as it was at the given version. Optionally include a presigned URL for downloading and parsed file content.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artifact"
+                ],
+                "summary": "Get artifact version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "/documents/report.md",
+                        "description": "File path including filename",
+                        "name": "file_path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "example": 2,
+                        "description": "Version number",
+                        "name": "version",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "example": true,
+                        "description": "Whether to return public URL, default is true",
+                        "name": "with_public_url",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "example": true,
+                        "description": "Whether to return parsed file content, default is true",
+                        "name": "with_content",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 3600,
+                        "description": "Expire time in seconds for presigned URL (default: 3600)",
+                        "name": "expire",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 0,
+                        "description": "Number of content lines to skip (default: 0)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 200,
+                        "description": "Maximum number of content lines to return (default: all)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "example": false,
+                        "description": "Whether to prefix content lines with line numbers",
+                        "name": "line_numbers",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handler.GetArtifactResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/artifact/versions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the previous versions of an artifact, newest first. A version is kept each time the artifact is overwritten, its meta is updated or an older version is restored, up to the disk's version retention.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "artifact"
+                ],
+                "summary": "List artifact versions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "/documents/report.md",
+                        "description": "File path including filename",
+                        "name": "file_path",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handler.ListArtifactVersionsResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/changes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return the change log of a disk: every create, update, delete and move of its artifacts, in seq order. Pass the next_since of a response as since to get only the later changes. With wait, the request is held until a change happens or the wait is over, so clients can long-poll the log.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disk"
+                ],
+                "summary": "List disk changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "example": 0,
+                        "description": "Return the changes after this seq, 0 for the whole log",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of changes to return, default 100. Max 1000.",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 30,
+                        "description": "Seconds to wait for a change when there is none yet, default 0. Max 60.",
+                        "name": "wait",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/service.ListDiskChangesOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/clone": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new disk with a copy of the current artifacts of a disk, optionally for a user identifier. Stored files are shared, not copied. Versions and snapshots are not cloned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disk"
+                ],
+                "summary": "Clone disk",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "CloneDisk payload",
+                        "name": "payload",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CloneDiskReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Disk"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "507": {
+                        "description": "Storage quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the artifacts of a disk with those recorded in a snapshot. Artifacts created after the snapshot are deleted and the version history of the replaced artifacts is dropped. The snapshot is kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disk"
+                ],
+                "summary": "Restore disk snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "RestoreDiskSnapshot payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.RestoreDiskSnapshotReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.DiskSnapshot"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "507": {
+                        "description": "Storage quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/share": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the share links of a disk, newest first, including expired ones. Tokens are not returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disk"
+                ],
+                "summary": "List share links",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.DiskShare"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create an expiring, read-only link to a file or to a directory with everything below it. The token is only returned once. Anyone with the token can list and download the shared files through /share/{token} without a project token, until the link expires, reaches its download limit or is revoked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disk"
+                ],
+                "summary": "Create share link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "CreateShare payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateShareReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/service.CreateShareOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/share/{share_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a share link. Its token stops working immediately.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disk"
+                ],
+                "summary": "Revoke share link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Share ID",
+                        "name": "share_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/snapshot": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the snapshots of a disk, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disk"
+                ],
+                "summary": "List disk snapshots",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.DiskSnapshot"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Record the current artifacts of a disk so the disk can be restored to this state later. Stored files are shared, not copied.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disk"
+                ],
+                "summary": "Create disk snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "CreateDiskSnapshot payload",
+                        "name": "payload",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateDiskSnapshotReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.DiskSnapshot"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/disk/{disk_id}/snapshot/{snapshot_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a snapshot of a disk. Stored files only referenced by the snapshot are removed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disk"
+                ],
+                "summary": "Delete disk snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Disk ID",
+                        "name": "disk_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Snapshot ID",
+                        "name": "snapshot_id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/serializer.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/model.Artifact"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/serializer.Response"
                         }
                     }
-                },
-                "x-code-samples": [
-                    {
-                        "label": "Python",
-                        "lang": "python",
-                        "source": "from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Upload file from sandbox to disk\nartifact = client.disks.artifacts.upload_from_sandbox(\n    disk_id='disk-uuid',\n    sandbox_id='sandbox-uuid',\n    sandbox_path='/home/user/',\n    sandbox_filename='output.txt',\n    file_path='/results/'\n)\nprint(f\"Created: {artifact.path}{artifact.filename}\")\n"
-                    },
-                    {
-                        "label": "JavaScript",
-                        "lang": "javascript",
-                        "source": "import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Upload file from sandbox to disk\nconst artifact = await client.disks.artifacts.uploadFromSandbox('disk-uuid', {\n  sandboxId: 'sandbox-uuid',\n  sandboxPath: '/home/user/',\n  sandboxFilename: 'output.txt',\n  filePath: '/results/'\n});\nconsole.log(` + "`" + `Created: ${artifact.path}${artifact.filename}` + "`" + `);\n"
-                    }
-                ]
+                }
             }
         },
         "/sandbox": {
@@ -1960,7 +3854,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get messages from session. Default format is openai. Can convert to acontext (original), anthropic, or gemini format.",
+                "description": "Get messages from session. Default format is openai. Can convert to acontext (original), anthropic, gemini, or bedrock format.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1995,7 +3889,7 @@ const docTemplate = `{
                     {
                         "type": "string",
                         "example": "true",
-                        "description": "Whether to return asset public url, default is true",
+                        "description": "Whether to return asset public url, default is true. Only affects the acontext format; other formats always resolve media stored as assets.",
                         "name": "with_asset_public_url",
                         "in": "query"
                     },
@@ -2004,10 +3898,11 @@ const docTemplate = `{
                             "acontext",
                             "openai",
                             "anthropic",
-                            "gemini"
+                            "gemini",
+                            "bedrock"
                         ],
                         "type": "string",
-                        "description": "Format to convert messages to: acontext (original), openai (default), anthropic, gemini.",
+                        "description": "Format to convert messages to: acontext (original), openai (default), anthropic, gemini, bedrock.",
                         "name": "format",
                         "in": "query"
                     },
@@ -2031,6 +3926,20 @@ const docTemplate = `{
                         "description": "Message ID to pin editing strategies at. When provided, strategies are only applied to messages up to and including this message ID, keeping subsequent messages unchanged. This helps maintain prompt cache stability by preserving a stable prefix. The response will include edit_at_message_id indicating where strategies were applied.",
                         "name": "pin_editing_strategies_at_message",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "true",
+                        "description": "Whether to include the session system prompt, default is true. It is returned as the first message for openai, as system for anthropic and bedrock, as system_instruction for gemini, and as system_prompt for acontext.",
+                        "name": "with_system_prompt",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "false",
+                        "description": "Fail with 422 instead of dropping information the target format cannot carry, default is false. Without strict mode, dropped parts and fields are listed per message ID in conversion_warnings.",
+                        "name": "strict",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -2051,6 +3960,12 @@ const docTemplate = `{
                                 }
                             ]
                         }
+                    },
+                    "422": {
+                        "description": "Strict mode: the conversion would lose information",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
+                        }
                     }
                 },
                 "x-code-samples": [
@@ -2072,7 +3987,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Supports JSON and multipart/form-data. In multipart mode: the payload is a JSON string placed in a form field. The format parameter indicates the format of the input message (default: openai, same as GET). The blob field should be a complete message object: for openai, use OpenAI ChatCompletionMessageParam format (with role and content); for anthropic, use Anthropic MessageParam format (with role and content); for acontext (internal), use {role, parts} format.",
+                "description": "Supports JSON and multipart/form-data. In multipart mode: the payload is a JSON string placed in a form field. The format parameter indicates the format of the input message (default: openai, same as GET). The blob field should be a complete message object: for openai, use OpenAI ChatCompletionMessageParam format (with role and content); for anthropic, use Anthropic MessageParam format (with role and content); for bedrock, use Bedrock Converse Message format (with role and content); for acontext (internal), use {role, parts} format. OpenAI system and developer messages are not stored as messages: they set a new version of the session system prompt and the response data is the stored system prompt.",
                 "consumes": [
                     "application/json",
                     "multipart/form-data"
@@ -2211,6 +4126,173 @@ const docTemplate = `{
                 ]
             }
         },
+        "/session/{session_id}/system_prompt": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the active session system prompt, or a specific version of it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Get session system prompt",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Version to return. Defaults to the active (latest) version.",
+                        "name": "version",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.SessionSystemPrompt"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Store a new version of the session system prompt. Earlier versions are kept and the new version becomes active. If append_space_use_when is true, the use_when conditions of the SOPs in the connected space are appended when the prompt is rendered.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "Set session system prompt",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "SetSystemPrompt payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.SetSystemPromptReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.SessionSystemPrompt"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/session/{session_id}/system_prompt/versions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List all versions of the session system prompt, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "session"
+                ],
+                "summary": "List session system prompt versions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.SessionSystemPrompt"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
         "/session/{session_id}/task": {
             "get": {
                 "security": [
@@ -2307,17 +4389,80 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "session"
+                    "session"
+                ],
+                "summary": "Get token counts for session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Session ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handler.TokenCountsResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                },
+                "x-code-samples": [
+                    {
+                        "label": "Python",
+                        "lang": "python",
+                        "source": "from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Get token counts\nresult = client.sessions.get_token_counts(session_id='session-uuid')\nprint(f\"Total tokens: {result.total_tokens}\")\n"
+                    },
+                    {
+                        "label": "JavaScript",
+                        "lang": "javascript",
+                        "source": "import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Get token counts\nconst result = await client.sessions.getTokenCounts('session-uuid');\nconsole.log(` + "`" + `Total tokens: ${result.total_tokens}` + "`" + `);\n"
+                    }
+                ]
+            }
+        },
+        "/share/{token}": {
+            "get": {
+                "description": "List the files and subdirectories of a shared directory, or the shared file. Paths are relative to the share, whose root is /. No project token is needed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "share"
                 ],
-                "summary": "Get token counts for session",
+                "summary": "Browse share link",
                 "parameters": [
                     {
                         "type": "string",
-                        "format": "uuid",
-                        "description": "Session ID",
-                        "name": "session_id",
+                        "description": "Share token",
+                        "name": "token",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "/drafts/",
+                        "description": "Directory relative to the share, defaults to its root",
+                        "name": "path",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -2332,26 +4477,68 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/handler.TokenCountsResp"
+                                            "$ref": "#/definitions/service.SharedListing"
                                         }
                                     }
                                 }
                             ]
                         }
+                    },
+                    "404": {
+                        "description": "Share or directory not found",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
+                        }
+                    },
+                    "410": {
+                        "description": "Share expired",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
+                        }
                     }
-                },
-                "x-code-samples": [
+                }
+            }
+        },
+        "/share/{token}/file": {
+            "get": {
+                "description": "Redirect to a short-lived download URL of a shared file. Each download counts toward the download limit of the share. No project token is needed.",
+                "tags": [
+                    "share"
+                ],
+                "summary": "Download shared file",
+                "parameters": [
                     {
-                        "label": "Python",
-                        "lang": "python",
-                        "source": "from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Get token counts\nresult = client.sessions.get_token_counts(session_id='session-uuid')\nprint(f\"Total tokens: {result.total_tokens}\")\n"
+                        "type": "string",
+                        "description": "Share token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "label": "JavaScript",
-                        "lang": "javascript",
-                        "source": "import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Get token counts\nconst result = await client.sessions.getTokenCounts('session-uuid');\nconsole.log(` + "`" + `Total tokens: ${result.total_tokens}` + "`" + `);\n"
+                        "type": "string",
+                        "example": "/drafts/notes.md",
+                        "description": "File path relative to the share, may be omitted for a shared file",
+                        "name": "file_path",
+                        "in": "query"
                     }
-                ]
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    },
+                    "404": {
+                        "description": "Share or file not found",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
+                        }
+                    },
+                    "410": {
+                        "description": "Share expired or download limit reached",
+                        "schema": {
+                            "$ref": "#/definitions/serializer.Response"
+                        }
+                    }
+                }
             }
         },
         "/space": {
@@ -3306,17 +5493,244 @@ const docTemplate = `{
                         "lang": "javascript",
                         "source": "import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Confirm experience and save data\nconst confirmation = await client.spaces.confirmExperience('space-uuid', 'experience-uuid', {\n  save: true\n});\nconsole.log(` + "`" + `Saved confirmation: ${JSON.stringify(confirmation.experience_data)}` + "`" + `);\n\n// Confirm experience without saving (just delete)\nawait client.spaces.confirmExperience('space-uuid', 'experience-uuid', {\n  save: false\n});\n"
                     }
-                ]
+                ]
+            }
+        },
+        "/space/{space_id}/experience_search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the experience search results for a given query within a space by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "space"
+                ],
+                "summary": "Get experience search",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "example": "123e4567-e89b-12d3-a456-426614174000",
+                        "description": "Space ID",
+                        "name": "space_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search query for page/folder titles",
+                        "name": "query",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results to return (1-50, default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search mode: fast or agentic (default fast)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "format": "float64",
+                        "description": "Cosine distance threshold (0=identical, 2=opposite)",
+                        "name": "semantic_threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of iterations for agentic search (1-100, default 16)",
+                        "name": "max_iterations",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/httpclient.SpaceSearchResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                },
+                "x-code-samples": [
+                    {
+                        "label": "Python",
+                        "lang": "python",
+                        "source": "from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Experience search\nresult = client.spaces.experience_search(\n    space_id='space-uuid',\n    query='How to implement authentication?',\n    limit=10,\n    mode='agentic',\n    max_iterations=20\n)\nfor block in result.cited_blocks:\n    print(f\"{block.title} (distance: {block.distance})\")\n"
+                    },
+                    {
+                        "label": "JavaScript",
+                        "lang": "javascript",
+                        "source": "import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Experience search\nconst result = await client.spaces.experienceSearch('space-uuid', {\n  query: 'How to implement authentication?',\n  limit: 10,\n  mode: 'agentic',\n  maxIterations: 20\n});\nfor (const block of result.cited_blocks) {\n  console.log(` + "`" + `${block.title} (distance: ${block.distance})` + "`" + `);\n}\n"
+                    }
+                ]
+            }
+        },
+        "/storage/quota": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the storage quotas set on the project, its users and its disks",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "storage"
+                ],
+                "summary": "List storage quotas",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.StorageQuota"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set the maximum bytes and files stored by the project, a user or a disk. A missing limit is unlimited, and a quota without limits is removed. The user or disk must exist. Writes that would exceed a quota fail with 507.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "storage"
+                ],
+                "summary": "Set storage quota",
+                "parameters": [
+                    {
+                        "description": "SetStorageQuota payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.SetStorageQuotaReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.StorageQuota"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/storage/usage": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the files, raw bytes and deduplicated bytes stored by the project, per disk and per user, along with the storage quotas. Raw bytes count every file, deduplicated bytes count identical contents once. Previous versions of artifacts and disk snapshots keep their contents stored: they add to the deduplicated bytes, but not to the files and raw bytes that quotas limit.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "storage"
+                ],
+                "summary": "Get storage usage",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/service.StorageUsageOutput"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
             }
         },
-        "/space/{space_id}/experience_search": {
-            "get": {
+        "/tool/convert": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve the experience search results for a given query within a space by its ID",
+                "description": "Convert tool definitions from one provider format to another (acontext, openai, anthropic, gemini, bedrock). JSON schemas are adapted to the target: Gemini gets its OpenAPI subset ($ref inlined, type arrays become nullable, unsupported keywords dropped) and strict=true rewrites the schemas for OpenAI strict mode. Every adaptation is listed in warnings.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3324,50 +5738,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "space"
+                    "tool"
                 ],
-                "summary": "Get experience search",
+                "summary": "Convert tool definitions",
                 "parameters": [
                     {
-                        "type": "string",
-                        "format": "uuid",
-                        "example": "123e4567-e89b-12d3-a456-426614174000",
-                        "description": "Space ID",
-                        "name": "space_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Search query for page/folder titles",
-                        "name": "query",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Maximum number of results to return (1-50, default 10)",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Search mode: fast or agentic (default fast)",
-                        "name": "mode",
-                        "in": "query"
-                    },
-                    {
-                        "type": "number",
-                        "format": "float64",
-                        "description": "Cosine distance threshold (0=identical, 2=opposite)",
-                        "name": "semantic_threshold",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Maximum number of iterations for agentic search (1-100, default 16)",
-                        "name": "max_iterations",
-                        "in": "query"
+                        "description": "Tool conversion request",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ConvertToolsReq"
+                        }
                     }
                 ],
                 "responses": {
@@ -3382,26 +5764,14 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/httpclient.SpaceSearchResult"
+                                            "$ref": "#/definitions/toolschema.Output"
                                         }
                                     }
                                 }
                             ]
                         }
                     }
-                },
-                "x-code-samples": [
-                    {
-                        "label": "Python",
-                        "lang": "python",
-                        "source": "from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Experience search\nresult = client.spaces.experience_search(\n    space_id='space-uuid',\n    query='How to implement authentication?',\n    limit=10,\n    mode='agentic',\n    max_iterations=20\n)\nfor block in result.cited_blocks:\n    print(f\"{block.title} (distance: {block.distance})\")\n"
-                    },
-                    {
-                        "label": "JavaScript",
-                        "lang": "javascript",
-                        "source": "import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Experience search\nconst result = await client.spaces.experienceSearch('space-uuid', {\n  query: 'How to implement authentication?',\n  limit: 10,\n  mode: 'agentic',\n  maxIterations: 20\n});\nfor (const block of result.cited_blocks) {\n  console.log(` + "`" + `${block.title} (distance: ${block.distance})` + "`" + `);\n}\n"
-                    }
-                ]
+                }
             }
         },
         "/tool/name": {
@@ -3520,6 +5890,67 @@ const docTemplate = `{
                 ]
             }
         },
+        "/tool/schemas": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the tools registered in the project as tool definitions in the given provider format",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tool"
+                ],
+                "summary": "Get tool schemas",
+                "parameters": [
+                    {
+                        "enum": [
+                            "acontext",
+                            "openai",
+                            "anthropic",
+                            "gemini",
+                            "bedrock"
+                        ],
+                        "type": "string",
+                        "description": "Output format: acontext, openai, anthropic, gemini or bedrock (default: openai)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Rewrite the schemas for OpenAI strict mode (default: false)",
+                        "name": "strict",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/serializer.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/toolschema.Output"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
         "/user/ls": {
             "get": {
                 "security": [
@@ -3708,16 +6139,58 @@ const docTemplate = `{
         "fileparser.FileContent": {
             "type": "object",
             "properties": {
+                "has_more": {
+                    "description": "Whether lines remain after the window",
+                    "type": "boolean"
+                },
+                "line_count": {
+                    "description": "Number of lines in Raw when a line window is read",
+                    "type": "integer"
+                },
+                "offset": {
+                    "description": "Lines skipped before Raw when a line window is read",
+                    "type": "integer"
+                },
                 "raw": {
                     "description": "Raw text content",
                     "type": "string"
                 },
+                "total_lines": {
+                    "description": "Number of lines in the whole file",
+                    "type": "integer"
+                },
                 "type": {
-                    "description": "\"text\", \"json\", \"csv\", \"code\"",
+                    "description": "\"text\", \"json\", \"csv\", \"code\", or \"pdf\", \"docx\", \"pptx\", \"xlsx\" for extracted text",
+                    "type": "string"
+                }
+            }
+        },
+        "grep.Line": {
+            "type": "object",
+            "properties": {
+                "line_number": {
+                    "description": "1-based line number",
+                    "type": "integer"
+                },
+                "match": {
+                    "description": "False for context lines",
+                    "type": "boolean"
+                },
+                "text": {
+                    "description": "Line without its line ending",
                     "type": "string"
                 }
             }
         },
+        "handler.CloneDiskReq": {
+            "type": "object",
+            "properties": {
+                "user": {
+                    "type": "string",
+                    "example": "alice@acontext.io"
+                }
+            }
+        },
         "handler.ConfirmExperienceReq": {
             "type": "object",
             "required": [
@@ -3742,6 +6215,35 @@ const docTemplate = `{
                 }
             }
         },
+        "handler.ConvertToolsReq": {
+            "type": "object",
+            "required": [
+                "from",
+                "to",
+                "tools"
+            ],
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "openai"
+                },
+                "strict": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "to": {
+                    "type": "string",
+                    "example": "gemini"
+                },
+                "tools": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "object"
+                    }
+                }
+            }
+        },
         "handler.CreateBlockReq": {
             "type": "object",
             "required": [
@@ -3773,6 +6275,16 @@ const docTemplate = `{
                 }
             }
         },
+        "handler.CreateDiskSnapshotReq": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "before-run-42"
+                }
+            }
+        },
         "handler.CreateSessionReq": {
             "type": "object",
             "properties": {
@@ -3795,6 +6307,40 @@ const docTemplate = `{
                 }
             }
         },
+        "handler.CreateShareReq": {
+            "type": "object",
+            "required": [
+                "file_path"
+            ],
+            "properties": {
+                "expire": {
+                    "description": "Expire time in seconds (default: 1 day, max: 30 days)",
+                    "type": "integer",
+                    "maximum": 2592000,
+                    "minimum": 60,
+                    "example": 86400
+                },
+                "file_path": {
+                    "description": "File path, or a directory ending with /",
+                    "type": "string",
+                    "example": "/reports/"
+                },
+                "max_downloads": {
+                    "description": "Maximum number of file downloads through the link, unlimited when omitted",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 10
+                },
+                "scope": {
+                    "description": "Access granted by the link, only read is supported",
+                    "type": "string",
+                    "enum": [
+                        "read"
+                    ],
+                    "example": "read"
+                }
+            }
+        },
         "handler.CreateSpaceReq": {
             "type": "object",
             "properties": {
@@ -3808,6 +6354,42 @@ const docTemplate = `{
                 }
             }
         },
+        "handler.CreateUploadURLReq": {
+            "type": "object",
+            "required": [
+                "file_path",
+                "sha256"
+            ],
+            "properties": {
+                "expire": {
+                    "description": "Expire time in seconds for the upload URL, defaults to the configured presign expiry",
+                    "type": "integer",
+                    "maximum": 604800,
+                    "minimum": 60,
+                    "example": 900
+                },
+                "file_path": {
+                    "description": "File path including filename",
+                    "type": "string",
+                    "example": "/datasets/train.parquet"
+                },
+                "meta": {
+                    "description": "Custom metadata as JSON string",
+                    "type": "string"
+                },
+                "sha256": {
+                    "description": "Hex SHA256 of the file",
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "size": {
+                    "description": "Size of the file in bytes",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1073741824
+                }
+            }
+        },
         "handler.DownloadToSandboxReq": {
             "type": "object",
             "required": [
@@ -3843,6 +6425,69 @@ const docTemplate = `{
                 }
             }
         },
+        "handler.EditArtifactReq": {
+            "type": "object",
+            "required": [
+                "command",
+                "file_path"
+            ],
+            "properties": {
+                "command": {
+                    "description": "Edit to apply",
+                    "type": "string",
+                    "enum": [
+                        "str_replace",
+                        "insert_at_line",
+                        "delete_lines",
+                        "apply_patch"
+                    ],
+                    "example": "str_replace"
+                },
+                "end_line": {
+                    "description": "delete_lines: last line to delete, inclusive",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 5
+                },
+                "file_path": {
+                    "description": "File path including filename",
+                    "type": "string",
+                    "example": "/src/main.go"
+                },
+                "insert_line": {
+                    "description": "insert_at_line: line to insert after, 0 for the beginning",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 10
+                },
+                "new_str": {
+                    "description": "str_replace: replacement text",
+                    "type": "string",
+                    "example": "fmt.Println(\"hello\")"
+                },
+                "old_str": {
+                    "description": "str_replace: text to replace, must occur exactly once",
+                    "type": "string",
+                    "example": "fmt.Println(\"hi\")"
+                },
+                "patch": {
+                    "description": "apply_patch: unified diff of the file",
+                    "type": "string",
+                    "example": "@@ -1,1 +1,1 @@\n-a\n+b\n"
+                },
+                "start_line": {
+                    "description": "delete_lines: first line to delete, 1-based",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 3
+                },
+                "text": {
+                    "description": "insert_at_line: text to insert",
+                    "type": "string",
+                    "example": "// TODO"
+                }
+            }
+        },
         "handler.ExecCommandReq": {
             "type": "object",
             "required": [
@@ -3854,6 +6499,19 @@ const docTemplate = `{
                 }
             }
         },
+        "handler.FinalizeUploadReq": {
+            "type": "object",
+            "required": [
+                "upload_id"
+            ],
+            "properties": {
+                "upload_id": {
+                    "description": "Upload ID returned by upload_url",
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
         "handler.GetArtifactResp": {
             "type": "object",
             "properties": {
@@ -3863,8 +6521,22 @@ const docTemplate = `{
                 "content": {
                     "$ref": "#/definitions/fileparser.FileContent"
                 },
-                "public_url": {
-                    "type": "string"
+                "public_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.ListArtifactVersionsResp": {
+            "type": "object",
+            "properties": {
+                "current_version": {
+                    "type": "integer"
+                },
+                "versions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ArtifactVersion"
+                    }
                 }
             }
         },
@@ -3878,10 +6550,17 @@ const docTemplate = `{
                     }
                 },
                 "directories": {
+                    "description": "Only returned with the first page",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                },
+                "has_more": {
+                    "type": "boolean"
+                },
+                "next_cursor": {
+                    "type": "string"
                 }
             }
         },
@@ -3911,6 +6590,86 @@ const docTemplate = `{
                 }
             }
         },
+        "handler.RestoreArtifactVersionReq": {
+            "type": "object",
+            "required": [
+                "file_path",
+                "version"
+            ],
+            "properties": {
+                "file_path": {
+                    "description": "File path including filename",
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 2
+                }
+            }
+        },
+        "handler.RestoreDiskSnapshotReq": {
+            "type": "object",
+            "required": [
+                "snapshot_id"
+            ],
+            "properties": {
+                "snapshot_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "handler.SetStorageQuotaReq": {
+            "type": "object",
+            "required": [
+                "scope"
+            ],
+            "properties": {
+                "disk_id": {
+                    "description": "Required for the disk scope",
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "max_bytes": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1073741824
+                },
+                "max_files": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 10000
+                },
+                "scope": {
+                    "type": "string",
+                    "enum": [
+                        "project",
+                        "user",
+                        "disk"
+                    ],
+                    "example": "user"
+                },
+                "user": {
+                    "description": "Required for the user scope",
+                    "type": "string",
+                    "example": "alice@acontext.io"
+                }
+            }
+        },
+        "handler.SetSystemPromptReq": {
+            "type": "object",
+            "properties": {
+                "append_space_use_when": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "content": {
+                    "type": "string",
+                    "example": "You are a helpful assistant."
+                }
+            }
+        },
         "handler.StoreMessageReq": {
             "type": "object",
             "required": [
@@ -3924,7 +6683,8 @@ const docTemplate = `{
                         "acontext",
                         "openai",
                         "anthropic",
-                        "gemini"
+                        "gemini",
+                        "bedrock"
                     ],
                     "example": "openai"
                 }
@@ -3953,6 +6713,35 @@ const docTemplate = `{
                 }
             }
         },
+        "handler.TransferArtifactsReq": {
+            "type": "object",
+            "required": [
+                "destination",
+                "source"
+            ],
+            "properties": {
+                "destination": {
+                    "description": "File path, or a directory ending with /",
+                    "type": "string",
+                    "example": "/archive/"
+                },
+                "on_conflict": {
+                    "description": "What to do when the destination exists, defaults to error",
+                    "type": "string",
+                    "enum": [
+                        "error",
+                        "skip",
+                        "overwrite"
+                    ],
+                    "example": "error"
+                },
+                "source": {
+                    "description": "File path, or a directory ending with /",
+                    "type": "string",
+                    "example": "/docs/report.md"
+                }
+            }
+        },
         "handler.UpdateArtifactReq": {
             "type": "object",
             "required": [
@@ -3998,6 +6787,20 @@ const docTemplate = `{
                 }
             }
         },
+        "handler.UpdateDiskReq": {
+            "type": "object",
+            "required": [
+                "version_retention"
+            ],
+            "properties": {
+                "version_retention": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 0,
+                    "example": 10
+                }
+            }
+        },
         "handler.UpdateSessionConfigsReq": {
             "type": "object",
             "properties": {
@@ -4202,6 +7005,67 @@ const docTemplate = `{
                 "path": {
                     "type": "string"
                 },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version of the current content; previous versions are kept as ArtifactVersion rows",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ArtifactVersion": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "CreatedAt is when this content was replaced",
+                    "type": "string"
+                },
+                "disk_id": {
+                    "type": "string"
+                },
+                "meta": {
+                    "type": "object"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.AssetReference": {
+            "type": "object",
+            "properties": {
+                "asset_meta": {
+                    "description": "Full asset metadata stored as JSON",
+                    "type": "object"
+                },
+                "created_at": {
+                    "description": "Timestamps",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_referenced_at": {
+                    "description": "Optional: Last referenced timestamp to help with garbage collection",
+                    "type": "string"
+                },
+                "project_id": {
+                    "description": "Project ID for multi-tenant isolation\nAssets are isolated per project for security and access control",
+                    "type": "string"
+                },
+                "ref_count": {
+                    "description": "Reference count - how many messages/entities reference this asset within this project",
+                    "type": "integer"
+                },
+                "s3_key": {
+                    "description": "Canonical S3 key - the first uploaded location or preferred location\nWhen same content is uploaded multiple times within a project, we keep only one copy\nFormat: assets/{project_id}/{sha256}.ext",
+                    "type": "string"
+                },
+                "sha256": {
+                    "description": "SHA256 hash as unique identifier for content-based deduplication\nCombined with ProjectID as composite unique key",
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
                 }
@@ -4245,6 +7109,10 @@ const docTemplate = `{
         "model.Disk": {
             "type": "object",
             "properties": {
+                "change_seq": {
+                    "description": "ChangeSeq is the seq of the last entry in the change log of the disk",
+                    "type": "integer"
+                },
                 "created_at": {
                     "type": "string"
                 },
@@ -4259,6 +7127,105 @@ const docTemplate = `{
                 },
                 "user_id": {
                     "type": "string"
+                },
+                "version_retention": {
+                    "description": "VersionRetention is how many previous versions are kept per artifact; 0 disables versioning",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.DiskChange": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "disk_id": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "from_filename": {
+                    "type": "string"
+                },
+                "from_path": {
+                    "description": "FromPath and FromFilename are where a moved artifact came from",
+                    "type": "string"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Path and Filename are where the artifact is after the change, or where it was for a delete",
+                    "type": "string"
+                },
+                "seq": {
+                    "type": "integer"
+                },
+                "sha256": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version and SHA256 identify the content after the change; they are empty for a delete",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.DiskShare": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "disk_id": {
+                    "type": "string"
+                },
+                "downloads": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_downloads": {
+                    "description": "MaxDownloads limits how many files can be downloaded through the link; nil means unlimited",
+                    "type": "integer"
+                },
+                "path": {
+                    "description": "Path is the shared directory, or the directory of the shared file when Filename is set",
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DiskSnapshot": {
+            "type": "object",
+            "properties": {
+                "artifact_count": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "disk_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "size_b": {
+                    "type": "integer"
                 }
             }
         },
@@ -4407,6 +7374,30 @@ const docTemplate = `{
                 }
             }
         },
+        "model.SessionSystemPrompt": {
+            "type": "object",
+            "properties": {
+                "append_space_use_when": {
+                    "description": "AppendSpaceUseWhen appends the use_when conditions of the SOPs in the session's space to the prompt",
+                    "type": "boolean"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
         "model.Space": {
             "type": "object",
             "properties": {
@@ -4430,6 +7421,29 @@ const docTemplate = `{
                 }
             }
         },
+        "model.StorageQuota": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "max_bytes": {
+                    "type": "integer"
+                },
+                "max_files": {
+                    "type": "integer"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "scope_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
         "model.Task": {
             "type": "object",
             "properties": {
@@ -4503,8 +7517,24 @@ const docTemplate = `{
                 "project_id": {
                     "type": "string"
                 },
-                "updated_at": {
-                    "type": "string"
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "repo.StorageUsage": {
+            "type": "object",
+            "properties": {
+                "dedup_bytes": {
+                    "description": "Sum of the sizes of distinct contents, as stored in S3, including previous versions and snapshots",
+                    "type": "integer"
+                },
+                "files": {
+                    "type": "integer"
+                },
+                "raw_bytes": {
+                    "description": "Sum of the sizes of all files",
+                    "type": "integer"
                 }
             }
         },
@@ -4566,6 +7596,140 @@ const docTemplate = `{
                 }
             }
         },
+        "service.ArtifactDiff": {
+            "type": "object",
+            "properties": {
+                "diff": {
+                    "description": "empty if both versions have the same content",
+                    "type": "string"
+                },
+                "from_version": {
+                    "type": "integer"
+                },
+                "to_version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.AssetGCReport": {
+            "type": "object",
+            "properties": {
+                "assets": {
+                    "description": "Assets lists what a dry run would collect",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AssetReference"
+                    }
+                },
+                "before": {
+                    "description": "Assets without references since before this time are collected",
+                    "type": "string"
+                },
+                "bytes": {
+                    "description": "Size of these objects",
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "description": "Objects that could not be deleted; they are tried again on the next run",
+                    "type": "integer"
+                },
+                "has_more": {
+                    "type": "boolean"
+                },
+                "objects": {
+                    "description": "S3 objects deleted, or that would be deleted in a dry run",
+                    "type": "integer"
+                }
+            }
+        },
+        "service.CreateShareOutput": {
+            "type": "object",
+            "properties": {
+                "share": {
+                    "$ref": "#/definitions/model.DiskShare"
+                },
+                "token": {
+                    "description": "Only returned once; the share stores an HMAC of it",
+                    "type": "string"
+                }
+            }
+        },
+        "service.CreateUploadURLOutput": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "headers": {
+                    "description": "Headers the upload request must be sent with",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "method": {
+                    "type": "string",
+                    "example": "PUT"
+                },
+                "upload_id": {
+                    "type": "string"
+                },
+                "upload_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.DiskStorageUsage": {
+            "type": "object",
+            "properties": {
+                "dedup_bytes": {
+                    "description": "Sum of the sizes of distinct contents, as stored in S3, including previous versions and snapshots",
+                    "type": "integer"
+                },
+                "disk_id": {
+                    "type": "string"
+                },
+                "files": {
+                    "type": "integer"
+                },
+                "raw_bytes": {
+                    "description": "Sum of the sizes of all files",
+                    "type": "integer"
+                }
+            }
+        },
+        "service.EditArtifactOutput": {
+            "type": "object",
+            "properties": {
+                "artifact": {
+                    "$ref": "#/definitions/model.Artifact"
+                },
+                "end_line": {
+                    "type": "integer"
+                },
+                "snippet": {
+                    "description": "Changed lines with some context, prefixed with line numbers",
+                    "type": "string"
+                },
+                "start_line": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.ExtractArchiveOutput": {
+            "type": "object",
+            "properties": {
+                "artifacts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Artifact"
+                    }
+                }
+            }
+        },
         "service.GetFileOutput": {
             "type": "object",
             "properties": {
@@ -4613,6 +7777,9 @@ const docTemplate = `{
                     "additionalProperties": {
                         "$ref": "#/definitions/service.PublicURL"
                     }
+                },
+                "system_prompt": {
+                    "type": "string"
                 }
             }
         },
@@ -4658,6 +7825,63 @@ const docTemplate = `{
                 }
             }
         },
+        "service.GlobEntries": {
+            "type": "object",
+            "properties": {
+                "artifacts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Artifact"
+                    }
+                },
+                "directories": {
+                    "description": "Full directory paths ending with /",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "service.GrepArtifactMatches": {
+            "type": "object",
+            "properties": {
+                "filename": {
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/grep.Line"
+                    }
+                },
+                "match_count": {
+                    "description": "Matching lines in the file, including those past max_count",
+                    "type": "integer"
+                },
+                "path": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.GrepArtifactMatchesOutput": {
+            "type": "object",
+            "properties": {
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.GrepArtifactMatches"
+                    }
+                },
+                "output": {
+                    "description": "Matches rendered like ripgrep, ready to hand to an agent",
+                    "type": "string"
+                },
+                "total_matches": {
+                    "type": "integer"
+                }
+            }
+        },
         "service.ListAgentSkillsOutput": {
             "type": "object",
             "properties": {
@@ -4675,6 +7899,28 @@ const docTemplate = `{
                 }
             }
         },
+        "service.ListDiskChangesOutput": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DiskChange"
+                    }
+                },
+                "latest_seq": {
+                    "description": "Seq of the last change of the disk",
+                    "type": "integer"
+                },
+                "next_since": {
+                    "description": "Seq to pass as since for the next call",
+                    "type": "integer"
+                }
+            }
+        },
         "service.ListDisksOutput": {
             "type": "object",
             "properties": {
@@ -4770,6 +8016,135 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "service.SharedFile": {
+            "type": "object",
+            "properties": {
+                "filename": {
+                    "type": "string"
+                },
+                "mime": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "size_b": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.SharedListing": {
+            "type": "object",
+            "properties": {
+                "directories": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.SharedFile"
+                    }
+                },
+                "path": {
+                    "type": "string"
+                },
+                "remaining_downloads": {
+                    "description": "nil for unlimited",
+                    "type": "integer"
+                }
+            }
+        },
+        "service.StorageUsageOutput": {
+            "type": "object",
+            "properties": {
+                "disks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.DiskStorageUsage"
+                    }
+                },
+                "project": {
+                    "$ref": "#/definitions/repo.StorageUsage"
+                },
+                "quotas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.StorageQuota"
+                    }
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.UserStorageUsage"
+                    }
+                }
+            }
+        },
+        "service.TransferArtifactsOutput": {
+            "type": "object",
+            "properties": {
+                "artifacts": {
+                    "description": "Artifacts at their destination",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Artifact"
+                    }
+                },
+                "skipped": {
+                    "description": "Source paths left alone because the destination was taken",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "service.UserStorageUsage": {
+            "type": "object",
+            "properties": {
+                "dedup_bytes": {
+                    "description": "Sum of the sizes of distinct contents, as stored in S3, including previous versions and snapshots",
+                    "type": "integer"
+                },
+                "files": {
+                    "type": "integer"
+                },
+                "identifier": {
+                    "type": "string"
+                },
+                "raw_bytes": {
+                    "description": "Sum of the sizes of all files",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "toolschema.Output": {
+            "type": "object",
+            "properties": {
+                "tools": {
+                    "type": "array",
+                    "items": {}
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
         }
     },
     "securityDefinitions": {
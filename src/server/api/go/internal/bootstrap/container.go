@@ -53,11 +53,19 @@ func BuildContainer() *do.Injector {
 				&model.User{},
 				&model.Space{},
 				&model.Session{},
+				&model.SessionSystemPrompt{},
 				&model.Task{},
 				&model.Message{},
 				&model.Block{},
 				&model.Disk{},
 				&model.Artifact{},
+				&model.ArtifactVersion{},
+				&model.DiskSnapshot{},
+				&model.DiskSnapshotArtifact{},
+				&model.ArtifactUpload{},
+				&model.DiskShare{},
+				&model.DiskChange{},
+				&model.StorageQuota{},
 				&model.AssetReference{},
 				&model.ToolReference{},
 				&model.ToolSOP{},
@@ -124,10 +132,10 @@ func BuildContainer() *do.Injector {
 		return mq.NewPublisher(conn, log, cfg, dialFn)
 	})
 
-	// S3
-	do.Provide(inj, func(i *do.Injector) (*blob.S3Deps, error) {
+	// Blob store, S3 or the local filesystem
+	do.Provide(inj, func(i *do.Injector) (blob.BlobStore, error) {
 		cfg := do.MustInvoke[*config.Config](i)
-		return blob.NewS3(context.Background(), cfg)
+		return blob.New(context.Background(), cfg)
 	})
 	// get presign expire duration
 	do.Provide(inj, func(i *do.Injector) (func() time.Duration, error) {
@@ -151,7 +159,7 @@ func BuildContainer() *do.Injector {
 	do.Provide(inj, func(i *do.Injector) (repo.AssetReferenceRepo, error) {
 		return repo.NewAssetReferenceRepo(
 			do.MustInvoke[*gorm.DB](i),
-			do.MustInvoke[*blob.S3Deps](i),
+			do.MustInvoke[blob.BlobStore](i),
 		), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (repo.SpaceRepo, error) {
@@ -161,7 +169,7 @@ func BuildContainer() *do.Injector {
 		return repo.NewSessionRepo(
 			do.MustInvoke[*gorm.DB](i),
 			do.MustInvoke[repo.AssetReferenceRepo](i),
-			do.MustInvoke[*blob.S3Deps](i),
+			do.MustInvoke[blob.BlobStore](i),
 			do.MustInvoke[*zap.Logger](i),
 		), nil
 	})
@@ -186,7 +194,7 @@ func BuildContainer() *do.Injector {
 	do.Provide(inj, func(i *do.Injector) (repo.AgentSkillsRepo, error) {
 		return repo.NewAgentSkillsRepo(
 			do.MustInvoke[*gorm.DB](i),
-			do.MustInvoke[*blob.S3Deps](i),
+			do.MustInvoke[blob.BlobStore](i),
 		), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (repo.UserRepo, error) {
@@ -195,6 +203,15 @@ func BuildContainer() *do.Injector {
 	do.Provide(inj, func(i *do.Injector) (repo.SandboxLogRepo, error) {
 		return repo.NewSandboxLogRepo(do.MustInvoke[*gorm.DB](i)), nil
 	})
+	do.Provide(inj, func(i *do.Injector) (repo.ToolReferenceRepo, error) {
+		return repo.NewToolReferenceRepo(do.MustInvoke[*gorm.DB](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (repo.ShareRepo, error) {
+		return repo.NewShareRepo(do.MustInvoke[*gorm.DB](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (repo.StorageRepo, error) {
+		return repo.NewStorageRepo(do.MustInvoke[*gorm.DB](i)), nil
+	})
 
 	// Service
 	do.Provide(inj, func(i *do.Injector) (service.SpaceService, error) {
@@ -209,8 +226,9 @@ func BuildContainer() *do.Injector {
 		return service.NewSessionService(
 			do.MustInvoke[repo.SessionRepo](i),
 			do.MustInvoke[repo.AssetReferenceRepo](i),
+			do.MustInvoke[repo.BlockRepo](i),
 			do.MustInvoke[*zap.Logger](i),
-			do.MustInvoke[*blob.S3Deps](i),
+			do.MustInvoke[blob.BlobStore](i),
 			do.MustInvoke[*mq.Publisher](i),
 			do.MustInvoke[*config.Config](i),
 			do.MustInvoke[*redis.Client](i),
@@ -220,12 +238,14 @@ func BuildContainer() *do.Injector {
 		return service.NewBlockService(do.MustInvoke[repo.BlockRepo](i)), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (service.DiskService, error) {
-		return service.NewDiskService(do.MustInvoke[repo.DiskRepo](i)), nil
+		return service.NewDiskService(do.MustInvoke[repo.DiskRepo](i), do.MustInvoke[service.StorageService](i)), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (service.ArtifactService, error) {
 		return service.NewArtifactService(
 			do.MustInvoke[repo.ArtifactRepo](i),
-			do.MustInvoke[*blob.S3Deps](i),
+			do.MustInvoke[repo.AssetReferenceRepo](i),
+			do.MustInvoke[blob.BlobStore](i),
+			do.MustInvoke[service.StorageService](i),
 		), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (service.TaskService, error) {
@@ -237,7 +257,8 @@ func BuildContainer() *do.Injector {
 	do.Provide(inj, func(i *do.Injector) (service.AgentSkillsService, error) {
 		return service.NewAgentSkillsService(
 			do.MustInvoke[repo.AgentSkillsRepo](i),
-			do.MustInvoke[*blob.S3Deps](i),
+			do.MustInvoke[blob.BlobStore](i),
+			do.MustInvoke[service.StorageService](i),
 		), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (service.UserService, error) {
@@ -246,6 +267,31 @@ func BuildContainer() *do.Injector {
 	do.Provide(inj, func(i *do.Injector) (service.SandboxLogService, error) {
 		return service.NewSandboxLogService(do.MustInvoke[repo.SandboxLogRepo](i)), nil
 	})
+	do.Provide(inj, func(i *do.Injector) (service.ToolService, error) {
+		return service.NewToolService(do.MustInvoke[repo.ToolReferenceRepo](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (service.ShareService, error) {
+		return service.NewShareService(
+			do.MustInvoke[repo.ShareRepo](i),
+			do.MustInvoke[repo.ArtifactRepo](i),
+			do.MustInvoke[repo.StorageRepo](i),
+			do.MustInvoke[blob.BlobStore](i),
+			do.MustInvoke[*config.Config](i).Root.SecretPepper,
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (service.StorageService, error) {
+		return service.NewStorageService(do.MustInvoke[repo.StorageRepo](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (service.AssetGCService, error) {
+		return service.NewAssetGCService(
+			do.MustInvoke[repo.AssetReferenceRepo](i),
+			do.MustInvoke[repo.ArtifactRepo](i),
+			do.MustInvoke[blob.BlobStore](i),
+			do.MustInvoke[*redis.Client](i),
+			do.MustInvoke[*config.Config](i),
+			do.MustInvoke[*zap.Logger](i),
+		), nil
+	})
 
 	// Handler
 	do.Provide(inj, func(i *do.Injector) (*handler.SpaceHandler, error) {
@@ -279,14 +325,17 @@ func BuildContainer() *do.Injector {
 			do.MustInvoke[service.ArtifactService](i),
 			do.MustInvoke[*config.Config](i),
 			do.MustInvoke[*httpclient.CoreClient](i),
-			do.MustInvoke[*blob.S3Deps](i),
+			do.MustInvoke[blob.BlobStore](i),
 		), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (*handler.TaskHandler, error) {
 		return handler.NewTaskHandler(do.MustInvoke[service.TaskService](i)), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (*handler.ToolHandler, error) {
-		return handler.NewToolHandler(do.MustInvoke[*httpclient.CoreClient](i)), nil
+		return handler.NewToolHandler(
+			do.MustInvoke[service.ToolService](i),
+			do.MustInvoke[*httpclient.CoreClient](i),
+		), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (*handler.AgentSkillsHandler, error) {
 		return handler.NewAgentSkillsHandler(
@@ -297,6 +346,24 @@ func BuildContainer() *do.Injector {
 	do.Provide(inj, func(i *do.Injector) (*handler.UserHandler, error) {
 		return handler.NewUserHandler(do.MustInvoke[service.UserService](i)), nil
 	})
+	do.Provide(inj, func(i *do.Injector) (*handler.ShareHandler, error) {
+		return handler.NewShareHandler(do.MustInvoke[service.ShareService](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (*handler.AdminHandler, error) {
+		return handler.NewAdminHandler(do.MustInvoke[service.AssetGCService](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (*handler.BlobHandler, error) {
+		return handler.NewBlobHandler(
+			do.MustInvoke[blob.BlobStore](i),
+			do.MustInvoke[*config.Config](i),
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (*handler.StorageHandler, error) {
+		return handler.NewStorageHandler(
+			do.MustInvoke[service.StorageService](i),
+			do.MustInvoke[service.UserService](i),
+		), nil
+	})
 	do.Provide(inj, func(i *do.Injector) (*handler.SandboxHandler, error) {
 		return handler.NewSandboxHandler(
 			do.MustInvoke[*httpclient.CoreClient](i),
@@ -61,7 +61,10 @@ type MQCfg struct {
 }
 
 type S3Cfg struct {
-	Endpoint         string
+	Backend          string // "s3", or "local" to keep blobs on disk and serve them through the API
+	LocalDir         string // Directory of the local backend
+	LocalSecret      string // Key signing the URLs of the local backend, defaults to the root API token
+	Endpoint         string // Public URL of the API itself with the local backend
 	InternalEndpoint string
 	Region           string
 	AccessKey        string
@@ -83,7 +86,17 @@ type TelemetryCfg struct {
 }
 
 type ArtifactCfg struct {
-	MaxUploadSizeBytes int64 // Maximum file upload size in bytes
+	MaxUploadSizeBytes       int64 // Maximum file upload size in bytes
+	MaxExtractSizeBytes      int64 // Maximum total uncompressed size of an extracted archive in bytes
+	MaxExtractFiles          int   // Maximum number of files in an extracted archive
+	MaxDirectUploadSizeBytes int64 // Maximum size in bytes of a file uploaded directly to S3 through a presigned URL
+}
+
+type AssetGCCfg struct {
+	Enabled     bool
+	IntervalSec int // How often one API instance collects orphaned assets and expired direct uploads
+	GraceSec    int // How long an asset must have had no references before its S3 object is deleted; also how long an upload may take to reference an asset it reuses
+	BatchSize   int // Assets deleted per batch
 }
 
 type Config struct {
@@ -97,6 +110,7 @@ type Config struct {
 	Core      CoreCfg
 	Telemetry TelemetryCfg
 	Artifact  ArtifactCfg
+	AssetGC   AssetGCCfg
 }
 
 func setDefaults(v *viper.Viper) {
@@ -112,6 +126,8 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("redis.db", 0)
 	v.SetDefault("redis.poolSize", 10)
 	v.SetDefault("redis.enableTLS", false)
+	v.SetDefault("s3.backend", "s3")
+	v.SetDefault("s3.localDir", "./data/blobs")
 	v.SetDefault("s3.endpoint", "http://127.0.0.1:19000")
 	v.SetDefault("s3.internalEndpoint", "http://127.0.0.1:19000")
 	v.SetDefault("s3.region", "auto")
@@ -125,8 +141,15 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("core.baseURL", "http://127.0.0.1:8019")
 	v.SetDefault("telemetry.otlpEndpoint", "http://127.0.0.1:4317")
 	v.SetDefault("telemetry.enabled", true)
-	v.SetDefault("telemetry.sampleRatio", 1.0)            // Default 100% sampling
-	v.SetDefault("artifact.maxUploadSizeBytes", 16777216) // Default 16MB (16 * 1024 * 1024 bytes)
+	v.SetDefault("telemetry.sampleRatio", 1.0)              // Default 100% sampling
+	v.SetDefault("artifact.maxUploadSizeBytes", 16777216)   // Default 16MB (16 * 1024 * 1024 bytes)
+	v.SetDefault("artifact.maxExtractSizeBytes", 134217728) // Default 128MB (128 * 1024 * 1024 bytes)
+	v.SetDefault("artifact.maxExtractFiles", 1000)
+	v.SetDefault("artifact.maxDirectUploadSizeBytes", 5368709120) // Default 5GB, the largest object S3 copies in one request
+	v.SetDefault("assetGC.enabled", true)
+	v.SetDefault("assetGC.intervalSec", 3600) // Default 1 hour
+	v.SetDefault("assetGC.graceSec", 86400)   // Default 1 day
+	v.SetDefault("assetGC.batchSize", 500)
 }
 
 func Load() (*Config, error) {
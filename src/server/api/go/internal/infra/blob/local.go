@@ -0,0 +1,479 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/mime"
+)
+
+var (
+	// ErrInvalidSignature is returned for a blob URL that was not signed by this store
+	ErrInvalidSignature = errors.New("invalid blob signature")
+	// ErrSignatureExpired is returned for a signed blob URL past its expiry
+	ErrSignatureExpired = errors.New("blob signature expired")
+)
+
+// LocalBlobPath is where the API serves the signed URLs of a LocalStore
+const LocalBlobPath = "/api/v1/blob/"
+
+// localTmpDir holds files being written under the root, before they are renamed to their key
+const localTmpDir = ".tmp"
+
+// LocalStore keeps blobs as files under a directory, for single-node deployments and tests that
+// run without an object store. Presigned URLs point to the API, which checks their HMAC signature
+// and serves or stores the file. Object metadata is not kept.
+type LocalStore struct {
+	root    string
+	baseURL string
+	secret  []byte
+}
+
+func NewLocal(cfg *config.Config) (*LocalStore, error) {
+	if cfg.S3.LocalDir == "" {
+		return nil, errors.New("local blob dir is empty")
+	}
+	// The endpoint is the public URL of the API itself
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.S3.Endpoint), "/")
+	if baseURL == "" {
+		return nil, errors.New("local blob endpoint is empty")
+	}
+	secret := cfg.S3.LocalSecret
+	if secret == "" {
+		secret = cfg.Root.ApiBearerToken
+	}
+	if secret == "" {
+		return nil, errors.New("local blob secret is empty")
+	}
+
+	root, err := filepath.Abs(cfg.S3.LocalDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve local blob dir: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, localTmpDir), 0o755); err != nil {
+		return nil, fmt.Errorf("create local blob dir %s: %w", root, err)
+	}
+
+	return &LocalStore{root: root, baseURL: baseURL, secret: []byte(secret)}, nil
+}
+
+// path returns the file of key, refusing keys that would leave the root
+func (l *LocalStore) path(key string) (string, error) {
+	p := filepath.FromSlash(key)
+	if key == "" || !filepath.IsLocal(p) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	if first, _, _ := strings.Cut(key, "/"); first == localTmpDir {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(l.root, p), nil
+}
+
+// writeTemp writes body to a new temporary file, returning its path, SHA256 and size
+func (l *LocalStore) writeTemp(body io.Reader) (string, string, int64, error) {
+	f, err := os.CreateTemp(filepath.Join(l.root, localTmpDir), "blob-*")
+	if err != nil {
+		return "", "", 0, fmt.Errorf("create temporary blob: %w", err)
+	}
+	defer f.Close()
+
+	hr := newHashingReader(body)
+	if _, err := io.Copy(f, hr); err != nil {
+		_ = os.Remove(f.Name())
+		return "", "", 0, fmt.Errorf("write temporary blob: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", "", 0, fmt.Errorf("write temporary blob: %w", err)
+	}
+	return f.Name(), hr.sum(), hr.size, nil
+}
+
+// moveTo renames the file at src to key, replacing what was stored there
+func (l *LocalStore) moveTo(src string, key string) error {
+	dst, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("create blob dir: %w", err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return fmt.Errorf("store blob: %w", err)
+	}
+	return nil
+}
+
+// promote moves the file at src to the content-addressed key of sumHex under keyPrefix, or
+// drops it if reuse hands out a stored copy of the same content
+func (l *LocalStore) promote(ctx context.Context, src string, keyPrefix string, sumHex string, size int64, contentType string, ext string, reuse Reuse) (*model.Asset, error) {
+	asset, err := reused(ctx, reuse, sumHex, contentType, size)
+	if err != nil {
+		return nil, err
+	}
+	if asset != nil {
+		if err := os.Remove(src); err != nil {
+			return nil, fmt.Errorf("remove duplicate blob: %w", err)
+		}
+		return asset, nil
+	}
+
+	key := dedupKey(keyPrefix, sumHex, ext)
+	if err := l.moveTo(src, key); err != nil {
+		return nil, err
+	}
+	return &model.Asset{
+		S3Key:  key,
+		ETag:   sumHex,
+		SHA256: sumHex,
+		MIME:   contentType,
+		SizeB:  size,
+	}, nil
+}
+
+// upload stores body at its content-addressed key under keyPrefix with deduplication
+func (l *LocalStore) upload(ctx context.Context, keyPrefix string, body io.Reader, contentType string, ext string, reuse Reuse) (*model.Asset, error) {
+	tmp, sumHex, size, err := l.writeTemp(body)
+	if err != nil {
+		return nil, err
+	}
+	asset, err := l.promote(ctx, tmp, keyPrefix, sumHex, size, contentType, ext, reuse)
+	if err != nil {
+		_ = os.Remove(tmp)
+		return nil, err
+	}
+	return asset, nil
+}
+
+// UploadStream stores a file of any size with automatic deduplication
+func (l *LocalStore) UploadStream(ctx context.Context, keyPrefix string, filename string, body io.Reader, reuse Reuse) (*model.Asset, error) {
+	contentType, body, err := mime.DetectMimeTypeReader(body, filename)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	return l.upload(ctx, keyPrefix, body, contentType, strings.ToLower(filepath.Ext(filename)), reuse)
+}
+
+// UploadFormFile stores an uploaded file with automatic deduplication
+func (l *LocalStore) UploadFormFile(ctx context.Context, keyPrefix string, fh *multipart.FileHeader, reuse Reuse) (*model.Asset, error) {
+	file, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return l.UploadStream(ctx, keyPrefix, fh.Filename, file, reuse)
+}
+
+// UploadBytes stores raw bytes with automatic deduplication
+func (l *LocalStore) UploadBytes(ctx context.Context, keyPrefix string, filename string, content []byte, reuse Reuse) (*model.Asset, error) {
+	contentType := mime.DetectMimeType(content, filename)
+	return l.upload(ctx, keyPrefix, bytes.NewReader(content), contentType, strings.ToLower(filepath.Ext(filename)), reuse)
+}
+
+// UploadJSON stores data as JSON with automatic deduplication
+func (l *LocalStore) UploadJSON(ctx context.Context, keyPrefix string, data interface{}, reuse Reuse) (*model.Asset, error) {
+	jsonData, err := sonic.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("marshal json: %w", err)
+	}
+	return l.upload(ctx, keyPrefix, bytes.NewReader(jsonData), "application/json", ".json", reuse)
+}
+
+// UploadReaderDirect stores body at the given key without deduplication
+func (l *LocalStore) UploadReaderDirect(ctx context.Context, key string, body io.Reader, contentType string) (*model.Asset, error) {
+	if _, err := l.path(key); err != nil {
+		return nil, err
+	}
+
+	tmp, sumHex, size, err := l.writeTemp(body)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.moveTo(tmp, key); err != nil {
+		_ = os.Remove(tmp)
+		return nil, err
+	}
+	return &model.Asset{
+		S3Key:  key,
+		ETag:   sumHex,
+		SHA256: sumHex,
+		MIME:   contentType,
+		SizeB:  size,
+	}, nil
+}
+
+// DownloadFile returns the content stored at key
+func (l *LocalStore) DownloadFile(ctx context.Context, key string) ([]byte, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("read blob: %w", err)
+	}
+	return content, nil
+}
+
+// DownloadJSON reads the JSON stored at key into target
+func (l *LocalStore) DownloadJSON(ctx context.Context, key string, target interface{}) error {
+	content, err := l.DownloadFile(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := sonic.Unmarshal(content, target); err != nil {
+		return fmt.Errorf("unmarshal json: %w", err)
+	}
+	return nil
+}
+
+// Open opens the file stored at key for serving a signed URL
+func (l *LocalStore) Open(key string) (*os.File, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	return f, err
+}
+
+// ReadStaged hashes a staged file like S3Deps.ReadStaged. A missing file fails with ErrObjectNotFound.
+func (l *LocalStore) ReadStaged(ctx context.Context, key string, keepUpTo int64) (*StagedObject, error) {
+	f, err := l.Open(key)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readStaged(f, keepUpTo)
+}
+
+// PromoteStaged moves a verified staged file to its content-addressed key under keyPrefix,
+// reusing the stored copy handed out by reuse if there is one. The file is first moved out of
+// the way of new uploads to the staging key and hashed again: if it is not the one that was read,
+// it is put back and ErrObjectChanged is returned.
+func (l *LocalStore) PromoteStaged(
+	ctx context.Context,
+	stagingKey string,
+	keyPrefix string,
+	staged *StagedObject,
+	contentType string,
+	ext string,
+	metadata map[string]string,
+	reuse Reuse,
+) (*model.Asset, error) {
+	src, err := l.path(stagingKey)
+	if err != nil {
+		return nil, err
+	}
+	tmp := filepath.Join(l.root, localTmpDir, "staged-"+uuid.NewString())
+	if err := os.Rename(src, tmp); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("take staged blob: %w", err)
+	}
+
+	sumHex, size, err := hashFile(tmp)
+	if err == nil && (sumHex != staged.SHA256 || size != staged.SizeB) {
+		// Linking does not replace a file uploaded again in the meantime
+		_ = os.Link(tmp, src)
+		err = ErrObjectChanged
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return nil, err
+	}
+
+	asset, err := l.promote(ctx, tmp, keyPrefix, sumHex, size, contentType, ext, reuse)
+	if err != nil {
+		_ = os.Remove(tmp)
+		return nil, err
+	}
+	return asset, nil
+}
+
+// hashFile returns the SHA256 and size of the file at p
+func hashFile(p string) (string, int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	hr := newHashingReader(f)
+	if _, err := io.Copy(io.Discard, hr); err != nil {
+		return "", 0, fmt.Errorf("read staged blob: %w", err)
+	}
+	return hr.sum(), hr.size, nil
+}
+
+// DeleteObject deletes the file at key; deleting a missing file succeeds as it does on S3
+func (l *LocalStore) DeleteObject(ctx context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("delete blob: %w", err)
+	}
+	return nil
+}
+
+// localListPage is how many keys ListObjects hands to visit at a time
+const localListPage = 1000
+
+// ListObjects calls visit with the keys of the files under prefix that were last modified before
+// the given time, up to localListPage keys at a time
+func (l *LocalStore) ListObjects(ctx context.Context, prefix string, before time.Time, visit func(keys []string) error) error {
+	// Walk the deepest directory of prefix and match the rest of it against the keys
+	dir, _ := path.Split(prefix)
+	root := l.root
+	if dir != "" {
+		p, err := l.path(strings.TrimSuffix(dir, "/"))
+		if err != nil {
+			return err
+		}
+		root = p
+	}
+
+	var keys []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rel, err := filepath.Rel(l.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if d.IsDir() {
+			if key == localTmpDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !info.ModTime().Before(before) {
+			return nil
+		}
+		keys = append(keys, key)
+		if len(keys) < localListPage {
+			return nil
+		}
+		err = visit(keys)
+		keys = nil
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("list blobs: %w", err)
+	}
+	if len(keys) > 0 {
+		return visit(keys)
+	}
+	return nil
+}
+
+// DeleteObjectsByPrefix deletes the directory of prefix with everything below it
+func (l *LocalStore) DeleteObjectsByPrefix(ctx context.Context, prefix string) error {
+	if prefix == "" {
+		return errors.New("prefix is empty")
+	}
+	p, err := l.path(strings.TrimSuffix(prefix, "/"))
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(p); err != nil {
+		return fmt.Errorf("delete blobs: %w", err)
+	}
+	return nil
+}
+
+// PresignGet returns a signed URL to download key from the API
+func (l *LocalStore) PresignGet(ctx context.Context, key string, expire time.Duration) (string, error) {
+	if key == "" {
+		return "", errors.New("key is empty")
+	}
+	return l.presign("GET", key, expire)
+}
+
+// PresignPut returns a signed URL to upload key to the API. The content type is not enforced.
+func (l *LocalStore) PresignPut(ctx context.Context, key, contentType string, expire time.Duration) (string, error) {
+	return l.presign("PUT", key, expire)
+}
+
+func (l *LocalStore) presign(method string, key string, expire time.Duration) (string, error) {
+	if _, err := l.path(key); err != nil {
+		return "", err
+	}
+
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	expires := strconv.FormatInt(time.Now().Add(expire).Unix(), 10)
+
+	q := url.Values{}
+	q.Set("expires", expires)
+	q.Set("signature", l.sign(method, key, expires))
+	return l.baseURL + LocalBlobPath + strings.Join(segments, "/") + "?" + q.Encode(), nil
+}
+
+func (l *LocalStore) sign(method string, key string, expires string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(method + "\n" + key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the expires and signature parameters of a URL made by PresignGet or PresignPut
+func (l *LocalStore) Verify(method string, key string, expires string, signature string) error {
+	if !hmac.Equal([]byte(signature), []byte(l.sign(method, key, expires))) {
+		return ErrInvalidSignature
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if time.Now().Unix() > unix {
+		return ErrSignatureExpired
+	}
+	return nil
+}
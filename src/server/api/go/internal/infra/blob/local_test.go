@@ -0,0 +1,234 @@
+package blob
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLocalStore(t *testing.T) *LocalStore {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.S3.Backend = BackendLocal
+	cfg.S3.LocalDir = t.TempDir()
+	cfg.S3.LocalSecret = "secret"
+	cfg.S3.Endpoint = "http://127.0.0.1:8029/"
+
+	store, err := New(context.Background(), cfg)
+	require.NoError(t, err)
+	return store.(*LocalStore)
+}
+
+// assetIndex stands in for the asset references of a project: uploads reuse what it holds
+type assetIndex map[string]*model.Asset
+
+func (idx assetIndex) reuse(ctx context.Context, sha256 string) (*model.Asset, error) {
+	return idx[sha256], nil
+}
+
+func TestLocalStore_Upload(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLocalStore(t)
+	idx := assetIndex{}
+
+	a, err := l.UploadBytes(ctx, "disks/p1", "notes.md", []byte("# hello"), idx.reuse)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(a.S3Key, "disks/p1/"))
+	assert.True(t, strings.HasSuffix(a.S3Key, a.SHA256+".md"))
+	assert.Equal(t, int64(7), a.SizeB)
+	idx[a.SHA256] = a
+
+	// The same content is stored once, whatever the prefix it is uploaded under
+	b, err := l.UploadStream(ctx, "assets/p1", "copy.txt", strings.NewReader("# hello"), idx.reuse)
+	require.NoError(t, err)
+	assert.Equal(t, a.S3Key, b.S3Key)
+	assert.Equal(t, "text/plain; charset=utf-8", b.MIME)
+
+	content, err := l.DownloadFile(ctx, a.S3Key)
+	require.NoError(t, err)
+	assert.Equal(t, "# hello", string(content))
+
+	j, err := l.UploadJSON(ctx, "parts/p1", map[string]string{"k": "v"}, nil)
+	require.NoError(t, err)
+	var got map[string]string
+	require.NoError(t, l.DownloadJSON(ctx, j.S3Key, &got))
+	assert.Equal(t, map[string]string{"k": "v"}, got)
+
+	// Temporary files do not stay behind
+	entries, err := os.ReadDir(filepath.Join(l.root, localTmpDir))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestLocalStore_UploadWhileCollected(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLocalStore(t)
+	idx := assetIndex{}
+
+	a, err := l.UploadBytes(ctx, "disks/p1", "notes.md", []byte("# hello"), idx.reuse)
+	require.NoError(t, err)
+	idx[a.SHA256] = a
+
+	// The collector deletes the orphaned asset while the same content is uploaded again, before
+	// the upload claims it: the claim waits for the collector and finds nothing
+	collectFirst := func(ctx context.Context, sha256 string) (*model.Asset, error) {
+		require.NoError(t, l.DeleteObject(ctx, idx[sha256].S3Key))
+		delete(idx, sha256)
+		return idx.reuse(ctx, sha256)
+	}
+	b, err := l.UploadStream(ctx, "disks/p1", "notes.md", strings.NewReader("# hello"), collectFirst)
+	require.NoError(t, err)
+
+	// The content is stored again instead of pointing at the deleted object
+	content, err := l.DownloadFile(ctx, b.S3Key)
+	require.NoError(t, err)
+	assert.Equal(t, "# hello", string(content))
+}
+
+func TestLocalStore_StagedUpload(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLocalStore(t)
+
+	_, err := l.ReadStaged(ctx, "staging/upload/1", 1024)
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+
+	_, err = l.UploadReaderDirect(ctx, "staging/upload/1", strings.NewReader("hello world"), "text/plain")
+	require.NoError(t, err)
+	staged, err := l.ReadStaged(ctx, "staging/upload/1", 1024)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), staged.SizeB)
+	assert.Equal(t, "hello world", string(staged.Content))
+
+	a, err := l.PromoteStaged(ctx, "staging/upload/1", "disks/p1", staged, "text/plain", ".txt", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, staged.SHA256, a.SHA256)
+	_, err = l.ReadStaged(ctx, "staging/upload/1", 1024)
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+	content, err := l.DownloadFile(ctx, a.S3Key)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(content))
+}
+
+func TestLocalStore_StagedUploadReplaced(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLocalStore(t)
+
+	_, err := l.UploadReaderDirect(ctx, "staging/upload/1", strings.NewReader("hello world"), "text/plain")
+	require.NoError(t, err)
+	staged, err := l.ReadStaged(ctx, "staging/upload/1", 1024)
+	require.NoError(t, err)
+
+	// The client uploads again between the verification and the promotion
+	_, err = l.UploadReaderDirect(ctx, "staging/upload/1", strings.NewReader("hello there"), "text/plain")
+	require.NoError(t, err)
+	_, err = l.PromoteStaged(ctx, "staging/upload/1", "disks/p1", staged, "text/plain", ".txt", nil, nil)
+	assert.ErrorIs(t, err, ErrObjectChanged)
+
+	// The new upload stays staged to be verified in turn
+	again, err := l.ReadStaged(ctx, "staging/upload/1", 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "hello there", string(again.Content))
+	_, err = os.Stat(filepath.Join(l.root, "disks"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLocalStore_Delete(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLocalStore(t)
+
+	a, err := l.UploadReaderDirect(ctx, "agent_skills/p1/s1/SKILL.md", strings.NewReader("skill"), "text/markdown")
+	require.NoError(t, err)
+	_, err = l.UploadReaderDirect(ctx, "agent_skills/p1/s1/scripts/run.py", strings.NewReader("print()"), "text/x-python")
+	require.NoError(t, err)
+
+	require.NoError(t, l.DeleteObject(ctx, a.S3Key))
+	require.NoError(t, l.DeleteObject(ctx, a.S3Key), "deleting a missing blob succeeds")
+
+	require.NoError(t, l.DeleteObjectsByPrefix(ctx, "agent_skills/p1/s1/"))
+	_, err = os.Stat(filepath.Join(l.root, "agent_skills", "p1", "s1"))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.Error(t, l.DeleteObjectsByPrefix(ctx, ""))
+}
+
+func TestLocalStore_ListObjects(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLocalStore(t)
+
+	for _, key := range []string{"staging/stream/old", "staging/stream/new", "staging/streamed", "staging/other/old"} {
+		_, err := l.UploadReaderDirect(ctx, key, strings.NewReader("x"), "text/plain")
+		require.NoError(t, err)
+	}
+	old := time.Now().Add(-time.Hour)
+	for _, key := range []string{"staging/stream/old", "staging/streamed", "staging/other/old"} {
+		require.NoError(t, os.Chtimes(filepath.Join(l.root, filepath.FromSlash(key)), old, old))
+	}
+
+	var keys []string
+	err := l.ListObjects(ctx, "staging/stream", time.Now().Add(-time.Minute), func(page []string) error {
+		keys = append(keys, page...)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"staging/stream/old", "staging/streamed"}, keys)
+
+	// A prefix without objects lists nothing
+	err = l.ListObjects(ctx, "missing/", time.Now(), func(page []string) error {
+		t.Fatalf("unexpected keys %v", page)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestLocalStore_InvalidKeys(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLocalStore(t)
+
+	for _, key := range []string{"", "../escape", "/abs/path", "a/../../escape", ".tmp/blob"} {
+		_, err := l.UploadReaderDirect(ctx, key, strings.NewReader("x"), "text/plain")
+		assert.Error(t, err, key)
+		_, err = l.DownloadFile(ctx, key)
+		assert.Error(t, err, key)
+	}
+}
+
+func TestLocalStore_Presign(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLocalStore(t)
+
+	raw, err := l.PresignGet(ctx, "disks/p1/2025/01/01/a b.md", time.Minute)
+	require.NoError(t, err)
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1/blob/disks/p1/2025/01/01/a b.md", u.Path)
+	assert.Equal(t, "127.0.0.1:8029", u.Host)
+
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+	key := strings.TrimPrefix(u.Path, LocalBlobPath)
+	assert.NoError(t, l.Verify("GET", key, expires, signature))
+	assert.ErrorIs(t, l.Verify("PUT", key, expires, signature), ErrInvalidSignature)
+	assert.ErrorIs(t, l.Verify("GET", "disks/p1/other.md", expires, signature), ErrInvalidSignature)
+	assert.ErrorIs(t, l.Verify("GET", key, expires+"0", signature), ErrInvalidSignature)
+
+	raw, err = l.PresignPut(ctx, "staging/upload/1", "text/plain", -time.Minute)
+	require.NoError(t, err)
+	u, err = url.Parse(raw)
+	require.NoError(t, err)
+	assert.ErrorIs(t, l.Verify("PUT", "staging/upload/1", u.Query().Get("expires"), u.Query().Get("signature")), ErrSignatureExpired)
+}
+
+func TestNew_UnknownBackend(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.S3.Backend = "gcs"
+	_, err := New(context.Background(), cfg)
+	assert.Error(t, err)
+}
@@ -7,20 +7,24 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"mime/multipart"
+	"net/http"
 	"net/url"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	awsCfg "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/bytedance/sonic"
+	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/config"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/pkg/utils/mime"
@@ -28,6 +32,18 @@ import (
 	"go.opentelemetry.io/otel"
 )
 
+var (
+	// ErrObjectNotFound is returned when an object that should have been uploaded does not exist
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrObjectChanged is returned when a staged object was overwritten after it was read
+	ErrObjectChanged = errors.New("object changed since it was read")
+)
+
+// StreamStagingPrefix holds the objects UploadStream writes before they are copied to their
+// content-addressed key. Objects left there by a process that died mid-upload are swept by the
+// asset garbage collector.
+const StreamStagingPrefix = "staging/stream/"
+
 type S3Deps struct {
 	Client    *s3.Client
 	Uploader  *manager.Uploader
@@ -172,9 +188,15 @@ func cleanETag(etag string) string {
 	return strings.Trim(etag, `"`)
 }
 
+// dedupKey returns the content-addressed key of a new object under keyPrefix
+func dedupKey(keyPrefix string, sumHex string, ext string) string {
+	datePrefix := time.Now().UTC().Format("2006/01/02")
+	return fmt.Sprintf("%s/%s/%s%s", keyPrefix, datePrefix, sumHex, ext)
+}
+
 // uploadWithDedup performs content-addressed deduplicated upload.
-// It searches for existing objects under keyPrefix that contain the given sumHex in the key.
-// If found, returns its metadata; otherwise uploads the new content using date + sumHex + ext as key.
+// If reuse hands out a stored copy of sumHex, returns its metadata; otherwise uploads the new
+// content using date + sumHex + ext as key.
 func (u *S3Deps) uploadWithDedup(
 	ctx context.Context,
 	keyPrefix string,
@@ -184,51 +206,14 @@ func (u *S3Deps) uploadWithDedup(
 	size int64,
 	body io.Reader,
 	metadata map[string]string,
+	reuse Reuse,
 ) (*model.Asset, error) {
-	// Check for existing object with pagination support
-	listInput := &s3.ListObjectsV2Input{
-		Bucket: &u.Bucket,
-		Prefix: &keyPrefix,
-	}
-
-	var continuationToken *string
-	for {
-		listInput.ContinuationToken = continuationToken
-		result, err := u.Client.ListObjectsV2(ctx, listInput)
-		if err != nil {
-			break
-		}
-
-		if result.Contents != nil {
-			for _, obj := range result.Contents {
-				if obj.Key != nil && strings.Contains(*obj.Key, sumHex) {
-					if headResult, herr := u.Client.HeadObject(ctx, &s3.HeadObjectInput{
-						Bucket: &u.Bucket,
-						Key:    obj.Key,
-					}); herr == nil {
-						return &model.Asset{
-							Bucket: u.Bucket,
-							S3Key:  *obj.Key,
-							ETag:   cleanETag(*headResult.ETag),
-							SHA256: sumHex,
-							MIME:   contentType,
-							SizeB:  aws.ToInt64(headResult.ContentLength),
-						}, nil
-					}
-				}
-			}
-		}
-
-		// Check if there are more pages
-		if !aws.ToBool(result.IsTruncated) {
-			break
-		}
-		continuationToken = result.NextContinuationToken
+	if existing, err := reused(ctx, reuse, sumHex, contentType, size); err != nil || existing != nil {
+		return existing, err
 	}
 
 	// No existing file found, upload new file with date prefix
-	datePrefix := time.Now().UTC().Format("2006/01/02")
-	key := fmt.Sprintf("%s/%s/%s%s", keyPrefix, datePrefix, sumHex, ext)
+	key := dedupKey(keyPrefix, sumHex, ext)
 
 	input := &s3.PutObjectInput{
 		Bucket:      aws.String(u.Bucket),
@@ -256,51 +241,314 @@ func (u *S3Deps) uploadWithDedup(
 	}, nil
 }
 
-// UploadFormFile uploads a file to S3 with automatic deduplication
-// It checks if a file with the same SHA256 already exists under the keyPrefix
-// If found, returns the existing file metadata; otherwise uploads the new file
-func (u *S3Deps) UploadFormFile(ctx context.Context, keyPrefix string, fh *multipart.FileHeader) (*model.Asset, error) {
-	file, err := fh.Open()
+// StagedObject is an object uploaded directly to a staging key, as read back for verification
+type StagedObject struct {
+	SizeB   int64
+	SHA256  string
+	ETag    string // Version of the object that was read, so that only that version is promoted
+	Head    []byte // First bytes of the content, enough for MIME detection
+	Content []byte // The whole content if it was not larger than asked for, nil otherwise
+}
+
+// stagedHeadSize is how much of a file is read for MIME detection
+const stagedHeadSize = 3072
+
+// ReadStaged streams a staged object to compute its size and SHA256, keeping the whole content
+// in memory only when it is at most keepUpTo bytes. A missing object fails with ErrObjectNotFound.
+func (u *S3Deps) ReadStaged(ctx context.Context, key string, keepUpTo int64) (*StagedObject, error) {
+	result, err := u.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &u.Bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		var noSuchKey *s3types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("get staged object from S3: %w", err)
+	}
+	defer result.Body.Close()
+
+	staged, err := readStaged(result.Body, keepUpTo)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	staged.ETag = aws.ToString(result.ETag)
+	return staged, nil
+}
 
-	// Read file content into memory
+// readStaged hashes and measures a staged object, keeping its head and, if small enough, its content
+func readStaged(body io.Reader, keepUpTo int64) (*StagedObject, error) {
+	h := sha256.New()
 	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, file); err != nil {
+	n, err := io.Copy(io.MultiWriter(h, &limitedBuffer{buf: &buf, limit: max(keepUpTo, stagedHeadSize)}), body)
+	if err != nil {
+		return nil, fmt.Errorf("read staged object: %w", err)
+	}
+
+	staged := &StagedObject{SizeB: n, SHA256: hex.EncodeToString(h.Sum(nil))}
+	data := buf.Bytes()
+	staged.Head = data[:min(len(data), stagedHeadSize)]
+	if n <= keepUpTo {
+		staged.Content = data
+	}
+	return staged, nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the rest
+type limitedBuffer struct {
+	buf   *bytes.Buffer
+	limit int64
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - int64(b.buf.Len()); room > 0 {
+		b.buf.Write(p[:min(int64(len(p)), room)])
+	}
+	return len(p), nil
+}
+
+// PromoteStaged moves a verified staged object to its content-addressed key under keyPrefix,
+// reusing the stored copy handed out by reuse if there is one. The staged object is deleted.
+// If it was overwritten since it was read, nothing is copied and ErrObjectChanged is returned.
+func (u *S3Deps) PromoteStaged(
+	ctx context.Context,
+	stagingKey string,
+	keyPrefix string,
+	staged *StagedObject,
+	contentType string,
+	ext string,
+	metadata map[string]string,
+	reuse Reuse,
+) (*model.Asset, error) {
+	return u.promote(ctx, stagingKey, staged.ETag, keyPrefix, staged.SHA256, staged.SizeB, contentType, ext, metadata, reuse)
+}
+
+// promote moves the object at tmpKey to the content-addressed key of sumHex under keyPrefix, or
+// drops it if reuse hands out a stored copy of the same content. A non-empty ifMatch is the ETag
+// the object must still have.
+func (u *S3Deps) promote(
+	ctx context.Context,
+	tmpKey string,
+	ifMatch string,
+	keyPrefix string,
+	sumHex string,
+	size int64,
+	contentType string,
+	ext string,
+	metadata map[string]string,
+	reuse Reuse,
+) (*model.Asset, error) {
+	asset, err := reused(ctx, reuse, sumHex, contentType, size)
+	if err != nil {
 		return nil, err
 	}
-	fileContent := buf.Bytes()
+	if asset == nil {
+		key := dedupKey(keyPrefix, sumHex, ext)
+		etag, err := u.copyObject(ctx, tmpKey, ifMatch, key, size, contentType, metadata)
+		if err != nil {
+			return nil, err
+		}
+		asset = &model.Asset{
+			Bucket: u.Bucket,
+			S3Key:  key,
+			ETag:   etag,
+			SHA256: sumHex,
+			MIME:   contentType,
+			SizeB:  size,
+		}
+	}
 
-	// Calculate SHA256 of the file content
-	h := sha256.New()
-	h.Write(fileContent)
-	sumHex := hex.EncodeToString(h.Sum(nil))
+	if err := u.DeleteObject(ctx, tmpKey); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
 
-	ext := strings.ToLower(filepath.Ext(fh.Filename))
+// maxCopySize is the largest object S3 copies in a single request
+const maxCopySize = 5 << 30
+
+// copyPartSize is the part size of multipart copies of larger objects
+const copyPartSize = 512 << 20
+
+// copyObject copies an object within the bucket, replacing its content type and metadata.
+// Objects over 5GB are copied in parts. A non-empty ifMatch is the ETag the source must have
+// for every request, otherwise the copy fails with ErrObjectChanged.
+func (u *S3Deps) copyObject(ctx context.Context, src string, ifMatch string, dst string, size int64, contentType string, metadata map[string]string) (string, error) {
+	copySource := copySourceOf(u.Bucket, src)
+	var copySourceIfMatch *string
+	if ifMatch != "" {
+		copySourceIfMatch = aws.String(ifMatch)
+	}
+	if size <= maxCopySize {
+		input := &s3.CopyObjectInput{
+			Bucket:            aws.String(u.Bucket),
+			Key:               aws.String(dst),
+			CopySource:        aws.String(copySource),
+			CopySourceIfMatch: copySourceIfMatch,
+			ContentType:       aws.String(contentType),
+			Metadata:          metadata,
+			MetadataDirective: s3types.MetadataDirectiveReplace,
+		}
+		if u.SSE != nil {
+			input.ServerSideEncryption = *u.SSE
+		}
+		out, err := u.Client.CopyObject(ctx, input)
+		if err != nil {
+			return "", fmt.Errorf("copy object: %w", copyErr(err))
+		}
+		if out.CopyObjectResult == nil {
+			return "", nil
+		}
+		return cleanETag(aws.ToString(out.CopyObjectResult.ETag)), nil
+	}
 
-	// Detect MIME type from file content, with extension-based refinement for text files
-	contentType := mime.DetectMimeType(fileContent, fh.Filename)
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(u.Bucket),
+		Key:         aws.String(dst),
+		ContentType: aws.String(contentType),
+		Metadata:    metadata,
+	}
+	if u.SSE != nil {
+		input.ServerSideEncryption = *u.SSE
+	}
+	mpu, err := u.Client.CreateMultipartUpload(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("create multipart copy: %w", err)
+	}
+	abort := func(err error) (string, error) {
+		_, _ = u.Client.AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(u.Bucket),
+			Key:      aws.String(dst),
+			UploadId: mpu.UploadId,
+		})
+		return "", err
+	}
 
-	return u.uploadWithDedup(
-		ctx,
-		keyPrefix,
-		sumHex,
-		contentType,
-		ext,
-		int64(len(fileContent)),
-		bytes.NewReader(fileContent),
-		map[string]string{
+	var parts []s3types.CompletedPart
+	for part, offset := int32(1), int64(0); offset < size; part, offset = part+1, offset+copyPartSize {
+		last := min(offset+copyPartSize, size) - 1
+		out, err := u.Client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+			Bucket:            aws.String(u.Bucket),
+			Key:               aws.String(dst),
+			UploadId:          mpu.UploadId,
+			PartNumber:        aws.Int32(part),
+			CopySource:        aws.String(copySource),
+			CopySourceIfMatch: copySourceIfMatch,
+			CopySourceRange:   aws.String(fmt.Sprintf("bytes=%d-%d", offset, last)),
+		})
+		if err != nil {
+			return abort(fmt.Errorf("copy part %d: %w", part, copyErr(err)))
+		}
+		parts = append(parts, s3types.CompletedPart{ETag: out.CopyPartResult.ETag, PartNumber: aws.Int32(part)})
+	}
+
+	done, err := u.Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(u.Bucket),
+		Key:             aws.String(dst),
+		UploadId:        mpu.UploadId,
+		MultipartUpload: &s3types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return abort(fmt.Errorf("complete multipart copy: %w", err))
+	}
+	return cleanETag(aws.ToString(done.ETag)), nil
+}
+
+// copySourceOf returns the URL-encoded CopySource of key. Each segment is escaped on its own so
+// the slashes between them are kept; + is escaped too since S3 would decode it as a space.
+func copySourceOf(bucket string, key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(s), "+", "%2B")
+	}
+	return bucket + "/" + strings.Join(segments, "/")
+}
+
+// copyErr turns the failed precondition of a copy into ErrObjectChanged
+func copyErr(err error) error {
+	var re *awshttp.ResponseError
+	if errors.As(err, &re) && re.HTTPStatusCode() == http.StatusPreconditionFailed {
+		return ErrObjectChanged
+	}
+	return err
+}
+
+// hashingReader hashes and counts what is read through it
+type hashingReader struct {
+	r    io.Reader
+	h    hash.Hash
+	size int64
+}
+
+func newHashingReader(r io.Reader) *hashingReader {
+	return &hashingReader{r: r, h: sha256.New()}
+}
+
+func (hr *hashingReader) Read(p []byte) (int, error) {
+	n, err := hr.r.Read(p)
+	hr.h.Write(p[:n])
+	hr.size += int64(n)
+	return n, err
+}
+
+func (hr *hashingReader) sum() string { return hex.EncodeToString(hr.h.Sum(nil)) }
+
+// UploadStream uploads a file of any size to S3 with automatic deduplication, holding only a
+// bounded part of it in memory. The content is hashed while it is uploaded to a temporary key
+// with multipart upload, then copied to its content-addressed key under keyPrefix, or dropped
+// if reuse hands out a stored copy of the same content.
+func (u *S3Deps) UploadStream(ctx context.Context, keyPrefix string, filename string, body io.Reader, reuse Reuse) (*model.Asset, error) {
+	// Detect MIME type from the first bytes, with extension-based refinement for text files
+	contentType, body, err := mime.DetectMimeTypeReader(body, filename)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	tmpKey := StreamStagingPrefix + uuid.NewString()
+	hr := newHashingReader(body)
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(u.Bucket),
+		Key:         aws.String(tmpKey),
+		Body:        hr,
+		ContentType: aws.String(contentType),
+	}
+	if u.SSE != nil {
+		input.ServerSideEncryption = *u.SSE
+	}
+	if _, err := u.Uploader.Upload(ctx, input); err != nil {
+		return nil, err
+	}
+
+	sumHex := hr.sum()
+	asset, err := u.promote(ctx, tmpKey, "", keyPrefix, sumHex, hr.size, contentType,
+		strings.ToLower(filepath.Ext(filename)), map[string]string{
 			"sha256": sumHex,
-			"name":   fh.Filename,
-		},
-	)
+			"name":   filename,
+		}, reuse)
+	if err != nil {
+		_ = u.DeleteObject(context.Background(), tmpKey)
+		return nil, err
+	}
+	return asset, nil
+}
+
+// UploadFormFile uploads a file to S3 with automatic deduplication
+// If reuse hands out a stored copy of the same content, returns its metadata; otherwise uploads the new file
+// The file is streamed, so only a bounded part of it is held in memory
+func (u *S3Deps) UploadFormFile(ctx context.Context, keyPrefix string, fh *multipart.FileHeader, reuse Reuse) (*model.Asset, error) {
+	file, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return u.UploadStream(ctx, keyPrefix, fh.Filename, file, reuse)
 }
 
 // UploadBytes uploads raw bytes to S3 with automatic deduplication
 // Similar to UploadFormFile but accepts raw bytes instead of multipart.FileHeader
-func (u *S3Deps) UploadBytes(ctx context.Context, keyPrefix string, filename string, content []byte) (*model.Asset, error) {
+func (u *S3Deps) UploadBytes(ctx context.Context, keyPrefix string, filename string, content []byte, reuse Reuse) (*model.Asset, error) {
 	// Calculate SHA256 of the content
 	h := sha256.New()
 	h.Write(content)
@@ -323,11 +571,12 @@ func (u *S3Deps) UploadBytes(ctx context.Context, keyPrefix string, filename str
 			"sha256": sumHex,
 			"name":   filename,
 		},
+		reuse,
 	)
 }
 
 // UploadJSON uploads JSON data to S3 and returns metadata
-func (u *S3Deps) UploadJSON(ctx context.Context, keyPrefix string, data interface{}) (*model.Asset, error) {
+func (u *S3Deps) UploadJSON(ctx context.Context, keyPrefix string, data interface{}, reuse Reuse) (*model.Asset, error) {
 	// Serialize data to JSON
 	jsonData, err := sonic.Marshal(data)
 	if err != nil {
@@ -350,6 +599,7 @@ func (u *S3Deps) UploadJSON(ctx context.Context, keyPrefix string, data interfac
 		map[string]string{
 			"sha256": sumHex,
 		},
+		reuse,
 	)
 }
 
@@ -393,6 +643,39 @@ func (u *S3Deps) UploadFileDirect(ctx context.Context, key string, content []byt
 	}, nil
 }
 
+// UploadReaderDirect streams body to S3 at the given key without deduplication. Unlike
+// UploadFileDirect, the SHA256 is computed while uploading, so it is not stored as object metadata.
+func (u *S3Deps) UploadReaderDirect(ctx context.Context, key string, body io.Reader, contentType string) (*model.Asset, error) {
+	if key == "" {
+		return nil, errors.New("key is empty")
+	}
+
+	hr := newHashingReader(body)
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(u.Bucket),
+		Key:         aws.String(key),
+		Body:        hr,
+		ContentType: aws.String(contentType),
+	}
+	if u.SSE != nil {
+		input.ServerSideEncryption = *u.SSE
+	}
+
+	out, err := u.Uploader.Upload(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Asset{
+		Bucket: u.Bucket,
+		S3Key:  key,
+		ETag:   cleanETag(*out.ETag),
+		SHA256: hr.sum(),
+		MIME:   contentType,
+		SizeB:  hr.size,
+	}, nil
+}
+
 // DownloadJSON downloads JSON data from S3 and unmarshals it into the provided interface
 func (u *S3Deps) DownloadJSON(ctx context.Context, key string, target interface{}) error {
 	result, err := u.Client.GetObject(ctx, &s3.GetObjectInput{
@@ -504,6 +787,34 @@ func (u *S3Deps) DeleteObjects(ctx context.Context, keys []string) error {
 	return nil
 }
 
+// ListObjects calls visit with the keys under prefix that were last modified before the given
+// time, one page at a time
+func (u *S3Deps) ListObjects(ctx context.Context, prefix string, before time.Time, visit func(keys []string) error) error {
+	paginator := s3.NewListObjectsV2Paginator(u.Client, &s3.ListObjectsV2Input{
+		Bucket: &u.Bucket,
+		Prefix: &prefix,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("list objects from S3: %w", err)
+		}
+		keys := make([]string, 0, len(page.Contents))
+		for _, obj := range page.Contents {
+			if obj.Key != nil && obj.LastModified != nil && obj.LastModified.Before(before) {
+				keys = append(keys, *obj.Key)
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		if err := visit(keys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // DeleteObjectsByPrefix recursively deletes all objects with the given prefix
 // This is equivalent to deleting an entire "directory" in S3
 func (u *S3Deps) DeleteObjectsByPrefix(ctx context.Context, prefix string) error {
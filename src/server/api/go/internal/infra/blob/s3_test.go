@@ -0,0 +1,22 @@
+package blob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopySourceOf(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"disks/p/2025/01/01/abc.md", "bucket/disks/p/2025/01/01/abc.md"},
+		{"staging/p/my report.pdf", "bucket/staging/p/my%20report.pdf"},
+		{"staging/p/a+b?c#d%e.txt", "bucket/staging/p/a%2Bb%3Fc%23d%25e.txt"},
+		{"staging/p/résumé.txt", "bucket/staging/p/r%C3%A9sum%C3%A9.txt"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, copySourceOf("bucket", tt.key), tt.key)
+	}
+}
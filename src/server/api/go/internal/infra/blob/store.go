@@ -0,0 +1,95 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"time"
+
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+)
+
+// Backends selectable through s3.backend
+const (
+	BackendS3    = "s3"
+	BackendLocal = "local"
+)
+
+// Reuse returns the stored copy of the content with the given SHA256 for an upload to use instead
+// of storing the content again, or nil to store it. The copy must stay stored until the upload
+// references it. Stores never look for copies themselves: an object without a live asset
+// reference may be deleted by the garbage collector at any time.
+type Reuse func(ctx context.Context, sha256 string) (*model.Asset, error)
+
+// reused returns the asset of the copy handed out by reuse, described with the content being uploaded
+func reused(ctx context.Context, reuse Reuse, sumHex string, contentType string, size int64) (*model.Asset, error) {
+	if reuse == nil {
+		return nil, nil
+	}
+	existing, err := reuse(ctx, sumHex)
+	if err != nil {
+		return nil, fmt.Errorf("find stored copy: %w", err)
+	}
+	if existing == nil || existing.S3Key == "" {
+		return nil, nil
+	}
+	return &model.Asset{
+		Bucket: existing.Bucket,
+		S3Key:  existing.S3Key,
+		ETag:   existing.ETag,
+		SHA256: sumHex,
+		MIME:   contentType,
+		SizeB:  size,
+	}, nil
+}
+
+// BlobStore stores the files behind assets, artifacts and skills under string keys. S3Deps keeps
+// them in an S3 bucket and LocalStore in a directory served through signed URLs by the API itself.
+// Deduplicating uploads take a Reuse, which may be nil to always store the content.
+type BlobStore interface {
+	UploadFormFile(ctx context.Context, keyPrefix string, fh *multipart.FileHeader, reuse Reuse) (*model.Asset, error)
+	UploadStream(ctx context.Context, keyPrefix string, filename string, body io.Reader, reuse Reuse) (*model.Asset, error)
+	UploadBytes(ctx context.Context, keyPrefix string, filename string, content []byte, reuse Reuse) (*model.Asset, error)
+	UploadJSON(ctx context.Context, keyPrefix string, data interface{}, reuse Reuse) (*model.Asset, error)
+	UploadReaderDirect(ctx context.Context, key string, body io.Reader, contentType string) (*model.Asset, error)
+
+	DownloadFile(ctx context.Context, key string) ([]byte, error)
+	DownloadJSON(ctx context.Context, key string, target interface{}) error
+
+	PresignGet(ctx context.Context, key string, expire time.Duration) (string, error)
+	PresignPut(ctx context.Context, key, contentType string, expire time.Duration) (string, error)
+
+	ReadStaged(ctx context.Context, key string, keepUpTo int64) (*StagedObject, error)
+	PromoteStaged(ctx context.Context, stagingKey string, keyPrefix string, staged *StagedObject, contentType string, ext string, metadata map[string]string, reuse Reuse) (*model.Asset, error)
+
+	ListObjects(ctx context.Context, prefix string, before time.Time, visit func(keys []string) error) error
+	DeleteObject(ctx context.Context, key string) error
+	DeleteObjectsByPrefix(ctx context.Context, prefix string) error
+}
+
+var (
+	_ BlobStore = (*S3Deps)(nil)
+	_ BlobStore = (*LocalStore)(nil)
+)
+
+// New opens the blob store of the configured backend, S3 unless s3.backend says otherwise
+func New(ctx context.Context, cfg *config.Config) (BlobStore, error) {
+	switch cfg.S3.Backend {
+	case "", BackendS3:
+		s, err := NewS3(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case BackendLocal:
+		l, err := NewLocal(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return l, nil
+	default:
+		return nil, fmt.Errorf("unknown blob backend %q", cfg.S3.Backend)
+	}
+}
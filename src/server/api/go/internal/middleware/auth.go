@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"errors"
 	"net/http"
 	"strings"
@@ -92,3 +93,17 @@ func ProjectAuth(cfg *config.Config, db *gorm.DB) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RootAuth returns a middleware that only lets through requests bearing the root API token.
+// It guards the admin routes, which act on every project.
+func RootAuth(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || cfg.Root.ApiBearerToken == "" ||
+			subtle.ConstantTimeCompare([]byte(raw), []byte(cfg.Root.ApiBearerToken)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, serializer.AuthErr("Unauthorized"))
+			return
+		}
+		c.Next()
+	}
+}
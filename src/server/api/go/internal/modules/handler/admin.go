@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+)
+
+type AdminHandler struct {
+	gc service.AssetGCService
+}
+
+func NewAdminHandler(gc service.AssetGCService) *AdminHandler {
+	return &AdminHandler{gc: gc}
+}
+
+type AssetGCDryRunReq struct {
+	GraceSec int `form:"grace_sec" json:"grace_sec" binding:"min=0" example:"86400"`                     // Grace period in seconds, defaults to the configured one
+	Limit    int `form:"limit,default=100" json:"limit" binding:"required,min=1,max=1000" example:"100"` // Maximum number of assets to list
+}
+
+// AssetGCDryRun godoc
+//
+//	@Summary		Dry-run asset garbage collection
+//	@Description	List the stored files of every project that the asset garbage collector would delete: files that nothing has referenced for the grace period, oldest first. Nothing is deleted. Requires the root API token, without the project token prefix.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			grace_sec	query	integer	false	"Grace period in seconds, defaults to the configured one"	example(86400)
+//	@Param			limit		query	integer	false	"Maximum number of assets to list, default 100. Max 1000."
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.AssetGCReport}
+//	@Router			/admin/gc/assets [get]
+func (h *AdminHandler) AssetGCDryRun(c *gin.Context) {
+	req := AssetGCDryRunReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	out, err := h.gc.Collect(c.Request.Context(), service.CollectAssetsInput{
+		Grace:  time.Duration(req.GraceSec) * time.Second,
+		Limit:  req.Limit,
+		DryRun: true,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAssetGCService is a mock implementation of AssetGCService
+type MockAssetGCService struct {
+	mock.Mock
+}
+
+func (m *MockAssetGCService) Collect(ctx context.Context, in service.CollectAssetsInput) (*service.AssetGCReport, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.AssetGCReport), args.Error(1)
+}
+
+func (m *MockAssetGCService) Run(ctx context.Context) {
+	m.Called(ctx)
+}
+
+func TestAdminHandler_AssetGCDryRun(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		setup          func(*MockAssetGCService)
+		expectedStatus int
+	}{
+		{
+			name:  "default grace period",
+			query: "",
+			setup: func(m *MockAssetGCService) {
+				m.On("Collect", mock.Anything, service.CollectAssetsInput{Limit: 100, DryRun: true}).
+					Return(&service.AssetGCReport{DryRun: true, Objects: 2, Bytes: 30}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "custom grace period",
+			query: "?grace_sec=3600&limit=10",
+			setup: func(m *MockAssetGCService) {
+				m.On("Collect", mock.Anything, service.CollectAssetsInput{Grace: time.Hour, Limit: 10, DryRun: true}).
+					Return(&service.AssetGCReport{DryRun: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "limit too large",
+			query:          "?limit=5000",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockAssetGCService{}
+			if tt.setup != nil {
+				tt.setup(mockService)
+			}
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/admin/gc/assets", NewAdminHandler(mockService).AssetGCDryRun)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/admin/gc/assets"+tt.query, nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"dry_run":true`)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
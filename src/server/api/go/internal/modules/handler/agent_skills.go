@@ -95,6 +95,10 @@ func (h *AgentSkillsHandler) CreateAgentSkill(c *gin.Context) {
 		Meta:      meta,
 	})
 	if err != nil {
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			c.JSON(http.StatusInsufficientStorage, serializer.Err(http.StatusInsufficientStorage, "storage quota exceeded", err))
+			return
+		}
 		// Check if error is a validation error (SKILL.md related)
 		errMsg := err.Error()
 		if strings.Contains(errMsg, "SKILL.md") || strings.Contains(errMsg, "name is required") || strings.Contains(errMsg, "description is required") {
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	pathpkg "path"
+	"regexp"
 	"strings"
 	"time"
 
@@ -17,18 +19,20 @@ import (
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/serializer"
 	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/archive"
 	"github.com/memodb-io/Acontext/internal/pkg/utils/fileparser"
 	"github.com/memodb-io/Acontext/internal/pkg/utils/path"
+	"gorm.io/gorm"
 )
 
 type ArtifactHandler struct {
 	svc        service.ArtifactService
 	config     *config.Config
 	coreClient *httpclient.CoreClient
-	s3         *blob.S3Deps
+	s3         blob.BlobStore
 }
 
-func NewArtifactHandler(s service.ArtifactService, cfg *config.Config, coreClient *httpclient.CoreClient, s3 *blob.S3Deps) *ArtifactHandler {
+func NewArtifactHandler(s service.ArtifactService, cfg *config.Config, coreClient *httpclient.CoreClient, s3 blob.BlobStore) *ArtifactHandler {
 	return &ArtifactHandler{svc: s, config: cfg, coreClient: coreClient, s3: s3}
 }
 
@@ -37,29 +41,93 @@ type CreateArtifactReq struct {
 	Meta     string `form:"meta" json:"meta"`
 }
 
+// ArtifactFilterReq narrows artifact listings and searches by user meta, type, size and modification time
+type ArtifactFilterReq struct {
+	Meta         string     `form:"meta" json:"meta" example:"{\"status\":\"reviewed\"}"`                                                      // JSON object the user meta must contain
+	MetaKeys     []string   `form:"meta_key" json:"meta_key" example:"source"`                                                                 // Keys the user meta must have, whatever their value; repeatable
+	MIME         string     `form:"mime" json:"mime" example:"text/*"`                                                                         // Exact MIME type, or a family such as text/*
+	MinSize      *int64     `form:"min_size" json:"min_size" binding:"omitempty,min=0" example:"1024"`                                         // Minimum size in bytes
+	MaxSize      *int64     `form:"max_size" json:"max_size" binding:"omitempty,min=0" example:"1048576"`                                      // Maximum size in bytes
+	UpdatedAfter *time.Time `form:"updated_after" json:"updated_after" time_format:"2006-01-02T15:04:05Z07:00" example:"2025-01-01T00:00:00Z"` // Only artifacts updated after this RFC 3339 time
+}
+
+func (r ArtifactFilterReq) filter() (service.ArtifactFilter, error) {
+	f := service.ArtifactFilter{
+		MetaKeys:     r.MetaKeys,
+		MIME:         r.MIME,
+		MinSizeB:     r.MinSize,
+		MaxSizeB:     r.MaxSize,
+		UpdatedAfter: r.UpdatedAfter,
+	}
+	if r.Meta != "" {
+		if err := sonic.Unmarshal([]byte(r.Meta), &f.Meta); err != nil {
+			return f, fmt.Errorf("meta must be a JSON object: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// precondition reads the If-Match and If-None-Match headers of a write
+func precondition(c *gin.Context) service.Precondition {
+	return service.Precondition{
+		IfMatch:     etagList(c.GetHeader("If-Match")),
+		IfNoneMatch: etagList(c.GetHeader("If-None-Match")),
+	}
+}
+
+// etagList splits a header listing entity tags. ETags of artifacts are strong, so weak tags are
+// compared as if they were strong.
+func etagList(header string) []string {
+	var etags []string
+	for _, etag := range strings.Split(header, ",") {
+		if etag = strings.TrimSpace(etag); etag != "" {
+			etags = append(etags, strings.TrimPrefix(etag, "W/"))
+		}
+	}
+	return etags
+}
+
 type GrepArtifactsReq struct {
 	Query string `form:"query" json:"query" binding:"required" example:"TODO.*"`
 	Limit *int   `form:"limit" json:"limit" binding:"omitempty,min=0,max=200" example:"20"`
+	ArtifactFilterReq
+}
+
+type GrepArtifactMatchesReq struct {
+	Query      string `form:"query" json:"query" binding:"required" example:"TODO.*"`
+	IgnoreCase bool   `form:"ignore_case" json:"ignore_case" example:"false"`
+	Glob       string `form:"glob" json:"glob" example:"/src/**/*.py"`                           // Only search files whose full path matches this glob
+	Context    int    `form:"context" json:"context" binding:"min=0,max=50" example:"2"`         // Context lines before and after each match (-C)
+	Before     *int   `form:"before" json:"before" binding:"omitempty,min=0,max=50" example:"2"` // Context lines before each match, overrides context (-B)
+	After      *int   `form:"after" json:"after" binding:"omitempty,min=0,max=50" example:"2"`   // Context lines after each match, overrides context (-A)
+	MaxCount   int    `form:"max_count" json:"max_count" binding:"min=0,max=1000" example:"20"`  // Matching lines returned per file, 0 for no limit (-m)
+	Limit      *int   `form:"limit" json:"limit" binding:"omitempty,min=0,max=200" example:"20"` // Maximum number of files
+	ArtifactFilterReq
 }
 
 type GlobArtifactsReq struct {
 	Query string `form:"query" json:"query" binding:"required" example:"*.py"`
 	Limit *int   `form:"limit" json:"limit" binding:"omitempty,min=0,max=200" example:"20"`
+	ArtifactFilterReq
 }
 
 // UpsertArtifact godoc
 //
 //	@Summary		Upsert artifact
-//	@Description	Upload a file and create or update an artifact record under a disk. File size must not exceed the configured maximum upload size limit (default: 16MB).
+//	@Description	Upload a file and create or update an artifact record under a disk. File size must not exceed the configured maximum upload size limit (default: 16MB). Send the ETag of the artifact in If-Match to only overwrite the version you have seen, or If-None-Match: * to only create it.
 //	@Tags			artifact
 //	@Accept			multipart/form-data
 //	@Produce		json
-//	@Param			disk_id		path		string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
-//	@Param			file_path	formData	string	false	"File path in the disk storage (optional, defaults to '/')"
-//	@Param			file		formData	file	true	"File to upload (size must not exceed configured limit)"
-//	@Param			meta		formData	string	false	"Custom metadata as JSON string (optional, system metadata will be stored under '__artifact_info__' key)"
+//	@Param			disk_id			path		string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			file_path		formData	string	false	"File path in the disk storage (optional, defaults to '/')"
+//	@Param			file			formData	file	true	"File to upload (size must not exceed configured limit)"
+//	@Param			meta			formData	string	false	"Custom metadata as JSON string (optional, system metadata will be stored under '__artifact_info__' key)"
+//	@Param			If-Match		header		string	false	"Only overwrite the artifact if it has one of these ETags"
+//	@Param			If-None-Match	header		string	false	"Only write if the artifact has none of these ETags; * to only create the artifact"
 //	@Security		BearerAuth
 //	@Success		201	{object}	serializer.Response{data=model.Artifact}
+//	@Header			201	{string}	ETag	"ETag of the written artifact"
+//	@Failure		412	{object}	serializer.Response	"The artifact does not match If-Match or If-None-Match"
 //	@Failure		413	{object}	serializer.Response	"File size exceeds maximum allowed size"
 //	@Router			/disk/{disk_id}/artifact [post]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Upload a file to disk\nwith open('report.pdf', 'rb') as f:\n    artifact = client.disks.upload_artifact(\n        disk_id='disk-uuid',\n        file=f,\n        file_path='/documents/',\n        meta={'category': 'reports', 'year': 2024}\n    )\nprint(f\"Uploaded artifact: {artifact.id}\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\nimport fs from 'fs';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Upload a file to disk\nconst fileBuffer = fs.readFileSync('report.pdf');\nconst artifact = await client.disks.uploadArtifact('disk-uuid', {\n  file: fileBuffer,\n  filePath: '/documents/',\n  meta: { category: 'reports', year: 2024 }\n});\nconsole.log(`Uploaded artifact: ${artifact.id}`);\n","label":"JavaScript"}]
@@ -127,18 +195,33 @@ func (h *ArtifactHandler) UpsertArtifact(c *gin.Context) {
 	}
 
 	artifactRecord, err := h.svc.Create(c.Request.Context(), service.CreateArtifactInput{
-		ProjectID:  project.ID,
-		DiskID:     diskID,
-		Path:       filePath,
-		Filename:   actualFilename,
-		FileHeader: file,
-		UserMeta:   userMeta,
+		ProjectID:    project.ID,
+		DiskID:       diskID,
+		Path:         filePath,
+		Filename:     actualFilename,
+		FileHeader:   file,
+		UserMeta:     userMeta,
+		TextLimit:    maxSize,
+		Precondition: precondition(c),
 	})
 	if err != nil {
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			c.JSON(http.StatusInsufficientStorage, serializer.Err(http.StatusInsufficientStorage, "storage quota exceeded", err))
+			return
+		}
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			c.JSON(http.StatusPreconditionFailed, serializer.Err(http.StatusPreconditionFailed, "artifact has changed", err))
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("disk not found", err))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
 		return
 	}
 
+	c.Header("ETag", artifactRecord.ETag())
 	c.JSON(http.StatusCreated, serializer.Response{Data: artifactRecord})
 }
 
@@ -149,14 +232,17 @@ type DeleteArtifactReq struct {
 // DeleteArtifact godoc
 //
 //	@Summary		Delete artifact
-//	@Description	Delete an artifact by path and filename
+//	@Description	Delete an artifact by path and filename. Send its ETag in If-Match to only delete the version you have seen.
 //	@Tags			artifact
 //	@Accept			json
 //	@Produce		json
-//	@Param			disk_id		path	string	true	"Disk ID"						Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
-//	@Param			file_path	query	string	true	"File path including filename"	example(/documents/report.pdf)
+//	@Param			disk_id			path	string	true	"Disk ID"						Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			file_path		query	string	true	"File path including filename"	example(/documents/report.pdf)
+//	@Param			If-Match		header	string	false	"Only delete the artifact if it has one of these ETags"
+//	@Param			If-None-Match	header	string	false	"Only delete the artifact if it has none of these ETags"
 //	@Security		BearerAuth
 //	@Success		200	{object}	serializer.Response{}
+//	@Failure		412	{object}	serializer.Response	"The artifact does not match If-Match or If-None-Match"
 //	@Router			/disk/{disk_id}/artifact [delete]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Delete an artifact\nclient.disks.delete_artifact(\n    disk_id='disk-uuid',\n    file_path='/documents/report.pdf'\n)\nprint('Artifact deleted successfully')\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Delete an artifact\nawait client.disks.deleteArtifact('disk-uuid', {\n  filePath: '/documents/report.pdf'\n});\nconsole.log('Artifact deleted successfully');\n","label":"JavaScript"}]
 func (h *ArtifactHandler) DeleteArtifact(c *gin.Context) {
@@ -187,7 +273,11 @@ func (h *ArtifactHandler) DeleteArtifact(c *gin.Context) {
 		return
 	}
 
-	if err := h.svc.DeleteByPath(c.Request.Context(), project.ID, diskID, filePath, filename); err != nil {
+	if err := h.svc.DeleteByPath(c.Request.Context(), project.ID, diskID, filePath, filename, precondition(c)); err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			c.JSON(http.StatusPreconditionFailed, serializer.Err(http.StatusPreconditionFailed, "artifact has changed", err))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
 		return
 	}
@@ -200,6 +290,18 @@ type GetArtifactReq struct {
 	WithPublicURL bool   `form:"with_public_url,default=true" json:"with_public_url" example:"true"`
 	WithContent   bool   `form:"with_content,default=true" json:"with_content" example:"true"`
 	Expire        int    `form:"expire,default=3600" json:"expire" example:"3600"` // Expire time in seconds for presigned URL
+	Offset        int    `form:"offset" json:"offset" binding:"min=0" example:"0"` // Number of content lines to skip
+	Limit         int    `form:"limit" json:"limit" binding:"min=0" example:"200"` // Maximum number of content lines to return, 0 returns all remaining lines
+	LineNumbers   bool   `form:"line_numbers" json:"line_numbers" example:"false"` // Whether to prefix each content line with its line number
+}
+
+// window reads the requested line window of the content
+func (r GetArtifactReq) window(content *fileparser.FileContent) *fileparser.FileContent {
+	w := fileparser.LineWindow{Offset: r.Offset, Limit: r.Limit, LineNumbers: r.LineNumbers}
+	if w.IsZero() {
+		return content
+	}
+	return content.Window(w)
 }
 
 type GetArtifactResp struct {
@@ -211,7 +313,7 @@ type GetArtifactResp struct {
 // GetArtifact godoc
 //
 //	@Summary		Get artifact
-//	@Description	Get artifact information by path and filename. Optionally include a presigned URL for downloading and parsed file content.
+//	@Description	Get artifact information by path and filename. Optionally include a presigned URL for downloading and parsed file content. Use offset and limit to read a window of lines from large text files; total_lines and has_more in the content tell how far to page. The ETag header identifies the version read, for If-Match on later writes.
 //	@Tags			artifact
 //	@Accept			json
 //	@Produce		json
@@ -220,8 +322,12 @@ type GetArtifactResp struct {
 //	@Param			with_public_url	query	boolean	false	"Whether to return public URL, default is true"				example(true)
 //	@Param			with_content	query	boolean	false	"Whether to return parsed file content, default is true"	example(true)
 //	@Param			expire			query	int		false	"Expire time in seconds for presigned URL (default: 3600)"	example(3600)
+//	@Param			offset			query	int		false	"Number of content lines to skip (default: 0)"				example(0)
+//	@Param			limit			query	int		false	"Maximum number of content lines to return (default: all)"	example(200)
+//	@Param			line_numbers	query	boolean	false	"Whether to prefix content lines with line numbers"		example(false)
 //	@Security		BearerAuth
 //	@Success		200	{object}	serializer.Response{data=handler.GetArtifactResp}
+//	@Header			200	{string}	ETag	"ETag of the artifact, taken from the SHA256 of its content and its version"
 //	@Router			/disk/{disk_id}/artifact [get]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Get artifact information\nartifact_info = client.disks.get_artifact(\n    disk_id='disk-uuid',\n    file_path='/documents/report.pdf',\n    with_public_url=True,\n    with_content=True,\n    expire=3600\n)\nprint(f\"Artifact: {artifact_info.artifact.filename}\")\nif artifact_info.public_url:\n    print(f\"Download URL: {artifact_info.public_url}\")\nif artifact_info.content:\n    print(f\"Content: {artifact_info.content.text[:100]}...\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Get artifact information\nconst artifactInfo = await client.disks.getArtifact('disk-uuid', {\n  filePath: '/documents/report.pdf',\n  withPublicUrl: true,\n  withContent: true,\n  expire: 3600\n});\nconsole.log(`Artifact: ${artifactInfo.artifact.filename}`);\nif (artifactInfo.publicUrl) {\n  console.log(`Download URL: ${artifactInfo.publicUrl}`);\n}\nif (artifactInfo.content) {\n  console.log(`Content: ${artifactInfo.content.text.substring(0, 100)}...`);\n}\n","label":"JavaScript"}]
 func (h *ArtifactHandler) GetArtifact(c *gin.Context) {
@@ -270,11 +376,12 @@ func (h *ArtifactHandler) GetArtifact(c *gin.Context) {
 		// Only set content if parsing succeeded
 		// Unsupported file types (images, binaries, etc.) will not have content
 		if err == nil && content != nil {
-			resp.Content = content
+			resp.Content = req.window(content)
 		}
 		// Don't return error for unsupported file types - just don't include content
 	}
 
+	c.Header("ETag", artifact.ETag())
 	c.JSON(http.StatusOK, serializer.Response{Data: resp})
 }
 
@@ -290,14 +397,18 @@ type UpdateArtifactResp struct {
 // UpdateArtifact godoc
 //
 //	@Summary		Update artifact meta
-//	@Description	Update an artifact's metadata (user-defined metadata only)
+//	@Description	Update an artifact's metadata (user-defined metadata only). Send its ETag in If-Match to only update the version you have seen.
 //	@Tags			artifact
 //	@Accept			json
 //	@Produce		json
-//	@Param			disk_id	path	string						true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
-//	@Param			request	body	handler.UpdateArtifactReq	true	"Update artifact request"
+//	@Param			disk_id			path	string						true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			request			body	handler.UpdateArtifactReq	true	"Update artifact request"
+//	@Param			If-Match		header	string						false	"Only update the artifact if it has one of these ETags"
+//	@Param			If-None-Match	header	string						false	"Only update the artifact if it has none of these ETags"
 //	@Security		BearerAuth
 //	@Success		200	{object}	serializer.Response{data=handler.UpdateArtifactResp}
+//	@Header			200	{string}	ETag	"ETag of the updated artifact"
+//	@Failure		412	{object}	serializer.Response	"The artifact does not match If-Match or If-None-Match"
 //	@Router			/disk/{disk_id}/artifact [put]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Update artifact metadata\nartifact = client.disks.update_artifact(\n    disk_id='disk-uuid',\n    file_path='/documents/report.pdf',\n    meta={'category': 'updated', 'reviewed': True, 'version': 2}\n)\nprint(f\"Updated artifact: {artifact.artifact.id}\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Update artifact metadata\nconst artifact = await client.disks.updateArtifact('disk-uuid', {\n  filePath: '/documents/report.pdf',\n  meta: { category: 'updated', reviewed: true, version: 2 }\n});\nconsole.log(`Updated artifact: ${artifact.artifact.id}`);\n","label":"JavaScript"}]
 func (h *ArtifactHandler) UpdateArtifact(c *gin.Context) {
@@ -339,295 +450,1223 @@ func (h *ArtifactHandler) UpdateArtifact(c *gin.Context) {
 	}
 
 	// Update artifact meta
-	artifactRecord, err := h.svc.UpdateArtifactMetaByPath(c.Request.Context(), diskID, filePath, filename, userMeta)
+	artifactRecord, err := h.svc.UpdateArtifactMetaByPath(c.Request.Context(), diskID, filePath, filename, userMeta, precondition(c))
 	if err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			c.JSON(http.StatusPreconditionFailed, serializer.Err(http.StatusPreconditionFailed, "artifact has changed", err))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
 		return
 	}
 
+	c.Header("ETag", artifactRecord.ETag())
 	c.JSON(http.StatusOK, serializer.Response{
 		Data: UpdateArtifactResp{Artifact: artifactRecord},
 	})
 }
 
-type ListArtifactsReq struct {
-	Path string `form:"path" json:"path"` // Optional path filter
+type ListArtifactVersionsReq struct {
+	FilePath string `form:"file_path" json:"file_path" binding:"required"` // File path including filename
 }
 
-type ListArtifactsResp struct {
-	Artifacts   []*model.Artifact `json:"artifacts"`
-	Directories []string          `json:"directories"`
+type ListArtifactVersionsResp struct {
+	CurrentVersion int                      `json:"current_version"`
+	Versions       []*model.ArtifactVersion `json:"versions"`
 }
 
-// ListArtifacts godoc
+// ListArtifactVersions godoc
 //
-//	@Summary		List artifacts
-//	@Description	List artifacts in a specific path or all artifacts in a disk
+//	@Summary		List artifact versions
+//	@Description	List the previous versions of an artifact, newest first. A version is kept each time the artifact is overwritten, its meta is updated or an older version is restored, up to the disk's version retention.
 //	@Tags			artifact
 //	@Accept			json
 //	@Produce		json
-//	@Param			disk_id	path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
-//	@Param			path	query	string	false	"Path filter (optional, defaults to root '/')"
+//	@Param			disk_id		path	string	true	"Disk ID"						Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			file_path	query	string	true	"File path including filename"	example(/documents/report.md)
 //	@Security		BearerAuth
-//	@Success		200	{object}	serializer.Response{data=handler.ListArtifactsResp}
-//	@Router			/disk/{disk_id}/artifact/ls [get]
-//	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# List artifacts in a path\nresult = client.disks.list_artifacts(\n    disk_id='disk-uuid',\n    path='/documents/'\n)\nprint(f\"Found {len(result.artifacts)} artifacts\")\nfor artifact in result.artifacts:\n    print(f\"  - {artifact.path}{artifact.filename}\")\nprint(f\"Subdirectories: {', '.join(result.directories)}\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// List artifacts in a path\nconst result = await client.disks.listArtifacts('disk-uuid', {\n  path: '/documents/'\n});\nconsole.log(`Found ${result.artifacts.length} artifacts`);\nfor (const artifact of result.artifacts) {\n  console.log(`  - ${artifact.path}${artifact.filename}`);\n}\nconsole.log(`Subdirectories: ${result.directories.join(', ')}`);\n","label":"JavaScript"}]
-func (h *ArtifactHandler) ListArtifacts(c *gin.Context) {
+//	@Success		200	{object}	serializer.Response{data=handler.ListArtifactVersionsResp}
+//	@Router			/disk/{disk_id}/artifact/versions [get]
+func (h *ArtifactHandler) ListArtifactVersions(c *gin.Context) {
+	req := ListArtifactVersionsReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
 	diskID, err := uuid.Parse(c.Param("disk_id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
 		return
 	}
 
-	pathQuery := c.Query("path")
+	filePath, filename := path.SplitFilePath(req.FilePath)
+	if err := path.ValidatePath(filePath); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid path", err))
+		return
+	}
 
-	// Set default path to root directory if not provided
-	if pathQuery == "" {
-		pathQuery = "/"
-	} else {
-		// Validate that path does not contain filename
-		if path, _ := path.SplitFilePath(pathQuery); path != pathQuery {
-			c.JSON(http.StatusBadRequest, serializer.ParamErr("both ends of the path must be '/'", errors.New("both ends of the path must be '/'")))
+	artifact, versions, err := h.svc.ListVersionsByPath(c.Request.Context(), diskID, filePath, filename)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("artifact not found", err))
 			return
 		}
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
 	}
 
-	// Validate the path parameter
-	if err := path.ValidatePath(pathQuery); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid path", err))
+	c.JSON(http.StatusOK, serializer.Response{Data: ListArtifactVersionsResp{
+		CurrentVersion: artifact.Version,
+		Versions:       versions,
+	}})
+}
+
+type GetArtifactVersionReq struct {
+	GetArtifactReq
+	Version int `form:"version" json:"version" binding:"required,min=1" example:"2"`
+}
+
+// GetArtifactVersion godoc
+//
+//	@Summary		Get artifact version
+//	@Description	Get an artifact as it was at the given version. Optionally include a presigned URL for downloading and parsed file content.
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id			path	string	true	"Disk ID"													Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			file_path		query	string	true	"File path including filename"								example(/documents/report.md)
+//	@Param			version			query	int		true	"Version number"											example(2)
+//	@Param			with_public_url	query	boolean	false	"Whether to return public URL, default is true"				example(true)
+//	@Param			with_content	query	boolean	false	"Whether to return parsed file content, default is true"	example(true)
+//	@Param			expire			query	int		false	"Expire time in seconds for presigned URL (default: 3600)"	example(3600)
+//	@Param			offset			query	int		false	"Number of content lines to skip (default: 0)"				example(0)
+//	@Param			limit			query	int		false	"Maximum number of content lines to return (default: all)"	example(200)
+//	@Param			line_numbers	query	boolean	false	"Whether to prefix content lines with line numbers"		example(false)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=handler.GetArtifactResp}
+//	@Router			/disk/{disk_id}/artifact/version [get]
+func (h *ArtifactHandler) GetArtifactVersion(c *gin.Context) {
+	req := GetArtifactVersionReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
 		return
 	}
 
-	artifacts, err := h.svc.ListByPath(c.Request.Context(), diskID, pathQuery)
+	diskID, err := uuid.Parse(c.Param("disk_id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	filePath, filename := path.SplitFilePath(req.FilePath)
+	if err := path.ValidatePath(filePath); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid path", err))
 		return
 	}
 
-	// Get all paths to extract directory names
-	allPaths, err := h.svc.GetAllPaths(c.Request.Context(), diskID)
+	artifact, err := h.svc.GetVersionByPath(c.Request.Context(), diskID, filePath, filename, req.Version)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("artifact version not found", err))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
 		return
 	}
 
-	// Extract direct subdirectories
-	directories := path.GetDirectoriesFromPaths(pathQuery, allPaths)
+	resp := GetArtifactResp{Artifact: artifact}
+
+	if req.WithPublicURL {
+		url, err := h.svc.GetPresignedURL(c.Request.Context(), artifact, time.Duration(req.Expire)*time.Second)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+			return
+		}
+		resp.PublicURL = &url
+	}
+
+	if req.WithContent {
+		// Unsupported file types (images, binaries, etc.) will not have content
+		if content, err := h.svc.GetFileContent(c.Request.Context(), artifact); err == nil && content != nil {
+			resp.Content = req.window(content)
+		}
+	}
 
-	c.JSON(http.StatusOK, serializer.Response{
-		Data: ListArtifactsResp{
-			Artifacts:   artifacts,
-			Directories: directories,
-		},
-	})
+	c.JSON(http.StatusOK, serializer.Response{Data: resp})
 }
 
-// GrepArtifacts godoc
+type DiffArtifactVersionsReq struct {
+	FilePath string `form:"file_path" json:"file_path" binding:"required"` // File path including filename
+	From     int    `form:"from" json:"from" binding:"required,min=1" example:"1"`
+	To       int    `form:"to" json:"to" binding:"omitempty,min=1" example:"2"` // Defaults to the current version
+}
+
+// DiffArtifactVersions godoc
 //
-//	@Summary		Search artifact content with regex
-//	@Description	Search through text-based artifact content using regex patterns
+//	@Summary		Diff artifact versions
+//	@Description	Get a unified diff between two versions of a text artifact
 //	@Tags			artifact
 //	@Accept			json
 //	@Produce		json
-//	@Param			disk_id	path	string	true	"Disk ID"	Format(uuid)
-//	@Param			query	query	string	true	"Regex pattern to search for"
-//	@Param			limit	query	int		false	"Maximum number of results (default 100, max 1000)"
+//	@Param			disk_id		path	string	true	"Disk ID"								Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			file_path	query	string	true	"File path including filename"			example(/documents/report.md)
+//	@Param			from		query	int		true	"Version to diff from"					example(1)
+//	@Param			to			query	int		false	"Version to diff to (default: current)"	example(2)
 //	@Security		BearerAuth
-//	@Success		200	{object}	serializer.Response{data=[]model.Artifact}
-//	@Router			/disk/{disk_id}/artifact/grep [get]
-func (h *ArtifactHandler) GrepArtifacts(c *gin.Context) {
-	project, ok := c.MustGet("project").(*model.Project)
-	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+//	@Success		200	{object}	serializer.Response{data=service.ArtifactDiff}
+//	@Router			/disk/{disk_id}/artifact/diff [get]
+func (h *ArtifactHandler) DiffArtifactVersions(c *gin.Context) {
+	req := DiffArtifactVersionsReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
 		return
 	}
 
 	diskID, err := uuid.Parse(c.Param("disk_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid disk_id", err))
-		return
-	}
-
-	req := GrepArtifactsReq{}
-	if err := c.ShouldBindQuery(&req); err != nil {
 		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
 		return
 	}
 
-	limit := 100
-	if req.Limit != nil {
-		limit = *req.Limit
+	filePath, filename := path.SplitFilePath(req.FilePath)
+	if err := path.ValidatePath(filePath); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid path", err))
+		return
 	}
 
-	artifacts, err := h.svc.GrepArtifacts(c.Request.Context(), project.ID, diskID, req.Query, limit)
+	diff, err := h.svc.DiffVersionsByPath(c.Request.Context(), diskID, filePath, filename, req.From, req.To)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("artifact version not found", err))
+			return
+		}
+		c.JSON(http.StatusBadRequest, serializer.DBErr("", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, serializer.Response{Data: artifacts})
+	c.JSON(http.StatusOK, serializer.Response{Data: diff})
 }
 
-// GlobArtifacts godoc
+type RestoreArtifactVersionReq struct {
+	FilePath string `form:"file_path" json:"file_path" binding:"required"` // File path including filename
+	Version  int    `form:"version" json:"version" binding:"required,min=1" example:"2"`
+}
+
+// RestoreArtifactVersion godoc
 //
-//	@Summary		Search artifact paths with glob patterns
-//	@Description	Search through artifact file paths using glob patterns (*, ?, etc.)
+//	@Summary		Restore artifact version
+//	@Description	Make a previous version the current content of an artifact. The replaced content is kept as a new version.
 //	@Tags			artifact
 //	@Accept			json
 //	@Produce		json
-//	@Param			disk_id	path	string	true	"Disk ID"	Format(uuid)
-//	@Param			query	query	string	true	"Glob pattern (e.g., '**/*.py', '*.txt')"*/
-//	@Param			limit	query	int		false	"Maximum number of results (default 100, max 1000)"
+//	@Param			disk_id	path	string								true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			request	body	handler.RestoreArtifactVersionReq	true	"Restore artifact version request"
 //	@Security		BearerAuth
-//	@Success		200	{object}	serializer.Response{data=[]model.Artifact}
-//	@Router			/disk/{disk_id}/artifact/glob [get]
-func (h *ArtifactHandler) GlobArtifacts(c *gin.Context) {
-	project, ok := c.MustGet("project").(*model.Project)
-	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+//	@Success		200	{object}	serializer.Response{data=handler.UpdateArtifactResp}
+//	@Router			/disk/{disk_id}/artifact/restore [post]
+func (h *ArtifactHandler) RestoreArtifactVersion(c *gin.Context) {
+	req := RestoreArtifactVersionReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
 		return
 	}
 
 	diskID, err := uuid.Parse(c.Param("disk_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid disk_id", err))
-		return
-	}
-
-	req := GlobArtifactsReq{}
-	if err := c.ShouldBindQuery(&req); err != nil {
 		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
 		return
 	}
 
-	limit := 100
-	if req.Limit != nil {
-		limit = *req.Limit
+	filePath, filename := path.SplitFilePath(req.FilePath)
+	if err := path.ValidatePath(filePath); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid path", err))
+		return
 	}
 
-	artifacts, err := h.svc.GlobArtifacts(c.Request.Context(), project.ID, diskID, req.Query, limit)
+	artifact, err := h.svc.RestoreVersionByPath(c.Request.Context(), diskID, filePath, filename, req.Version)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("artifact version not found", err))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, serializer.Response{Data: artifacts})
-}
-
-type DownloadToSandboxReq struct {
-	FilePath    string `json:"file_path" binding:"required"`    // File path (directory) of the artifact
-	Filename    string `json:"filename" binding:"required"`     // Filename of the artifact
-	SandboxID   string `json:"sandbox_id" binding:"required"`   // Target sandbox ID
-	SandboxPath string `json:"sandbox_path" binding:"required"` // Destination directory in the sandbox
+	c.JSON(http.StatusOK, serializer.Response{Data: UpdateArtifactResp{Artifact: artifact}})
 }
 
-type DownloadToSandboxResp struct {
-	Success bool `json:"success"`
+type EditArtifactReq struct {
+	FilePath   string `form:"file_path" json:"file_path" binding:"required" example:"/src/main.go"`                                                      // File path including filename
+	Command    string `form:"command" json:"command" binding:"required,oneof=str_replace insert_at_line delete_lines apply_patch" example:"str_replace"` // Edit to apply
+	OldStr     string `form:"old_str" json:"old_str" example:"fmt.Println(\"hi\")"`                                                                      // str_replace: text to replace, must occur exactly once
+	NewStr     string `form:"new_str" json:"new_str" example:"fmt.Println(\"hello\")"`                                                                   // str_replace: replacement text
+	InsertLine int    `form:"insert_line" json:"insert_line" binding:"min=0" example:"10"`                                                               // insert_at_line: line to insert after, 0 for the beginning
+	Text       string `form:"text" json:"text" example:"// TODO"`                                                                                        // insert_at_line: text to insert
+	StartLine  int    `form:"start_line" json:"start_line" binding:"min=0" example:"3"`                                                                  // delete_lines: first line to delete, 1-based
+	EndLine    int    `form:"end_line" json:"end_line" binding:"min=0" example:"5"`                                                                      // delete_lines: last line to delete, inclusive
+	Patch      string `form:"patch" json:"patch" example:"@@ -1,1 +1,1 @@\n-a\n+b\n"`                                                                    // apply_patch: unified diff of the file
 }
 
-// DownloadToSandbox godoc
+// EditArtifact godoc
 //
-//	@Summary		Download artifact to sandbox
-//	@Description	Download an artifact from disk storage to a sandbox environment
+//	@Summary		Edit artifact
+//	@Description	Edit a text file in place with str_replace, insert_at_line, delete_lines or apply_patch, without uploading it again. The previous content is kept as a version. Returns the changed lines with some context. Send the ETag of the artifact in If-Match to only edit the version you have seen; without it, an edit that races with another write is applied again to the new content.
 //	@Tags			artifact
 //	@Accept			json
 //	@Produce		json
-//	@Param			disk_id	path	string							true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
-//	@Param			request	body	handler.DownloadToSandboxReq	true	"Download to sandbox request"
+//	@Param			disk_id			path	string					true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			request			body	handler.EditArtifactReq	true	"Edit artifact request"
+//	@Param			If-Match		header	string					false	"Only edit the artifact if it has one of these ETags"
+//	@Param			If-None-Match	header	string					false	"Only edit the artifact if it has none of these ETags"
 //	@Security		BearerAuth
-//	@Success		200	{object}	serializer.Response{data=handler.DownloadToSandboxResp}
-//	@Router			/disk/{disk_id}/artifact/download_to_sandbox [post]
-//	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Download artifact to sandbox\nresult = client.disks.artifacts.download_to_sandbox(\n    disk_id='disk-uuid',\n    file_path='/documents/',\n    filename='report.pdf',\n    sandbox_id='sandbox-uuid',\n    sandbox_path='/home/user/'\n)\nprint(f\"Success: {result.success}\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Download artifact to sandbox\nconst result = await client.disks.artifacts.downloadToSandbox('disk-uuid', {\n  filePath: '/documents/',\n  filename: 'report.pdf',\n  sandboxId: 'sandbox-uuid',\n  sandboxPath: '/home/user/'\n});\nconsole.log(`Success: ${result.success}`);\n","label":"JavaScript"}]
-func (h *ArtifactHandler) DownloadToSandbox(c *gin.Context) {
+//	@Success		200	{object}	serializer.Response{data=service.EditArtifactOutput}
+//	@Header			200	{string}	ETag	"ETag of the edited artifact"
+//	@Failure		409	{object}	serializer.Response	"The artifact kept changing while the edit was applied"
+//	@Failure		412	{object}	serializer.Response	"The artifact does not match If-Match or If-None-Match"
+//	@Router			/disk/{disk_id}/artifact/edit [post]
+func (h *ArtifactHandler) EditArtifact(c *gin.Context) {
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
 		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
 		return
 	}
 
-	diskID, err := uuid.Parse(c.Param("disk_id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid disk_id", err))
-		return
-	}
-
-	req := DownloadToSandboxReq{}
-	if err := c.ShouldBindJSON(&req); err != nil {
+	req := EditArtifactReq{}
+	if err := c.ShouldBind(&req); err != nil {
 		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
 		return
 	}
 
-	// Parse sandbox ID
-	sandboxID, err := uuid.Parse(req.SandboxID)
+	diskID, err := uuid.Parse(c.Param("disk_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid sandbox_id", err))
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
 		return
 	}
 
-	// Validate and normalize the file path
-	filePath := req.FilePath
+	filePath, filename := path.SplitFilePath(req.FilePath)
 	if err := path.ValidatePath(filePath); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid file_path", err))
-		return
-	}
-
-	// Get the artifact to retrieve its S3 key
-	artifact, err := h.svc.GetByPath(c.Request.Context(), diskID, filePath, req.Filename)
-	if err != nil {
-		c.JSON(http.StatusNotFound, serializer.DBErr("artifact not found", err))
-		return
-	}
-
-	// Get the S3 key from artifact's asset metadata
-	assetData := artifact.AssetMeta.Data()
-	if assetData.S3Key == "" {
-		c.JSON(http.StatusInternalServerError, serializer.Err(http.StatusInternalServerError, "artifact has no S3 key", nil))
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid path", err))
 		return
 	}
 
-	// Build the destination path in sandbox: sandbox_path + "/" + filename
-	sandboxDestPath := strings.TrimSuffix(req.SandboxPath, "/") + "/" + req.Filename
-
-	// Upload the file from S3 to the sandbox
-	result, err := h.coreClient.UploadSandboxFile(c.Request.Context(), project.ID, sandboxID, assetData.S3Key, sandboxDestPath)
+	out, err := h.svc.EditArtifact(c.Request.Context(), service.EditArtifactInput{
+		ProjectID:    project.ID,
+		DiskID:       diskID,
+		Path:         filePath,
+		Filename:     filename,
+		Command:      req.Command,
+		OldStr:       req.OldStr,
+		NewStr:       req.NewStr,
+		InsertLine:   req.InsertLine,
+		Text:         req.Text,
+		StartLine:    req.StartLine,
+		EndLine:      req.EndLine,
+		Patch:        req.Patch,
+		Precondition: precondition(c),
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.Err(http.StatusInternalServerError, "failed to download artifact to sandbox", err))
+		switch {
+		case errors.Is(err, service.ErrPreconditionFailed):
+			c.JSON(http.StatusPreconditionFailed, serializer.Err(http.StatusPreconditionFailed, "artifact has changed", err))
+		case errors.Is(err, service.ErrEditConflict):
+			c.JSON(http.StatusConflict, serializer.Err(http.StatusConflict, "artifact is being changed concurrently", err))
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, serializer.DBErr("artifact not found", err))
+		case errors.Is(err, service.ErrInvalidEdit), errors.Is(err, service.ErrNotEditable):
+			c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		case errors.Is(err, service.ErrQuotaExceeded):
+			c.JSON(http.StatusInsufficientStorage, serializer.Err(http.StatusInsufficientStorage, "storage quota exceeded", err))
+		default:
+			c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, serializer.Response{Data: DownloadToSandboxResp{Success: result.Success}})
+	c.Header("ETag", out.Artifact.ETag())
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
 }
 
-type UploadFromSandboxReq struct {
-	SandboxID       string `json:"sandbox_id" binding:"required"`       // Source sandbox ID
-	SandboxPath     string `json:"sandbox_path" binding:"required"`     // Source directory in the sandbox
-	SandboxFilename string `json:"sandbox_filename" binding:"required"` // Filename in the sandbox
-	FilePath        string `json:"file_path" binding:"required"`        // Destination directory path on the disk
+type CreateUploadURLReq struct {
+	FilePath string `form:"file_path" json:"file_path" binding:"required" example:"/datasets/train.parquet"`                                    // File path including filename
+	Size     int64  `form:"size" json:"size" binding:"min=0" example:"1073741824"`                                                              // Size of the file in bytes
+	SHA256   string `form:"sha256" json:"sha256" binding:"required" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"` // Hex SHA256 of the file
+	Meta     string `form:"meta" json:"meta"`                                                                                                   // Custom metadata as JSON string
+	Expire   int    `form:"expire" json:"expire" binding:"omitempty,min=60,max=604800" example:"900"`                                           // Expire time in seconds for the upload URL, defaults to the configured presign expiry
 }
 
-// UploadFromSandbox godoc
+// CreateUploadURL godoc
 //
-//	@Summary		Upload file from sandbox to disk
-//	@Description	Upload a file from a sandbox environment to disk storage as an artifact
+//	@Summary		Create artifact upload URL
+//	@Description	Start a direct upload of a large file to storage. PUT the file to the returned upload_url with the returned headers, then call finalize with the upload_id to create the artifact. The file is not sent through the API, so it can be up to the configured maximum direct upload size (default: 5GB).
 //	@Tags			artifact
 //	@Accept			json
 //	@Produce		json
-//	@Param			disk_id	path	string							true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
-//	@Param			request	body	handler.UploadFromSandboxReq	true	"Upload from sandbox request"
+//	@Param			disk_id	path	string						true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			request	body	handler.CreateUploadURLReq	true	"Create upload URL request"
 //	@Security		BearerAuth
-//	@Success		200	{object}	serializer.Response{data=model.Artifact}
-//	@Router			/disk/{disk_id}/artifact/upload_from_sandbox [post]
-//	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Upload file from sandbox to disk\nartifact = client.disks.artifacts.upload_from_sandbox(\n    disk_id='disk-uuid',\n    sandbox_id='sandbox-uuid',\n    sandbox_path='/home/user/',\n    sandbox_filename='output.txt',\n    file_path='/results/'\n)\nprint(f\"Created: {artifact.path}{artifact.filename}\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Upload file from sandbox to disk\nconst artifact = await client.disks.artifacts.uploadFromSandbox('disk-uuid', {\n  sandboxId: 'sandbox-uuid',\n  sandboxPath: '/home/user/',\n  sandboxFilename: 'output.txt',\n  filePath: '/results/'\n});\nconsole.log(`Created: ${artifact.path}${artifact.filename}`);\n","label":"JavaScript"}]
-func (h *ArtifactHandler) UploadFromSandbox(c *gin.Context) {
+//	@Success		201	{object}	serializer.Response{data=service.CreateUploadURLOutput}
+//	@Failure		413	{object}	serializer.Response	"File size exceeds maximum allowed size"
+//	@Failure		507	{object}	serializer.Response	"Storage quota exceeded"
+//	@Router			/disk/{disk_id}/artifact/upload_url [post]
+func (h *ArtifactHandler) CreateUploadURL(c *gin.Context) {
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
 		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
 		return
 	}
 
-	diskID, err := uuid.Parse(c.Param("disk_id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid disk_id", err))
+	req := CreateUploadURLReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	filePath, filename := path.SplitFilePath(req.FilePath)
+	if filename == "" {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("file_path must include a filename")))
+		return
+	}
+	if err := path.ValidatePath(filePath); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid path", err))
+		return
+	}
+
+	var userMeta map[string]interface{}
+	if req.Meta != "" {
+		if err := sonic.Unmarshal([]byte(req.Meta), &userMeta); err != nil {
+			c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid meta JSON format", err))
+			return
+		}
+		for _, reservedKey := range (model.Artifact{}).GetReservedKeys() {
+			if _, exists := userMeta[reservedKey]; exists {
+				c.JSON(http.StatusBadRequest, serializer.ParamErr("", fmt.Errorf("reserved key '%s' is not allowed in user meta", reservedKey)))
+				return
+			}
+		}
+	}
+
+	expire := time.Duration(req.Expire) * time.Second
+	if expire == 0 {
+		expire = 15 * time.Minute
+		if h.config.S3.PresignExpireSec > 0 {
+			expire = time.Duration(h.config.S3.PresignExpireSec) * time.Second
+		}
+	}
+
+	out, err := h.svc.CreateUploadURL(c.Request.Context(), service.CreateUploadURLInput{
+		ProjectID: project.ID,
+		DiskID:    diskID,
+		Path:      filePath,
+		Filename:  filename,
+		SizeB:     req.Size,
+		SHA256:    req.SHA256,
+		UserMeta:  userMeta,
+		MaxSizeB:  h.config.Artifact.MaxDirectUploadSizeBytes,
+		Expire:    expire,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, serializer.DBErr("disk not found", err))
+		case errors.Is(err, service.ErrInvalidUpload):
+			c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		case errors.Is(err, service.ErrUploadTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, serializer.ParamErr("", err))
+		case errors.Is(err, service.ErrQuotaExceeded):
+			c.JSON(http.StatusInsufficientStorage, serializer.Err(http.StatusInsufficientStorage, "storage quota exceeded", err))
+		default:
+			c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: out})
+}
+
+type FinalizeUploadReq struct {
+	UploadID string `form:"upload_id" json:"upload_id" binding:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"` // Upload ID returned by upload_url
+}
+
+// FinalizeUpload godoc
+//
+//	@Summary		Finalize artifact upload
+//	@Description	Finish a direct upload started with upload_url. The uploaded file must match the declared size and SHA256. Creates the artifact, or a new version of it if the file already exists.
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string						true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			request	body	handler.FinalizeUploadReq	true	"Finalize upload request"
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=model.Artifact}
+//	@Failure		410	{object}	serializer.Response	"Upload expired"
+//	@Failure		507	{object}	serializer.Response	"Storage quota exceeded"
+//	@Router			/disk/{disk_id}/artifact/finalize [post]
+func (h *ArtifactHandler) FinalizeUpload(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	req := FinalizeUploadReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	artifact, err := h.svc.FinalizeUpload(c.Request.Context(), service.FinalizeUploadInput{
+		ProjectID: project.ID,
+		DiskID:    diskID,
+		UploadID:  uuid.MustParse(req.UploadID),
+		TextLimit: h.config.Artifact.MaxUploadSizeBytes,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, serializer.DBErr("upload not found", err))
+		case errors.Is(err, service.ErrInvalidUpload):
+			c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		case errors.Is(err, service.ErrUploadExpired):
+			c.JSON(http.StatusGone, serializer.Err(http.StatusGone, "upload expired", err))
+		case errors.Is(err, service.ErrQuotaExceeded):
+			c.JSON(http.StatusInsufficientStorage, serializer.Err(http.StatusInsufficientStorage, "storage quota exceeded", err))
+		default:
+			c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: artifact})
+}
+
+type TransferArtifactsReq struct {
+	Source      string `form:"source" json:"source" binding:"required" example:"/docs/report.md"`                             // File path, or a directory ending with /
+	Destination string `form:"destination" json:"destination" binding:"required" example:"/archive/"`                         // File path, or a directory ending with /
+	OnConflict  string `form:"on_conflict" json:"on_conflict" binding:"omitempty,oneof=error skip overwrite" example:"error"` // What to do when the destination exists, defaults to error
+}
+
+// MoveArtifacts godoc
+//
+//	@Summary		Move artifacts
+//	@Description	Move or rename a file, or a directory with everything below it. A destination ending with / keeps the filename. Versions move with their artifact.
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string							true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			request	body	handler.TransferArtifactsReq	true	"Move artifacts request"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.TransferArtifactsOutput}
+//	@Router			/disk/{disk_id}/artifact/move [post]
+func (h *ArtifactHandler) MoveArtifacts(c *gin.Context) {
+	h.transferArtifacts(c, h.svc.MoveArtifacts)
+}
+
+// CopyArtifacts godoc
+//
+//	@Summary		Copy artifacts
+//	@Description	Copy a file, or a directory with everything below it. A destination ending with / keeps the filename. Copies share the stored file and start without version history.
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string							true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			request	body	handler.TransferArtifactsReq	true	"Copy artifacts request"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.TransferArtifactsOutput}
+//	@Router			/disk/{disk_id}/artifact/copy [post]
+func (h *ArtifactHandler) CopyArtifacts(c *gin.Context) {
+	h.transferArtifacts(c, h.svc.CopyArtifacts)
+}
+
+func (h *ArtifactHandler) transferArtifacts(c *gin.Context, transfer func(context.Context, service.TransferArtifactsInput) (*service.TransferArtifactsOutput, error)) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	req := TransferArtifactsReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	out, err := transfer(c.Request.Context(), service.TransferArtifactsInput{
+		ProjectID:   project.ID,
+		DiskID:      diskID,
+		Source:      req.Source,
+		Destination: req.Destination,
+		OnConflict:  req.OnConflict,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, serializer.DBErr("artifact not found", err))
+		case errors.Is(err, service.ErrArtifactConflict):
+			c.JSON(http.StatusConflict, serializer.Err(http.StatusConflict, "destination already exists", err))
+		case errors.Is(err, service.ErrInvalidTransfer):
+			c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		default:
+			c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
+
+type ArchiveArtifactsReq struct {
+	Path   string `form:"path" json:"path" example:"/out/"`                                                    // Directory to archive, defaults to the disk root
+	Format string `form:"format,default=zip" json:"format" binding:"omitempty,oneof=zip tar.gz" example:"zip"` // zip (default) or tar.gz
+}
+
+// ArchiveArtifacts godoc
+//
+//	@Summary		Download directory archive
+//	@Description	Stream a zip or tar.gz archive of a directory with everything below it. Paths in the archive are relative to the directory.
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		application/zip
+//	@Produce		application/gzip
+//	@Param			disk_id	path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			path	query	string	false	"Directory to archive, defaults to the disk root"	example(/out/)
+//	@Param			format	query	string	false	"Archive format, zip (default) or tar.gz"	Enums(zip, tar.gz)
+//	@Security		BearerAuth
+//	@Success		200	{file}	binary
+//	@Router			/disk/{disk_id}/artifact/archive [get]
+func (h *ArtifactHandler) ArchiveArtifacts(c *gin.Context) {
+	req := ArchiveArtifactsReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	out, err := h.svc.ArchiveArtifacts(c.Request.Context(), service.ArchiveArtifactsInput{
+		DiskID: diskID,
+		Path:   req.Path,
+		Format: req.Format,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, serializer.DBErr("no artifacts found under path", err))
+		case errors.Is(err, service.ErrInvalidArchive):
+			c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		default:
+			c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		}
+		return
+	}
+
+	c.Header("Content-Type", out.ContentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", out.Filename))
+	c.Status(http.StatusOK)
+	if err := out.WriteTo(c.Request.Context(), c.Writer); err != nil {
+		// Headers are already sent, so the client sees a truncated archive
+		_ = c.Error(err)
+		c.Abort()
+	}
+}
+
+type ExtractArchiveReq struct {
+	Path string `form:"path" json:"path" example:"/project/"` // Directory to extract into, defaults to the disk root
+}
+
+// ExtractArchive godoc
+//
+//	@Summary		Extract archive
+//	@Description	Upload a zip or tar.gz archive and unpack its files into a directory. Existing files get a new version. Either every file is written or none is. Archives with unsafe paths are rejected, and so are archives expanding beyond the configured size or file count.
+//	@Tags			artifact
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			disk_id	path		string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			path	formData	string	false	"Directory to extract into, defaults to the disk root"	example(/project/)
+//	@Param			file	formData	file	true	"Archive ending in .zip, .tar.gz or .tgz"
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=service.ExtractArchiveOutput}
+//	@Router			/disk/{disk_id}/artifact/extract [post]
+func (h *ArtifactHandler) ExtractArchive(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	req := ExtractArchiveReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("file is required", err))
+		return
+	}
+
+	maxSize := h.config.Artifact.MaxUploadSizeBytes
+	if file.Size > maxSize {
+		maxSizeMB := float64(maxSize) / (1024 * 1024)
+		c.JSON(http.StatusRequestEntityTooLarge, serializer.ParamErr("", fmt.Errorf("file size exceeds maximum allowed size of %.2fMB", maxSizeMB)))
+		return
+	}
+
+	out, err := h.svc.ExtractArchive(c.Request.Context(), service.ExtractArchiveInput{
+		ProjectID: project.ID,
+		DiskID:    diskID,
+		Path:      req.Path,
+		Archive:   file,
+		Limits: archive.Limits{
+			MaxBytes: h.config.Artifact.MaxExtractSizeBytes,
+			MaxFiles: h.config.Artifact.MaxExtractFiles,
+		},
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrArchiveTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, serializer.Err(http.StatusRequestEntityTooLarge, "archive is too large", err))
+		case errors.Is(err, service.ErrQuotaExceeded):
+			c.JSON(http.StatusInsufficientStorage, serializer.Err(http.StatusInsufficientStorage, "storage quota exceeded", err))
+		case errors.Is(err, service.ErrInvalidArchive):
+			c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, serializer.DBErr("disk not found", err))
+		default:
+			c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: out})
+}
+
+type ListArtifactsReq struct {
+	Path   string `form:"path" json:"path"`                                                       // Optional path filter
+	Limit  int    `form:"limit,default=1000" json:"limit" binding:"min=1,max=1000" example:"100"` // Maximum number of artifacts per page
+	Cursor string `form:"cursor" json:"cursor" example:"cHJvdGVjdGVkIGN1cnNvciBleGFtcGxl"`        // Cursor from the previous page
+	ArtifactFilterReq
+}
+
+type ListArtifactsResp struct {
+	Artifacts   []*model.Artifact `json:"artifacts"`
+	Directories []string          `json:"directories"` // Only returned with the first page
+	NextCursor  string            `json:"next_cursor,omitempty"`
+	HasMore     bool              `json:"has_more"`
+}
+
+// ListArtifacts godoc
+//
+//	@Summary		List artifacts
+//	@Description	List the artifacts in a directory, oldest first, with its subdirectories. Artifacts can be filtered by user meta, MIME type, size and modification time. Pages hold up to limit artifacts; pass next_cursor to get the next one.
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id			path	string		true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			path			query	string		false	"Path filter (optional, defaults to root '/')"
+//	@Param			limit			query	int			false	"Maximum number of artifacts per page (default 1000, max 1000)"
+//	@Param			cursor			query	string		false	"Cursor from the previous page"
+//	@Param			meta			query	string		false	"JSON object the user meta must contain"	example({"status":"reviewed"})
+//	@Param			meta_key		query	[]string	false	"Keys the user meta must have, whatever their value"	collectionFormat(multi)
+//	@Param			mime			query	string		false	"Exact MIME type, or a family such as text/*"	example(text/*)
+//	@Param			min_size		query	int			false	"Minimum size in bytes"
+//	@Param			max_size		query	int			false	"Maximum size in bytes"
+//	@Param			updated_after	query	string		false	"Only artifacts updated after this RFC 3339 time"	example(2025-01-01T00:00:00Z)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=handler.ListArtifactsResp}
+//	@Router			/disk/{disk_id}/artifact/ls [get]
+//	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# List artifacts in a path\nresult = client.disks.list_artifacts(\n    disk_id='disk-uuid',\n    path='/documents/'\n)\nprint(f\"Found {len(result.artifacts)} artifacts\")\nfor artifact in result.artifacts:\n    print(f\"  - {artifact.path}{artifact.filename}\")\nprint(f\"Subdirectories: {', '.join(result.directories)}\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// List artifacts in a path\nconst result = await client.disks.listArtifacts('disk-uuid', {\n  path: '/documents/'\n});\nconsole.log(`Found ${result.artifacts.length} artifacts`);\nfor (const artifact of result.artifacts) {\n  console.log(`  - ${artifact.path}${artifact.filename}`);\n}\nconsole.log(`Subdirectories: ${result.directories.join(', ')}`);\n","label":"JavaScript"}]
+func (h *ArtifactHandler) ListArtifacts(c *gin.Context) {
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	req := ListArtifactsReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+	filter, err := req.filter()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	pathQuery := req.Path
+
+	// Set default path to root directory if not provided
+	if pathQuery == "" {
+		pathQuery = "/"
+	} else {
+		// Validate that path does not contain filename
+		if path, _ := path.SplitFilePath(pathQuery); path != pathQuery {
+			c.JSON(http.StatusBadRequest, serializer.ParamErr("both ends of the path must be '/'", errors.New("both ends of the path must be '/'")))
+			return
+		}
+	}
+
+	// Validate the path parameter
+	if err := path.ValidatePath(pathQuery); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid path", err))
+		return
+	}
+
+	page, err := h.svc.ListArtifacts(c.Request.Context(), service.ListArtifactsInput{
+		DiskID: diskID,
+		Path:   pathQuery,
+		Filter: filter,
+		Limit:  req.Limit,
+		Cursor: req.Cursor,
+	})
+	if err != nil {
+		searchErr(c, err)
+		return
+	}
+
+	resp := ListArtifactsResp{
+		Artifacts:   page.Items,
+		Directories: []string{},
+		NextCursor:  page.NextCursor,
+		HasMore:     page.HasMore,
+	}
+
+	// Directories are listed once, with the first page
+	if req.Cursor == "" {
+		// Get all paths to extract directory names
+		allPaths, err := h.svc.GetAllPaths(c.Request.Context(), diskID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+			return
+		}
+
+		// Extract direct subdirectories
+		resp.Directories = path.GetDirectoriesFromPaths(pathQuery, allPaths)
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: resp})
+}
+
+// GrepArtifacts godoc
+//
+//	@Summary		Search artifact content with regex
+//	@Description	Search through text-based artifact content using regex patterns, including the text extracted from PDF and Office documents. Artifacts can be filtered by user meta, MIME type, size and modification time.
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id			path	string		true	"Disk ID"	Format(uuid)
+//	@Param			query			query	string		true	"Regex pattern to search for"
+//	@Param			limit			query	int			false	"Maximum number of results (default 100, max 1000)"
+//	@Param			meta			query	string		false	"JSON object the user meta must contain"	example({"status":"reviewed"})
+//	@Param			meta_key		query	[]string	false	"Keys the user meta must have, whatever their value"	collectionFormat(multi)
+//	@Param			mime			query	string		false	"Exact MIME type, or a family such as text/*"	example(text/*)
+//	@Param			min_size		query	int			false	"Minimum size in bytes"
+//	@Param			max_size		query	int			false	"Maximum size in bytes"
+//	@Param			updated_after	query	string		false	"Only artifacts updated after this RFC 3339 time"	example(2025-01-01T00:00:00Z)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=[]model.Artifact}
+//	@Router			/disk/{disk_id}/artifact/grep [get]
+func (h *ArtifactHandler) GrepArtifacts(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid disk_id", err))
+		return
+	}
+
+	req := GrepArtifactsReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	filter, err := req.filter()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	limit := 100
+	if req.Limit != nil {
+		limit = *req.Limit
+	}
+
+	artifacts, err := h.svc.GrepArtifacts(c.Request.Context(), project.ID, diskID, req.Query, filter, limit)
+	if err != nil {
+		searchErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: artifacts})
+}
+
+// GrepArtifactMatches godoc
+//
+//	@Summary		Search artifact content lines with regex
+//	@Description	Search through text-based artifact content like ripgrep, including the text extracted from PDF and Office documents. Returns the matching lines with their line numbers and optional context for each file, the total number of matching lines, and the same result rendered as grep output (path:line:text for matches, path-line-text for context).
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id			path	string		true	"Disk ID"	Format(uuid)
+//	@Param			query			query	string		true	"Regex pattern to search for"
+//	@Param			ignore_case		query	boolean		false	"Match case-insensitively"
+//	@Param			glob			query	string		false	"Only search files whose full path matches this glob (e.g., '/src/**/*.py')"
+//	@Param			context			query	int			false	"Context lines before and after each match (max 50)"
+//	@Param			before			query	int			false	"Context lines before each match, overrides context (max 50)"
+//	@Param			after			query	int			false	"Context lines after each match, overrides context (max 50)"
+//	@Param			max_count		query	int			false	"Maximum matching lines returned per file (default no limit)"
+//	@Param			limit			query	int			false	"Maximum number of files (default 100, max 200)"
+//	@Param			meta			query	string		false	"JSON object the user meta must contain"	example({"status":"reviewed"})
+//	@Param			meta_key		query	[]string	false	"Keys the user meta must have, whatever their value"	collectionFormat(multi)
+//	@Param			mime			query	string		false	"Exact MIME type, or a family such as text/*"	example(text/*)
+//	@Param			min_size		query	int			false	"Minimum size in bytes"
+//	@Param			max_size		query	int			false	"Maximum size in bytes"
+//	@Param			updated_after	query	string		false	"Only artifacts updated after this RFC 3339 time"	example(2025-01-01T00:00:00Z)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.GrepArtifactMatchesOutput}
+//	@Router			/disk/{disk_id}/artifact/grep/matches [get]
+func (h *ArtifactHandler) GrepArtifactMatches(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid disk_id", err))
+		return
+	}
+
+	req := GrepArtifactMatchesReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+	if _, err := regexp.Compile(req.Query); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid query", err))
+		return
+	}
+	filter, err := req.filter()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	in := service.GrepArtifactMatchesInput{
+		ProjectID:  project.ID,
+		DiskID:     diskID,
+		Pattern:    req.Query,
+		IgnoreCase: req.IgnoreCase,
+		PathGlob:   req.Glob,
+		Filter:     filter,
+		Before:     req.Context,
+		After:      req.Context,
+		MaxCount:   req.MaxCount,
+		Limit:      100,
+	}
+	if req.Before != nil {
+		in.Before = *req.Before
+	}
+	if req.After != nil {
+		in.After = *req.After
+	}
+	if req.Limit != nil {
+		in.Limit = *req.Limit
+	}
+
+	out, err := h.svc.GrepArtifactMatches(c.Request.Context(), in)
+	if err != nil {
+		searchErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
+
+// GlobArtifacts godoc
+//
+//	@Summary		Search artifact paths with glob patterns
+//	@Description	Search artifact file paths with a glob pattern matched against the full path. * and ? stay within one directory, ** crosses directories, {a,b} matches either alternative and [abc], [a-z], [!abc] match one character. Patterns without a leading / start at the root. Results are sorted by path.
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id			path	string		true	"Disk ID"	Format(uuid)
+//	@Param			query			query	string		true	"Glob pattern (e.g., '**/*.py', '*.txt', '/docs/**/*.{md,txt}')"
+//	@Param			limit			query	int			false	"Maximum number of results (default 100, max 1000)"
+//	@Param			meta			query	string		false	"JSON object the user meta must contain"	example({"status":"reviewed"})
+//	@Param			meta_key		query	[]string	false	"Keys the user meta must have, whatever their value"	collectionFormat(multi)
+//	@Param			mime			query	string		false	"Exact MIME type, or a family such as text/*"	example(text/*)
+//	@Param			min_size		query	int			false	"Minimum size in bytes"
+//	@Param			max_size		query	int			false	"Maximum size in bytes"
+//	@Param			updated_after	query	string		false	"Only artifacts updated after this RFC 3339 time"	example(2025-01-01T00:00:00Z)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=[]model.Artifact}
+//	@Router			/disk/{disk_id}/artifact/glob [get]
+func (h *ArtifactHandler) GlobArtifacts(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid disk_id", err))
+		return
+	}
+
+	req := GlobArtifactsReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+	if _, err := path.CompileGlob(req.Query); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid query", err))
+		return
+	}
+
+	filter, err := req.filter()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	limit := 100
+	if req.Limit != nil {
+		limit = *req.Limit
+	}
+
+	artifacts, err := h.svc.GlobArtifacts(c.Request.Context(), project.ID, diskID, req.Query, filter, limit)
+	if err != nil {
+		searchErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: artifacts})
+}
+
+// GlobArtifactEntries godoc
+//
+//	@Summary		Search artifact and directory paths with glob patterns
+//	@Description	Like the glob search, but also returns the directories matching the pattern as full paths ending with /, so 'src/*' lists both the files and the subdirectories of /src/. Both lists are sorted by path.
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id			path	string		true	"Disk ID"	Format(uuid)
+//	@Param			query			query	string		true	"Glob pattern (e.g., '/src/*', '/docs/**')"
+//	@Param			limit			query	int			false	"Maximum number of artifacts and of directories (default 100)"
+//	@Param			meta			query	string		false	"JSON object the user meta must contain"	example({"status":"reviewed"})
+//	@Param			meta_key		query	[]string	false	"Keys the user meta must have, whatever their value"	collectionFormat(multi)
+//	@Param			mime			query	string		false	"Exact MIME type, or a family such as text/*"	example(text/*)
+//	@Param			min_size		query	int			false	"Minimum size in bytes"
+//	@Param			max_size		query	int			false	"Maximum size in bytes"
+//	@Param			updated_after	query	string		false	"Only artifacts updated after this RFC 3339 time"	example(2025-01-01T00:00:00Z)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.GlobEntries}
+//	@Router			/disk/{disk_id}/artifact/glob/entries [get]
+func (h *ArtifactHandler) GlobArtifactEntries(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid disk_id", err))
+		return
+	}
+
+	req := GlobArtifactsReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+	if _, err := path.CompileGlob(req.Query); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid query", err))
+		return
+	}
+
+	filter, err := req.filter()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	limit := 100
+	if req.Limit != nil {
+		limit = *req.Limit
+	}
+
+	entries, err := h.svc.GlobEntries(c.Request.Context(), project.ID, diskID, req.Query, filter, limit)
+	if err != nil {
+		searchErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: entries})
+}
+
+// searchErr maps the errors of listing and search endpoints
+func searchErr(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidFilter) {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+}
+
+type DownloadToSandboxReq struct {
+	FilePath    string `json:"file_path" binding:"required"`    // File path (directory) of the artifact
+	Filename    string `json:"filename" binding:"required"`     // Filename of the artifact
+	SandboxID   string `json:"sandbox_id" binding:"required"`   // Target sandbox ID
+	SandboxPath string `json:"sandbox_path" binding:"required"` // Destination directory in the sandbox
+}
+
+type DownloadToSandboxResp struct {
+	Success bool `json:"success"`
+}
+
+// DownloadToSandbox godoc
+//
+//	@Summary		Download artifact to sandbox
+//	@Description	Download an artifact from disk storage to a sandbox environment
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string							true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			request	body	handler.DownloadToSandboxReq	true	"Download to sandbox request"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=handler.DownloadToSandboxResp}
+//	@Router			/disk/{disk_id}/artifact/download_to_sandbox [post]
+//	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Download artifact to sandbox\nresult = client.disks.artifacts.download_to_sandbox(\n    disk_id='disk-uuid',\n    file_path='/documents/',\n    filename='report.pdf',\n    sandbox_id='sandbox-uuid',\n    sandbox_path='/home/user/'\n)\nprint(f\"Success: {result.success}\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Download artifact to sandbox\nconst result = await client.disks.artifacts.downloadToSandbox('disk-uuid', {\n  filePath: '/documents/',\n  filename: 'report.pdf',\n  sandboxId: 'sandbox-uuid',\n  sandboxPath: '/home/user/'\n});\nconsole.log(`Success: ${result.success}`);\n","label":"JavaScript"}]
+func (h *ArtifactHandler) DownloadToSandbox(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid disk_id", err))
+		return
+	}
+
+	req := DownloadToSandboxReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	// Parse sandbox ID
+	sandboxID, err := uuid.Parse(req.SandboxID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid sandbox_id", err))
+		return
+	}
+
+	// Validate and normalize the file path
+	filePath := req.FilePath
+	if err := path.ValidatePath(filePath); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid file_path", err))
+		return
+	}
+
+	// Get the artifact to retrieve its S3 key
+	artifact, err := h.svc.GetByPath(c.Request.Context(), diskID, filePath, req.Filename)
+	if err != nil {
+		c.JSON(http.StatusNotFound, serializer.DBErr("artifact not found", err))
+		return
+	}
+
+	// Get the S3 key from artifact's asset metadata
+	assetData := artifact.AssetMeta.Data()
+	if assetData.S3Key == "" {
+		c.JSON(http.StatusInternalServerError, serializer.Err(http.StatusInternalServerError, "artifact has no S3 key", nil))
+		return
+	}
+
+	// Build the destination path in sandbox: sandbox_path + "/" + filename
+	sandboxDestPath := strings.TrimSuffix(req.SandboxPath, "/") + "/" + req.Filename
+
+	// Upload the file from S3 to the sandbox
+	result, err := h.coreClient.UploadSandboxFile(c.Request.Context(), project.ID, sandboxID, assetData.S3Key, sandboxDestPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, serializer.Err(http.StatusInternalServerError, "failed to download artifact to sandbox", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: DownloadToSandboxResp{Success: result.Success}})
+}
+
+type UploadFromSandboxReq struct {
+	SandboxID       string `json:"sandbox_id" binding:"required"`       // Source sandbox ID
+	SandboxPath     string `json:"sandbox_path" binding:"required"`     // Source directory in the sandbox
+	SandboxFilename string `json:"sandbox_filename" binding:"required"` // Filename in the sandbox
+	FilePath        string `json:"file_path" binding:"required"`        // Destination directory path on the disk
+}
+
+// UploadFromSandbox godoc
+//
+//	@Summary		Upload file from sandbox to disk
+//	@Description	Upload a file from a sandbox environment to disk storage as an artifact
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string							true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			request	body	handler.UploadFromSandboxReq	true	"Upload from sandbox request"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=model.Artifact}
+//	@Router			/disk/{disk_id}/artifact/upload_from_sandbox [post]
+//	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Upload file from sandbox to disk\nartifact = client.disks.artifacts.upload_from_sandbox(\n    disk_id='disk-uuid',\n    sandbox_id='sandbox-uuid',\n    sandbox_path='/home/user/',\n    sandbox_filename='output.txt',\n    file_path='/results/'\n)\nprint(f\"Created: {artifact.path}{artifact.filename}\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Upload file from sandbox to disk\nconst artifact = await client.disks.artifacts.uploadFromSandbox('disk-uuid', {\n  sandboxId: 'sandbox-uuid',\n  sandboxPath: '/home/user/',\n  sandboxFilename: 'output.txt',\n  filePath: '/results/'\n});\nconsole.log(`Created: ${artifact.path}${artifact.filename}`);\n","label":"JavaScript"}]
+func (h *ArtifactHandler) UploadFromSandbox(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid disk_id", err))
 		return
 	}
 
@@ -691,6 +1730,10 @@ func (h *ArtifactHandler) UploadFromSandbox(c *gin.Context) {
 	_ = h.s3.DeleteObject(c.Request.Context(), tempS3Key)
 
 	if err != nil {
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			c.JSON(http.StatusInsufficientStorage, serializer.Err(http.StatusInsufficientStorage, "storage quota exceeded", err))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, serializer.DBErr("failed to create artifact", err))
 		return
 	}
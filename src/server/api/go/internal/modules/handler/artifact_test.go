@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -17,10 +18,12 @@ import (
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/serializer"
 	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/grep"
 	"github.com/memodb-io/Acontext/internal/pkg/utils/fileparser"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 // MockArtifactService is a mock implementation of ArtifactService
@@ -53,9 +56,12 @@ func (m *MockArtifactService) UpdateArtifact(ctx context.Context, diskID uuid.UU
 	return args.Get(0).(*model.Artifact), args.Error(1)
 }
 
-func (m *MockArtifactService) ListByPath(ctx context.Context, diskID uuid.UUID, path string) ([]*model.Artifact, error) {
-	args := m.Called(ctx, diskID, path)
-	return args.Get(0).([]*model.Artifact), args.Error(1)
+func (m *MockArtifactService) ListArtifacts(ctx context.Context, in service.ListArtifactsInput) (*service.ListArtifactsOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ListArtifactsOutput), args.Error(1)
 }
 
 func (m *MockArtifactService) GetAllPaths(ctx context.Context, diskID uuid.UUID) ([]string, error) {
@@ -68,8 +74,8 @@ func (m *MockArtifactService) GetByDiskID(ctx context.Context, diskID uuid.UUID)
 	return args.Get(0).([]*model.Artifact), args.Error(1)
 }
 
-func (m *MockArtifactService) DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string) error {
-	args := m.Called(ctx, projectID, diskID, path, filename)
+func (m *MockArtifactService) DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string, cond service.Precondition) error {
+	args := m.Called(ctx, projectID, diskID, path, filename, cond)
 	return args.Error(0)
 }
 
@@ -83,8 +89,8 @@ func (m *MockArtifactService) UpdateArtifactByPath(ctx context.Context, diskID u
 	return args.Get(0).(*model.Artifact), args.Error(1)
 }
 
-func (m *MockArtifactService) UpdateArtifactMetaByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, userMeta map[string]interface{}) (*model.Artifact, error) {
-	args := m.Called(ctx, diskID, path, filename, userMeta)
+func (m *MockArtifactService) UpdateArtifactMetaByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, userMeta map[string]interface{}, cond service.Precondition) (*model.Artifact, error) {
+	args := m.Called(ctx, diskID, path, filename, userMeta, cond)
 	return args.Get(0).(*model.Artifact), args.Error(1)
 }
 
@@ -96,22 +102,38 @@ func (m *MockArtifactService) GetFileContent(ctx context.Context, artifact *mode
 	return args.Get(0).(*fileparser.FileContent), args.Error(1)
 }
 
-func (m *MockArtifactService) GrepArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, limit int) ([]*model.Artifact, error) {
-	args := m.Called(ctx, projectID, diskID, pattern, limit)
+func (m *MockArtifactService) GrepArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, filter service.ArtifactFilter, limit int) ([]*model.Artifact, error) {
+	args := m.Called(ctx, projectID, diskID, pattern, filter, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*model.Artifact), args.Error(1)
 }
 
-func (m *MockArtifactService) GlobArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, limit int) ([]*model.Artifact, error) {
-	args := m.Called(ctx, projectID, diskID, pattern, limit)
+func (m *MockArtifactService) GrepArtifactMatches(ctx context.Context, in service.GrepArtifactMatchesInput) (*service.GrepArtifactMatchesOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.GrepArtifactMatchesOutput), args.Error(1)
+}
+
+func (m *MockArtifactService) GlobArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, filter service.ArtifactFilter, limit int) ([]*model.Artifact, error) {
+	args := m.Called(ctx, projectID, diskID, pattern, filter, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*model.Artifact), args.Error(1)
 }
 
+func (m *MockArtifactService) GlobEntries(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, filter service.ArtifactFilter, limit int) (*service.GlobEntries, error) {
+	args := m.Called(ctx, projectID, diskID, pattern, filter, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.GlobEntries), args.Error(1)
+}
+
 func (m *MockArtifactService) CreateFromBytes(ctx context.Context, in service.CreateArtifactFromBytesInput) (*model.Artifact, error) {
 	args := m.Called(ctx, in)
 	if args.Get(0) == nil {
@@ -120,6 +142,94 @@ func (m *MockArtifactService) CreateFromBytes(ctx context.Context, in service.Cr
 	return args.Get(0).(*model.Artifact), args.Error(1)
 }
 
+func (m *MockArtifactService) ListVersionsByPath(ctx context.Context, diskID uuid.UUID, path string, filename string) (*model.Artifact, []*model.ArtifactVersion, error) {
+	args := m.Called(ctx, diskID, path, filename)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*model.Artifact), args.Get(1).([]*model.ArtifactVersion), args.Error(2)
+}
+
+func (m *MockArtifactService) GetVersionByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, version int) (*model.Artifact, error) {
+	args := m.Called(ctx, diskID, path, filename, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Artifact), args.Error(1)
+}
+
+func (m *MockArtifactService) DiffVersionsByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, fromVersion int, toVersion int) (*service.ArtifactDiff, error) {
+	args := m.Called(ctx, diskID, path, filename, fromVersion, toVersion)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ArtifactDiff), args.Error(1)
+}
+
+func (m *MockArtifactService) RestoreVersionByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, version int) (*model.Artifact, error) {
+	args := m.Called(ctx, diskID, path, filename, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Artifact), args.Error(1)
+}
+
+func (m *MockArtifactService) MoveArtifacts(ctx context.Context, in service.TransferArtifactsInput) (*service.TransferArtifactsOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.TransferArtifactsOutput), args.Error(1)
+}
+
+func (m *MockArtifactService) CopyArtifacts(ctx context.Context, in service.TransferArtifactsInput) (*service.TransferArtifactsOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.TransferArtifactsOutput), args.Error(1)
+}
+
+func (m *MockArtifactService) ArchiveArtifacts(ctx context.Context, in service.ArchiveArtifactsInput) (*service.ArtifactArchive, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ArtifactArchive), args.Error(1)
+}
+
+func (m *MockArtifactService) ExtractArchive(ctx context.Context, in service.ExtractArchiveInput) (*service.ExtractArchiveOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ExtractArchiveOutput), args.Error(1)
+}
+
+func (m *MockArtifactService) EditArtifact(ctx context.Context, in service.EditArtifactInput) (*service.EditArtifactOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.EditArtifactOutput), args.Error(1)
+}
+
+func (m *MockArtifactService) CreateUploadURL(ctx context.Context, in service.CreateUploadURLInput) (*service.CreateUploadURLOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.CreateUploadURLOutput), args.Error(1)
+}
+
+func (m *MockArtifactService) FinalizeUpload(ctx context.Context, in service.FinalizeUploadInput) (*model.Artifact, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Artifact), args.Error(1)
+}
+
 // createTestConfig creates a test config with default artifact settings
 func createTestConfig(maxUploadSizeBytes int64) *config.Config {
 	return &config.Config{
@@ -200,6 +310,19 @@ func TestArtifactHandler_UpsertArtifact(t *testing.T) {
 			},
 			expectedStatus: http.StatusRequestEntityTooLarge,
 		},
+		{
+			name:          "storage quota exceeded",
+			diskID:        uuid.New().String(),
+			filePath:      "/test/test.txt",
+			meta:          "",
+			fileContent:   "test content",
+			fileName:      "test.txt",
+			maxUploadSize: 16777216,
+			mockSetup: func(m *MockArtifactService, diskIDStr string, projectID uuid.UUID) {
+				m.On("Create", mock.Anything, mock.Anything).Return((*model.Artifact)(nil), fmt.Errorf("%w: disk stores 10 of 10 files, 1 more requested", service.ErrQuotaExceeded))
+			},
+			expectedStatus: http.StatusInsufficientStorage,
+		},
 		{
 			name:          "file size at limit boundary",
 			diskID:        uuid.New().String(),
@@ -321,6 +444,7 @@ func TestArtifactHandler_DeleteArtifact(t *testing.T) {
 		name           string
 		diskID         string
 		filePath       string
+		ifMatch        string
 		mockSetup      func(*MockArtifactService, string, string, uuid.UUID)
 		expectedStatus int
 	}{
@@ -330,10 +454,23 @@ func TestArtifactHandler_DeleteArtifact(t *testing.T) {
 			filePath: "/test/test.txt",
 			mockSetup: func(m *MockArtifactService, diskIDStr string, filePath string, projectID uuid.UUID) {
 				diskID := uuid.MustParse(diskIDStr)
-				m.On("DeleteByPath", mock.Anything, projectID, diskID, "/test/", "test.txt").Return(nil)
+				m.On("DeleteByPath", mock.Anything, projectID, diskID, "/test/", "test.txt", service.Precondition{}).Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:     "artifact changed since read",
+			diskID:   uuid.New().String(),
+			filePath: "/test/test.txt",
+			ifMatch:  `"abc-1", W/"abc-2"`,
+			mockSetup: func(m *MockArtifactService, diskIDStr string, filePath string, projectID uuid.UUID) {
+				diskID := uuid.MustParse(diskIDStr)
+				m.On("DeleteByPath", mock.Anything, projectID, diskID, "/test/", "test.txt", service.Precondition{
+					IfMatch: []string{`"abc-1"`, `"abc-2"`},
+				}).Return(service.ErrPreconditionFailed)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
 	}
 
 	for _, tt := range tests {
@@ -347,6 +484,9 @@ func TestArtifactHandler_DeleteArtifact(t *testing.T) {
 
 			// Create request with query parameters
 			req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/disk/%s/artifact?file_path=%s", tt.diskID, tt.filePath), nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 
 			// Create response recorder
 			w := httptest.NewRecorder()
@@ -417,7 +557,7 @@ func TestArtifactHandler_UpdateArtifact(t *testing.T) {
 					"description": "Updated report",
 					"version":     "2.0",
 				}
-				m.On("UpdateArtifactMetaByPath", mock.Anything, diskID, "/test/", "report.pdf", expectedMeta).Return(expectedFile, nil)
+				m.On("UpdateArtifactMetaByPath", mock.Anything, diskID, "/test/", "report.pdf", expectedMeta, service.Precondition{}).Return(expectedFile, nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -650,6 +790,8 @@ func TestArtifactHandler_GetArtifact(t *testing.T) {
 			assert.Equal(t, tt.expectedStatus, w.Code)
 
 			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, `"test-sha256-0"`, w.Header().Get("ETag"))
+
 				var response serializer.Response
 				err := json.Unmarshal(w.Body.Bytes(), &response)
 				assert.NoError(t, err)
@@ -670,6 +812,180 @@ func TestArtifactHandler_GetArtifact(t *testing.T) {
 	}
 }
 
+func TestArtifactHandler_GetArtifact_LineWindow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	diskID := uuid.New()
+	artifact := &model.Artifact{
+		ID:        uuid.New(),
+		DiskID:    diskID,
+		Path:      "/src/",
+		Filename:  "main.go",
+		AssetMeta: datatypes.NewJSONType(model.Asset{S3Key: "test-key", MIME: "text/x-go"}),
+	}
+	content := &fileparser.FileContent{Type: "code", Raw: "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(1)\n}\n", TotalLines: 7}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expected       *fileparser.FileContent
+	}{
+		{
+			name:           "whole file",
+			query:          "",
+			expectedStatus: http.StatusOK,
+			expected:       content,
+		},
+		{
+			name:           "line window",
+			query:          "&offset=4&limit=2",
+			expectedStatus: http.StatusOK,
+			expected:       &fileparser.FileContent{Type: "code", Raw: "func main() {\n\tfmt.Println(1)\n", TotalLines: 7, Offset: 4, LineCount: 2, HasMore: true},
+		},
+		{
+			name:           "line window with line numbers",
+			query:          "&offset=5&line_numbers=true",
+			expectedStatus: http.StatusOK,
+			expected:       &fileparser.FileContent{Type: "code", Raw: "6\t\tfmt.Println(1)\n7\t}\n", TotalLines: 7, Offset: 5, LineCount: 2},
+		},
+		{
+			name:           "negative offset",
+			query:          "&offset=-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockArtifactService)
+			if tt.expected != nil {
+				mockService.On("GetByPath", mock.Anything, diskID, "/src/", "main.go").Return(artifact, nil)
+				mockService.On("GetFileContent", mock.Anything, artifact).Return(content, nil)
+			}
+			handler := NewArtifactHandler(mockService, createDefaultTestConfig(), nil, nil)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/disk/"+diskID.String()+"/artifact?file_path=/src/main.go&with_public_url=false"+tt.query, nil)
+			c.Params = []gin.Param{{Key: "disk_id", Value: diskID.String()}}
+
+			handler.GetArtifact(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expected != nil {
+				var response struct {
+					Data GetArtifactResp `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expected, response.Data.Content)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactHandler_ListArtifacts(t *testing.T) {
+	diskID := uuid.New()
+	minSize := int64(1024)
+	updatedAfter := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func(*MockArtifactService)
+		expectedStatus int
+		checkBody      func(*testing.T, string)
+	}{
+		{
+			name:  "first page with filters",
+			query: `path=/docs/&limit=1&meta={"status":"reviewed"}&meta_key=source&meta_key=owner&mime=text/*&min_size=1024&updated_after=2025-01-01T00:00:00Z`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("ListArtifacts", mock.Anything, service.ListArtifactsInput{
+					DiskID: diskID,
+					Path:   "/docs/",
+					Filter: service.ArtifactFilter{
+						Meta:         map[string]interface{}{"status": "reviewed"},
+						MetaKeys:     []string{"source", "owner"},
+						MIME:         "text/*",
+						MinSizeB:     &minSize,
+						UpdatedAfter: &updatedAfter,
+					},
+					Limit: 1,
+				}).Return(&service.ListArtifactsOutput{
+					Items:      []*model.Artifact{{ID: uuid.New(), Path: "/docs/", Filename: "a.md"}},
+					NextCursor: "next",
+					HasMore:    true,
+				}, nil)
+				svc.On("GetAllPaths", mock.Anything, diskID).Return([]string{"/docs/", "/docs/old/"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, `"filename":"a.md"`)
+				assert.Contains(t, body, `"directories":["old"]`)
+				assert.Contains(t, body, `"next_cursor":"next"`)
+				assert.Contains(t, body, `"has_more":true`)
+			},
+		},
+		{
+			name:  "next page skips directories",
+			query: "cursor=next",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("ListArtifacts", mock.Anything, service.ListArtifactsInput{DiskID: diskID, Path: "/", Limit: 1000, Cursor: "next"}).
+					Return(&service.ListArtifactsOutput{Items: []*model.Artifact{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, `"directories":[]`)
+				assert.Contains(t, body, `"has_more":false`)
+			},
+		},
+		{
+			name:           "meta is not JSON",
+			query:          "meta=status",
+			setupMock:      func(svc *MockArtifactService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "negative size",
+			query:          "max_size=-1",
+			setupMock:      func(svc *MockArtifactService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "invalid filter",
+			query: "mime=text",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("ListArtifacts", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: bad mime", service.ErrInvalidFilter))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(MockArtifactService)
+			tt.setupMock(mockSvc)
+
+			handler := NewArtifactHandler(mockSvc, createTestConfig(10*1024*1024), nil, nil)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Set("project", &model.Project{ID: uuid.New()})
+			c.Request = httptest.NewRequest("GET", "/disk/"+diskID.String()+"/artifact/ls?"+tt.query, nil)
+			c.Params = gin.Params{{Key: "disk_id", Value: diskID.String()}}
+
+			handler.ListArtifacts(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.checkBody != nil {
+				tt.checkBody(t, w.Body.String())
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
 func TestArtifactHandler_GrepArtifacts(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -686,7 +1002,7 @@ func TestArtifactHandler_GrepArtifacts(t *testing.T) {
 			query:  "TODO",
 			limit:  "10",
 			setupMock: func(svc *MockArtifactService) {
-				svc.On("GrepArtifacts", mock.Anything, mock.Anything, mock.Anything, "TODO", 10).
+				svc.On("GrepArtifacts", mock.Anything, mock.Anything, mock.Anything, "TODO", service.ArtifactFilter{}, 10).
 					Return([]*model.Artifact{
 						{
 							ID:       uuid.New(),
@@ -706,7 +1022,7 @@ func TestArtifactHandler_GrepArtifacts(t *testing.T) {
 			query:  "NOTFOUND",
 			limit:  "50",
 			setupMock: func(svc *MockArtifactService) {
-				svc.On("GrepArtifacts", mock.Anything, mock.Anything, mock.Anything, "NOTFOUND", 50).
+				svc.On("GrepArtifacts", mock.Anything, mock.Anything, mock.Anything, "NOTFOUND", service.ArtifactFilter{}, 50).
 					Return([]*model.Artifact{}, nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -760,6 +1076,99 @@ func TestArtifactHandler_GrepArtifacts(t *testing.T) {
 	}
 }
 
+func TestArtifactHandler_GrepArtifactMatches(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	output := &service.GrepArtifactMatchesOutput{
+		Files: []service.GrepArtifactMatches{{
+			Path:       "/src/",
+			Filename:   "main.py",
+			MatchCount: 1,
+			Lines:      []grep.Line{{Number: 3, Text: "# TODO: fix", Match: true}},
+		}},
+		TotalMatches: 1,
+		Output:       "/src/main.py:3:# TODO: fix\n",
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func(*MockArtifactService)
+		expectedStatus int
+		checkBody      func(*testing.T, string)
+	}{
+		{
+			name:  "defaults",
+			query: "query=TODO",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("GrepArtifactMatches", mock.Anything, service.GrepArtifactMatchesInput{
+					ProjectID: projectID, DiskID: diskID, Pattern: "TODO", Limit: 100,
+				}).Return(output, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, `"total_matches":1`)
+				assert.Contains(t, body, `"line_number":3`)
+				assert.Contains(t, body, `/src/main.py:3:# TODO: fix`)
+			},
+		},
+		{
+			name:  "context with before override",
+			query: "query=todo&ignore_case=true&glob=/src/**/*.py&context=2&before=0&max_count=5&limit=10",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("GrepArtifactMatches", mock.Anything, service.GrepArtifactMatchesInput{
+					ProjectID: projectID, DiskID: diskID, Pattern: "todo", IgnoreCase: true, PathGlob: "/src/**/*.py",
+					Before: 0, After: 2, MaxCount: 5, Limit: 10,
+				}).Return(output, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid regex",
+			query:          "query=(unclosed",
+			setupMock:      func(svc *MockArtifactService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "context too large",
+			query:          "query=TODO&context=51",
+			setupMock:      func(svc *MockArtifactService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "service error",
+			query: "query=TODO",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("GrepArtifactMatches", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(MockArtifactService)
+			tt.setupMock(mockSvc)
+
+			handler := NewArtifactHandler(mockSvc, createDefaultTestConfig(), nil, nil)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Set("project", &model.Project{ID: projectID})
+			c.Request = httptest.NewRequest("GET", "/disk/"+diskID.String()+"/artifact/grep/matches?"+tt.query, nil)
+			c.Params = gin.Params{{Key: "disk_id", Value: diskID.String()}}
+
+			handler.GrepArtifactMatches(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.checkBody != nil {
+				tt.checkBody(t, w.Body.String())
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
 func TestArtifactHandler_GlobArtifacts(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -776,7 +1185,7 @@ func TestArtifactHandler_GlobArtifacts(t *testing.T) {
 			query:  "*.py",
 			limit:  "20",
 			setupMock: func(svc *MockArtifactService) {
-				svc.On("GlobArtifacts", mock.Anything, mock.Anything, mock.Anything, "*.py", 20).
+				svc.On("GlobArtifacts", mock.Anything, mock.Anything, mock.Anything, "*.py", service.ArtifactFilter{}, 20).
 					Return([]*model.Artifact{
 						{
 							ID:       uuid.New(),
@@ -802,7 +1211,7 @@ func TestArtifactHandler_GlobArtifacts(t *testing.T) {
 			query:  "*.xyz",
 			limit:  "10",
 			setupMock: func(svc *MockArtifactService) {
-				svc.On("GlobArtifacts", mock.Anything, mock.Anything, mock.Anything, "*.xyz", 10).
+				svc.On("GlobArtifacts", mock.Anything, mock.Anything, mock.Anything, "*.xyz", service.ArtifactFilter{}, 10).
 					Return([]*model.Artifact{}, nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -815,9 +1224,17 @@ func TestArtifactHandler_GlobArtifacts(t *testing.T) {
 			setupMock:      func(svc *MockArtifactService) {},
 			expectedStatus: http.StatusBadRequest,
 		},
-	}
-
-	for _, tt := range tests {
+		{
+			name:           "unclosed alternation",
+			diskID:         "123e4567-e89b-12d3-a456-426614174000",
+			query:          "*.{md,txt",
+			limit:          "10",
+			setupMock:      func(svc *MockArtifactService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockSvc := new(MockArtifactService)
 			tt.setupMock(mockSvc)
@@ -844,3 +1261,708 @@ func TestArtifactHandler_GlobArtifacts(t *testing.T) {
 		})
 	}
 }
+
+func TestArtifactHandler_GlobArtifactEntries(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func(*MockArtifactService)
+		expectedStatus int
+		checkBody      func(*testing.T, string)
+	}{
+		{
+			name:  "files and directories",
+			query: "query=/src/*",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("GlobEntries", mock.Anything, projectID, diskID, "/src/*", service.ArtifactFilter{}, 100).Return(&service.GlobEntries{
+					Artifacts:   []*model.Artifact{{Path: "/src/", Filename: "main.go"}},
+					Directories: []string{"/src/pkg/"},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, `"filename":"main.go"`)
+				assert.Contains(t, body, `"directories":["/src/pkg/"]`)
+			},
+		},
+		{
+			name:           "invalid pattern",
+			query:          "query=/src/{a",
+			setupMock:      func(svc *MockArtifactService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "service error",
+			query: "query=*&limit=5",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("GlobEntries", mock.Anything, projectID, diskID, "*", service.ArtifactFilter{}, 5).Return(nil, fmt.Errorf("db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(MockArtifactService)
+			tt.setupMock(mockSvc)
+
+			handler := NewArtifactHandler(mockSvc, createDefaultTestConfig(), nil, nil)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Set("project", &model.Project{ID: projectID})
+			c.Request = httptest.NewRequest("GET", "/disk/"+diskID.String()+"/artifact/glob/entries?"+tt.query, nil)
+			c.Params = gin.Params{{Key: "disk_id", Value: diskID.String()}}
+
+			handler.GlobArtifactEntries(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.checkBody != nil {
+				tt.checkBody(t, w.Body.String())
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactHandler_ArtifactVersions(t *testing.T) {
+	diskID := uuid.New()
+	artifact := &model.Artifact{ID: uuid.New(), DiskID: diskID, Path: "/docs/", Filename: "notes.md", Version: 3}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		setupMock      func(*MockArtifactService)
+		expectedStatus int
+		checkBody      func(*testing.T, string)
+	}{
+		{
+			name:   "list versions",
+			method: "GET",
+			url:    "/versions?file_path=/docs/notes.md",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("ListVersionsByPath", mock.Anything, diskID, "/docs/", "notes.md").
+					Return(artifact, []*model.ArtifactVersion{{ID: uuid.New(), ArtifactID: artifact.ID, Version: 2}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, `"current_version":3`)
+				assert.Contains(t, body, `"version":2`)
+			},
+		},
+		{
+			name:   "list versions of missing artifact",
+			method: "GET",
+			url:    "/versions?file_path=/docs/missing.md",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("ListVersionsByPath", mock.Anything, diskID, "/docs/", "missing.md").
+					Return(nil, nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "get version",
+			method: "GET",
+			url:    "/version?file_path=/docs/notes.md&version=1&with_public_url=false&with_content=false",
+			setupMock: func(svc *MockArtifactService) {
+				snapshot := *artifact
+				snapshot.Version = 1
+				svc.On("GetVersionByPath", mock.Anything, diskID, "/docs/", "notes.md", 1).Return(&snapshot, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, `"version":1`)
+				assert.NotContains(t, body, "public_url")
+			},
+		},
+		{
+			name:   "get unknown version",
+			method: "GET",
+			url:    "/version?file_path=/docs/notes.md&version=9",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("GetVersionByPath", mock.Anything, diskID, "/docs/", "notes.md", 9).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "get version without version number",
+			method:         "GET",
+			url:            "/version?file_path=/docs/notes.md",
+			setupMock:      func(svc *MockArtifactService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "diff against current version",
+			method: "GET",
+			url:    "/diff?file_path=/docs/notes.md&from=1",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("DiffVersionsByPath", mock.Anything, diskID, "/docs/", "notes.md", 1, 0).
+					Return(&service.ArtifactDiff{FromVersion: 1, ToVersion: 3, Diff: "-old\n+new\n"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, `"to_version":3`)
+				assert.Contains(t, body, `+new`)
+			},
+		},
+		{
+			name:   "diff of binary versions",
+			method: "GET",
+			url:    "/diff?file_path=/docs/notes.md&from=1&to=2",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("DiffVersionsByPath", mock.Anything, diskID, "/docs/", "notes.md", 1, 2).
+					Return(nil, fmt.Errorf("version 1 is not a text file"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "restore version",
+			method: "POST",
+			url:    "/restore",
+			body:   `{"file_path": "/docs/notes.md", "version": 2}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("RestoreVersionByPath", mock.Anything, diskID, "/docs/", "notes.md", 2).Return(artifact, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, `"filename":"notes.md"`)
+			},
+		},
+		{
+			name:   "restore unknown version",
+			method: "POST",
+			url:    "/restore",
+			body:   `{"file_path": "/docs/notes.md", "version": 5}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("RestoreVersionByPath", mock.Anything, diskID, "/docs/", "notes.md", 5).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockArtifactService{}
+			tt.setupMock(mockService)
+			handler := NewArtifactHandler(mockService, createDefaultTestConfig(), nil, nil)
+
+			router := gin.New()
+			group := router.Group("/disk/:disk_id/artifact")
+			group.GET("/versions", handler.ListArtifactVersions)
+			group.GET("/version", handler.GetArtifactVersion)
+			group.GET("/diff", handler.DiffArtifactVersions)
+			group.POST("/restore", handler.RestoreArtifactVersion)
+
+			req := httptest.NewRequest(tt.method, "/disk/"+diskID.String()+"/artifact"+tt.url, bytes.NewBufferString(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.checkBody != nil {
+				tt.checkBody(t, w.Body.String())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactHandler_TransferArtifacts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	projectID := uuid.New()
+	diskID := uuid.New()
+	moved := &model.Artifact{ID: uuid.New(), DiskID: diskID, Path: "/archive/", Filename: "notes.md"}
+
+	tests := []struct {
+		name           string
+		url            string
+		body           string
+		setupMock      func(*MockArtifactService)
+		expectedStatus int
+		checkBody      func(*testing.T, string)
+	}{
+		{
+			name: "move file",
+			url:  "/move",
+			body: `{"source": "/docs/notes.md", "destination": "/archive/"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("MoveArtifacts", mock.Anything, service.TransferArtifactsInput{
+					ProjectID: projectID, DiskID: diskID, Source: "/docs/notes.md", Destination: "/archive/",
+				}).Return(&service.TransferArtifactsOutput{Artifacts: []*model.Artifact{moved}, Skipped: []string{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, `"path":"/archive/"`)
+				assert.Contains(t, body, `"skipped":[]`)
+			},
+		},
+		{
+			name: "copy directory skipping conflicts",
+			url:  "/copy",
+			body: `{"source": "/docs/", "destination": "/backup/", "on_conflict": "skip"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("CopyArtifacts", mock.Anything, service.TransferArtifactsInput{
+					ProjectID: projectID, DiskID: diskID, Source: "/docs/", Destination: "/backup/", OnConflict: "skip",
+				}).Return(&service.TransferArtifactsOutput{Artifacts: []*model.Artifact{}, Skipped: []string{"/docs/notes.md"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, `"skipped":["/docs/notes.md"]`)
+			},
+		},
+		{
+			name:           "unknown conflict mode",
+			url:            "/move",
+			body:           `{"source": "/docs/notes.md", "destination": "/archive/", "on_conflict": "merge"}`,
+			setupMock:      func(svc *MockArtifactService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing destination",
+			url:            "/copy",
+			body:           `{"source": "/docs/notes.md"}`,
+			setupMock:      func(svc *MockArtifactService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "destination taken",
+			url:  "/move",
+			body: `{"source": "/docs/notes.md", "destination": "/archive/"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("MoveArtifacts", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("%w: /archive/notes.md", service.ErrArtifactConflict))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "invalid transfer",
+			url:  "/move",
+			body: `{"source": "/docs/", "destination": "/docs/old/"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("MoveArtifacts", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("%w: source and destination directories must not contain each other", service.ErrInvalidTransfer))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "source not found",
+			url:  "/copy",
+			body: `{"source": "/docs/missing.md", "destination": "/backup/"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("CopyArtifacts", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockArtifactService{}
+			tt.setupMock(mockService)
+			handler := NewArtifactHandler(mockService, createDefaultTestConfig(), nil, nil)
+
+			router := gin.New()
+			group := router.Group("/disk/:disk_id/artifact", func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+			})
+			group.POST("/move", handler.MoveArtifacts)
+			group.POST("/copy", handler.CopyArtifacts)
+
+			req := httptest.NewRequest("POST", "/disk/"+diskID.String()+"/artifact"+tt.url, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.checkBody != nil {
+				tt.checkBody(t, w.Body.String())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactHandler_ArchiveArtifacts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diskID := uuid.New()
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func(*MockArtifactService)
+		expectedStatus int
+	}{
+		{
+			name:  "stream zip",
+			query: "?path=/out/",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("ArchiveArtifacts", mock.Anything, service.ArchiveArtifactsInput{DiskID: diskID, Path: "/out/", Format: "zip"}).
+					Return(&service.ArtifactArchive{Filename: "out.zip", ContentType: "application/zip"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unsupported format",
+			query:          "?path=/out/&format=rar",
+			setupMock:      func(svc *MockArtifactService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "empty directory",
+			query: "?path=/missing/&format=tar.gz",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("ArchiveArtifacts", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockArtifactService{}
+			tt.setupMock(mockService)
+			handler := NewArtifactHandler(mockService, createDefaultTestConfig(), nil, nil)
+
+			router := gin.New()
+			router.GET("/disk/:disk_id/artifact/archive", handler.ArchiveArtifacts)
+
+			req := httptest.NewRequest("GET", "/disk/"+diskID.String()+"/artifact/archive"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+				assert.Equal(t, `attachment; filename="out.zip"`, w.Header().Get("Content-Disposition"))
+				_, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+				assert.NoError(t, err)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactHandler_ExtractArchive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	projectID := uuid.New()
+	diskID := uuid.New()
+
+	tests := []struct {
+		name           string
+		setupMock      func(*MockArtifactService)
+		expectedStatus int
+	}{
+		{
+			name: "extract",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("ExtractArchive", mock.Anything, mock.MatchedBy(func(in service.ExtractArchiveInput) bool {
+					return in.ProjectID == projectID && in.DiskID == diskID && in.Path == "/project/" &&
+						in.Archive.Filename == "project.zip" && in.Limits.MaxBytes == 1<<20 && in.Limits.MaxFiles == 10
+				})).Return(&service.ExtractArchiveOutput{Artifacts: []*model.Artifact{{Path: "/project/", Filename: "a.txt"}}}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "zip bomb",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("ExtractArchive", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: more than 1048576 bytes uncompressed", service.ErrArchiveTooLarge))
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "unsafe path",
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("ExtractArchive", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: unsafe path in archive", service.ErrInvalidArchive))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockArtifactService{}
+			tt.setupMock(mockService)
+			cfg := createDefaultTestConfig()
+			cfg.Artifact.MaxExtractSizeBytes = 1 << 20
+			cfg.Artifact.MaxExtractFiles = 10
+			handler := NewArtifactHandler(mockService, cfg, nil, nil)
+
+			router := gin.New()
+			group := router.Group("/disk/:disk_id/artifact", func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+			})
+			group.POST("/extract", handler.ExtractArchive)
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			_ = writer.WriteField("path", "/project/")
+			part, _ := writer.CreateFormFile("file", "project.zip")
+			_, _ = part.Write([]byte("PK"))
+			writer.Close()
+
+			req := httptest.NewRequest("POST", "/disk/"+diskID.String()+"/artifact/extract", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactHandler_EditArtifact(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	projectID := uuid.New()
+	diskID := uuid.New()
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(*MockArtifactService)
+		expectedStatus int
+	}{
+		{
+			name: "str_replace",
+			body: `{"file_path": "/src/main.go", "command": "str_replace", "old_str": "hi", "new_str": "hello"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("EditArtifact", mock.Anything, service.EditArtifactInput{
+					ProjectID: projectID, DiskID: diskID, Path: "/src/", Filename: "main.go",
+					Command: "str_replace", OldStr: "hi", NewStr: "hello",
+				}).Return(&service.EditArtifactOutput{Artifact: &model.Artifact{Path: "/src/", Filename: "main.go"}, Snippet: "1\thello\n"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown command",
+			body:           `{"file_path": "/src/main.go", "command": "undo"}`,
+			setupMock:      func(svc *MockArtifactService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "ambiguous match",
+			body: `{"file_path": "/src/main.go", "command": "str_replace", "old_str": "a"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("EditArtifact", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: multiple matches found", service.ErrInvalidEdit))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "missing file",
+			body: `{"file_path": "/src/missing.go", "command": "delete_lines", "start_line": 1, "end_line": 2}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("EditArtifact", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "concurrent writes",
+			body: `{"file_path": "/src/main.go", "command": "insert_at_line", "text": "x"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("EditArtifact", mock.Anything, mock.Anything).Return(nil, service.ErrEditConflict)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockArtifactService{}
+			tt.setupMock(mockService)
+			handler := NewArtifactHandler(mockService, createDefaultTestConfig(), nil, nil)
+
+			router := gin.New()
+			group := router.Group("/disk/:disk_id/artifact", func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+			})
+			group.POST("/edit", handler.EditArtifact)
+
+			req := httptest.NewRequest("POST", "/disk/"+diskID.String()+"/artifact/edit", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"snippet":"1\thello\n"`)
+				assert.Contains(t, w.Body.String(), `"start_line":0`)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactHandler_CreateUploadURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	projectID := uuid.New()
+	diskID := uuid.New()
+	sum := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	cfg := createDefaultTestConfig()
+	cfg.Artifact.MaxDirectUploadSizeBytes = 1 << 30
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(*MockArtifactService)
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			body: `{"file_path": "/data/train.parquet", "size": 1048576, "sha256": "` + sum + `", "meta": "{\"split\": \"train\"}"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("CreateUploadURL", mock.Anything, service.CreateUploadURLInput{
+					ProjectID: projectID, DiskID: diskID, Path: "/data/", Filename: "train.parquet",
+					SizeB: 1048576, SHA256: sum, UserMeta: map[string]interface{}{"split": "train"},
+					MaxSizeB: 1 << 30, Expire: 15 * time.Minute,
+				}).Return(&service.CreateUploadURLOutput{UploadID: uuid.New(), URL: "https://s3.example.com/staging", Method: "PUT"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing filename",
+			body:           `{"file_path": "/data/", "size": 1, "sha256": "` + sum + `"}`,
+			setupMock:      func(svc *MockArtifactService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "reserved meta key",
+			body:           `{"file_path": "/a.bin", "size": 1, "sha256": "` + sum + `", "meta": "{\"__artifact_info__\": {}}"}`,
+			setupMock:      func(svc *MockArtifactService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "too large",
+			body: `{"file_path": "/a.bin", "size": 2147483648, "sha256": "` + sum + `"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("CreateUploadURL", mock.Anything, mock.Anything).Return(nil, service.ErrUploadTooLarge)
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "quota exceeded",
+			body: `{"file_path": "/a.bin", "size": 1, "sha256": "` + sum + `"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("CreateUploadURL", mock.Anything, mock.Anything).Return(nil, service.ErrQuotaExceeded)
+			},
+			expectedStatus: http.StatusInsufficientStorage,
+		},
+		{
+			name: "disk not found",
+			body: `{"file_path": "/a.bin", "size": 1, "sha256": "` + sum + `"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("CreateUploadURL", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockArtifactService{}
+			tt.setupMock(mockService)
+			handler := NewArtifactHandler(mockService, cfg, nil, nil)
+
+			router := gin.New()
+			group := router.Group("/disk/:disk_id/artifact", func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+			})
+			group.POST("/upload_url", handler.CreateUploadURL)
+
+			req := httptest.NewRequest("POST", "/disk/"+diskID.String()+"/artifact/upload_url", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedStatus == http.StatusCreated {
+				assert.Contains(t, w.Body.String(), `"upload_url":"https://s3.example.com/staging"`)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactHandler_FinalizeUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	projectID := uuid.New()
+	diskID := uuid.New()
+	uploadID := uuid.New()
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(*MockArtifactService)
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			body: `{"upload_id": "` + uploadID.String() + `"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("FinalizeUpload", mock.Anything, service.FinalizeUploadInput{
+					ProjectID: projectID, DiskID: diskID, UploadID: uploadID, TextLimit: 16777216,
+				}).Return(&model.Artifact{Path: "/data/", Filename: "train.parquet"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "invalid upload id",
+			body:           `{"upload_id": "nope"}`,
+			setupMock:      func(svc *MockArtifactService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "checksum mismatch",
+			body: `{"upload_id": "` + uploadID.String() + `"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("FinalizeUpload", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: sha256 mismatch", service.ErrInvalidUpload))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "expired",
+			body: `{"upload_id": "` + uploadID.String() + `"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("FinalizeUpload", mock.Anything, mock.Anything).Return(nil, service.ErrUploadExpired)
+			},
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name: "unknown upload",
+			body: `{"upload_id": "` + uploadID.String() + `"}`,
+			setupMock: func(svc *MockArtifactService) {
+				svc.On("FinalizeUpload", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockArtifactService{}
+			tt.setupMock(mockService)
+			handler := NewArtifactHandler(mockService, createDefaultTestConfig(), nil, nil)
+
+			router := gin.New()
+			group := router.Group("/disk/:disk_id/artifact", func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+			})
+			group.POST("/finalize", handler.FinalizeUpload)
+
+			req := httptest.NewRequest("POST", "/disk/"+diskID.String()+"/artifact/finalize", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+)
+
+// BlobHandler serves the presigned URLs of the local blob store. With the S3 backend the
+// URLs point to the bucket and these routes answer 404.
+type BlobHandler struct {
+	local   *blob.LocalStore
+	maxSize int64
+}
+
+func NewBlobHandler(store blob.BlobStore, cfg *config.Config) *BlobHandler {
+	local, _ := store.(*blob.LocalStore)
+	return &BlobHandler{local: local, maxSize: cfg.Artifact.MaxDirectUploadSizeBytes}
+}
+
+type BlobReq struct {
+	Expires   string `form:"expires" binding:"required"`
+	Signature string `form:"signature" binding:"required"`
+}
+
+// verify checks the signature of the request and returns its blob key
+func (h *BlobHandler) verify(c *gin.Context) (string, bool) {
+	if h.local == nil {
+		c.JSON(http.StatusNotFound, serializer.Err(http.StatusNotFound, "blob not found", nil))
+		return "", false
+	}
+
+	req := BlobReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return "", false
+	}
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if err := h.local.Verify(c.Request.Method, key, req.Expires, req.Signature); err != nil {
+		c.JSON(http.StatusForbidden, serializer.Err(http.StatusForbidden, err.Error(), err))
+		return "", false
+	}
+	return key, true
+}
+
+// GetBlob downloads the file of a URL signed by LocalStore.PresignGet
+func (h *BlobHandler) GetBlob(c *gin.Context) {
+	key, ok := h.verify(c)
+	if !ok {
+		return
+	}
+
+	f, err := h.local.Open(key)
+	if errors.Is(err, blob.ErrObjectNotFound) {
+		c.JSON(http.StatusNotFound, serializer.Err(http.StatusNotFound, "blob not found", err))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, serializer.Err(http.StatusInternalServerError, "blob error", err))
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, serializer.Err(http.StatusInternalServerError, "blob error", err))
+		return
+	}
+	http.ServeContent(c.Writer, c.Request, path.Base(key), info.ModTime(), f)
+}
+
+// PutBlob stores the request body at the key of a URL signed by LocalStore.PresignPut
+func (h *BlobHandler) PutBlob(c *gin.Context) {
+	key, ok := h.verify(c)
+	if !ok {
+		return
+	}
+
+	body := c.Request.Body
+	if h.maxSize > 0 {
+		body = http.MaxBytesReader(c.Writer, body, h.maxSize)
+	}
+	if _, err := h.local.UploadReaderDirect(c.Request.Context(), key, body, c.ContentType()); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, serializer.Err(http.StatusRequestEntityTooLarge, "blob too large", err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, serializer.Err(http.StatusInternalServerError, "blob error", err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
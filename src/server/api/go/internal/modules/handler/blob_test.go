@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBlobRouter(t *testing.T, maxSize int64) (*gin.Engine, blob.BlobStore) {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.S3.Backend = blob.BackendLocal
+	cfg.S3.LocalDir = t.TempDir()
+	cfg.S3.LocalSecret = "secret"
+	cfg.S3.Endpoint = "http://127.0.0.1:8029"
+	cfg.Artifact.MaxDirectUploadSizeBytes = maxSize
+
+	store, err := blob.New(context.Background(), cfg)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	h := NewBlobHandler(store, cfg)
+	router.GET("/api/v1/blob/*key", h.GetBlob)
+	router.PUT("/api/v1/blob/*key", h.PutBlob)
+	return router, store
+}
+
+// requestURI strips the host of a presigned URL
+func requestURI(t *testing.T, raw string) string {
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u.RequestURI()
+}
+
+func TestBlobHandler_PutAndGet(t *testing.T) {
+	ctx := context.Background()
+	router, store := setupBlobRouter(t, 16)
+
+	putURL, err := store.PresignPut(ctx, "staging/upload/1", "text/plain", time.Minute)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PUT", requestURI(t, putURL), strings.NewReader("hello world")))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	getURL, err := store.PresignGet(ctx, "staging/upload/1", time.Minute)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", requestURI(t, getURL), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello world", w.Body.String())
+
+	// A GET URL does not allow uploads
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PUT", requestURI(t, getURL), strings.NewReader("other")))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Bodies over the direct upload limit are refused
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PUT", requestURI(t, putURL), strings.NewReader(strings.Repeat("x", 17))))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestBlobHandler_Get(t *testing.T) {
+	ctx := context.Background()
+	router, store := setupBlobRouter(t, 0)
+
+	asset, err := store.UploadBytes(ctx, "disks/p1", "notes.md", []byte("# notes"), nil)
+	require.NoError(t, err)
+	getURL, err := store.PresignGet(ctx, asset.S3Key, time.Minute)
+	require.NoError(t, err)
+	missingURL, err := store.PresignGet(ctx, "disks/p1/missing.md", time.Minute)
+	require.NoError(t, err)
+	expiredURL, err := store.PresignGet(ctx, asset.S3Key, -time.Minute)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		uri            string
+		expectedStatus int
+	}{
+		{name: "signed", uri: requestURI(t, getURL), expectedStatus: http.StatusOK},
+		{name: "missing blob", uri: requestURI(t, missingURL), expectedStatus: http.StatusNotFound},
+		{name: "expired", uri: requestURI(t, expiredURL), expectedStatus: http.StatusForbidden},
+		{name: "tampered", uri: strings.Replace(requestURI(t, getURL), "signature=", "signature=0", 1), expectedStatus: http.StatusForbidden},
+		{name: "unsigned", uri: "/api/v1/blob/" + asset.S3Key, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.uri, nil))
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestBlobHandler_S3Backend(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/blob/*key", NewBlobHandler(nil, &config.Config{}).GetBlob)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/blob/disks/p1/a.md?expires=1&signature=x", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
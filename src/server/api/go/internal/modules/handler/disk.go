@@ -3,12 +3,14 @@ package handler
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/serializer"
 	"github.com/memodb-io/Acontext/internal/modules/service"
+	"gorm.io/gorm"
 )
 
 type DiskHandler struct {
@@ -151,3 +153,353 @@ func (h *DiskHandler) DeleteDisk(c *gin.Context) {
 
 	c.JSON(http.StatusOK, serializer.Response{})
 }
+
+type UpdateDiskReq struct {
+	VersionRetention *int `form:"version_retention" json:"version_retention" binding:"required,min=0,max=1000" example:"10"`
+}
+
+// UpdateDisk godoc
+//
+//	@Summary		Update disk
+//	@Description	Update the settings of a disk. version_retention is how many previous versions are kept per artifact (0 disables versioning); a lower value is applied to an artifact the next time it changes.
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string					true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			payload	body	handler.UpdateDiskReq	true	"UpdateDisk payload"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=model.Disk}
+//	@Router			/disk/{disk_id} [put]
+func (h *DiskHandler) UpdateDisk(c *gin.Context) {
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	req := UpdateDiskReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	disk, err := h.svc.UpdateVersionRetention(c.Request.Context(), project.ID, diskID, *req.VersionRetention)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("disk not found", err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: disk})
+}
+
+type CreateDiskSnapshotReq struct {
+	Name string `form:"name" json:"name" binding:"max=255" example:"before-run-42"`
+}
+
+// CreateDiskSnapshot godoc
+//
+//	@Summary		Create disk snapshot
+//	@Description	Record the current artifacts of a disk so the disk can be restored to this state later. Stored files are shared, not copied.
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string							true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			payload	body	handler.CreateDiskSnapshotReq	false	"CreateDiskSnapshot payload"
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=model.DiskSnapshot}
+//	@Router			/disk/{disk_id}/snapshot [post]
+func (h *DiskHandler) CreateDiskSnapshot(c *gin.Context) {
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	req := CreateDiskSnapshotReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	snapshot, err := h.svc.CreateSnapshot(c.Request.Context(), project.ID, diskID, req.Name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("disk not found", err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: snapshot})
+}
+
+// ListDiskSnapshots godoc
+//
+//	@Summary		List disk snapshots
+//	@Description	List the snapshots of a disk, newest first
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=[]model.DiskSnapshot}
+//	@Router			/disk/{disk_id}/snapshot [get]
+func (h *DiskHandler) ListDiskSnapshots(c *gin.Context) {
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	snapshots, err := h.svc.ListSnapshots(c.Request.Context(), project.ID, diskID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("disk not found", err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: snapshots})
+}
+
+// DeleteDiskSnapshot godoc
+//
+//	@Summary		Delete disk snapshot
+//	@Description	Delete a snapshot of a disk. Stored files only referenced by the snapshot are removed.
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id		path	string	true	"Disk ID"		Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			snapshot_id	path	string	true	"Snapshot ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{}
+//	@Router			/disk/{disk_id}/snapshot/{snapshot_id} [delete]
+func (h *DiskHandler) DeleteDiskSnapshot(c *gin.Context) {
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+	snapshotID, err := uuid.Parse(c.Param("snapshot_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	if err := h.svc.DeleteSnapshot(c.Request.Context(), project.ID, diskID, snapshotID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("snapshot not found", err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{})
+}
+
+type RestoreDiskSnapshotReq struct {
+	SnapshotID string `form:"snapshot_id" json:"snapshot_id" binding:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+}
+
+// RestoreDiskSnapshot godoc
+//
+//	@Summary		Restore disk snapshot
+//	@Description	Replace the artifacts of a disk with those recorded in a snapshot. Artifacts created after the snapshot are deleted and the version history of the replaced artifacts is dropped. The snapshot is kept.
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string							true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			payload	body	handler.RestoreDiskSnapshotReq	true	"RestoreDiskSnapshot payload"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=model.DiskSnapshot}
+//	@Failure		507	{object}	serializer.Response	"Storage quota exceeded"
+//	@Router			/disk/{disk_id}/restore [post]
+func (h *DiskHandler) RestoreDiskSnapshot(c *gin.Context) {
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	req := RestoreDiskSnapshotReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	snapshot, err := h.svc.RestoreSnapshot(c.Request.Context(), project.ID, diskID, uuid.MustParse(req.SnapshotID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("snapshot not found", err))
+			return
+		}
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			c.JSON(http.StatusInsufficientStorage, serializer.Err(http.StatusInsufficientStorage, "storage quota exceeded", err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: snapshot})
+}
+
+type CloneDiskReq struct {
+	User string `form:"user" json:"user" example:"alice@acontext.io"`
+}
+
+// CloneDisk godoc
+//
+//	@Summary		Clone disk
+//	@Description	Create a new disk with a copy of the current artifacts of a disk, optionally for a user identifier. Stored files are shared, not copied. Versions and snapshots are not cloned.
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string					true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			payload	body	handler.CloneDiskReq	false	"CloneDisk payload"
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=model.Disk}
+//	@Failure		507	{object}	serializer.Response	"Storage quota exceeded"
+//	@Router			/disk/{disk_id}/clone [post]
+func (h *DiskHandler) CloneDisk(c *gin.Context) {
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	req := CloneDiskReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	// If user identifier is provided, get or create the user
+	var userID *uuid.UUID
+	if req.User != "" {
+		user, err := h.userSvc.GetOrCreate(c.Request.Context(), project.ID, req.User)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, serializer.DBErr("failed to get or create user", err))
+			return
+		}
+		userID = &user.ID
+	}
+
+	disk, err := h.svc.Clone(c.Request.Context(), project.ID, diskID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("disk not found", err))
+			return
+		}
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			c.JSON(http.StatusInsufficientStorage, serializer.Err(http.StatusInsufficientStorage, "storage quota exceeded", err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: disk})
+}
+
+type ListDiskChangesReq struct {
+	Since int64 `form:"since" json:"since" binding:"min=0" example:"0"`                                 // Return the changes after this seq, 0 for the whole log
+	Limit int   `form:"limit,default=100" json:"limit" binding:"required,min=1,max=1000" example:"100"` // Maximum number of changes to return
+	Wait  int   `form:"wait" json:"wait" binding:"min=0,max=60" example:"30"`                           // Seconds to wait for a change when there is none yet
+}
+
+// ListDiskChanges godoc
+//
+//	@Summary		List disk changes
+//	@Description	Return the change log of a disk: every create, update, delete and move of its artifacts, in seq order. Pass the next_since of a response as since to get only the later changes. With wait, the request is held until a change happens or the wait is over, so clients can long-poll the log.
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			since	query	integer	false	"Return the changes after this seq, 0 for the whole log"	example(0)
+//	@Param			limit	query	integer	false	"Maximum number of changes to return, default 100. Max 1000."
+//	@Param			wait	query	integer	false	"Seconds to wait for a change when there is none yet, default 0. Max 60."	example(30)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.ListDiskChangesOutput}
+//	@Router			/disk/{disk_id}/changes [get]
+func (h *DiskHandler) ListDiskChanges(c *gin.Context) {
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	req := ListDiskChangesReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	out, err := h.svc.ListChanges(c.Request.Context(), service.ListDiskChangesInput{
+		ProjectID: project.ID,
+		DiskID:    diskID,
+		Since:     req.Since,
+		Limit:     req.Limit,
+		Wait:      time.Duration(req.Wait) * time.Second,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("disk not found", err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/memodb-io/Acontext/internal/modules/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 // MockDiskService is a mock implementation of DiskService
@@ -43,6 +45,59 @@ func (m *MockDiskService) List(ctx context.Context, in service.ListDisksInput) (
 	return args.Get(0).(*service.ListDisksOutput), args.Error(1)
 }
 
+func (m *MockDiskService) UpdateVersionRetention(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, retention int) (*model.Disk, error) {
+	args := m.Called(ctx, projectID, diskID, retention)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Disk), args.Error(1)
+}
+
+func (m *MockDiskService) CreateSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, name string) (*model.DiskSnapshot, error) {
+	args := m.Called(ctx, projectID, diskID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DiskSnapshot), args.Error(1)
+}
+
+func (m *MockDiskService) ListSnapshots(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskSnapshot, error) {
+	args := m.Called(ctx, projectID, diskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.DiskSnapshot), args.Error(1)
+}
+
+func (m *MockDiskService) DeleteSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) error {
+	args := m.Called(ctx, projectID, diskID, snapshotID)
+	return args.Error(0)
+}
+
+func (m *MockDiskService) RestoreSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) (*model.DiskSnapshot, error) {
+	args := m.Called(ctx, projectID, diskID, snapshotID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DiskSnapshot), args.Error(1)
+}
+
+func (m *MockDiskService) Clone(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, userID *uuid.UUID) (*model.Disk, error) {
+	args := m.Called(ctx, projectID, diskID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Disk), args.Error(1)
+}
+
+func (m *MockDiskService) ListChanges(ctx context.Context, in service.ListDiskChangesInput) (*service.ListDiskChangesOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ListDiskChangesOutput), args.Error(1)
+}
+
 func setupDiskRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
@@ -273,3 +328,386 @@ func TestDiskHandler_DeleteDisk(t *testing.T) {
 		})
 	}
 }
+
+func TestDiskHandler_UpdateDisk(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+
+	tests := []struct {
+		name           string
+		diskID         string
+		body           string
+		setup          func(*MockDiskService)
+		expectedStatus int
+	}{
+		{
+			name:   "update version retention",
+			diskID: diskID.String(),
+			body:   `{"version_retention": 5}`,
+			setup: func(svc *MockDiskService) {
+				svc.On("UpdateVersionRetention", mock.Anything, projectID, diskID, 5).
+					Return(&model.Disk{ID: diskID, ProjectID: projectID, VersionRetention: 5}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "disable versioning",
+			diskID: diskID.String(),
+			body:   `{"version_retention": 0}`,
+			setup: func(svc *MockDiskService) {
+				svc.On("UpdateVersionRetention", mock.Anything, projectID, diskID, 0).
+					Return(&model.Disk{ID: diskID, ProjectID: projectID}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing version retention",
+			diskID:         diskID.String(),
+			body:           `{}`,
+			setup:          func(svc *MockDiskService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "negative version retention",
+			diskID:         diskID.String(),
+			body:           `{"version_retention": -1}`,
+			setup:          func(svc *MockDiskService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid disk ID",
+			diskID:         "invalid-uuid",
+			body:           `{"version_retention": 5}`,
+			setup:          func(svc *MockDiskService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "disk not found",
+			diskID: diskID.String(),
+			body:   `{"version_retention": 5}`,
+			setup: func(svc *MockDiskService) {
+				svc.On("UpdateVersionRetention", mock.Anything, projectID, diskID, 5).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockDiskService{}
+			tt.setup(mockService)
+			handler := NewDiskHandler(mockService, &MockUserService{})
+
+			router := setupDiskRouter()
+			router.PUT("/disk/:disk_id", func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+				handler.UpdateDisk(c)
+			})
+
+			req := httptest.NewRequest("PUT", "/disk/"+tt.diskID, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiskHandler_Snapshots(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	snapshotID := uuid.New()
+	snapshot := &model.DiskSnapshot{ID: snapshotID, DiskID: diskID, Name: "before-run", ArtifactCount: 2}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		setup          func(*MockDiskService)
+		expectedStatus int
+		checkBody      func(*testing.T, string)
+	}{
+		{
+			name:   "create snapshot",
+			method: "POST",
+			url:    "/disk/" + diskID.String() + "/snapshot",
+			body:   `{"name": "before-run"}`,
+			setup: func(svc *MockDiskService) {
+				svc.On("CreateSnapshot", mock.Anything, projectID, diskID, "before-run").Return(snapshot, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			checkBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, `"artifact_count":2`)
+			},
+		},
+		{
+			name:   "create snapshot of unknown disk",
+			method: "POST",
+			url:    "/disk/" + diskID.String() + "/snapshot",
+			body:   `{}`,
+			setup: func(svc *MockDiskService) {
+				svc.On("CreateSnapshot", mock.Anything, projectID, diskID, "").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "list snapshots",
+			method: "GET",
+			url:    "/disk/" + diskID.String() + "/snapshot",
+			setup: func(svc *MockDiskService) {
+				svc.On("ListSnapshots", mock.Anything, projectID, diskID).Return([]*model.DiskSnapshot{snapshot}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, snapshotID.String())
+			},
+		},
+		{
+			name:   "delete snapshot",
+			method: "DELETE",
+			url:    "/disk/" + diskID.String() + "/snapshot/" + snapshotID.String(),
+			setup: func(svc *MockDiskService) {
+				svc.On("DeleteSnapshot", mock.Anything, projectID, diskID, snapshotID).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "delete snapshot with invalid ID",
+			method:         "DELETE",
+			url:            "/disk/" + diskID.String() + "/snapshot/invalid-uuid",
+			setup:          func(svc *MockDiskService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "restore snapshot",
+			method: "POST",
+			url:    "/disk/" + diskID.String() + "/restore",
+			body:   `{"snapshot_id": "` + snapshotID.String() + `"}`,
+			setup: func(svc *MockDiskService) {
+				svc.On("RestoreSnapshot", mock.Anything, projectID, diskID, snapshotID).Return(snapshot, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "restore unknown snapshot",
+			method: "POST",
+			url:    "/disk/" + diskID.String() + "/restore",
+			body:   `{"snapshot_id": "` + snapshotID.String() + `"}`,
+			setup: func(svc *MockDiskService) {
+				svc.On("RestoreSnapshot", mock.Anything, projectID, diskID, snapshotID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "restore over quota",
+			method: "POST",
+			url:    "/disk/" + diskID.String() + "/restore",
+			body:   `{"snapshot_id": "` + snapshotID.String() + `"}`,
+			setup: func(svc *MockDiskService) {
+				svc.On("RestoreSnapshot", mock.Anything, projectID, diskID, snapshotID).Return(nil, service.ErrQuotaExceeded)
+			},
+			expectedStatus: http.StatusInsufficientStorage,
+		},
+		{
+			name:           "restore without snapshot ID",
+			method:         "POST",
+			url:            "/disk/" + diskID.String() + "/restore",
+			body:           `{"snapshot_id": "latest"}`,
+			setup:          func(svc *MockDiskService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockDiskService{}
+			tt.setup(mockService)
+			handler := NewDiskHandler(mockService, &MockUserService{})
+
+			router := setupDiskRouter()
+			group := router.Group("/disk", func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+			})
+			group.POST("/:disk_id/snapshot", handler.CreateDiskSnapshot)
+			group.GET("/:disk_id/snapshot", handler.ListDiskSnapshots)
+			group.DELETE("/:disk_id/snapshot/:snapshot_id", handler.DeleteDiskSnapshot)
+			group.POST("/:disk_id/restore", handler.RestoreDiskSnapshot)
+
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.checkBody != nil {
+				tt.checkBody(t, w.Body.String())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiskHandler_CloneDisk(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	userID := uuid.New()
+
+	tests := []struct {
+		name           string
+		body           string
+		setup          func(*MockDiskService, *MockUserService)
+		expectedStatus int
+	}{
+		{
+			name: "clone for a user",
+			body: `{"user": "alice@acontext.io"}`,
+			setup: func(svc *MockDiskService, userSvc *MockUserService) {
+				userSvc.On("GetOrCreate", mock.Anything, projectID, "alice@acontext.io").Return(&model.User{ID: userID}, nil)
+				svc.On("Clone", mock.Anything, projectID, diskID, &userID).
+					Return(&model.Disk{ID: uuid.New(), ProjectID: projectID, UserID: &userID}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "clone without a user",
+			body: `{}`,
+			setup: func(svc *MockDiskService, userSvc *MockUserService) {
+				svc.On("Clone", mock.Anything, projectID, diskID, (*uuid.UUID)(nil)).
+					Return(&model.Disk{ID: uuid.New(), ProjectID: projectID}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "unknown disk",
+			body: `{}`,
+			setup: func(svc *MockDiskService, userSvc *MockUserService) {
+				svc.On("Clone", mock.Anything, projectID, diskID, (*uuid.UUID)(nil)).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "over quota",
+			body: `{}`,
+			setup: func(svc *MockDiskService, userSvc *MockUserService) {
+				svc.On("Clone", mock.Anything, projectID, diskID, (*uuid.UUID)(nil)).Return(nil, service.ErrQuotaExceeded)
+			},
+			expectedStatus: http.StatusInsufficientStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockDiskService{}
+			mockUserService := &MockUserService{}
+			tt.setup(mockService, mockUserService)
+			handler := NewDiskHandler(mockService, mockUserService)
+
+			router := setupDiskRouter()
+			router.POST("/disk/:disk_id/clone", func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+				handler.CloneDisk(c)
+			})
+
+			req := httptest.NewRequest("POST", "/disk/"+diskID.String()+"/clone", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			mockService.AssertExpectations(t)
+			mockUserService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiskHandler_ListDiskChanges(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+
+	tests := []struct {
+		name           string
+		query          string
+		setup          func(*MockDiskService)
+		expectedStatus int
+		checkBody      func(*testing.T, string)
+	}{
+		{
+			name:  "whole log with default limit",
+			query: "",
+			setup: func(svc *MockDiskService) {
+				svc.On("ListChanges", mock.Anything, service.ListDiskChangesInput{ProjectID: projectID, DiskID: diskID, Limit: 100}).
+					Return(&service.ListDiskChangesOutput{
+						Items:     []*model.DiskChange{{DiskID: diskID, Seq: 1, Op: model.DiskChangeCreate, Path: "/", Filename: "a.md", Version: 1}},
+						NextSince: 1,
+						LatestSeq: 1,
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkBody: func(t *testing.T, body string) {
+				assert.Contains(t, body, `"op":"create"`)
+				assert.Contains(t, body, `"next_since":1`)
+				assert.NotContains(t, body, "from_path")
+			},
+		},
+		{
+			name:  "long-poll after a seq",
+			query: "?since=7&limit=50&wait=30",
+			setup: func(svc *MockDiskService) {
+				svc.On("ListChanges", mock.Anything, service.ListDiskChangesInput{ProjectID: projectID, DiskID: diskID, Since: 7, Limit: 50, Wait: 30 * time.Second}).
+					Return(&service.ListDiskChangesOutput{Items: []*model.DiskChange{}, NextSince: 7, LatestSeq: 7}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "negative since",
+			query:          "?since=-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "wait too long",
+			query:          "?wait=600",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "unknown disk",
+			query: "",
+			setup: func(svc *MockDiskService) {
+				svc.On("ListChanges", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockDiskService{}
+			if tt.setup != nil {
+				tt.setup(mockService)
+			}
+			handler := NewDiskHandler(mockService, &MockUserService{})
+
+			router := setupDiskRouter()
+			router.GET("/disk/:disk_id/changes", func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+				handler.ListDiskChanges(c)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/disk/"+diskID.String()+"/changes"+tt.query, nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.checkBody != nil {
+				tt.checkBody(t, w.Body.String())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/memodb-io/Acontext/internal/pkg/normalizer"
 	"github.com/memodb-io/Acontext/internal/pkg/tokenizer"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type SessionHandler struct {
@@ -316,15 +317,131 @@ func (h *SessionHandler) ConnectToSpace(c *gin.Context) {
 	c.JSON(http.StatusOK, serializer.Response{})
 }
 
+type SetSystemPromptReq struct {
+	Content            string `form:"content" json:"content" example:"You are a helpful assistant."`
+	AppendSpaceUseWhen bool   `form:"append_space_use_when" json:"append_space_use_when" example:"false"`
+}
+
+// SetSystemPrompt godoc
+//
+//	@Summary		Set session system prompt
+//	@Description	Store a new version of the session system prompt. Earlier versions are kept and the new version becomes active. If append_space_use_when is true, the use_when conditions of the SOPs in the connected space are appended when the prompt is rendered.
+//	@Tags			session
+//	@Accept			json
+//	@Produce		json
+//	@Param			session_id	path	string						true	"Session ID"	format(uuid)
+//	@Param			payload		body	handler.SetSystemPromptReq	true	"SetSystemPrompt payload"
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=model.SessionSystemPrompt}
+//	@Router			/session/{session_id}/system_prompt [put]
+func (h *SessionHandler) SetSystemPrompt(c *gin.Context) {
+	req := SetSystemPromptReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	prompt, err := h.svc.SetSystemPrompt(c.Request.Context(), service.SetSystemPromptInput{
+		SessionID:          sessionID,
+		Content:            req.Content,
+		AppendSpaceUseWhen: req.AppendSpaceUseWhen,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("session not found", err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: prompt})
+}
+
+type GetSystemPromptReq struct {
+	Version int `form:"version" json:"version" binding:"omitempty,min=1" example:"1"`
+}
+
+// GetSystemPrompt godoc
+//
+//	@Summary		Get session system prompt
+//	@Description	Get the active session system prompt, or a specific version of it
+//	@Tags			session
+//	@Accept			json
+//	@Produce		json
+//	@Param			session_id	path	string	true	"Session ID"	format(uuid)
+//	@Param			version		query	integer	false	"Version to return. Defaults to the active (latest) version."	example(1)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=model.SessionSystemPrompt}
+//	@Router			/session/{session_id}/system_prompt [get]
+func (h *SessionHandler) GetSystemPrompt(c *gin.Context) {
+	req := GetSystemPromptReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	prompt, err := h.svc.GetSystemPrompt(c.Request.Context(), sessionID, req.Version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("system prompt not found", err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: prompt})
+}
+
+// ListSystemPromptVersions godoc
+//
+//	@Summary		List session system prompt versions
+//	@Description	List all versions of the session system prompt, newest first
+//	@Tags			session
+//	@Accept			json
+//	@Produce		json
+//	@Param			session_id	path	string	true	"Session ID"	format(uuid)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=[]model.SessionSystemPrompt}
+//	@Router			/session/{session_id}/system_prompt/versions [get]
+func (h *SessionHandler) ListSystemPromptVersions(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	prompts, err := h.svc.ListSystemPrompts(c.Request.Context(), sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: prompts})
+}
+
 type StoreMessageReq struct {
 	Blob   interface{} `form:"blob" json:"blob" binding:"required"`
-	Format string      `form:"format" json:"format" binding:"omitempty,oneof=acontext openai anthropic gemini" example:"openai" enums:"acontext,openai,anthropic,gemini"`
+	Format string      `form:"format" json:"format" binding:"omitempty,oneof=acontext openai anthropic gemini bedrock" example:"openai" enums:"acontext,openai,anthropic,gemini,bedrock"`
 }
 
 // StoreMessage godoc
 //
 //	@Summary		Store message to session
-//	@Description	Supports JSON and multipart/form-data. In multipart mode: the payload is a JSON string placed in a form field. The format parameter indicates the format of the input message (default: openai, same as GET). The blob field should be a complete message object: for openai, use OpenAI ChatCompletionMessageParam format (with role and content); for anthropic, use Anthropic MessageParam format (with role and content); for acontext (internal), use {role, parts} format.
+//	@Description	Supports JSON and multipart/form-data. In multipart mode: the payload is a JSON string placed in a form field. The format parameter indicates the format of the input message (default: openai, same as GET). The blob field should be a complete message object: for openai, use OpenAI ChatCompletionMessageParam format (with role and content); for anthropic, use Anthropic MessageParam format (with role and content); for bedrock, use Bedrock Converse Message format (with role and content); for acontext (internal), use {role, parts} format. OpenAI system and developer messages are not stored as messages: they set a new version of the session system prompt and the response data is the stored system prompt.
 //	@Tags			session
 //	@Accept			json
 //	@Accept			multipart/form-data
@@ -394,11 +511,16 @@ func (h *SessionHandler) StoreMessage(c *gin.Context) {
 			return
 		}
 
-		// Collect file fields from normalized parts
+		// Collect file fields from normalized parts, including nested tool-result content
 		for _, p := range normalizedParts {
 			if p.FileField != "" {
 				fileFields = append(fileFields, p.FileField)
 			}
+			for _, nested := range p.Content {
+				if nested.FileField != "" {
+					fileFields = append(fileFields, nested.FileField)
+				}
+			}
 		}
 
 	case model.FormatOpenAI:
@@ -449,11 +571,33 @@ func (h *SessionHandler) StoreMessage(c *gin.Context) {
 			}
 		}
 
+	case model.FormatBedrock:
+		// Parse and validate Bedrock Converse message
+		norm := &normalizer.BedrockNormalizer{}
+		normalizedRole, normalizedParts, normalizedMeta, err = norm.NormalizeFromBedrockMessage(blobJSON)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, serializer.ParamErr("failed to normalize Bedrock message", err))
+			return
+		}
+
+		// Collect file fields from normalized parts
+		for _, p := range normalizedParts {
+			if p.FileField != "" {
+				fileFields = append(fileFields, p.FileField)
+			}
+		}
+
 	default:
 		c.JSON(http.StatusBadRequest, serializer.ParamErr("unsupported format", fmt.Errorf("format %s is not supported", format)))
 		return
 	}
 
+	// System and developer messages are not stored as messages; they set the session system prompt
+	if normalizedRole == "system" {
+		h.storeSystemMessage(c, normalizedParts)
+		return
+	}
+
 	// Validate that we have at least one part
 	if len(normalizedParts) == 0 {
 		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("message must contain at least one part")))
@@ -502,33 +646,76 @@ func (h *SessionHandler) StoreMessage(c *gin.Context) {
 	c.JSON(http.StatusCreated, serializer.Response{Data: out})
 }
 
+// storeSystemMessage stores the text of a system message as a new session system prompt version.
+// The space use_when setting of the active version is carried over.
+func (h *SessionHandler) storeSystemMessage(c *gin.Context, parts []service.PartIn) {
+	sessionID, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	texts := make([]string, 0, len(parts))
+	for _, p := range parts {
+		texts = append(texts, p.Text)
+	}
+
+	in := service.SetSystemPromptInput{
+		SessionID: sessionID,
+		Content:   strings.Join(texts, "\n"),
+	}
+	if active, err := h.svc.GetSystemPrompt(c.Request.Context(), sessionID, 0); err == nil {
+		in.AppendSpaceUseWhen = active.AppendSpaceUseWhen
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	prompt, err := h.svc.SetSystemPrompt(c.Request.Context(), in)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("session not found", err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: prompt})
+}
+
 type GetMessagesReq struct {
 	Limit                         *int   `form:"limit" json:"limit" binding:"omitempty,min=0,max=200" example:"20"`
 	Cursor                        string `form:"cursor" json:"cursor" example:"cHJvdGVjdGVkIHZlcnNpb24gdG8gYmUgZXhjbHVkZWQgaW4gcGFyc2luZyB0aGUgY3Vyc29y"`
 	WithAssetPublicURL            bool   `form:"with_asset_public_url,default=true" json:"with_asset_public_url" example:"true"`
-	Format                        string `form:"format,default=openai" json:"format" binding:"omitempty,oneof=acontext openai anthropic gemini" example:"openai" enums:"acontext,openai,anthropic,gemini"`
+	Format                        string `form:"format,default=openai" json:"format" binding:"omitempty,oneof=acontext openai anthropic gemini bedrock" example:"openai" enums:"acontext,openai,anthropic,gemini,bedrock"`
 	TimeDesc                      bool   `form:"time_desc,default=false" json:"time_desc" example:"false"`
 	EditStrategies                string `form:"edit_strategies" json:"edit_strategies" example:"[{\"type\":\"remove_tool_result\",\"params\":{\"keep_recent_n_tool_results\":3}}]"`
 	PinEditingStrategiesAtMessage string `form:"pin_editing_strategies_at_message" json:"pin_editing_strategies_at_message" example:""`
+	WithSystemPrompt              bool   `form:"with_system_prompt,default=true" json:"with_system_prompt" example:"true"`
+	Strict                        bool   `form:"strict,default=false" json:"strict" example:"false"`
 }
 
 // GetMessages godoc
 //
 //	@Summary		Get messages from session
-//	@Description	Get messages from session. Default format is openai. Can convert to acontext (original), anthropic, or gemini format.
+//	@Description	Get messages from session. Default format is openai. Can convert to acontext (original), anthropic, gemini, or bedrock format.
 //	@Tags			session
 //	@Accept			json
 //	@Produce		json
 //	@Param			session_id							path	string	true	"Session ID"	format(uuid)
 //	@Param			limit								query	integer	false	"Limit of messages to return. Max 200. If limit is 0 or not provided, all messages will be returned. \n\nWARNING!\n Use `limit` only for read-only/display purposes (pagination, viewing). Do NOT use `limit` to truncate messages before sending to LLM as it may cause tool-call and tool-result unpairing issues. Instead, use the `token_limit` edit strategy in `edit_strategies` parameter to safely manage message context size."
 //	@Param			cursor								query	string	false	"Cursor for pagination. Use the cursor from the previous response to get the next page."
-//	@Param			with_asset_public_url				query	string	false	"Whether to return asset public url, default is true"																																																																							example(true)
-//	@Param			format								query	string	false	"Format to convert messages to: acontext (original), openai (default), anthropic, gemini."																																																														enums(acontext,openai,anthropic,gemini)
+//	@Param			with_asset_public_url				query	string	false	"Whether to return asset public url, default is true. Only affects the acontext format; other formats always resolve media stored as assets."																																																																							example(true)
+//	@Param			format								query	string	false	"Format to convert messages to: acontext (original), openai (default), anthropic, gemini, bedrock."																																																														enums(acontext,openai,anthropic,gemini,bedrock)
 //	@Param			time_desc							query	string	false	"Order by created_at descending if true, ascending if false (default false)"																																																																	example(false)
 //	@Param			edit_strategies						query	string	false	"JSON array of edit strategies to apply before format conversion"																																																																				example([{"type":"remove_tool_result","params":{"keep_recent_n_tool_results":3}}])
 //	@Param			pin_editing_strategies_at_message	query	string	false	"Message ID to pin editing strategies at. When provided, strategies are only applied to messages up to and including this message ID, keeping subsequent messages unchanged. This helps maintain prompt cache stability by preserving a stable prefix. The response will include edit_at_message_id indicating where strategies were applied."	example()
+//	@Param			with_system_prompt					query	string	false	"Whether to include the session system prompt, default is true. It is returned as the first message for openai, as system for anthropic and bedrock, as system_instruction for gemini, and as system_prompt for acontext."	example(true)
+//	@Param			strict								query	string	false	"Fail with 422 instead of dropping information the target format cannot carry, default is false. Without strict mode, dropped parts and fields are listed per message ID in conversion_warnings."	example(false)
 //	@Security		BearerAuth
 //	@Success		200	{object}	serializer.Response{data=service.GetMessagesOutput}
+//	@Failure		422	{object}	serializer.Response	"Strict mode: the conversion would lose information"
 //	@Router			/session/{session_id}/messages [get]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Get messages from session\nmessages = client.sessions.get_messages(\n    session_id='session-uuid',\n    limit=50,\n    format='acontext',\n    time_desc=True\n)\nfor message in messages.items:\n    print(f\"{message.role}: {message.parts}\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Get messages from session\nconst messages = await client.sessions.getMessages('session-uuid', {\n  limit: 50,\n  format: 'acontext',\n  timeDesc: true\n});\nfor (const message of messages.items) {\n  console.log(`${message.role}: ${JSON.stringify(message.parts)}`);\n}\n","label":"JavaScript"}]
 func (h *SessionHandler) GetMessages(c *gin.Context) {
@@ -559,33 +746,37 @@ func (h *SessionHandler) GetMessages(c *gin.Context) {
 		}
 	}
 
+	// Messages are converted to this format (default: openai)
+	formatStr := req.Format
+	if formatStr == "" {
+		formatStr = string(model.FormatOpenAI)
+	}
+
+	format, err := converter.ValidateFormat(formatStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid format", err))
+		return
+	}
+
+	// Provider formats need presigned URLs to inline or link media stored as assets
+	withAssetPublicURL := req.WithAssetPublicURL || format != model.FormatAcontext
+
 	out, err := h.svc.GetMessages(c.Request.Context(), service.GetMessagesInput{
 		SessionID:                     sessionID,
 		Limit:                         limit,
 		Cursor:                        req.Cursor,
-		WithAssetPublicURL:            req.WithAssetPublicURL,
+		WithAssetPublicURL:            withAssetPublicURL,
 		AssetExpire:                   time.Hour * 24,
 		TimeDesc:                      req.TimeDesc,
 		EditStrategies:                editStrategies,
 		PinEditingStrategiesAtMessage: req.PinEditingStrategiesAtMessage,
+		WithSystemPrompt:              req.WithSystemPrompt,
 	})
 	if err != nil {
 		c.JSON(http.StatusBadRequest, serializer.DBErr("", err))
 		return
 	}
 
-	// Convert messages to specified format (default: openai)
-	formatStr := req.Format
-	if formatStr == "" {
-		formatStr = string(model.FormatOpenAI)
-	}
-
-	format, err := converter.ValidateFormat(formatStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid format", err))
-		return
-	}
-
 	// Calculate token count for the returned messages
 	thisTimeTokens, err := tokenizer.CountMessagePartsTokens(c.Request.Context(), out.Items)
 	if err != nil {
@@ -601,8 +792,17 @@ func (h *SessionHandler) GetMessages(c *gin.Context) {
 		out.HasMore,
 		thisTimeTokens,
 		out.EditAtMessageID,
+		out.SystemPrompt,
+		req.Strict,
 	)
 	if err != nil {
+		var lossErr *converter.LossError
+		if errors.As(err, &lossErr) {
+			res := serializer.Err(http.StatusUnprocessableEntity, "conversion would lose information", err)
+			res.Data = gin.H{"conversion_warnings": converter.GroupWarningsByMessage(lossErr.Warnings)}
+			c.JSON(http.StatusUnprocessableEntity, res)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, serializer.DBErr("failed to convert messages", err))
 		return
 	}
@@ -21,6 +21,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 // MockSessionService is a mock implementation of SessionService
@@ -91,6 +92,35 @@ func (m *MockSessionService) GetSessionObservingStatus(ctx context.Context, sess
 	return args.Get(0).(*model.MessageObservingStatus), args.Error(1)
 }
 
+func (m *MockSessionService) SetSystemPrompt(ctx context.Context, in service.SetSystemPromptInput) (*model.SessionSystemPrompt, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SessionSystemPrompt), args.Error(1)
+}
+
+func (m *MockSessionService) GetSystemPrompt(ctx context.Context, sessionID uuid.UUID, version int) (*model.SessionSystemPrompt, error) {
+	args := m.Called(ctx, sessionID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SessionSystemPrompt), args.Error(1)
+}
+
+func (m *MockSessionService) ListSystemPrompts(ctx context.Context, sessionID uuid.UUID) ([]model.SessionSystemPrompt, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.SessionSystemPrompt), args.Error(1)
+}
+
+func (m *MockSessionService) ResolveSystemPrompt(ctx context.Context, sessionID uuid.UUID) (string, error) {
+	args := m.Called(ctx, sessionID)
+	return args.String(0), args.Error(1)
+}
+
 func setupSessionRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
@@ -2073,7 +2103,7 @@ func TestSessionHandler_GetMessages(t *testing.T) {
 		{
 			name:           "with_asset_public_url false",
 			sessionIDParam: sessionID.String(),
-			queryParams:    "?limit=20&with_asset_public_url=false",
+			queryParams:    "?limit=20&with_asset_public_url=false&format=acontext",
 			setup: func(svc *MockSessionService) {
 				expectedOutput := &service.GetMessagesOutput{
 					Items: []model.Message{
@@ -2091,6 +2121,60 @@ func TestSessionHandler_GetMessages(t *testing.T) {
 			},
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "with_asset_public_url false is ignored for provider formats",
+			sessionIDParam: sessionID.String(),
+			queryParams:    "?limit=20&with_asset_public_url=false&format=anthropic",
+			setup: func(svc *MockSessionService) {
+				expectedOutput := &service.GetMessagesOutput{
+					Items:   []model.Message{},
+					HasMore: false,
+				}
+				svc.On("GetMessages", mock.Anything, mock.MatchedBy(func(in service.GetMessagesInput) bool {
+					return in.SessionID == sessionID && in.WithAssetPublicURL == true
+				})).Return(expectedOutput, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "strict mode rejects lossy conversion",
+			sessionIDParam: sessionID.String(),
+			queryParams:    "?limit=20&format=openai&strict=true",
+			setup: func(svc *MockSessionService) {
+				expectedOutput := &service.GetMessagesOutput{
+					Items: []model.Message{
+						{
+							ID:        uuid.New(),
+							SessionID: sessionID,
+							Role:      "user",
+							Parts:     []model.Part{{Type: "data", Meta: map[string]any{"data_type": "json"}}},
+						},
+					},
+					HasMore: false,
+				}
+				svc.On("GetMessages", mock.Anything, mock.Anything).Return(expectedOutput, nil)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "strict mode passes lossless conversion",
+			sessionIDParam: sessionID.String(),
+			queryParams:    "?limit=20&format=openai&strict=true",
+			setup: func(svc *MockSessionService) {
+				expectedOutput := &service.GetMessagesOutput{
+					Items: []model.Message{
+						{
+							ID:        uuid.New(),
+							SessionID: sessionID,
+							Role:      "user",
+						},
+					},
+					HasMore: false,
+				}
+				svc.On("GetMessages", mock.Anything, mock.Anything).Return(expectedOutput, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
 		{
 			name:           "with_asset_public_url true (default)",
 			sessionIDParam: sessionID.String(),
@@ -3706,3 +3790,166 @@ func TestSessionHandler_GetSessionObservingStatus_ServiceError(t *testing.T) {
 	assert.Contains(t, response["error"].(string), "database connection failed")
 	mockService.AssertExpectations(t)
 }
+
+func TestSessionHandler_SetSystemPrompt(t *testing.T) {
+	sessionID := uuid.New()
+
+	tests := []struct {
+		name           string
+		sessionIDParam string
+		requestBody    SetSystemPromptReq
+		setup          func(*MockSessionService)
+		expectedStatus int
+	}{
+		{
+			name:           "successful system prompt update",
+			sessionIDParam: sessionID.String(),
+			requestBody: SetSystemPromptReq{
+				Content:            "You are a helpful assistant.",
+				AppendSpaceUseWhen: true,
+			},
+			setup: func(svc *MockSessionService) {
+				svc.On("SetSystemPrompt", mock.Anything, service.SetSystemPromptInput{
+					SessionID:          sessionID,
+					Content:            "You are a helpful assistant.",
+					AppendSpaceUseWhen: true,
+				}).Return(&model.SessionSystemPrompt{SessionID: sessionID, Version: 2}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "invalid session ID",
+			sessionIDParam: "invalid-uuid",
+			requestBody:    SetSystemPromptReq{Content: "prompt"},
+			setup:          func(svc *MockSessionService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "session not found",
+			sessionIDParam: sessionID.String(),
+			requestBody:    SetSystemPromptReq{Content: "prompt"},
+			setup: func(svc *MockSessionService) {
+				svc.On("SetSystemPrompt", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockSessionService{}
+			tt.setup(mockService)
+
+			handler := NewSessionHandler(mockService, &MockUserService{}, getMockSessionCoreClient())
+			router := setupSessionRouter()
+			router.PUT("/session/:session_id/system_prompt", handler.SetSystemPrompt)
+
+			body, _ := sonic.Marshal(tt.requestBody)
+			req := httptest.NewRequest("PUT", "/session/"+tt.sessionIDParam+"/system_prompt", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSessionHandler_GetSystemPrompt(t *testing.T) {
+	sessionID := uuid.New()
+
+	tests := []struct {
+		name           string
+		query          string
+		setup          func(*MockSessionService)
+		expectedStatus int
+	}{
+		{
+			name:  "active version",
+			query: "",
+			setup: func(svc *MockSessionService) {
+				svc.On("GetSystemPrompt", mock.Anything, sessionID, 0).Return(&model.SessionSystemPrompt{Version: 3}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "specific version",
+			query: "?version=1",
+			setup: func(svc *MockSessionService) {
+				svc.On("GetSystemPrompt", mock.Anything, sessionID, 1).Return(&model.SessionSystemPrompt{Version: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid version",
+			query:          "?version=-1",
+			setup:          func(svc *MockSessionService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "no system prompt",
+			query: "",
+			setup: func(svc *MockSessionService) {
+				svc.On("GetSystemPrompt", mock.Anything, sessionID, 0).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockSessionService{}
+			tt.setup(mockService)
+
+			handler := NewSessionHandler(mockService, &MockUserService{}, getMockSessionCoreClient())
+			router := setupSessionRouter()
+			router.GET("/session/:session_id/system_prompt", handler.GetSystemPrompt)
+
+			req := httptest.NewRequest("GET", "/session/"+sessionID.String()+"/system_prompt"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSessionHandler_StoreMessage_SystemMessage(t *testing.T) {
+	sessionID := uuid.New()
+
+	mockService := &MockSessionService{}
+	mockService.On("GetSystemPrompt", mock.Anything, sessionID, 0).Return(&model.SessionSystemPrompt{
+		Version:            1,
+		AppendSpaceUseWhen: true,
+	}, nil)
+	mockService.On("SetSystemPrompt", mock.Anything, service.SetSystemPromptInput{
+		SessionID:          sessionID,
+		Content:            "You are a helpful assistant.",
+		AppendSpaceUseWhen: true,
+	}).Return(&model.SessionSystemPrompt{SessionID: sessionID, Version: 2}, nil)
+
+	handler := NewSessionHandler(mockService, &MockUserService{}, getMockSessionCoreClient())
+	router := setupSessionRouter()
+	router.POST("/session/:session_id/messages", handler.StoreMessage)
+
+	body, _ := sonic.Marshal(map[string]interface{}{
+		"format": "openai",
+		"blob": map[string]interface{}{
+			"role":    "system",
+			"content": "You are a helpful assistant.",
+		},
+	})
+	req := httptest.NewRequest("POST", "/session/"+sessionID.String()+"/messages", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockService.AssertNotCalled(t, "StoreMessage", mock.Anything, mock.Anything)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"gorm.io/gorm"
+)
+
+type ShareHandler struct {
+	svc service.ShareService
+}
+
+func NewShareHandler(s service.ShareService) *ShareHandler {
+	return &ShareHandler{svc: s}
+}
+
+type CreateShareReq struct {
+	FilePath     string `form:"file_path" json:"file_path" binding:"required" example:"/reports/"`           // File path, or a directory ending with /
+	Expire       int    `form:"expire" json:"expire" binding:"omitempty,min=60,max=2592000" example:"86400"` // Expire time in seconds (default: 1 day, max: 30 days)
+	MaxDownloads *int   `form:"max_downloads" json:"max_downloads" binding:"omitempty,min=1" example:"10"`   // Maximum number of file downloads through the link, unlimited when omitted
+	Scope        string `form:"scope" json:"scope" binding:"omitempty,oneof=read" example:"read"`            // Access granted by the link, only read is supported
+}
+
+// CreateShare godoc
+//
+//	@Summary		Create share link
+//	@Description	Create an expiring, read-only link to a file or to a directory with everything below it. The token is only returned once. Anyone with the token can list and download the shared files through /share/{token} without a project token, until the link expires, reaches its download limit or is revoked.
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string					true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			payload	body	handler.CreateShareReq	true	"CreateShare payload"
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=service.CreateShareOutput}
+//	@Router			/disk/{disk_id}/share [post]
+func (h *ShareHandler) CreateShare(c *gin.Context) {
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	req := CreateShareReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	expire := time.Duration(req.Expire) * time.Second
+	if expire == 0 {
+		expire = 24 * time.Hour
+	}
+
+	out, err := h.svc.Create(c.Request.Context(), service.CreateShareInput{
+		ProjectID:    project.ID,
+		DiskID:       diskID,
+		FilePath:     req.FilePath,
+		Expire:       expire,
+		MaxDownloads: req.MaxDownloads,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, serializer.DBErr("disk or file not found", err))
+		case errors.Is(err, service.ErrInvalidShare):
+			c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		default:
+			c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: out})
+}
+
+// ListShares godoc
+//
+//	@Summary		List share links
+//	@Description	List the share links of a disk, newest first, including expired ones. Tokens are not returned.
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=[]model.DiskShare}
+//	@Router			/disk/{disk_id}/share [get]
+func (h *ShareHandler) ListShares(c *gin.Context) {
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	shares, err := h.svc.List(c.Request.Context(), project.ID, diskID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("disk not found", err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: shares})
+}
+
+// RevokeShare godoc
+//
+//	@Summary		Revoke share link
+//	@Description	Delete a share link. Its token stops working immediately.
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id		path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			share_id	path	string	true	"Share ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{}
+//	@Router			/disk/{disk_id}/share/{share_id} [delete]
+func (h *ShareHandler) RevokeShare(c *gin.Context) {
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+	shareID, err := uuid.Parse(c.Param("share_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	if err := h.svc.Revoke(c.Request.Context(), project.ID, diskID, shareID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("share not found", err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{})
+}
+
+type BrowseShareReq struct {
+	Path string `form:"path" json:"path" example:"/drafts/"` // Directory relative to the share, defaults to its root
+}
+
+// BrowseShare godoc
+//
+//	@Summary		Browse share link
+//	@Description	List the files and subdirectories of a shared directory, or the shared file. Paths are relative to the share, whose root is /. No project token is needed.
+//	@Tags			share
+//	@Accept			json
+//	@Produce		json
+//	@Param			token	path	string	true	"Share token"
+//	@Param			path	query	string	false	"Directory relative to the share, defaults to its root"	example(/drafts/)
+//	@Success		200	{object}	serializer.Response{data=service.SharedListing}
+//	@Failure		404	{object}	serializer.Response	"Share or directory not found"
+//	@Failure		410	{object}	serializer.Response	"Share expired"
+//	@Router			/share/{token} [get]
+func (h *ShareHandler) BrowseShare(c *gin.Context) {
+	req := BrowseShareReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	listing, err := h.svc.Browse(c.Request.Context(), c.Param("token"), req.Path)
+	if err != nil {
+		h.shareErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: listing})
+}
+
+type DownloadShareReq struct {
+	FilePath string `form:"file_path" json:"file_path" example:"/drafts/notes.md"` // File path relative to the share, may be omitted for a shared file
+}
+
+// DownloadShare godoc
+//
+//	@Summary		Download shared file
+//	@Description	Redirect to a short-lived download URL of a shared file. Each download counts toward the download limit of the share. No project token is needed.
+//	@Tags			share
+//	@Param			token		path	string	true	"Share token"
+//	@Param			file_path	query	string	false	"File path relative to the share, may be omitted for a shared file"	example(/drafts/notes.md)
+//	@Success		302
+//	@Failure		404	{object}	serializer.Response	"Share or file not found"
+//	@Failure		410	{object}	serializer.Response	"Share expired or download limit reached"
+//	@Router			/share/{token}/file [get]
+func (h *ShareHandler) DownloadShare(c *gin.Context) {
+	req := DownloadShareReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	out, err := h.svc.Download(c.Request.Context(), c.Param("token"), req.FilePath)
+	if err != nil {
+		h.shareErr(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, out.URL)
+}
+
+// shareErr maps the errors of the public share routes
+func (h *ShareHandler) shareErr(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrShareNotFound):
+		c.JSON(http.StatusNotFound, serializer.Err(http.StatusNotFound, "share not found", err))
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, serializer.Err(http.StatusNotFound, "not found in share", err))
+	case errors.Is(err, service.ErrShareExpired):
+		c.JSON(http.StatusGone, serializer.Err(http.StatusGone, "share expired", err))
+	case errors.Is(err, service.ErrShareExhausted):
+		c.JSON(http.StatusGone, serializer.Err(http.StatusGone, "share download limit reached", err))
+	case errors.Is(err, service.ErrInvalidShare):
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+	default:
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+	}
+}
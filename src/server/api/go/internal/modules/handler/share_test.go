@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockShareService is a mock implementation of ShareService
+type MockShareService struct {
+	mock.Mock
+}
+
+func (m *MockShareService) Create(ctx context.Context, in service.CreateShareInput) (*service.CreateShareOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.CreateShareOutput), args.Error(1)
+}
+
+func (m *MockShareService) List(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskShare, error) {
+	args := m.Called(ctx, projectID, diskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.DiskShare), args.Error(1)
+}
+
+func (m *MockShareService) Revoke(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, shareID uuid.UUID) error {
+	args := m.Called(ctx, projectID, diskID, shareID)
+	return args.Error(0)
+}
+
+func (m *MockShareService) Browse(ctx context.Context, token string, dir string) (*service.SharedListing, error) {
+	args := m.Called(ctx, token, dir)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.SharedListing), args.Error(1)
+}
+
+func (m *MockShareService) Download(ctx context.Context, token string, filePath string) (*service.SharedDownload, error) {
+	args := m.Called(ctx, token, filePath)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.SharedDownload), args.Error(1)
+}
+
+func setupShareRouter(h *ShareHandler, projectID uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	disk := router.Group("/disk", func(c *gin.Context) {
+		c.Set("project", &model.Project{ID: projectID})
+	})
+	disk.POST("/:disk_id/share", h.CreateShare)
+	disk.GET("/:disk_id/share", h.ListShares)
+	disk.DELETE("/:disk_id/share/:share_id", h.RevokeShare)
+
+	// Public routes have no project
+	router.GET("/share/:token", h.BrowseShare)
+	router.GET("/share/:token/file", h.DownloadShare)
+	return router
+}
+
+func TestShareHandler_CreateShare(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	maxDownloads := 10
+
+	tests := []struct {
+		name           string
+		body           string
+		setup          func(*MockShareService)
+		expectedStatus int
+	}{
+		{
+			name: "directory with download limit",
+			body: `{"file_path":"/reports/","expire":3600,"max_downloads":10}`,
+			setup: func(m *MockShareService) {
+				m.On("Create", mock.Anything, service.CreateShareInput{
+					ProjectID: projectID, DiskID: diskID, FilePath: "/reports/", Expire: time.Hour, MaxDownloads: &maxDownloads,
+				}).Return(&service.CreateShareOutput{Share: &model.DiskShare{ID: uuid.New()}, Token: "tok"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "default expiry",
+			body: `{"file_path":"/reports/q3.md"}`,
+			setup: func(m *MockShareService) {
+				m.On("Create", mock.Anything, service.CreateShareInput{
+					ProjectID: projectID, DiskID: diskID, FilePath: "/reports/q3.md", Expire: 24 * time.Hour,
+				}).Return(&service.CreateShareOutput{Share: &model.DiskShare{ID: uuid.New()}, Token: "tok"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "write scope",
+			body:           `{"file_path":"/reports/","scope":"write"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "expire too long",
+			body:           `{"file_path":"/reports/","expire":31536000}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "missing file",
+			body: `{"file_path":"/reports/missing.md"}`,
+			setup: func(m *MockShareService) {
+				m.On("Create", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockShareService{}
+			if tt.setup != nil {
+				tt.setup(mockService)
+			}
+			router := setupShareRouter(NewShareHandler(mockService), projectID)
+
+			req := httptest.NewRequest("POST", "/disk/"+diskID.String()+"/share", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestShareHandler_RevokeShare(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	shareID := uuid.New()
+
+	mockService := &MockShareService{}
+	mockService.On("Revoke", mock.Anything, projectID, diskID, shareID).Return(nil).Once()
+	mockService.On("Revoke", mock.Anything, projectID, diskID, shareID).Return(gorm.ErrRecordNotFound).Once()
+	router := setupShareRouter(NewShareHandler(mockService), projectID)
+
+	for _, expected := range []int{http.StatusOK, http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("DELETE", "/disk/"+diskID.String()+"/share/"+shareID.String(), nil))
+		assert.Equal(t, expected, w.Code, w.Body.String())
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestShareHandler_BrowseShare(t *testing.T) {
+	mockService := &MockShareService{}
+	mockService.On("Browse", mock.Anything, "good", "/drafts/").Return(&service.SharedListing{
+		Path:        "/drafts/",
+		Files:       []service.SharedFile{{Path: "/drafts/", Filename: "notes.md"}},
+		Directories: []string{},
+	}, nil)
+	mockService.On("Browse", mock.Anything, "expired", "").Return(nil, service.ErrShareExpired)
+	mockService.On("Browse", mock.Anything, "unknown", "").Return(nil, service.ErrShareNotFound)
+	router := setupShareRouter(NewShareHandler(mockService), uuid.New())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/share/good?path=/drafts/", nil))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"filename":"notes.md"`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/share/expired", nil))
+	assert.Equal(t, http.StatusGone, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/share/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestShareHandler_DownloadShare(t *testing.T) {
+	mockService := &MockShareService{}
+	mockService.On("Download", mock.Anything, "good", "/drafts/notes.md").Return(&service.SharedDownload{
+		Filename: "notes.md",
+		URL:      "https://s3.example.com/assets/notes.md",
+	}, nil)
+	mockService.On("Download", mock.Anything, "used", "").Return(nil, service.ErrShareExhausted)
+	router := setupShareRouter(NewShareHandler(mockService), uuid.New())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/share/good/file?file_path=/drafts/notes.md", nil))
+	assert.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	assert.Equal(t, "https://s3.example.com/assets/notes.md", w.Header().Get("Location"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/share/used/file", nil))
+	assert.Equal(t, http.StatusGone, w.Code)
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"gorm.io/gorm"
+)
+
+type StorageHandler struct {
+	svc     service.StorageService
+	userSvc service.UserService
+}
+
+func NewStorageHandler(s service.StorageService, userSvc service.UserService) *StorageHandler {
+	return &StorageHandler{svc: s, userSvc: userSvc}
+}
+
+// GetStorageUsage godoc
+//
+//	@Summary		Get storage usage
+//	@Description	Get the files, raw bytes and deduplicated bytes stored by the project, per disk and per user, along with the storage quotas. Raw bytes count every file, deduplicated bytes count identical contents once. Previous versions of artifacts and disk snapshots keep their contents stored: they add to the deduplicated bytes, but not to the files and raw bytes that quotas limit.
+//	@Tags			storage
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.StorageUsageOutput}
+//	@Router			/storage/usage [get]
+func (h *StorageHandler) GetStorageUsage(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	out, err := h.svc.GetUsage(c.Request.Context(), project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
+
+// ListStorageQuotas godoc
+//
+//	@Summary		List storage quotas
+//	@Description	List the storage quotas set on the project, its users and its disks
+//	@Tags			storage
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=[]model.StorageQuota}
+//	@Router			/storage/quota [get]
+func (h *StorageHandler) ListStorageQuotas(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	quotas, err := h.svc.ListQuotas(c.Request.Context(), project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: quotas})
+}
+
+type SetStorageQuotaReq struct {
+	Scope    string `form:"scope" json:"scope" binding:"required,oneof=project user disk" example:"user"`
+	User     string `form:"user" json:"user" example:"alice@acontext.io"`                          // Required for the user scope
+	DiskID   string `form:"disk_id" json:"disk_id" example:"123e4567-e89b-12d3-a456-426614174000"` // Required for the disk scope
+	MaxBytes *int64 `form:"max_bytes" json:"max_bytes" binding:"omitempty,min=0" example:"1073741824"`
+	MaxFiles *int64 `form:"max_files" json:"max_files" binding:"omitempty,min=0" example:"10000"`
+}
+
+// SetStorageQuota godoc
+//
+//	@Summary		Set storage quota
+//	@Description	Set the maximum bytes and files stored by the project, a user or a disk. A missing limit is unlimited, and a quota without limits is removed. The user or disk must exist. Writes that would exceed a quota fail with 507.
+//	@Tags			storage
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	handler.SetStorageQuotaReq	true	"SetStorageQuota payload"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=model.StorageQuota}
+//	@Router			/storage/quota [put]
+func (h *StorageHandler) SetStorageQuota(c *gin.Context) {
+	req := SetStorageQuotaReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	var scopeID uuid.UUID
+	switch req.Scope {
+	case model.QuotaScopeProject:
+		scopeID = project.ID
+	case model.QuotaScopeUser:
+		if req.User == "" {
+			c.JSON(http.StatusBadRequest, serializer.ParamErr("user is required for the user scope", nil))
+			return
+		}
+		user, err := h.userSvc.Get(c.Request.Context(), project.ID, req.User)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, serializer.DBErr("user not found", err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, serializer.DBErr("failed to get user", err))
+			return
+		}
+		scopeID = user.ID
+	case model.QuotaScopeDisk:
+		diskID, err := uuid.Parse(req.DiskID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, serializer.ParamErr("disk_id is required for the disk scope", err))
+			return
+		}
+		scopeID = diskID
+	}
+
+	quota, err := h.svc.SetQuota(c.Request.Context(), service.SetQuotaInput{
+		ProjectID: project.ID,
+		Scope:     req.Scope,
+		ScopeID:   scopeID,
+		MaxBytes:  req.MaxBytes,
+		MaxFiles:  req.MaxFiles,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, serializer.DBErr("disk not found", err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: quota})
+}
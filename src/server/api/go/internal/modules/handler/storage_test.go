@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockStorageService is a mock implementation of StorageService
+type MockStorageService struct {
+	mock.Mock
+}
+
+func (m *MockStorageService) CheckQuota(ctx context.Context, in service.CheckQuotaInput) error {
+	args := m.Called(ctx, in)
+	return args.Error(0)
+}
+
+func (m *MockStorageService) GetUsage(ctx context.Context, projectID uuid.UUID) (*service.StorageUsageOutput, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.StorageUsageOutput), args.Error(1)
+}
+
+func (m *MockStorageService) ListQuotas(ctx context.Context, projectID uuid.UUID) ([]*model.StorageQuota, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.StorageQuota), args.Error(1)
+}
+
+func (m *MockStorageService) SetQuota(ctx context.Context, in service.SetQuotaInput) (*model.StorageQuota, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.StorageQuota), args.Error(1)
+}
+
+func setupStorageRouter(h *StorageHandler, projectID uuid.UUID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	group := router.Group("/storage", func(c *gin.Context) {
+		c.Set("project", &model.Project{ID: projectID})
+	})
+	group.GET("/usage", h.GetStorageUsage)
+	group.GET("/quota", h.ListStorageQuotas)
+	group.PUT("/quota", h.SetStorageQuota)
+	return router
+}
+
+func TestStorageHandler_GetStorageUsage(t *testing.T) {
+	projectID := uuid.New()
+
+	mockService := &MockStorageService{}
+	mockService.On("GetUsage", mock.Anything, projectID).Return(&service.StorageUsageOutput{
+		Disks:  []service.DiskStorageUsage{},
+		Users:  []service.UserStorageUsage{},
+		Quotas: []*model.StorageQuota{},
+	}, nil)
+	router := setupStorageRouter(NewStorageHandler(mockService, &MockUserService{}), projectID)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/storage/usage", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"dedup_bytes":0`)
+	mockService.AssertExpectations(t)
+}
+
+func TestStorageHandler_SetStorageQuota(t *testing.T) {
+	projectID := uuid.New()
+	userID := uuid.New()
+	diskID := uuid.New()
+
+	tests := []struct {
+		name           string
+		body           string
+		setup          func(*MockStorageService, *MockUserService)
+		expectedStatus int
+	}{
+		{
+			name: "project quota",
+			body: `{"scope": "project", "max_bytes": 1024}`,
+			setup: func(svc *MockStorageService, userSvc *MockUserService) {
+				svc.On("SetQuota", mock.Anything, mock.MatchedBy(func(in service.SetQuotaInput) bool {
+					return in.Scope == model.QuotaScopeProject && in.ScopeID == projectID && *in.MaxBytes == 1024 && in.MaxFiles == nil
+				})).Return(&model.StorageQuota{Scope: model.QuotaScopeProject, ScopeID: projectID}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "user quota",
+			body: `{"scope": "user", "user": "alice@acontext.io", "max_files": 10}`,
+			setup: func(svc *MockStorageService, userSvc *MockUserService) {
+				userSvc.On("Get", mock.Anything, projectID, "alice@acontext.io").Return(&model.User{ID: userID}, nil)
+				svc.On("SetQuota", mock.Anything, mock.MatchedBy(func(in service.SetQuotaInput) bool {
+					return in.Scope == model.QuotaScopeUser && in.ScopeID == userID && *in.MaxFiles == 10
+				})).Return(&model.StorageQuota{Scope: model.QuotaScopeUser, ScopeID: userID}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "unknown user",
+			body: `{"scope": "user", "user": "bob@acontext.io", "max_files": 10}`,
+			setup: func(svc *MockStorageService, userSvc *MockUserService) {
+				userSvc.On("Get", mock.Anything, projectID, "bob@acontext.io").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "user scope without user",
+			body:           `{"scope": "user", "max_files": 10}`,
+			setup:          func(svc *MockStorageService, userSvc *MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid scope",
+			body:           `{"scope": "space", "max_files": 10}`,
+			setup:          func(svc *MockStorageService, userSvc *MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "negative limit",
+			body:           `{"scope": "project", "max_bytes": -1}`,
+			setup:          func(svc *MockStorageService, userSvc *MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown disk",
+			body: `{"scope": "disk", "disk_id": "` + diskID.String() + `", "max_bytes": 1}`,
+			setup: func(svc *MockStorageService, userSvc *MockUserService) {
+				svc.On("SetQuota", mock.Anything, mock.MatchedBy(func(in service.SetQuotaInput) bool {
+					return in.Scope == model.QuotaScopeDisk && in.ScopeID == diskID
+				})).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockStorageService{}
+			mockUserService := &MockUserService{}
+			tt.setup(mockService, mockUserService)
+			router := setupStorageRouter(NewStorageHandler(mockService, mockUserService), projectID)
+
+			req := httptest.NewRequest("PUT", "/storage/quota", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			mockService.AssertExpectations(t)
+			mockUserService.AssertExpectations(t)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 
@@ -8,14 +9,19 @@ import (
 	"github.com/memodb-io/Acontext/internal/infra/httpclient"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/converter"
+	"github.com/memodb-io/Acontext/internal/pkg/toolschema"
 )
 
 type ToolHandler struct {
+	svc        service.ToolService
 	coreClient *httpclient.CoreClient
 }
 
-func NewToolHandler(coreClient *httpclient.CoreClient) *ToolHandler {
+func NewToolHandler(s service.ToolService, coreClient *httpclient.CoreClient) *ToolHandler {
 	return &ToolHandler{
+		svc:        s,
 		coreClient: coreClient,
 	}
 }
@@ -102,3 +108,97 @@ func (h *ToolHandler) GetToolName(c *gin.Context) {
 
 	c.JSON(http.StatusOK, serializer.Response{Data: result})
 }
+
+type ConvertToolsReq struct {
+	From   string            `json:"from" binding:"required" example:"openai"`
+	To     string            `json:"to" binding:"required" example:"gemini"`
+	Tools  []json.RawMessage `json:"tools" binding:"required,min=1" swaggertype:"array,object"`
+	Strict bool              `json:"strict" example:"false"`
+}
+
+// ConvertTools godoc
+//
+//	@Summary		Convert tool definitions
+//	@Description	Convert tool definitions from one provider format to another (acontext, openai, anthropic, gemini, bedrock). JSON schemas are adapted to the target: Gemini gets its OpenAPI subset ($ref inlined, type arrays become nullable, unsupported keywords dropped) and strict=true rewrites the schemas for OpenAI strict mode. Every adaptation is listed in warnings.
+//	@Tags			tool
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	handler.ConvertToolsReq	true	"Tool conversion request"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=toolschema.Output}
+//	@Router			/tool/convert [post]
+func (h *ToolHandler) ConvertTools(c *gin.Context) {
+	req := ConvertToolsReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	from, err := converter.ValidateFormat(req.From)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid from format", err))
+		return
+	}
+	to, err := converter.ValidateFormat(req.To)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid to format", err))
+		return
+	}
+
+	out, err := toolschema.Convert(from, to, req.Tools, toolschema.Options{Strict: req.Strict})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("failed to convert tools", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
+
+type GetToolSchemasReq struct {
+	Format string `form:"format,default=openai" json:"format" example:"openai"`
+	Strict bool   `form:"strict,default=false" json:"strict" example:"false"`
+}
+
+// GetToolSchemas godoc
+//
+//	@Summary		Get tool schemas
+//	@Description	Get the tools registered in the project as tool definitions in the given provider format
+//	@Tags			tool
+//	@Accept			json
+//	@Produce		json
+//	@Param			format	query	string	false	"Output format: acontext, openai, anthropic, gemini or bedrock (default: openai)"	enums(acontext,openai,anthropic,gemini,bedrock)
+//	@Param			strict	query	boolean	false	"Rewrite the schemas for OpenAI strict mode (default: false)"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=toolschema.Output}
+//	@Router			/tool/schemas [get]
+func (h *ToolHandler) GetToolSchemas(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		return
+	}
+
+	req := GetToolSchemasReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		return
+	}
+
+	format, err := converter.ValidateFormat(req.Format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid format", err))
+		return
+	}
+
+	out, err := h.svc.GetToolSchemas(c.Request.Context(), service.GetToolSchemasInput{
+		ProjectID: project.ID,
+		Format:    format,
+		Strict:    req.Strict,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, serializer.DBErr("", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
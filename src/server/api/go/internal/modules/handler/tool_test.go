@@ -2,6 +2,8 @@ package handler
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,9 +13,25 @@ import (
 	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/infra/httpclient"
 	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/toolschema"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
+// MockToolService is a mock implementation of ToolService
+type MockToolService struct {
+	mock.Mock
+}
+
+func (m *MockToolService) GetToolSchemas(ctx context.Context, in service.GetToolSchemasInput) (*toolschema.Output, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*toolschema.Output), args.Error(1)
+}
+
 func setupToolRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
@@ -84,7 +102,7 @@ func TestToolHandler_RenameToolName(t *testing.T) {
 				t.Skip("Skipping test that requires Core service integration")
 			}
 
-			handler := NewToolHandler(getMockToolCoreClient())
+			handler := NewToolHandler(&MockToolService{}, getMockToolCoreClient())
 			router := setupToolRouter()
 			// Add middleware to set project in context
 			router.Use(func(c *gin.Context) {
@@ -114,7 +132,7 @@ func TestToolHandler_GetToolName(t *testing.T) {
 	t.Run("successful get tool names", func(t *testing.T) {
 		t.Skip("Skipping test that requires Core service integration")
 
-		handler := NewToolHandler(getMockToolCoreClient())
+		handler := NewToolHandler(&MockToolService{}, getMockToolCoreClient())
 		router := setupToolRouter()
 
 		router.Use(func(c *gin.Context) {
@@ -133,3 +151,181 @@ func TestToolHandler_GetToolName(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 	})
 }
+
+func TestToolHandler_ConvertTools(t *testing.T) {
+	weatherTool := map[string]interface{}{
+		"type": "function",
+		"function": map[string]interface{}{
+			"name": "get_weather",
+			"parameters": map[string]interface{}{
+				"type":                 "object",
+				"properties":           map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+				"additionalProperties": false,
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		expectedStatus int
+		checkResponse  func(*testing.T, map[string]interface{})
+	}{
+		{
+			name: "openai to gemini",
+			requestBody: map[string]interface{}{
+				"from":  "openai",
+				"to":    "gemini",
+				"tools": []interface{}{weatherTool},
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, data map[string]interface{}) {
+				tools := data["tools"].([]interface{})
+				assert.Len(t, tools, 1)
+				declarations := tools[0].(map[string]interface{})["functionDeclarations"].([]interface{})
+				assert.Equal(t, "get_weather", declarations[0].(map[string]interface{})["name"])
+				assert.Len(t, data["warnings"], 1)
+			},
+		},
+		{
+			name: "openai to anthropic",
+			requestBody: map[string]interface{}{
+				"from":  "openai",
+				"to":    "anthropic",
+				"tools": []interface{}{weatherTool},
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, data map[string]interface{}) {
+				tool := data["tools"].([]interface{})[0].(map[string]interface{})
+				assert.Equal(t, "get_weather", tool["name"])
+				assert.NotNil(t, tool["input_schema"])
+				assert.Nil(t, data["warnings"])
+			},
+		},
+		{
+			name: "invalid from format",
+			requestBody: map[string]interface{}{
+				"from":  "cohere",
+				"to":    "openai",
+				"tools": []interface{}{weatherTool},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "empty tools",
+			requestBody: map[string]interface{}{
+				"from":  "openai",
+				"to":    "anthropic",
+				"tools": []interface{}{},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "strict mode rejects open schema",
+			requestBody: map[string]interface{}{
+				"from": "anthropic",
+				"to":   "openai",
+				"tools": []interface{}{map[string]interface{}{
+					"name":         "f",
+					"input_schema": map[string]interface{}{"type": "object", "additionalProperties": true},
+				}},
+				"strict": true,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewToolHandler(&MockToolService{}, getMockToolCoreClient())
+			router := setupToolRouter()
+			router.POST("/tool/convert", handler.ConvertTools)
+
+			body, _ := sonic.Marshal(tt.requestBody)
+			req := httptest.NewRequest("POST", "/tool/convert", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.checkResponse != nil {
+				var resp map[string]interface{}
+				assert.NoError(t, sonic.Unmarshal(w.Body.Bytes(), &resp))
+				tt.checkResponse(t, resp["data"].(map[string]interface{}))
+			}
+		})
+	}
+}
+
+func TestToolHandler_GetToolSchemas(t *testing.T) {
+	projectID := uuid.New()
+
+	tests := []struct {
+		name           string
+		queryParams    string
+		setup          func(*MockToolService)
+		expectedStatus int
+	}{
+		{
+			name:        "default format",
+			queryParams: "",
+			setup: func(svc *MockToolService) {
+				svc.On("GetToolSchemas", mock.Anything, service.GetToolSchemasInput{
+					ProjectID: projectID,
+					Format:    model.FormatOpenAI,
+				}).Return(&toolschema.Output{Tools: []interface{}{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "gemini strict",
+			queryParams: "?format=gemini&strict=true",
+			setup: func(svc *MockToolService) {
+				svc.On("GetToolSchemas", mock.Anything, service.GetToolSchemasInput{
+					ProjectID: projectID,
+					Format:    model.FormatGemini,
+					Strict:    true,
+				}).Return(&toolschema.Output{Tools: []interface{}{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid format",
+			queryParams:    "?format=cohere",
+			setup:          func(svc *MockToolService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "service error",
+			queryParams: "?format=openai&strict=true",
+			setup: func(svc *MockToolService) {
+				svc.On("GetToolSchemas", mock.Anything, mock.Anything).Return(nil, errors.New("strict mode requires an object schema"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockToolService{}
+			tt.setup(mockService)
+
+			handler := NewToolHandler(mockService, getMockToolCoreClient())
+			router := setupToolRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+				c.Next()
+			})
+			router.GET("/tool/schemas", handler.GetToolSchemas)
+
+			req := httptest.NewRequest("GET", "/tool/schemas"+tt.queryParams, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
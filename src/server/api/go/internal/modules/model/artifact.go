@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,6 +20,12 @@ type Disk struct {
 	ProjectID uuid.UUID  `gorm:"type:uuid;not null;index" json:"project_id"`
 	UserID    *uuid.UUID `gorm:"type:uuid;index" json:"user_id"`
 
+	// VersionRetention is how many previous versions are kept per artifact; 0 disables versioning
+	VersionRetention int `gorm:"not null;default:10" json:"version_retention"`
+
+	// ChangeSeq is the seq of the last entry in the change log of the disk
+	ChangeSeq int64 `gorm:"not null;default:0" json:"change_seq"`
+
 	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 
@@ -33,22 +40,110 @@ func (Disk) TableName() string { return "disks" }
 
 type Artifact struct {
 	ID        uuid.UUID                 `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"-"`
-	DiskID    uuid.UUID                 `gorm:"type:uuid;not null;index;uniqueIndex:idx_disk_path_filename" json:"disk_id"`
-	Path      string                    `gorm:"type:text;not null;uniqueIndex:idx_disk_path_filename" json:"path"`
+	DiskID    uuid.UUID                 `gorm:"type:uuid;not null;index;uniqueIndex:idx_disk_path_filename;index:idx_disk_path_prefix,priority:1" json:"disk_id"`
+	Path      string                    `gorm:"type:text;not null;uniqueIndex:idx_disk_path_filename;index:idx_disk_path_prefix,priority:2,expression:path text_pattern_ops" json:"path"`
 	Filename  string                    `gorm:"type:text;not null;uniqueIndex:idx_disk_path_filename" json:"filename"`
-	Meta      datatypes.JSONMap         `gorm:"type:jsonb" swaggertype:"object" json:"meta"`
+	Meta      datatypes.JSONMap         `gorm:"type:jsonb;index:idx_artifact_meta,type:gin" swaggertype:"object" json:"meta"`
 	AssetMeta datatypes.JSONType[Asset] `gorm:"type:jsonb;not null" swaggertype:"-" json:"-"`
 
+	// Version of the current content; previous versions are kept as ArtifactVersion rows
+	Version int `gorm:"not null;default:1" json:"version"`
+
 	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 
 	// Artifact <-> Disk
 	Disk *Disk `gorm:"foreignKey:DiskID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+
+	// Artifact <-> ArtifactVersion
+	Versions []ArtifactVersion `gorm:"constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
 }
 
 func (Artifact) TableName() string { return "artifacts" }
 
+// ETag identifies the current state of the artifact for conditional requests. It combines the
+// SHA256 of the content with the version, which is bumped by every write, meta updates included.
+func (a *Artifact) ETag() string {
+	return strconv.Quote(a.AssetMeta.Data().SHA256 + "-" + strconv.Itoa(a.Version))
+}
+
 // GetReservedKeys returns a list of reserved metadata keys for Artifact
 func (Artifact) GetReservedKeys() []string {
 	return []string{ArtifactInfoKey}
 }
+
+// ArtifactVersion is a previous content of an artifact, archived when the artifact is overwritten,
+// updated or restored. Each version holds its own asset reference.
+type ArtifactVersion struct {
+	ID         uuid.UUID                 `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"-"`
+	ArtifactID uuid.UUID                 `gorm:"type:uuid;not null;uniqueIndex:idx_artifact_version,priority:1" json:"-"`
+	DiskID     uuid.UUID                 `gorm:"type:uuid;not null;index" json:"disk_id"`
+	Version    int                       `gorm:"not null;uniqueIndex:idx_artifact_version,priority:2" json:"version"`
+	Meta       datatypes.JSONMap         `gorm:"type:jsonb" swaggertype:"object" json:"meta"`
+	AssetMeta  datatypes.JSONType[Asset] `gorm:"type:jsonb;not null" swaggertype:"-" json:"-"`
+
+	// CreatedAt is when this content was replaced
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// ArtifactVersion <-> Artifact
+	Artifact *Artifact `gorm:"foreignKey:ArtifactID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+}
+
+func (ArtifactVersion) TableName() string { return "artifact_versions" }
+
+// DiskSnapshot is a point-in-time copy of the artifacts of a disk. Its artifacts share the
+// stored files of the disk and hold their own asset references.
+type DiskSnapshot struct {
+	ID     uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	DiskID uuid.UUID `gorm:"type:uuid;not null;index" json:"disk_id"`
+	Name   string    `gorm:"type:text;not null;default:''" json:"name"`
+
+	ArtifactCount int   `gorm:"not null;default:0" json:"artifact_count"`
+	SizeB         int64 `gorm:"not null;default:0" json:"size_b"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// DiskSnapshot <-> Disk
+	Disk *Disk `gorm:"foreignKey:DiskID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+
+	// DiskSnapshot <-> DiskSnapshotArtifact
+	Artifacts []DiskSnapshotArtifact `gorm:"foreignKey:SnapshotID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+}
+
+func (DiskSnapshot) TableName() string { return "disk_snapshots" }
+
+// DiskSnapshotArtifact is an artifact as it was when its snapshot was taken
+type DiskSnapshotArtifact struct {
+	ID         uuid.UUID                 `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"-"`
+	SnapshotID uuid.UUID                 `gorm:"type:uuid;not null;index" json:"-"`
+	Path       string                    `gorm:"type:text;not null" json:"path"`
+	Filename   string                    `gorm:"type:text;not null" json:"filename"`
+	Meta       datatypes.JSONMap         `gorm:"type:jsonb" swaggertype:"object" json:"meta"`
+	AssetMeta  datatypes.JSONType[Asset] `gorm:"type:jsonb;not null" swaggertype:"-" json:"-"`
+}
+
+func (DiskSnapshotArtifact) TableName() string { return "disk_snapshot_artifacts" }
+
+// ArtifactUpload is a file being uploaded directly to S3 through a presigned URL. The file is
+// staged under S3Key until the upload is finalized into an artifact at Path and Filename.
+type ArtifactUpload struct {
+	ID        uuid.UUID         `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ProjectID uuid.UUID         `gorm:"type:uuid;not null;index" json:"-"`
+	DiskID    uuid.UUID         `gorm:"type:uuid;not null;index" json:"disk_id"`
+	Path      string            `gorm:"type:text;not null" json:"path"`
+	Filename  string            `gorm:"type:text;not null" json:"filename"`
+	Meta      datatypes.JSONMap `gorm:"type:jsonb" swaggertype:"object" json:"meta"`
+	S3Key     string            `gorm:"type:text;not null" json:"-"`
+
+	// SizeB and SHA256 are declared by the client and checked when the upload is finalized
+	SizeB  int64  `gorm:"not null" json:"size_b"`
+	SHA256 string `gorm:"type:text;not null" json:"sha256"`
+
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// ArtifactUpload <-> Disk
+	Disk *Disk `gorm:"foreignKey:DiskID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+}
+
+func (ArtifactUpload) TableName() string { return "artifact_uploads" }
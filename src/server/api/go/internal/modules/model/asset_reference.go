@@ -56,10 +56,11 @@ type Asset struct {
 	SHA256  string `json:"sha256"`
 	MIME    string `json:"mime"`
 	SizeB   int64  `json:"size_b"`
-	Content string `json:"content,omitempty"` // Text content for text-searchable files (text/*, application/json, application/x-*)
+	Content string `json:"content,omitempty"` // Text content of text files, or the text extracted from documents
 }
 
-// IsOrphaned returns true if this asset has no references
+// IsOrphaned returns true if this asset has no references. Orphaned assets are deleted with
+// their S3 object by the garbage collector once they have been orphaned for a grace period.
 func (a *AssetReference) IsOrphaned() bool {
 	return a.RefCount <= 0
 }
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Disk change operations
+const (
+	DiskChangeCreate = "create"
+	DiskChangeUpdate = "update"
+	DiskChangeDelete = "delete"
+	DiskChangeMove   = "move"
+)
+
+// DiskChange is an entry of the change log of a disk. Seq grows by one with every artifact change
+// of the disk in commit order, so a client can sync by asking for the changes after the last seq
+// it has seen.
+type DiskChange struct {
+	DiskID uuid.UUID `gorm:"type:uuid;primaryKey" json:"disk_id"`
+	Seq    int64     `gorm:"primaryKey;autoIncrement:false" json:"seq"`
+	Op     string    `gorm:"type:text;not null" json:"op"`
+
+	// Path and Filename are where the artifact is after the change, or where it was for a delete
+	Path     string `gorm:"type:text;not null" json:"path"`
+	Filename string `gorm:"type:text;not null" json:"filename"`
+
+	// FromPath and FromFilename are where a moved artifact came from
+	FromPath     string `gorm:"type:text;not null;default:''" json:"from_path,omitempty"`
+	FromFilename string `gorm:"type:text;not null;default:''" json:"from_filename,omitempty"`
+
+	// Version and SHA256 identify the content after the change; they are empty for a delete
+	Version int    `gorm:"not null;default:0" json:"version,omitempty"`
+	SHA256  string `gorm:"type:text;not null;default:''" json:"sha256,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// DiskChange <-> Disk
+	Disk *Disk `gorm:"foreignKey:DiskID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+}
+
+func (DiskChange) TableName() string { return "disk_changes" }
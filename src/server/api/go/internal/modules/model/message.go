@@ -15,6 +15,7 @@ const (
 	FormatOpenAI    MessageFormat = "openai"
 	FormatAnthropic MessageFormat = "anthropic"
 	FormatGemini    MessageFormat = "gemini"
+	FormatBedrock   MessageFormat = "bedrock"
 )
 
 // Reserved metadata keys that are not allowed in user metadata
@@ -61,10 +62,10 @@ func (Message) GetReservedKeys() []string {
 }
 
 type Part struct {
-	// "text" | "image" | "audio" | "video" | "file" | "tool-call" | "tool-result" | "data"
+	// "text" | "image" | "audio" | "video" | "file" | "tool-call" | "tool-result" | "thinking" | "data"
 	Type string `json:"type"`
 
-	// text part
+	// text part (also the reasoning text of a thinking part)
 	Text string `json:"text,omitempty"`
 
 	// media part
@@ -73,4 +74,20 @@ type Part struct {
 
 	// embedding、ocr、asr、caption...
 	Meta map[string]any `json:"meta,omitempty"`
+
+	// nested content of a tool-result part (text, image and file parts, in order).
+	// Text still holds the concatenated text for consumers that only handle text results.
+	Content []Part `json:"content,omitempty"`
+}
+
+// Assets returns the assets referenced by the part, including those of its nested content
+func (p *Part) Assets() []Asset {
+	assets := []Asset{}
+	if p.Asset != nil {
+		assets = append(assets, *p.Asset)
+	}
+	for i := range p.Content {
+		assets = append(assets, p.Content[i].Assets()...)
+	}
+	return assets
 }
@@ -32,6 +32,9 @@ type Session struct {
 
 	// Session <-> Task
 	Tasks []Task `gorm:"constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+
+	// Session <-> SessionSystemPrompt
+	SystemPrompts []SessionSystemPrompt `gorm:"constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
 }
 
 func (Session) TableName() string { return "sessions" }
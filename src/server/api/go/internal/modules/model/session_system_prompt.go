@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionSystemPrompt is one immutable version of a session's system prompt.
+// Versions start at 1 and increase by one on every change; the highest version is the active prompt.
+type SessionSystemPrompt struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	SessionID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:ux_session_system_prompts_session_version,priority:1" json:"session_id"`
+	Version   int       `gorm:"not null;uniqueIndex:ux_session_system_prompts_session_version,priority:2" json:"version"`
+	Content   string    `gorm:"type:text;not null" json:"content"`
+
+	// AppendSpaceUseWhen appends the use_when conditions of the SOPs in the session's space to the prompt
+	AppendSpaceUseWhen bool `gorm:"not null;default:false" json:"append_space_use_when"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// SessionSystemPrompt <-> Session
+	Session *Session `gorm:"foreignKey:SessionID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+}
+
+func (SessionSystemPrompt) TableName() string { return "session_system_prompts" }
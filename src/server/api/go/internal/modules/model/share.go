@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Share scopes
+const (
+	ShareScopeRead = "read"
+)
+
+// DiskShare is a link that gives access to a file or a directory of a disk without a project
+// token. Only an HMAC of the link token is stored, so a lost token cannot be recovered.
+type DiskShare struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ProjectID uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	DiskID    uuid.UUID `gorm:"type:uuid;not null;index" json:"disk_id"`
+
+	// Path is the shared directory, or the directory of the shared file when Filename is set
+	Path     string `gorm:"type:text;not null" json:"path"`
+	Filename string `gorm:"type:text;not null;default:''" json:"filename"`
+	Scope    string `gorm:"type:text;not null;default:'read'" json:"scope"`
+
+	TokenHMAC string `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+
+	// MaxDownloads limits how many files can be downloaded through the link; nil means unlimited
+	MaxDownloads *int `json:"max_downloads"`
+	Downloads    int  `gorm:"not null;default:0" json:"downloads"`
+
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// DiskShare <-> Disk
+	Disk *Disk `gorm:"foreignKey:DiskID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+}
+
+func (DiskShare) TableName() string { return "disk_shares" }
+
+// IsDirectory reports whether the share covers a directory rather than a single file
+func (s *DiskShare) IsDirectory() bool { return s.Filename == "" }
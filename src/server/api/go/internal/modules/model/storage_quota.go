@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Storage quota scopes
+const (
+	QuotaScopeProject = "project"
+	QuotaScopeUser    = "user"
+	QuotaScopeDisk    = "disk"
+)
+
+// StorageQuota limits the bytes and files stored by a project, a user or a disk. A nil limit
+// means unlimited. Bytes are counted from the current content of artifacts and agent skills.
+type StorageQuota struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"-"`
+	ProjectID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_storage_quota_scope,priority:1" json:"-"`
+	Scope     string    `gorm:"type:text;not null;uniqueIndex:idx_storage_quota_scope,priority:2" json:"scope"`
+	ScopeID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_storage_quota_scope,priority:3" json:"scope_id"`
+
+	MaxBytes *int64 `json:"max_bytes"`
+	MaxFiles *int64 `json:"max_files"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+
+	// StorageQuota <-> Project
+	Project *Project `gorm:"foreignKey:ProjectID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+}
+
+func (StorageQuota) TableName() string { return "storage_quotas" }
@@ -21,10 +21,10 @@ type AgentSkillsRepo interface {
 
 type agentSkillsRepo struct {
 	db *gorm.DB
-	s3 *blob.S3Deps
+	s3 blob.BlobStore
 }
 
-func NewAgentSkillsRepo(db *gorm.DB, s3 *blob.S3Deps) AgentSkillsRepo {
+func NewAgentSkillsRepo(db *gorm.DB, s3 blob.BlobStore) AgentSkillsRepo {
 	return &agentSkillsRepo{
 		db: db,
 		s3: s3,
@@ -2,24 +2,67 @@ package repo
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/path"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ArtifactRepo interface {
 	Create(ctx context.Context, projectID uuid.UUID, a *model.Artifact) error
-	DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string) error
+	DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string, ifVersion int) error
 	Update(ctx context.Context, a *model.Artifact) error
 	GetByPath(ctx context.Context, diskID uuid.UUID, path string, filename string) (*model.Artifact, error)
 	ListByPath(ctx context.Context, diskID uuid.UUID, path string) ([]*model.Artifact, error)
+	ListByPathWithCursor(ctx context.Context, diskID uuid.UUID, path string, filter ArtifactFilter, afterCreatedAt time.Time, afterID uuid.UUID, limit int) ([]*model.Artifact, error)
 	GetAllPaths(ctx context.Context, diskID uuid.UUID) ([]string, error)
 	ExistsByPathAndFilename(ctx context.Context, diskID uuid.UUID, path string, filename string, excludeID *uuid.UUID) (bool, error)
-	GrepArtifacts(ctx context.Context, diskID uuid.UUID, pattern string, limit int) ([]*model.Artifact, error)
-	GlobArtifacts(ctx context.Context, diskID uuid.UUID, pattern string, limit int) ([]*model.Artifact, error)
+	GrepArtifactsWithFilter(ctx context.Context, diskID uuid.UUID, filter GrepFilter, limit int) ([]*model.Artifact, error)
+	GlobArtifacts(ctx context.Context, diskID uuid.UUID, glob *path.Glob, filter ArtifactFilter, limit int) ([]*model.Artifact, error)
+	GetPathsUnder(ctx context.Context, diskID uuid.UUID, dir string) ([]string, error)
+	Replace(ctx context.Context, a *model.Artifact, asset model.Asset, meta map[string]interface{}, ifVersion int) error
+	SaveAll(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, writes []ArtifactWrite) error
+	ListVersions(ctx context.Context, artifactID uuid.UUID) ([]*model.ArtifactVersion, error)
+	GetVersion(ctx context.Context, artifactID uuid.UUID, version int) (*model.ArtifactVersion, error)
+	ListUnderPath(ctx context.Context, diskID uuid.UUID, dir string) ([]*model.Artifact, error)
+	Move(ctx context.Context, projectID uuid.UUID, transfers []ArtifactTransfer, onConflict OnConflict) ([]ArtifactTransfer, error)
+	Copy(ctx context.Context, projectID uuid.UUID, transfers []ArtifactTransfer, onConflict OnConflict) ([]*model.Artifact, []ArtifactTransfer, error)
+	CreateUpload(ctx context.Context, u *model.ArtifactUpload) error
+	GetUpload(ctx context.Context, diskID uuid.UUID, uploadID uuid.UUID) (*model.ArtifactUpload, error)
+	DeleteUpload(ctx context.Context, uploadID uuid.UUID) error
+	ListExpiredUploads(ctx context.Context, before time.Time, limit int) ([]*model.ArtifactUpload, error)
+}
+
+var (
+	// ErrArtifactConflict is returned when the destination of a move or copy is already taken
+	ErrArtifactConflict = errors.New("artifact already exists at destination")
+	// ErrPreconditionFailed is returned when a conditional write finds the artifact at another version
+	ErrPreconditionFailed = errors.New("artifact precondition failed")
+)
+
+// OnConflict tells a move or copy what to do with a destination that is already taken
+type OnConflict string
+
+const (
+	OnConflictError     OnConflict = "error"     // Fail the whole operation
+	OnConflictSkip      OnConflict = "skip"      // Leave the source in place
+	OnConflictOverwrite OnConflict = "overwrite" // Delete the artifact at the destination with its versions
+)
+
+// ArtifactTransfer is an artifact and where it is moved or copied to
+type ArtifactTransfer struct {
+	Artifact *model.Artifact
+	Path     string
+	Filename string
+	Meta     map[string]interface{} // Meta of the artifact at the destination
 }
 
 type artifactRepo struct {
@@ -32,24 +75,39 @@ func NewArtifactRepo(db *gorm.DB, assetReferenceRepo AssetReferenceRepo) Artifac
 }
 
 func (r *artifactRepo) Create(ctx context.Context, projectID uuid.UUID, a *model.Artifact) error {
-	// Save asset meta before creation for reference increment
-	asset := a.AssetMeta.Data()
-
-	// Use transaction to ensure atomicity: create artifact and increment reference
+	// Use transaction to ensure atomicity: create artifact, increment reference and log the change
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(a).Error; err != nil {
+		log, err := lockChangeLog(tx, a.DiskID)
+		if err != nil {
 			return err
 		}
-
-		if err := r.assetReferenceRepo.IncrementAssetRef(ctx, projectID, asset); err != nil {
-			return fmt.Errorf("increment asset reference: %w", err)
+		if err := r.create(ctx, tx, log, projectID, a); err != nil {
+			return err
 		}
-
-		return nil
+		return log.flush()
 	})
 }
 
-func (r *artifactRepo) DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string) error {
+// create inserts an artifact and references its asset within tx
+func (r *artifactRepo) create(ctx context.Context, tx *gorm.DB, log *changeLog, projectID uuid.UUID, a *model.Artifact) error {
+	// Save asset meta before creation for reference increment
+	asset := a.AssetMeta.Data()
+
+	if err := tx.Create(a).Error; err != nil {
+		return err
+	}
+
+	if err := r.assetReferenceRepo.WithTx(tx).IncrementAssetRef(ctx, projectID, asset); err != nil {
+		return fmt.Errorf("increment asset reference: %w", err)
+	}
+
+	log.add(model.DiskChangeCreate, a)
+	return nil
+}
+
+// DeleteByPath deletes an artifact with its versions. A positive ifVersion only deletes the artifact
+// if it is still at that version.
+func (r *artifactRepo) DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string, ifVersion int) error {
 	var a model.Artifact
 	err := r.db.WithContext(ctx).Where("disk_id = ? AND path = ? AND filename = ?", diskID, path, filename).First(&a).Error
 	if err != nil {
@@ -60,19 +118,42 @@ func (r *artifactRepo) DeleteByPath(ctx context.Context, projectID uuid.UUID, di
 	}
 
 	// Save asset meta before deletion for reference decrement
-	asset := a.AssetMeta.Data()
+	assets := []model.Asset{a.AssetMeta.Data()}
 
-	// Use transaction to ensure atomicity: delete artifact and decrement reference
+	// Use transaction to ensure atomicity: delete artifact, decrement references and log the change
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Delete(&a).Error; err != nil {
+		log, err := lockChangeLog(tx, diskID)
+		if err != nil {
 			return err
 		}
 
-		if err := r.assetReferenceRepo.DecrementAssetRef(ctx, projectID, asset); err != nil {
+		// Versions are deleted by CASCADE, but each of them holds an asset reference
+		var versions []model.ArtifactVersion
+		if err := tx.Where("artifact_id = ?", a.ID).Find(&versions).Error; err != nil {
+			return fmt.Errorf("query artifact versions: %w", err)
+		}
+		for _, v := range versions {
+			assets = append(assets, v.AssetMeta.Data())
+		}
+
+		del := tx
+		if ifVersion > 0 {
+			del = del.Where("version = ?", ifVersion)
+		}
+		res := del.Delete(&a)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrPreconditionFailed
+		}
+
+		if err := r.assetReferenceRepo.WithTx(tx).BatchDecrementAssetRefs(ctx, projectID, assets); err != nil {
 			return fmt.Errorf("decrement asset reference: %w", err)
 		}
 
-		return nil
+		log.add(model.DiskChangeDelete, &a)
+		return log.flush()
 	})
 }
 
@@ -105,6 +186,62 @@ func (r *artifactRepo) ListByPath(ctx context.Context, diskID uuid.UUID, path st
 	return artifacts, nil
 }
 
+// ArtifactFilter narrows listings and searches by user meta, type, size and modification time.
+// The zero value matches every artifact.
+type ArtifactFilter struct {
+	Meta         map[string]interface{} // Key-value pairs the meta must contain (JSONB containment)
+	MetaKeys     []string               // Keys the meta must have, whatever their value
+	MIME         string                 // Exact MIME type, or a family such as text/*
+	MinSizeB     *int64
+	MaxSizeB     *int64
+	UpdatedAfter *time.Time
+}
+
+// apply adds the conditions of the filter to a query on artifacts
+func (f ArtifactFilter) apply(query *gorm.DB) (*gorm.DB, error) {
+	if len(f.Meta) > 0 {
+		meta, err := json.Marshal(f.Meta)
+		if err != nil {
+			return nil, fmt.Errorf("encode meta filter: %w", err)
+		}
+		query = query.Where("meta @> ?::jsonb", string(meta))
+	}
+	for _, key := range f.MetaKeys {
+		query = query.Where("jsonb_exists(meta, ?)", key)
+	}
+	if family, ok := strings.CutSuffix(f.MIME, "/*"); ok {
+		query = query.Where("(asset_meta->>'mime') LIKE ?", escapeLike(family)+"/%")
+	} else if f.MIME != "" {
+		query = query.Where("(asset_meta->>'mime') = ?", f.MIME)
+	}
+	if f.MinSizeB != nil {
+		query = query.Where("(asset_meta->>'size_b')::bigint >= ?", *f.MinSizeB)
+	}
+	if f.MaxSizeB != nil {
+		query = query.Where("(asset_meta->>'size_b')::bigint <= ?", *f.MaxSizeB)
+	}
+	if f.UpdatedAfter != nil {
+		query = query.Where("updated_at > ?", *f.UpdatedAfter)
+	}
+	return query, nil
+}
+
+// ListByPathWithCursor lists the artifacts directly in path matching filter, oldest first
+func (r *artifactRepo) ListByPathWithCursor(ctx context.Context, diskID uuid.UUID, path string, filter ArtifactFilter, afterCreatedAt time.Time, afterID uuid.UUID, limit int) ([]*model.Artifact, error) {
+	q, err := filter.apply(r.db.WithContext(ctx).Where("disk_id = ? AND path = ?", diskID, path))
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply cursor-based pagination filter if cursor is provided
+	if !afterCreatedAt.IsZero() && afterID != uuid.Nil {
+		q = q.Where("(created_at > ?) OR (created_at = ? AND id > ?)", afterCreatedAt, afterCreatedAt, afterID)
+	}
+
+	var artifacts []*model.Artifact
+	return artifacts, q.Order("created_at ASC, id ASC").Limit(limit).Find(&artifacts).Error
+}
+
 func (r *artifactRepo) GetAllPaths(ctx context.Context, diskID uuid.UUID) ([]string, error) {
 	var paths []string
 	err := r.db.WithContext(ctx).
@@ -137,20 +274,37 @@ func (r *artifactRepo) ExistsByPathAndFilename(ctx context.Context, diskID uuid.
 	return count > 0, nil
 }
 
-func (r *artifactRepo) GrepArtifacts(ctx context.Context, diskID uuid.UUID, pattern string, limit int) ([]*model.Artifact, error) {
+// GrepFilter selects the artifacts whose text content is searched
+type GrepFilter struct {
+	Pattern    string     // Regex matched against the text content
+	IgnoreCase bool       // Match the pattern case-insensitively
+	Glob       *path.Glob // Optional glob the full file path must match
+	ArtifactFilter
+}
+
+func (r *artifactRepo) GrepArtifactsWithFilter(ctx context.Context, diskID uuid.UUID, filter GrepFilter, limit int) ([]*model.Artifact, error) {
 	var artifacts []*model.Artifact
 
-	// Use regex pattern matching on text content
-	// Filter by text-searchable mime types and ensure content is not null
-	// This matches the index condition for optimal performance
+	regexOp := "~"
+	if filter.IgnoreCase {
+		regexOp = "~*"
+	}
+
+	// Use regex pattern matching on text content. Any artifact with content is searched, which
+	// includes the text extracted from documents such as PDF and DOCX files.
 	query := r.db.WithContext(ctx).
 		Where("disk_id = ?", diskID).
 		Where("(asset_meta->>'content') IS NOT NULL").
-		Where("((asset_meta->>'mime') LIKE 'text/%' OR (asset_meta->>'mime') = 'application/json' OR (asset_meta->>'mime') LIKE 'application/x-%')").
-		Where("(asset_meta->>'content') ~ ?", pattern).
-		Limit(limit)
+		Where("(asset_meta->>'content') "+regexOp+" ?", filter.Pattern)
+	if filter.Glob != nil {
+		query = whereGlob(query, filter.Glob)
+	}
+	query, err := filter.ArtifactFilter.apply(query)
+	if err != nil {
+		return nil, err
+	}
 
-	err := query.Find(&artifacts).Error
+	err = query.Order("path, filename").Limit(limit).Find(&artifacts).Error
 	if err != nil {
 		return nil, err
 	}
@@ -158,33 +312,440 @@ func (r *artifactRepo) GrepArtifacts(ctx context.Context, diskID uuid.UUID, patt
 	return artifacts, nil
 }
 
-func (r *artifactRepo) GlobArtifacts(ctx context.Context, diskID uuid.UUID, pattern string, limit int) ([]*model.Artifact, error) {
+// whereGlob restricts a query to artifacts whose full path matches a glob. The literal directory
+// of the pattern narrows the rows through the (disk_id, path) indexes before the regex is applied.
+func whereGlob(query *gorm.DB, glob *path.Glob) *gorm.DB {
+	if glob.ExactDir() {
+		query = query.Where("path = ?", glob.Dir())
+	} else if glob.Dir() != "/" {
+		query = query.Where("path LIKE ?", escapeLike(glob.Dir())+"%")
+	}
+	return query.Where("(path || filename) ~ ?", glob.Regex())
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern so s is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (r *artifactRepo) GlobArtifacts(ctx context.Context, diskID uuid.UUID, glob *path.Glob, filter ArtifactFilter, limit int) ([]*model.Artifact, error) {
 	var artifacts []*model.Artifact
 
-	// Convert glob pattern to PostgreSQL LIKE pattern
-	// Replace ** with % (recursive directory matching)
-	// Replace * with % (any characters)
-	// Replace ? with _ (single character)
-	// Note: ** must be replaced before * to avoid double replacement
-	sqlPattern := strings.ReplaceAll(pattern, "**", "%")
-	sqlPattern = strings.ReplaceAll(sqlPattern, "*", "%")
-	sqlPattern = strings.ReplaceAll(sqlPattern, "?", "_")
-
-	// Escape special LIKE characters that aren't glob patterns
-	// PostgreSQL LIKE uses % and _ as wildcards, \ as escape
-	// We need to escape any literal % or _ that aren't from our replacements
-	// But since we're doing full replacement, we don't need to escape
-
-	// Match against combined path/filename
+	query, err := filter.apply(whereGlob(r.db.WithContext(ctx).Where("disk_id = ?", diskID), glob))
+	if err != nil {
+		return nil, err
+	}
+
+	err = query.Order("path, filename").Limit(limit).Find(&artifacts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return artifacts, nil
+}
+
+// GetPathsUnder returns the distinct paths of the artifacts in dir or below it
+func (r *artifactRepo) GetPathsUnder(ctx context.Context, diskID uuid.UUID, dir string) ([]string, error) {
+	var paths []string
 	query := r.db.WithContext(ctx).
-		Where("disk_id = ?", diskID).
-		Where("(path || '/' || filename) LIKE ?", sqlPattern).
-		Limit(limit)
+		Model(&model.Artifact{}).
+		Where("disk_id = ?", diskID)
+	if dir != "/" {
+		query = query.Where("path LIKE ?", escapeLike(dir)+"%")
+	}
+	err := query.Distinct("path").Order("path").Pluck("path", &paths).Error
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
 
-	err := query.Find(&artifacts).Error
+// Replace sets new content and meta on an existing artifact. The previous content is archived
+// as a version unless the disk's version retention is 0, and versions beyond the retention are pruned.
+// a must be loaded from the database and is updated in place. A positive ifVersion only replaces
+// the artifact if it is still at that version.
+func (r *artifactRepo) Replace(ctx context.Context, a *model.Artifact, asset model.Asset, meta map[string]interface{}, ifVersion int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		log, err := lockChangeLog(tx, a.DiskID)
+		if err != nil {
+			return err
+		}
+		if err := r.replace(ctx, tx, log, a, asset, meta, ifVersion); err != nil {
+			return err
+		}
+		return log.flush()
+	})
+}
+
+// replace sets new content and meta on an existing artifact within tx, as described by Replace
+func (r *artifactRepo) replace(ctx context.Context, tx *gorm.DB, log *changeLog, a *model.Artifact, asset model.Asset, meta map[string]interface{}, ifVersion int) error {
+	// Lock the artifact so concurrent writes get consecutive versions
+	var current model.Artifact
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND disk_id = ?", a.ID, a.DiskID).
+		First(&current).Error; err != nil {
+		return err
+	}
+	if ifVersion > 0 && current.Version != ifVersion {
+		return ErrPreconditionFailed
+	}
+
+	// The disk provides the project for asset references and the version retention
+	var disk model.Disk
+	if err := tx.Select("project_id", "version_retention").Where("id = ?", a.DiskID).First(&disk).Error; err != nil {
+		return fmt.Errorf("get disk: %w", err)
+	}
+
+	// Assets released by the replaced content or the pruned versions
+	var decrements []model.Asset
+
+	if disk.VersionRetention > 0 {
+		version := &model.ArtifactVersion{
+			ArtifactID: current.ID,
+			DiskID:     current.DiskID,
+			Version:    current.Version,
+			Meta:       current.Meta,
+			AssetMeta:  current.AssetMeta,
+		}
+		if err := tx.Create(version).Error; err != nil {
+			return fmt.Errorf("archive artifact version: %w", err)
+		}
+	} else {
+		decrements = append(decrements, current.AssetMeta.Data())
+	}
+
+	// Keep the newest VersionRetention versions; this also applies a lowered retention
+	var pruned []model.ArtifactVersion
+	if err := tx.Where("artifact_id = ? AND version <= ?", current.ID, current.Version-disk.VersionRetention).
+		Find(&pruned).Error; err != nil {
+		return fmt.Errorf("query expired artifact versions: %w", err)
+	}
+	if len(pruned) > 0 {
+		if err := tx.Delete(&pruned).Error; err != nil {
+			return fmt.Errorf("prune artifact versions: %w", err)
+		}
+		for _, v := range pruned {
+			decrements = append(decrements, v.AssetMeta.Data())
+		}
+	}
+
+	updates := map[string]interface{}{
+		"asset_meta": datatypes.NewJSONType(asset),
+		"meta":       datatypes.JSONMap(meta),
+		"version":    current.Version + 1,
+	}
+	if err := tx.Model(&model.Artifact{}).Where("id = ?", current.ID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("update artifact: %w", err)
+	}
+
+	if err := r.assetReferenceRepo.WithTx(tx).IncrementAssetRef(ctx, disk.ProjectID, asset); err != nil {
+		return fmt.Errorf("increment asset reference: %w", err)
+	}
+	if len(decrements) > 0 {
+		if err := r.assetReferenceRepo.WithTx(tx).BatchDecrementAssetRefs(ctx, disk.ProjectID, decrements); err != nil {
+			return fmt.Errorf("decrement asset references: %w", err)
+		}
+	}
+
+	a.AssetMeta = datatypes.NewJSONType(asset)
+	a.Meta = meta
+	a.Version = current.Version + 1
+
+	log.add(model.DiskChangeUpdate, a)
+	return nil
+}
+
+// ArtifactWrite is one write of SaveAll: the creation of Artifact, or the replacement of the
+// content and meta of Existing with those of Artifact
+type ArtifactWrite struct {
+	Existing *model.Artifact // Artifact to replace, loaded from the database; nil to create Artifact
+	Artifact *model.Artifact
+}
+
+// SaveAll creates and replaces artifacts of a disk in one transaction, so that either every write
+// is applied or none is. Existing artifacts are updated in place.
+func (r *artifactRepo) SaveAll(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, writes []ArtifactWrite) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		log, err := lockChangeLog(tx, diskID)
+		if err != nil {
+			return err
+		}
+		for _, w := range writes {
+			if w.Existing == nil {
+				err = r.create(ctx, tx, log, projectID, w.Artifact)
+			} else {
+				err = r.replace(ctx, tx, log, w.Existing, w.Artifact.AssetMeta.Data(), w.Artifact.Meta, 0)
+			}
+			if err != nil {
+				return fmt.Errorf("save %s%s: %w", w.Artifact.Path, w.Artifact.Filename, err)
+			}
+		}
+		return log.flush()
+	})
+}
+
+func (r *artifactRepo) ListVersions(ctx context.Context, artifactID uuid.UUID) ([]*model.ArtifactVersion, error) {
+	var versions []*model.ArtifactVersion
+	err := r.db.WithContext(ctx).
+		Where("artifact_id = ?", artifactID).
+		Order("version DESC").
+		Find(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (r *artifactRepo) GetVersion(ctx context.Context, artifactID uuid.UUID, version int) (*model.ArtifactVersion, error) {
+	var v model.ArtifactVersion
+	err := r.db.WithContext(ctx).Where("artifact_id = ? AND version = ?", artifactID, version).First(&v).Error
 	if err != nil {
 		return nil, err
 	}
+	return &v, nil
+}
 
+// ListUnderPath returns the artifacts in dir or below it, sorted by path and filename
+func (r *artifactRepo) ListUnderPath(ctx context.Context, diskID uuid.UUID, dir string) ([]*model.Artifact, error) {
+	var artifacts []*model.Artifact
+	err := r.db.WithContext(ctx).
+		Where("disk_id = ? AND path LIKE ?", diskID, escapeLike(dir)+"%").
+		Order("path, filename").
+		Find(&artifacts).Error
+	if err != nil {
+		return nil, err
+	}
 	return artifacts, nil
 }
+
+// CreateUpload records a direct upload, failing with gorm.ErrRecordNotFound if its disk does not
+// exist in its project
+func (r *artifactRepo) CreateUpload(ctx context.Context, u *model.ArtifactUpload) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").Where("id = ? AND project_id = ?", u.DiskID, u.ProjectID).First(&model.Disk{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(u).Error
+	})
+}
+
+func (r *artifactRepo) GetUpload(ctx context.Context, diskID uuid.UUID, uploadID uuid.UUID) (*model.ArtifactUpload, error) {
+	var u model.ArtifactUpload
+	err := r.db.WithContext(ctx).Where("id = ? AND disk_id = ?", uploadID, diskID).First(&u).Error
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *artifactRepo) DeleteUpload(ctx context.Context, uploadID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", uploadID).Delete(&model.ArtifactUpload{}).Error
+}
+
+// ListExpiredUploads returns up to limit uploads whose URL expired before the given time, oldest first
+func (r *artifactRepo) ListExpiredUploads(ctx context.Context, before time.Time, limit int) ([]*model.ArtifactUpload, error) {
+	var uploads []*model.ArtifactUpload
+	err := r.db.WithContext(ctx).
+		Where("expires_at < ?", before).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&uploads).Error
+	return uploads, err
+}
+
+// Move changes the path and filename of artifacts in one transaction. Versions move with their
+// artifact and asset references are unchanged. It returns the transfers skipped on conflict.
+func (r *artifactRepo) Move(ctx context.Context, projectID uuid.UUID, transfers []ArtifactTransfer, onConflict OnConflict) ([]ArtifactTransfer, error) {
+	if len(transfers) == 0 {
+		return nil, nil
+	}
+
+	var skipped []ArtifactTransfer
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		log, err := lockChangeLog(tx, transfers[0].Artifact.DiskID)
+		if err != nil {
+			return err
+		}
+
+		transfers, skipped, err = r.resolveConflicts(ctx, tx, log, projectID, transfers, onConflict, true)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range transfers {
+			updates := map[string]interface{}{
+				"path":     t.Path,
+				"filename": t.Filename,
+				"meta":     datatypes.JSONMap(t.Meta),
+			}
+			if err := tx.Model(&model.Artifact{}).Where("id = ?", t.Artifact.ID).Updates(updates).Error; err != nil {
+				return fmt.Errorf("move artifact %s%s: %w", t.Artifact.Path, t.Artifact.Filename, err)
+			}
+			moved := *t.Artifact
+			moved.Path, moved.Filename = t.Path, t.Filename
+			log.addMove(&moved, t.Artifact.Path, t.Artifact.Filename)
+		}
+		return log.flush()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range transfers {
+		t.Artifact.Path = t.Path
+		t.Artifact.Filename = t.Filename
+		t.Artifact.Meta = t.Meta
+	}
+	return skipped, nil
+}
+
+// Copy creates new artifacts sharing the assets of the transferred ones in one transaction.
+// Versions are not copied. It returns the created artifacts and the transfers skipped on conflict.
+func (r *artifactRepo) Copy(ctx context.Context, projectID uuid.UUID, transfers []ArtifactTransfer, onConflict OnConflict) ([]*model.Artifact, []ArtifactTransfer, error) {
+	if len(transfers) == 0 {
+		return nil, nil, nil
+	}
+
+	var (
+		created []*model.Artifact
+		skipped []ArtifactTransfer
+	)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		log, err := lockChangeLog(tx, transfers[0].Artifact.DiskID)
+		if err != nil {
+			return err
+		}
+
+		transfers, skipped, err = r.resolveConflicts(ctx, tx, log, projectID, transfers, onConflict, false)
+		if err != nil {
+			return err
+		}
+		if len(transfers) == 0 {
+			return nil
+		}
+
+		created = make([]*model.Artifact, 0, len(transfers))
+		assets := make([]model.Asset, 0, len(transfers))
+		for _, t := range transfers {
+			created = append(created, &model.Artifact{
+				DiskID:    t.Artifact.DiskID,
+				Path:      t.Path,
+				Filename:  t.Filename,
+				Meta:      t.Meta,
+				AssetMeta: t.Artifact.AssetMeta,
+			})
+			assets = append(assets, t.Artifact.AssetMeta.Data())
+		}
+		if err := tx.Create(&created).Error; err != nil {
+			return fmt.Errorf("copy artifacts: %w", err)
+		}
+
+		// The copies share the S3 objects of their sources
+		if err := r.assetReferenceRepo.WithTx(tx).BatchIncrementAssetRefs(ctx, projectID, assets); err != nil {
+			return fmt.Errorf("increment asset references: %w", err)
+		}
+
+		for _, a := range created {
+			log.add(model.DiskChangeCreate, a)
+		}
+		return log.flush()
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return created, skipped, nil
+}
+
+// resolveConflicts locks the sources of a move or copy and applies onConflict to the destinations
+// that are already taken. Moved artifacts do not block each other's destinations, and overwritten
+// artifacts are logged as deleted.
+func (r *artifactRepo) resolveConflicts(ctx context.Context, tx *gorm.DB, log *changeLog, projectID uuid.UUID, transfers []ArtifactTransfer, onConflict OnConflict, move bool) ([]ArtifactTransfer, []ArtifactTransfer, error) {
+	if len(transfers) == 0 {
+		return nil, nil, nil
+	}
+	diskID := transfers[0].Artifact.DiskID
+
+	sourceIDs := make([]uuid.UUID, 0, len(transfers))
+	destinations := make([][]interface{}, 0, len(transfers))
+	for _, t := range transfers {
+		sourceIDs = append(sourceIDs, t.Artifact.ID)
+		destinations = append(destinations, []interface{}{t.Path, t.Filename})
+	}
+
+	// The sources must still exist when the transaction runs
+	var locked int64
+	if err := tx.Model(&model.Artifact{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("disk_id = ? AND id IN ?", diskID, sourceIDs).
+		Count(&locked).Error; err != nil {
+		return nil, nil, err
+	}
+	if int(locked) != len(transfers) {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("disk_id = ? AND (path, filename) IN ?", diskID, destinations)
+	if move {
+		query = query.Where("id NOT IN ?", sourceIDs)
+	}
+	var taken []model.Artifact
+	if err := query.Find(&taken).Error; err != nil {
+		return nil, nil, fmt.Errorf("query destinations: %w", err)
+	}
+	if len(taken) == 0 {
+		return transfers, nil, nil
+	}
+
+	takenPaths := make(map[string]bool, len(taken))
+	for _, a := range taken {
+		takenPaths[a.Path+a.Filename] = true
+	}
+
+	switch onConflict {
+	case OnConflictSkip:
+		var kept, skipped []ArtifactTransfer
+		for _, t := range transfers {
+			if takenPaths[t.Path+t.Filename] {
+				skipped = append(skipped, t)
+			} else {
+				kept = append(kept, t)
+			}
+		}
+		return kept, skipped, nil
+
+	case OnConflictOverwrite:
+		// Versions are deleted by CASCADE, but each of them holds an asset reference
+		ids := make([]uuid.UUID, 0, len(taken))
+		assets := make([]model.Asset, 0, len(taken))
+		for _, a := range taken {
+			ids = append(ids, a.ID)
+			assets = append(assets, a.AssetMeta.Data())
+		}
+		var versions []model.ArtifactVersion
+		if err := tx.Where("artifact_id IN ?", ids).Find(&versions).Error; err != nil {
+			return nil, nil, fmt.Errorf("query artifact versions: %w", err)
+		}
+		for _, v := range versions {
+			assets = append(assets, v.AssetMeta.Data())
+		}
+
+		if err := tx.Delete(&taken).Error; err != nil {
+			return nil, nil, fmt.Errorf("delete overwritten artifacts: %w", err)
+		}
+		for i := range taken {
+			log.add(model.DiskChangeDelete, &taken[i])
+		}
+		if err := r.assetReferenceRepo.WithTx(tx).BatchDecrementAssetRefs(ctx, projectID, assets); err != nil {
+			return nil, nil, fmt.Errorf("decrement asset references: %w", err)
+		}
+		return transfers, nil, nil
+
+	default:
+		paths := make([]string, 0, len(taken))
+		for _, a := range taken {
+			paths = append(paths, a.Path+a.Filename)
+		}
+		return nil, nil, fmt.Errorf("%w: %s", ErrArtifactConflict, strings.Join(paths, ", "))
+	}
+}
@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+func TestArtifactRepo_GrepArtifactsWithFilter_Documents(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return // Test was skipped
+	}
+	require.NoError(t, db.AutoMigrate(&model.User{}, &model.Disk{}, &model.Artifact{}, &model.ArtifactVersion{}))
+	repo := NewArtifactRepo(db, NewAssetReferenceRepo(db, nil))
+	ctx := context.Background()
+
+	project := &model.Project{
+		ID:               uuid.New(),
+		SecretKeyHMAC:    "test_hmac_artifact_grep",
+		SecretKeyHashPHC: "test_hash",
+	}
+	require.NoError(t, db.Create(project).Error)
+	defer func() {
+		db.Exec("DELETE FROM artifacts WHERE disk_id IN (SELECT id FROM disks WHERE project_id = ?)", project.ID)
+		db.Exec("DELETE FROM disks WHERE project_id = ?", project.ID)
+		cleanupTestDB(t, db, project.ID)
+	}()
+
+	disk := &model.Disk{ID: uuid.New(), ProjectID: project.ID}
+	require.NoError(t, db.Create(disk).Error)
+
+	artifacts := []*model.Artifact{
+		{
+			DiskID: disk.ID, Path: "/docs/", Filename: "plan.docx",
+			AssetMeta: datatypes.NewJSONType(model.Asset{
+				MIME:    "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+				Content: "Project plan\nShip the quarterly report\n",
+			}),
+		},
+		{
+			DiskID: disk.ID, Path: "/docs/", Filename: "report.pdf",
+			AssetMeta: datatypes.NewJSONType(model.Asset{
+				MIME:    "application/pdf",
+				Content: "--- Page 1 ---\nQuarterly revenue grew\n",
+			}),
+		},
+		{
+			DiskID: disk.ID, Path: "/images/", Filename: "chart.png",
+			AssetMeta: datatypes.NewJSONType(model.Asset{MIME: "image/png"}),
+		},
+	}
+	for _, a := range artifacts {
+		require.NoError(t, db.Create(a).Error)
+	}
+
+	found, err := repo.GrepArtifactsWithFilter(ctx, disk.ID, GrepFilter{Pattern: "quarterly", IgnoreCase: true}, 10)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "plan.docx", found[0].Filename)
+	assert.Equal(t, "report.pdf", found[1].Filename)
+}
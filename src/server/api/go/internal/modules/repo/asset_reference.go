@@ -2,7 +2,9 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,17 +20,29 @@ type AssetReferenceRepo interface {
 	DecrementAssetRef(ctx context.Context, projectID uuid.UUID, asset model.Asset) error
 	BatchIncrementAssetRefs(ctx context.Context, projectID uuid.UUID, assets []model.Asset) error
 	BatchDecrementAssetRefs(ctx context.Context, projectID uuid.UUID, assets []model.Asset) error
+	ListOrphaned(ctx context.Context, before time.Time, limit int) ([]*model.AssetReference, error)
+	DeleteOrphaned(ctx context.Context, id uuid.UUID, before time.Time) (bool, error)
+	UnreferencedKeys(ctx context.Context, keys []string) ([]string, error)
+	ClaimAsset(ctx context.Context, projectID uuid.UUID, sha256 string) (*model.Asset, error)
+	WithTx(tx *gorm.DB) AssetReferenceRepo
 }
 
 type assetReferenceRepo struct {
 	db *gorm.DB
-	s3 *blob.S3Deps
+	s3 blob.BlobStore
 }
 
-func NewAssetReferenceRepo(db *gorm.DB, s3 *blob.S3Deps) AssetReferenceRepo {
+func NewAssetReferenceRepo(db *gorm.DB, s3 blob.BlobStore) AssetReferenceRepo {
 	return &assetReferenceRepo{db: db, s3: s3}
 }
 
+// WithTx returns a repo whose reference changes are part of tx. Writes that add or drop references
+// must use it: a decrement committed apart from a write that rolls back would let the garbage
+// collector delete an object that is still referenced.
+func (r *assetReferenceRepo) WithTx(tx *gorm.DB) AssetReferenceRepo {
+	return &assetReferenceRepo{db: tx, s3: r.s3}
+}
+
 // IncrementAssetRef finds or creates an asset reference and increments its RefCount.
 // It upserts by (project_id, sha256) and updates canonical fields.
 // Uses SkipHooks to prevent recursive hook triggers when called from other hooks.
@@ -70,7 +84,9 @@ func (r *assetReferenceRepo) IncrementAssetRef(ctx context.Context, projectID uu
 	).Omit(clause.Associations).Create(&row).Error
 }
 
-// DecrementAssetRef decrements RefCount and deletes the row if it reaches zero.
+// DecrementAssetRef decrements RefCount. An asset whose count reaches zero is kept until the
+// garbage collector deletes it after a grace period, so the same content uploaded again in the
+// meantime can still use it.
 // Uses SkipHooks to prevent recursive hook triggers when called from other hooks.
 func (r *assetReferenceRepo) DecrementAssetRef(ctx context.Context, projectID uuid.UUID, asset model.Asset) error {
 	if projectID == uuid.Nil {
@@ -80,25 +96,18 @@ func (r *assetReferenceRepo) DecrementAssetRef(ctx context.Context, projectID uu
 		return fmt.Errorf("DecrementAssetRef: asset.sha256 is required")
 	}
 
-	var ref model.AssetReference
-	err := r.db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true}).Where("project_id = ? AND sha256 = ?", projectID, asset.SHA256).First(&ref).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil
-		}
-		return err
-	}
-
-	if ref.RefCount <= 1 {
-		if err := r.s3.DeleteObject(ctx, ref.S3Key); err != nil {
-			return err
-		}
-		return r.db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true}).Delete(&ref).Error
-	}
+	return r.decrement(r.db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true}), projectID, asset.SHA256, 1)
+}
 
-	return r.db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true}).Model(&model.AssetReference{}).
-		Where("project_id = ? AND sha256 = ?", projectID, asset.SHA256).
-		UpdateColumn("ref_count", gorm.Expr("ref_count - 1")).Error
+// decrement lowers the count of an asset by dec, stopping at zero. updated_at records when the
+// asset lost its last reference for the garbage collector.
+func (r *assetReferenceRepo) decrement(db *gorm.DB, projectID uuid.UUID, sha256 string, dec int) error {
+	return db.Model(&model.AssetReference{}).
+		Where("project_id = ? AND sha256 = ?", projectID, sha256).
+		UpdateColumns(map[string]any{
+			"ref_count":  gorm.Expr("GREATEST(ref_count - ?, 0)", dec),
+			"updated_at": time.Now(),
+		}).Error
 }
 
 // BatchIncrementAssetRefs increments reference counts for a slice of assets.
@@ -135,7 +144,8 @@ func (r *assetReferenceRepo) BatchIncrementAssetRefs(ctx context.Context, projec
 
 	now := time.Now()
 	rows := make([]model.AssetReference, 0, len(grouped))
-	for _, g := range grouped {
+	for _, sha := range sortedKeys(grouped) {
+		g := grouped[sha]
 		rows = append(rows, model.AssetReference{
 			ProjectID:        projectID,
 			SHA256:           g.asset.SHA256,
@@ -161,7 +171,7 @@ func (r *assetReferenceRepo) BatchIncrementAssetRefs(ctx context.Context, projec
 }
 
 // BatchDecrementAssetRefs decrements reference counts for a slice of assets.
-// When count reaches zero or below, the asset reference row is deleted.
+// Assets whose count reaches zero are left to the garbage collector, as in DecrementAssetRef.
 // Uses SkipHooks to prevent recursive hook triggers when called from other hooks.
 func (r *assetReferenceRepo) BatchDecrementAssetRefs(ctx context.Context, projectID uuid.UUID, assets []model.Asset) error {
 	if projectID == uuid.Nil {
@@ -179,36 +189,114 @@ func (r *assetReferenceRepo) BatchDecrementAssetRefs(ctx context.Context, projec
 		}
 		grouped[a.SHA256]++
 	}
-	if len(grouped) == 0 {
-		return nil
-	}
 
-	// For each sha, decrement or delete
 	// Use SkipHooks to prevent recursive hook triggers when called from other hooks
 	sessionTx := r.db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true})
-	for sha, dec := range grouped {
+	for _, sha := range sortedKeys(grouped) {
+		if err := r.decrement(sessionTx, projectID, sha, grouped[sha]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order. Rows are updated in this order so concurrent
+// transactions lock shared assets in the same order and do not deadlock.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// ListOrphaned returns the assets that have had no references since before, oldest first
+func (r *assetReferenceRepo) ListOrphaned(ctx context.Context, before time.Time, limit int) ([]*model.AssetReference, error) {
+	var refs []*model.AssetReference
+	return refs, r.db.WithContext(ctx).
+		Where("ref_count = 0 AND updated_at < ?", before).
+		Order("updated_at ASC, id ASC").
+		Limit(limit).
+		Find(&refs).Error
+}
+
+// UnreferencedKeys returns the S3 keys among keys that no asset reference points to, in their
+// original order
+func (r *assetReferenceRepo) UnreferencedKeys(ctx context.Context, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	var referenced []string
+	err := r.db.WithContext(ctx).
+		Model(&model.AssetReference{}).
+		Where("s3_key IN ?", keys).
+		Distinct().
+		Pluck("s3_key", &referenced).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, key := range keys {
+		if !slices.Contains(referenced, key) {
+			out = append(out, key)
+		}
+	}
+	return out, nil
+}
+
+// ClaimAsset returns the stored asset of content in a project for an upload to reuse, or nil if
+// there is none. Claiming touches updated_at, so an orphaned asset is kept by the garbage collector
+// for another grace period, in which the upload references it. A claim that races DeleteOrphaned
+// waits for it and finds nothing, and the upload stores the content again.
+func (r *assetReferenceRepo) ClaimAsset(ctx context.Context, projectID uuid.UUID, sha256 string) (*model.Asset, error) {
+	var refs []model.AssetReference
+	err := r.db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true}).
+		Model(&refs).
+		Clauses(clause.Returning{}).
+		Where("project_id = ? AND sha256 = ?", projectID, sha256).
+		UpdateColumn("updated_at", time.Now()).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	asset := refs[0].AssetMeta.Data()
+	asset.S3Key = refs[0].S3Key
+	asset.Content = ""
+	return &asset, nil
+}
+
+// DeleteOrphaned deletes an asset and its S3 object if it still has had no references since
+// before. The row stays locked until the object is gone: a ClaimAsset or IncrementAssetRef that
+// gets there first keeps the asset, one that comes later waits and no longer finds it.
+// It returns false when the asset is kept.
+func (r *assetReferenceRepo) DeleteOrphaned(ctx context.Context, id uuid.UUID, before time.Time) (bool, error) {
+	deleted := false
+	err := r.db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true}).Transaction(func(tx *gorm.DB) error {
 		var ref model.AssetReference
-		err := sessionTx.Where("project_id = ? AND sha256 = ?", projectID, sha).First(&ref).Error
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND ref_count = 0 AND updated_at < ?", id, before).
+			First(&ref).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
 		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				continue
-			}
 			return err
 		}
-		if ref.RefCount <= dec {
-			if err := r.s3.DeleteObject(ctx, ref.S3Key); err != nil {
-				return err
-			}
-			if err := sessionTx.Delete(&ref).Error; err != nil {
-				return err
-			}
-			continue
-		}
-		if err := sessionTx.Model(&model.AssetReference{}).
-			Where("project_id = ? AND sha256 = ?", projectID, sha).
-			UpdateColumn("ref_count", gorm.Expr("ref_count - ?", dec)).Error; err != nil {
+
+		if err := tx.Delete(&ref).Error; err != nil {
 			return err
 		}
-	}
-	return nil
+		// A failed delete rolls back, so the asset is tried again on the next run
+		if err := r.s3.DeleteObject(ctx, ref.S3Key); err != nil {
+			return fmt.Errorf("delete object %s: %w", ref.S3Key, err)
+		}
+		deleted = true
+		return nil
+	})
+	return deleted, err
 }
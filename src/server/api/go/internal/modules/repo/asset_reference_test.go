@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+func TestAssetReferenceRepo_UnreferencedKeys(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return // Test was skipped
+	}
+	require.NoError(t, db.AutoMigrate(&model.AssetReference{}))
+	repo := NewAssetReferenceRepo(db, nil)
+	ctx := context.Background()
+
+	project := &model.Project{
+		ID:               uuid.New(),
+		SecretKeyHMAC:    "test_hmac_asset_unreferenced",
+		SecretKeyHashPHC: "test_hash",
+	}
+	require.NoError(t, db.Create(project).Error)
+	defer func() {
+		db.Exec("DELETE FROM asset_references WHERE project_id = ?", project.ID)
+		cleanupTestDB(t, db, project.ID)
+	}()
+
+	prefix := "disks/" + project.ID.String() + "/2025/01/01/"
+	// An orphaned reference still keeps its object until the collector deletes both
+	for i, key := range []string{prefix + "live.md", prefix + "orphaned.md"} {
+		ref := &model.AssetReference{
+			ProjectID: project.ID,
+			SHA256:    fmt.Sprintf("%064d", i),
+			S3Key:     key,
+			RefCount:  1 - i,
+			AssetMeta: datatypes.NewJSONType(model.Asset{S3Key: key}),
+		}
+		require.NoError(t, db.Create(ref).Error)
+	}
+
+	keys := []string{prefix + "stray-b.md", prefix + "live.md", prefix + "stray-a.md", prefix + "orphaned.md"}
+	got, err := repo.UnreferencedKeys(ctx, keys)
+	require.NoError(t, err)
+	assert.Equal(t, []string{prefix + "stray-b.md", prefix + "stray-a.md"}, got)
+
+	got, err = repo.UnreferencedKeys(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
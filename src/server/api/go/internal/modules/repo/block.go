@@ -21,6 +21,7 @@ type BlockRepo interface {
 	MoveToParentAppend(ctx context.Context, id uuid.UUID, newParentID *uuid.UUID) error
 	ReorderWithinGroup(ctx context.Context, id uuid.UUID, newSort int64) error
 	MoveToParentAtSort(ctx context.Context, id uuid.UUID, newParentID *uuid.UUID, targetSort int64) error
+	ListSOPUseWhen(ctx context.Context, spaceID uuid.UUID) ([]string, error)
 }
 
 type blockRepo struct{ db *gorm.DB }
@@ -86,6 +87,17 @@ func (r *blockRepo) ListBySpace(ctx context.Context, spaceID uuid.UUID, blockTyp
 	return list, nil
 }
 
+// ListSOPUseWhen returns the use_when conditions of all non-archived SOP blocks in a space.
+// A SOP block stores its use_when condition as the block title.
+func (r *blockRepo) ListSOPUseWhen(ctx context.Context, spaceID uuid.UUID) ([]string, error) {
+	var useWhen []string
+	err := r.db.WithContext(ctx).Model(&model.Block{}).
+		Where("space_id = ? AND type = ? AND is_archived = ? AND title <> ''", spaceID, model.BlockTypeSOP, false).
+		Order("created_at ASC, id ASC").
+		Pluck("title", &useWhen).Error
+	return useWhen, err
+}
+
 // NextSort returns max(sort)+1 within group (space_id, parent_id)
 func (r *blockRepo) NextSort(ctx context.Context, spaceID uuid.UUID, parentID *uuid.UUID) (int64, error) {
 	type result struct{ Next int64 }
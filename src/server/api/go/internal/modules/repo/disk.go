@@ -8,12 +8,28 @@ import (
 	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type DiskRepo interface {
 	Create(ctx context.Context, d *model.Disk) error
 	Delete(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) error
+	UpdateVersionRetention(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, retention int) (*model.Disk, error)
 	ListWithCursor(ctx context.Context, projectID uuid.UUID, userIdentifier string, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.Disk, error)
+	CreateSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, name string) (*model.DiskSnapshot, error)
+	ListSnapshots(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskSnapshot, error)
+	GetSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) (*model.DiskSnapshot, error)
+	GetContentSize(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*DiskContentSize, error)
+	DeleteSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) error
+	RestoreSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) (*model.DiskSnapshot, error)
+	Clone(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, userID *uuid.UUID) (*model.Disk, error)
+	ListChanges(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, since int64, limit int) ([]*model.DiskChange, int64, error)
+}
+
+// DiskContentSize is the number and total size of the current artifacts of a disk
+type DiskContentSize struct {
+	Files int64
+	Bytes int64
 }
 
 type diskRepo struct {
@@ -45,26 +61,55 @@ func (r *diskRepo) Delete(ctx context.Context, projectID uuid.UUID, diskID uuid.
 			return fmt.Errorf("query artifacts: %w", err)
 		}
 
-		// Collect asset meta from all artifacts for batch decrement
-		assets := make([]model.Asset, 0, len(artifacts))
+		// Artifact versions are deleted by CASCADE too and hold their own references
+		var versions []model.ArtifactVersion
+		if err := tx.Where("disk_id = ?", diskID).Find(&versions).Error; err != nil {
+			return fmt.Errorf("query artifact versions: %w", err)
+		}
+
+		// Snapshots are deleted by CASCADE too and hold their own references
+		var snapshotArtifacts []model.DiskSnapshotArtifact
+		if err := tx.Joins("JOIN disk_snapshots ON disk_snapshots.id = disk_snapshot_artifacts.snapshot_id").
+			Where("disk_snapshots.disk_id = ?", diskID).
+			Find(&snapshotArtifacts).Error; err != nil {
+			return fmt.Errorf("query snapshot artifacts: %w", err)
+		}
+
+		// Collect asset meta from all artifacts, versions and snapshots for batch decrement
+		assets := make([]model.Asset, 0, len(artifacts)+len(versions)+len(snapshotArtifacts))
 		for _, artifact := range artifacts {
 			asset := artifact.AssetMeta.Data()
 			if asset.SHA256 != "" {
 				assets = append(assets, asset)
 			}
 		}
+		for _, version := range versions {
+			asset := version.AssetMeta.Data()
+			if asset.SHA256 != "" {
+				assets = append(assets, asset)
+			}
+		}
+		for _, sa := range snapshotArtifacts {
+			asset := sa.AssetMeta.Data()
+			if asset.SHA256 != "" {
+				assets = append(assets, asset)
+			}
+		}
 
 		// Delete the disk (artifacts will be deleted automatically by CASCADE)
 		if err := tx.Delete(&disk).Error; err != nil {
 			return fmt.Errorf("delete disk: %w", err)
 		}
 
-		// Batch decrement asset references
-		// Note: BatchDecrementAssetRefs uses its own DB connection and may involve S3 operations
-		// The database operations within BatchDecrementAssetRefs will not be part of this transaction,
-		// but the disk and artifacts deletion will be atomic
+		// Quotas reference the disk by scope ID only, so they are not deleted by CASCADE
+		if err := tx.Where("project_id = ? AND scope = ? AND scope_id = ?", projectID, model.QuotaScopeDisk, diskID).
+			Delete(&model.StorageQuota{}).Error; err != nil {
+			return fmt.Errorf("delete disk storage quota: %w", err)
+		}
+
+		// Batch decrement asset references with the deletion, so a rollback keeps them
 		if len(assets) > 0 {
-			if err := r.assetReferenceRepo.BatchDecrementAssetRefs(ctx, projectID, assets); err != nil {
+			if err := r.assetReferenceRepo.WithTx(tx).BatchDecrementAssetRefs(ctx, projectID, assets); err != nil {
 				return fmt.Errorf("decrement asset references: %w", err)
 			}
 		}
@@ -73,6 +118,17 @@ func (r *diskRepo) Delete(ctx context.Context, projectID uuid.UUID, diskID uuid.
 	})
 }
 
+func (r *diskRepo) UpdateVersionRetention(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, retention int) (*model.Disk, error) {
+	var disk model.Disk
+	if err := r.db.WithContext(ctx).Where("id = ? AND project_id = ?", diskID, projectID).First(&disk).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Model(&disk).Update("version_retention", retention).Error; err != nil {
+		return nil, err
+	}
+	return &disk, nil
+}
+
 func (r *diskRepo) ListWithCursor(ctx context.Context, projectID uuid.UUID, userIdentifier string, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.Disk, error) {
 	q := r.db.WithContext(ctx).Where("disks.project_id = ?", projectID)
 
@@ -104,3 +160,310 @@ func (r *diskRepo) ListWithCursor(ctx context.Context, projectID uuid.UUID, user
 	var disks []*model.Disk
 	return disks, q.Order(orderBy).Limit(limit).Find(&disks).Error
 }
+
+// lockDisk verifies that the disk belongs to the project and locks it for the rest of tx,
+// so snapshots, restores and clones of one disk run one at a time
+func lockDisk(tx *gorm.DB, projectID uuid.UUID, diskID uuid.UUID) (*model.Disk, error) {
+	var disk model.Disk
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND project_id = ?", diskID, projectID).
+		First(&disk).Error; err != nil {
+		return nil, err
+	}
+	return &disk, nil
+}
+
+// CreateSnapshot copies the current artifacts of a disk into a new snapshot. Only rows are
+// copied; the snapshot references the same S3 objects.
+func (r *diskRepo) CreateSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, name string) (*model.DiskSnapshot, error) {
+	var snapshot *model.DiskSnapshot
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockDisk(tx, projectID, diskID); err != nil {
+			return err
+		}
+
+		var artifacts []model.Artifact
+		if err := tx.Where("disk_id = ?", diskID).Find(&artifacts).Error; err != nil {
+			return fmt.Errorf("query artifacts: %w", err)
+		}
+
+		snapshot = &model.DiskSnapshot{DiskID: diskID, Name: name, ArtifactCount: len(artifacts)}
+		rows := make([]model.DiskSnapshotArtifact, 0, len(artifacts))
+		assets := make([]model.Asset, 0, len(artifacts))
+		for _, a := range artifacts {
+			asset := a.AssetMeta.Data()
+			snapshot.SizeB += asset.SizeB
+			rows = append(rows, model.DiskSnapshotArtifact{
+				Path:      a.Path,
+				Filename:  a.Filename,
+				Meta:      a.Meta,
+				AssetMeta: a.AssetMeta,
+			})
+			assets = append(assets, asset)
+		}
+
+		if err := tx.Create(snapshot).Error; err != nil {
+			return fmt.Errorf("create snapshot: %w", err)
+		}
+		if len(rows) > 0 {
+			for i := range rows {
+				rows[i].SnapshotID = snapshot.ID
+			}
+			if err := tx.CreateInBatches(&rows, 500).Error; err != nil {
+				return fmt.Errorf("create snapshot artifacts: %w", err)
+			}
+		}
+
+		if err := r.assetReferenceRepo.WithTx(tx).BatchIncrementAssetRefs(ctx, projectID, assets); err != nil {
+			return fmt.Errorf("increment asset references: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func (r *diskRepo) ListSnapshots(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskSnapshot, error) {
+	var disk model.Disk
+	if err := r.db.WithContext(ctx).Where("id = ? AND project_id = ?", diskID, projectID).First(&disk).Error; err != nil {
+		return nil, err
+	}
+
+	var snapshots []*model.DiskSnapshot
+	return snapshots, r.db.WithContext(ctx).
+		Where("disk_id = ?", diskID).
+		Order("created_at DESC, id DESC").
+		Find(&snapshots).Error
+}
+
+// DeleteSnapshot deletes a snapshot and releases the asset references of its artifacts
+func (r *diskRepo) GetSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) (*model.DiskSnapshot, error) {
+	var snapshot model.DiskSnapshot
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN disks ON disks.id = disk_snapshots.disk_id").
+		Where("disk_snapshots.id = ? AND disk_snapshots.disk_id = ? AND disks.project_id = ?", snapshotID, diskID, projectID).
+		First(&snapshot).Error; err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// GetContentSize sums the current artifacts of a disk, without versions and snapshots
+func (r *diskRepo) GetContentSize(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*DiskContentSize, error) {
+	if err := r.db.WithContext(ctx).Select("id").Where("id = ? AND project_id = ?", diskID, projectID).First(&model.Disk{}).Error; err != nil {
+		return nil, err
+	}
+
+	var size DiskContentSize
+	if err := r.db.WithContext(ctx).Model(&model.Artifact{}).
+		Select("COUNT(*) AS files, COALESCE(SUM((asset_meta->>'size_b')::bigint), 0) AS bytes").
+		Where("disk_id = ?", diskID).
+		Scan(&size).Error; err != nil {
+		return nil, err
+	}
+	return &size, nil
+}
+
+func (r *diskRepo) DeleteSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockDisk(tx, projectID, diskID); err != nil {
+			return err
+		}
+
+		var snapshot model.DiskSnapshot
+		if err := tx.Where("id = ? AND disk_id = ?", snapshotID, diskID).First(&snapshot).Error; err != nil {
+			return err
+		}
+
+		var rows []model.DiskSnapshotArtifact
+		if err := tx.Where("snapshot_id = ?", snapshotID).Find(&rows).Error; err != nil {
+			return fmt.Errorf("query snapshot artifacts: %w", err)
+		}
+		assets := make([]model.Asset, 0, len(rows))
+		for _, row := range rows {
+			assets = append(assets, row.AssetMeta.Data())
+		}
+
+		// Snapshot artifacts are deleted by CASCADE
+		if err := tx.Delete(&snapshot).Error; err != nil {
+			return fmt.Errorf("delete snapshot: %w", err)
+		}
+
+		if err := r.assetReferenceRepo.WithTx(tx).BatchDecrementAssetRefs(ctx, projectID, assets); err != nil {
+			return fmt.Errorf("decrement asset references: %w", err)
+		}
+		return nil
+	})
+}
+
+// RestoreSnapshot replaces the artifacts of a disk with those of a snapshot. The replaced
+// artifacts are deleted with their versions; the snapshot itself is kept. The change log gets a
+// delete for every replaced artifact and a create for every restored one.
+func (r *diskRepo) RestoreSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) (*model.DiskSnapshot, error) {
+	var snapshot model.DiskSnapshot
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockDisk(tx, projectID, diskID); err != nil {
+			return err
+		}
+		log, err := lockChangeLog(tx, diskID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ? AND disk_id = ?", snapshotID, diskID).First(&snapshot).Error; err != nil {
+			return err
+		}
+		var rows []model.DiskSnapshotArtifact
+		if err := tx.Where("snapshot_id = ?", snapshotID).Find(&rows).Error; err != nil {
+			return fmt.Errorf("query snapshot artifacts: %w", err)
+		}
+
+		// Collect the references held by the current artifacts and their versions
+		var artifacts []model.Artifact
+		if err := tx.Where("disk_id = ?", diskID).Find(&artifacts).Error; err != nil {
+			return fmt.Errorf("query artifacts: %w", err)
+		}
+		var versions []model.ArtifactVersion
+		if err := tx.Where("disk_id = ?", diskID).Find(&versions).Error; err != nil {
+			return fmt.Errorf("query artifact versions: %w", err)
+		}
+		released := make([]model.Asset, 0, len(artifacts)+len(versions))
+		for i, a := range artifacts {
+			released = append(released, a.AssetMeta.Data())
+			log.add(model.DiskChangeDelete, &artifacts[i])
+		}
+		for _, v := range versions {
+			released = append(released, v.AssetMeta.Data())
+		}
+
+		// Versions are deleted by CASCADE
+		if err := tx.Where("disk_id = ?", diskID).Delete(&model.Artifact{}).Error; err != nil {
+			return fmt.Errorf("delete artifacts: %w", err)
+		}
+
+		restored := make([]model.Artifact, 0, len(rows))
+		acquired := make([]model.Asset, 0, len(rows))
+		for _, row := range rows {
+			restored = append(restored, model.Artifact{
+				DiskID:    diskID,
+				Path:      row.Path,
+				Filename:  row.Filename,
+				Meta:      row.Meta,
+				AssetMeta: row.AssetMeta,
+			})
+			acquired = append(acquired, row.AssetMeta.Data())
+		}
+		if len(restored) > 0 {
+			if err := tx.CreateInBatches(&restored, 500).Error; err != nil {
+				return fmt.Errorf("restore artifacts: %w", err)
+			}
+		}
+		for i := range restored {
+			log.add(model.DiskChangeCreate, &restored[i])
+		}
+
+		// Increment first so objects shared by both sides never reach zero references
+		if err := r.assetReferenceRepo.WithTx(tx).BatchIncrementAssetRefs(ctx, projectID, acquired); err != nil {
+			return fmt.Errorf("increment asset references: %w", err)
+		}
+		if err := r.assetReferenceRepo.WithTx(tx).BatchDecrementAssetRefs(ctx, projectID, released); err != nil {
+			return fmt.Errorf("decrement asset references: %w", err)
+		}
+		return log.flush()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// Clone creates a new disk in the same project with a copy of the current artifacts of a disk.
+// Versions and snapshots are not copied, and the copies share the S3 objects of the source.
+func (r *diskRepo) Clone(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, userID *uuid.UUID) (*model.Disk, error) {
+	var clone *model.Disk
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		source, err := lockDisk(tx, projectID, diskID)
+		if err != nil {
+			return err
+		}
+
+		clone = &model.Disk{
+			ProjectID:        projectID,
+			UserID:           userID,
+			VersionRetention: source.VersionRetention,
+		}
+		if err := tx.Create(clone).Error; err != nil {
+			return fmt.Errorf("create disk: %w", err)
+		}
+		// A zero retention is omitted by Create and replaced with the column default
+		if source.VersionRetention == 0 {
+			if err := tx.Model(clone).Update("version_retention", 0).Error; err != nil {
+				return fmt.Errorf("set version retention: %w", err)
+			}
+		}
+
+		var artifacts []model.Artifact
+		if err := tx.Where("disk_id = ?", diskID).Find(&artifacts).Error; err != nil {
+			return fmt.Errorf("query artifacts: %w", err)
+		}
+		if len(artifacts) == 0 {
+			return nil
+		}
+
+		copies := make([]model.Artifact, 0, len(artifacts))
+		assets := make([]model.Asset, 0, len(artifacts))
+		for _, a := range artifacts {
+			copies = append(copies, model.Artifact{
+				DiskID:    clone.ID,
+				Path:      a.Path,
+				Filename:  a.Filename,
+				Meta:      a.Meta,
+				AssetMeta: a.AssetMeta,
+			})
+			assets = append(assets, a.AssetMeta.Data())
+		}
+		if err := tx.CreateInBatches(&copies, 500).Error; err != nil {
+			return fmt.Errorf("copy artifacts: %w", err)
+		}
+
+		if err := r.assetReferenceRepo.WithTx(tx).BatchIncrementAssetRefs(ctx, projectID, assets); err != nil {
+			return fmt.Errorf("increment asset references: %w", err)
+		}
+
+		// The change log of the clone starts with the creation of the copies
+		log, err := lockChangeLog(tx, clone.ID)
+		if err != nil {
+			return err
+		}
+		for i := range copies {
+			log.add(model.DiskChangeCreate, &copies[i])
+		}
+		return log.flush()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// ListChanges returns the changes of a disk after since in seq order, with the seq of the last
+// change of the disk
+func (r *diskRepo) ListChanges(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, since int64, limit int) ([]*model.DiskChange, int64, error) {
+	var disk model.Disk
+	if err := r.db.WithContext(ctx).Select("id", "change_seq").Where("id = ? AND project_id = ?", diskID, projectID).First(&disk).Error; err != nil {
+		return nil, 0, err
+	}
+	if since >= disk.ChangeSeq {
+		return nil, disk.ChangeSeq, nil
+	}
+
+	var changes []*model.DiskChange
+	err := r.db.WithContext(ctx).
+		Where("disk_id = ? AND seq > ?", diskID, since).
+		Order("seq ASC").
+		Limit(limit).
+		Find(&changes).Error
+	return changes, disk.ChangeSeq, err
+}
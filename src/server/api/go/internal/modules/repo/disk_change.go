@@ -0,0 +1,66 @@
+package repo
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// changeLog collects the changes a transaction makes to the artifacts of a disk
+type changeLog struct {
+	tx      *gorm.DB
+	diskID  uuid.UUID
+	seq     int64
+	changes []model.DiskChange
+}
+
+// lockChangeLog locks the disk row for the rest of tx and starts collecting its changes. Writes
+// take this lock before touching artifacts, so seqs are handed out in commit order and never skipped.
+func lockChangeLog(tx *gorm.DB, diskID uuid.UUID) (*changeLog, error) {
+	var disk model.Disk
+	if err := tx.Clauses(clause.Locking{Strength: "NO KEY UPDATE"}).
+		Select("id", "change_seq").
+		Where("id = ?", diskID).
+		First(&disk).Error; err != nil {
+		return nil, err
+	}
+	return &changeLog{tx: tx, diskID: diskID, seq: disk.ChangeSeq}, nil
+}
+
+func (l *changeLog) add(op string, a *model.Artifact) {
+	c := model.DiskChange{Op: op, Path: a.Path, Filename: a.Filename}
+	if op != model.DiskChangeDelete {
+		c.Version = a.Version
+		c.SHA256 = a.AssetMeta.Data().SHA256
+	}
+	l.changes = append(l.changes, c)
+}
+
+func (l *changeLog) addMove(a *model.Artifact, fromPath string, fromFilename string) {
+	l.add(model.DiskChangeMove, a)
+	l.changes[len(l.changes)-1].FromPath = fromPath
+	l.changes[len(l.changes)-1].FromFilename = fromFilename
+}
+
+// flush numbers the collected changes and records them with the last seq of the disk
+func (l *changeLog) flush() error {
+	if len(l.changes) == 0 {
+		return nil
+	}
+	for i := range l.changes {
+		l.seq++
+		l.changes[i].DiskID = l.diskID
+		l.changes[i].Seq = l.seq
+	}
+	if err := l.tx.CreateInBatches(&l.changes, 500).Error; err != nil {
+		return fmt.Errorf("record disk changes: %w", err)
+	}
+	if err := l.tx.Model(&model.Disk{}).Where("id = ?", l.diskID).UpdateColumn("change_seq", l.seq).Error; err != nil {
+		return fmt.Errorf("update disk change seq: %w", err)
+	}
+	l.changes = nil
+	return nil
+}
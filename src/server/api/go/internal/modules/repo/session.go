@@ -27,16 +27,19 @@ type SessionRepo interface {
 	ListAllMessagesBySession(ctx context.Context, sessionID uuid.UUID) ([]model.Message, error)
 	GetObservingStatus(ctx context.Context, sessionID string) (*model.MessageObservingStatus, error)
 	PopGeminiCallIDAndName(ctx context.Context, sessionID uuid.UUID) (string, string, error)
+	CreateSystemPrompt(ctx context.Context, p *model.SessionSystemPrompt) error
+	GetSystemPrompt(ctx context.Context, sessionID uuid.UUID, version int) (*model.SessionSystemPrompt, error)
+	ListSystemPrompts(ctx context.Context, sessionID uuid.UUID) ([]model.SessionSystemPrompt, error)
 }
 
 type sessionRepo struct {
 	db                 *gorm.DB
 	assetReferenceRepo AssetReferenceRepo
-	s3                 *blob.S3Deps
+	s3                 blob.BlobStore
 	log                *zap.Logger
 }
 
-func NewSessionRepo(db *gorm.DB, assetReferenceRepo AssetReferenceRepo, s3 *blob.S3Deps, log *zap.Logger) SessionRepo {
+func NewSessionRepo(db *gorm.DB, assetReferenceRepo AssetReferenceRepo, s3 blob.BlobStore, log *zap.Logger) SessionRepo {
 	return &sessionRepo{
 		db:                 db,
 		assetReferenceRepo: assetReferenceRepo,
@@ -84,8 +87,10 @@ func (r *sessionRepo) Delete(ctx context.Context, projectID uuid.UUID, sessionID
 
 				// Extract assets from parts
 				for _, part := range parts {
-					if part.Asset != nil && part.Asset.SHA256 != "" {
-						assets = append(assets, *part.Asset)
+					for _, partAsset := range part.Assets() {
+						if partAsset.SHA256 != "" {
+							assets = append(assets, partAsset)
+						}
 					}
 				}
 			}
@@ -96,11 +101,9 @@ func (r *sessionRepo) Delete(ctx context.Context, projectID uuid.UUID, sessionID
 			return fmt.Errorf("delete session: %w", err)
 		}
 
-		// Note: BatchDecrementAssetRefs uses its own DB connection and may involve S3 operations
-		// The database operations within BatchDecrementAssetRefs will not be part of this transaction,
-		// but the session and messages deletion will be atomic
+		// Decrement asset references with the deletion, so a rollback keeps them
 		if len(assets) > 0 {
-			if err := r.assetReferenceRepo.BatchDecrementAssetRefs(ctx, projectID, assets); err != nil {
+			if err := r.assetReferenceRepo.WithTx(tx).BatchDecrementAssetRefs(ctx, projectID, assets); err != nil {
 				return fmt.Errorf("decrement asset references: %w", err)
 			}
 		}
@@ -390,3 +393,52 @@ func (r *sessionRepo) PopGeminiCallIDAndName(ctx context.Context, sessionID uuid
 
 	return poppedID, poppedName, nil
 }
+
+// CreateSystemPrompt stores p as the next system prompt version of its session.
+// The session row is locked so concurrent writers get distinct, consecutive versions.
+func (r *sessionRepo) CreateSystemPrompt(ctx context.Context, p *model.SessionSystemPrompt) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var session model.Session
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", p.SessionID).
+			First(&session).Error; err != nil {
+			return err
+		}
+
+		var latest struct{ Version int }
+		if err := tx.Model(&model.SessionSystemPrompt{}).
+			Select("COALESCE(MAX(version), 0) AS version").
+			Where("session_id = ?", p.SessionID).
+			Take(&latest).Error; err != nil {
+			return fmt.Errorf("query latest system prompt version: %w", err)
+		}
+
+		p.Version = latest.Version + 1
+		return tx.Create(p).Error
+	})
+}
+
+// GetSystemPrompt returns the given system prompt version of a session, or the latest one if version <= 0.
+func (r *sessionRepo) GetSystemPrompt(ctx context.Context, sessionID uuid.UUID, version int) (*model.SessionSystemPrompt, error) {
+	var p model.SessionSystemPrompt
+	q := r.db.WithContext(ctx).Where("session_id = ?", sessionID)
+	if version > 0 {
+		q = q.Where("version = ?", version)
+	} else {
+		q = q.Order("version DESC")
+	}
+	if err := q.First(&p).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListSystemPrompts returns all system prompt versions of a session, newest first.
+func (r *sessionRepo) ListSystemPrompts(ctx context.Context, sessionID uuid.UUID) ([]model.SessionSystemPrompt, error) {
+	var prompts []model.SessionSystemPrompt
+	return prompts, r.db.WithContext(ctx).
+		Where("session_id = ?", sessionID).
+		Order("version DESC").
+		Find(&prompts).Error
+}
@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"gorm.io/gorm"
+)
+
+type ShareRepo interface {
+	Create(ctx context.Context, s *model.DiskShare) error
+	List(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskShare, error)
+	Delete(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, shareID uuid.UUID) error
+	GetByTokenHMAC(ctx context.Context, tokenHMAC string) (*model.DiskShare, error)
+	CountDownload(ctx context.Context, shareID uuid.UUID) (bool, error)
+}
+
+type shareRepo struct{ db *gorm.DB }
+
+func NewShareRepo(db *gorm.DB) ShareRepo {
+	return &shareRepo{db: db}
+}
+
+func (r *shareRepo) Create(ctx context.Context, s *model.DiskShare) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").Where("id = ? AND project_id = ?", s.DiskID, s.ProjectID).First(&model.Disk{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(s).Error
+	})
+}
+
+func (r *shareRepo) List(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskShare, error) {
+	if err := r.db.WithContext(ctx).Select("id").Where("id = ? AND project_id = ?", diskID, projectID).First(&model.Disk{}).Error; err != nil {
+		return nil, err
+	}
+
+	var shares []*model.DiskShare
+	return shares, r.db.WithContext(ctx).
+		Where("disk_id = ?", diskID).
+		Order("created_at DESC, id DESC").
+		Find(&shares).Error
+}
+
+func (r *shareRepo) Delete(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, shareID uuid.UUID) error {
+	res := r.db.WithContext(ctx).
+		Where("id = ? AND disk_id = ? AND project_id = ?", shareID, diskID, projectID).
+		Delete(&model.DiskShare{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *shareRepo) GetByTokenHMAC(ctx context.Context, tokenHMAC string) (*model.DiskShare, error) {
+	var s model.DiskShare
+	if err := r.db.WithContext(ctx).Where("token_hmac = ?", tokenHMAC).First(&s).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CountDownload records a download through a share. It returns false when the share has expired
+// or reached its download limit, so concurrent downloads cannot go over the limit.
+func (r *shareRepo) CountDownload(ctx context.Context, shareID uuid.UUID) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.DiskShare{}).
+		Where("id = ? AND expires_at > now() AND (max_downloads IS NULL OR downloads < max_downloads)", shareID).
+		UpdateColumn("downloads", gorm.Expr("downloads + 1"))
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
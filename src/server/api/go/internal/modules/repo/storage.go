@@ -0,0 +1,272 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StorageRepo interface {
+	ListQuotas(ctx context.Context, projectID uuid.UUID) ([]*model.StorageQuota, error)
+	GetQuotas(ctx context.Context, projectID uuid.UUID, userID *uuid.UUID, diskID *uuid.UUID) ([]*model.StorageQuota, error)
+	UpsertQuota(ctx context.Context, q *model.StorageQuota) error
+	DeleteQuota(ctx context.Context, projectID uuid.UUID, scope string, scopeID uuid.UUID) error
+	GetDiskUserID(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*uuid.UUID, error)
+	GetUsage(ctx context.Context, projectID uuid.UUID, scope string, scopeID uuid.UUID) (*StorageUsage, error)
+	ListUsage(ctx context.Context, projectID uuid.UUID, scope string) ([]*StorageUsage, error)
+	GetQuotaUsage(ctx context.Context, projectID uuid.UUID, userID *uuid.UUID, diskID *uuid.UUID) (map[string]*StorageUsage, error)
+}
+
+// StorageUsage is what a project, user or disk stores
+type StorageUsage struct {
+	ScopeID    uuid.UUID `json:"-"`
+	Identifier string    `json:"-"` // User identifier, only set for the user scope
+	Files      int64     `json:"files"`
+	RawBytes   int64     `json:"raw_bytes"`   // Sum of the sizes of all files
+	DedupBytes int64     `json:"dedup_bytes"` // Sum of the sizes of distinct contents, as stored in S3, including previous versions and snapshots
+}
+
+type storageRepo struct{ db *gorm.DB }
+
+func NewStorageRepo(db *gorm.DB) StorageRepo {
+	return &storageRepo{db: db}
+}
+
+func (r *storageRepo) ListQuotas(ctx context.Context, projectID uuid.UUID) ([]*model.StorageQuota, error) {
+	var quotas []*model.StorageQuota
+	return quotas, r.db.WithContext(ctx).
+		Where("project_id = ?", projectID).
+		Order("scope, created_at").
+		Find(&quotas).Error
+}
+
+// GetQuotas returns the quotas that apply to the project and, when set, the user and the disk
+func (r *storageRepo) GetQuotas(ctx context.Context, projectID uuid.UUID, userID *uuid.UUID, diskID *uuid.UUID) ([]*model.StorageQuota, error) {
+	scopes := r.db.Where("scope = ? AND scope_id = ?", model.QuotaScopeProject, projectID)
+	if userID != nil {
+		scopes = scopes.Or("scope = ? AND scope_id = ?", model.QuotaScopeUser, *userID)
+	}
+	if diskID != nil {
+		scopes = scopes.Or("scope = ? AND scope_id = ?", model.QuotaScopeDisk, *diskID)
+	}
+
+	var quotas []*model.StorageQuota
+	return quotas, r.db.WithContext(ctx).
+		Where("project_id = ?", projectID).
+		Where(scopes).
+		Find(&quotas).Error
+}
+
+func (r *storageRepo) UpsertQuota(ctx context.Context, q *model.StorageQuota) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "project_id"}, {Name: "scope"}, {Name: "scope_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_bytes", "max_files", "updated_at"}),
+	}).Create(q).Error
+}
+
+func (r *storageRepo) DeleteQuota(ctx context.Context, projectID uuid.UUID, scope string, scopeID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Where("project_id = ? AND scope = ? AND scope_id = ?", projectID, scope, scopeID).
+		Delete(&model.StorageQuota{}).Error
+}
+
+// GetDiskUserID returns the user a disk belongs to, or nil for a disk without user
+func (r *storageRepo) GetDiskUserID(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*uuid.UUID, error) {
+	var disk model.Disk
+	if err := r.db.WithContext(ctx).
+		Select("id", "user_id").
+		Where("id = ? AND project_id = ?", diskID, projectID).
+		First(&disk).Error; err != nil {
+		return nil, err
+	}
+	return disk.UserID, nil
+}
+
+// usageQuery sums the files of a project per scope. Artifacts count one file each and agent
+// skills count the files of their package. Deduplication is by SHA256 within each scope;
+// skills and artifacts without a hash are never deduplicated. The previous versions of artifacts
+// and the artifacts of disk snapshots keep their contents stored, so they add to the
+// deduplicated bytes, but not to the files and raw bytes that quotas limit.
+const usageQuery = `
+WITH files AS (
+	SELECT disks.project_id, disks.id AS disk_id, disks.user_id,
+		COALESCE(NULLIF(artifacts.asset_meta->>'sha256', ''), 'artifacts:' || artifacts.id::text) AS content_key,
+		COALESCE((artifacts.asset_meta->>'size_b')::bigint, 0) AS size_b,
+		1 AS files,
+		TRUE AS live
+	FROM artifacts
+	JOIN disks ON disks.id = artifacts.disk_id
+	WHERE disks.project_id = @project_id
+	UNION ALL
+	SELECT disks.project_id, disks.id, disks.user_id,
+		COALESCE(NULLIF(artifact_versions.asset_meta->>'sha256', ''), 'artifact_versions:' || artifact_versions.id::text),
+		COALESCE((artifact_versions.asset_meta->>'size_b')::bigint, 0),
+		0,
+		FALSE
+	FROM artifact_versions
+	JOIN disks ON disks.id = artifact_versions.disk_id
+	WHERE disks.project_id = @project_id
+	UNION ALL
+	SELECT disks.project_id, disks.id, disks.user_id,
+		COALESCE(NULLIF(disk_snapshot_artifacts.asset_meta->>'sha256', ''), 'disk_snapshot_artifacts:' || disk_snapshot_artifacts.id::text),
+		COALESCE((disk_snapshot_artifacts.asset_meta->>'size_b')::bigint, 0),
+		0,
+		FALSE
+	FROM disk_snapshot_artifacts
+	JOIN disk_snapshots ON disk_snapshots.id = disk_snapshot_artifacts.snapshot_id
+	JOIN disks ON disks.id = disk_snapshots.disk_id
+	WHERE disks.project_id = @project_id
+	UNION ALL
+	SELECT agent_skills.project_id, NULL::uuid, agent_skills.user_id,
+		'agent_skills:' || agent_skills.id::text,
+		COALESCE((agent_skills.asset_meta->>'size_b')::bigint, 0),
+		CASE WHEN jsonb_typeof(agent_skills.file_index) = 'array' THEN jsonb_array_length(agent_skills.file_index) ELSE 0 END,
+		TRUE
+	FROM agent_skills
+	WHERE agent_skills.project_id = @project_id
+), scoped AS (
+	SELECT %[1]s AS scope_id, content_key, size_b, files, live
+	FROM files
+	WHERE %[1]s IS NOT NULL %[2]s
+), contents AS (
+	SELECT scope_id, MAX(size_b) AS size_b FROM scoped GROUP BY scope_id, content_key
+)
+SELECT totals.scope_id, totals.files, totals.raw_bytes, dedup.dedup_bytes
+FROM (
+	SELECT scope_id, SUM(files) AS files, COALESCE(SUM(size_b) FILTER (WHERE live), 0) AS raw_bytes
+	FROM scoped GROUP BY scope_id
+) totals
+JOIN (SELECT scope_id, SUM(size_b) AS dedup_bytes FROM contents GROUP BY scope_id) dedup ON dedup.scope_id = totals.scope_id
+ORDER BY totals.raw_bytes DESC, totals.scope_id`
+
+var usageScopeColumns = map[string]string{
+	model.QuotaScopeProject: "project_id",
+	model.QuotaScopeUser:    "user_id",
+	model.QuotaScopeDisk:    "disk_id",
+}
+
+func (r *storageRepo) usage(ctx context.Context, projectID uuid.UUID, scope string, scopeID *uuid.UUID) ([]*StorageUsage, error) {
+	column, ok := usageScopeColumns[scope]
+	if !ok {
+		return nil, fmt.Errorf("unknown storage scope %q", scope)
+	}
+	args := map[string]interface{}{"project_id": projectID}
+	filter := ""
+	if scopeID != nil {
+		filter = "AND " + column + " = @scope_id"
+		args["scope_id"] = *scopeID
+	}
+
+	var usage []*StorageUsage
+	if err := r.db.WithContext(ctx).Raw(fmt.Sprintf(usageQuery, column, filter), args).Scan(&usage).Error; err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// GetUsage returns what one project, user or disk stores
+func (r *storageRepo) GetUsage(ctx context.Context, projectID uuid.UUID, scope string, scopeID uuid.UUID) (*StorageUsage, error) {
+	usage, err := r.usage(ctx, projectID, scope, &scopeID)
+	if err != nil {
+		return nil, err
+	}
+	if len(usage) == 0 {
+		return &StorageUsage{ScopeID: scopeID}, nil
+	}
+	return usage[0], nil
+}
+
+// ListUsage returns what each user or disk of a project stores, largest first. Users and disks
+// that store nothing are left out.
+func (r *storageRepo) ListUsage(ctx context.Context, projectID uuid.UUID, scope string) ([]*StorageUsage, error) {
+	usage, err := r.usage(ctx, projectID, scope, nil)
+	if err != nil {
+		return nil, err
+	}
+	if scope != model.QuotaScopeUser || len(usage) == 0 {
+		return usage, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(usage))
+	for _, u := range usage {
+		ids = append(ids, u.ScopeID)
+	}
+	var users []model.User
+	if err := r.db.WithContext(ctx).Select("id", "identifier").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("query users: %w", err)
+	}
+	identifiers := make(map[uuid.UUID]string, len(users))
+	for _, u := range users {
+		identifiers[u.ID] = u.Identifier
+	}
+	for _, u := range usage {
+		u.Identifier = identifiers[u.ScopeID]
+	}
+	return usage, nil
+}
+
+// quotaUsageQuery sums what quotas limit, the files and raw bytes of a project and of one user and
+// disk of it, in a single scan. It counts files like usageQuery but leaves out previous versions,
+// snapshots and deduplication, which quotas do not limit.
+const quotaUsageQuery = `
+WITH files AS (
+	SELECT disks.id AS disk_id, disks.user_id,
+		COALESCE((artifacts.asset_meta->>'size_b')::bigint, 0) AS size_b,
+		1 AS files
+	FROM artifacts
+	JOIN disks ON disks.id = artifacts.disk_id
+	WHERE disks.project_id = @project_id
+	UNION ALL
+	SELECT NULL::uuid, agent_skills.user_id,
+		COALESCE((agent_skills.asset_meta->>'size_b')::bigint, 0),
+		CASE WHEN jsonb_typeof(agent_skills.file_index) = 'array' THEN jsonb_array_length(agent_skills.file_index) ELSE 0 END
+	FROM agent_skills
+	WHERE agent_skills.project_id = @project_id
+)
+SELECT
+	COALESCE(SUM(files), 0) AS project_files,
+	COALESCE(SUM(size_b), 0) AS project_bytes,
+	COALESCE(SUM(files) FILTER (WHERE user_id = @user_id), 0) AS user_files,
+	COALESCE(SUM(size_b) FILTER (WHERE user_id = @user_id), 0) AS user_bytes,
+	COALESCE(SUM(files) FILTER (WHERE disk_id = @disk_id), 0) AS disk_files,
+	COALESCE(SUM(size_b) FILTER (WHERE disk_id = @disk_id), 0) AS disk_bytes
+FROM files`
+
+// GetQuotaUsage returns the files and raw bytes stored by the project and, when set, the user and
+// the disk, keyed by scope. Checking a write against every quota that applies takes one query.
+func (r *storageRepo) GetQuotaUsage(ctx context.Context, projectID uuid.UUID, userID *uuid.UUID, diskID *uuid.UUID) (map[string]*StorageUsage, error) {
+	args := map[string]interface{}{"project_id": projectID, "user_id": uuid.Nil, "disk_id": uuid.Nil}
+	if userID != nil {
+		args["user_id"] = *userID
+	}
+	if diskID != nil {
+		args["disk_id"] = *diskID
+	}
+
+	var row struct {
+		ProjectFiles int64
+		ProjectBytes int64
+		UserFiles    int64
+		UserBytes    int64
+		DiskFiles    int64
+		DiskBytes    int64
+	}
+	if err := r.db.WithContext(ctx).Raw(quotaUsageQuery, args).Scan(&row).Error; err != nil {
+		return nil, err
+	}
+
+	usage := map[string]*StorageUsage{
+		model.QuotaScopeProject: {ScopeID: projectID, Files: row.ProjectFiles, RawBytes: row.ProjectBytes},
+	}
+	if userID != nil {
+		usage[model.QuotaScopeUser] = &StorageUsage{ScopeID: *userID, Files: row.UserFiles, RawBytes: row.UserBytes}
+	}
+	if diskID != nil {
+		usage[model.QuotaScopeDisk] = &StorageUsage{ScopeID: *diskID, Files: row.DiskFiles, RawBytes: row.DiskBytes}
+	}
+	return usage, nil
+}
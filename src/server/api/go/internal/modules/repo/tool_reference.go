@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"gorm.io/gorm"
+)
+
+type ToolReferenceRepo interface {
+	ListByProject(ctx context.Context, projectID uuid.UUID) ([]model.ToolReference, error)
+}
+
+type toolReferenceRepo struct{ db *gorm.DB }
+
+func NewToolReferenceRepo(db *gorm.DB) ToolReferenceRepo {
+	return &toolReferenceRepo{db: db}
+}
+
+func (r *toolReferenceRepo) ListByProject(ctx context.Context, projectID uuid.UUID) ([]model.ToolReference, error) {
+	var tools []model.ToolReference
+	err := r.db.WithContext(ctx).
+		Where("project_id = ?", projectID).
+		Order("name ASC, created_at ASC").
+		Find(&tools).Error
+	return tools, err
+}
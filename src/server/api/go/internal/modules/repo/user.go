@@ -81,9 +81,16 @@ func (r *userRepo) GetOrCreate(ctx context.Context, projectID uuid.UUID, identif
 }
 
 func (r *userRepo) Delete(ctx context.Context, projectID uuid.UUID, identifier string) error {
-	return r.db.WithContext(ctx).
-		Where("project_id = ? AND identifier = ?", projectID, identifier).
-		Delete(&model.User{}).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Quotas reference the user by scope ID only, so they are not deleted by CASCADE
+		if err := tx.Where("project_id = ? AND scope = ? AND scope_id IN (?)", projectID, model.QuotaScopeUser,
+			tx.Model(&model.User{}).Select("id").Where("project_id = ? AND identifier = ?", projectID, identifier)).
+			Delete(&model.StorageQuota{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("project_id = ? AND identifier = ?", projectID, identifier).
+			Delete(&model.User{}).Error
+	})
 }
 
 func (r *userRepo) List(ctx context.Context, projectID uuid.UUID, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.User, error) {
@@ -2,7 +2,6 @@ package service
 
 import (
 	"archive/zip"
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -34,14 +33,16 @@ type AgentSkillsService interface {
 }
 
 type agentSkillsService struct {
-	r  repo.AgentSkillsRepo
-	s3 *blob.S3Deps
+	r     repo.AgentSkillsRepo
+	s3    blob.BlobStore
+	quota StorageService
 }
 
-func NewAgentSkillsService(r repo.AgentSkillsRepo, s3 *blob.S3Deps) AgentSkillsService {
+func NewAgentSkillsService(r repo.AgentSkillsRepo, s3 blob.BlobStore, quota StorageService) AgentSkillsService {
 	return &agentSkillsService{
-		r:  r,
-		s3: s3,
+		r:     r,
+		s3:    s3,
+		quota: quota,
 	}
 }
 
@@ -108,11 +109,12 @@ func isMacOSSystemFile(fileName string) bool {
 		filepath.Base(fileName) == ".DS_Store"
 }
 
+// maxSkillMDBytes bounds how much of SKILL.md is read to parse its front matter
+const maxSkillMDBytes = 1 << 20
+
 type zipFileData struct {
-	name         string
-	content      []byte
+	file         *zip.File
 	relativePath string
-	mimeType     string
 }
 
 func (s *agentSkillsService) Create(ctx context.Context, in CreateAgentSkillsInput) (*model.AgentSkills, error) {
@@ -122,12 +124,9 @@ func (s *agentSkillsService) Create(ctx context.Context, in CreateAgentSkillsInp
 	}
 	defer zipFile.Close()
 
-	zipContent, err := io.ReadAll(zipFile)
-	if err != nil {
-		return nil, fmt.Errorf("read zip file: %w", err)
-	}
-
-	zipReader, err := zip.NewReader(bytes.NewReader(zipContent), int64(len(zipContent)))
+	// Entries are read from the uploaded file as they are uploaded, so neither the archive nor
+	// its files are held in memory
+	zipReader, err := zip.NewReader(zipFile, in.ZipFile.Size)
 	if err != nil {
 		return nil, fmt.Errorf("open zip archive: %w", err)
 	}
@@ -147,19 +146,16 @@ func (s *agentSkillsService) Create(ctx context.Context, in CreateAgentSkillsInp
 			continue
 		}
 
-		fileReader, err := file.Open()
-		if err != nil {
-			return nil, fmt.Errorf("open file in zip: %w", err)
-		}
-
-		fileContent, err := io.ReadAll(fileReader)
-		fileReader.Close()
-		if err != nil {
-			return nil, fmt.Errorf("read file in zip: %w", err)
-		}
-
 		fileName := filepath.Base(file.Name)
 		if strings.EqualFold(fileName, "SKILL.md") && !skillMetadataFound {
+			if file.UncompressedSize64 > maxSkillMDBytes {
+				return nil, fmt.Errorf("SKILL.md must not be larger than %d bytes", maxSkillMDBytes)
+			}
+			fileContent, err := readZipFile(file)
+			if err != nil {
+				return nil, err
+			}
+
 			yamlContent := extractYAMLFrontMatter(fileContent)
 			if yamlContent == "" {
 				return nil, errors.New("SKILL.md must contain YAML front matter")
@@ -183,10 +179,7 @@ func (s *agentSkillsService) Create(ctx context.Context, in CreateAgentSkillsInp
 		}
 
 		fileNames = append(fileNames, file.Name)
-		filesToUpload = append(filesToUpload, &zipFileData{
-			name:    file.Name,
-			content: fileContent,
-		})
+		filesToUpload = append(filesToUpload, &zipFileData{file: file})
 	}
 
 	if !skillMetadataFound {
@@ -212,13 +205,28 @@ func (s *agentSkillsService) Create(ctx context.Context, in CreateAgentSkillsInp
 		}
 	}
 
+	var totalSize int64
+	for _, fileData := range filesToUpload {
+		// archive/zip fails reads past the declared size, so the headers can be trusted
+		totalSize += int64(fileData.file.UncompressedSize64)
+	}
+	if s.quota != nil {
+		if err := s.quota.CheckQuota(ctx, CheckQuotaInput{
+			ProjectID: in.ProjectID,
+			UserID:    in.UserID,
+			Bytes:     totalSize,
+			Files:     int64(len(filesToUpload)),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
 	for _, fileData := range filesToUpload {
-		relativePath := fileData.name
-		if rootPrefix != "" && strings.HasPrefix(fileData.name, rootPrefix) {
-			relativePath = strings.TrimPrefix(fileData.name, rootPrefix)
+		relativePath := fileData.file.Name
+		if rootPrefix != "" && strings.HasPrefix(fileData.file.Name, rootPrefix) {
+			relativePath = strings.TrimPrefix(fileData.file.Name, rootPrefix)
 		}
 		fileData.relativePath = relativePath
-		fileData.mimeType = mime.DetectMimeType(fileData.content, fileData.name)
 	}
 
 	agentSkills := &model.AgentSkills{
@@ -260,8 +268,19 @@ func (s *agentSkillsService) Create(ctx context.Context, in CreateAgentSkillsInp
 	for i, fileData := range filesToUpload {
 		i, fileData := i, fileData
 		g.Go(func() error {
+			rc, err := fileData.file.Open()
+			if err != nil {
+				return fmt.Errorf("open file in zip: %w", err)
+			}
+			defer rc.Close()
+
+			mimeType, body, err := mime.DetectMimeTypeReader(rc, fileData.file.Name)
+			if err != nil {
+				return fmt.Errorf("read file in zip: %w", err)
+			}
+
 			fullS3Key := fmt.Sprintf("%s/%s", baseS3Key, fileData.relativePath)
-			asset, err := s.s3.UploadFileDirect(gctx, fullS3Key, fileData.content, fileData.mimeType)
+			asset, err := s.s3.UploadReaderDirect(gctx, fullS3Key, body, mimeType)
 			if err != nil {
 				return fmt.Errorf("upload file to S3: %w", err)
 			}
@@ -272,7 +291,7 @@ func (s *agentSkillsService) Create(ctx context.Context, in CreateAgentSkillsInp
 			}
 			fileIndex[i] = model.FileInfo{
 				Path: fileData.relativePath,
-				MIME: fileData.mimeType,
+				MIME: mimeType,
 			}
 			mu.Unlock()
 
@@ -292,7 +311,7 @@ func (s *agentSkillsService) Create(ctx context.Context, in CreateAgentSkillsInp
 		ETag:   "",
 		SHA256: "",
 		MIME:   "",
-		SizeB:  0,
+		SizeB:  totalSize, // Uncompressed size of all files, counted in storage usage
 	}
 
 	agentSkills.AssetMeta = datatypes.NewJSONType(*baseAsset)
@@ -305,6 +324,21 @@ func (s *agentSkillsService) Create(ctx context.Context, in CreateAgentSkillsInp
 	return agentSkills, nil
 }
 
+// readZipFile reads a whole file from a zip archive
+func readZipFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open file in zip: %w", err)
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("read file in zip: %w", err)
+	}
+	return content, nil
+}
+
 func (s *agentSkillsService) GetByID(ctx context.Context, projectID uuid.UUID, id uuid.UUID) (*model.AgentSkills, error) {
 	return s.r.GetByID(ctx, projectID, id)
 }
@@ -419,10 +453,10 @@ func (s *agentSkillsService) GetFile(ctx context.Context, agentSkills *model.Age
 	// Get full S3 key
 	fullS3Key := agentSkills.GetFileS3Key(filePath)
 
-	// Check if file type is parseable
+	// Check if file type is parseable; documents such as PDFs are downloaded rather than extracted
 	parser := fileparser.NewFileParser()
 	filename := filepath.Base(filePath)
-	canParse := parser.CanParseFile(filename, fileInfo.MIME)
+	canParse := parser.CanParseFile(filename, fileInfo.MIME) && parser.DocumentType(filename, fileInfo.MIME) == ""
 
 	output := &GetFileOutput{
 		Path: fileInfo.Path,
@@ -67,7 +67,11 @@ type MockAgentSkillsS3 struct {
 	mock.Mock
 }
 
-func (m *MockAgentSkillsS3) UploadFileDirect(ctx context.Context, key string, content []byte, contentType string) (*model.Asset, error) {
+func (m *MockAgentSkillsS3) UploadReaderDirect(ctx context.Context, key string, body io.Reader, contentType string) (*model.Asset, error) {
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
 	args := m.Called(ctx, key, content, contentType)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -109,12 +113,7 @@ func (s *testAgentSkillsService) Create(ctx context.Context, in CreateAgentSkill
 	}
 	defer zipFile.Close()
 
-	zipContent, err := io.ReadAll(zipFile)
-	if err != nil {
-		return nil, err
-	}
-
-	zipReader, err := zip.NewReader(bytes.NewReader(zipContent), int64(len(zipContent)))
+	zipReader, err := zip.NewReader(zipFile, in.ZipFile.Size)
 	if err != nil {
 		return nil, err
 	}
@@ -134,19 +133,13 @@ func (s *testAgentSkillsService) Create(ctx context.Context, in CreateAgentSkill
 			continue
 		}
 
-		fileReader, err := file.Open()
-		if err != nil {
-			return nil, err
-		}
-
-		fileContent, err := io.ReadAll(fileReader)
-		fileReader.Close()
-		if err != nil {
-			return nil, err
-		}
-
 		fileName := filepath.Base(file.Name)
 		if strings.EqualFold(fileName, "SKILL.md") && !skillMetadataFound {
+			fileContent, err := readZipFile(file)
+			if err != nil {
+				return nil, err
+			}
+
 			yamlContent := extractYAMLFrontMatter(fileContent)
 			if yamlContent == "" {
 				return nil, errors.New("SKILL.md must contain YAML front matter")
@@ -170,10 +163,7 @@ func (s *testAgentSkillsService) Create(ctx context.Context, in CreateAgentSkill
 		}
 
 		fileNames = append(fileNames, file.Name)
-		filesToUpload = append(filesToUpload, &zipFileData{
-			name:    file.Name,
-			content: fileContent,
-		})
+		filesToUpload = append(filesToUpload, &zipFileData{file: file})
 	}
 
 	if !skillMetadataFound {
@@ -200,12 +190,11 @@ func (s *testAgentSkillsService) Create(ctx context.Context, in CreateAgentSkill
 	}
 
 	for _, fileData := range filesToUpload {
-		relativePath := fileData.name
-		if rootPrefix != "" && strings.HasPrefix(fileData.name, rootPrefix) {
-			relativePath = strings.TrimPrefix(fileData.name, rootPrefix)
+		relativePath := fileData.file.Name
+		if rootPrefix != "" && strings.HasPrefix(fileData.file.Name, rootPrefix) {
+			relativePath = strings.TrimPrefix(fileData.file.Name, rootPrefix)
 		}
 		fileData.relativePath = relativePath
-		fileData.mimeType = mime.DetectMimeType(fileData.content, fileData.name)
 	}
 
 	agentSkills := &model.AgentSkills{
@@ -241,8 +230,21 @@ func (s *testAgentSkillsService) Create(ctx context.Context, in CreateAgentSkill
 	var baseBucket string
 
 	for i, fileData := range filesToUpload {
+		rc, openErr := fileData.file.Open()
+		if openErr != nil {
+			err = openErr
+			return nil, err
+		}
+		mimeType, body, readErr := mime.DetectMimeTypeReader(rc, fileData.file.Name)
+		if readErr != nil {
+			rc.Close()
+			err = readErr
+			return nil, err
+		}
+
 		fullS3Key := fmt.Sprintf("%s/%s", baseS3Key, fileData.relativePath)
-		asset, uploadErr := s.s3.UploadFileDirect(ctx, fullS3Key, fileData.content, fileData.mimeType)
+		asset, uploadErr := s.s3.UploadReaderDirect(ctx, fullS3Key, body, mimeType)
+		rc.Close()
 		if uploadErr != nil {
 			err = uploadErr
 			return nil, err
@@ -253,7 +255,7 @@ func (s *testAgentSkillsService) Create(ctx context.Context, in CreateAgentSkill
 		}
 		fileIndex[i] = model.FileInfo{
 			Path: fileData.relativePath,
-			MIME: fileData.mimeType,
+			MIME: mimeType,
 		}
 	}
 
@@ -552,7 +554,7 @@ func TestAgentSkillsService_Create_Success(t *testing.T) {
 					return as.Name == "test-skill" && as.Description == "Test description"
 				})).Return(nil)
 
-				s3.On("UploadFileDirect", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				s3.On("UploadReaderDirect", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(&model.Asset{
 						Bucket: "test-bucket",
 						S3Key:  "test-key",
@@ -675,6 +677,20 @@ func TestAgentSkillsService_Create_ValidationFailures(t *testing.T) {
 	}
 }
 
+func TestAgentSkillsService_Create_LargeSkillMD(t *testing.T) {
+	zipContent, _ := createTestZipFile(map[string]string{
+		"SKILL.md": "---\nname: big\ndescription: too big\n---\n" + strings.Repeat("x", maxSkillMDBytes),
+	})
+
+	// The archive is rejected before anything is recorded or uploaded
+	svc := &agentSkillsService{r: &MockAgentSkillsRepo{}}
+	_, err := svc.Create(context.Background(), CreateAgentSkillsInput{
+		ProjectID: uuid.New(),
+		ZipFile:   createTestMultipartFileHeader("skills.zip", zipContent),
+	})
+	assert.ErrorContains(t, err, "SKILL.md must not be larger than")
+}
+
 func TestAgentSkillsService_Create_TwoPhaseRollback(t *testing.T) {
 	ctx := context.Background()
 	projectID := uuid.New()
@@ -693,7 +709,7 @@ func TestAgentSkillsService_Create_TwoPhaseRollback(t *testing.T) {
 			as.ID = uuid.New()
 		}).Return(nil)
 
-		mockS3.On("UploadFileDirect", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		mockS3.On("UploadReaderDirect", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 			Return(nil, errors.New("S3 upload failed"))
 
 		mockRepo.On("Delete", mock.Anything, projectID, mock.Anything).Return(nil)
@@ -723,7 +739,7 @@ func TestAgentSkillsService_Create_TwoPhaseRollback(t *testing.T) {
 			as.ID = uuid.New()
 		}).Return(nil)
 
-		mockS3.On("UploadFileDirect", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		mockS3.On("UploadReaderDirect", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 			Return(&model.Asset{
 				Bucket: "test-bucket",
 				S3Key:  "test-key",
@@ -771,7 +787,7 @@ func TestAgentSkillsService_Create_MacOSFiltering(t *testing.T) {
 	mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
 
 	uploadCount := 0
-	mockS3.On("UploadFileDirect", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+	mockS3.On("UploadReaderDirect", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Run(func(args mock.Arguments) {
 			uploadCount++
 		}).
@@ -810,7 +826,7 @@ func TestAgentSkillsService_Create_RootPrefixStripping(t *testing.T) {
 	mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
 
 	var uploadedPaths []string
-	mockS3.On("UploadFileDirect", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+	mockS3.On("UploadReaderDirect", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Run(func(args mock.Arguments) {
 			key := args.Get(1).(string)
 			uploadedPaths = append(uploadedPaths, key)
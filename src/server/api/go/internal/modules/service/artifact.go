@@ -6,37 +6,111 @@ import (
 	"fmt"
 	"io"
 	"mime/multipart"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/infra/blob"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/archive"
+	"github.com/memodb-io/Acontext/internal/pkg/grep"
+	"github.com/memodb-io/Acontext/internal/pkg/paging"
+	"github.com/memodb-io/Acontext/internal/pkg/textedit"
 	"github.com/memodb-io/Acontext/internal/pkg/utils/fileparser"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/mime"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/path"
+	"github.com/pmezard/go-difflib/difflib"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type ArtifactService interface {
 	Create(ctx context.Context, in CreateArtifactInput) (*model.Artifact, error)
 	CreateFromBytes(ctx context.Context, in CreateArtifactFromBytesInput) (*model.Artifact, error)
-	DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string) error
+	DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string, cond Precondition) error
 	GetByPath(ctx context.Context, diskID uuid.UUID, path string, filename string) (*model.Artifact, error)
 	GetPresignedURL(ctx context.Context, artifact *model.Artifact, expire time.Duration) (string, error)
 	GetFileContent(ctx context.Context, artifact *model.Artifact) (*fileparser.FileContent, error)
-	UpdateArtifactMetaByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, userMeta map[string]interface{}) (*model.Artifact, error)
-	ListByPath(ctx context.Context, diskID uuid.UUID, path string) ([]*model.Artifact, error)
+	UpdateArtifactMetaByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, userMeta map[string]interface{}, cond Precondition) (*model.Artifact, error)
+	ListArtifacts(ctx context.Context, in ListArtifactsInput) (*ListArtifactsOutput, error)
 	GetAllPaths(ctx context.Context, diskID uuid.UUID) ([]string, error)
-	GrepArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, limit int) ([]*model.Artifact, error)
-	GlobArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, limit int) ([]*model.Artifact, error)
+	GrepArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, filter ArtifactFilter, limit int) ([]*model.Artifact, error)
+	GrepArtifactMatches(ctx context.Context, in GrepArtifactMatchesInput) (*GrepArtifactMatchesOutput, error)
+	GlobArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, filter ArtifactFilter, limit int) ([]*model.Artifact, error)
+	GlobEntries(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, filter ArtifactFilter, limit int) (*GlobEntries, error)
+	ListVersionsByPath(ctx context.Context, diskID uuid.UUID, path string, filename string) (*model.Artifact, []*model.ArtifactVersion, error)
+	GetVersionByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, version int) (*model.Artifact, error)
+	DiffVersionsByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, fromVersion int, toVersion int) (*ArtifactDiff, error)
+	RestoreVersionByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, version int) (*model.Artifact, error)
+	MoveArtifacts(ctx context.Context, in TransferArtifactsInput) (*TransferArtifactsOutput, error)
+	CopyArtifacts(ctx context.Context, in TransferArtifactsInput) (*TransferArtifactsOutput, error)
+	ArchiveArtifacts(ctx context.Context, in ArchiveArtifactsInput) (*ArtifactArchive, error)
+	ExtractArchive(ctx context.Context, in ExtractArchiveInput) (*ExtractArchiveOutput, error)
+	EditArtifact(ctx context.Context, in EditArtifactInput) (*EditArtifactOutput, error)
+	CreateUploadURL(ctx context.Context, in CreateUploadURLInput) (*CreateUploadURLOutput, error)
+	FinalizeUpload(ctx context.Context, in FinalizeUploadInput) (*model.Artifact, error)
+}
+
+var (
+	ErrInvalidTransfer    = errors.New("invalid move or copy")
+	ErrInvalidFilter      = errors.New("invalid artifact filter")
+	ErrArtifactConflict   = repo.ErrArtifactConflict
+	ErrPreconditionFailed = repo.ErrPreconditionFailed
+)
+
+// Precondition is the If-Match and If-None-Match condition of a write, compared with the ETag of
+// the artifact. The zero value always holds.
+type Precondition struct {
+	IfMatch     []string // The artifact must have one of these ETags; * matches any existing artifact
+	IfNoneMatch []string // The artifact must have none of these ETags; * requires that there is no artifact
+}
+
+func (p Precondition) IsZero() bool {
+	return len(p.IfMatch) == 0 && len(p.IfNoneMatch) == 0
+}
+
+// check evaluates the condition against the current artifact, nil if there is none
+func (p Precondition) check(current *model.Artifact) error {
+	if len(p.IfMatch) > 0 && (current == nil || !matchETag(p.IfMatch, current)) {
+		return ErrPreconditionFailed
+	}
+	if len(p.IfNoneMatch) > 0 && current != nil && matchETag(p.IfNoneMatch, current) {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+// ifVersion is the version a write checked by p must still find, 0 when p always holds
+func (p Precondition) ifVersion(current *model.Artifact) int {
+	if p.IsZero() || current == nil {
+		return 0
+	}
+	return current.Version
+}
+
+func matchETag(etags []string, a *model.Artifact) bool {
+	etag := a.ETag()
+	for _, e := range etags {
+		if e == "*" || e == etag {
+			return true
+		}
+	}
+	return false
 }
 
 type artifactService struct {
-	r  repo.ArtifactRepo
-	s3 *blob.S3Deps
+	r     repo.ArtifactRepo
+	refs  repo.AssetReferenceRepo
+	s3    blob.BlobStore
+	quota StorageService
 }
 
-func NewArtifactService(r repo.ArtifactRepo, s3 *blob.S3Deps) ArtifactService {
-	return &artifactService{r: r, s3: s3}
+func NewArtifactService(r repo.ArtifactRepo, refs repo.AssetReferenceRepo, s3 blob.BlobStore, quota StorageService) ArtifactService {
+	return &artifactService{r: r, refs: refs, s3: s3, quota: quota}
 }
 
 type CreateArtifactInput struct {
@@ -46,6 +120,8 @@ type CreateArtifactInput struct {
 	Filename   string
 	FileHeader *multipart.FileHeader
 	UserMeta   map[string]interface{}
+	TextLimit  int64 // Files up to this size are read whole to extract their text for grep search
+	Precondition
 }
 
 type CreateArtifactFromBytesInput struct {
@@ -57,41 +133,30 @@ type CreateArtifactFromBytesInput struct {
 }
 
 func (s *artifactService) Create(ctx context.Context, in CreateArtifactInput) (*model.Artifact, error) {
-	// Check if artifact with same path and filename already exists in the same disk
-	exists, err := s.r.ExistsByPathAndFilename(ctx, in.DiskID, in.Path, in.Filename, nil)
+	// An artifact with the same path and filename in the same disk gets a new version
+	existing, err := s.getExisting(ctx, in.DiskID, in.Path, in.Filename)
 	if err != nil {
-		return nil, fmt.Errorf("check artifact existence: %w", err)
+		return nil, err
 	}
-	if exists {
-		if err := s.r.DeleteByPath(ctx, in.ProjectID, in.DiskID, in.Path, in.Filename); err != nil {
-			return nil, fmt.Errorf("upsert existing artifact: %w", err)
-		}
+	if err := in.Precondition.check(existing); err != nil {
+		return nil, err
+	}
+	if err := s.checkQuota(ctx, in.ProjectID, in.DiskID, existing, in.FileHeader.Size); err != nil {
+		return nil, err
 	}
 
-	asset, err := s.s3.UploadFormFile(ctx, "disks/"+in.ProjectID.String(), in.FileHeader)
+	asset, err := s.s3.UploadFormFile(ctx, "disks/"+in.ProjectID.String(), in.FileHeader, reuseAssets(s.refs, in.ProjectID))
 	if err != nil {
 		return nil, fmt.Errorf("upload file to S3: %w", err)
 	}
 
 	// Extract and store text content for text-searchable files
 	// This enables grep search functionality
-	parser := fileparser.NewFileParser()
-	var textContent string
-	if parser.CanParseFile(in.FileHeader.Filename, asset.MIME) {
-		// Read file content to extract text
-		file, err := in.FileHeader.Open()
-		if err == nil {
-			content, readErr := io.ReadAll(file)
-			file.Close()
-			if readErr == nil {
-				fileContent, parseErr := parser.ParseFile(in.FileHeader.Filename, asset.MIME, content)
-				if parseErr == nil && fileContent != nil {
-					textContent = fileContent.Raw
-				}
-			}
+	if in.FileHeader.Size <= in.TextLimit && fileparser.NewFileParser().CanParseFile(in.FileHeader.Filename, asset.MIME) {
+		if content, err := readFormFile(in.FileHeader, in.TextLimit); err == nil {
+			asset.Content = extractText(in.FileHeader.Filename, asset.MIME, content)
 		}
 	}
-	asset.Content = textContent
 
 	// Build artifact metadata
 	meta := map[string]interface{}{
@@ -106,50 +171,29 @@ func (s *artifactService) Create(ctx context.Context, in CreateArtifactInput) (*
 		meta[k] = v
 	}
 
-	artifact := &model.Artifact{
+	return s.save(ctx, in.ProjectID, existing, &model.Artifact{
 		DiskID:    in.DiskID,
 		Path:      in.Path,
 		Filename:  in.Filename,
 		Meta:      meta,
 		AssetMeta: datatypes.NewJSONType(*asset),
-	}
-
-	if err := s.r.Create(ctx, in.ProjectID, artifact); err != nil {
-		return nil, fmt.Errorf("create artifact record: %w", err)
-	}
-
-	return artifact, nil
+	}, in.Precondition)
 }
 
 func (s *artifactService) CreateFromBytes(ctx context.Context, in CreateArtifactFromBytesInput) (*model.Artifact, error) {
-	// Check if artifact with same path and filename already exists in the same disk
-	exists, err := s.r.ExistsByPathAndFilename(ctx, in.DiskID, in.Path, in.Filename, nil)
+	// An artifact with the same path and filename in the same disk gets a new version
+	existing, err := s.getExisting(ctx, in.DiskID, in.Path, in.Filename)
 	if err != nil {
-		return nil, fmt.Errorf("check artifact existence: %w", err)
+		return nil, err
 	}
-	if exists {
-		if err := s.r.DeleteByPath(ctx, in.ProjectID, in.DiskID, in.Path, in.Filename); err != nil {
-			return nil, fmt.Errorf("upsert existing artifact: %w", err)
-		}
+	if err := s.checkQuota(ctx, in.ProjectID, in.DiskID, existing, int64(len(in.Content))); err != nil {
+		return nil, err
 	}
 
-	// Upload bytes to S3 with deduplication
-	asset, err := s.s3.UploadBytes(ctx, "disks/"+in.ProjectID.String(), in.Filename, in.Content)
+	asset, err := s.uploadBytes(ctx, in.ProjectID, in.Filename, in.Content)
 	if err != nil {
-		return nil, fmt.Errorf("upload bytes to S3: %w", err)
-	}
-
-	// Extract and store text content for text-searchable files
-	// This enables grep search functionality
-	parser := fileparser.NewFileParser()
-	var textContent string
-	if parser.CanParseFile(in.Filename, asset.MIME) {
-		fileContent, parseErr := parser.ParseFile(in.Filename, asset.MIME, in.Content)
-		if parseErr == nil && fileContent != nil {
-			textContent = fileContent.Raw
-		}
+		return nil, err
 	}
-	asset.Content = textContent
 
 	// Build artifact metadata
 	meta := map[string]interface{}{
@@ -161,26 +205,123 @@ func (s *artifactService) CreateFromBytes(ctx context.Context, in CreateArtifact
 		},
 	}
 
-	artifact := &model.Artifact{
+	return s.save(ctx, in.ProjectID, existing, &model.Artifact{
 		DiskID:    in.DiskID,
 		Path:      in.Path,
 		Filename:  in.Filename,
 		Meta:      meta,
 		AssetMeta: datatypes.NewJSONType(*asset),
+	}, Precondition{})
+}
+
+// uploadBytes uploads content to S3 with deduplication and extracts its text for grep search
+func (s *artifactService) uploadBytes(ctx context.Context, projectID uuid.UUID, filename string, content []byte) (*model.Asset, error) {
+	asset, err := s.s3.UploadBytes(ctx, "disks/"+projectID.String(), filename, content, reuseAssets(s.refs, projectID))
+	if err != nil {
+		return nil, fmt.Errorf("upload bytes to S3: %w", err)
+	}
+
+	asset.Content = extractText(filename, asset.MIME, content)
+	return asset, nil
+}
+
+// readFormFile reads an uploaded file whole, failing if it is larger than limit
+func readFormFile(fh *multipart.FileHeader, limit int64) ([]byte, error) {
+	file, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > limit {
+		return nil, fmt.Errorf("file is larger than %d bytes", limit)
 	}
+	return content, nil
+}
 
-	if err := s.r.Create(ctx, in.ProjectID, artifact); err != nil {
-		return nil, fmt.Errorf("create artifact record: %w", err)
+// extractText returns the text of content for grep search, or "" if it is not a text file
+func extractText(filename string, mimeType string, content []byte) string {
+	parser := fileparser.NewFileParser()
+	if !parser.CanParseFile(filename, mimeType) {
+		return ""
 	}
+	fileContent, err := parser.ParseFile(filename, mimeType, content)
+	if err != nil || fileContent == nil {
+		return ""
+	}
+	return fileContent.Raw
+}
 
-	return artifact, nil
+// getExisting returns the artifact at path and filename, or nil if there is none
+func (s *artifactService) getExisting(ctx context.Context, diskID uuid.UUID, path string, filename string) (*model.Artifact, error) {
+	existing, err := s.r.GetByPath(ctx, diskID, path, filename)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("check artifact existence: %w", err)
+	}
+	return existing, nil
+}
+
+// checkQuota checks the storage quotas for a file of size written to a disk, replacing existing if set
+func (s *artifactService) checkQuota(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, existing *model.Artifact, size int64) error {
+	if s.quota == nil {
+		return nil
+	}
+	in := CheckQuotaInput{ProjectID: projectID, DiskID: &diskID, Bytes: size, Files: 1}
+	if existing != nil {
+		in.Bytes -= existing.AssetMeta.Data().SizeB
+		in.Files = 0
+	}
+	return s.quota.CheckQuota(ctx, in)
+}
+
+// save creates artifact, or replaces the content of existing with it, archiving the previous version.
+// cond must already hold for existing; save fails if another write got in between.
+func (s *artifactService) save(ctx context.Context, projectID uuid.UUID, existing *model.Artifact, artifact *model.Artifact, cond Precondition) (*model.Artifact, error) {
+	if existing == nil {
+		if err := s.r.Create(ctx, projectID, artifact); err != nil {
+			// A concurrent write created the artifact first
+			if !cond.IsZero() {
+				if created, _ := s.getExisting(ctx, artifact.DiskID, artifact.Path, artifact.Filename); created != nil {
+					return nil, ErrPreconditionFailed
+				}
+			}
+			return nil, fmt.Errorf("create artifact record: %w", err)
+		}
+		return artifact, nil
+	}
+
+	if err := s.r.Replace(ctx, existing, artifact.AssetMeta.Data(), artifact.Meta, cond.ifVersion(existing)); err != nil {
+		return nil, fmt.Errorf("upsert existing artifact: %w", err)
+	}
+	return existing, nil
 }
 
-func (s *artifactService) DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string) error {
+func (s *artifactService) DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string, cond Precondition) error {
 	if path == "" || filename == "" {
 		return errors.New("path and filename are required")
 	}
-	return s.r.DeleteByPath(ctx, projectID, diskID, path, filename)
+	if cond.IsZero() {
+		return s.r.DeleteByPath(ctx, projectID, diskID, path, filename, 0)
+	}
+
+	artifact, err := s.getExisting(ctx, diskID, path, filename)
+	if err != nil {
+		return err
+	}
+	if err := cond.check(artifact); err != nil {
+		return err
+	}
+	if artifact == nil {
+		return gorm.ErrRecordNotFound
+	}
+	return s.r.DeleteByPath(ctx, projectID, diskID, path, filename, artifact.Version)
 }
 
 func (s *artifactService) GetByPath(ctx context.Context, diskID uuid.UUID, path string, filename string) (*model.Artifact, error) {
@@ -219,6 +360,15 @@ func (s *artifactService) GetFileContent(ctx context.Context, artifact *model.Ar
 		return nil, fmt.Errorf("unsupported file type: %s (mime: %s)", artifact.Filename, assetData.MIME)
 	}
 
+	// The text of documents was extracted on upload, which is much cheaper than parsing them again
+	if docType := parser.DocumentType(artifact.Filename, assetData.MIME); docType != "" && assetData.Content != "" {
+		return &fileparser.FileContent{
+			Type:       docType,
+			Raw:        assetData.Content,
+			TotalLines: fileparser.CountLines(assetData.Content),
+		}, nil
+	}
+
 	// Download file content from S3
 	content, err := s.s3.DownloadFile(ctx, assetData.S3Key)
 	if err != nil {
@@ -234,12 +384,15 @@ func (s *artifactService) GetFileContent(ctx context.Context, artifact *model.Ar
 	return fileContent, nil
 }
 
-func (s *artifactService) UpdateArtifactMetaByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, userMeta map[string]interface{}) (*model.Artifact, error) {
+func (s *artifactService) UpdateArtifactMetaByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, userMeta map[string]interface{}, cond Precondition) (*model.Artifact, error) {
 	// Get existing artifact
 	artifact, err := s.GetByPath(ctx, diskID, path, filename)
 	if err != nil {
 		return nil, err
 	}
+	if err := cond.check(artifact); err != nil {
+		return nil, err
+	}
 
 	// Validate that user meta doesn't contain system reserved keys
 	reservedKeys := model.Artifact{}.GetReservedKeys()
@@ -262,46 +415,1075 @@ func (s *artifactService) UpdateArtifactMetaByPath(ctx context.Context, diskID u
 		newMeta[k] = v
 	}
 
-	// Update artifact meta
-	artifact.Meta = newMeta
-
-	if err := s.r.Update(ctx, artifact); err != nil {
+	// Update artifact meta, keeping the previous meta as a version
+	if err := s.r.Replace(ctx, artifact, artifact.AssetMeta.Data(), newMeta, cond.ifVersion(artifact)); err != nil {
 		return nil, fmt.Errorf("update artifact meta: %w", err)
 	}
 
 	return artifact, nil
 }
 
-func (s *artifactService) ListByPath(ctx context.Context, diskID uuid.UUID, path string) ([]*model.Artifact, error) {
-	return s.r.ListByPath(ctx, diskID, path)
+// ArtifactFilter narrows artifact listings and searches. The zero value matches every artifact.
+type ArtifactFilter struct {
+	Meta         map[string]interface{} // Key-value pairs the user meta must contain; nested objects match by containment
+	MetaKeys     []string               // Keys the user meta must have, whatever their value
+	MIME         string                 // Exact MIME type, or a family such as text/*
+	MinSizeB     *int64
+	MaxSizeB     *int64
+	UpdatedAfter *time.Time
+}
+
+// toRepo validates the filter and converts it for the repo
+func (f ArtifactFilter) toRepo() (repo.ArtifactFilter, error) {
+	for _, key := range f.MetaKeys {
+		if key == "" {
+			return repo.ArtifactFilter{}, fmt.Errorf("%w: meta key must not be empty", ErrInvalidFilter)
+		}
+	}
+	if f.MIME != "" && (strings.Count(f.MIME, "/") != 1 || strings.HasPrefix(f.MIME, "/") || strings.HasSuffix(f.MIME, "/")) {
+		return repo.ArtifactFilter{}, fmt.Errorf("%w: mime must look like type/subtype or type/*", ErrInvalidFilter)
+	}
+	if (f.MinSizeB != nil && *f.MinSizeB < 0) || (f.MaxSizeB != nil && *f.MaxSizeB < 0) {
+		return repo.ArtifactFilter{}, fmt.Errorf("%w: sizes must not be negative", ErrInvalidFilter)
+	}
+	if f.MinSizeB != nil && f.MaxSizeB != nil && *f.MinSizeB > *f.MaxSizeB {
+		return repo.ArtifactFilter{}, fmt.Errorf("%w: min_size is larger than max_size", ErrInvalidFilter)
+	}
+	return repo.ArtifactFilter{
+		Meta:         f.Meta,
+		MetaKeys:     f.MetaKeys,
+		MIME:         f.MIME,
+		MinSizeB:     f.MinSizeB,
+		MaxSizeB:     f.MaxSizeB,
+		UpdatedAfter: f.UpdatedAfter,
+	}, nil
+}
+
+type ListArtifactsInput struct {
+	DiskID uuid.UUID
+	Path   string
+	Filter ArtifactFilter
+	Limit  int
+	Cursor string
+}
+
+type ListArtifactsOutput struct {
+	Items      []*model.Artifact `json:"items"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	HasMore    bool              `json:"has_more"`
+}
+
+// ListArtifacts lists a page of the artifacts directly in a directory, oldest first
+func (s *artifactService) ListArtifacts(ctx context.Context, in ListArtifactsInput) (*ListArtifactsOutput, error) {
+	filter, err := in.Filter.toRepo()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse cursor (createdAt, id); an empty cursor indicates starting from the oldest
+	var afterT time.Time
+	var afterID uuid.UUID
+	if in.Cursor != "" {
+		afterT, afterID, err = paging.DecodeCursor(in.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad cursor: %v", ErrInvalidFilter, err)
+		}
+	}
+
+	// Query limit+1 is used to determine has_more
+	artifacts, err := s.r.ListByPathWithCursor(ctx, in.DiskID, in.Path, filter, afterT, afterID, in.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ListArtifactsOutput{Items: artifacts}
+	if out.Items == nil {
+		out.Items = []*model.Artifact{}
+	}
+	if len(artifacts) > in.Limit {
+		out.HasMore = true
+		out.Items = artifacts[:in.Limit]
+		last := out.Items[len(out.Items)-1]
+		out.NextCursor = paging.EncodeCursor(last.CreatedAt, last.ID)
+	}
+	return out, nil
 }
 
 func (s *artifactService) GetAllPaths(ctx context.Context, diskID uuid.UUID) ([]string, error) {
 	return s.r.GetAllPaths(ctx, diskID)
 }
 
-func (s *artifactService) GrepArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, limit int) ([]*model.Artifact, error) {
+// searchLimit applies the default and the cap to the number of grep and glob results
+func searchLimit(limit int) int {
 	// Set default limit if not provided
 	if limit <= 0 {
-		limit = 100
+		return 100
 	}
 	// Cap at 1000 results
-	if limit > 1000 {
-		limit = 1000
+	return min(limit, 1000)
+}
+
+func (s *artifactService) GrepArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, filter ArtifactFilter, limit int) ([]*model.Artifact, error) {
+	f, err := filter.toRepo()
+	if err != nil {
+		return nil, err
 	}
+	return s.r.GrepArtifactsWithFilter(ctx, diskID, repo.GrepFilter{Pattern: pattern, ArtifactFilter: f}, searchLimit(limit))
+}
+
+type GrepArtifactMatchesInput struct {
+	ProjectID  uuid.UUID
+	DiskID     uuid.UUID
+	Pattern    string
+	IgnoreCase bool
+	PathGlob   string // Only search files whose full path matches this glob, empty for all files
+	Filter     ArtifactFilter
+	Before     int // Context lines before each match
+	After      int // Context lines after each match
+	MaxCount   int // Matching lines returned per file, 0 for no limit
+	Limit      int // Maximum number of files
+}
 
-	return s.r.GrepArtifacts(ctx, diskID, pattern, limit)
+type GrepArtifactMatches struct {
+	Path       string      `json:"path"`
+	Filename   string      `json:"filename"`
+	MatchCount int         `json:"match_count"` // Matching lines in the file, including those past max_count
+	Lines      []grep.Line `json:"lines"`
 }
 
-func (s *artifactService) GlobArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, limit int) ([]*model.Artifact, error) {
-	// Set default limit if not provided
-	if limit <= 0 {
-		limit = 100
+type GrepArtifactMatchesOutput struct {
+	Files        []GrepArtifactMatches `json:"files"`
+	TotalMatches int                   `json:"total_matches"`
+	Output       string                `json:"output"` // Matches rendered like ripgrep, ready to hand to an agent
+}
+
+// GrepArtifactMatches searches text artifacts line by line and returns the matching lines with their context
+func (s *artifactService) GrepArtifactMatches(ctx context.Context, in GrepArtifactMatchesInput) (*GrepArtifactMatchesOutput, error) {
+	pattern := in.Pattern
+	if in.IgnoreCase {
+		pattern = "(?i)" + pattern
 	}
-	// Cap at 1000 results
-	if limit > 1000 {
-		limit = 1000
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	filter := repo.GrepFilter{Pattern: in.Pattern, IgnoreCase: in.IgnoreCase}
+	if filter.ArtifactFilter, err = in.Filter.toRepo(); err != nil {
+		return nil, err
+	}
+	if in.PathGlob != "" {
+		if filter.Glob, err = path.CompileGlob(in.PathGlob); err != nil {
+			return nil, err
+		}
+	}
+
+	artifacts, err := s.r.GrepArtifactsWithFilter(ctx, in.DiskID, filter, searchLimit(in.Limit))
+	if err != nil {
+		return nil, err
+	}
+
+	out := &GrepArtifactMatchesOutput{Files: make([]GrepArtifactMatches, 0, len(artifacts))}
+	var output strings.Builder
+	opts := grep.Options{Before: in.Before, After: in.After, MaxCount: in.MaxCount}
+	for _, a := range artifacts {
+		res := grep.Search(re, a.AssetMeta.Data().Content, opts)
+		// The database matches the whole content, so patterns spanning lines find no single line
+		if res.MatchCount == 0 {
+			continue
+		}
+		out.Files = append(out.Files, GrepArtifactMatches{
+			Path:       a.Path,
+			Filename:   a.Filename,
+			MatchCount: res.MatchCount,
+			Lines:      res.Lines,
+		})
+		out.TotalMatches += res.MatchCount
+
+		if output.Len() > 0 && (in.Before > 0 || in.After > 0) {
+			output.WriteString("--\n")
+		}
+		output.WriteString(grep.Format(a.Path+a.Filename, res.Lines))
+	}
+	out.Output = output.String()
+
+	return out, nil
+}
+
+func (s *artifactService) GlobArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, filter ArtifactFilter, limit int) ([]*model.Artifact, error) {
+	glob, err := path.CompileGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	f, err := filter.toRepo()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.r.GlobArtifacts(ctx, diskID, glob, f, searchLimit(limit))
+}
+
+type GlobEntries struct {
+	Artifacts   []*model.Artifact `json:"artifacts"`
+	Directories []string          `json:"directories"` // Full directory paths ending with /
+}
+
+// GlobEntries returns the artifacts and the directories matching a glob pattern, both sorted by
+// path. The filter only applies to artifacts.
+func (s *artifactService) GlobEntries(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, filter ArtifactFilter, limit int) (*GlobEntries, error) {
+	glob, err := path.CompileGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	f, err := filter.toRepo()
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts, err := s.r.GlobArtifacts(ctx, diskID, glob, f, searchLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	// Directories only exist through the artifacts in them, so every ancestor of an artifact path is one
+	paths, err := s.r.GetPathsUnder(ctx, diskID, glob.Dir())
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	directories := make([]string, 0)
+	for _, p := range paths {
+		for i := len(glob.Dir()) - 1; i < len(p); i++ {
+			if p[i] != '/' {
+				continue
+			}
+			dir := p[:i+1]
+			if !seen[dir] && glob.MatchDir(dir) {
+				directories = append(directories, dir)
+			}
+			seen[dir] = true
+		}
+	}
+	sort.Strings(directories)
+	if len(directories) > searchLimit(limit) {
+		directories = directories[:searchLimit(limit)]
+	}
+
+	return &GlobEntries{Artifacts: artifacts, Directories: directories}, nil
+}
+
+// ListVersionsByPath returns the artifact with its previous versions, newest first
+func (s *artifactService) ListVersionsByPath(ctx context.Context, diskID uuid.UUID, path string, filename string) (*model.Artifact, []*model.ArtifactVersion, error) {
+	artifact, err := s.GetByPath(ctx, diskID, path, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	versions, err := s.r.ListVersions(ctx, artifact.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return artifact, versions, nil
+}
+
+// GetVersionByPath returns the artifact as it was at the given version.
+// The current version returns the artifact itself.
+func (s *artifactService) GetVersionByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, version int) (*model.Artifact, error) {
+	artifact, err := s.GetByPath(ctx, diskID, path, filename)
+	if err != nil {
+		return nil, err
+	}
+	return s.atVersion(ctx, artifact, version)
+}
+
+func (s *artifactService) atVersion(ctx context.Context, artifact *model.Artifact, version int) (*model.Artifact, error) {
+	if version == artifact.Version {
+		return artifact, nil
+	}
+
+	v, err := s.r.GetVersion(ctx, artifact.ID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := *artifact
+	snapshot.Meta = v.Meta
+	snapshot.AssetMeta = v.AssetMeta
+	snapshot.Version = v.Version
+	snapshot.UpdatedAt = v.CreatedAt
+	return &snapshot, nil
+}
+
+// ArtifactDiff is a unified diff between two versions of a text artifact
+type ArtifactDiff struct {
+	FromVersion int    `json:"from_version"`
+	ToVersion   int    `json:"to_version"`
+	Diff        string `json:"diff"` // empty if both versions have the same content
+}
+
+// DiffVersionsByPath diffs two versions of a text artifact. A toVersion of 0 means the current version.
+func (s *artifactService) DiffVersionsByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, fromVersion int, toVersion int) (*ArtifactDiff, error) {
+	artifact, err := s.GetByPath(ctx, diskID, path, filename)
+	if err != nil {
+		return nil, err
+	}
+	if toVersion == 0 {
+		toVersion = artifact.Version
+	}
+
+	from, err := s.atVersion(ctx, artifact, fromVersion)
+	if err != nil {
+		return nil, fmt.Errorf("get version %d: %w", fromVersion, err)
+	}
+	to, err := s.atVersion(ctx, artifact, toVersion)
+	if err != nil {
+		return nil, fmt.Errorf("get version %d: %w", toVersion, err)
+	}
+
+	fromText, err := s.textContent(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	toText, err := s.textContent(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(fromText),
+		B:        difflib.SplitLines(toText),
+		FromFile: fmt.Sprintf("%s%s@v%d", path, filename, fromVersion),
+		ToFile:   fmt.Sprintf("%s%s@v%d", path, filename, toVersion),
+		Context:  3,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("diff versions: %w", err)
+	}
+
+	return &ArtifactDiff{FromVersion: fromVersion, ToVersion: toVersion, Diff: diff}, nil
+}
+
+// textContent returns the text of an artifact, preferring the content stored for grep over a download
+func (s *artifactService) textContent(ctx context.Context, artifact *model.Artifact) (string, error) {
+	if content := artifact.AssetMeta.Data().Content; content != "" {
+		return content, nil
+	}
+	fileContent, err := s.GetFileContent(ctx, artifact)
+	if err != nil {
+		return "", err
+	}
+	return fileContent.Raw, nil
+}
+
+// RestoreVersionByPath makes a previous version the current content. The replaced content is kept as a new version.
+func (s *artifactService) RestoreVersionByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, version int) (*model.Artifact, error) {
+	artifact, err := s.GetByPath(ctx, diskID, path, filename)
+	if err != nil {
+		return nil, err
+	}
+	if version == artifact.Version {
+		return nil, fmt.Errorf("version %d is already the current version", version)
+	}
+
+	v, err := s.r.GetVersion(ctx, artifact.ID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.r.Replace(ctx, artifact, v.AssetMeta.Data(), v.Meta, 0); err != nil {
+		return nil, fmt.Errorf("restore artifact version: %w", err)
+	}
+	return artifact, nil
+}
+
+type TransferArtifactsInput struct {
+	ProjectID   uuid.UUID
+	DiskID      uuid.UUID
+	Source      string // A file path such as /a/b.txt, or a directory ending with /
+	Destination string // A file path, or a directory ending with /
+	OnConflict  string // error (default), skip or overwrite
+}
+
+type TransferArtifactsOutput struct {
+	Artifacts []*model.Artifact `json:"artifacts"` // Artifacts at their destination
+	Skipped   []string          `json:"skipped"`   // Source paths left alone because the destination was taken
+}
+
+// MoveArtifacts moves or renames a file or a whole directory. Versions move with their artifact.
+func (s *artifactService) MoveArtifacts(ctx context.Context, in TransferArtifactsInput) (*TransferArtifactsOutput, error) {
+	transfers, err := s.planTransfer(ctx, in)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.r.GlobArtifacts(ctx, diskID, pattern, limit)
+	skipped, err := s.r.Move(ctx, in.ProjectID, transfers, onConflict(in.OnConflict))
+	if err != nil {
+		return nil, err
+	}
+
+	out := &TransferArtifactsOutput{Artifacts: make([]*model.Artifact, 0, len(transfers)), Skipped: skippedPaths(skipped)}
+	skippedIDs := make(map[uuid.UUID]bool, len(skipped))
+	for _, t := range skipped {
+		skippedIDs[t.Artifact.ID] = true
+	}
+	for _, t := range transfers {
+		if !skippedIDs[t.Artifact.ID] {
+			out.Artifacts = append(out.Artifacts, t.Artifact)
+		}
+	}
+	return out, nil
+}
+
+// CopyArtifacts copies a file or a whole directory. The copies share the stored files of their
+// sources and start without version history.
+func (s *artifactService) CopyArtifacts(ctx context.Context, in TransferArtifactsInput) (*TransferArtifactsOutput, error) {
+	transfers, err := s.planTransfer(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copies count against quotas even though they share stored files
+	if s.quota != nil {
+		check := CheckQuotaInput{ProjectID: in.ProjectID, DiskID: &in.DiskID, Files: int64(len(transfers))}
+		for _, t := range transfers {
+			check.Bytes += t.Artifact.AssetMeta.Data().SizeB
+		}
+		if err := s.quota.CheckQuota(ctx, check); err != nil {
+			return nil, err
+		}
+	}
+
+	created, skipped, err := s.r.Copy(ctx, in.ProjectID, transfers, onConflict(in.OnConflict))
+	if err != nil {
+		return nil, err
+	}
+	if created == nil {
+		created = []*model.Artifact{}
+	}
+	return &TransferArtifactsOutput{Artifacts: created, Skipped: skippedPaths(skipped)}, nil
+}
+
+// planTransfer resolves the source of a move or copy to artifacts and their destinations
+func (s *artifactService) planTransfer(ctx context.Context, in TransferArtifactsInput) ([]repo.ArtifactTransfer, error) {
+	src := strings.TrimSpace(in.Source)
+	dst := strings.TrimSpace(in.Destination)
+	for _, p := range []string{src, dst} {
+		if err := path.ValidatePath(p); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidTransfer, p, err)
+		}
+	}
+	if !strings.HasPrefix(src, "/") {
+		src = "/" + src
+	}
+	if !strings.HasPrefix(dst, "/") {
+		dst = "/" + dst
+	}
+	switch onConflict(in.OnConflict) {
+	case repo.OnConflictError, repo.OnConflictSkip, repo.OnConflictOverwrite:
+	default:
+		return nil, fmt.Errorf("%w: unknown on_conflict %q", ErrInvalidTransfer, in.OnConflict)
+	}
+
+	// A single file
+	if !strings.HasSuffix(src, "/") {
+		srcPath, srcFilename := path.SplitFilePath(src)
+		dstPath, dstFilename := path.SplitFilePath(dst)
+		if dstFilename == "" {
+			dstFilename = srcFilename
+		}
+		if srcPath == dstPath && srcFilename == dstFilename {
+			return nil, fmt.Errorf("%w: source and destination are the same", ErrInvalidTransfer)
+		}
+
+		artifact, err := s.GetByPath(ctx, in.DiskID, srcPath, srcFilename)
+		if err != nil {
+			return nil, err
+		}
+		return []repo.ArtifactTransfer{transferTo(artifact, dstPath, dstFilename)}, nil
+	}
+
+	// A directory and everything below it
+	if !strings.HasSuffix(dst, "/") {
+		return nil, fmt.Errorf("%w: destination of a directory must end with /", ErrInvalidTransfer)
+	}
+	if strings.HasPrefix(dst, src) || strings.HasPrefix(src, dst) {
+		return nil, fmt.Errorf("%w: source and destination directories must not contain each other", ErrInvalidTransfer)
+	}
+
+	artifacts, err := s.r.ListUnderPath(ctx, in.DiskID, src)
+	if err != nil {
+		return nil, err
+	}
+	if len(artifacts) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	transfers := make([]repo.ArtifactTransfer, 0, len(artifacts))
+	for _, a := range artifacts {
+		transfers = append(transfers, transferTo(a, dst+strings.TrimPrefix(a.Path, src), a.Filename))
+	}
+	return transfers, nil
+}
+
+// transferTo builds a transfer of artifact with its system meta pointing at the destination
+func transferTo(artifact *model.Artifact, dstPath string, dstFilename string) repo.ArtifactTransfer {
+	meta := make(map[string]interface{}, len(artifact.Meta))
+	for k, v := range artifact.Meta {
+		meta[k] = v
+	}
+	info := map[string]interface{}{}
+	if old, ok := artifact.Meta[model.ArtifactInfoKey].(map[string]interface{}); ok {
+		for k, v := range old {
+			info[k] = v
+		}
+	}
+	info["path"] = dstPath
+	info["filename"] = dstFilename
+	meta[model.ArtifactInfoKey] = info
+
+	return repo.ArtifactTransfer{Artifact: artifact, Path: dstPath, Filename: dstFilename, Meta: meta}
+}
+
+func onConflict(s string) repo.OnConflict {
+	if s == "" {
+		return repo.OnConflictError
+	}
+	return repo.OnConflict(s)
+}
+
+func skippedPaths(skipped []repo.ArtifactTransfer) []string {
+	paths := make([]string, 0, len(skipped))
+	for _, t := range skipped {
+		paths = append(paths, t.Artifact.Path+t.Artifact.Filename)
+	}
+	return paths
+}
+
+var (
+	ErrInvalidArchive  = errors.New("invalid archive")
+	ErrArchiveTooLarge = archive.ErrTooLarge
+)
+
+type ArchiveArtifactsInput struct {
+	DiskID uuid.UUID
+	Path   string // Directory to archive, / for the whole disk
+	Format string // zip (default) or tar.gz
+}
+
+// ArtifactArchive is an archive of a directory, ready to be streamed
+type ArtifactArchive struct {
+	Filename    string // Suggested download filename, such as out.zip
+	ContentType string
+	format      archive.Format
+	dir         string
+	artifacts   []*model.Artifact
+	download    func(ctx context.Context, key string) ([]byte, error)
+}
+
+// ArchiveArtifacts resolves the artifacts under a directory into an archive. Nothing is read from
+// S3 until the archive is written, so errors about the request come before any output.
+func (s *artifactService) ArchiveArtifacts(ctx context.Context, in ArchiveArtifactsInput) (*ArtifactArchive, error) {
+	format := archive.FormatZip
+	if in.Format != "" {
+		var err error
+		if format, err = archive.ParseFormat(in.Format); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+	}
+	dir, err := archiveDir(in.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts, err := s.r.ListUnderPath(ctx, in.DiskID, dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(artifacts) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	name := "disk"
+	if dir != "/" {
+		name = strings.TrimSuffix(dir, "/")
+		name = name[strings.LastIndex(name, "/")+1:]
+	}
+	return &ArtifactArchive{
+		Filename:    name + "." + format.Ext(),
+		ContentType: format.ContentType(),
+		format:      format,
+		dir:         dir,
+		artifacts:   artifacts,
+		download:    s.s3.DownloadFile,
+	}, nil
+}
+
+// WriteTo streams the archive to w, reading one file at a time from S3
+func (a *ArtifactArchive) WriteTo(ctx context.Context, w io.Writer) error {
+	aw := archive.NewWriter(w, a.format)
+	for _, artifact := range a.artifacts {
+		content, err := a.download(ctx, artifact.AssetMeta.Data().S3Key)
+		if err != nil {
+			return fmt.Errorf("download %s%s: %w", artifact.Path, artifact.Filename, err)
+		}
+		name := strings.TrimPrefix(artifact.Path, a.dir) + artifact.Filename
+		if err := aw.Add(name, artifact.UpdatedAt, content); err != nil {
+			return fmt.Errorf("write %s to archive: %w", name, err)
+		}
+	}
+	return aw.Close()
+}
+
+type ExtractArchiveInput struct {
+	ProjectID uuid.UUID
+	DiskID    uuid.UUID
+	Path      string // Directory to extract into, / for the disk root
+	Archive   *multipart.FileHeader
+	Limits    archive.Limits
+}
+
+type ExtractArchiveOutput struct {
+	Artifacts []*model.Artifact `json:"artifacts"`
+}
+
+// ExtractArchive unpacks a zip or tar.gz archive into a directory. Files that already exist get a
+// new version. The whole archive is validated before anything is written, and its files are either
+// all written or none is.
+func (s *artifactService) ExtractArchive(ctx context.Context, in ExtractArchiveInput) (*ExtractArchiveOutput, error) {
+	dir, err := archiveDir(in.Path)
+	if err != nil {
+		return nil, err
+	}
+	format, err := archive.DetectFormat(in.Archive.Filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	f, err := in.Archive.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+
+	files, err := archive.Read(f, in.Archive.Size, format, in.Limits)
+	if err != nil {
+		if errors.Is(err, archive.ErrTooLarge) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	// A name that appears more than once is extracted from its last entry, as unpacking in order would leave it
+	last := make(map[string]int, len(files))
+	for i, file := range files {
+		last[file.Name] = i
+	}
+
+	// Files that replace existing ones only count the difference of their size against the quota
+	var bytes, newFiles int64
+	extracted := make([]archive.File, 0, len(files))
+	writes := make([]repo.ArtifactWrite, 0, len(files))
+	for i, file := range files {
+		if isMacOSSystemFile(file.Name) || last[file.Name] != i {
+			continue
+		}
+		filePath, filename := path.SplitFilePath(dir + file.Name)
+		if err := path.ValidatePath(filePath); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, file.Name, err)
+		}
+		existing, err := s.getExisting(ctx, in.DiskID, filePath, filename)
+		if err != nil {
+			return nil, err
+		}
+		bytes += int64(len(file.Content))
+		if existing != nil {
+			bytes -= existing.AssetMeta.Data().SizeB
+		} else {
+			newFiles++
+		}
+		extracted = append(extracted, file)
+		writes = append(writes, repo.ArtifactWrite{
+			Existing: existing,
+			Artifact: &model.Artifact{DiskID: in.DiskID, Path: filePath, Filename: filename},
+		})
+	}
+
+	// Check the whole archive up front so that it is not extracted halfway
+	if s.quota != nil {
+		if err := s.quota.CheckQuota(ctx, CheckQuotaInput{
+			ProjectID: in.ProjectID,
+			DiskID:    &in.DiskID,
+			Bytes:     bytes,
+			Files:     newFiles,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, file := range extracted {
+		artifact := writes[i].Artifact
+		asset, err := s.uploadBytes(ctx, in.ProjectID, artifact.Filename, file.Content)
+		if err != nil {
+			return nil, fmt.Errorf("extract %s: %w", file.Name, err)
+		}
+		artifact.AssetMeta = datatypes.NewJSONType(*asset)
+		artifact.Meta = map[string]interface{}{
+			model.ArtifactInfoKey: map[string]interface{}{
+				"path":     artifact.Path,
+				"filename": artifact.Filename,
+				"mime":     asset.MIME,
+				"size":     asset.SizeB,
+			},
+		}
+	}
+
+	// The artifacts are written in one transaction, so that a failure leaves the disk as it was
+	if err := s.r.SaveAll(ctx, in.ProjectID, in.DiskID, writes); err != nil {
+		return nil, fmt.Errorf("extract archive: %w", err)
+	}
+
+	out := &ExtractArchiveOutput{Artifacts: make([]*model.Artifact, 0, len(writes))}
+	for _, w := range writes {
+		if w.Existing != nil {
+			out.Artifacts = append(out.Artifacts, w.Existing)
+		} else {
+			out.Artifacts = append(out.Artifacts, w.Artifact)
+		}
+	}
+	return out, nil
+}
+
+// archiveDir normalizes the directory of an archive to start and end with /
+func archiveDir(p string) (string, error) {
+	dir := strings.TrimSpace(p)
+	if dir == "" {
+		dir = "/"
+	}
+	if err := path.ValidatePath(dir); err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrInvalidArchive, dir, err)
+	}
+	if !strings.HasPrefix(dir, "/") {
+		dir = "/" + dir
+	}
+	if !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	return dir, nil
+}
+
+var (
+	ErrInvalidEdit  = errors.New("invalid edit")
+	ErrNotEditable  = errors.New("artifact is not a text file")
+	ErrEditConflict = errors.New("artifact kept changing during the edit")
+)
+
+// Edit commands
+const (
+	EditStrReplace   = "str_replace"
+	EditInsertAtLine = "insert_at_line"
+	EditDeleteLines  = "delete_lines"
+	EditApplyPatch   = "apply_patch"
+)
+
+// editSnippetContext is the number of lines shown around the changed region of an edit
+const editSnippetContext = 4
+
+// editAttempts is how many times an edit without a precondition is applied to the latest content
+// before it gives up on concurrent writes
+const editAttempts = 3
+
+type EditArtifactInput struct {
+	ProjectID  uuid.UUID
+	DiskID     uuid.UUID
+	Path       string
+	Filename   string
+	Command    string // str_replace, insert_at_line, delete_lines or apply_patch
+	OldStr     string // str_replace: text to replace, must occur exactly once
+	NewStr     string // str_replace: replacement text
+	InsertLine int    // insert_at_line: line to insert after, 0 for the beginning
+	Text       string // insert_at_line: text to insert
+	StartLine  int    // delete_lines: first line to delete, 1-based
+	EndLine    int    // delete_lines: last line to delete, inclusive
+	Patch      string // apply_patch: unified diff of the file
+	Precondition
+}
+
+type EditArtifactOutput struct {
+	Artifact *model.Artifact `json:"artifact"`
+	textedit.Region
+	Snippet string `json:"snippet"` // Changed lines with some context, prefixed with line numbers
+}
+
+// EditArtifact edits a text file on the server. The result is stored like an upload of the new
+// content, so the previous content is kept as a version and user meta is preserved. The edited
+// content only replaces the version it was made from: if another write got in between, an edit
+// with a precondition fails with ErrPreconditionFailed, and one without is applied again to the
+// new content.
+func (s *artifactService) EditArtifact(ctx context.Context, in EditArtifactInput) (*EditArtifactOutput, error) {
+	for attempt := 1; ; attempt++ {
+		out, err := s.edit(ctx, in)
+		if !errors.Is(err, ErrPreconditionFailed) || !in.Precondition.IsZero() {
+			return out, err
+		}
+		if attempt == editAttempts {
+			return nil, fmt.Errorf("%w: gave up after %d attempts", ErrEditConflict, attempt)
+		}
+	}
+}
+
+// edit applies an edit to the current content of the artifact
+func (s *artifactService) edit(ctx context.Context, in EditArtifactInput) (*EditArtifactOutput, error) {
+	artifact, err := s.GetByPath(ctx, in.DiskID, in.Path, in.Filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.Precondition.check(artifact); err != nil {
+		return nil, err
+	}
+	// Documents such as PDFs have text extracted for reading, but it cannot be written back
+	parser := fileparser.NewFileParser()
+	if mimeType := artifact.AssetMeta.Data().MIME; !parser.CanParseFile(artifact.Filename, mimeType) ||
+		parser.DocumentType(artifact.Filename, mimeType) != "" {
+		return nil, ErrNotEditable
+	}
+	text, err := s.textContent(ctx, artifact)
+	if err != nil {
+		return nil, fmt.Errorf("read artifact content: %w", err)
+	}
+
+	var (
+		edited string
+		region textedit.Region
+	)
+	switch in.Command {
+	case EditStrReplace:
+		edited, region, err = textedit.StrReplace(text, in.OldStr, in.NewStr)
+	case EditInsertAtLine:
+		edited, region, err = textedit.InsertAtLine(text, in.InsertLine, in.Text)
+	case EditDeleteLines:
+		edited, region, err = textedit.DeleteLines(text, in.StartLine, in.EndLine)
+	case EditApplyPatch:
+		edited, region, err = textedit.ApplyPatch(text, in.Patch)
+	default:
+		err = fmt.Errorf("unknown command %q", in.Command)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEdit, err)
+	}
+
+	out := &EditArtifactOutput{Artifact: artifact, Region: region, Snippet: editSnippet(edited, region)}
+	if edited == text {
+		return out, nil
+	}
+
+	content := []byte(edited)
+	if err := s.checkQuota(ctx, in.ProjectID, in.DiskID, artifact, int64(len(content))); err != nil {
+		return nil, err
+	}
+	asset, err := s.uploadBytes(ctx, in.ProjectID, artifact.Filename, content)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := make(map[string]interface{}, len(artifact.Meta))
+	for k, v := range artifact.Meta {
+		meta[k] = v
+	}
+	info := map[string]interface{}{}
+	if old, ok := artifact.Meta[model.ArtifactInfoKey].(map[string]interface{}); ok {
+		for k, v := range old {
+			info[k] = v
+		}
+	}
+	info["mime"] = asset.MIME
+	info["size"] = asset.SizeB
+	meta[model.ArtifactInfoKey] = info
+
+	if err := s.r.Replace(ctx, artifact, *asset, meta, artifact.Version); err != nil {
+		return nil, fmt.Errorf("upsert existing artifact: %w", err)
+	}
+	return out, nil
+}
+
+// editSnippet returns the lines of region with some context, numbered like a file read
+func editSnippet(text string, region textedit.Region) string {
+	start := max(region.Start-1-editSnippetContext, 0)
+	end := max(region.End, region.Start-1) + editSnippetContext
+	content := &fileparser.FileContent{Raw: text}
+	return content.Window(fileparser.LineWindow{Offset: start, Limit: end - start, LineNumbers: true}).Raw
+}
+
+var (
+	ErrInvalidUpload  = errors.New("invalid upload")
+	ErrUploadTooLarge = errors.New("upload is too large")
+	ErrUploadExpired  = errors.New("upload expired")
+)
+
+// uploadFinalizeWindow is how long after its URL expired an upload can still be finalized, so
+// that a PUT started just before the expiry can complete
+const uploadFinalizeWindow = time.Hour
+
+// stagedContentType is the content type presigned PUTs are signed with. The actual type of the
+// file is detected from its content when the upload is finalized.
+const stagedContentType = "application/octet-stream"
+
+var sha256Hex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type CreateUploadURLInput struct {
+	ProjectID uuid.UUID
+	DiskID    uuid.UUID
+	Path      string
+	Filename  string
+	SizeB     int64
+	SHA256    string // Hex SHA256 of the content
+	UserMeta  map[string]interface{}
+	MaxSizeB  int64         // Largest file that can be uploaded
+	Expire    time.Duration // How long the upload URL is valid
+}
+
+type CreateUploadURLOutput struct {
+	UploadID  uuid.UUID         `json:"upload_id"`
+	URL       string            `json:"upload_url"`
+	Method    string            `json:"method" example:"PUT"`
+	Headers   map[string]string `json:"headers"` // Headers the upload request must be sent with
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// CreateUploadURL starts a direct upload of a file to S3. The client PUTs the file to the
+// returned URL, then finalizes the upload to create the artifact.
+func (s *artifactService) CreateUploadURL(ctx context.Context, in CreateUploadURLInput) (*CreateUploadURLOutput, error) {
+	sum := strings.ToLower(in.SHA256)
+	if !sha256Hex.MatchString(sum) {
+		return nil, fmt.Errorf("%w: sha256 must be 64 hex characters", ErrInvalidUpload)
+	}
+	if in.SizeB < 0 {
+		return nil, fmt.Errorf("%w: negative size", ErrInvalidUpload)
+	}
+	if in.SizeB > in.MaxSizeB {
+		return nil, fmt.Errorf("%w: %d bytes, the limit is %d", ErrUploadTooLarge, in.SizeB, in.MaxSizeB)
+	}
+
+	existing, err := s.getExisting(ctx, in.DiskID, in.Path, in.Filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkQuota(ctx, in.ProjectID, in.DiskID, existing, in.SizeB); err != nil {
+		return nil, err
+	}
+
+	id := uuid.New()
+	upload := &model.ArtifactUpload{
+		ID:        id,
+		ProjectID: in.ProjectID,
+		DiskID:    in.DiskID,
+		Path:      in.Path,
+		Filename:  in.Filename,
+		Meta:      in.UserMeta,
+		S3Key:     fmt.Sprintf("staging/%s/%s%s", in.ProjectID, id, strings.ToLower(filepath.Ext(in.Filename))),
+		SizeB:     in.SizeB,
+		SHA256:    sum,
+		ExpiresAt: time.Now().Add(in.Expire),
+	}
+	if err := s.r.CreateUpload(ctx, upload); err != nil {
+		return nil, err
+	}
+
+	url, err := s.s3.PresignPut(ctx, upload.S3Key, stagedContentType, in.Expire)
+	if err != nil {
+		_ = s.r.DeleteUpload(ctx, upload.ID)
+		return nil, fmt.Errorf("presign upload URL: %w", err)
+	}
+	return &CreateUploadURLOutput{
+		UploadID:  upload.ID,
+		URL:       url,
+		Method:    "PUT",
+		Headers:   map[string]string{"Content-Type": stagedContentType},
+		ExpiresAt: upload.ExpiresAt,
+	}, nil
+}
+
+type FinalizeUploadInput struct {
+	ProjectID uuid.UUID
+	DiskID    uuid.UUID
+	UploadID  uuid.UUID
+	TextLimit int64 // Files up to this size are read whole to extract their text for grep search
+}
+
+// FinalizeUpload checks the size and SHA256 of a file uploaded to its presigned URL, moves it to
+// its content-addressed location and creates the artifact, or a new version of it.
+func (s *artifactService) FinalizeUpload(ctx context.Context, in FinalizeUploadInput) (*model.Artifact, error) {
+	upload, err := s.r.GetUpload(ctx, in.DiskID, in.UploadID)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(upload.ExpiresAt.Add(uploadFinalizeWindow)) {
+		_ = s.s3.DeleteObject(ctx, upload.S3Key)
+		_ = s.r.DeleteUpload(ctx, upload.ID)
+		return nil, ErrUploadExpired
+	}
+
+	staged, err := s.s3.ReadStaged(ctx, upload.S3Key, in.TextLimit)
+	if err != nil {
+		if errors.Is(err, blob.ErrObjectNotFound) {
+			return nil, fmt.Errorf("%w: the file has not been uploaded yet", ErrInvalidUpload)
+		}
+		return nil, err
+	}
+	// The staged file is left in place on a mismatch, so that it can be uploaded again
+	if staged.SizeB != upload.SizeB {
+		return nil, fmt.Errorf("%w: uploaded %d bytes, expected %d", ErrInvalidUpload, staged.SizeB, upload.SizeB)
+	}
+	if staged.SHA256 != upload.SHA256 {
+		return nil, fmt.Errorf("%w: sha256 of the uploaded file is %s, expected %s", ErrInvalidUpload, staged.SHA256, upload.SHA256)
+	}
+
+	existing, err := s.getExisting(ctx, upload.DiskID, upload.Path, upload.Filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkQuota(ctx, in.ProjectID, upload.DiskID, existing, staged.SizeB); err != nil {
+		return nil, err
+	}
+
+	contentType := mime.DetectMimeType(staged.Head, upload.Filename)
+	asset, err := s.s3.PromoteStaged(ctx, upload.S3Key, "disks/"+in.ProjectID.String(), staged, contentType,
+		strings.ToLower(filepath.Ext(upload.Filename)), map[string]string{
+			"sha256": staged.SHA256,
+			"name":   upload.Filename,
+		}, reuseAssets(s.refs, in.ProjectID))
+	if err != nil {
+		if errors.Is(err, blob.ErrObjectChanged) {
+			return nil, fmt.Errorf("%w: the file was uploaded again while it was finalized", ErrInvalidUpload)
+		}
+		return nil, err
+	}
+	if staged.Content != nil {
+		asset.Content = extractText(upload.Filename, asset.MIME, staged.Content)
+	}
+
+	meta := map[string]interface{}{
+		model.ArtifactInfoKey: map[string]interface{}{
+			"path":     upload.Path,
+			"filename": upload.Filename,
+			"mime":     asset.MIME,
+			"size":     asset.SizeB,
+		},
+	}
+	for k, v := range upload.Meta {
+		meta[k] = v
+	}
+
+	artifact, err := s.save(ctx, in.ProjectID, existing, &model.Artifact{
+		DiskID:    upload.DiskID,
+		Path:      upload.Path,
+		Filename:  upload.Filename,
+		Meta:      meta,
+		AssetMeta: datatypes.NewJSONType(*asset),
+	}, Precondition{})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.r.DeleteUpload(ctx, upload.ID); err != nil {
+		return nil, fmt.Errorf("delete upload record: %w", err)
+	}
+	return artifact, nil
 }
@@ -1,18 +1,30 @@
 package service
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"mime/multipart"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
 	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/archive"
+	"github.com/memodb-io/Acontext/internal/pkg/paging"
+	"github.com/memodb-io/Acontext/internal/pkg/textedit"
 	"github.com/memodb-io/Acontext/internal/pkg/utils/fileparser"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/path"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 // MockArtifactRepo is a mock implementation of ArtifactRepo
@@ -25,8 +37,8 @@ func (m *MockArtifactRepo) Create(ctx context.Context, projectID uuid.UUID, f *m
 	return args.Error(0)
 }
 
-func (m *MockArtifactRepo) DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string) error {
-	args := m.Called(ctx, projectID, diskID, path, filename)
+func (m *MockArtifactRepo) DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string, ifVersion int) error {
+	args := m.Called(ctx, projectID, diskID, path, filename, ifVersion)
 	return args.Error(0)
 }
 
@@ -51,6 +63,14 @@ func (m *MockArtifactRepo) ListByPath(ctx context.Context, diskID uuid.UUID, pat
 	return args.Get(0).([]*model.Artifact), args.Error(1)
 }
 
+func (m *MockArtifactRepo) ListByPathWithCursor(ctx context.Context, diskID uuid.UUID, path string, filter repo.ArtifactFilter, afterCreatedAt time.Time, afterID uuid.UUID, limit int) ([]*model.Artifact, error) {
+	args := m.Called(ctx, diskID, path, filter, afterCreatedAt, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Artifact), args.Error(1)
+}
+
 func (m *MockArtifactRepo) GetAllPaths(ctx context.Context, diskID uuid.UUID) ([]string, error) {
 	args := m.Called(ctx, diskID)
 	if args.Get(0) == nil {
@@ -64,22 +84,111 @@ func (m *MockArtifactRepo) ExistsByPathAndFilename(ctx context.Context, diskID u
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockArtifactRepo) GrepArtifacts(ctx context.Context, diskID uuid.UUID, pattern string, limit int) ([]*model.Artifact, error) {
-	args := m.Called(ctx, diskID, pattern, limit)
+func (m *MockArtifactRepo) GrepArtifactsWithFilter(ctx context.Context, diskID uuid.UUID, filter repo.GrepFilter, limit int) ([]*model.Artifact, error) {
+	args := m.Called(ctx, diskID, filter, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*model.Artifact), args.Error(1)
 }
 
-func (m *MockArtifactRepo) GlobArtifacts(ctx context.Context, diskID uuid.UUID, pattern string, limit int) ([]*model.Artifact, error) {
-	args := m.Called(ctx, diskID, pattern, limit)
+func (m *MockArtifactRepo) GlobArtifacts(ctx context.Context, diskID uuid.UUID, glob *path.Glob, filter repo.ArtifactFilter, limit int) ([]*model.Artifact, error) {
+	args := m.Called(ctx, diskID, glob.String(), filter, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*model.Artifact), args.Error(1)
 }
 
+func (m *MockArtifactRepo) GetPathsUnder(ctx context.Context, diskID uuid.UUID, dir string) ([]string, error) {
+	args := m.Called(ctx, diskID, dir)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockArtifactRepo) Replace(ctx context.Context, a *model.Artifact, asset model.Asset, meta map[string]interface{}, ifVersion int) error {
+	args := m.Called(ctx, a, asset, meta, ifVersion)
+	return args.Error(0)
+}
+
+func (m *MockArtifactRepo) ListVersions(ctx context.Context, artifactID uuid.UUID) ([]*model.ArtifactVersion, error) {
+	args := m.Called(ctx, artifactID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.ArtifactVersion), args.Error(1)
+}
+
+func (m *MockArtifactRepo) GetVersion(ctx context.Context, artifactID uuid.UUID, version int) (*model.ArtifactVersion, error) {
+	args := m.Called(ctx, artifactID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ArtifactVersion), args.Error(1)
+}
+
+func (m *MockArtifactRepo) ListUnderPath(ctx context.Context, diskID uuid.UUID, dir string) ([]*model.Artifact, error) {
+	args := m.Called(ctx, diskID, dir)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Artifact), args.Error(1)
+}
+
+func (m *MockArtifactRepo) Move(ctx context.Context, projectID uuid.UUID, transfers []repo.ArtifactTransfer, onConflict repo.OnConflict) ([]repo.ArtifactTransfer, error) {
+	args := m.Called(ctx, projectID, transfers, onConflict)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repo.ArtifactTransfer), args.Error(1)
+}
+
+func (m *MockArtifactRepo) Copy(ctx context.Context, projectID uuid.UUID, transfers []repo.ArtifactTransfer, onConflict repo.OnConflict) ([]*model.Artifact, []repo.ArtifactTransfer, error) {
+	args := m.Called(ctx, projectID, transfers, onConflict)
+	var created []*model.Artifact
+	if args.Get(0) != nil {
+		created = args.Get(0).([]*model.Artifact)
+	}
+	var skipped []repo.ArtifactTransfer
+	if args.Get(1) != nil {
+		skipped = args.Get(1).([]repo.ArtifactTransfer)
+	}
+	return created, skipped, args.Error(2)
+}
+
+func (m *MockArtifactRepo) CreateUpload(ctx context.Context, u *model.ArtifactUpload) error {
+	args := m.Called(ctx, u)
+	return args.Error(0)
+}
+
+func (m *MockArtifactRepo) GetUpload(ctx context.Context, diskID uuid.UUID, uploadID uuid.UUID) (*model.ArtifactUpload, error) {
+	args := m.Called(ctx, diskID, uploadID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ArtifactUpload), args.Error(1)
+}
+
+func (m *MockArtifactRepo) DeleteUpload(ctx context.Context, uploadID uuid.UUID) error {
+	args := m.Called(ctx, uploadID)
+	return args.Error(0)
+}
+
+func (m *MockArtifactRepo) SaveAll(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, writes []repo.ArtifactWrite) error {
+	args := m.Called(ctx, projectID, diskID, writes)
+	return args.Error(0)
+}
+
+func (m *MockArtifactRepo) ListExpiredUploads(ctx context.Context, before time.Time, limit int) ([]*model.ArtifactUpload, error) {
+	args := m.Called(ctx, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.ArtifactUpload), args.Error(1)
+}
+
 // MockArtifactS3Deps is a mock implementation of blob.S3Deps for file service
 type MockArtifactS3Deps struct {
 	mock.Mock
@@ -217,11 +326,8 @@ func (s *testArtifactService) Create(ctx context.Context, in CreateArtifactInput
 	return file, nil
 }
 
-func (s *testArtifactService) DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string) error {
-	if path == "" || filename == "" {
-		return errors.New("path and filename are required")
-	}
-	return s.r.DeleteByPath(ctx, projectID, diskID, path, filename)
+func (s *testArtifactService) DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string, cond Precondition) error {
+	return (&artifactService{r: s.r}).DeleteByPath(ctx, projectID, diskID, path, filename, cond)
 }
 
 func (s *testArtifactService) GetByPath(ctx context.Context, diskID uuid.UUID, path string, filename string) (*model.Artifact, error) {
@@ -244,15 +350,15 @@ func (s *testArtifactService) GetPresignedURL(ctx context.Context, artifact *mod
 	return s.s3.PresignGet(ctx, assetData.S3Key, expire)
 }
 
-func (s *testArtifactService) ListByPath(ctx context.Context, diskID uuid.UUID, path string) ([]*model.Artifact, error) {
-	return s.r.ListByPath(ctx, diskID, path)
+func (s *testArtifactService) ListArtifacts(ctx context.Context, in ListArtifactsInput) (*ListArtifactsOutput, error) {
+	return (&artifactService{r: s.r}).ListArtifacts(ctx, in)
 }
 
 func (s *testArtifactService) GetAllPaths(ctx context.Context, diskID uuid.UUID) ([]string, error) {
 	return s.r.GetAllPaths(ctx, diskID)
 }
 
-func (s *testArtifactService) UpdateArtifactMetaByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, userMeta map[string]interface{}) (*model.Artifact, error) {
+func (s *testArtifactService) UpdateArtifactMetaByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, userMeta map[string]interface{}, cond Precondition) (*model.Artifact, error) {
 	// Get existing artifact
 	artifact, err := s.GetByPath(ctx, diskID, path, filename)
 	if err != nil {
@@ -303,16 +409,69 @@ func (s *testArtifactService) GetFileContent(ctx context.Context, artifact *mode
 	}, nil
 }
 
-func (s *testArtifactService) GrepArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, limit int) ([]*model.Artifact, error) {
+func (s *testArtifactService) GrepArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, filter ArtifactFilter, limit int) ([]*model.Artifact, error) {
 	// Test implementation - return empty list for now
 	return []*model.Artifact{}, nil
 }
 
-func (s *testArtifactService) GlobArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, limit int) ([]*model.Artifact, error) {
+func (s *testArtifactService) GrepArtifactMatches(ctx context.Context, in GrepArtifactMatchesInput) (*GrepArtifactMatchesOutput, error) {
+	return (&artifactService{r: s.r}).GrepArtifactMatches(ctx, in)
+}
+
+func (s *testArtifactService) GlobEntries(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, filter ArtifactFilter, limit int) (*GlobEntries, error) {
+	return (&artifactService{r: s.r}).GlobEntries(ctx, projectID, diskID, pattern, filter, limit)
+}
+
+func (s *testArtifactService) GlobArtifacts(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, pattern string, filter ArtifactFilter, limit int) ([]*model.Artifact, error) {
 	// Test implementation - return empty list for now
 	return []*model.Artifact{}, nil
 }
 
+// Versioning does not touch S3 for text artifacts, so the real implementation is used
+func (s *testArtifactService) ListVersionsByPath(ctx context.Context, diskID uuid.UUID, path string, filename string) (*model.Artifact, []*model.ArtifactVersion, error) {
+	return (&artifactService{r: s.r}).ListVersionsByPath(ctx, diskID, path, filename)
+}
+
+func (s *testArtifactService) GetVersionByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, version int) (*model.Artifact, error) {
+	return (&artifactService{r: s.r}).GetVersionByPath(ctx, diskID, path, filename, version)
+}
+
+func (s *testArtifactService) DiffVersionsByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, fromVersion int, toVersion int) (*ArtifactDiff, error) {
+	return (&artifactService{r: s.r}).DiffVersionsByPath(ctx, diskID, path, filename, fromVersion, toVersion)
+}
+
+func (s *testArtifactService) RestoreVersionByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, version int) (*model.Artifact, error) {
+	return (&artifactService{r: s.r}).RestoreVersionByPath(ctx, diskID, path, filename, version)
+}
+
+func (s *testArtifactService) MoveArtifacts(ctx context.Context, in TransferArtifactsInput) (*TransferArtifactsOutput, error) {
+	return (&artifactService{r: s.r}).MoveArtifacts(ctx, in)
+}
+
+func (s *testArtifactService) CopyArtifacts(ctx context.Context, in TransferArtifactsInput) (*TransferArtifactsOutput, error) {
+	return (&artifactService{r: s.r}).CopyArtifacts(ctx, in)
+}
+
+func (s *testArtifactService) ArchiveArtifacts(ctx context.Context, in ArchiveArtifactsInput) (*ArtifactArchive, error) {
+	return (&artifactService{r: s.r}).ArchiveArtifacts(ctx, in)
+}
+
+func (s *testArtifactService) EditArtifact(ctx context.Context, in EditArtifactInput) (*EditArtifactOutput, error) {
+	return (&artifactService{r: s.r}).EditArtifact(ctx, in)
+}
+
+func (s *testArtifactService) CreateUploadURL(ctx context.Context, in CreateUploadURLInput) (*CreateUploadURLOutput, error) {
+	return (&artifactService{r: s.r}).CreateUploadURL(ctx, in)
+}
+
+func (s *testArtifactService) FinalizeUpload(ctx context.Context, in FinalizeUploadInput) (*model.Artifact, error) {
+	return (&artifactService{r: s.r}).FinalizeUpload(ctx, in)
+}
+
+func (s *testArtifactService) ExtractArchive(ctx context.Context, in ExtractArchiveInput) (*ExtractArchiveOutput, error) {
+	return (&artifactService{r: s.r}).ExtractArchive(ctx, in)
+}
+
 func (s *testArtifactService) CreateFromBytes(ctx context.Context, in CreateArtifactFromBytesInput) (*model.Artifact, error) {
 	// Check if artifact with same path and filename already exists in the same disk
 	exists, err := s.r.ExistsByPathAndFilename(ctx, in.DiskID, in.Path, in.Filename, nil)
@@ -541,7 +700,7 @@ func TestArtifactService_UpdateArtifactMetaByPath(t *testing.T) {
 
 			service := newTestArtifactService(mockRepo, &MockArtifactS3Deps{})
 
-			artifact, err := service.UpdateArtifactMetaByPath(context.Background(), diskID, path, filename, tt.userMeta)
+			artifact, err := service.UpdateArtifactMetaByPath(context.Background(), diskID, path, filename, tt.userMeta, Precondition{})
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -565,6 +724,170 @@ func TestArtifactService_UpdateArtifactMetaByPath(t *testing.T) {
 	}
 }
 
+func TestArtifactService_Precondition(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	current := func() *model.Artifact {
+		return &model.Artifact{
+			ID: uuid.New(), DiskID: diskID, Path: "/docs/", Filename: "a.md", Version: 3,
+			Meta:      map[string]interface{}{model.ArtifactInfoKey: map[string]interface{}{}},
+			AssetMeta: datatypes.NewJSONType(model.Asset{SHA256: "abc", MIME: "text/markdown"}),
+		}
+	}
+	etag := `"abc-3"`
+
+	t.Run("etag", func(t *testing.T) {
+		assert.Equal(t, etag, current().ETag())
+	})
+
+	t.Run("check", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			cond    Precondition
+			current *model.Artifact
+			wantErr bool
+		}{
+			{name: "no condition", current: current()},
+			{name: "if-match", cond: Precondition{IfMatch: []string{`"old-1"`, etag}}, current: current()},
+			{name: "if-match stale", cond: Precondition{IfMatch: []string{`"abc-2"`}}, current: current(), wantErr: true},
+			{name: "if-match any", cond: Precondition{IfMatch: []string{"*"}}, current: current()},
+			{name: "if-match missing", cond: Precondition{IfMatch: []string{"*"}}, wantErr: true},
+			{name: "create only", cond: Precondition{IfNoneMatch: []string{"*"}}},
+			{name: "create only existing", cond: Precondition{IfNoneMatch: []string{"*"}}, current: current(), wantErr: true},
+			{name: "if-none-match other", cond: Precondition{IfNoneMatch: []string{`"abc-2"`}}, current: current()},
+			{name: "if-none-match current", cond: Precondition{IfNoneMatch: []string{etag}}, current: current(), wantErr: true},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := tt.cond.check(tt.current)
+				if tt.wantErr {
+					assert.ErrorIs(t, err, ErrPreconditionFailed)
+				} else {
+					assert.NoError(t, err)
+				}
+			})
+		}
+	})
+
+	t.Run("create only", func(t *testing.T) {
+		mockRepo := new(MockArtifactRepo)
+		mockRepo.On("GetByPath", mock.Anything, diskID, "/docs/", "a.md").Return(current(), nil)
+		svc := &artifactService{r: mockRepo}
+
+		// Fails before anything is uploaded
+		_, err := svc.Create(context.Background(), CreateArtifactInput{
+			ProjectID: projectID, DiskID: diskID, Path: "/docs/", Filename: "a.md",
+			FileHeader:   &multipart.FileHeader{Filename: "a.md", Size: 1},
+			Precondition: Precondition{IfNoneMatch: []string{"*"}},
+		})
+		assert.ErrorIs(t, err, ErrPreconditionFailed)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		mockRepo := new(MockArtifactRepo)
+		mockRepo.On("GetByPath", mock.Anything, diskID, "/docs/", "a.md").Return(current(), nil)
+		mockRepo.On("DeleteByPath", mock.Anything, projectID, diskID, "/docs/", "a.md", 3).Return(nil).Once()
+		svc := &artifactService{r: mockRepo}
+
+		require.NoError(t, svc.DeleteByPath(context.Background(), projectID, diskID, "/docs/", "a.md", Precondition{IfMatch: []string{etag}}))
+		err := svc.DeleteByPath(context.Background(), projectID, diskID, "/docs/", "a.md", Precondition{IfMatch: []string{`"abc-2"`}})
+		assert.ErrorIs(t, err, ErrPreconditionFailed)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("update meta", func(t *testing.T) {
+		artifact := current()
+		mockRepo := new(MockArtifactRepo)
+		mockRepo.On("GetByPath", mock.Anything, diskID, "/docs/", "a.md").Return(artifact, nil)
+		// Another write replaced the artifact after it was read
+		mockRepo.On("Replace", mock.Anything, artifact, mock.Anything, mock.Anything, 3).Return(ErrPreconditionFailed)
+		svc := &artifactService{r: mockRepo}
+
+		_, err := svc.UpdateArtifactMetaByPath(context.Background(), diskID, "/docs/", "a.md", map[string]interface{}{"k": "v"}, Precondition{IfMatch: []string{etag}})
+		assert.ErrorIs(t, err, ErrPreconditionFailed)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("edit", func(t *testing.T) {
+		mockRepo := new(MockArtifactRepo)
+		mockRepo.On("GetByPath", mock.Anything, diskID, "/docs/", "a.md").Return(current(), nil)
+		svc := &artifactService{r: mockRepo}
+
+		_, err := svc.EditArtifact(context.Background(), EditArtifactInput{
+			ProjectID: projectID, DiskID: diskID, Path: "/docs/", Filename: "a.md",
+			Command: EditStrReplace, OldStr: "a", NewStr: "b",
+			Precondition: Precondition{IfMatch: []string{`"abc-2"`}},
+		})
+		assert.ErrorIs(t, err, ErrPreconditionFailed)
+	})
+}
+
+func TestArtifactService_ListArtifacts(t *testing.T) {
+	diskID := uuid.New()
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	artifacts := []*model.Artifact{
+		{ID: uuid.New(), Path: "/docs/", Filename: "a.md", CreatedAt: createdAt},
+		{ID: uuid.New(), Path: "/docs/", Filename: "b.md", CreatedAt: createdAt.Add(time.Second)},
+		{ID: uuid.New(), Path: "/docs/", Filename: "c.md", CreatedAt: createdAt.Add(2 * time.Second)},
+	}
+	filter := ArtifactFilter{Meta: map[string]interface{}{"status": "reviewed"}, MIME: "text/*"}
+	repoFilter := repo.ArtifactFilter{Meta: filter.Meta, MIME: "text/*"}
+
+	mockRepo := new(MockArtifactRepo)
+	mockRepo.On("ListByPathWithCursor", mock.Anything, diskID, "/docs/", repoFilter, time.Time{}, uuid.Nil, 3).Return(artifacts, nil)
+	mockRepo.On("ListByPathWithCursor", mock.Anything, diskID, "/docs/", repoFilter, artifacts[1].CreatedAt, artifacts[1].ID, 3).Return(artifacts[2:], nil)
+	svc := &artifactService{r: mockRepo}
+
+	page, err := svc.ListArtifacts(context.Background(), ListArtifactsInput{DiskID: diskID, Path: "/docs/", Filter: filter, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, artifacts[:2], page.Items)
+	assert.True(t, page.HasMore)
+	assert.Equal(t, paging.EncodeCursor(artifacts[1].CreatedAt, artifacts[1].ID), page.NextCursor)
+
+	page, err = svc.ListArtifacts(context.Background(), ListArtifactsInput{DiskID: diskID, Path: "/docs/", Filter: filter, Limit: 2, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, artifacts[2:], page.Items)
+	assert.False(t, page.HasMore)
+	assert.Empty(t, page.NextCursor)
+	mockRepo.AssertExpectations(t)
+
+	_, err = svc.ListArtifacts(context.Background(), ListArtifactsInput{DiskID: diskID, Path: "/docs/", Limit: 2, Cursor: "not a cursor"})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
+
+func TestArtifactFilter_ToRepo(t *testing.T) {
+	size := func(n int64) *int64 { return &n }
+
+	tests := []struct {
+		name    string
+		filter  ArtifactFilter
+		wantErr bool
+	}{
+		{name: "empty", filter: ArtifactFilter{}},
+		{name: "exact mime", filter: ArtifactFilter{MIME: "application/pdf"}},
+		{name: "mime family", filter: ArtifactFilter{MIME: "image/*"}},
+		{name: "size range", filter: ArtifactFilter{MinSizeB: size(10), MaxSizeB: size(10)}},
+		{name: "empty meta key", filter: ArtifactFilter{MetaKeys: []string{"source", ""}}, wantErr: true},
+		{name: "mime without subtype", filter: ArtifactFilter{MIME: "text"}, wantErr: true},
+		{name: "mime with empty subtype", filter: ArtifactFilter{MIME: "text/"}, wantErr: true},
+		{name: "negative size", filter: ArtifactFilter{MinSizeB: size(-1)}, wantErr: true},
+		{name: "inverted size range", filter: ArtifactFilter{MinSizeB: size(20), MaxSizeB: size(10)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := tt.filter.toRepo()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidFilter)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.filter.MIME, f.MIME)
+			assert.Equal(t, tt.filter.MinSizeB, f.MinSizeB)
+		})
+	}
+}
+
 func TestArtifactService_GrepArtifacts(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -578,8 +901,8 @@ func TestArtifactService_GrepArtifacts(t *testing.T) {
 			name:    "successful search with default limit",
 			pattern: "TODO",
 			limit:   0, // Should default to 100
-			setupMock: func(repo *MockArtifactRepo) {
-				repo.On("GrepArtifacts", mock.Anything, mock.Anything, "TODO", 100).
+			setupMock: func(m *MockArtifactRepo) {
+				m.On("GrepArtifactsWithFilter", mock.Anything, mock.Anything, repo.GrepFilter{Pattern: "TODO"}, 100).
 					Return([]*model.Artifact{
 						{Filename: "test.py", Path: "/"},
 					}, nil)
@@ -591,8 +914,8 @@ func TestArtifactService_GrepArtifacts(t *testing.T) {
 			name:    "limit capped at 1000",
 			pattern: "function",
 			limit:   5000, // Should be capped to 1000
-			setupMock: func(repo *MockArtifactRepo) {
-				repo.On("GrepArtifacts", mock.Anything, mock.Anything, "function", 1000).
+			setupMock: func(m *MockArtifactRepo) {
+				m.On("GrepArtifactsWithFilter", mock.Anything, mock.Anything, repo.GrepFilter{Pattern: "function"}, 1000).
 					Return([]*model.Artifact{}, nil)
 			},
 			wantCount: 0,
@@ -602,8 +925,8 @@ func TestArtifactService_GrepArtifacts(t *testing.T) {
 			name:    "custom limit",
 			pattern: "import",
 			limit:   50,
-			setupMock: func(repo *MockArtifactRepo) {
-				repo.On("GrepArtifacts", mock.Anything, mock.Anything, "import", 50).
+			setupMock: func(m *MockArtifactRepo) {
+				m.On("GrepArtifactsWithFilter", mock.Anything, mock.Anything, repo.GrepFilter{Pattern: "import"}, 50).
 					Return([]*model.Artifact{
 						{Filename: "main.py", Path: "/"},
 						{Filename: "utils.py", Path: "/"},
@@ -626,6 +949,7 @@ func TestArtifactService_GrepArtifacts(t *testing.T) {
 				uuid.New(),
 				uuid.New(),
 				tt.pattern,
+				ArtifactFilter{},
 				tt.limit,
 			)
 
@@ -640,6 +964,118 @@ func TestArtifactService_GrepArtifacts(t *testing.T) {
 	}
 }
 
+func TestArtifactService_GrepArtifactMatches(t *testing.T) {
+	diskID := uuid.New()
+	textArtifact := func(path, filename, content string) *model.Artifact {
+		return &model.Artifact{
+			ID:        uuid.New(),
+			DiskID:    diskID,
+			Path:      path,
+			Filename:  filename,
+			AssetMeta: datatypes.NewJSONType(model.Asset{MIME: "text/plain", Content: content}),
+		}
+	}
+	artifacts := []*model.Artifact{
+		textArtifact("/notes/", "a.md", "intro\nTODO: first\nbody\ntodo: second\nend\n"),
+		textArtifact("/notes/", "b.md", "nothing\nTODO: third\n"),
+		// Matched by the database across lines but by no single line
+		textArtifact("/notes/", "c.md", "TO\nDO\n"),
+	}
+
+	tests := []struct {
+		name   string
+		in     GrepArtifactMatchesInput
+		filter repo.GrepFilter
+		glob   string
+		limit  int
+		check  func(*testing.T, *GrepArtifactMatchesOutput)
+	}{
+		{
+			name:   "matching lines",
+			in:     GrepArtifactMatchesInput{DiskID: diskID, Pattern: "TODO"},
+			filter: repo.GrepFilter{Pattern: "TODO"},
+			limit:  100,
+			check: func(t *testing.T, out *GrepArtifactMatchesOutput) {
+				require.Len(t, out.Files, 2)
+				assert.Equal(t, 1, out.Files[0].MatchCount)
+				assert.Equal(t, 2, out.TotalMatches)
+				assert.Equal(t, "/notes/a.md:2:TODO: first\n/notes/b.md:2:TODO: third\n", out.Output)
+			},
+		},
+		{
+			name:   "case insensitive with context and path glob",
+			in:     GrepArtifactMatchesInput{DiskID: diskID, Pattern: "todo", IgnoreCase: true, PathGlob: "/notes/*.md", Before: 1, MaxCount: 1, Limit: 5000},
+			filter: repo.GrepFilter{Pattern: "todo", IgnoreCase: true},
+			glob:   "/notes/*.md",
+			limit:  1000,
+			check: func(t *testing.T, out *GrepArtifactMatchesOutput) {
+				require.Len(t, out.Files, 2)
+				// max_count limits the lines but every match is counted
+				assert.Equal(t, 2, out.Files[0].MatchCount)
+				assert.Len(t, out.Files[0].Lines, 2)
+				assert.Equal(t, 3, out.TotalMatches)
+				assert.Equal(t, "/notes/a.md-1-intro\n/notes/a.md:2:TODO: first\n--\n/notes/b.md-1-nothing\n/notes/b.md:2:TODO: third\n", out.Output)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockArtifactRepo)
+			matchFilter := mock.MatchedBy(func(f repo.GrepFilter) bool {
+				glob := ""
+				if f.Glob != nil {
+					glob = f.Glob.String()
+				}
+				return f.Pattern == tt.filter.Pattern && f.IgnoreCase == tt.filter.IgnoreCase && glob == tt.glob
+			})
+			mockRepo.On("GrepArtifactsWithFilter", mock.Anything, diskID, matchFilter, tt.limit).Return(artifacts, nil)
+
+			svc := &artifactService{r: mockRepo}
+			out, err := svc.GrepArtifactMatches(context.Background(), tt.in)
+
+			require.NoError(t, err)
+			tt.check(t, out)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		svc := &artifactService{r: new(MockArtifactRepo)}
+		_, err := svc.GrepArtifactMatches(context.Background(), GrepArtifactMatchesInput{DiskID: diskID, Pattern: "(unclosed"})
+		assert.ErrorContains(t, err, "invalid pattern")
+	})
+}
+
+func TestArtifactService_GrepArtifactMatches_Document(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	doc := `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Project plan</w:t></w:r></w:p>
+<w:p><w:r><w:t>Ship the quarterly report</w:t></w:r></w:p>
+</w:body></w:document>`
+	docx := zipArchive(t, map[string]string{"word/document.xml": doc, "[Content_Types].xml": "<Types/>"})
+
+	mockRepo := &MockArtifactRepo{}
+	mockRepo.On("GetByPath", mock.Anything, diskID, "/docs/", "plan.docx").Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("Create", mock.Anything, projectID, mock.Anything).Return(nil)
+	svc := &artifactService{r: mockRepo, s3: uploadingBlobStore{}}
+
+	artifact, err := svc.Create(context.Background(), CreateArtifactInput{
+		ProjectID: projectID, DiskID: diskID, Path: "/docs/", Filename: "plan.docx",
+		FileHeader: formFile(t, "plan.docx", string(docx)),
+		TextLimit:  1 << 20,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, artifact.AssetMeta.Data().Content)
+
+	mockRepo.On("GrepArtifactsWithFilter", mock.Anything, diskID, mock.Anything, 100).Return([]*model.Artifact{artifact}, nil)
+	out, err := svc.GrepArtifactMatches(context.Background(), GrepArtifactMatchesInput{DiskID: diskID, Pattern: "quarterly"})
+	require.NoError(t, err)
+	require.Len(t, out.Files, 1)
+	assert.Equal(t, "/docs/plan.docx:2:Ship the quarterly report\n", out.Output)
+}
+
 func TestArtifactService_GlobArtifacts(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -653,8 +1089,8 @@ func TestArtifactService_GlobArtifacts(t *testing.T) {
 			name:    "successful glob with wildcard",
 			pattern: "*.py",
 			limit:   100,
-			setupMock: func(repo *MockArtifactRepo) {
-				repo.On("GlobArtifacts", mock.Anything, mock.Anything, "*.py", 100).
+			setupMock: func(m *MockArtifactRepo) {
+				m.On("GlobArtifacts", mock.Anything, mock.Anything, "/*.py", repo.ArtifactFilter{}, 100).
 					Return([]*model.Artifact{
 						{Filename: "test.py", Path: "/"},
 						{Filename: "main.py", Path: "/src/"},
@@ -667,8 +1103,8 @@ func TestArtifactService_GlobArtifacts(t *testing.T) {
 			name:    "no results",
 			pattern: "*.xyz",
 			limit:   100,
-			setupMock: func(repo *MockArtifactRepo) {
-				repo.On("GlobArtifacts", mock.Anything, mock.Anything, "*.xyz", 100).
+			setupMock: func(m *MockArtifactRepo) {
+				m.On("GlobArtifacts", mock.Anything, mock.Anything, "/*.xyz", repo.ArtifactFilter{}, 100).
 					Return([]*model.Artifact{}, nil)
 			},
 			wantCount: 0,
@@ -678,13 +1114,20 @@ func TestArtifactService_GlobArtifacts(t *testing.T) {
 			name:    "limit enforcement",
 			pattern: "**/*.txt",
 			limit:   0, // Should default to 100
-			setupMock: func(repo *MockArtifactRepo) {
-				repo.On("GlobArtifacts", mock.Anything, mock.Anything, "**/*.txt", 100).
+			setupMock: func(m *MockArtifactRepo) {
+				m.On("GlobArtifacts", mock.Anything, mock.Anything, "/**/*.txt", repo.ArtifactFilter{}, 100).
 					Return([]*model.Artifact{{Filename: "readme.txt", Path: "/"}}, nil)
 			},
 			wantCount: 1,
 			wantErr:   false,
 		},
+		{
+			name:      "invalid pattern",
+			pattern:   "/{a,b",
+			limit:     100,
+			setupMock: func(m *MockArtifactRepo) {},
+			wantErr:   true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -699,6 +1142,7 @@ func TestArtifactService_GlobArtifacts(t *testing.T) {
 				uuid.New(),
 				uuid.New(),
 				tt.pattern,
+				ArtifactFilter{},
 				tt.limit,
 			)
 
@@ -712,3 +1156,823 @@ func TestArtifactService_GlobArtifacts(t *testing.T) {
 		})
 	}
 }
+
+func TestArtifactService_GlobEntries(t *testing.T) {
+	diskID := uuid.New()
+	artifacts := []*model.Artifact{{Filename: "main.go", Path: "/src/"}}
+
+	mockRepo := new(MockArtifactRepo)
+	mockRepo.On("GlobArtifacts", mock.Anything, diskID, "/src/*", repo.ArtifactFilter{}, 100).Return(artifacts, nil)
+	mockRepo.On("GetPathsUnder", mock.Anything, diskID, "/src/").
+		Return([]string{"/src/", "/src/pkg/util/", "/src/cmd/", "/src/pkg/"}, nil)
+
+	svc := &artifactService{r: mockRepo}
+	entries, err := svc.GlobEntries(context.Background(), uuid.New(), diskID, "src/*", ArtifactFilter{}, 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, artifacts, entries.Artifacts)
+	// Only direct subdirectories match, including those that only hold other directories
+	assert.Equal(t, []string{"/src/cmd/", "/src/pkg/"}, entries.Directories)
+	mockRepo.AssertExpectations(t)
+}
+
+func createTestArtifactVersion(artifact *model.Artifact, version int, content string) *model.ArtifactVersion {
+	return &model.ArtifactVersion{
+		ID:         uuid.New(),
+		ArtifactID: artifact.ID,
+		DiskID:     artifact.DiskID,
+		Version:    version,
+		Meta:       map[string]interface{}{"note": content},
+		AssetMeta: datatypes.NewJSONType(model.Asset{
+			S3Key:   "disks/old/" + content,
+			SHA256:  "sha-" + content,
+			MIME:    "text/plain",
+			Content: content,
+		}),
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestArtifactService_ListVersionsByPath(t *testing.T) {
+	artifact := createTestArtifact()
+	artifact.Version = 3
+
+	mockRepo := &MockArtifactRepo{}
+	versions := []*model.ArtifactVersion{
+		createTestArtifactVersion(artifact, 2, "b"),
+		createTestArtifactVersion(artifact, 1, "a"),
+	}
+	mockRepo.On("GetByPath", mock.Anything, artifact.DiskID, artifact.Path, artifact.Filename).Return(artifact, nil)
+	mockRepo.On("ListVersions", mock.Anything, artifact.ID).Return(versions, nil)
+
+	service := newTestArtifactService(mockRepo, &MockArtifactS3Deps{})
+	current, got, err := service.ListVersionsByPath(context.Background(), artifact.DiskID, artifact.Path, artifact.Filename)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, current.Version)
+	assert.Equal(t, versions, got)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestArtifactService_GetVersionByPath(t *testing.T) {
+	artifact := createTestArtifact()
+	artifact.Version = 3
+	old := createTestArtifactVersion(artifact, 1, "first draft")
+
+	tests := []struct {
+		name        string
+		version     int
+		setup       func(*MockArtifactRepo)
+		expectError bool
+		check       func(*testing.T, *model.Artifact)
+	}{
+		{
+			name:    "current version",
+			version: 3,
+			setup:   func(repo *MockArtifactRepo) {},
+			check: func(t *testing.T, a *model.Artifact) {
+				assert.Same(t, artifact, a)
+			},
+		},
+		{
+			name:    "previous version",
+			version: 1,
+			setup: func(repo *MockArtifactRepo) {
+				repo.On("GetVersion", mock.Anything, artifact.ID, 1).Return(old, nil)
+			},
+			check: func(t *testing.T, a *model.Artifact) {
+				assert.Equal(t, 1, a.Version)
+				assert.Equal(t, artifact.ID, a.ID)
+				assert.Equal(t, artifact.Filename, a.Filename)
+				assert.Equal(t, "first draft", a.AssetMeta.Data().Content)
+				assert.Equal(t, "first draft", a.Meta["note"])
+				// The current artifact is left untouched
+				assert.Equal(t, 3, artifact.Version)
+			},
+		},
+		{
+			name:    "unknown version",
+			version: 7,
+			setup: func(repo *MockArtifactRepo) {
+				repo.On("GetVersion", mock.Anything, artifact.ID, 7).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockArtifactRepo{}
+			mockRepo.On("GetByPath", mock.Anything, artifact.DiskID, artifact.Path, artifact.Filename).Return(artifact, nil)
+			tt.setup(mockRepo)
+
+			service := newTestArtifactService(mockRepo, &MockArtifactS3Deps{})
+			got, err := service.GetVersionByPath(context.Background(), artifact.DiskID, artifact.Path, artifact.Filename, tt.version)
+
+			if tt.expectError {
+				assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+			} else {
+				assert.NoError(t, err)
+				tt.check(t, got)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactService_DiffVersionsByPath(t *testing.T) {
+	artifact := createTestArtifact()
+	artifact.Version = 3
+	artifact.AssetMeta = datatypes.NewJSONType(model.Asset{SHA256: "sha-c", MIME: "text/plain", Content: "line 1\nline 2 changed\nline 3\n"})
+	v1 := createTestArtifactVersion(artifact, 1, "line 1\nline 2\nline 3\n")
+	v2 := createTestArtifactVersion(artifact, 2, "line 1\nline 2\nline 3\n")
+
+	tests := []struct {
+		name     string
+		from, to int
+		setup    func(*MockArtifactRepo)
+		check    func(*testing.T, *ArtifactDiff)
+	}{
+		{
+			name: "previous to current",
+			from: 1,
+			to:   0,
+			setup: func(repo *MockArtifactRepo) {
+				repo.On("GetVersion", mock.Anything, artifact.ID, 1).Return(v1, nil)
+			},
+			check: func(t *testing.T, d *ArtifactDiff) {
+				assert.Equal(t, 1, d.FromVersion)
+				assert.Equal(t, 3, d.ToVersion)
+				assert.Contains(t, d.Diff, "--- /test/pathtest.txt@v1")
+				assert.Contains(t, d.Diff, "-line 2\n")
+				assert.Contains(t, d.Diff, "+line 2 changed\n")
+			},
+		},
+		{
+			name: "identical versions",
+			from: 1,
+			to:   2,
+			setup: func(repo *MockArtifactRepo) {
+				repo.On("GetVersion", mock.Anything, artifact.ID, 1).Return(v1, nil)
+				repo.On("GetVersion", mock.Anything, artifact.ID, 2).Return(v2, nil)
+			},
+			check: func(t *testing.T, d *ArtifactDiff) {
+				assert.Empty(t, d.Diff)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockArtifactRepo{}
+			mockRepo.On("GetByPath", mock.Anything, artifact.DiskID, artifact.Path, artifact.Filename).Return(artifact, nil)
+			tt.setup(mockRepo)
+
+			service := newTestArtifactService(mockRepo, &MockArtifactS3Deps{})
+			diff, err := service.DiffVersionsByPath(context.Background(), artifact.DiskID, artifact.Path, artifact.Filename, tt.from, tt.to)
+
+			assert.NoError(t, err)
+			tt.check(t, diff)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactService_RestoreVersionByPath(t *testing.T) {
+	artifact := createTestArtifact()
+	artifact.Version = 3
+	old := createTestArtifactVersion(artifact, 2, "restored")
+
+	tests := []struct {
+		name        string
+		version     int
+		setup       func(*MockArtifactRepo)
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:    "successful restore",
+			version: 2,
+			setup: func(repo *MockArtifactRepo) {
+				repo.On("GetVersion", mock.Anything, artifact.ID, 2).Return(old, nil)
+				repo.On("Replace", mock.Anything, artifact, old.AssetMeta.Data(), map[string]interface{}(old.Meta), 0).Return(nil)
+			},
+		},
+		{
+			name:        "current version",
+			version:     3,
+			setup:       func(repo *MockArtifactRepo) {},
+			expectError: true,
+			errorMsg:    "already the current version",
+		},
+		{
+			name:    "unknown version",
+			version: 1,
+			setup: func(repo *MockArtifactRepo) {
+				repo.On("GetVersion", mock.Anything, artifact.ID, 1).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectError: true,
+			errorMsg:    "record not found",
+		},
+		{
+			name:    "replace error",
+			version: 2,
+			setup: func(repo *MockArtifactRepo) {
+				repo.On("GetVersion", mock.Anything, artifact.ID, 2).Return(old, nil)
+				repo.On("Replace", mock.Anything, artifact, mock.Anything, mock.Anything, 0).Return(errors.New("db error"))
+			},
+			expectError: true,
+			errorMsg:    "restore artifact version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockArtifactRepo{}
+			mockRepo.On("GetByPath", mock.Anything, artifact.DiskID, artifact.Path, artifact.Filename).Return(artifact, nil)
+			tt.setup(mockRepo)
+
+			service := newTestArtifactService(mockRepo, &MockArtifactS3Deps{})
+			got, err := service.RestoreVersionByPath(context.Background(), artifact.DiskID, artifact.Path, artifact.Filename, tt.version)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+			} else {
+				assert.NoError(t, err)
+				assert.Same(t, artifact, got)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func createTestArtifactAt(diskID uuid.UUID, path string, filename string) *model.Artifact {
+	a := createTestArtifact()
+	a.DiskID = diskID
+	a.Path = path
+	a.Filename = filename
+	a.Meta = map[string]interface{}{
+		model.ArtifactInfoKey: map[string]interface{}{"path": path, "filename": filename, "mime": "text/plain"},
+		"owner":               "alice",
+	}
+	return a
+}
+
+func TestArtifactService_MoveArtifacts_File(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	artifact := createTestArtifactAt(diskID, "/docs/", "a.md")
+
+	mockRepo := &MockArtifactRepo{}
+	mockRepo.On("GetByPath", mock.Anything, diskID, "/docs/", "a.md").Return(artifact, nil)
+	mockRepo.On("Move", mock.Anything, projectID, mock.MatchedBy(func(ts []repo.ArtifactTransfer) bool {
+		if len(ts) != 1 || ts[0].Artifact != artifact || ts[0].Path != "/archive/" || ts[0].Filename != "b.md" {
+			return false
+		}
+		info := ts[0].Meta[model.ArtifactInfoKey].(map[string]interface{})
+		// User meta and the rest of the system meta are kept
+		return info["path"] == "/archive/" && info["filename"] == "b.md" && info["mime"] == "text/plain" && ts[0].Meta["owner"] == "alice"
+	}), repo.OnConflictError).Return(nil, nil)
+
+	svc := &artifactService{r: mockRepo}
+	out, err := svc.MoveArtifacts(context.Background(), TransferArtifactsInput{
+		ProjectID:   projectID,
+		DiskID:      diskID,
+		Source:      "/docs/a.md",
+		Destination: "/archive/b.md",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []*model.Artifact{artifact}, out.Artifacts)
+	assert.Empty(t, out.Skipped)
+	// The source meta is left alone until the repo has moved the artifact
+	assert.Equal(t, "/docs/", artifact.Meta[model.ArtifactInfoKey].(map[string]interface{})["path"])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestArtifactService_MoveArtifacts_Directory(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	a := createTestArtifactAt(diskID, "/src/", "main.go")
+	b := createTestArtifactAt(diskID, "/src/pkg/", "util.go")
+
+	mockRepo := &MockArtifactRepo{}
+	mockRepo.On("ListUnderPath", mock.Anything, diskID, "/src/").Return([]*model.Artifact{a, b}, nil)
+	mockRepo.On("Move", mock.Anything, projectID, mock.MatchedBy(func(ts []repo.ArtifactTransfer) bool {
+		return len(ts) == 2 &&
+			ts[0].Path == "/lib/" && ts[0].Filename == "main.go" &&
+			ts[1].Path == "/lib/pkg/" && ts[1].Filename == "util.go"
+	}), repo.OnConflictSkip).Return([]repo.ArtifactTransfer{{Artifact: b, Path: "/lib/pkg/", Filename: "util.go"}}, nil)
+
+	svc := &artifactService{r: mockRepo}
+	out, err := svc.MoveArtifacts(context.Background(), TransferArtifactsInput{
+		ProjectID:   projectID,
+		DiskID:      diskID,
+		Source:      "/src/",
+		Destination: "/lib/",
+		OnConflict:  "skip",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []*model.Artifact{a}, out.Artifacts)
+	assert.Equal(t, []string{"/src/pkg/util.go"}, out.Skipped)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestArtifactService_CopyArtifacts(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	artifact := createTestArtifactAt(diskID, "/docs/", "a.md")
+	copied := createTestArtifactAt(diskID, "/backup/", "a.md")
+
+	mockRepo := &MockArtifactRepo{}
+	mockRepo.On("GetByPath", mock.Anything, diskID, "/docs/", "a.md").Return(artifact, nil)
+	mockRepo.On("Copy", mock.Anything, projectID, mock.MatchedBy(func(ts []repo.ArtifactTransfer) bool {
+		// A destination directory keeps the filename
+		return len(ts) == 1 && ts[0].Path == "/backup/" && ts[0].Filename == "a.md"
+	}), repo.OnConflictOverwrite).Return([]*model.Artifact{copied}, nil, nil)
+
+	svc := &artifactService{r: mockRepo}
+	out, err := svc.CopyArtifacts(context.Background(), TransferArtifactsInput{
+		ProjectID:   projectID,
+		DiskID:      diskID,
+		Source:      "/docs/a.md",
+		Destination: "/backup/",
+		OnConflict:  "overwrite",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []*model.Artifact{copied}, out.Artifacts)
+	assert.Empty(t, out.Skipped)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestArtifactService_TransferArtifacts_Errors(t *testing.T) {
+	diskID := uuid.New()
+
+	tests := []struct {
+		name        string
+		source      string
+		destination string
+		onConflict  string
+		setup       func(*MockArtifactRepo)
+		expectedErr error
+	}{
+		{name: "same file", source: "/a.md", destination: "/a.md", expectedErr: ErrInvalidTransfer},
+		{name: "same file through its directory", source: "/docs/a.md", destination: "/docs/", expectedErr: ErrInvalidTransfer},
+		{name: "directory to file", source: "/docs/", destination: "/archive", expectedErr: ErrInvalidTransfer},
+		{name: "directory into itself", source: "/docs/", destination: "/docs/old/", expectedErr: ErrInvalidTransfer},
+		{name: "directory onto its parent", source: "/docs/old/", destination: "/docs/", expectedErr: ErrInvalidTransfer},
+		{name: "path traversal", source: "/a.md", destination: "/../b.md", expectedErr: ErrInvalidTransfer},
+		{name: "unknown conflict mode", source: "/a.md", destination: "/b.md", onConflict: "merge", expectedErr: ErrInvalidTransfer},
+		{
+			name:        "missing file",
+			source:      "/a.md",
+			destination: "/b.md",
+			setup: func(r *MockArtifactRepo) {
+				r.On("GetByPath", mock.Anything, diskID, "/", "a.md").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		},
+		{
+			name:        "empty directory",
+			source:      "/docs/",
+			destination: "/archive/",
+			setup: func(r *MockArtifactRepo) {
+				r.On("ListUnderPath", mock.Anything, diskID, "/docs/").Return([]*model.Artifact{}, nil)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockArtifactRepo{}
+			if tt.setup != nil {
+				tt.setup(mockRepo)
+			}
+
+			svc := &artifactService{r: mockRepo}
+			_, err := svc.MoveArtifacts(context.Background(), TransferArtifactsInput{
+				ProjectID:   uuid.New(),
+				DiskID:      diskID,
+				Source:      tt.source,
+				Destination: tt.destination,
+				OnConflict:  tt.onConflict,
+			})
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// fakeBlobStore serves downloads from a map of keys to contents; other calls panic
+type fakeBlobStore struct {
+	blob.BlobStore
+	files map[string]string
+}
+
+func (f fakeBlobStore) DownloadFile(ctx context.Context, key string) ([]byte, error) {
+	return []byte(f.files[key]), nil
+}
+
+func TestArtifactService_ArchiveArtifacts(t *testing.T) {
+	diskID := uuid.New()
+	mockRepo := &MockArtifactRepo{}
+	mockRepo.On("ListUnderPath", mock.Anything, diskID, "/out/").Return([]*model.Artifact{
+		{Path: "/out/", Filename: "README.md", AssetMeta: datatypes.NewJSONType(model.Asset{S3Key: "k1"})},
+		{Path: "/out/src/", Filename: "main.go", AssetMeta: datatypes.NewJSONType(model.Asset{S3Key: "k2"})},
+	}, nil)
+	mockRepo.On("ListUnderPath", mock.Anything, diskID, "/empty/").Return([]*model.Artifact{}, nil)
+
+	svc := &artifactService{r: mockRepo, s3: fakeBlobStore{files: map[string]string{"k1": "# out\n", "k2": "package main\n"}}}
+
+	a, err := svc.ArchiveArtifacts(context.Background(), ArchiveArtifactsInput{DiskID: diskID, Path: "out", Format: "tar.gz"})
+	require.NoError(t, err)
+	assert.Equal(t, "out.tar.gz", a.Filename)
+	assert.Equal(t, "application/gzip", a.ContentType)
+
+	var buf bytes.Buffer
+	require.NoError(t, a.WriteTo(context.Background(), &buf))
+
+	files, err := archive.Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()), archive.FormatTarGz, archive.Limits{MaxBytes: 1 << 20, MaxFiles: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []archive.File{
+		{Name: "README.md", Content: []byte("# out\n")},
+		{Name: "src/main.go", Content: []byte("package main\n")},
+	}, files)
+
+	_, err = svc.ArchiveArtifacts(context.Background(), ArchiveArtifactsInput{DiskID: diskID, Path: "/empty/"})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = svc.ArchiveArtifacts(context.Background(), ArchiveArtifactsInput{DiskID: diskID, Path: "/out/", Format: "rar"})
+	assert.ErrorIs(t, err, ErrInvalidArchive)
+	_, err = svc.ArchiveArtifacts(context.Background(), ArchiveArtifactsInput{DiskID: diskID, Path: "/../"})
+	assert.ErrorIs(t, err, ErrInvalidArchive)
+	mockRepo.AssertExpectations(t)
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestArtifactService_ExtractArchive_Rejected(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	limits := archive.Limits{MaxBytes: 1 << 10, MaxFiles: 10}
+
+	storageRepo := &MockStorageRepo{}
+	storageRepo.On("GetDiskUserID", mock.Anything, projectID, diskID).Return((*uuid.UUID)(nil), nil)
+	storageRepo.On("GetQuotas", mock.Anything, projectID, (*uuid.UUID)(nil), &diskID).Return([]*model.StorageQuota{
+		{Scope: model.QuotaScopeDisk, ScopeID: diskID, MaxFiles: int64Ptr(1)},
+	}, nil)
+	storageRepo.On("GetQuotaUsage", mock.Anything, projectID, (*uuid.UUID)(nil), &diskID).Return(map[string]*repo.StorageUsage{
+		model.QuotaScopeDisk: {},
+	}, nil)
+
+	// Nothing is uploaded or saved
+	mockRepo := &MockArtifactRepo{}
+	mockRepo.On("GetByPath", mock.Anything, diskID, mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+	svc := &artifactService{r: mockRepo, quota: NewStorageService(storageRepo)}
+
+	tests := []struct {
+		name        string
+		filename    string
+		content     []byte
+		path        string
+		expectedErr error
+	}{
+		{name: "unknown format", filename: "out.rar", content: []byte("x"), expectedErr: ErrInvalidArchive},
+		{name: "corrupt archive", filename: "out.zip", content: []byte("not a zip"), expectedErr: ErrInvalidArchive},
+		{name: "path traversal", filename: "out.zip", content: zipArchive(t, map[string]string{"../../etc/cron": "x"}), expectedErr: ErrInvalidArchive},
+		{name: "invalid target", filename: "out.zip", content: zipArchive(t, map[string]string{"a.txt": "x"}), path: "/a/../", expectedErr: ErrInvalidArchive},
+		{name: "zip bomb", filename: "out.zip", content: zipArchive(t, map[string]string{"bomb": strings.Repeat("0", 1<<20)}), expectedErr: ErrArchiveTooLarge},
+		{name: "over quota", filename: "out.zip", content: zipArchive(t, map[string]string{"a.txt": "a", "b.txt": "b"}), expectedErr: ErrQuotaExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.ExtractArchive(context.Background(), ExtractArchiveInput{
+				ProjectID: projectID,
+				DiskID:    diskID,
+				Path:      tt.path,
+				Archive:   createTestMultipartFileHeader(tt.filename, tt.content),
+				Limits:    limits,
+			})
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestArtifactService_ExtractArchive(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	existing := &model.Artifact{
+		ID: uuid.New(), DiskID: diskID, Path: "/docs/", Filename: "a.txt", Version: 1,
+		AssetMeta: datatypes.NewJSONType(model.Asset{SizeB: 8}),
+	}
+
+	// The disk is full: only files that replace larger ones fit
+	storageRepo := &MockStorageRepo{}
+	storageRepo.On("GetDiskUserID", mock.Anything, projectID, diskID).Return((*uuid.UUID)(nil), nil)
+	storageRepo.On("GetQuotas", mock.Anything, projectID, (*uuid.UUID)(nil), &diskID).Return([]*model.StorageQuota{
+		{Scope: model.QuotaScopeDisk, ScopeID: diskID, MaxBytes: int64Ptr(10), MaxFiles: int64Ptr(2)},
+	}, nil)
+	storageRepo.On("GetQuotaUsage", mock.Anything, projectID, (*uuid.UUID)(nil), &diskID).Return(map[string]*repo.StorageUsage{
+		model.QuotaScopeDisk: {Files: 2, RawBytes: 10},
+	}, nil)
+
+	mockRepo := &MockArtifactRepo{}
+	mockRepo.On("GetByPath", mock.Anything, diskID, "/docs/", "a.txt").Return(existing, nil)
+	mockRepo.On("GetByPath", mock.Anything, diskID, "/docs/", "b.txt").Return(nil, gorm.ErrRecordNotFound)
+	svc := &artifactService{r: mockRepo, s3: uploadingBlobStore{}, quota: NewStorageService(storageRepo)}
+	extract := func(files map[string]string) (*ExtractArchiveOutput, error) {
+		return svc.ExtractArchive(context.Background(), ExtractArchiveInput{
+			ProjectID: projectID,
+			DiskID:    diskID,
+			Path:      "/docs",
+			Archive:   createTestMultipartFileHeader("docs.zip", zipArchive(t, files)),
+			Limits:    archive.Limits{MaxBytes: 1 << 10, MaxFiles: 10},
+		})
+	}
+
+	mockRepo.On("SaveAll", mock.Anything, projectID, diskID, mock.MatchedBy(func(writes []repo.ArtifactWrite) bool {
+		return len(writes) == 1 && writes[0].Existing == existing && writes[0].Artifact.AssetMeta.Data().SizeB == 5
+	})).Return(nil).Once()
+	out, err := extract(map[string]string{"a.txt": "12345"})
+	require.NoError(t, err)
+	assert.Equal(t, []*model.Artifact{existing}, out.Artifacts)
+
+	_, err = extract(map[string]string{"a.txt": "12345", "b.txt": "1"})
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+	// Nothing is written when one of the files fails
+	mockRepo.On("SaveAll", mock.Anything, projectID, diskID, mock.Anything).Return(errors.New("unique violation")).Once()
+	_, err = extract(map[string]string{"a.txt": "1"})
+	assert.Error(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestArtifactService_EditArtifact_WithoutChange(t *testing.T) {
+	diskID := uuid.New()
+	text := "line 1\nline 2\nline 3\n"
+	artifact := &model.Artifact{
+		ID: uuid.New(), DiskID: diskID, Path: "/", Filename: "notes.txt",
+		AssetMeta: datatypes.NewJSONType(model.Asset{MIME: "text/plain", SizeB: int64(len(text)), Content: text}),
+	}
+	image := &model.Artifact{
+		ID: uuid.New(), DiskID: diskID, Path: "/", Filename: "logo.png",
+		AssetMeta: datatypes.NewJSONType(model.Asset{MIME: "image/png", SizeB: 100}),
+	}
+	report := &model.Artifact{
+		ID: uuid.New(), DiskID: diskID, Path: "/", Filename: "report.pdf",
+		AssetMeta: datatypes.NewJSONType(model.Asset{MIME: "application/pdf", SizeB: 100, Content: "--- Page 1 ---\nSummary\n"}),
+	}
+
+	mockRepo := &MockArtifactRepo{}
+	mockRepo.On("GetByPath", mock.Anything, diskID, "/", "notes.txt").Return(artifact, nil)
+	mockRepo.On("GetByPath", mock.Anything, diskID, "/", "logo.png").Return(image, nil)
+	mockRepo.On("GetByPath", mock.Anything, diskID, "/", "report.pdf").Return(report, nil)
+	mockRepo.On("GetByPath", mock.Anything, diskID, "/", "missing.txt").Return(nil, gorm.ErrRecordNotFound)
+
+	// Nothing is uploaded or saved, so no S3 is needed
+	svc := &artifactService{r: mockRepo}
+
+	out, err := svc.EditArtifact(context.Background(), EditArtifactInput{
+		DiskID: diskID, Path: "/", Filename: "notes.txt",
+		Command: EditStrReplace, OldStr: "line 2", NewStr: "line 2",
+	})
+	require.NoError(t, err)
+	assert.Same(t, artifact, out.Artifact)
+	assert.Equal(t, 2, out.Start)
+	assert.Equal(t, "1\tline 1\n2\tline 2\n3\tline 3\n", out.Snippet)
+
+	tests := []struct {
+		name        string
+		in          EditArtifactInput
+		expectedErr error
+	}{
+		{name: "no match", in: EditArtifactInput{Filename: "notes.txt", Command: EditStrReplace, OldStr: "line 4"}, expectedErr: ErrInvalidEdit},
+		{name: "ambiguous match", in: EditArtifactInput{Filename: "notes.txt", Command: EditStrReplace, OldStr: "line"}, expectedErr: ErrInvalidEdit},
+		{name: "line out of range", in: EditArtifactInput{Filename: "notes.txt", Command: EditDeleteLines, StartLine: 3, EndLine: 4}, expectedErr: ErrInvalidEdit},
+		{name: "bad patch", in: EditArtifactInput{Filename: "notes.txt", Command: EditApplyPatch, Patch: "@@ -1 +1 @@\n-line 9\n+x\n"}, expectedErr: ErrInvalidEdit},
+		{name: "unknown command", in: EditArtifactInput{Filename: "notes.txt", Command: "undo"}, expectedErr: ErrInvalidEdit},
+		{name: "binary file", in: EditArtifactInput{Filename: "logo.png", Command: EditInsertAtLine, Text: "x"}, expectedErr: ErrNotEditable},
+		{name: "document", in: EditArtifactInput{Filename: "report.pdf", Command: EditStrReplace, OldStr: "Summary", NewStr: "x"}, expectedErr: ErrNotEditable},
+		{name: "missing file", in: EditArtifactInput{Filename: "missing.txt", Command: EditInsertAtLine, Text: "x"}, expectedErr: gorm.ErrRecordNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.DiskID, tt.in.Path = diskID, "/"
+			_, err := svc.EditArtifact(context.Background(), tt.in)
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+// uploadingBlobStore stores uploaded bytes in memory; other calls panic
+type uploadingBlobStore struct {
+	blob.BlobStore
+}
+
+func (uploadingBlobStore) UploadBytes(ctx context.Context, keyPrefix string, filename string, content []byte, reuse blob.Reuse) (*model.Asset, error) {
+	return &model.Asset{S3Key: keyPrefix + "/" + filename, MIME: "text/plain", SizeB: int64(len(content))}, nil
+}
+
+func (uploadingBlobStore) UploadFormFile(ctx context.Context, keyPrefix string, fh *multipart.FileHeader, reuse blob.Reuse) (*model.Asset, error) {
+	return &model.Asset{S3Key: keyPrefix + "/" + fh.Filename, MIME: "text/plain", SizeB: fh.Size}, nil
+}
+
+// formFile returns the header of a file uploaded in a multipart form
+func formFile(t *testing.T, filename string, content string) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+func TestArtifactService_Create_TextLimit(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	mockRepo := &MockArtifactRepo{}
+	mockRepo.On("GetByPath", mock.Anything, diskID, "/", mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("Create", mock.Anything, projectID, mock.Anything).Return(nil)
+	svc := &artifactService{r: mockRepo, s3: uploadingBlobStore{}}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "within the limit", content: "hello", expected: "hello"},
+		{name: "over the limit", content: "hello world", expected: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			artifact, err := svc.Create(context.Background(), CreateArtifactInput{
+				ProjectID: projectID, DiskID: diskID, Path: "/", Filename: "notes.txt",
+				FileHeader: formFile(t, "notes.txt", tt.content),
+				TextLimit:  8,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, artifact.AssetMeta.Data().Content)
+		})
+	}
+}
+
+func TestArtifactService_EditArtifact_ConcurrentWrite(t *testing.T) {
+	diskID := uuid.New()
+	version := func(n int, text string) *model.Artifact {
+		return &model.Artifact{
+			ID: uuid.New(), DiskID: diskID, Path: "/", Filename: "notes.txt", Version: n,
+			AssetMeta: datatypes.NewJSONType(model.Asset{MIME: "text/plain", SizeB: int64(len(text)), Content: text, SHA256: fmt.Sprint(n)}),
+		}
+	}
+	v1, v2 := version(1, "a\nb\n"), version(2, "a\nb\nc\n")
+	edit := EditArtifactInput{DiskID: diskID, Path: "/", Filename: "notes.txt", Command: EditStrReplace, OldStr: "b", NewStr: "B"}
+
+	t.Run("applied again to the new content", func(t *testing.T) {
+		mockRepo := &MockArtifactRepo{}
+		mockRepo.On("GetByPath", mock.Anything, diskID, "/", "notes.txt").Return(v1, nil).Once()
+		mockRepo.On("GetByPath", mock.Anything, diskID, "/", "notes.txt").Return(v2, nil).Once()
+		// Another write replaced version 1 after it was read
+		mockRepo.On("Replace", mock.Anything, v1, mock.Anything, mock.Anything, 1).Return(ErrPreconditionFailed).Once()
+		mockRepo.On("Replace", mock.Anything, v2, mock.MatchedBy(func(a model.Asset) bool {
+			return a.Content == "a\nB\nc\n"
+		}), mock.Anything, 2).Return(nil).Once()
+		svc := &artifactService{r: mockRepo, s3: uploadingBlobStore{}}
+
+		out, err := svc.EditArtifact(context.Background(), edit)
+		require.NoError(t, err)
+		assert.Same(t, v2, out.Artifact)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		mockRepo := &MockArtifactRepo{}
+		mockRepo.On("GetByPath", mock.Anything, diskID, "/", "notes.txt").Return(v1, nil)
+		mockRepo.On("Replace", mock.Anything, v1, mock.Anything, mock.Anything, 1).Return(ErrPreconditionFailed)
+		svc := &artifactService{r: mockRepo, s3: uploadingBlobStore{}}
+
+		_, err := svc.EditArtifact(context.Background(), edit)
+		assert.ErrorIs(t, err, ErrEditConflict)
+		mockRepo.AssertNumberOfCalls(t, "Replace", editAttempts)
+	})
+
+	t.Run("precondition", func(t *testing.T) {
+		mockRepo := &MockArtifactRepo{}
+		mockRepo.On("GetByPath", mock.Anything, diskID, "/", "notes.txt").Return(v1, nil)
+		mockRepo.On("Replace", mock.Anything, v1, mock.Anything, mock.Anything, 1).Return(ErrPreconditionFailed)
+		svc := &artifactService{r: mockRepo, s3: uploadingBlobStore{}}
+
+		in := edit
+		in.Precondition = Precondition{IfMatch: []string{v1.ETag()}}
+		_, err := svc.EditArtifact(context.Background(), in)
+		assert.ErrorIs(t, err, ErrPreconditionFailed)
+		mockRepo.AssertNumberOfCalls(t, "Replace", 1)
+	})
+}
+
+func TestEditSnippet(t *testing.T) {
+	var b strings.Builder
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	text := b.String()
+
+	snippet := editSnippet(text, textedit.Region{Start: 10, End: 11})
+	assert.True(t, strings.HasPrefix(snippet, " 6\tline 6\n"))
+	assert.True(t, strings.HasSuffix(snippet, "15\tline 15\n"))
+
+	// A deletion shows the context around the removed lines
+	snippet = editSnippet(text, textedit.Region{Start: 2, End: 1})
+	assert.Equal(t, "1\tline 1\n2\tline 2\n3\tline 3\n4\tline 4\n5\tline 5\n", snippet)
+}
+
+func TestArtifactService_CreateUploadURL_Rejected(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	missingDiskID := uuid.New()
+	sum := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	mockRepo := &MockArtifactRepo{}
+	mockRepo.On("GetByPath", mock.Anything, mock.Anything, "/", "big.bin").Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("CreateUpload", mock.Anything, mock.MatchedBy(func(u *model.ArtifactUpload) bool {
+		return u.DiskID == missingDiskID
+	})).Return(gorm.ErrRecordNotFound)
+
+	storageRepo := &MockStorageRepo{}
+	storageRepo.On("GetDiskUserID", mock.Anything, projectID, mock.Anything).Return((*uuid.UUID)(nil), nil)
+	storageRepo.On("GetQuotas", mock.Anything, projectID, (*uuid.UUID)(nil), &diskID).Return([]*model.StorageQuota{
+		{Scope: model.QuotaScopeDisk, ScopeID: diskID, MaxBytes: int64Ptr(1 << 20)},
+	}, nil)
+	storageRepo.On("GetQuotas", mock.Anything, projectID, (*uuid.UUID)(nil), &missingDiskID).Return([]*model.StorageQuota{}, nil)
+	storageRepo.On("GetQuotaUsage", mock.Anything, projectID, (*uuid.UUID)(nil), &diskID).Return(map[string]*repo.StorageUsage{
+		model.QuotaScopeDisk: {},
+	}, nil)
+
+	// Every case fails before a URL is presigned, so no S3 is needed
+	svc := &artifactService{r: mockRepo, quota: NewStorageService(storageRepo)}
+
+	tests := []struct {
+		name        string
+		diskID      uuid.UUID
+		size        int64
+		sha256      string
+		expectedErr error
+	}{
+		{name: "bad checksum", diskID: diskID, size: 10, sha256: "abc", expectedErr: ErrInvalidUpload},
+		{name: "negative size", diskID: diskID, size: -1, sha256: sum, expectedErr: ErrInvalidUpload},
+		{name: "too large", diskID: diskID, size: 1 << 31, sha256: sum, expectedErr: ErrUploadTooLarge},
+		{name: "over quota", diskID: diskID, size: 2 << 20, sha256: sum, expectedErr: ErrQuotaExceeded},
+		{name: "missing disk", diskID: missingDiskID, size: 10, sha256: strings.ToUpper(sum), expectedErr: gorm.ErrRecordNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateUploadURL(context.Background(), CreateUploadURLInput{
+				ProjectID: projectID,
+				DiskID:    tt.diskID,
+				Path:      "/",
+				Filename:  "big.bin",
+				SizeB:     tt.size,
+				SHA256:    tt.sha256,
+				MaxSizeB:  1 << 30,
+				Expire:    time.Minute,
+			})
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestArtifactService_FinalizeUpload_NotFound(t *testing.T) {
+	diskID := uuid.New()
+	uploadID := uuid.New()
+
+	mockRepo := &MockArtifactRepo{}
+	mockRepo.On("GetUpload", mock.Anything, diskID, uploadID).Return(nil, gorm.ErrRecordNotFound)
+
+	svc := &artifactService{r: mockRepo}
+	_, err := svc.FinalizeUpload(context.Background(), FinalizeUploadInput{DiskID: diskID, UploadID: uploadID})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// assetGCLeaderKey is the Redis key held by the API instance that collects assets in the
+// current interval
+const assetGCLeaderKey = "asset_gc:leader"
+
+// streamStagingMaxAge is how long an object may stay under the stream staging prefix. Streams
+// are copied to their content key as soon as they are written, so older objects were left behind
+// by an upload that never finished.
+const streamStagingMaxAge = 24 * time.Hour
+
+// assetKeyPrefixes are the prefixes of the content-addressed objects that asset references track
+var assetKeyPrefixes = []string{"disks/", "parts/", "assets/"}
+
+// reuseAssets lets the uploads of a project reuse stored content through its asset references,
+// which keeps the reused objects from the garbage collector
+func reuseAssets(refs repo.AssetReferenceRepo, projectID uuid.UUID) blob.Reuse {
+	if refs == nil {
+		return nil
+	}
+	return func(ctx context.Context, sha256 string) (*model.Asset, error) {
+		return refs.ClaimAsset(ctx, projectID, sha256)
+	}
+}
+
+type AssetGCService interface {
+	Collect(ctx context.Context, in CollectAssetsInput) (*AssetGCReport, error)
+	Run(ctx context.Context)
+}
+
+type assetGCService struct {
+	r       repo.AssetReferenceRepo
+	uploads repo.ArtifactRepo
+	s3      blob.BlobStore
+	cfg     config.AssetGCCfg
+	log     *zap.Logger
+
+	// lead reports whether this instance won the collection for the next ttl
+	lead func(ctx context.Context, ttl time.Duration) (bool, error)
+}
+
+func NewAssetGCService(r repo.AssetReferenceRepo, uploads repo.ArtifactRepo, s3 blob.BlobStore, rdb *redis.Client, cfg *config.Config, log *zap.Logger) AssetGCService {
+	instanceID := uuid.NewString()
+	return &assetGCService{
+		r:       r,
+		uploads: uploads,
+		s3:      s3,
+		cfg:     cfg.AssetGC,
+		log:     log,
+		lead: func(ctx context.Context, ttl time.Duration) (bool, error) {
+			return rdb.SetNX(ctx, assetGCLeaderKey, instanceID, ttl).Result()
+		},
+	}
+}
+
+type CollectAssetsInput struct {
+	Grace  time.Duration // Defaults to the configured grace period
+	Limit  int           // Maximum number of assets to collect
+	DryRun bool          // Only report what would be collected
+}
+
+type AssetGCReport struct {
+	DryRun  bool      `json:"dry_run"`
+	Before  time.Time `json:"before"`  // Assets without references since before this time are collected
+	Objects int       `json:"objects"` // S3 objects deleted, or that would be deleted in a dry run
+	Bytes   int64     `json:"bytes"`   // Size of these objects
+	Failed  int       `json:"failed"`  // Objects that could not be deleted; they are tried again on the next run
+	HasMore bool      `json:"has_more"`
+
+	// Assets lists what a dry run would collect
+	Assets []*model.AssetReference `json:"assets,omitempty"`
+}
+
+// Collect deletes up to in.Limit assets that have had no references for the grace period,
+// with their S3 objects. Assets referenced again in the meantime are kept.
+func (s *assetGCService) Collect(ctx context.Context, in CollectAssetsInput) (*AssetGCReport, error) {
+	grace := in.Grace
+	if grace <= 0 {
+		grace = time.Duration(s.cfg.GraceSec) * time.Second
+	}
+	out := &AssetGCReport{DryRun: in.DryRun, Before: time.Now().Add(-grace)}
+
+	// Query limit+1 is used to determine has_more
+	refs, err := s.r.ListOrphaned(ctx, out.Before, in.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) > in.Limit {
+		out.HasMore = true
+		refs = refs[:in.Limit]
+	}
+
+	if in.DryRun {
+		out.Assets = refs
+		for _, ref := range refs {
+			out.Objects++
+			out.Bytes += ref.AssetMeta.Data().SizeB
+		}
+		if out.Assets == nil {
+			out.Assets = []*model.AssetReference{}
+		}
+		return out, nil
+	}
+
+	for _, ref := range refs {
+		deleted, err := s.r.DeleteOrphaned(ctx, ref.ID, out.Before)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			s.log.Warn("failed to collect orphaned asset", zap.String("s3_key", ref.S3Key), zap.Error(err))
+			out.Failed++
+			continue
+		}
+		if deleted {
+			out.Objects++
+			out.Bytes += ref.AssetMeta.Data().SizeB
+		}
+	}
+	return out, nil
+}
+
+// Run collects orphaned assets, unreferenced asset objects, expired direct uploads and stale
+// stream staging objects every configured interval until ctx is done. Each interval, the instance that takes the leader key in Redis does the collection and the
+// others skip it. A run
+// that outlasts the interval may overlap with the next one, which is safe since every asset is
+// deleted under a row lock.
+func (s *assetGCService) Run(ctx context.Context) {
+	interval := time.Duration(s.cfg.IntervalSec) * time.Second
+	if interval <= 0 {
+		s.log.Warn("asset collector not started, interval must be positive")
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		leader, err := s.lead(ctx, interval)
+		if err != nil {
+			s.log.Warn("failed to elect asset collector", zap.Error(err))
+			continue
+		}
+		if leader {
+			s.collectAll(ctx)
+			s.sweepUnreferenced(ctx)
+			s.sweepUploads(ctx)
+			s.sweepStaging(ctx)
+		}
+	}
+}
+
+// collectAll collects batches until no orphaned asset is left or a batch makes no progress
+func (s *assetGCService) collectAll(ctx context.Context) {
+	total := AssetGCReport{}
+	start := time.Now()
+	for {
+		out, err := s.Collect(ctx, CollectAssetsInput{Limit: s.cfg.BatchSize})
+		if err != nil {
+			s.log.Error("collect orphaned assets", zap.Error(err))
+			break
+		}
+		total.Objects += out.Objects
+		total.Bytes += out.Bytes
+		total.Failed += out.Failed
+		if !out.HasMore || out.Objects == 0 {
+			break
+		}
+	}
+
+	s.log.Info("collected orphaned assets",
+		zap.Int("objects", total.Objects),
+		zap.Int64("bytes", total.Bytes),
+		zap.Int("failed", total.Failed),
+		zap.Duration("took", time.Since(start)),
+	)
+}
+
+// sweepUnreferenced deletes the asset objects older than the grace period that no asset reference
+// points to, such as the objects of uploads whose write was rolled back. A new reference to such an
+// object can only come from an upload of the same content, which writes it again and so makes it
+// new. Objects that cannot be deleted are tried again on the next run.
+func (s *assetGCService) sweepUnreferenced(ctx context.Context) {
+	before := time.Now().Add(-time.Duration(s.cfg.GraceSec) * time.Second)
+	swept, failed := 0, 0
+	for _, prefix := range assetKeyPrefixes {
+		err := s.s3.ListObjects(ctx, prefix, before, func(keys []string) error {
+			unreferenced, err := s.r.UnreferencedKeys(ctx, keys)
+			if err != nil {
+				return err
+			}
+			for _, key := range unreferenced {
+				if err := s.s3.DeleteObject(ctx, key); err != nil {
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
+					s.log.Warn("failed to delete unreferenced asset object", zap.String("s3_key", key), zap.Error(err))
+					failed++
+					continue
+				}
+				swept++
+			}
+			return nil
+		})
+		if err != nil {
+			s.log.Error("sweep unreferenced asset objects", zap.String("prefix", prefix), zap.Error(err))
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}
+
+	if swept > 0 || failed > 0 {
+		s.log.Info("swept unreferenced asset objects", zap.Int("objects", swept), zap.Int("failed", failed))
+	}
+}
+
+// sweepUploads deletes the direct uploads that can no longer be finalized, with their staged
+// objects. An upload whose object cannot be deleted is kept and tried again on the next run.
+func (s *assetGCService) sweepUploads(ctx context.Context) {
+	before := time.Now().Add(-uploadFinalizeWindow)
+	swept, failed := 0, 0
+	for {
+		uploads, err := s.uploads.ListExpiredUploads(ctx, before, s.cfg.BatchSize)
+		if err != nil {
+			s.log.Error("list expired uploads", zap.Error(err))
+			break
+		}
+		removed := 0
+		for _, u := range uploads {
+			if err := s.s3.DeleteObject(ctx, u.S3Key); err != nil {
+				s.log.Warn("failed to delete staged upload", zap.String("s3_key", u.S3Key), zap.Error(err))
+				failed++
+				continue
+			}
+			if err := s.uploads.DeleteUpload(ctx, u.ID); err != nil {
+				s.log.Warn("failed to delete upload record", zap.String("upload_id", u.ID.String()), zap.Error(err))
+				failed++
+				continue
+			}
+			removed++
+		}
+		swept += removed
+		if len(uploads) < s.cfg.BatchSize || removed == 0 {
+			break
+		}
+	}
+
+	if swept > 0 || failed > 0 {
+		s.log.Info("swept expired uploads", zap.Int("uploads", swept), zap.Int("failed", failed))
+	}
+}
+
+// sweepStaging deletes the objects that uploads streamed to the staging prefix and never copied
+// to their content key. Objects that cannot be deleted are tried again on the next run.
+func (s *assetGCService) sweepStaging(ctx context.Context) {
+	swept, failed := 0, 0
+	err := s.s3.ListObjects(ctx, blob.StreamStagingPrefix, time.Now().Add(-streamStagingMaxAge), func(keys []string) error {
+		for _, key := range keys {
+			if err := s.s3.DeleteObject(ctx, key); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				s.log.Warn("failed to delete stream staging object", zap.String("s3_key", key), zap.Error(err))
+				failed++
+				continue
+			}
+			swept++
+		}
+		return nil
+	})
+	if err != nil {
+		s.log.Error("list stream staging objects", zap.Error(err))
+	}
+
+	if swept > 0 || failed > 0 {
+		s.log.Info("swept stream staging objects", zap.Int("objects", swept), zap.Int("failed", failed))
+	}
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+)
+
+func newTestAssetGCService(r *MockAssetReferenceRepo, lead func(ctx context.Context, ttl time.Duration) (bool, error)) *assetGCService {
+	return &assetGCService{
+		r:    r,
+		cfg:  config.AssetGCCfg{Enabled: true, IntervalSec: 3600, GraceSec: 86400, BatchSize: 2},
+		log:  zap.NewNop(),
+		lead: lead,
+	}
+}
+
+// newTestBlobStore returns a local blob store that keeps its objects in dir
+func newTestBlobStore(t *testing.T, dir string) blob.BlobStore {
+	cfg := &config.Config{}
+	cfg.S3.Backend = blob.BackendLocal
+	cfg.S3.LocalDir = dir
+	cfg.S3.LocalSecret = "secret"
+	cfg.S3.Endpoint = "http://127.0.0.1:8029"
+	store, err := blob.New(context.Background(), cfg)
+	require.NoError(t, err)
+	return store
+}
+
+func orphanedAsset(key string, size int64) *model.AssetReference {
+	return &model.AssetReference{
+		ID:        uuid.New(),
+		S3Key:     key,
+		AssetMeta: datatypes.NewJSONType(model.Asset{S3Key: key, SizeB: size}),
+	}
+}
+
+func TestAssetGCService_Collect(t *testing.T) {
+	a := orphanedAsset("assets/a.md", 10)
+	b := orphanedAsset("assets/b.md", 20)
+	c := orphanedAsset("assets/c.md", 40)
+
+	t.Run("dry run", func(t *testing.T) {
+		mockRepo := &MockAssetReferenceRepo{}
+		mockRepo.On("ListOrphaned", mock.Anything, mock.AnythingOfType("time.Time"), 3).Return([]*model.AssetReference{a, b, c}, nil)
+		svc := newTestAssetGCService(mockRepo, nil)
+
+		out, err := svc.Collect(context.Background(), CollectAssetsInput{Limit: 2, DryRun: true})
+		require.NoError(t, err)
+		assert.True(t, out.DryRun)
+		assert.Equal(t, 2, out.Objects)
+		assert.Equal(t, int64(30), out.Bytes)
+		assert.True(t, out.HasMore)
+		assert.Equal(t, []*model.AssetReference{a, b}, out.Assets)
+		// The configured grace period applies by default
+		assert.WithinDuration(t, time.Now().Add(-24*time.Hour), out.Before, time.Minute)
+		mockRepo.AssertNotCalled(t, "DeleteOrphaned", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("deletes what is still orphaned", func(t *testing.T) {
+		mockRepo := &MockAssetReferenceRepo{}
+		mockRepo.On("ListOrphaned", mock.Anything, mock.AnythingOfType("time.Time"), 11).Return([]*model.AssetReference{a, b, c}, nil)
+		mockRepo.On("DeleteOrphaned", mock.Anything, a.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+		// Referenced again since it was listed
+		mockRepo.On("DeleteOrphaned", mock.Anything, b.ID, mock.AnythingOfType("time.Time")).Return(false, nil)
+		mockRepo.On("DeleteOrphaned", mock.Anything, c.ID, mock.AnythingOfType("time.Time")).Return(false, errors.New("s3 down"))
+		svc := newTestAssetGCService(mockRepo, nil)
+
+		out, err := svc.Collect(context.Background(), CollectAssetsInput{Limit: 10, Grace: time.Hour})
+		require.NoError(t, err)
+		assert.Equal(t, 1, out.Objects)
+		assert.Equal(t, int64(10), out.Bytes)
+		assert.Equal(t, 1, out.Failed)
+		assert.False(t, out.HasMore)
+		assert.Nil(t, out.Assets)
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), out.Before, time.Minute)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestAssetGCService_CollectAll(t *testing.T) {
+	a := orphanedAsset("assets/a.md", 10)
+	b := orphanedAsset("assets/b.md", 20)
+	c := orphanedAsset("assets/c.md", 40)
+
+	mockRepo := &MockAssetReferenceRepo{}
+	mockRepo.On("ListOrphaned", mock.Anything, mock.Anything, 3).Return([]*model.AssetReference{a, b, c}, nil).Once()
+	mockRepo.On("ListOrphaned", mock.Anything, mock.Anything, 3).Return([]*model.AssetReference{c}, nil).Once()
+	mockRepo.On("DeleteOrphaned", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	svc := newTestAssetGCService(mockRepo, nil)
+
+	svc.collectAll(context.Background())
+	mockRepo.AssertNumberOfCalls(t, "ListOrphaned", 2)
+	mockRepo.AssertNumberOfCalls(t, "DeleteOrphaned", 3)
+
+	// A batch that deletes nothing is not retried within the run
+	mockRepo = &MockAssetReferenceRepo{}
+	mockRepo.On("ListOrphaned", mock.Anything, mock.Anything, 3).Return([]*model.AssetReference{a, b, c}, nil)
+	mockRepo.On("DeleteOrphaned", mock.Anything, mock.Anything, mock.Anything).Return(false, errors.New("s3 down"))
+	svc = newTestAssetGCService(mockRepo, nil)
+
+	svc.collectAll(context.Background())
+	mockRepo.AssertNumberOfCalls(t, "ListOrphaned", 1)
+}
+
+func TestAssetGCService_SweepUploads(t *testing.T) {
+	ctx := context.Background()
+	store := newTestBlobStore(t, t.TempDir())
+
+	a := &model.ArtifactUpload{ID: uuid.New(), S3Key: "staging/p/a.txt"}
+	b := &model.ArtifactUpload{ID: uuid.New(), S3Key: "staging/p/b.txt"}
+	for _, u := range []*model.ArtifactUpload{a, b} {
+		_, err := store.UploadReaderDirect(ctx, u.S3Key, strings.NewReader("staged"), "text/plain")
+		require.NoError(t, err)
+	}
+
+	uploads := &MockArtifactRepo{}
+	uploads.On("ListExpiredUploads", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		// Uploads that can still be finalized are kept
+		return before.Before(time.Now().Add(-uploadFinalizeWindow + time.Minute))
+	}), 2).Return([]*model.ArtifactUpload{a, b}, nil).Once()
+	uploads.On("ListExpiredUploads", mock.Anything, mock.Anything, 2).Return(nil, nil).Once()
+	uploads.On("DeleteUpload", mock.Anything, a.ID).Return(nil)
+	uploads.On("DeleteUpload", mock.Anything, b.ID).Return(errors.New("db down"))
+
+	svc := newTestAssetGCService(&MockAssetReferenceRepo{}, nil)
+	svc.uploads = uploads
+	svc.s3 = store
+
+	svc.sweepUploads(ctx)
+	uploads.AssertExpectations(t)
+	for _, u := range []*model.ArtifactUpload{a, b} {
+		_, err := store.ReadStaged(ctx, u.S3Key, 0)
+		assert.ErrorIs(t, err, blob.ErrObjectNotFound, u.S3Key)
+	}
+}
+
+func TestAssetGCService_SweepUnreferenced(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := newTestBlobStore(t, dir)
+
+	orphaned := "disks/p/2025/01/01/a.md"
+	referenced := "disks/p/2025/01/01/b.md"
+	fresh := "assets/p/2025/01/02/c.png"
+	skill := "agent_skills/p/s/SKILL.md"
+	for _, key := range []string{orphaned, referenced, fresh, skill} {
+		_, err := store.UploadReaderDirect(ctx, key, strings.NewReader("content"), "text/plain")
+		require.NoError(t, err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	for _, key := range []string{orphaned, referenced, skill} {
+		require.NoError(t, os.Chtimes(filepath.Join(dir, filepath.FromSlash(key)), old, old))
+	}
+
+	mockRepo := &MockAssetReferenceRepo{}
+	mockRepo.On("UnreferencedKeys", mock.Anything, []string{orphaned, referenced}).Return([]string{orphaned}, nil).Once()
+	svc := newTestAssetGCService(mockRepo, nil)
+	svc.s3 = store
+	svc.sweepUnreferenced(ctx)
+	mockRepo.AssertExpectations(t)
+
+	_, err := store.ReadStaged(ctx, orphaned, 0)
+	assert.ErrorIs(t, err, blob.ErrObjectNotFound)
+	// Referenced objects, objects within the grace period and objects outside the asset prefixes
+	// are kept
+	for _, key := range []string{referenced, fresh, skill} {
+		_, err := store.ReadStaged(ctx, key, 0)
+		assert.NoError(t, err, key)
+	}
+}
+
+func TestAssetGCService_SweepStaging(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := newTestBlobStore(t, dir)
+
+	stale := blob.StreamStagingPrefix + "stale"
+	fresh := blob.StreamStagingPrefix + "fresh"
+	upload := "staging/p/upload.txt"
+	for _, key := range []string{stale, fresh, upload} {
+		_, err := store.UploadReaderDirect(ctx, key, strings.NewReader("staged"), "text/plain")
+		require.NoError(t, err)
+	}
+	old := time.Now().Add(-streamStagingMaxAge - time.Hour)
+	for _, key := range []string{stale, upload} {
+		require.NoError(t, os.Chtimes(filepath.Join(dir, filepath.FromSlash(key)), old, old))
+	}
+
+	svc := newTestAssetGCService(&MockAssetReferenceRepo{}, nil)
+	svc.s3 = store
+	svc.sweepStaging(ctx)
+
+	_, err := store.ReadStaged(ctx, stale, 0)
+	assert.ErrorIs(t, err, blob.ErrObjectNotFound)
+	// Streams that may still be in flight and other staged objects are kept
+	for _, key := range []string{fresh, upload} {
+		_, err := store.ReadStaged(ctx, key, 0)
+		assert.NoError(t, err, key)
+	}
+}
+
+func TestAssetGCService_Run(t *testing.T) {
+	mockRepo := &MockAssetReferenceRepo{}
+	mockRepo.On("ListOrphaned", mock.Anything, mock.Anything, 3).Return(nil, nil)
+	uploads := &MockArtifactRepo{}
+	uploads.On("ListExpiredUploads", mock.Anything, mock.Anything, 2).Return(nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	elections := 0
+	svc := newTestAssetGCService(mockRepo, func(ctx context.Context, ttl time.Duration) (bool, error) {
+		elections++
+		assert.Equal(t, time.Second, ttl)
+		if elections < 2 {
+			return false, nil
+		}
+		// The second election is won and the server stops during the collection
+		cancel()
+		return true, nil
+	})
+	svc.cfg.IntervalSec = 1
+	svc.uploads = uploads
+	svc.s3 = newTestBlobStore(t, t.TempDir())
+
+	done := make(chan struct{})
+	go func() {
+		svc.Run(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("collector did not stop")
+	}
+
+	assert.Equal(t, 2, elections)
+	mockRepo.AssertNumberOfCalls(t, "ListOrphaned", 1)
+	uploads.AssertNumberOfCalls(t, "ListExpiredUploads", 1)
+}
+
+func TestReuseAssets(t *testing.T) {
+	projectID := uuid.New()
+	stored := &model.Asset{S3Key: "disks/p/2025/01/01/abc.md", SHA256: "abc"}
+
+	mockRepo := &MockAssetReferenceRepo{}
+	mockRepo.On("ClaimAsset", mock.Anything, projectID, "abc").Return(stored, nil)
+	mockRepo.On("ClaimAsset", mock.Anything, projectID, "def").Return(nil, nil)
+
+	reuse := reuseAssets(mockRepo, projectID)
+	got, err := reuse(context.Background(), "abc")
+	require.NoError(t, err)
+	assert.Equal(t, stored, got)
+	got, err = reuse(context.Background(), "def")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+	mockRepo.AssertExpectations(t)
+
+	// Without asset references nothing is reused
+	assert.Nil(t, reuseAssets(nil, projectID))
+}
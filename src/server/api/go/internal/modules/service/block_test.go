@@ -67,6 +67,14 @@ func (m *MockBlockRepo) ListBySpace(ctx context.Context, spaceID uuid.UUID, bloc
 	return args.Get(0).([]model.Block), args.Error(1)
 }
 
+func (m *MockBlockRepo) ListSOPUseWhen(ctx context.Context, spaceID uuid.UUID) ([]string, error) {
+	args := m.Called(ctx, spaceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func TestBlockService_Create_Page(t *testing.T) {
 	ctx := context.Background()
 	spaceID := uuid.New()
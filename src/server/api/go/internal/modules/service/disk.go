@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,12 +17,28 @@ type DiskService interface {
 	Create(ctx context.Context, projectID uuid.UUID, userID *uuid.UUID) (*model.Disk, error)
 	Delete(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) error
 	List(ctx context.Context, in ListDisksInput) (*ListDisksOutput, error)
+	UpdateVersionRetention(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, retention int) (*model.Disk, error)
+	CreateSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, name string) (*model.DiskSnapshot, error)
+	ListSnapshots(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskSnapshot, error)
+	DeleteSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) error
+	RestoreSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) (*model.DiskSnapshot, error)
+	Clone(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, userID *uuid.UUID) (*model.Disk, error)
+	ListChanges(ctx context.Context, in ListDiskChangesInput) (*ListDiskChangesOutput, error)
 }
 
-type diskService struct{ r repo.DiskRepo }
+// defaultChangePoll is how often a waiting ListChanges looks for new changes
+const defaultChangePoll = 500 * time.Millisecond
 
-func NewDiskService(r repo.DiskRepo) DiskService {
-	return &diskService{r: r}
+type diskService struct {
+	r     repo.DiskRepo
+	quota StorageService // Optional; restores and clones are not checked against quotas without it
+
+	// changePoll overrides defaultChangePoll when set
+	changePoll time.Duration
+}
+
+func NewDiskService(r repo.DiskRepo, quota StorageService) DiskService {
+	return &diskService{r: r, quota: quota}
 }
 
 func (s *diskService) Create(ctx context.Context, projectID uuid.UUID, userID *uuid.UUID) (*model.Disk, error) {
@@ -44,6 +61,81 @@ func (s *diskService) Delete(ctx context.Context, projectID uuid.UUID, diskID uu
 	return s.r.Delete(ctx, projectID, diskID)
 }
 
+// UpdateVersionRetention sets how many previous versions are kept per artifact.
+// A lower retention is applied to an artifact the next time it changes.
+func (s *diskService) UpdateVersionRetention(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, retention int) (*model.Disk, error) {
+	if retention < 0 {
+		return nil, errors.New("version retention must not be negative")
+	}
+	return s.r.UpdateVersionRetention(ctx, projectID, diskID, retention)
+}
+
+// CreateSnapshot records the current artifacts of a disk so they can be restored later
+func (s *diskService) CreateSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, name string) (*model.DiskSnapshot, error) {
+	return s.r.CreateSnapshot(ctx, projectID, diskID, strings.TrimSpace(name))
+}
+
+func (s *diskService) ListSnapshots(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskSnapshot, error) {
+	snapshots, err := s.r.ListSnapshots(ctx, projectID, diskID)
+	if err != nil {
+		return nil, err
+	}
+	if snapshots == nil {
+		snapshots = []*model.DiskSnapshot{}
+	}
+	return snapshots, nil
+}
+
+func (s *diskService) DeleteSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) error {
+	return s.r.DeleteSnapshot(ctx, projectID, diskID, snapshotID)
+}
+
+// RestoreSnapshot rolls a disk back to a snapshot. Artifacts created after the snapshot are
+// deleted and the version history of the replaced artifacts is dropped.
+func (s *diskService) RestoreSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) (*model.DiskSnapshot, error) {
+	// Only what the snapshot adds over the current artifacts counts against quotas
+	if s.quota != nil {
+		snapshot, err := s.r.GetSnapshot(ctx, projectID, diskID, snapshotID)
+		if err != nil {
+			return nil, err
+		}
+		size, err := s.r.GetContentSize(ctx, projectID, diskID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.quota.CheckQuota(ctx, CheckQuotaInput{
+			ProjectID: projectID,
+			DiskID:    &diskID,
+			Bytes:     snapshot.SizeB - size.Bytes,
+			Files:     int64(snapshot.ArtifactCount) - size.Files,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return s.r.RestoreSnapshot(ctx, projectID, diskID, snapshotID)
+}
+
+// Clone creates a new disk with a copy of the current artifacts of a disk, optionally for another user
+func (s *diskService) Clone(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, userID *uuid.UUID) (*model.Disk, error) {
+	// The copies count against the quotas of the project and the user of the clone, even though
+	// they share stored files
+	if s.quota != nil {
+		size, err := s.r.GetContentSize(ctx, projectID, diskID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.quota.CheckQuota(ctx, CheckQuotaInput{ProjectID: projectID, UserID: userID, Bytes: size.Bytes, Files: size.Files}); err != nil {
+			return nil, err
+		}
+	}
+
+	disk, err := s.r.Clone(ctx, projectID, diskID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("clone disk: %w", err)
+	}
+	return disk, nil
+}
+
 type ListDisksInput struct {
 	ProjectID uuid.UUID `json:"project_id"`
 	User      string    `json:"user"`
@@ -89,3 +181,66 @@ func (s *diskService) List(ctx context.Context, in ListDisksInput) (*ListDisksOu
 
 	return out, nil
 }
+
+type ListDiskChangesInput struct {
+	ProjectID uuid.UUID     `json:"project_id"`
+	DiskID    uuid.UUID     `json:"disk_id"`
+	Since     int64         `json:"since"`
+	Limit     int           `json:"limit"`
+	Wait      time.Duration `json:"wait"` // How long to wait for a change when there is none yet
+}
+
+type ListDiskChangesOutput struct {
+	Items     []*model.DiskChange `json:"items"`
+	NextSince int64               `json:"next_since"` // Seq to pass as since for the next call
+	LatestSeq int64               `json:"latest_seq"` // Seq of the last change of the disk
+	HasMore   bool                `json:"has_more"`
+}
+
+// ListChanges returns the changes of a disk after in.Since. When there are none, it keeps looking
+// for up to in.Wait, so clients can long-poll the change log.
+func (s *diskService) ListChanges(ctx context.Context, in ListDiskChangesInput) (*ListDiskChangesOutput, error) {
+	if in.Since < 0 {
+		return nil, errors.New("since must not be negative")
+	}
+	poll := s.changePoll
+	if poll <= 0 {
+		poll = defaultChangePoll
+	}
+	deadline := time.Now().Add(in.Wait)
+
+	for {
+		// Query limit+1 is used to determine has_more
+		changes, latest, err := s.r.ListChanges(ctx, in.ProjectID, in.DiskID, in.Since, in.Limit+1)
+		if err != nil {
+			return nil, err
+		}
+
+		wait := time.Until(deadline)
+		if len(changes) > 0 || wait <= 0 {
+			out := &ListDiskChangesOutput{
+				Items:     changes,
+				NextSince: in.Since,
+				LatestSeq: latest,
+			}
+			if len(changes) > in.Limit {
+				out.HasMore = true
+				out.Items = changes[:in.Limit]
+			}
+			if len(out.Items) > 0 {
+				out.NextSince = out.Items[len(out.Items)-1].Seq
+				// A change may commit between reading the disk and its changes
+				out.LatestSeq = max(out.LatestSeq, changes[len(changes)-1].Seq)
+			} else {
+				out.Items = []*model.DiskChange{}
+			}
+			return out, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(min(poll, wait)):
+		}
+	}
+}
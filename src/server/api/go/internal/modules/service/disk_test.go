@@ -8,8 +8,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 // MockDiskRepo is a mock implementation of DiskRepo
@@ -27,14 +29,81 @@ func (m *MockDiskRepo) Delete(ctx context.Context, projectID uuid.UUID, diskID u
 	return args.Error(0)
 }
 
-func (m *MockDiskRepo) ListWithCursor(ctx context.Context, projectID uuid.UUID, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.Disk, error) {
-	args := m.Called(ctx, projectID, afterCreatedAt, afterID, limit, timeDesc)
+func (m *MockDiskRepo) ListWithCursor(ctx context.Context, projectID uuid.UUID, userIdentifier string, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.Disk, error) {
+	args := m.Called(ctx, projectID, userIdentifier, afterCreatedAt, afterID, limit, timeDesc)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*model.Disk), args.Error(1)
 }
 
+func (m *MockDiskRepo) UpdateVersionRetention(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, retention int) (*model.Disk, error) {
+	args := m.Called(ctx, projectID, diskID, retention)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Disk), args.Error(1)
+}
+
+func (m *MockDiskRepo) CreateSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, name string) (*model.DiskSnapshot, error) {
+	args := m.Called(ctx, projectID, diskID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DiskSnapshot), args.Error(1)
+}
+
+func (m *MockDiskRepo) ListSnapshots(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskSnapshot, error) {
+	args := m.Called(ctx, projectID, diskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.DiskSnapshot), args.Error(1)
+}
+
+func (m *MockDiskRepo) GetSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) (*model.DiskSnapshot, error) {
+	args := m.Called(ctx, projectID, diskID, snapshotID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DiskSnapshot), args.Error(1)
+}
+
+func (m *MockDiskRepo) GetContentSize(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*repo.DiskContentSize, error) {
+	args := m.Called(ctx, projectID, diskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repo.DiskContentSize), args.Error(1)
+}
+
+func (m *MockDiskRepo) DeleteSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) error {
+	args := m.Called(ctx, projectID, diskID, snapshotID)
+	return args.Error(0)
+}
+
+func (m *MockDiskRepo) RestoreSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) (*model.DiskSnapshot, error) {
+	args := m.Called(ctx, projectID, diskID, snapshotID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DiskSnapshot), args.Error(1)
+}
+
+func (m *MockDiskRepo) Clone(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, userID *uuid.UUID) (*model.Disk, error) {
+	args := m.Called(ctx, projectID, diskID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Disk), args.Error(1)
+}
+
+func (m *MockDiskRepo) ListChanges(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, since int64, limit int) ([]*model.DiskChange, int64, error) {
+	args := m.Called(ctx, projectID, diskID, since, limit)
+	changes, _ := args.Get(0).([]*model.DiskChange)
+	return changes, args.Get(1).(int64), args.Error(2)
+}
+
 // MockS3Deps is a mock implementation of blob.S3Deps
 type MockS3Deps struct {
 	mock.Mock
@@ -85,13 +154,44 @@ func (s *testDiskService) Delete(ctx context.Context, projectID uuid.UUID, diskI
 }
 
 func (s *testDiskService) List(ctx context.Context, in ListDisksInput) (*ListDisksOutput, error) {
-	disks, err := s.r.ListWithCursor(ctx, in.ProjectID, time.Time{}, uuid.UUID{}, in.Limit, in.TimeDesc)
+	disks, err := s.r.ListWithCursor(ctx, in.ProjectID, in.User, time.Time{}, uuid.UUID{}, in.Limit, in.TimeDesc)
 	if err != nil {
 		return nil, err
 	}
 	return &ListDisksOutput{Items: disks, HasMore: false}, nil
 }
 
+func (s *testDiskService) UpdateVersionRetention(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, retention int) (*model.Disk, error) {
+	if retention < 0 {
+		return nil, errors.New("version retention must not be negative")
+	}
+	return s.r.UpdateVersionRetention(ctx, projectID, diskID, retention)
+}
+
+func (s *testDiskService) CreateSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, name string) (*model.DiskSnapshot, error) {
+	return (&diskService{r: s.r}).CreateSnapshot(ctx, projectID, diskID, name)
+}
+
+func (s *testDiskService) ListSnapshots(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskSnapshot, error) {
+	return (&diskService{r: s.r}).ListSnapshots(ctx, projectID, diskID)
+}
+
+func (s *testDiskService) DeleteSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) error {
+	return (&diskService{r: s.r}).DeleteSnapshot(ctx, projectID, diskID, snapshotID)
+}
+
+func (s *testDiskService) RestoreSnapshot(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, snapshotID uuid.UUID) (*model.DiskSnapshot, error) {
+	return (&diskService{r: s.r}).RestoreSnapshot(ctx, projectID, diskID, snapshotID)
+}
+
+func (s *testDiskService) Clone(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, userID *uuid.UUID) (*model.Disk, error) {
+	return (&diskService{r: s.r}).Clone(ctx, projectID, diskID, userID)
+}
+
+func (s *testDiskService) ListChanges(ctx context.Context, in ListDiskChangesInput) (*ListDiskChangesOutput, error) {
+	return (&diskService{r: s.r, changePoll: time.Millisecond}).ListChanges(ctx, in)
+}
+
 func createTestDisk() *model.Disk {
 	projectID := uuid.New()
 	diskID := uuid.New()
@@ -179,7 +279,7 @@ func TestDiskService_List(t *testing.T) {
 				Limit:     10,
 			},
 			setup: func(repo *MockDiskRepo) {
-				repo.On("ListWithCursor", mock.Anything, projectID, time.Time{}, uuid.UUID{}, 10, false).Return([]*model.Disk{disk1, disk2}, nil)
+				repo.On("ListWithCursor", mock.Anything, projectID, "", time.Time{}, uuid.UUID{}, 10, false).Return([]*model.Disk{disk1, disk2}, nil)
 			},
 			expectError: false,
 			expectCount: 2,
@@ -191,7 +291,7 @@ func TestDiskService_List(t *testing.T) {
 				Limit:     10,
 			},
 			setup: func(repo *MockDiskRepo) {
-				repo.On("ListWithCursor", mock.Anything, projectID, time.Time{}, uuid.UUID{}, 10, false).Return([]*model.Disk{}, nil)
+				repo.On("ListWithCursor", mock.Anything, projectID, "", time.Time{}, uuid.UUID{}, 10, false).Return([]*model.Disk{}, nil)
 			},
 			expectError: false,
 			expectCount: 0,
@@ -203,7 +303,7 @@ func TestDiskService_List(t *testing.T) {
 				Limit:     10,
 			},
 			setup: func(repo *MockDiskRepo) {
-				repo.On("ListWithCursor", mock.Anything, projectID, time.Time{}, uuid.UUID{}, 10, false).Return(nil, errors.New("list error"))
+				repo.On("ListWithCursor", mock.Anything, projectID, "", time.Time{}, uuid.UUID{}, 10, false).Return(nil, errors.New("list error"))
 			},
 			expectError: true,
 			errorMsg:    "list error",
@@ -299,3 +399,322 @@ func TestDiskService_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestDiskService_UpdateVersionRetention(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+
+	tests := []struct {
+		name        string
+		retention   int
+		setup       func(*MockDiskRepo)
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:      "successful update",
+			retention: 5,
+			setup: func(repo *MockDiskRepo) {
+				repo.On("UpdateVersionRetention", mock.Anything, projectID, diskID, 5).
+					Return(&model.Disk{ID: diskID, ProjectID: projectID, VersionRetention: 5}, nil)
+			},
+		},
+		{
+			name:      "zero disables versioning",
+			retention: 0,
+			setup: func(repo *MockDiskRepo) {
+				repo.On("UpdateVersionRetention", mock.Anything, projectID, diskID, 0).
+					Return(&model.Disk{ID: diskID, ProjectID: projectID}, nil)
+			},
+		},
+		{
+			name:        "negative retention",
+			retention:   -1,
+			setup:       func(repo *MockDiskRepo) {},
+			expectError: true,
+			errorMsg:    "version retention must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockDiskRepo{}
+			tt.setup(mockRepo)
+
+			service := newTestDiskService(mockRepo, &MockS3Deps{})
+			disk, err := service.UpdateVersionRetention(context.Background(), projectID, diskID, tt.retention)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.retention, disk.VersionRetention)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiskService_CreateSnapshot(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+
+	mockRepo := &MockDiskRepo{}
+	mockRepo.On("CreateSnapshot", mock.Anything, projectID, diskID, "before-run").
+		Return(&model.DiskSnapshot{ID: uuid.New(), DiskID: diskID, Name: "before-run", ArtifactCount: 3}, nil)
+
+	service := newTestDiskService(mockRepo, &MockS3Deps{})
+	snapshot, err := service.CreateSnapshot(context.Background(), projectID, diskID, "  before-run ")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, snapshot.ArtifactCount)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDiskService_ListSnapshots(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+
+	mockRepo := &MockDiskRepo{}
+	mockRepo.On("ListSnapshots", mock.Anything, projectID, diskID).Return(nil, nil)
+
+	service := newTestDiskService(mockRepo, &MockS3Deps{})
+	snapshots, err := service.ListSnapshots(context.Background(), projectID, diskID)
+
+	assert.NoError(t, err)
+	// An empty list is returned instead of null
+	assert.NotNil(t, snapshots)
+	assert.Empty(t, snapshots)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDiskService_RestoreSnapshot(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	snapshotID := uuid.New()
+
+	tests := []struct {
+		name        string
+		setup       func(*MockDiskRepo)
+		expectError error
+	}{
+		{
+			name: "successful restore",
+			setup: func(repo *MockDiskRepo) {
+				repo.On("RestoreSnapshot", mock.Anything, projectID, diskID, snapshotID).
+					Return(&model.DiskSnapshot{ID: snapshotID, DiskID: diskID}, nil)
+			},
+		},
+		{
+			name: "unknown snapshot",
+			setup: func(repo *MockDiskRepo) {
+				repo.On("RestoreSnapshot", mock.Anything, projectID, diskID, snapshotID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectError: gorm.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockDiskRepo{}
+			tt.setup(mockRepo)
+
+			service := newTestDiskService(mockRepo, &MockS3Deps{})
+			snapshot, err := service.RestoreSnapshot(context.Background(), projectID, diskID, snapshotID)
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, snapshotID, snapshot.ID)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiskService_Clone(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	userID := uuid.New()
+
+	tests := []struct {
+		name        string
+		setup       func(*MockDiskRepo)
+		expectError error
+	}{
+		{
+			name: "successful clone",
+			setup: func(repo *MockDiskRepo) {
+				repo.On("Clone", mock.Anything, projectID, diskID, &userID).
+					Return(&model.Disk{ID: uuid.New(), ProjectID: projectID, UserID: &userID}, nil)
+			},
+		},
+		{
+			name: "unknown disk",
+			setup: func(repo *MockDiskRepo) {
+				repo.On("Clone", mock.Anything, projectID, diskID, &userID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectError: gorm.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockDiskRepo{}
+			tt.setup(mockRepo)
+
+			service := newTestDiskService(mockRepo, &MockS3Deps{})
+			disk, err := service.Clone(context.Background(), projectID, diskID, &userID)
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEqual(t, diskID, disk.ID)
+				assert.Equal(t, &userID, disk.UserID)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiskService_Quota(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	snapshotID := uuid.New()
+	userID := uuid.New()
+
+	storageRepo := &MockStorageRepo{}
+	storageRepo.On("GetDiskUserID", mock.Anything, projectID, diskID).Return((*uuid.UUID)(nil), nil)
+	storageRepo.On("GetQuotas", mock.Anything, projectID, mock.Anything, mock.Anything).Return([]*model.StorageQuota{
+		{Scope: model.QuotaScopeProject, ScopeID: projectID, MaxBytes: int64Ptr(1000)},
+	}, nil)
+	storageRepo.On("GetQuotaUsage", mock.Anything, projectID, mock.Anything, mock.Anything).Return(map[string]*repo.StorageUsage{
+		model.QuotaScopeProject: {Files: 3, RawBytes: 600},
+	}, nil)
+
+	mockRepo := &MockDiskRepo{}
+	mockRepo.On("GetContentSize", mock.Anything, projectID, diskID).Return(&repo.DiskContentSize{Files: 3, Bytes: 600}, nil)
+	svc := &diskService{r: mockRepo, quota: NewStorageService(storageRepo)}
+
+	t.Run("clone over quota", func(t *testing.T) {
+		_, err := svc.Clone(context.Background(), projectID, diskID, &userID)
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+		mockRepo.AssertNotCalled(t, "Clone", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("restore over quota", func(t *testing.T) {
+		mockRepo.On("GetSnapshot", mock.Anything, projectID, diskID, snapshotID).
+			Return(&model.DiskSnapshot{ID: snapshotID, DiskID: diskID, ArtifactCount: 5, SizeB: 1100}, nil).Once()
+
+		_, err := svc.RestoreSnapshot(context.Background(), projectID, diskID, snapshotID)
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+		mockRepo.AssertNotCalled(t, "RestoreSnapshot", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("restore within quota", func(t *testing.T) {
+		// Only the 400 bytes the snapshot adds are checked
+		mockRepo.On("GetSnapshot", mock.Anything, projectID, diskID, snapshotID).
+			Return(&model.DiskSnapshot{ID: snapshotID, DiskID: diskID, ArtifactCount: 5, SizeB: 1000}, nil).Once()
+		mockRepo.On("RestoreSnapshot", mock.Anything, projectID, diskID, snapshotID).
+			Return(&model.DiskSnapshot{ID: snapshotID, DiskID: diskID}, nil).Once()
+
+		_, err := svc.RestoreSnapshot(context.Background(), projectID, diskID, snapshotID)
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestDiskService_ListChanges(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	changes := []*model.DiskChange{
+		{DiskID: diskID, Seq: 4, Op: model.DiskChangeCreate, Path: "/", Filename: "a.md", Version: 1},
+		{DiskID: diskID, Seq: 5, Op: model.DiskChangeMove, Path: "/", Filename: "b.md", FromPath: "/", FromFilename: "a.md", Version: 1},
+		{DiskID: diskID, Seq: 6, Op: model.DiskChangeDelete, Path: "/", Filename: "b.md"},
+	}
+
+	t.Run("pages through the log", func(t *testing.T) {
+		mockRepo := &MockDiskRepo{}
+		mockRepo.On("ListChanges", mock.Anything, projectID, diskID, int64(3), 3).Return(changes, int64(6), nil)
+
+		service := newTestDiskService(mockRepo, &MockS3Deps{})
+		out, err := service.ListChanges(context.Background(), ListDiskChangesInput{ProjectID: projectID, DiskID: diskID, Since: 3, Limit: 2})
+
+		assert.NoError(t, err)
+		assert.Len(t, out.Items, 2)
+		assert.True(t, out.HasMore)
+		assert.Equal(t, int64(5), out.NextSince)
+		assert.Equal(t, int64(6), out.LatestSeq)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("caught up", func(t *testing.T) {
+		mockRepo := &MockDiskRepo{}
+		mockRepo.On("ListChanges", mock.Anything, projectID, diskID, int64(6), 11).Return(nil, int64(6), nil)
+
+		service := newTestDiskService(mockRepo, &MockS3Deps{})
+		out, err := service.ListChanges(context.Background(), ListDiskChangesInput{ProjectID: projectID, DiskID: diskID, Since: 6, Limit: 10})
+
+		assert.NoError(t, err)
+		// An empty list is returned instead of null, and since is kept
+		assert.NotNil(t, out.Items)
+		assert.Empty(t, out.Items)
+		assert.False(t, out.HasMore)
+		assert.Equal(t, int64(6), out.NextSince)
+		mockRepo.AssertNumberOfCalls(t, "ListChanges", 1)
+	})
+
+	t.Run("waits for a change", func(t *testing.T) {
+		mockRepo := &MockDiskRepo{}
+		mockRepo.On("ListChanges", mock.Anything, projectID, diskID, int64(6), 11).Return(nil, int64(6), nil).Twice()
+		mockRepo.On("ListChanges", mock.Anything, projectID, diskID, int64(6), 11).
+			Return([]*model.DiskChange{{DiskID: diskID, Seq: 7, Op: model.DiskChangeUpdate}}, int64(6), nil).Once()
+
+		service := newTestDiskService(mockRepo, &MockS3Deps{})
+		out, err := service.ListChanges(context.Background(), ListDiskChangesInput{ProjectID: projectID, DiskID: diskID, Since: 6, Limit: 10, Wait: time.Minute})
+
+		assert.NoError(t, err)
+		assert.Len(t, out.Items, 1)
+		assert.Equal(t, int64(7), out.NextSince)
+		// The change committed after the disk was read
+		assert.Equal(t, int64(7), out.LatestSeq)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("wait is over", func(t *testing.T) {
+		mockRepo := &MockDiskRepo{}
+		mockRepo.On("ListChanges", mock.Anything, projectID, diskID, int64(6), 11).Return(nil, int64(6), nil)
+
+		service := newTestDiskService(mockRepo, &MockS3Deps{})
+		out, err := service.ListChanges(context.Background(), ListDiskChangesInput{ProjectID: projectID, DiskID: diskID, Since: 6, Limit: 10, Wait: 20 * time.Millisecond})
+
+		assert.NoError(t, err)
+		assert.Empty(t, out.Items)
+		assert.Greater(t, len(mockRepo.Calls), 1)
+	})
+
+	t.Run("request canceled while waiting", func(t *testing.T) {
+		mockRepo := &MockDiskRepo{}
+		mockRepo.On("ListChanges", mock.Anything, projectID, diskID, int64(6), 11).Return(nil, int64(6), nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		service := newTestDiskService(mockRepo, &MockS3Deps{})
+		_, err := service.ListChanges(ctx, ListDiskChangesInput{ProjectID: projectID, DiskID: diskID, Since: 6, Limit: 10, Wait: time.Minute})
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("unknown disk", func(t *testing.T) {
+		mockRepo := &MockDiskRepo{}
+		mockRepo.On("ListChanges", mock.Anything, projectID, diskID, int64(0), 11).Return(nil, int64(0), gorm.ErrRecordNotFound)
+
+		service := newTestDiskService(mockRepo, &MockS3Deps{})
+		_, err := service.ListChanges(context.Background(), ListDiskChangesInput{ProjectID: projectID, DiskID: diskID, Limit: 10, Wait: time.Minute})
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
@@ -2,10 +2,13 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"mime"
 	"mime/multipart"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/bytedance/sonic"
@@ -34,13 +37,18 @@ type SessionService interface {
 	GetMessages(ctx context.Context, in GetMessagesInput) (*GetMessagesOutput, error)
 	GetAllMessages(ctx context.Context, sessionID uuid.UUID) ([]model.Message, error)
 	GetSessionObservingStatus(ctx context.Context, sessionID string) (*model.MessageObservingStatus, error)
+	SetSystemPrompt(ctx context.Context, in SetSystemPromptInput) (*model.SessionSystemPrompt, error)
+	GetSystemPrompt(ctx context.Context, sessionID uuid.UUID, version int) (*model.SessionSystemPrompt, error)
+	ListSystemPrompts(ctx context.Context, sessionID uuid.UUID) ([]model.SessionSystemPrompt, error)
+	ResolveSystemPrompt(ctx context.Context, sessionID uuid.UUID) (string, error)
 }
 
 type sessionService struct {
 	sessionRepo        repo.SessionRepo
 	assetReferenceRepo repo.AssetReferenceRepo
+	blockRepo          repo.BlockRepo
 	log                *zap.Logger
-	s3                 *blob.S3Deps
+	s3                 blob.BlobStore
 	publisher          *mq.Publisher
 	cfg                *config.Config
 	redis              *redis.Client
@@ -53,10 +61,11 @@ const (
 	defaultPartsCacheTTL = time.Hour
 )
 
-func NewSessionService(sessionRepo repo.SessionRepo, assetReferenceRepo repo.AssetReferenceRepo, log *zap.Logger, s3 *blob.S3Deps, publisher *mq.Publisher, cfg *config.Config, redis *redis.Client) SessionService {
+func NewSessionService(sessionRepo repo.SessionRepo, assetReferenceRepo repo.AssetReferenceRepo, blockRepo repo.BlockRepo, log *zap.Logger, s3 blob.BlobStore, publisher *mq.Publisher, cfg *config.Config, redis *redis.Client) SessionService {
 	return &sessionService{
 		sessionRepo:        sessionRepo,
 		assetReferenceRepo: assetReferenceRepo,
+		blockRepo:          blockRepo,
 		log:                log,
 		s3:                 s3,
 		publisher:          publisher,
@@ -145,7 +154,7 @@ type StoreMessageInput struct {
 	SessionID   uuid.UUID
 	Role        string
 	Parts       []PartIn
-	Format      model.MessageFormat    // Message format (acontext, openai, anthropic, gemini)
+	Format      model.MessageFormat    // Message format (acontext, openai, anthropic, gemini, bedrock)
 	MessageMeta map[string]interface{} // Message-level metadata (e.g., name, source_format)
 	Files       map[string]*multipart.FileHeader
 }
@@ -157,10 +166,11 @@ type StoreMQPublishJSON struct {
 }
 
 type PartIn struct {
-	Type      string                 `json:"type" validate:"required,oneof=text image audio video file tool-call tool-result data"` // "text" | "image" | ...
-	Text      string                 `json:"text,omitempty"`                                                                        // Text sharding
-	FileField string                 `json:"file_field,omitempty"`                                                                  // File field name in the form
-	Meta      map[string]interface{} `json:"meta,omitempty"`                                                                        // [Optional] metadata
+	Type      string                 `json:"type" validate:"required,oneof=text image audio video file tool-call tool-result thinking data"` // "text" | "image" | ...
+	Text      string                 `json:"text,omitempty"`                                                                                 // Text sharding
+	FileField string                 `json:"file_field,omitempty"`                                                                           // File field name in the form
+	Meta      map[string]interface{} `json:"meta,omitempty"`                                                                                 // [Optional] metadata
+	Content   []PartIn               `json:"content,omitempty"`                                                                              // [Optional] nested content of a tool-result part
 }
 
 func (p *PartIn) Validate() error {
@@ -171,6 +181,10 @@ func (p *PartIn) Validate() error {
 		return err
 	}
 
+	if len(p.Content) > 0 && p.Type != "tool-result" {
+		return errors.New("only tool-result parts can have nested content")
+	}
+
 	// Validate required fields based on different types
 	switch p.Type {
 	case "text":
@@ -198,6 +212,23 @@ func (p *PartIn) Validate() error {
 		if _, hasToolCallID := p.Meta["tool_call_id"]; !hasToolCallID {
 			return errors.New("tool-result part requires 'tool_call_id' in meta")
 		}
+		// Nested content may only hold text, image and file parts, one level deep
+		for i := range p.Content {
+			nested := &p.Content[i]
+			if nested.Type != "text" && nested.Type != "image" && nested.Type != "file" {
+				return fmt.Errorf("tool-result content[%d]: type must be one of text, image, file", i)
+			}
+			if err := nested.Validate(); err != nil {
+				return fmt.Errorf("tool-result content[%d]: %w", i, err)
+			}
+		}
+	case "thinking":
+		// Redacted or encrypted reasoning may have no readable text, but must then carry its payload
+		if p.Text == "" {
+			if p.Meta == nil || p.Meta["data"] == nil {
+				return errors.New("thinking part requires non-empty text or 'data' in meta")
+			}
+		}
 	case "data":
 		if p.Meta == nil {
 			return errors.New("data part requires meta field")
@@ -290,40 +321,16 @@ func (s *sessionService) StoreMessage(ctx context.Context, in StoreMessageInput)
 			}
 		}
 
-		part := model.Part{
-			Type: partIn.Type,
-			Meta: partIn.Meta,
-		}
-
-		if partIn.FileField != "" {
-			fh, ok := in.Files[partIn.FileField]
-			if !ok || fh == nil {
-				return nil, fmt.Errorf("parts[%d]: missing uploaded file %s", idx, partIn.FileField)
-			}
-
-			// upload asset to S3
-			asset, err := s.s3.UploadFormFile(ctx, "assets/"+in.ProjectID.String(), fh)
-			if err != nil {
-				return nil, fmt.Errorf("upload %s failed: %w", partIn.FileField, err)
-			}
-
-			if err := s.assetReferenceRepo.IncrementAssetRef(ctx, in.ProjectID, *asset); err != nil {
-				return nil, fmt.Errorf("increment asset reference: %w", err)
-			}
-
-			part.Asset = asset
-			part.Filename = fh.Filename
-		}
-
-		if partIn.Text != "" {
-			part.Text = partIn.Text
+		part, err := s.buildPart(ctx, in, partIn, fmt.Sprintf("parts[%d]", idx))
+		if err != nil {
+			return nil, err
 		}
 
 		parts = append(parts, part)
 	}
 
 	// upload parts to S3 as JSON file
-	asset, err := s.s3.UploadJSON(ctx, "parts/"+in.ProjectID.String(), parts)
+	asset, err := s.s3.UploadJSON(ctx, "parts/"+in.ProjectID.String(), parts, reuseAssets(s.assetReferenceRepo, in.ProjectID))
 	if err != nil {
 		return nil, fmt.Errorf("upload parts to S3 failed: %w", err)
 	}
@@ -377,6 +384,141 @@ func (s *sessionService) StoreMessage(ctx context.Context, in StoreMessageInput)
 	return &msg, nil
 }
 
+// buildPart converts an incoming part into a stored part, uploading its files to S3.
+// Inline base64 media of image and file parts is moved to S3 assets as well, so that parts JSON stays small
+// and identical payloads are deduplicated. Nested tool-result content is built the same way.
+func (s *sessionService) buildPart(ctx context.Context, in StoreMessageInput, partIn *PartIn, path string) (model.Part, error) {
+	part := model.Part{
+		Type: partIn.Type,
+		Meta: partIn.Meta,
+	}
+
+	if partIn.FileField != "" {
+		fh, ok := in.Files[partIn.FileField]
+		if !ok || fh == nil {
+			return model.Part{}, fmt.Errorf("%s: missing uploaded file %s", path, partIn.FileField)
+		}
+
+		// upload asset to S3
+		asset, err := s.s3.UploadFormFile(ctx, "assets/"+in.ProjectID.String(), fh, reuseAssets(s.assetReferenceRepo, in.ProjectID))
+		if err != nil {
+			return model.Part{}, fmt.Errorf("upload %s failed: %w", partIn.FileField, err)
+		}
+
+		if err := s.assetReferenceRepo.IncrementAssetRef(ctx, in.ProjectID, *asset); err != nil {
+			return model.Part{}, fmt.Errorf("increment asset reference: %w", err)
+		}
+
+		part.Asset = asset
+		part.Filename = fh.Filename
+	}
+
+	if part.Asset == nil && (part.Type == "image" || part.Type == "file") {
+		if err := s.uploadInlineMedia(ctx, in.ProjectID, &part); err != nil {
+			return model.Part{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if partIn.Text != "" {
+		part.Text = partIn.Text
+	}
+
+	for i := range partIn.Content {
+		nested, err := s.buildPart(ctx, in, &partIn.Content[i], fmt.Sprintf("%s.content[%d]", path, i))
+		if err != nil {
+			return model.Part{}, err
+		}
+		part.Content = append(part.Content, nested)
+	}
+
+	return part, nil
+}
+
+// uploadInlineMedia moves base64 media carried in a part's meta into an S3 asset,
+// so that large payloads such as screenshots are not stored in the parts JSON.
+// Parts without inline media are left unchanged.
+func (s *sessionService) uploadInlineMedia(ctx context.Context, projectID uuid.UUID, part *model.Part) error {
+	data, mediaType, ok, err := extractInlineMedia(part.Meta)
+	if err != nil || !ok {
+		return err
+	}
+	if s.s3 == nil {
+		return errors.New("blob storage is not available for inline media")
+	}
+
+	filename, _ := part.Meta["filename"].(string)
+	objectName := filename
+	if objectName == "" {
+		// Give the object an extension so it can be served with the right content type
+		objectName = part.Type
+		if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+			objectName += exts[0]
+		}
+	}
+	asset, err := s.s3.UploadBytes(ctx, "assets/"+projectID.String(), objectName, data, reuseAssets(s.assetReferenceRepo, projectID))
+	if err != nil {
+		return fmt.Errorf("upload inline media: %w", err)
+	}
+	if mediaType != "" {
+		asset.MIME = mediaType
+	}
+
+	if err := s.assetReferenceRepo.IncrementAssetRef(ctx, projectID, *asset); err != nil {
+		return fmt.Errorf("increment asset reference: %w", err)
+	}
+
+	part.Asset = asset
+	part.Filename = filename
+	return nil
+}
+
+// extractInlineMedia decodes base64 media from a part's meta and removes the inline copy.
+// Supported forms: {"type": "base64", "data": ...} and data URLs in "url" or "file_data".
+// ok is false if the meta carries no inline media.
+func extractInlineMedia(meta map[string]interface{}) (data []byte, mediaType string, ok bool, err error) {
+	if meta == nil {
+		return nil, "", false, nil
+	}
+	mediaType, _ = meta["media_type"].(string)
+
+	if sourceType, _ := meta["type"].(string); sourceType == "base64" {
+		encoded, _ := meta["data"].(string)
+		if encoded == "" {
+			return nil, "", false, nil
+		}
+		data, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, "", false, fmt.Errorf("decode base64 data: %w", err)
+		}
+		delete(meta, "type")
+		delete(meta, "data")
+		return data, mediaType, true, nil
+	}
+
+	for _, key := range []string{"url", "file_data"} {
+		dataURL, _ := meta[key].(string)
+		if !strings.HasPrefix(dataURL, "data:") {
+			continue
+		}
+		header, encoded, found := strings.Cut(dataURL, ",")
+		if !found || !strings.HasSuffix(header, ";base64") {
+			return nil, "", false, fmt.Errorf("invalid data URL in meta %q", key)
+		}
+		data, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, "", false, fmt.Errorf("decode data URL: %w", err)
+		}
+		if mediaType == "" {
+			mediaType = strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64")
+			meta["media_type"] = mediaType
+		}
+		delete(meta, key)
+		return data, mediaType, true, nil
+	}
+
+	return nil, "", false, nil
+}
+
 type GetMessagesInput struct {
 	SessionID                     uuid.UUID               `json:"session_id"`
 	Limit                         int                     `json:"limit"`
@@ -386,6 +528,7 @@ type GetMessagesInput struct {
 	TimeDesc                      bool                    `json:"time_desc"`
 	EditStrategies                []editor.StrategyConfig `json:"edit_strategies,omitempty"`
 	PinEditingStrategiesAtMessage string                  `json:"pin_editing_strategies_at_message,omitempty"`
+	WithSystemPrompt              bool                    `json:"with_system_prompt"`
 }
 
 type PublicURL struct {
@@ -399,6 +542,7 @@ type GetMessagesOutput struct {
 	HasMore         bool                 `json:"has_more"`
 	PublicURLs      map[string]PublicURL `json:"public_urls,omitempty"` // file_name -> url
 	EditAtMessageID string               `json:"edit_at_message_id,omitempty"`
+	SystemPrompt    string               `json:"system_prompt,omitempty"`
 }
 
 func (s *sessionService) GetMessages(ctx context.Context, in GetMessagesInput) (*GetMessagesOutput, error) {
@@ -479,24 +623,109 @@ func (s *sessionService) GetMessages(ctx context.Context, in GetMessagesInput) (
 		out.PublicURLs = make(map[string]PublicURL)
 		for _, m := range out.Items {
 			for _, p := range m.Parts {
-				if p.Asset == nil {
-					continue
-				}
-				url, err := s.s3.PresignGet(ctx, p.Asset.S3Key, in.AssetExpire)
-				if err != nil {
-					return nil, fmt.Errorf("get presigned url for asset %s: %w", p.Asset.S3Key, err)
-				}
-				out.PublicURLs[p.Asset.SHA256] = PublicURL{
-					URL:      url,
-					ExpireAt: time.Now().Add(in.AssetExpire),
+				for _, asset := range p.Assets() {
+					url, err := s.s3.PresignGet(ctx, asset.S3Key, in.AssetExpire)
+					if err != nil {
+						return nil, fmt.Errorf("get presigned url for asset %s: %w", asset.S3Key, err)
+					}
+					out.PublicURLs[asset.SHA256] = PublicURL{
+						URL:      url,
+						ExpireAt: time.Now().Add(in.AssetExpire),
+					}
 				}
 			}
 		}
 	}
 
+	if in.WithSystemPrompt {
+		out.SystemPrompt, err = s.ResolveSystemPrompt(ctx, in.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("resolve system prompt: %w", err)
+		}
+	}
+
 	return out, nil
 }
 
+type SetSystemPromptInput struct {
+	SessionID          uuid.UUID `json:"session_id"`
+	Content            string    `json:"content"`
+	AppendSpaceUseWhen bool      `json:"append_space_use_when"`
+}
+
+// SetSystemPrompt stores a new system prompt version for the session; earlier versions are kept.
+func (s *sessionService) SetSystemPrompt(ctx context.Context, in SetSystemPromptInput) (*model.SessionSystemPrompt, error) {
+	p := &model.SessionSystemPrompt{
+		SessionID:          in.SessionID,
+		Content:            in.Content,
+		AppendSpaceUseWhen: in.AppendSpaceUseWhen,
+	}
+	if err := s.sessionRepo.CreateSystemPrompt(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetSystemPrompt returns the given system prompt version, or the active one if version <= 0.
+func (s *sessionService) GetSystemPrompt(ctx context.Context, sessionID uuid.UUID, version int) (*model.SessionSystemPrompt, error) {
+	return s.sessionRepo.GetSystemPrompt(ctx, sessionID, version)
+}
+
+func (s *sessionService) ListSystemPrompts(ctx context.Context, sessionID uuid.UUID) ([]model.SessionSystemPrompt, error) {
+	return s.sessionRepo.ListSystemPrompts(ctx, sessionID)
+}
+
+// ResolveSystemPrompt renders the active system prompt of a session.
+// If the prompt asks for it, the use_when conditions of the SOPs in the session's space are appended.
+// Returns "" if the session has no system prompt.
+func (s *sessionService) ResolveSystemPrompt(ctx context.Context, sessionID uuid.UUID) (string, error) {
+	p, err := s.sessionRepo.GetSystemPrompt(ctx, sessionID, 0)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	if !p.AppendSpaceUseWhen || s.blockRepo == nil {
+		return p.Content, nil
+	}
+
+	session, err := s.sessionRepo.Get(ctx, &model.Session{ID: sessionID})
+	if err != nil {
+		return "", err
+	}
+	if session.SpaceID == nil {
+		return p.Content, nil
+	}
+
+	useWhen, err := s.blockRepo.ListSOPUseWhen(ctx, *session.SpaceID)
+	if err != nil {
+		return "", fmt.Errorf("list space use_when: %w", err)
+	}
+
+	return appendSpaceUseWhen(p.Content, useWhen), nil
+}
+
+// appendSpaceUseWhen appends the space's SOP use_when conditions to a system prompt as a bullet list
+func appendSpaceUseWhen(prompt string, useWhen []string) string {
+	if len(useWhen) == 0 {
+		return prompt
+	}
+
+	var b strings.Builder
+	if prompt != "" {
+		b.WriteString(prompt)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("You have learned SOPs for the following situations. Look up the matching SOP before acting:")
+	for _, condition := range useWhen {
+		b.WriteString("\n- ")
+		b.WriteString(condition)
+	}
+	return b.String()
+}
+
 // cachePartsInRedis stores message parts in Redis with a fixed TTL
 func (s *sessionService) cachePartsInRedis(ctx context.Context, sha256 string, parts []model.Part) error {
 	if s.redis == nil {
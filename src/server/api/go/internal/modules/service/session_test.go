@@ -10,9 +10,11 @@ import (
 	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/config"
 	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // MockSessionRepo is a mock implementation of SessionRepo
@@ -90,6 +92,27 @@ func (m *MockSessionRepo) PopGeminiCallIDAndName(ctx context.Context, sessionID
 	return args.String(0), args.String(1), args.Error(2)
 }
 
+func (m *MockSessionRepo) CreateSystemPrompt(ctx context.Context, p *model.SessionSystemPrompt) error {
+	args := m.Called(ctx, p)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepo) GetSystemPrompt(ctx context.Context, sessionID uuid.UUID, version int) (*model.SessionSystemPrompt, error) {
+	args := m.Called(ctx, sessionID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SessionSystemPrompt), args.Error(1)
+}
+
+func (m *MockSessionRepo) ListSystemPrompts(ctx context.Context, sessionID uuid.UUID) ([]model.SessionSystemPrompt, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.SessionSystemPrompt), args.Error(1)
+}
+
 // MockAssetReferenceRepo is a mock implementation of AssetReferenceRepo
 type MockAssetReferenceRepo struct {
 	mock.Mock
@@ -115,6 +138,40 @@ func (m *MockAssetReferenceRepo) BatchDecrementAssetRefs(ctx context.Context, pr
 	return args.Error(0)
 }
 
+func (m *MockAssetReferenceRepo) ListOrphaned(ctx context.Context, before time.Time, limit int) ([]*model.AssetReference, error) {
+	args := m.Called(ctx, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.AssetReference), args.Error(1)
+}
+
+func (m *MockAssetReferenceRepo) DeleteOrphaned(ctx context.Context, id uuid.UUID, before time.Time) (bool, error) {
+	args := m.Called(ctx, id, before)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAssetReferenceRepo) UnreferencedKeys(ctx context.Context, keys []string) ([]string, error) {
+	args := m.Called(ctx, keys)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAssetReferenceRepo) ClaimAsset(ctx context.Context, projectID uuid.UUID, sha256 string) (*model.Asset, error) {
+	args := m.Called(ctx, projectID, sha256)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Asset), args.Error(1)
+}
+
+// WithTx returns the mock itself, so expectations hold inside transactions too
+func (m *MockAssetReferenceRepo) WithTx(tx *gorm.DB) repo.AssetReferenceRepo {
+	return m
+}
+
 // MockBlobService is a mock implementation of blob service
 type MockBlobService struct {
 	mock.Mock
@@ -207,7 +264,7 @@ func TestSessionService_Create(t *testing.T) {
 					},
 				},
 			}
-			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil)
+			service := NewSessionService(repo, mockAssetRefRepo, nil, logger, nil, nil, cfg, nil)
 
 			err := service.Create(ctx, tt.session)
 
@@ -285,7 +342,7 @@ func TestSessionService_Delete(t *testing.T) {
 					},
 				},
 			}
-			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil)
+			service := NewSessionService(repo, mockAssetRefRepo, nil, logger, nil, nil, cfg, nil)
 
 			err := service.Delete(ctx, tt.projectID, tt.sessionID)
 
@@ -370,7 +427,7 @@ func TestSessionService_GetByID(t *testing.T) {
 					},
 				},
 			}
-			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil)
+			service := NewSessionService(repo, mockAssetRefRepo, nil, logger, nil, nil, cfg, nil)
 
 			result, err := service.GetByID(ctx, tt.session)
 
@@ -442,7 +499,7 @@ func TestSessionService_UpdateByID(t *testing.T) {
 					},
 				},
 			}
-			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil)
+			service := NewSessionService(repo, mockAssetRefRepo, nil, logger, nil, nil, cfg, nil)
 
 			err := service.UpdateByID(ctx, tt.session)
 
@@ -580,7 +637,7 @@ func TestSessionService_List(t *testing.T) {
 					},
 				},
 			}
-			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil)
+			service := NewSessionService(repo, mockAssetRefRepo, nil, logger, nil, nil, cfg, nil)
 
 			result, err := service.List(ctx, tt.input)
 
@@ -683,6 +740,93 @@ func TestPartIn_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "tool-result part requires 'tool_call_id' in meta", // UNIFIED FORMAT
 		},
+		{
+			name: "valid tool-result part with nested content",
+			part: PartIn{
+				Type: "tool-result",
+				Meta: map[string]interface{}{
+					"tool_call_id": "call_123",
+				},
+				Content: []PartIn{
+					{Type: "text", Text: "Screenshot taken"},
+					{Type: "image", Meta: map[string]interface{}{"type": "base64", "media_type": "image/png", "data": "aGVsbG8="}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "tool-result nested content with unsupported type",
+			part: PartIn{
+				Type: "tool-result",
+				Meta: map[string]interface{}{
+					"tool_call_id": "call_123",
+				},
+				Content: []PartIn{
+					{Type: "tool-call", Meta: map[string]interface{}{"name": "f", "arguments": "{}"}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "tool-result content[0]: type must be one of text, image, file",
+		},
+		{
+			name: "tool-result nested content with invalid part",
+			part: PartIn{
+				Type: "tool-result",
+				Meta: map[string]interface{}{
+					"tool_call_id": "call_123",
+				},
+				Content: []PartIn{
+					{Type: "text"},
+				},
+			},
+			wantErr: true,
+			errMsg:  "tool-result content[0]: text part requires non-empty text field",
+		},
+		{
+			name: "nested content on non tool-result part",
+			part: PartIn{
+				Type:    "text",
+				Text:    "hello",
+				Content: []PartIn{{Type: "text", Text: "nested"}},
+			},
+			wantErr: true,
+			errMsg:  "only tool-result parts can have nested content",
+		},
+		{
+			name: "valid thinking part",
+			part: PartIn{
+				Type: "thinking",
+				Text: "Let me think about this",
+				Meta: map[string]interface{}{
+					"source_format": "anthropic",
+					"signature":     "sig_123",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid redacted thinking part",
+			part: PartIn{
+				Type: "thinking",
+				Meta: map[string]interface{}{
+					"source_format": "anthropic",
+					"redacted":      true,
+					"data":          "encrypted",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "thinking part without text or data",
+			part: PartIn{
+				Type: "thinking",
+				Meta: map[string]interface{}{
+					"source_format": "anthropic",
+				},
+			},
+			wantErr: true,
+			errMsg:  "thinking part requires non-empty text or 'data' in meta",
+		},
 		{
 			name: "valid data part",
 			part: PartIn{
@@ -730,6 +874,42 @@ func TestPartIn_Validate(t *testing.T) {
 	}
 }
 
+func TestExtractInlineMedia(t *testing.T) {
+	t.Run("base64 source", func(t *testing.T) {
+		meta := map[string]interface{}{"type": "base64", "media_type": "image/png", "data": "aGVsbG8=", "detail": "high"}
+		data, mediaType, ok, err := extractInlineMedia(meta)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("hello"), data)
+		assert.Equal(t, "image/png", mediaType)
+		// Inline payload is removed, other meta is kept
+		assert.Equal(t, map[string]interface{}{"media_type": "image/png", "detail": "high"}, meta)
+	})
+
+	t.Run("data URL", func(t *testing.T) {
+		meta := map[string]interface{}{"file_data": "data:application/pdf;base64,aGVsbG8=", "filename": "a.pdf"}
+		data, mediaType, ok, err := extractInlineMedia(meta)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("hello"), data)
+		assert.Equal(t, "application/pdf", mediaType)
+		assert.Equal(t, map[string]interface{}{"media_type": "application/pdf", "filename": "a.pdf"}, meta)
+	})
+
+	t.Run("remote URL is left alone", func(t *testing.T) {
+		meta := map[string]interface{}{"type": "url", "url": "https://example.com/a.png"}
+		_, _, ok, err := extractInlineMedia(meta)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, "https://example.com/a.png", meta["url"])
+	})
+
+	t.Run("invalid base64", func(t *testing.T) {
+		_, _, _, err := extractInlineMedia(map[string]interface{}{"type": "base64", "data": "%%%"})
+		assert.Error(t, err)
+	})
+}
+
 // TestSessionService_StoreMessage_GeminiFunctionResponse tests StoreMessage with Gemini function responses
 // Focuses on boundary cases for ID and name matching
 func TestSessionService_StoreMessage_GeminiFunctionResponse(t *testing.T) {
@@ -1080,7 +1260,7 @@ func TestSessionService_StoreMessage_GeminiFunctionResponse(t *testing.T) {
 			var service SessionService
 			if tt.wantErr {
 				// For error cases, we can use nil S3 since errors happen before S3 upload
-				service = NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil)
+				service = NewSessionService(repo, mockAssetRefRepo, nil, logger, nil, nil, cfg, nil)
 			} else {
 				// For success cases, we need to skip this test or use integration test
 				// For now, we'll mark these as skipped or use a workaround
@@ -1240,7 +1420,7 @@ func TestSessionService_GetMessages(t *testing.T) {
 				},
 			}
 			// Note: blob is nil in test, so GetMessages will skip DownloadJSON and PresignGet
-			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil)
+			service := NewSessionService(repo, mockAssetRefRepo, nil, logger, nil, nil, cfg, nil)
 
 			result, err := service.GetMessages(ctx, tt.input)
 
@@ -1400,7 +1580,7 @@ func TestSessionService_GetMessages_SortOrder(t *testing.T) {
 					},
 				},
 			}
-			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil)
+			service := NewSessionService(repo, mockAssetRefRepo, nil, logger, nil, nil, cfg, nil)
 
 			result, err := service.GetMessages(ctx, tt.input)
 
@@ -1434,3 +1614,102 @@ func TestSessionService_GetMessages_SortOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestSessionService_ResolveSystemPrompt(t *testing.T) {
+	ctx := context.Background()
+	sessionID := uuid.New()
+	spaceID := uuid.New()
+
+	tests := []struct {
+		name  string
+		setup func(*MockSessionRepo, *MockBlockRepo)
+		want  string
+	}{
+		{
+			name: "no system prompt",
+			setup: func(repo *MockSessionRepo, blockRepo *MockBlockRepo) {
+				repo.On("GetSystemPrompt", ctx, sessionID, 0).Return(nil, gorm.ErrRecordNotFound)
+			},
+			want: "",
+		},
+		{
+			name: "prompt without space use_when",
+			setup: func(repo *MockSessionRepo, blockRepo *MockBlockRepo) {
+				repo.On("GetSystemPrompt", ctx, sessionID, 0).Return(&model.SessionSystemPrompt{
+					SessionID: sessionID,
+					Version:   2,
+					Content:   "You are a helpful assistant.",
+				}, nil)
+			},
+			want: "You are a helpful assistant.",
+		},
+		{
+			name: "prompt with space use_when",
+			setup: func(repo *MockSessionRepo, blockRepo *MockBlockRepo) {
+				repo.On("GetSystemPrompt", ctx, sessionID, 0).Return(&model.SessionSystemPrompt{
+					SessionID:          sessionID,
+					Version:            1,
+					Content:            "You are a helpful assistant.",
+					AppendSpaceUseWhen: true,
+				}, nil)
+				repo.On("Get", ctx, mock.Anything).Return(&model.Session{ID: sessionID, SpaceID: &spaceID}, nil)
+				blockRepo.On("ListSOPUseWhen", ctx, spaceID).Return([]string{"star a repo on github.com"}, nil)
+			},
+			want: "You are a helpful assistant.\n\nYou have learned SOPs for the following situations. Look up the matching SOP before acting:\n- star a repo on github.com",
+		},
+		{
+			name: "append use_when but session not connected to a space",
+			setup: func(repo *MockSessionRepo, blockRepo *MockBlockRepo) {
+				repo.On("GetSystemPrompt", ctx, sessionID, 0).Return(&model.SessionSystemPrompt{
+					SessionID:          sessionID,
+					Version:            1,
+					Content:            "You are a helpful assistant.",
+					AppendSpaceUseWhen: true,
+				}, nil)
+				repo.On("Get", ctx, mock.Anything).Return(&model.Session{ID: sessionID}, nil)
+			},
+			want: "You are a helpful assistant.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockSessionRepo{}
+			blockRepo := &MockBlockRepo{}
+			tt.setup(repo, blockRepo)
+
+			service := NewSessionService(repo, &MockAssetReferenceRepo{}, blockRepo, zap.NewNop(), nil, nil, &config.Config{}, nil)
+
+			got, err := service.ResolveSystemPrompt(ctx, sessionID)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			repo.AssertExpectations(t)
+			blockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSessionService_SetSystemPrompt(t *testing.T) {
+	ctx := context.Background()
+	sessionID := uuid.New()
+
+	repo := &MockSessionRepo{}
+	repo.On("CreateSystemPrompt", ctx, mock.MatchedBy(func(p *model.SessionSystemPrompt) bool {
+		return p.SessionID == sessionID && p.Content == "Be concise." && p.AppendSpaceUseWhen
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*model.SessionSystemPrompt).Version = 3
+	}).Return(nil)
+
+	service := NewSessionService(repo, &MockAssetReferenceRepo{}, nil, zap.NewNop(), nil, nil, &config.Config{}, nil)
+
+	p, err := service.SetSystemPrompt(ctx, SetSystemPromptInput{
+		SessionID:          sessionID,
+		Content:            "Be concise.",
+		AppendSpaceUseWhen: true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, p.Version)
+	repo.AssertExpectations(t)
+}
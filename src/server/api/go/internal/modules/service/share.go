@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/path"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/tokens"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidShare   = errors.New("invalid share")
+	ErrShareNotFound  = errors.New("share not found")
+	ErrShareExpired   = errors.New("share has expired")
+	ErrShareExhausted = errors.New("share download limit reached")
+)
+
+const (
+	shareTokenBytes = 32
+
+	// shareDownloadExpire is how long the URL a shared file is redirected to stays valid. It is
+	// short because every download through the share is counted.
+	shareDownloadExpire = time.Minute
+)
+
+type ShareService interface {
+	Create(ctx context.Context, in CreateShareInput) (*CreateShareOutput, error)
+	List(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskShare, error)
+	Revoke(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, shareID uuid.UUID) error
+	Browse(ctx context.Context, token string, dir string) (*SharedListing, error)
+	Download(ctx context.Context, token string, filePath string) (*SharedDownload, error)
+}
+
+type shareService struct {
+	r         repo.ShareRepo
+	artifacts repo.ArtifactRepo
+	storage   repo.StorageRepo
+	pepper    string
+	presign   func(ctx context.Context, key string, expire time.Duration) (string, error)
+}
+
+func NewShareService(r repo.ShareRepo, artifacts repo.ArtifactRepo, storage repo.StorageRepo, s3 blob.BlobStore, pepper string) ShareService {
+	return &shareService{r: r, artifacts: artifacts, storage: storage, pepper: pepper, presign: s3.PresignGet}
+}
+
+type CreateShareInput struct {
+	ProjectID    uuid.UUID
+	DiskID       uuid.UUID
+	FilePath     string // A file, or a directory when it ends with /
+	Expire       time.Duration
+	MaxDownloads *int // nil for unlimited
+}
+
+type CreateShareOutput struct {
+	Share *model.DiskShare `json:"share"`
+	Token string           `json:"token"` // Only returned once; the share stores an HMAC of it
+}
+
+// Create mints a read-only share for a file or a directory of a disk. A shared file must exist;
+// a shared directory covers whatever is below it when the share is used.
+func (s *shareService) Create(ctx context.Context, in CreateShareInput) (*CreateShareOutput, error) {
+	if in.Expire <= 0 {
+		return nil, fmt.Errorf("%w: expire must be positive", ErrInvalidShare)
+	}
+	if in.MaxDownloads != nil && *in.MaxDownloads < 1 {
+		return nil, fmt.Errorf("%w: max_downloads must be at least 1", ErrInvalidShare)
+	}
+
+	dir, filename := path.SplitFilePath(in.FilePath)
+	if err := path.ValidatePath(dir); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidShare, err)
+	}
+	// Look the file up only on a disk of the project, so another project's files cannot be probed
+	if _, err := s.storage.GetDiskUserID(ctx, in.ProjectID, in.DiskID); err != nil {
+		return nil, err
+	}
+	if filename != "" {
+		if _, err := s.artifacts.GetByPath(ctx, in.DiskID, dir, filename); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		return nil, fmt.Errorf("generate share token: %w", err)
+	}
+
+	share := &model.DiskShare{
+		ProjectID:    in.ProjectID,
+		DiskID:       in.DiskID,
+		Path:         dir,
+		Filename:     filename,
+		Scope:        model.ShareScopeRead,
+		TokenHMAC:    tokens.HMAC256Hex(s.pepper, token),
+		MaxDownloads: in.MaxDownloads,
+		ExpiresAt:    time.Now().Add(in.Expire),
+	}
+	if err := s.r.Create(ctx, share); err != nil {
+		return nil, err
+	}
+	return &CreateShareOutput{Share: share, Token: token}, nil
+}
+
+func newShareToken() (string, error) {
+	b := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (s *shareService) List(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskShare, error) {
+	shares, err := s.r.List(ctx, projectID, diskID)
+	if err != nil {
+		return nil, err
+	}
+	if shares == nil {
+		shares = []*model.DiskShare{}
+	}
+	return shares, nil
+}
+
+// Revoke deletes a share; its token stops working immediately
+func (s *shareService) Revoke(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, shareID uuid.UUID) error {
+	return s.r.Delete(ctx, projectID, diskID, shareID)
+}
+
+// resolve finds the share of a token. Unknown and revoked tokens look the same.
+func (s *shareService) resolve(ctx context.Context, token string) (*model.DiskShare, error) {
+	if token == "" {
+		return nil, ErrShareNotFound
+	}
+	share, err := s.r.GetByTokenHMAC(ctx, tokens.HMAC256Hex(s.pepper, token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShareNotFound
+		}
+		return nil, err
+	}
+	if !time.Now().Before(share.ExpiresAt) {
+		return nil, ErrShareExpired
+	}
+	return share, nil
+}
+
+// SharedFile is a file as seen through a share. Paths are relative to the share, whose root is /.
+type SharedFile struct {
+	Path      string    `json:"path"`
+	Filename  string    `json:"filename"`
+	MIME      string    `json:"mime"`
+	SizeB     int64     `json:"size_b"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SharedListing struct {
+	Path               string       `json:"path"`
+	Files              []SharedFile `json:"files"`
+	Directories        []string     `json:"directories"`
+	ExpiresAt          time.Time    `json:"expires_at"`
+	RemainingDownloads *int         `json:"remaining_downloads"` // nil for unlimited
+}
+
+// Browse lists a directory of a share. A file share lists the shared file at its root.
+func (s *shareService) Browse(ctx context.Context, token string, dir string) (*SharedListing, error) {
+	share, err := s.resolve(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &SharedListing{
+		Path:        "/",
+		Files:       []SharedFile{},
+		Directories: []string{},
+		ExpiresAt:   share.ExpiresAt,
+	}
+	if share.MaxDownloads != nil {
+		remaining := max(*share.MaxDownloads-share.Downloads, 0)
+		out.RemainingDownloads = &remaining
+	}
+
+	if !share.IsDirectory() {
+		if dir != "" && dir != "/" {
+			return nil, gorm.ErrRecordNotFound
+		}
+		artifact, err := s.artifacts.GetByPath(ctx, share.DiskID, share.Path, share.Filename)
+		if err != nil {
+			return nil, err
+		}
+		out.Files = append(out.Files, sharedFile(share, artifact))
+		return out, nil
+	}
+
+	if dir != "" {
+		if !strings.HasSuffix(dir, "/") {
+			return nil, fmt.Errorf("%w: path must end with /", ErrInvalidShare)
+		}
+		if err := path.ValidatePath(dir); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidShare, err)
+		}
+		out.Path = "/" + strings.TrimPrefix(dir, "/")
+	}
+	diskDir := share.Path + strings.TrimPrefix(out.Path, "/")
+
+	artifacts, err := s.artifacts.ListByPath(ctx, share.DiskID, diskDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range artifacts {
+		out.Files = append(out.Files, sharedFile(share, a))
+	}
+	paths, err := s.artifacts.GetPathsUnder(ctx, share.DiskID, diskDir)
+	if err != nil {
+		return nil, err
+	}
+	out.Directories = append(out.Directories, path.GetDirectoriesFromPaths(diskDir, paths)...)
+	return out, nil
+}
+
+func sharedFile(share *model.DiskShare, a *model.Artifact) SharedFile {
+	asset := a.AssetMeta.Data()
+	return SharedFile{
+		Path:      "/" + strings.TrimPrefix(a.Path, share.Path),
+		Filename:  a.Filename,
+		MIME:      asset.MIME,
+		SizeB:     asset.SizeB,
+		UpdatedAt: a.UpdatedAt,
+	}
+}
+
+type SharedDownload struct {
+	Filename string
+	URL      string
+}
+
+// Download counts a download through a share and returns a short-lived URL of the file. filePath
+// is relative to the share and may be empty for a file share.
+func (s *shareService) Download(ctx context.Context, token string, filePath string) (*SharedDownload, error) {
+	share, err := s.resolve(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, filename := share.Path, share.Filename
+	if share.IsDirectory() {
+		rel, name := path.SplitFilePath(filePath)
+		if name == "" {
+			return nil, fmt.Errorf("%w: file_path must name a file", ErrInvalidShare)
+		}
+		if err := path.ValidatePath(rel); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidShare, err)
+		}
+		dir, filename = share.Path+strings.TrimPrefix(rel, "/"), name
+	} else if filePath != "" && strings.TrimPrefix(filePath, "/") != share.Filename {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	artifact, err := s.artifacts.GetByPath(ctx, share.DiskID, dir, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	counted, err := s.r.CountDownload(ctx, share.ID)
+	if err != nil {
+		return nil, fmt.Errorf("count share download: %w", err)
+	}
+	if !counted {
+		if !time.Now().Before(share.ExpiresAt) {
+			return nil, ErrShareExpired
+		}
+		return nil, ErrShareExhausted
+	}
+
+	url, err := s.presign(ctx, artifact.AssetMeta.Data().S3Key, shareDownloadExpire)
+	if err != nil {
+		return nil, fmt.Errorf("presign shared file: %w", err)
+	}
+	return &SharedDownload{Filename: artifact.Filename, URL: url}, nil
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// MockShareRepo is a mock implementation of ShareRepo
+type MockShareRepo struct {
+	mock.Mock
+}
+
+func (m *MockShareRepo) Create(ctx context.Context, s *model.DiskShare) error {
+	args := m.Called(ctx, s)
+	return args.Error(0)
+}
+
+func (m *MockShareRepo) List(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskShare, error) {
+	args := m.Called(ctx, projectID, diskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.DiskShare), args.Error(1)
+}
+
+func (m *MockShareRepo) Delete(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, shareID uuid.UUID) error {
+	args := m.Called(ctx, projectID, diskID, shareID)
+	return args.Error(0)
+}
+
+func (m *MockShareRepo) GetByTokenHMAC(ctx context.Context, tokenHMAC string) (*model.DiskShare, error) {
+	args := m.Called(ctx, tokenHMAC)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DiskShare), args.Error(1)
+}
+
+func (m *MockShareRepo) CountDownload(ctx context.Context, shareID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, shareID)
+	return args.Bool(0), args.Error(1)
+}
+
+const testPepper = "pepper"
+
+func newTestShareService(r *MockShareRepo, artifacts *MockArtifactRepo, storage *MockStorageRepo) *shareService {
+	return &shareService{
+		r:         r,
+		artifacts: artifacts,
+		storage:   storage,
+		pepper:    testPepper,
+		presign: func(ctx context.Context, key string, expire time.Duration) (string, error) {
+			return "https://s3.example.com/" + key, nil
+		},
+	}
+}
+
+func testArtifactAt(diskID uuid.UUID, dir string, filename string) *model.Artifact {
+	return &model.Artifact{
+		ID: uuid.New(), DiskID: diskID, Path: dir, Filename: filename,
+		AssetMeta: datatypes.NewJSONType(model.Asset{S3Key: "assets/" + filename, MIME: "text/markdown", SizeB: 42}),
+	}
+}
+
+func TestShareService_Create(t *testing.T) {
+	projectID := uuid.New()
+	otherProjectID := uuid.New()
+	diskID := uuid.New()
+	maxDownloads := 3
+
+	shareRepo := &MockShareRepo{}
+	artifactRepo := &MockArtifactRepo{}
+	artifactRepo.On("GetByPath", mock.Anything, diskID, "/reports/", "q3.md").Return(testArtifactAt(diskID, "/reports/", "q3.md"), nil)
+	artifactRepo.On("GetByPath", mock.Anything, diskID, "/reports/", "missing.md").Return(nil, gorm.ErrRecordNotFound)
+	shareRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.DiskShare")).Return(nil)
+	storageRepo := &MockStorageRepo{}
+	storageRepo.On("GetDiskUserID", mock.Anything, projectID, diskID).Return((*uuid.UUID)(nil), nil)
+	storageRepo.On("GetDiskUserID", mock.Anything, otherProjectID, diskID).Return((*uuid.UUID)(nil), gorm.ErrRecordNotFound)
+	svc := newTestShareService(shareRepo, artifactRepo, storageRepo)
+
+	out, err := svc.Create(context.Background(), CreateShareInput{
+		ProjectID: projectID, DiskID: diskID, FilePath: "/reports/q3.md",
+		Expire: time.Hour, MaxDownloads: &maxDownloads,
+	})
+	require.NoError(t, err)
+	assert.Len(t, out.Token, 43)
+	assert.Equal(t, tokens.HMAC256Hex(testPepper, out.Token), out.Share.TokenHMAC)
+	assert.Equal(t, "/reports/", out.Share.Path)
+	assert.Equal(t, "q3.md", out.Share.Filename)
+	assert.Equal(t, model.ShareScopeRead, out.Share.Scope)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), out.Share.ExpiresAt, time.Minute)
+
+	// A directory is shared without checking what is below it
+	out, err = svc.Create(context.Background(), CreateShareInput{ProjectID: projectID, DiskID: diskID, FilePath: "/reports/", Expire: time.Hour})
+	require.NoError(t, err)
+	assert.True(t, out.Share.IsDirectory())
+	assert.Nil(t, out.Share.MaxDownloads)
+
+	zero := 0
+	tests := []struct {
+		name        string
+		in          CreateShareInput
+		expectedErr error
+	}{
+		{name: "missing file", in: CreateShareInput{FilePath: "/reports/missing.md", Expire: time.Hour}, expectedErr: gorm.ErrRecordNotFound},
+		{name: "path traversal", in: CreateShareInput{FilePath: "/../secrets/", Expire: time.Hour}, expectedErr: ErrInvalidShare},
+		{name: "no expiry", in: CreateShareInput{FilePath: "/reports/"}, expectedErr: ErrInvalidShare},
+		{name: "zero downloads", in: CreateShareInput{FilePath: "/reports/", Expire: time.Hour, MaxDownloads: &zero}, expectedErr: ErrInvalidShare},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.ProjectID, tt.in.DiskID = projectID, diskID
+			_, err := svc.Create(context.Background(), tt.in)
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+
+	// A file on another project's disk is not looked up
+	_, err = svc.Create(context.Background(), CreateShareInput{ProjectID: otherProjectID, DiskID: diskID, FilePath: "/reports/q3.md", Expire: time.Hour})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	artifactRepo.AssertNumberOfCalls(t, "GetByPath", 2)
+	shareRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestShareService_Browse(t *testing.T) {
+	diskID := uuid.New()
+	maxDownloads := 5
+	dirShare := &model.DiskShare{ID: uuid.New(), DiskID: diskID, Path: "/reports/", ExpiresAt: time.Now().Add(time.Hour), MaxDownloads: &maxDownloads, Downloads: 2}
+	fileShare := &model.DiskShare{ID: uuid.New(), DiskID: diskID, Path: "/reports/", Filename: "q3.md", ExpiresAt: time.Now().Add(time.Hour)}
+	expired := &model.DiskShare{ID: uuid.New(), DiskID: diskID, Path: "/", ExpiresAt: time.Now().Add(-time.Minute)}
+
+	shareRepo := &MockShareRepo{}
+	shareRepo.On("GetByTokenHMAC", mock.Anything, tokens.HMAC256Hex(testPepper, "dir")).Return(dirShare, nil)
+	shareRepo.On("GetByTokenHMAC", mock.Anything, tokens.HMAC256Hex(testPepper, "file")).Return(fileShare, nil)
+	shareRepo.On("GetByTokenHMAC", mock.Anything, tokens.HMAC256Hex(testPepper, "expired")).Return(expired, nil)
+	shareRepo.On("GetByTokenHMAC", mock.Anything, tokens.HMAC256Hex(testPepper, "revoked")).Return(nil, gorm.ErrRecordNotFound)
+
+	artifactRepo := &MockArtifactRepo{}
+	artifactRepo.On("ListByPath", mock.Anything, diskID, "/reports/drafts/").Return([]*model.Artifact{testArtifactAt(diskID, "/reports/drafts/", "notes.md")}, nil)
+	artifactRepo.On("GetPathsUnder", mock.Anything, diskID, "/reports/drafts/").Return([]string{"/reports/drafts/", "/reports/drafts/old/"}, nil)
+	artifactRepo.On("GetByPath", mock.Anything, diskID, "/reports/", "q3.md").Return(testArtifactAt(diskID, "/reports/", "q3.md"), nil)
+	svc := newTestShareService(shareRepo, artifactRepo, &MockStorageRepo{})
+
+	listing, err := svc.Browse(context.Background(), "dir", "/drafts/")
+	require.NoError(t, err)
+	assert.Equal(t, "/drafts/", listing.Path)
+	require.Len(t, listing.Files, 1)
+	assert.Equal(t, SharedFile{Path: "/drafts/", Filename: "notes.md", MIME: "text/markdown", SizeB: 42}, listing.Files[0])
+	assert.Equal(t, []string{"old"}, listing.Directories)
+	require.NotNil(t, listing.RemainingDownloads)
+	assert.Equal(t, 3, *listing.RemainingDownloads)
+
+	listing, err = svc.Browse(context.Background(), "file", "")
+	require.NoError(t, err)
+	require.Len(t, listing.Files, 1)
+	assert.Equal(t, "/", listing.Files[0].Path)
+	assert.Equal(t, "q3.md", listing.Files[0].Filename)
+	assert.Nil(t, listing.RemainingDownloads)
+
+	_, err = svc.Browse(context.Background(), "file", "/other/")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = svc.Browse(context.Background(), "dir", "/../")
+	assert.ErrorIs(t, err, ErrInvalidShare)
+	_, err = svc.Browse(context.Background(), "expired", "")
+	assert.ErrorIs(t, err, ErrShareExpired)
+	_, err = svc.Browse(context.Background(), "revoked", "")
+	assert.ErrorIs(t, err, ErrShareNotFound)
+	_, err = svc.Browse(context.Background(), "", "")
+	assert.ErrorIs(t, err, ErrShareNotFound)
+}
+
+func TestShareService_Download(t *testing.T) {
+	diskID := uuid.New()
+	dirShare := &model.DiskShare{ID: uuid.New(), DiskID: diskID, Path: "/reports/", ExpiresAt: time.Now().Add(time.Hour)}
+	fileShare := &model.DiskShare{ID: uuid.New(), DiskID: diskID, Path: "/reports/", Filename: "q3.md", ExpiresAt: time.Now().Add(time.Hour)}
+
+	shareRepo := &MockShareRepo{}
+	shareRepo.On("GetByTokenHMAC", mock.Anything, tokens.HMAC256Hex(testPepper, "dir")).Return(dirShare, nil)
+	shareRepo.On("GetByTokenHMAC", mock.Anything, tokens.HMAC256Hex(testPepper, "file")).Return(fileShare, nil)
+	shareRepo.On("CountDownload", mock.Anything, dirShare.ID).Return(true, nil)
+	shareRepo.On("CountDownload", mock.Anything, fileShare.ID).Return(false, nil)
+
+	artifactRepo := &MockArtifactRepo{}
+	artifactRepo.On("GetByPath", mock.Anything, diskID, "/reports/drafts/", "notes.md").Return(testArtifactAt(diskID, "/reports/drafts/", "notes.md"), nil)
+	artifactRepo.On("GetByPath", mock.Anything, diskID, "/reports/", "q3.md").Return(testArtifactAt(diskID, "/reports/", "q3.md"), nil)
+	svc := newTestShareService(shareRepo, artifactRepo, &MockStorageRepo{})
+
+	out, err := svc.Download(context.Background(), "dir", "/drafts/notes.md")
+	require.NoError(t, err)
+	assert.Equal(t, "notes.md", out.Filename)
+	assert.Equal(t, "https://s3.example.com/assets/notes.md", out.URL)
+
+	_, err = svc.Download(context.Background(), "dir", "/drafts/")
+	assert.ErrorIs(t, err, ErrInvalidShare)
+	_, err = svc.Download(context.Background(), "dir", "/../secret.md")
+	assert.ErrorIs(t, err, ErrInvalidShare)
+
+	// A file share only serves its own file
+	_, err = svc.Download(context.Background(), "file", "/other.md")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = svc.Download(context.Background(), "file", "")
+	assert.ErrorIs(t, err, ErrShareExhausted)
+
+	svc.presign = func(ctx context.Context, key string, expire time.Duration) (string, error) {
+		return "", errors.New("s3 down")
+	}
+	_, err = svc.Download(context.Background(), "dir", "/drafts/notes.md")
+	assert.ErrorContains(t, err, "s3 down")
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+)
+
+// ErrQuotaExceeded is returned when a write would take a project, user or disk over its storage quota
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+type StorageService interface {
+	CheckQuota(ctx context.Context, in CheckQuotaInput) error
+	GetUsage(ctx context.Context, projectID uuid.UUID) (*StorageUsageOutput, error)
+	ListQuotas(ctx context.Context, projectID uuid.UUID) ([]*model.StorageQuota, error)
+	SetQuota(ctx context.Context, in SetQuotaInput) (*model.StorageQuota, error)
+}
+
+type storageService struct{ r repo.StorageRepo }
+
+func NewStorageService(r repo.StorageRepo) StorageService {
+	return &storageService{r: r}
+}
+
+type CheckQuotaInput struct {
+	ProjectID uuid.UUID
+	UserID    *uuid.UUID // Owner of what is written; taken from the disk when DiskID is set
+	DiskID    *uuid.UUID
+	Bytes     int64 // Bytes added, negative when content shrinks
+	Files     int64 // Files added
+}
+
+// CheckQuota returns an error wrapping ErrQuotaExceeded when adding Bytes and Files would exceed
+// a quota of the project, the user or the disk. Writes that do not grow storage always pass.
+// The check is not atomic with the write, so concurrent uploads may overshoot a quota slightly.
+func (s *storageService) CheckQuota(ctx context.Context, in CheckQuotaInput) error {
+	if in.Bytes <= 0 && in.Files <= 0 {
+		return nil
+	}
+
+	userID := in.UserID
+	if in.DiskID != nil {
+		var err error
+		if userID, err = s.r.GetDiskUserID(ctx, in.ProjectID, *in.DiskID); err != nil {
+			return err
+		}
+	}
+
+	quotas, err := s.r.GetQuotas(ctx, in.ProjectID, userID, in.DiskID)
+	if err != nil {
+		return fmt.Errorf("get storage quotas: %w", err)
+	}
+
+	// The usage of every scope is computed together, and only when a quota limits this write
+	var usages map[string]*repo.StorageUsage
+	for _, q := range quotas {
+		if (q.MaxBytes == nil || in.Bytes <= 0) && (q.MaxFiles == nil || in.Files <= 0) {
+			continue
+		}
+
+		if usages == nil {
+			if usages, err = s.r.GetQuotaUsage(ctx, in.ProjectID, userID, in.DiskID); err != nil {
+				return fmt.Errorf("get storage usage: %w", err)
+			}
+		}
+		usage := usages[q.Scope]
+		if usage == nil {
+			usage = &repo.StorageUsage{}
+		}
+		if q.MaxBytes != nil && in.Bytes > 0 && usage.RawBytes+in.Bytes > *q.MaxBytes {
+			return fmt.Errorf("%w: %s stores %d of %d bytes, %d more requested", ErrQuotaExceeded, q.Scope, usage.RawBytes, *q.MaxBytes, in.Bytes)
+		}
+		if q.MaxFiles != nil && in.Files > 0 && usage.Files+in.Files > *q.MaxFiles {
+			return fmt.Errorf("%w: %s stores %d of %d files, %d more requested", ErrQuotaExceeded, q.Scope, usage.Files, *q.MaxFiles, in.Files)
+		}
+	}
+	return nil
+}
+
+type DiskStorageUsage struct {
+	DiskID uuid.UUID `json:"disk_id"`
+	repo.StorageUsage
+}
+
+type UserStorageUsage struct {
+	UserID     uuid.UUID `json:"user_id"`
+	Identifier string    `json:"identifier"`
+	repo.StorageUsage
+}
+
+type StorageUsageOutput struct {
+	Project repo.StorageUsage     `json:"project"`
+	Disks   []DiskStorageUsage    `json:"disks"`
+	Users   []UserStorageUsage    `json:"users"`
+	Quotas  []*model.StorageQuota `json:"quotas"`
+}
+
+// GetUsage reports what the project stores in total, per disk and per user, with its quotas
+func (s *storageService) GetUsage(ctx context.Context, projectID uuid.UUID) (*StorageUsageOutput, error) {
+	project, err := s.r.GetUsage(ctx, projectID, model.QuotaScopeProject, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("get project storage usage: %w", err)
+	}
+	disks, err := s.r.ListUsage(ctx, projectID, model.QuotaScopeDisk)
+	if err != nil {
+		return nil, fmt.Errorf("list disk storage usage: %w", err)
+	}
+	users, err := s.r.ListUsage(ctx, projectID, model.QuotaScopeUser)
+	if err != nil {
+		return nil, fmt.Errorf("list user storage usage: %w", err)
+	}
+	quotas, err := s.ListQuotas(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &StorageUsageOutput{
+		Project: *project,
+		Disks:   make([]DiskStorageUsage, 0, len(disks)),
+		Users:   make([]UserStorageUsage, 0, len(users)),
+		Quotas:  quotas,
+	}
+	for _, d := range disks {
+		out.Disks = append(out.Disks, DiskStorageUsage{DiskID: d.ScopeID, StorageUsage: *d})
+	}
+	for _, u := range users {
+		out.Users = append(out.Users, UserStorageUsage{UserID: u.ScopeID, Identifier: u.Identifier, StorageUsage: *u})
+	}
+	return out, nil
+}
+
+func (s *storageService) ListQuotas(ctx context.Context, projectID uuid.UUID) ([]*model.StorageQuota, error) {
+	quotas, err := s.r.ListQuotas(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("list storage quotas: %w", err)
+	}
+	if quotas == nil {
+		quotas = []*model.StorageQuota{}
+	}
+	return quotas, nil
+}
+
+type SetQuotaInput struct {
+	ProjectID uuid.UUID
+	Scope     string    // project, user or disk
+	ScopeID   uuid.UUID // Project, user or disk ID
+	MaxBytes  *int64    // Nil for unlimited
+	MaxFiles  *int64    // Nil for unlimited
+}
+
+// SetQuota sets the limits of a scope. Clearing both limits removes the quota and returns nil.
+func (s *storageService) SetQuota(ctx context.Context, in SetQuotaInput) (*model.StorageQuota, error) {
+	switch in.Scope {
+	case model.QuotaScopeProject, model.QuotaScopeUser, model.QuotaScopeDisk:
+	default:
+		return nil, fmt.Errorf("unknown storage quota scope %q", in.Scope)
+	}
+	if (in.MaxBytes != nil && *in.MaxBytes < 0) || (in.MaxFiles != nil && *in.MaxFiles < 0) {
+		return nil, errors.New("storage quota must not be negative")
+	}
+
+	if in.Scope == model.QuotaScopeDisk {
+		// Make sure the disk belongs to the project
+		if _, err := s.r.GetDiskUserID(ctx, in.ProjectID, in.ScopeID); err != nil {
+			return nil, err
+		}
+	}
+
+	if in.MaxBytes == nil && in.MaxFiles == nil {
+		if err := s.r.DeleteQuota(ctx, in.ProjectID, in.Scope, in.ScopeID); err != nil {
+			return nil, fmt.Errorf("delete storage quota: %w", err)
+		}
+		return nil, nil
+	}
+
+	q := &model.StorageQuota{
+		ProjectID: in.ProjectID,
+		Scope:     in.Scope,
+		ScopeID:   in.ScopeID,
+		MaxBytes:  in.MaxBytes,
+		MaxFiles:  in.MaxFiles,
+	}
+	if err := s.r.UpsertQuota(ctx, q); err != nil {
+		return nil, fmt.Errorf("set storage quota: %w", err)
+	}
+	return q, nil
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// MockStorageRepo is a mock implementation of StorageRepo
+type MockStorageRepo struct {
+	mock.Mock
+}
+
+func (m *MockStorageRepo) ListQuotas(ctx context.Context, projectID uuid.UUID) ([]*model.StorageQuota, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.StorageQuota), args.Error(1)
+}
+
+func (m *MockStorageRepo) GetQuotas(ctx context.Context, projectID uuid.UUID, userID *uuid.UUID, diskID *uuid.UUID) ([]*model.StorageQuota, error) {
+	args := m.Called(ctx, projectID, userID, diskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.StorageQuota), args.Error(1)
+}
+
+func (m *MockStorageRepo) UpsertQuota(ctx context.Context, q *model.StorageQuota) error {
+	args := m.Called(ctx, q)
+	return args.Error(0)
+}
+
+func (m *MockStorageRepo) DeleteQuota(ctx context.Context, projectID uuid.UUID, scope string, scopeID uuid.UUID) error {
+	args := m.Called(ctx, projectID, scope, scopeID)
+	return args.Error(0)
+}
+
+func (m *MockStorageRepo) GetDiskUserID(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*uuid.UUID, error) {
+	args := m.Called(ctx, projectID, diskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*uuid.UUID), args.Error(1)
+}
+
+func (m *MockStorageRepo) GetUsage(ctx context.Context, projectID uuid.UUID, scope string, scopeID uuid.UUID) (*repo.StorageUsage, error) {
+	args := m.Called(ctx, projectID, scope, scopeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repo.StorageUsage), args.Error(1)
+}
+
+func (m *MockStorageRepo) ListUsage(ctx context.Context, projectID uuid.UUID, scope string) ([]*repo.StorageUsage, error) {
+	args := m.Called(ctx, projectID, scope)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*repo.StorageUsage), args.Error(1)
+}
+
+func (m *MockStorageRepo) GetQuotaUsage(ctx context.Context, projectID uuid.UUID, userID *uuid.UUID, diskID *uuid.UUID) (map[string]*repo.StorageUsage, error) {
+	args := m.Called(ctx, projectID, userID, diskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*repo.StorageUsage), args.Error(1)
+}
+
+func int64Ptr(v int64) *int64 { return &v }
+
+func TestStorageService_CheckQuota(t *testing.T) {
+	projectID := uuid.New()
+	userID := uuid.New()
+	diskID := uuid.New()
+
+	tests := []struct {
+		name     string
+		in       CheckQuotaInput
+		setup    func(*MockStorageRepo)
+		exceeded bool
+	}{
+		{
+			name: "no quotas",
+			in:   CheckQuotaInput{ProjectID: projectID, DiskID: &diskID, Bytes: 100, Files: 1},
+			setup: func(r *MockStorageRepo) {
+				r.On("GetDiskUserID", mock.Anything, projectID, diskID).Return(&userID, nil)
+				r.On("GetQuotas", mock.Anything, projectID, &userID, &diskID).Return([]*model.StorageQuota{}, nil)
+			},
+		},
+		{
+			name: "within disk quota",
+			in:   CheckQuotaInput{ProjectID: projectID, DiskID: &diskID, Bytes: 100, Files: 1},
+			setup: func(r *MockStorageRepo) {
+				r.On("GetDiskUserID", mock.Anything, projectID, diskID).Return(&userID, nil)
+				r.On("GetQuotas", mock.Anything, projectID, &userID, &diskID).Return([]*model.StorageQuota{
+					{Scope: model.QuotaScopeDisk, ScopeID: diskID, MaxBytes: int64Ptr(1000), MaxFiles: int64Ptr(10)},
+				}, nil)
+				r.On("GetQuotaUsage", mock.Anything, projectID, &userID, &diskID).Return(map[string]*repo.StorageUsage{
+					model.QuotaScopeDisk: {Files: 9, RawBytes: 900},
+				}, nil)
+			},
+		},
+		{
+			name: "exceeds user bytes",
+			in:   CheckQuotaInput{ProjectID: projectID, DiskID: &diskID, Bytes: 101, Files: 1},
+			setup: func(r *MockStorageRepo) {
+				r.On("GetDiskUserID", mock.Anything, projectID, diskID).Return(&userID, nil)
+				r.On("GetQuotas", mock.Anything, projectID, &userID, &diskID).Return([]*model.StorageQuota{
+					{Scope: model.QuotaScopeUser, ScopeID: userID, MaxBytes: int64Ptr(1000)},
+				}, nil)
+				r.On("GetQuotaUsage", mock.Anything, projectID, &userID, &diskID).Return(map[string]*repo.StorageUsage{
+					model.QuotaScopeUser: {Files: 3, RawBytes: 900},
+				}, nil)
+			},
+			exceeded: true,
+		},
+		{
+			name: "exceeds project files",
+			in:   CheckQuotaInput{ProjectID: projectID, UserID: &userID, Bytes: 10, Files: 2},
+			setup: func(r *MockStorageRepo) {
+				r.On("GetQuotas", mock.Anything, projectID, &userID, (*uuid.UUID)(nil)).Return([]*model.StorageQuota{
+					{Scope: model.QuotaScopeProject, ScopeID: projectID, MaxFiles: int64Ptr(10)},
+				}, nil)
+				r.On("GetQuotaUsage", mock.Anything, projectID, &userID, (*uuid.UUID)(nil)).Return(map[string]*repo.StorageUsage{
+					model.QuotaScopeProject: {Files: 9},
+				}, nil)
+			},
+			exceeded: true,
+		},
+		{
+			name: "usage computed once for every quota",
+			in:   CheckQuotaInput{ProjectID: projectID, DiskID: &diskID, Bytes: 100, Files: 1},
+			setup: func(r *MockStorageRepo) {
+				r.On("GetDiskUserID", mock.Anything, projectID, diskID).Return(&userID, nil)
+				r.On("GetQuotas", mock.Anything, projectID, &userID, &diskID).Return([]*model.StorageQuota{
+					{Scope: model.QuotaScopeProject, ScopeID: projectID, MaxBytes: int64Ptr(10000)},
+					{Scope: model.QuotaScopeUser, ScopeID: userID, MaxBytes: int64Ptr(5000)},
+					{Scope: model.QuotaScopeDisk, ScopeID: diskID, MaxFiles: int64Ptr(10)},
+				}, nil)
+				r.On("GetQuotaUsage", mock.Anything, projectID, &userID, &diskID).Return(map[string]*repo.StorageUsage{
+					model.QuotaScopeProject: {Files: 20, RawBytes: 9000},
+					model.QuotaScopeUser:    {Files: 10, RawBytes: 4000},
+					model.QuotaScopeDisk:    {Files: 9, RawBytes: 3000},
+				}, nil).Once()
+			},
+		},
+		{
+			name: "overwrite with smaller content skips bytes quota",
+			in:   CheckQuotaInput{ProjectID: projectID, DiskID: &diskID, Bytes: -50, Files: 0},
+			setup: func(r *MockStorageRepo) {
+			},
+		},
+		{
+			name: "file count quota ignored when no file is added",
+			in:   CheckQuotaInput{ProjectID: projectID, DiskID: &diskID, Bytes: 10, Files: 0},
+			setup: func(r *MockStorageRepo) {
+				r.On("GetDiskUserID", mock.Anything, projectID, diskID).Return((*uuid.UUID)(nil), nil)
+				r.On("GetQuotas", mock.Anything, projectID, (*uuid.UUID)(nil), &diskID).Return([]*model.StorageQuota{
+					{Scope: model.QuotaScopeDisk, ScopeID: diskID, MaxFiles: int64Ptr(1)},
+				}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &MockStorageRepo{}
+			tt.setup(r)
+
+			err := NewStorageService(r).CheckQuota(context.Background(), tt.in)
+			if tt.exceeded {
+				assert.ErrorIs(t, err, ErrQuotaExceeded)
+			} else {
+				assert.NoError(t, err)
+			}
+			r.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStorageService_CheckQuota_DiskNotFound(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+
+	r := &MockStorageRepo{}
+	r.On("GetDiskUserID", mock.Anything, projectID, diskID).Return(nil, gorm.ErrRecordNotFound)
+
+	err := NewStorageService(r).CheckQuota(context.Background(), CheckQuotaInput{ProjectID: projectID, DiskID: &diskID, Bytes: 1, Files: 1})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	r.AssertExpectations(t)
+}
+
+func TestStorageService_GetUsage(t *testing.T) {
+	projectID := uuid.New()
+	userID := uuid.New()
+	diskID := uuid.New()
+
+	r := &MockStorageRepo{}
+	r.On("GetUsage", mock.Anything, projectID, model.QuotaScopeProject, projectID).Return(&repo.StorageUsage{ScopeID: projectID, Files: 3, RawBytes: 300, DedupBytes: 200}, nil)
+	r.On("ListUsage", mock.Anything, projectID, model.QuotaScopeDisk).Return([]*repo.StorageUsage{{ScopeID: diskID, Files: 3, RawBytes: 300, DedupBytes: 200}}, nil)
+	r.On("ListUsage", mock.Anything, projectID, model.QuotaScopeUser).Return([]*repo.StorageUsage{{ScopeID: userID, Identifier: "alice@acontext.io", Files: 3, RawBytes: 300, DedupBytes: 200}}, nil)
+	r.On("ListQuotas", mock.Anything, projectID).Return(nil, nil)
+
+	out, err := NewStorageService(r).GetUsage(context.Background(), projectID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(200), out.Project.DedupBytes)
+	assert.Len(t, out.Disks, 1)
+	assert.Equal(t, diskID, out.Disks[0].DiskID)
+	assert.Len(t, out.Users, 1)
+	assert.Equal(t, userID, out.Users[0].UserID)
+	assert.Equal(t, "alice@acontext.io", out.Users[0].Identifier)
+	assert.NotNil(t, out.Quotas)
+	r.AssertExpectations(t)
+}
+
+func TestStorageService_SetQuota(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+
+	t.Run("upsert", func(t *testing.T) {
+		r := &MockStorageRepo{}
+		r.On("GetDiskUserID", mock.Anything, projectID, diskID).Return((*uuid.UUID)(nil), nil)
+		r.On("UpsertQuota", mock.Anything, mock.MatchedBy(func(q *model.StorageQuota) bool {
+			return q.ProjectID == projectID && q.Scope == model.QuotaScopeDisk && q.ScopeID == diskID && *q.MaxBytes == 1024 && q.MaxFiles == nil
+		})).Return(nil)
+
+		q, err := NewStorageService(r).SetQuota(context.Background(), SetQuotaInput{
+			ProjectID: projectID, Scope: model.QuotaScopeDisk, ScopeID: diskID, MaxBytes: int64Ptr(1024),
+		})
+		assert.NoError(t, err)
+		assert.NotNil(t, q)
+		r.AssertExpectations(t)
+	})
+
+	t.Run("clearing limits deletes quota", func(t *testing.T) {
+		r := &MockStorageRepo{}
+		r.On("DeleteQuota", mock.Anything, projectID, model.QuotaScopeProject, projectID).Return(nil)
+
+		q, err := NewStorageService(r).SetQuota(context.Background(), SetQuotaInput{
+			ProjectID: projectID, Scope: model.QuotaScopeProject, ScopeID: projectID,
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, q)
+		r.AssertExpectations(t)
+	})
+
+	t.Run("disk of another project", func(t *testing.T) {
+		r := &MockStorageRepo{}
+		r.On("GetDiskUserID", mock.Anything, projectID, diskID).Return(nil, gorm.ErrRecordNotFound)
+
+		_, err := NewStorageService(r).SetQuota(context.Background(), SetQuotaInput{
+			ProjectID: projectID, Scope: model.QuotaScopeDisk, ScopeID: diskID, MaxFiles: int64Ptr(1),
+		})
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		r.AssertExpectations(t)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		r := &MockStorageRepo{}
+		svc := NewStorageService(r)
+
+		_, err := svc.SetQuota(context.Background(), SetQuotaInput{ProjectID: projectID, Scope: "space", ScopeID: projectID, MaxFiles: int64Ptr(1)})
+		assert.Error(t, err)
+		_, err = svc.SetQuota(context.Background(), SetQuotaInput{ProjectID: projectID, Scope: model.QuotaScopeProject, ScopeID: projectID, MaxBytes: int64Ptr(-1)})
+		assert.Error(t, err)
+		r.AssertNotCalled(t, "UpsertQuota", mock.Anything, mock.Anything)
+	})
+}
+
+func TestArtifactService_CheckQuota(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+
+	r := &MockStorageRepo{}
+	r.On("GetDiskUserID", mock.Anything, projectID, diskID).Return((*uuid.UUID)(nil), nil)
+	r.On("GetQuotas", mock.Anything, projectID, (*uuid.UUID)(nil), &diskID).Return([]*model.StorageQuota{
+		{Scope: model.QuotaScopeDisk, ScopeID: diskID, MaxBytes: int64Ptr(1000), MaxFiles: int64Ptr(1)},
+	}, nil)
+	r.On("GetQuotaUsage", mock.Anything, projectID, (*uuid.UUID)(nil), &diskID).Return(map[string]*repo.StorageUsage{
+		model.QuotaScopeDisk: {Files: 1, RawBytes: 500},
+	}, nil)
+
+	s := &artifactService{quota: NewStorageService(r)}
+
+	// A new file exceeds the file count
+	err := s.checkQuota(context.Background(), projectID, diskID, nil, 10)
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+	// Replacing a file only counts the size difference
+	existing := &model.Artifact{AssetMeta: datatypes.NewJSONType(model.Asset{SizeB: 400})}
+	assert.NoError(t, s.checkQuota(context.Background(), projectID, diskID, existing, 900))
+	assert.ErrorIs(t, s.checkQuota(context.Background(), projectID, diskID, existing, 901), ErrQuotaExceeded)
+
+	// Without a storage service nothing is checked
+	assert.NoError(t, (&artifactService{}).checkQuota(context.Background(), projectID, diskID, nil, 1<<40))
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/toolschema"
+)
+
+type ToolService interface {
+	GetToolSchemas(ctx context.Context, in GetToolSchemasInput) (*toolschema.Output, error)
+}
+
+type toolService struct {
+	r repo.ToolReferenceRepo
+}
+
+func NewToolService(r repo.ToolReferenceRepo) ToolService {
+	return &toolService{r: r}
+}
+
+type GetToolSchemasInput struct {
+	ProjectID uuid.UUID           `json:"project_id"`
+	Format    model.MessageFormat `json:"format"`
+	Strict    bool                `json:"strict"`
+}
+
+// GetToolSchemas renders the tools registered in a project in the requested format
+func (s *toolService) GetToolSchemas(ctx context.Context, in GetToolSchemasInput) (*toolschema.Output, error) {
+	refs, err := s.r.ListByProject(ctx, in.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	tools := make([]toolschema.Tool, 0, len(refs))
+	for _, ref := range refs {
+		tools = append(tools, toolschema.FromToolReference(ref))
+	}
+	return toolschema.Render(tools, in.Format, toolschema.Options{Strict: in.Strict})
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockToolReferenceRepo is a mock implementation of ToolReferenceRepo
+type MockToolReferenceRepo struct {
+	mock.Mock
+}
+
+func (m *MockToolReferenceRepo) ListByProject(ctx context.Context, projectID uuid.UUID) ([]model.ToolReference, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ToolReference), args.Error(1)
+}
+
+func TestToolService_GetToolSchemas(t *testing.T) {
+	ctx := context.Background()
+	projectID := uuid.New()
+	description := "Get the weather"
+
+	tests := []struct {
+		name    string
+		input   GetToolSchemasInput
+		setup   func(*MockToolReferenceRepo)
+		wantErr bool
+		check   func(*testing.T, []interface{})
+	}{
+		{
+			name:  "render registered tools",
+			input: GetToolSchemasInput{ProjectID: projectID, Format: model.FormatAnthropic},
+			setup: func(r *MockToolReferenceRepo) {
+				r.On("ListByProject", ctx, projectID).Return([]model.ToolReference{
+					{Name: "get_weather", Description: &description, ArgumentsSchema: map[string]interface{}{
+						"type":       "object",
+						"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+					}},
+					{Name: "ping"},
+				}, nil)
+			},
+			check: func(t *testing.T, tools []interface{}) {
+				require.Len(t, tools, 2)
+			},
+		},
+		{
+			name:  "strict mode rejects open schemas",
+			input: GetToolSchemasInput{ProjectID: projectID, Format: model.FormatOpenAI, Strict: true},
+			setup: func(r *MockToolReferenceRepo) {
+				r.On("ListByProject", ctx, projectID).Return([]model.ToolReference{
+					{Name: "f", ArgumentsSchema: map[string]interface{}{"type": "object", "additionalProperties": true}},
+				}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name:  "repo error",
+			input: GetToolSchemasInput{ProjectID: projectID, Format: model.FormatOpenAI},
+			setup: func(r *MockToolReferenceRepo) {
+				r.On("ListByProject", ctx, projectID).Return(nil, errors.New("db down"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &MockToolReferenceRepo{}
+			tt.setup(r)
+
+			out, err := NewToolService(r).GetToolSchemas(ctx, tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				tt.check(t, out.Tools)
+			}
+			r.AssertExpectations(t)
+		})
+	}
+}
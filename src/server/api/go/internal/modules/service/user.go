@@ -13,6 +13,7 @@ import (
 
 type UserService interface {
 	GetOrCreate(ctx context.Context, projectID uuid.UUID, identifier string) (*model.User, error)
+	Get(ctx context.Context, projectID uuid.UUID, identifier string) (*model.User, error)
 	Delete(ctx context.Context, projectID uuid.UUID, identifier string) error
 	List(ctx context.Context, in ListUsersInput) (*ListUsersOutput, error)
 	GetResourceCounts(ctx context.Context, projectID uuid.UUID, identifier string) (*GetUserResourcesOutput, error)
@@ -33,6 +34,14 @@ func (s *userService) GetOrCreate(ctx context.Context, projectID uuid.UUID, iden
 	return s.r.GetOrCreate(ctx, projectID, identifier)
 }
 
+// Get returns an existing user, or gorm.ErrRecordNotFound if the project has no such user
+func (s *userService) Get(ctx context.Context, projectID uuid.UUID, identifier string) (*model.User, error) {
+	if identifier == "" {
+		return nil, errors.New("user identifier is empty")
+	}
+	return s.r.GetByIdentifier(ctx, projectID, identifier)
+}
+
 func (s *userService) Delete(ctx context.Context, projectID uuid.UUID, identifier string) error {
 	if identifier == "" {
 		return errors.New("user identifier is empty")
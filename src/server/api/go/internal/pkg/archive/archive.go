@@ -0,0 +1,260 @@
+// Package archive writes zip and tar.gz archives, and reads them back safely from untrusted input.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	pathpkg "path"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatZip   Format = "zip"
+	FormatTarGz Format = "tar.gz"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported archive format")
+	ErrUnsafePath        = errors.New("unsafe path in archive")
+	ErrTooLarge          = errors.New("archive is too large")
+)
+
+// ParseFormat returns the format named s, accepting tgz for tar.gz
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "zip":
+		return FormatZip, nil
+	case "tar.gz", "tgz":
+		return FormatTarGz, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, s)
+}
+
+// DetectFormat returns the format of an archive from its filename
+func DetectFormat(filename string) (Format, error) {
+	name := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip, nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, filename)
+}
+
+// Ext returns the file extension of the format, without the leading dot
+func (f Format) Ext() string { return string(f) }
+
+func (f Format) ContentType() string {
+	if f == FormatTarGz {
+		return "application/gzip"
+	}
+	return "application/zip"
+}
+
+// Writer streams files into an archive. Close must be called to complete the archive.
+type Writer struct {
+	zw *zip.Writer
+	gw *gzip.Writer
+	tw *tar.Writer
+}
+
+func NewWriter(w io.Writer, f Format) *Writer {
+	if f == FormatTarGz {
+		gw := gzip.NewWriter(w)
+		return &Writer{gw: gw, tw: tar.NewWriter(gw)}
+	}
+	return &Writer{zw: zip.NewWriter(w)}
+}
+
+// Add writes a file named name, a slash separated path relative to the archive root
+func (w *Writer) Add(name string, modTime time.Time, content []byte) error {
+	if w.zw != nil {
+		f, err := w.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(content)
+		return err
+	}
+
+	if err := w.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0o644,
+		Size:     int64(len(content)),
+		ModTime:  modTime,
+	}); err != nil {
+		return err
+	}
+	_, err := w.tw.Write(content)
+	return err
+}
+
+func (w *Writer) Close() error {
+	if w.zw != nil {
+		return w.zw.Close()
+	}
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.gw.Close()
+}
+
+// Limits bound what Read extracts, protecting against archive bombs
+type Limits struct {
+	MaxBytes int64 // Total uncompressed size of the files
+	MaxFiles int   // Number of files; directories and skipped entries may add as many again
+}
+
+// File is a regular file read from an archive
+type File struct {
+	Name    string // Clean slash separated path relative to the archive root, without leading slash
+	Content []byte
+}
+
+// Read returns the regular files of an archive sorted by name. Directories, links and other
+// special entries are skipped. A later entry with the same name replaces an earlier one.
+// Entries with absolute paths or .. elements fail with ErrUnsafePath, and archives beyond
+// limits fail with ErrTooLarge, whatever their headers claim.
+func Read(r io.ReaderAt, size int64, f Format, limits Limits) ([]File, error) {
+	rd := &reader{limits: limits, files: map[string][]byte{}}
+	var err error
+	switch f {
+	case FormatZip:
+		err = rd.readZip(r, size)
+	case FormatTarGz:
+		err = rd.readTarGz(io.NewSectionReader(r, 0, size))
+	default:
+		err = fmt.Errorf("%w: %q", ErrUnsupportedFormat, f)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]File, 0, len(rd.files))
+	for name, content := range rd.files {
+		files = append(files, File{Name: name, Content: content})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+type reader struct {
+	limits  Limits
+	entries int
+	bytes   int64
+	files   map[string][]byte
+}
+
+// entry counts an entry of any kind, so that archives of empty entries cannot run forever
+func (rd *reader) entry() error {
+	rd.entries++
+	if rd.entries > 2*rd.limits.MaxFiles {
+		return fmt.Errorf("%w: more than %d entries", ErrTooLarge, 2*rd.limits.MaxFiles)
+	}
+	return nil
+}
+
+// add reads a file from src, counting its actual size against the limits
+func (rd *reader) add(name string, src io.Reader) error {
+	name, err := cleanName(name)
+	if err != nil {
+		return err
+	}
+	if _, ok := rd.files[name]; !ok && len(rd.files) >= rd.limits.MaxFiles {
+		return fmt.Errorf("%w: more than %d files", ErrTooLarge, rd.limits.MaxFiles)
+	}
+
+	remaining := rd.limits.MaxBytes - rd.bytes
+	content, err := io.ReadAll(io.LimitReader(src, remaining+1))
+	if err != nil {
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+	if int64(len(content)) > remaining {
+		return fmt.Errorf("%w: more than %d bytes uncompressed", ErrTooLarge, rd.limits.MaxBytes)
+	}
+	rd.bytes += int64(len(content))
+	rd.files[name] = content
+	return nil
+}
+
+func (rd *reader) readZip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("open zip archive: %w", err)
+	}
+	for _, zf := range zr.File {
+		if err := rd.entry(); err != nil {
+			return err
+		}
+		if !zf.Mode().IsRegular() {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return fmt.Errorf("open %s: %w", zf.Name, err)
+		}
+		err = rd.add(zf.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rd *reader) readTarGz(r io.Reader) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("open gzip stream: %w", err)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read tar archive: %w", err)
+		}
+		if err := rd.entry(); err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := rd.add(hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// cleanName normalizes an entry name, rejecting names that could escape the extraction directory
+func cleanName(name string) (string, error) {
+	name = strings.ReplaceAll(name, `\`, "/")
+	if strings.HasPrefix(name, "/") || strings.Contains(name, "\x00") ||
+		(len(name) >= 2 && name[1] == ':') {
+		return "", fmt.Errorf("%w: %q", ErrUnsafePath, name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("%w: %q", ErrUnsafePath, name)
+		}
+	}
+	clean := pathpkg.Clean(name)
+	if clean == "." || strings.HasSuffix(name, "/") {
+		return "", fmt.Errorf("%w: %q", ErrUnsafePath, name)
+	}
+	return clean, nil
+}
@@ -0,0 +1,126 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLimits = Limits{MaxBytes: 1 << 20, MaxFiles: 10}
+
+func TestFormat(t *testing.T) {
+	f, err := ParseFormat("tgz")
+	require.NoError(t, err)
+	assert.Equal(t, FormatTarGz, f)
+
+	f, err = DetectFormat("Project.ZIP")
+	require.NoError(t, err)
+	assert.Equal(t, FormatZip, f)
+
+	f, err = DetectFormat("out.tar.gz")
+	require.NoError(t, err)
+	assert.Equal(t, FormatTarGz, f)
+
+	_, err = DetectFormat("out.rar")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	_, err = ParseFormat("7z")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestWriteRead(t *testing.T) {
+	for _, f := range []Format{FormatZip, FormatTarGz} {
+		t.Run(string(f), func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewWriter(&buf, f)
+			require.NoError(t, w.Add("src/main.go", time.Now(), []byte("package main\n")))
+			require.NoError(t, w.Add("README.md", time.Now(), []byte("# readme\n")))
+			require.NoError(t, w.Add("empty.txt", time.Now(), nil))
+			require.NoError(t, w.Close())
+
+			files, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()), f, testLimits)
+			require.NoError(t, err)
+			require.Len(t, files, 3)
+			assert.Equal(t, File{Name: "README.md", Content: []byte("# readme\n")}, files[0])
+			assert.Equal(t, "empty.txt", files[1].Name)
+			assert.Empty(t, files[1].Content)
+			assert.Equal(t, File{Name: "src/main.go", Content: []byte("package main\n")}, files[2])
+		})
+	}
+}
+
+func zipOf(t *testing.T, entries map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range entries {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestRead_UnsafePaths(t *testing.T) {
+	for _, name := range []string{"../evil.sh", "a/../../evil.sh", "/etc/passwd", `..\evil.sh`, "C:/evil.sh"} {
+		t.Run(name, func(t *testing.T) {
+			data := zipOf(t, map[string]string{name: "x"})
+			_, err := Read(bytes.NewReader(data), int64(len(data)), FormatZip, testLimits)
+			assert.ErrorIs(t, err, ErrUnsafePath)
+		})
+	}
+}
+
+func TestRead_SkipsDirectoriesAndLinks(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "dir/", Mode: 0o755}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: "dir/link", Linkname: "/etc/passwd"}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "dir/./a.txt", Mode: 0o644, Size: 1}))
+	_, err := tw.Write([]byte("a"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	files, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()), FormatTarGz, testLimits)
+	require.NoError(t, err)
+	assert.Equal(t, []File{{Name: "dir/a.txt", Content: []byte("a")}}, files)
+}
+
+func TestRead_Limits(t *testing.T) {
+	t.Run("uncompressed bytes", func(t *testing.T) {
+		// Compresses to a few KB but expands past the limit
+		data := zipOf(t, map[string]string{"bomb.txt": strings.Repeat("0", 2<<20)})
+		assert.Less(t, len(data), 1<<20)
+		_, err := Read(bytes.NewReader(data), int64(len(data)), FormatZip, testLimits)
+		assert.ErrorIs(t, err, ErrTooLarge)
+	})
+
+	t.Run("files", func(t *testing.T) {
+		entries := map[string]string{}
+		for i := 0; i < 11; i++ {
+			entries[strings.Repeat("f", i+1)] = "x"
+		}
+		data := zipOf(t, entries)
+		_, err := Read(bytes.NewReader(data), int64(len(data)), FormatZip, testLimits)
+		assert.ErrorIs(t, err, ErrTooLarge)
+	})
+
+	t.Run("entries", func(t *testing.T) {
+		entries := map[string]string{}
+		for i := 0; i < 21; i++ {
+			entries[strings.Repeat("d", i+1)+"/"] = ""
+		}
+		data := zipOf(t, entries)
+		_, err := Read(bytes.NewReader(data), int64(len(data)), FormatZip, testLimits)
+		assert.ErrorIs(t, err, ErrTooLarge)
+	})
+}
@@ -60,3 +60,8 @@ func (c *AcontextConverter) Convert(messages []model.Message, publicURLs map[str
 
 	return result, nil
 }
+
+// DetectLoss reports nothing: the Acontext format is the internal format
+func (c *AcontextConverter) DetectLoss(messages []model.Message, publicURLs map[string]service.PublicURL) []ConversionWarning {
+	return nil
+}
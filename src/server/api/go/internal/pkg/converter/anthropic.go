@@ -28,6 +28,11 @@ func (c *AnthropicConverter) Convert(messages []model.Message, publicURLs map[st
 	return result, nil
 }
 
+// ConvertSystemPrompt converts the session system prompt to Anthropic's top-level system blocks
+func (c *AnthropicConverter) ConvertSystemPrompt(systemPrompt string) []anthropic.TextBlockParam {
+	return []anthropic.TextBlockParam{{Text: systemPrompt}}
+}
+
 func (c *AnthropicConverter) convertMessage(msg model.Message, publicURLs map[string]service.PublicURL) anthropic.MessageParam {
 	role := c.convertRole(msg.Role)
 
@@ -90,17 +95,24 @@ func (c *AnthropicConverter) convertParts(parts []model.Part, publicURLs map[str
 			}
 
 		case "tool-result":
-			toolResultBlock := c.convertToolResultPart(part)
+			toolResultBlock := c.convertToolResultPart(part, publicURLs)
 			if toolResultBlock != nil {
 				contentBlocks = append(contentBlocks, *toolResultBlock)
 			}
 
 		case "file":
 			// Convert file to document block
-			if part.Meta != nil {
-				docBlock := c.convertDocumentPart(part, publicURLs)
-				if docBlock != nil {
-					contentBlocks = append(contentBlocks, *docBlock)
+			docBlock := c.convertDocumentPart(part, publicURLs)
+			if docBlock != nil {
+				contentBlocks = append(contentBlocks, *docBlock)
+			}
+
+		case "thinking":
+			// Only Anthropic-issued thinking can be replayed; other providers' reasoning is dropped
+			if isThinkingFromFormat(part, model.FormatAnthropic) {
+				thinkingBlock := c.convertThinkingPart(part)
+				if thinkingBlock != nil {
+					contentBlocks = append(contentBlocks, *thinkingBlock)
 				}
 			}
 		}
@@ -110,6 +122,18 @@ func (c *AnthropicConverter) convertParts(parts []model.Part, publicURLs map[str
 }
 
 func (c *AnthropicConverter) convertImagePart(part model.Part, publicURLs map[string]service.PublicURL) *anthropic.ContentBlockParamUnion {
+	// Inline base64 image kept in meta
+	if part.Asset == nil && part.Meta != nil {
+		if sourceType, _ := part.Meta["type"].(string); sourceType == "base64" {
+			mediaType, _ := part.Meta["media_type"].(string)
+			data, _ := part.Meta["data"].(string)
+			if mediaType != "" && data != "" {
+				block := anthropic.NewImageBlockBase64(mediaType, data)
+				return &block
+			}
+		}
+	}
+
 	// Try to get image URL from asset
 	imageURL := c.getAssetURL(part.Asset, publicURLs)
 	if imageURL == "" && part.Meta != nil {
@@ -181,7 +205,7 @@ func (c *AnthropicConverter) convertToolCallPart(part model.Part) *anthropic.Con
 	return &block
 }
 
-func (c *AnthropicConverter) convertToolResultPart(part model.Part) *anthropic.ContentBlockParamUnion {
+func (c *AnthropicConverter) convertToolResultPart(part model.Part, publicURLs map[string]service.PublicURL) *anthropic.ContentBlockParamUnion {
 	// UNIFIED FORMAT: Use tool_call_id (unified field name)
 	toolUseID := ""
 	isError := false
@@ -199,16 +223,81 @@ func (c *AnthropicConverter) convertToolResultPart(part model.Part) *anthropic.C
 		return nil
 	}
 
-	block := anthropic.NewToolResultBlock(toolUseID, part.Text, isError)
+	if len(part.Content) == 0 {
+		block := anthropic.NewToolResultBlock(toolUseID, part.Text, isError)
+		return &block
+	}
+
+	// Rebuild rich tool results from the nested parts
+	toolResult := anthropic.ToolResultBlockParam{
+		ToolUseID: toolUseID,
+		IsError:   anthropic.Bool(isError),
+	}
+	for _, nested := range part.Content {
+		switch nested.Type {
+		case "text":
+			if nested.Text != "" {
+				toolResult.Content = append(toolResult.Content, anthropic.ToolResultBlockParamContentUnion{
+					OfText: &anthropic.TextBlockParam{Text: nested.Text},
+				})
+			}
+		case "image":
+			if imageBlock := c.convertImagePart(nested, publicURLs); imageBlock != nil && imageBlock.OfImage != nil {
+				toolResult.Content = append(toolResult.Content, anthropic.ToolResultBlockParamContentUnion{
+					OfImage: imageBlock.OfImage,
+				})
+			}
+		case "file":
+			if docBlock := c.convertDocumentPart(nested, publicURLs); docBlock != nil && docBlock.OfDocument != nil {
+				toolResult.Content = append(toolResult.Content, anthropic.ToolResultBlockParamContentUnion{
+					OfDocument: docBlock.OfDocument,
+				})
+			}
+		}
+	}
+
+	block := anthropic.ContentBlockParamUnion{OfToolResult: &toolResult}
 	return &block
 }
 
-func (c *AnthropicConverter) convertDocumentPart(part model.Part, publicURLs map[string]service.PublicURL) *anthropic.ContentBlockParamUnion {
-	// Try to get document URL or base64 data from meta
-	if part.Meta == nil {
+func (c *AnthropicConverter) convertThinkingPart(part model.Part) *anthropic.ContentBlockParamUnion {
+	if redacted, _ := part.Meta["redacted"].(bool); redacted {
+		data, _ := part.Meta["data"].(string)
+		if data == "" {
+			return nil
+		}
+		block := anthropic.NewRedactedThinkingBlock(data)
+		return &block
+	}
+
+	// Anthropic rejects thinking blocks without a valid signature
+	signature, _ := part.Meta["signature"].(string)
+	if signature == "" {
 		return nil
 	}
 
+	block := anthropic.NewThinkingBlock(signature, part.Text)
+	return &block
+}
+
+func (c *AnthropicConverter) convertDocumentPart(part model.Part, publicURLs map[string]service.PublicURL) *anthropic.ContentBlockParamUnion {
+	// Documents stored as assets are downloaded and sent inline
+	if assetURL := c.getAssetURL(part.Asset, publicURLs); assetURL != "" {
+		if base64Data, _ := c.downloadImageAsBase64(assetURL); base64Data != "" {
+			if strings.HasPrefix(part.Asset.MIME, "text/") {
+				data, err := base64.StdEncoding.DecodeString(base64Data)
+				if err != nil {
+					return nil
+				}
+				block := anthropic.NewDocumentBlock(anthropic.PlainTextSourceParam{Data: string(data)})
+				return &block
+			}
+			block := anthropic.NewDocumentBlock(anthropic.Base64PDFSourceParam{Data: base64Data})
+			return &block
+		}
+	}
+
+	// Try to get document URL or base64 data from meta
 	if sourceType, ok := part.Meta["type"].(string); ok {
 		switch sourceType {
 		case "base64":
@@ -267,12 +356,75 @@ func (c *AnthropicConverter) downloadImageAsBase64(imageURL string) (string, str
 }
 
 func (c *AnthropicConverter) getAssetURL(asset *model.Asset, publicURLs map[string]service.PublicURL) string {
-	if asset == nil {
+	return assetPublicURL(asset, publicURLs)
+}
+
+// DetectLoss reports the parts and fields that Convert drops for Anthropic
+func (c *AnthropicConverter) DetectLoss(messages []model.Message, publicURLs map[string]service.PublicURL) []ConversionWarning {
+	r := &lossReport{format: model.FormatAnthropic}
+	mediaLoss := func(part model.Part) string { return c.mediaLoss(part, publicURLs) }
+
+	for _, msg := range messages {
+		r.checkMessageName(msg)
+		for i, part := range msg.Parts {
+			if !r.checkCommon(msg, i, part) {
+				continue
+			}
+
+			switch part.Type {
+			case "image", "file":
+				if reason := mediaLoss(part); reason != "" {
+					r.dropPart(msg, i, part, reason)
+				}
+			case "audio":
+				r.dropPart(msg, i, part, "anthropic does not accept audio input")
+			case "tool-call":
+				if !hasToolCallIdentity(part) {
+					r.dropPart(msg, i, part, "tool call has no id or name")
+				}
+			case "tool-result":
+				if toolResultCallID(part) == "" {
+					r.dropPart(msg, i, part, "tool result has no tool_call_id")
+					continue
+				}
+				r.checkNestedContent(msg, i, part, mediaLoss)
+			case "thinking":
+				if redacted, _ := part.Meta["redacted"].(bool); !redacted {
+					if signature, _ := part.Meta["signature"].(string); signature == "" {
+						r.dropPart(msg, i, part, "anthropic thinking blocks need a signature")
+					}
+				}
+			}
+		}
+	}
+
+	return r.warnings
+}
+
+// mediaLoss returns why an image or file part cannot be converted, or "" if it can
+func (c *AnthropicConverter) mediaLoss(part model.Part, publicURLs map[string]service.PublicURL) string {
+	if c.getAssetURL(part.Asset, publicURLs) != "" {
 		return ""
 	}
-	assetKey := asset.S3Key
-	if publicURL, ok := publicURLs[assetKey]; ok {
-		return publicURL.URL
+
+	sourceType, _ := part.Meta["type"].(string)
+	if part.Type == "image" {
+		url, _ := part.Meta["url"].(string)
+		if (sourceType == "base64" && hasInlineData(part) && partMediaType(part) != "") || url != "" {
+			return ""
+		}
+		return "image has no URL or inline data"
+	}
+
+	switch sourceType {
+	case "base64":
+		if hasInlineData(part) && partMediaType(part) != "" {
+			return ""
+		}
+	case "url":
+		if remoteURL(part) != "" {
+			return ""
+		}
 	}
-	return ""
+	return "anthropic documents need base64 data or a URL"
 }
@@ -1,6 +1,9 @@
 package converter
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/memodb-io/Acontext/internal/modules/model"
@@ -117,3 +120,102 @@ func TestAnthropicConverter_Convert_Image(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, result)
 }
+
+func TestAnthropicConverter_Convert_Thinking(t *testing.T) {
+	converter := &AnthropicConverter{}
+
+	messages := []model.Message{
+		createTestMessage("assistant", []model.Part{
+			{Type: "thinking", Text: "Reasoning", Meta: map[string]any{"source_format": "anthropic", "signature": "sig_abc"}},
+			{Type: "thinking", Meta: map[string]any{"source_format": "anthropic", "redacted": true, "data": "encrypted"}},
+			{Type: "thinking", Text: "Foreign reasoning", Meta: map[string]any{"source_format": "gemini"}},
+			{Type: "text", Text: "Answer"},
+		}, nil),
+	}
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{
+		"role": "assistant",
+		"content": [
+			{"type": "thinking", "thinking": "Reasoning", "signature": "sig_abc"},
+			{"type": "redacted_thinking", "data": "encrypted"},
+			{"type": "text", "text": "Answer"}
+		]
+	}]`, string(raw))
+}
+
+func TestAnthropicConverter_Convert_RichToolResult(t *testing.T) {
+	converter := &AnthropicConverter{}
+
+	messages := []model.Message{
+		createTestMessage("user", []model.Part{
+			{
+				Type: "tool-result",
+				Text: "Screenshot taken",
+				Meta: map[string]any{"tool_call_id": "toolu_123"},
+				Content: []model.Part{
+					{Type: "text", Text: "Screenshot taken"},
+					{Type: "image", Meta: map[string]any{"type": "base64", "media_type": "image/png", "data": "aGVsbG8="}},
+				},
+			},
+		}, nil),
+	}
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(result)
+	require.NoError(t, err)
+
+	var decoded []map[string]any
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	block := decoded[0]["content"].([]any)[0].(map[string]any)
+	assert.Equal(t, "tool_result", block["type"])
+
+	content := block["content"].([]any)
+	require.Len(t, content, 2)
+	assert.Equal(t, "text", content[0].(map[string]any)["type"])
+	image := content[1].(map[string]any)
+	assert.Equal(t, "image", image["type"])
+	assert.Equal(t, "aGVsbG8=", image["source"].(map[string]any)["data"])
+}
+
+func TestAnthropicConverter_Convert_ImageAssetBySHA256(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	converter := &AnthropicConverter{}
+
+	messages := []model.Message{
+		createTestMessage("user", []model.Part{
+			{
+				Type:  "image",
+				Meta:  map[string]any{"media_type": "image/png"},
+				Asset: &model.Asset{SHA256: "abc", S3Key: "assets/abc.png", MIME: "image/png"},
+			},
+		}, nil),
+	}
+	// The session service keys public URLs by SHA256
+	publicURLs := map[string]service.PublicURL{
+		"abc": {URL: server.URL + "/abc.png"},
+	}
+
+	result, err := converter.Convert(messages, publicURLs)
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(result)
+	require.NoError(t, err)
+
+	var decoded []map[string]any
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	source := decoded[0]["content"].([]any)[0].(map[string]any)["source"].(map[string]any)
+	assert.Equal(t, "base64", source["type"])
+	assert.Equal(t, "aGVsbG8=", source["data"])
+}
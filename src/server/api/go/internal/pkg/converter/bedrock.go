@@ -0,0 +1,524 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/normalizer"
+)
+
+const (
+	// Bedrock toolUseId must match ^[a-zA-Z0-9_-]+$ and be at most 64 characters
+	bedrockToolUseIDMaxLen = 64
+
+	// bedrockDownloadTimeout bounds the download of media referenced by URL
+	bedrockDownloadTimeout = 30 * time.Second
+	// Converse rejects larger images and documents, so downloads stop past these sizes
+	bedrockMaxImageBytes    = 3_750_000
+	bedrockMaxDocumentBytes = 4_500_000
+)
+
+var (
+	bedrockToolUseIDInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+	// Bedrock document names only allow alphanumerics, whitespace, hyphens, parentheses and square brackets
+	bedrockDocumentNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9\s\-\(\)\[\]]`)
+	bedrockConsecutiveSpaces        = regexp.MustCompile(`\s{2,}`)
+
+	bedrockHTTPClient = &http.Client{Timeout: bedrockDownloadTimeout}
+)
+
+// BedrockConverter converts messages to AWS Bedrock Converse-compatible format
+type BedrockConverter struct {
+	// client downloads media referenced by URL; nil uses bedrockHTTPClient
+	client *http.Client
+	// loss collects the media that the last Convert could not download
+	loss *lossReport
+}
+
+func (c *BedrockConverter) Convert(messages []model.Message, publicURLs map[string]service.PublicURL) (interface{}, error) {
+	result := make([]normalizer.BedrockMessage, 0, len(messages))
+	c.loss = &lossReport{format: model.FormatBedrock}
+
+	for _, msg := range messages {
+		bedrockMsg := c.convertMessage(msg, publicURLs)
+		// Bedrock rejects messages without content blocks
+		if len(bedrockMsg.Content) == 0 {
+			continue
+		}
+		result = append(result, bedrockMsg)
+	}
+
+	return result, nil
+}
+
+// ConvertLoss reports the media that the last Convert dropped because it could not be downloaded
+func (c *BedrockConverter) ConvertLoss() []ConversionWarning {
+	if c.loss == nil {
+		return nil
+	}
+	return c.loss.warnings
+}
+
+// ConvertSystemPrompt converts the session system prompt to Bedrock Converse system blocks
+func (c *BedrockConverter) ConvertSystemPrompt(systemPrompt string) []normalizer.BedrockSystemContentBlock {
+	return []normalizer.BedrockSystemContentBlock{{Text: &systemPrompt}}
+}
+
+func (c *BedrockConverter) convertMessage(msg model.Message, publicURLs map[string]service.PublicURL) normalizer.BedrockMessage {
+	return normalizer.BedrockMessage{
+		Role:    c.convertRole(msg.Role),
+		Content: c.convertParts(msg, publicURLs),
+	}
+}
+
+func (c *BedrockConverter) convertRole(role string) string {
+	// Bedrock roles: "user", "assistant"
+	switch role {
+	case "assistant":
+		return "assistant"
+	default:
+		return "user"
+	}
+}
+
+func (c *BedrockConverter) convertParts(msg model.Message, publicURLs map[string]service.PublicURL) []normalizer.BedrockContentBlock {
+	blocks := make([]normalizer.BedrockContentBlock, 0, len(msg.Parts))
+
+	for i, part := range msg.Parts {
+		var block *normalizer.BedrockContentBlock
+		// drop records media of the part, or of its field, that could not be loaded
+		drop := func(field string, reason string) {
+			if field == "" {
+				c.loss.dropPart(msg, i, part, reason)
+			} else {
+				c.loss.dropField(msg, i, part.Type, field, reason)
+			}
+		}
+
+		switch part.Type {
+		case "text":
+			if part.Text != "" {
+				text := part.Text
+				block = &normalizer.BedrockContentBlock{Text: &text}
+			}
+
+		case "image":
+			if image := c.convertImagePart(part, publicURLs, drop); image != nil {
+				block = &normalizer.BedrockContentBlock{Image: image}
+			}
+
+		case "file":
+			if document := c.convertDocumentPart(part, publicURLs, drop); document != nil {
+				block = &normalizer.BedrockContentBlock{Document: document}
+			}
+
+		case "tool-call":
+			// UNIFIED FORMAT: Convert tool-call to Bedrock toolUse
+			if toolUse := c.convertToolCallPart(part); toolUse != nil {
+				block = &normalizer.BedrockContentBlock{ToolUse: toolUse}
+			}
+
+		case "tool-result":
+			// UNIFIED FORMAT: Convert tool-result to Bedrock toolResult
+			if toolResult := c.convertToolResultPart(part, publicURLs, drop); toolResult != nil {
+				block = &normalizer.BedrockContentBlock{ToolResult: toolResult}
+			}
+
+		case "thinking":
+			// Only Bedrock-issued reasoning can be replayed; other providers' reasoning is dropped
+			if isThinkingFromFormat(part, model.FormatBedrock) {
+				if reasoning := c.convertThinkingPart(part); reasoning != nil {
+					block = &normalizer.BedrockContentBlock{ReasoningContent: reasoning}
+				}
+			}
+		}
+
+		if block == nil {
+			continue
+		}
+		blocks = append(blocks, *block)
+
+		// Anthropic-style cache_control on a part becomes a cachePoint right after it
+		if normalizer.BuildAnthropicCacheControl(part.Meta) != nil {
+			blocks = append(blocks, normalizer.BedrockContentBlock{
+				CachePoint: &normalizer.BedrockCachePointBlock{Type: "default"},
+			})
+		}
+	}
+
+	return blocks
+}
+
+func (c *BedrockConverter) convertImagePart(part model.Part, publicURLs map[string]service.PublicURL, drop func(field string, reason string)) *normalizer.BedrockImageBlock {
+	source, mediaType := c.loadPartSource(part, publicURLs, bedrockMaxImageBytes, drop)
+	if source == nil {
+		return nil
+	}
+
+	format := normalizer.BedrockImageFormat(mediaType)
+	if format == "" {
+		return nil
+	}
+
+	return &normalizer.BedrockImageBlock{
+		Format: format,
+		Source: *source,
+	}
+}
+
+func (c *BedrockConverter) convertDocumentPart(part model.Part, publicURLs map[string]service.PublicURL, drop func(field string, reason string)) *normalizer.BedrockDocumentBlock {
+	source, mediaType := c.loadPartSource(part, publicURLs, bedrockMaxDocumentBytes, drop)
+	if source == nil {
+		return nil
+	}
+
+	format := normalizer.BedrockDocumentFormat(mediaType)
+	if format == "" {
+		return nil
+	}
+
+	name := part.Filename
+	if part.Meta != nil {
+		if filename, ok := part.Meta["filename"].(string); ok && filename != "" {
+			name = filename
+		}
+	}
+
+	return &normalizer.BedrockDocumentBlock{
+		Format: format,
+		Name:   bedrockDocumentName(name),
+		Source: *source,
+	}
+}
+
+func (c *BedrockConverter) convertToolCallPart(part model.Part) *normalizer.BedrockToolUseBlock {
+	if part.Meta == nil {
+		return nil
+	}
+
+	// UNIFIED FORMAT: Extract from unified field names
+	id, _ := part.Meta["id"].(string)
+	name, _ := part.Meta["name"].(string)
+	if id == "" || name == "" {
+		return nil
+	}
+
+	// Bedrock requires the input to be a JSON object
+	var input map[string]interface{}
+	if argsStr, ok := part.Meta["arguments"].(string); ok {
+		if err := json.Unmarshal([]byte(argsStr), &input); err != nil {
+			input = nil
+		}
+	} else if argsObj, ok := part.Meta["arguments"].(map[string]interface{}); ok {
+		input = argsObj
+	}
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+
+	return &normalizer.BedrockToolUseBlock{
+		ToolUseID: bedrockToolUseID(id),
+		Name:      name,
+		Input:     input,
+	}
+}
+
+func (c *BedrockConverter) convertToolResultPart(part model.Part, publicURLs map[string]service.PublicURL, drop func(field string, reason string)) *normalizer.BedrockToolResultBlock {
+	if part.Meta == nil {
+		return nil
+	}
+
+	// UNIFIED FORMAT: Use tool_call_id (unified field name)
+	toolCallID, _ := part.Meta["tool_call_id"].(string)
+	if toolCallID == "" {
+		return nil
+	}
+
+	toolResult := &normalizer.BedrockToolResultBlock{
+		ToolUseID: bedrockToolUseID(toolCallID),
+		Content:   c.convertToolResultContent(part, publicURLs, drop),
+	}
+
+	if isError, ok := part.Meta["is_error"].(bool); ok && isError {
+		toolResult.Status = "error"
+	}
+
+	return toolResult
+}
+
+// convertToolResultContent rebuilds the content of a tool result from its nested parts,
+// falling back to the flattened text when there are none.
+func (c *BedrockConverter) convertToolResultContent(part model.Part, publicURLs map[string]service.PublicURL, drop func(field string, reason string)) []normalizer.BedrockToolResultContentBlock {
+	content := make([]normalizer.BedrockToolResultContentBlock, 0, len(part.Content))
+	for i, nested := range part.Content {
+		dropNested := func(_ string, reason string) { drop(fmt.Sprintf("content[%d]", i), reason) }
+		switch nested.Type {
+		case "text":
+			if nested.Text != "" {
+				text := nested.Text
+				content = append(content, normalizer.BedrockToolResultContentBlock{Text: &text})
+			}
+		case "image":
+			if image := c.convertImagePart(nested, publicURLs, dropNested); image != nil {
+				content = append(content, normalizer.BedrockToolResultContentBlock{Image: image})
+			}
+		case "file":
+			if document := c.convertDocumentPart(nested, publicURLs, dropNested); document != nil {
+				content = append(content, normalizer.BedrockToolResultContentBlock{Document: document})
+			}
+		}
+	}
+
+	if len(content) == 0 {
+		text := part.Text
+		content = append(content, normalizer.BedrockToolResultContentBlock{Text: &text})
+	}
+	return content
+}
+
+func (c *BedrockConverter) convertThinkingPart(part model.Part) *normalizer.BedrockReasoningContentBlock {
+	if redacted, _ := part.Meta["redacted"].(bool); redacted {
+		data, _ := part.Meta["data"].(string)
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil || len(decoded) == 0 {
+			return nil
+		}
+		return &normalizer.BedrockReasoningContentBlock{RedactedContent: decoded}
+	}
+
+	if part.Text == "" {
+		return nil
+	}
+	signature, _ := part.Meta["signature"].(string)
+	return &normalizer.BedrockReasoningContentBlock{
+		ReasoningText: &normalizer.BedrockReasoningTextBlock{
+			Text:      part.Text,
+			Signature: signature,
+		},
+	}
+}
+
+// loadPartSource resolves the source and media type of an image or file part, or nil if it has none.
+// An S3 location from Bedrock is passed through as is. Other sources are loaded as bytes, tried in
+// order: inline base64 meta, OpenAI file_data, asset public URL, meta url.
+func (c *BedrockConverter) loadPartSource(part model.Part, publicURLs map[string]service.PublicURL, maxBytes int64, drop func(field string, reason string)) (*normalizer.BedrockSource, string) {
+	if location := bedrockS3Location(part); location != nil {
+		mediaType, _ := part.Meta["media_type"].(string)
+		return &normalizer.BedrockSource{S3Location: location}, mediaType
+	}
+
+	data, mediaType := c.loadPartBytes(part, publicURLs, maxBytes, drop)
+	if len(data) == 0 {
+		return nil, ""
+	}
+	return &normalizer.BedrockSource{Bytes: data}, mediaType
+}
+
+// bedrockS3Location returns the S3 location a part was stored with by the Bedrock normalizer, or nil
+func bedrockS3Location(part model.Part) *normalizer.BedrockS3Location {
+	if sourceType, _ := part.Meta["type"].(string); sourceType != "s3" {
+		return nil
+	}
+	uri, _ := part.Meta["s3_uri"].(string)
+	if uri == "" {
+		return nil
+	}
+	owner, _ := part.Meta["bucket_owner"].(string)
+	return &normalizer.BedrockS3Location{URI: uri, BucketOwner: owner}
+}
+
+// loadPartBytes resolves the raw bytes and media type of an image or file part.
+// Media that cannot be downloaded within maxBytes is reported to drop.
+func (c *BedrockConverter) loadPartBytes(part model.Part, publicURLs map[string]service.PublicURL, maxBytes int64, drop func(field string, reason string)) ([]byte, string) {
+	if part.Meta != nil {
+		if sourceType, _ := part.Meta["type"].(string); sourceType == "base64" {
+			mediaType, _ := part.Meta["media_type"].(string)
+			data, _ := part.Meta["data"].(string)
+			if decoded, err := base64.StdEncoding.DecodeString(data); err == nil && len(decoded) > 0 {
+				return decoded, mediaType
+			}
+		}
+		if fileData, ok := part.Meta["file_data"].(string); ok && strings.HasPrefix(fileData, "data:") {
+			if decoded, mediaType := decodeDataURL(fileData); len(decoded) > 0 {
+				return decoded, mediaType
+			}
+		}
+	}
+
+	url := c.getAssetURL(part.Asset, publicURLs)
+	if url == "" && part.Meta != nil {
+		url, _ = part.Meta["url"].(string)
+	}
+	if url == "" {
+		return nil, ""
+	}
+
+	if strings.HasPrefix(url, "data:") {
+		return decodeDataURL(url)
+	}
+
+	data, mediaType, err := c.download(url, maxBytes)
+	if err != nil {
+		drop("", fmt.Sprintf("%s could not be downloaded: %v", part.Type, err))
+		return nil, ""
+	}
+	if part.Asset != nil && part.Asset.MIME != "" {
+		mediaType = part.Asset.MIME
+	}
+	return data, mediaType
+}
+
+// download fetches media by URL, failing past the client timeout or maxBytes. Errors leave the
+// URL out, since it may be presigned.
+func (c *BedrockConverter) download(rawURL string, maxBytes int64) ([]byte, string, error) {
+	client := c.client
+	if client == nil {
+		client = bedrockHTTPClient
+	}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, "", fmt.Errorf("larger than the %d bytes bedrock accepts", maxBytes)
+	}
+
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+func (c *BedrockConverter) getAssetURL(asset *model.Asset, publicURLs map[string]service.PublicURL) string {
+	return assetPublicURL(asset, publicURLs)
+}
+
+// decodeDataURL decodes a base64 data URL (e.g., "data:image/png;base64,...")
+func decodeDataURL(dataURL string) ([]byte, string) {
+	parts := strings.SplitN(dataURL, ",", 2)
+	if len(parts) != 2 {
+		return nil, ""
+	}
+
+	mediaType := ""
+	if strings.Contains(parts[0], ":") {
+		typePart := strings.SplitN(parts[0], ":", 2)[1]
+		mediaType = strings.Split(typePart, ";")[0]
+	}
+
+	data, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ""
+	}
+	return data, mediaType
+}
+
+// bedrockToolUseID maps a tool call ID onto Bedrock's toolUseId constraints.
+// The mapping is deterministic so tool-call and tool-result parts stay paired.
+func bedrockToolUseID(id string) string {
+	sanitized := bedrockToolUseIDInvalidChars.ReplaceAllString(id, "_")
+	if sanitized == id && len(id) <= bedrockToolUseIDMaxLen {
+		return id
+	}
+
+	// Append a short hash of the original ID so distinct IDs don't collide after sanitizing
+	sum := sha256.Sum256([]byte(id))
+	suffix := "_" + hex.EncodeToString(sum[:])[:8]
+	if len(sanitized) > bedrockToolUseIDMaxLen-len(suffix) {
+		sanitized = sanitized[:bedrockToolUseIDMaxLen-len(suffix)]
+	}
+	return sanitized + suffix
+}
+
+func bedrockDocumentName(name string) string {
+	// Drop the extension; the format is carried separately
+	if idx := strings.LastIndex(name, "."); idx > 0 {
+		name = name[:idx]
+	}
+	name = bedrockDocumentNameInvalidChars.ReplaceAllString(name, "-")
+	name = bedrockConsecutiveSpaces.ReplaceAllString(name, " ")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "document"
+	}
+	return name
+}
+
+// DetectLoss reports the parts and fields that Convert drops for Bedrock
+func (c *BedrockConverter) DetectLoss(messages []model.Message, publicURLs map[string]service.PublicURL) []ConversionWarning {
+	r := &lossReport{format: model.FormatBedrock}
+	mediaLoss := func(part model.Part) string { return c.mediaLoss(part, publicURLs) }
+
+	for _, msg := range messages {
+		r.checkMessageName(msg)
+		for i, part := range msg.Parts {
+			if !r.checkCommon(msg, i, part) {
+				continue
+			}
+
+			switch part.Type {
+			case "image", "file":
+				if reason := mediaLoss(part); reason != "" {
+					r.dropPart(msg, i, part, reason)
+				}
+			case "audio":
+				r.dropPart(msg, i, part, "bedrock converse does not accept audio input")
+			case "tool-call":
+				if !hasToolCallIdentity(part) {
+					r.dropPart(msg, i, part, "tool call has no id or name")
+				}
+			case "tool-result":
+				if toolResultCallID(part) == "" {
+					r.dropPart(msg, i, part, "tool result has no tool_call_id")
+					continue
+				}
+				r.checkNestedContent(msg, i, part, mediaLoss)
+			}
+		}
+	}
+
+	return r.warnings
+}
+
+// mediaLoss returns why an image or file part cannot be converted, or "" if it can
+func (c *BedrockConverter) mediaLoss(part model.Part, publicURLs map[string]service.PublicURL) string {
+	if c.getAssetURL(part.Asset, publicURLs) == "" && !hasInlineData(part) && remoteURL(part) == "" && bedrockS3Location(part) == nil {
+		return fmt.Sprintf("%s has no URL or inline data", part.Type)
+	}
+
+	// Formats can only be checked when the media type is known before download
+	mediaType := partMediaType(part)
+	if mediaType == "" {
+		return ""
+	}
+	if part.Type == "image" && normalizer.BedrockImageFormat(mediaType) == "" {
+		return fmt.Sprintf("bedrock does not accept %s images", mediaType)
+	}
+	if part.Type == "file" && normalizer.BedrockDocumentFormat(mediaType) == "" {
+		return fmt.Sprintf("bedrock does not accept %s documents", mediaType)
+	}
+	return ""
+}
@@ -0,0 +1,349 @@
+package converter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/normalizer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBedrockConverter_Convert_TextMessage(t *testing.T) {
+	converter := &BedrockConverter{}
+
+	messages := []model.Message{
+		createTestMessage("user", []model.Part{
+			{Type: "text", Text: "Hello from Bedrock!"},
+		}, nil),
+	}
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+
+	bedrockMsgs, ok := result.([]normalizer.BedrockMessage)
+	require.True(t, ok)
+	require.Len(t, bedrockMsgs, 1)
+	assert.Equal(t, "user", bedrockMsgs[0].Role)
+	require.Len(t, bedrockMsgs[0].Content, 1)
+	assert.Equal(t, "Hello from Bedrock!", *bedrockMsgs[0].Content[0].Text)
+}
+
+func TestBedrockConverter_Convert_CacheControlBecomesCachePoint(t *testing.T) {
+	converter := &BedrockConverter{}
+
+	messages := []model.Message{
+		createTestMessage("user", []model.Part{
+			{
+				Type: "text",
+				Text: "Cached content",
+				Meta: map[string]any{
+					"cache_control": map[string]interface{}{"type": "ephemeral"},
+				},
+			},
+			{Type: "text", Text: "Not cached"},
+		}, nil),
+	}
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+
+	bedrockMsgs := result.([]normalizer.BedrockMessage)
+	require.Len(t, bedrockMsgs[0].Content, 3)
+	assert.NotNil(t, bedrockMsgs[0].Content[0].Text)
+	require.NotNil(t, bedrockMsgs[0].Content[1].CachePoint)
+	assert.Equal(t, "default", bedrockMsgs[0].Content[1].CachePoint.Type)
+	assert.NotNil(t, bedrockMsgs[0].Content[2].Text)
+}
+
+func TestBedrockConverter_Convert_ToolCallAndResult(t *testing.T) {
+	converter := &BedrockConverter{}
+
+	messages := []model.Message{
+		createTestMessage("assistant", []model.Part{
+			{
+				Type: "tool-call",
+				Meta: map[string]any{
+					"id":        "call_123",
+					"name":      "get_weather",
+					"arguments": "{\"city\":\"Boston\"}",
+				},
+			},
+		}, nil),
+		createTestMessage("user", []model.Part{
+			{
+				Type: "tool-result",
+				Text: "Sunny",
+				Meta: map[string]any{
+					"tool_call_id": "call_123",
+					"is_error":     true,
+				},
+			},
+		}, nil),
+	}
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+
+	bedrockMsgs := result.([]normalizer.BedrockMessage)
+	require.Len(t, bedrockMsgs, 2)
+
+	toolUse := bedrockMsgs[0].Content[0].ToolUse
+	require.NotNil(t, toolUse)
+	assert.Equal(t, "call_123", toolUse.ToolUseID)
+	assert.Equal(t, "get_weather", toolUse.Name)
+	assert.Equal(t, map[string]interface{}{"city": "Boston"}, toolUse.Input)
+
+	toolResult := bedrockMsgs[1].Content[0].ToolResult
+	require.NotNil(t, toolResult)
+	assert.Equal(t, "call_123", toolResult.ToolUseID)
+	assert.Equal(t, "error", toolResult.Status)
+	require.Len(t, toolResult.Content, 1)
+	assert.Equal(t, "Sunny", *toolResult.Content[0].Text)
+}
+
+func TestBedrockConverter_Convert_ToolIDMapping(t *testing.T) {
+	converter := &BedrockConverter{}
+
+	originalID := "functions.get_weather:0"
+	messages := []model.Message{
+		createTestMessage("assistant", []model.Part{
+			{Type: "tool-call", Meta: map[string]any{"id": originalID, "name": "get_weather", "arguments": "{}"}},
+		}, nil),
+		createTestMessage("user", []model.Part{
+			{Type: "tool-result", Text: "ok", Meta: map[string]any{"tool_call_id": originalID}},
+		}, nil),
+	}
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+
+	bedrockMsgs := result.([]normalizer.BedrockMessage)
+	toolUseID := bedrockMsgs[0].Content[0].ToolUse.ToolUseID
+	assert.NotEqual(t, originalID, toolUseID)
+	assert.Regexp(t, `^[a-zA-Z0-9_-]+$`, toolUseID)
+	assert.Equal(t, toolUseID, bedrockMsgs[1].Content[0].ToolResult.ToolUseID)
+}
+
+func TestBedrockToolUseID(t *testing.T) {
+	assert.Equal(t, "toolu_01ABC", bedrockToolUseID("toolu_01ABC"))
+
+	long := strings.Repeat("a", 100)
+	mapped := bedrockToolUseID(long)
+	assert.LessOrEqual(t, len(mapped), bedrockToolUseIDMaxLen)
+	assert.Equal(t, mapped, bedrockToolUseID(long))
+
+	// Distinct IDs that sanitize to the same string must not collide
+	assert.NotEqual(t, bedrockToolUseID("a.b"), bedrockToolUseID("a:b"))
+}
+
+func TestBedrockConverter_Convert_InlineImageAndDocument(t *testing.T) {
+	converter := &BedrockConverter{}
+
+	messages := []model.Message{
+		createTestMessage("user", []model.Part{
+			{
+				Type: "image",
+				Meta: map[string]any{"type": "base64", "media_type": "image/jpeg", "data": "aGVsbG8="},
+			},
+			{
+				Type: "file",
+				Meta: map[string]any{"file_data": "data:application/pdf;base64,aGVsbG8=", "filename": "my report.pdf"},
+			},
+			{
+				Type: "image",
+				Meta: map[string]any{"type": "base64", "media_type": "image/tiff", "data": "aGVsbG8="},
+			},
+		}, nil),
+	}
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+
+	bedrockMsgs := result.([]normalizer.BedrockMessage)
+	require.Len(t, bedrockMsgs[0].Content, 2, "unsupported image formats are dropped")
+
+	image := bedrockMsgs[0].Content[0].Image
+	require.NotNil(t, image)
+	assert.Equal(t, "jpeg", image.Format)
+	assert.Equal(t, []byte("hello"), image.Source.Bytes)
+
+	document := bedrockMsgs[0].Content[1].Document
+	require.NotNil(t, document)
+	assert.Equal(t, "pdf", document.Format)
+	assert.Equal(t, "my report", document.Name)
+
+	// Bytes are serialized as base64 like the Converse API expects
+	raw, err := json.Marshal(bedrockMsgs[0].Content[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"image":{"format":"jpeg","source":{"bytes":"aGVsbG8="}}}`, string(raw))
+}
+
+func TestBedrockConverter_Convert_S3LocationRoundTrip(t *testing.T) {
+	converter := &BedrockConverter{}
+
+	content := `[
+		{"image": {"format": "png", "source": {"s3Location": {"uri": "s3://bucket/chart.png", "bucketOwner": "111122223333"}}}},
+		{"document": {"format": "pdf", "name": "report", "source": {"s3Location": {"uri": "s3://bucket/report.pdf"}}}}
+	]`
+	role, partsIn, _, err := (&normalizer.BedrockNormalizer{}).NormalizeFromBedrockMessage(json.RawMessage(`{"role": "user", "content": ` + content + `}`))
+	require.NoError(t, err)
+
+	parts := make([]model.Part, 0, len(partsIn))
+	for _, p := range partsIn {
+		parts = append(parts, model.Part{Type: p.Type, Meta: p.Meta})
+	}
+	messages := []model.Message{createTestMessage(role, parts, nil)}
+	assert.Empty(t, converter.DetectLoss(messages, nil))
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+
+	bedrockMsgs := result.([]normalizer.BedrockMessage)
+	require.Len(t, bedrockMsgs, 1)
+	raw, err := json.Marshal(bedrockMsgs[0].Content)
+	require.NoError(t, err)
+	assert.JSONEq(t, content, string(raw))
+}
+
+func TestBedrockConverter_Convert_SkipsEmptyMessages(t *testing.T) {
+	converter := &BedrockConverter{}
+
+	messages := []model.Message{
+		createTestMessage("user", []model.Part{
+			{Type: "tool-result", Text: "orphan", Meta: map[string]any{}},
+		}, nil),
+		createTestMessage("user", []model.Part{
+			{Type: "text", Text: "Hi"},
+		}, nil),
+	}
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+	assert.Len(t, result.([]normalizer.BedrockMessage), 1)
+}
+
+func TestBedrockConverter_Convert_Reasoning(t *testing.T) {
+	converter := &BedrockConverter{}
+
+	messages := []model.Message{
+		createTestMessage("assistant", []model.Part{
+			{Type: "thinking", Text: "Let me check", Meta: map[string]any{"source_format": "bedrock", "signature": "sig_1"}},
+			{Type: "thinking", Meta: map[string]any{"source_format": "bedrock", "redacted": true, "data": "aGVsbG8="}},
+			{Type: "thinking", Text: "Foreign", Meta: map[string]any{"source_format": "openai"}},
+			{Type: "text", Text: "Done"},
+		}, nil),
+	}
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{
+		"role": "assistant",
+		"content": [
+			{"reasoningContent": {"reasoningText": {"text": "Let me check", "signature": "sig_1"}}},
+			{"reasoningContent": {"redactedContent": "aGVsbG8="}},
+			{"text": "Done"}
+		]
+	}]`, string(raw))
+}
+
+func TestBedrockConverter_Convert_RichToolResult(t *testing.T) {
+	converter := &BedrockConverter{}
+
+	messages := []model.Message{
+		createTestMessage("user", []model.Part{
+			{
+				Type: "tool-result",
+				Text: "Chart:",
+				Meta: map[string]any{"tool_call_id": "tooluse_abc"},
+				Content: []model.Part{
+					{Type: "text", Text: "Chart:"},
+					{Type: "image", Meta: map[string]any{"type": "base64", "media_type": "image/png", "data": "aGVsbG8="}},
+				},
+			},
+		}, nil),
+	}
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+
+	toolResult := result.([]normalizer.BedrockMessage)[0].Content[0].ToolResult
+	require.NotNil(t, toolResult)
+	require.Len(t, toolResult.Content, 2)
+	assert.Equal(t, "Chart:", *toolResult.Content[0].Text)
+	require.NotNil(t, toolResult.Content[1].Image)
+	assert.Equal(t, "png", toolResult.Content[1].Image.Format)
+	assert.Equal(t, []byte("hello"), toolResult.Content[1].Image.Source.Bytes)
+}
+
+func TestBedrockConverter_Convert_DownloadFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok.png":
+			_, _ = w.Write([]byte("png"))
+		case "/large.pdf":
+			_, _ = w.Write(make([]byte, bedrockMaxDocumentBytes+1))
+		case "/slow.png":
+			time.Sleep(200 * time.Millisecond)
+			_, _ = w.Write([]byte("png"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	asset := func(name string, mime string) *model.Asset {
+		return &model.Asset{SHA256: name, S3Key: "assets/" + name, MIME: mime}
+	}
+	publicURLs := map[string]service.PublicURL{}
+	for _, name := range []string{"ok.png", "missing.png", "large.pdf", "slow.png"} {
+		publicURLs[name] = service.PublicURL{URL: server.URL + "/" + name}
+	}
+
+	messages := []model.Message{
+		createTestMessage("user", []model.Part{
+			{Type: "image", Asset: asset("ok.png", "image/png")},
+			{Type: "image", Asset: asset("missing.png", "image/png")},
+			{Type: "file", Filename: "report.pdf", Asset: asset("large.pdf", "application/pdf")},
+			{
+				Type: "tool-result",
+				Meta: map[string]any{"tool_call_id": "call_1"},
+				Content: []model.Part{
+					{Type: "image", Asset: asset("slow.png", "image/png")},
+				},
+			},
+		}, nil),
+	}
+
+	converter := &BedrockConverter{client: &http.Client{Timeout: 50 * time.Millisecond}}
+	result, err := converter.Convert(messages, publicURLs)
+	require.NoError(t, err)
+
+	bedrockMsgs := result.([]normalizer.BedrockMessage)
+	require.Len(t, bedrockMsgs[0].Content, 2)
+	require.NotNil(t, bedrockMsgs[0].Content[0].Image)
+	assert.Equal(t, []byte("png"), bedrockMsgs[0].Content[0].Image.Source.Bytes)
+	require.NotNil(t, bedrockMsgs[0].Content[1].ToolResult)
+
+	losses := converter.ConvertLoss()
+	require.Len(t, losses, 3)
+	assert.Equal(t, 1, losses[0].PartIndex)
+	assert.Contains(t, losses[0].Reason, "status 404")
+	assert.Equal(t, 2, losses[1].PartIndex)
+	assert.Contains(t, losses[1].Reason, "larger than")
+	assert.Equal(t, 3, losses[2].PartIndex)
+	assert.Equal(t, "content[0]", losses[2].Field)
+	for _, l := range losses {
+		// Presigned URLs stay out of the warnings
+		assert.NotContains(t, l.Reason, server.URL)
+	}
+}
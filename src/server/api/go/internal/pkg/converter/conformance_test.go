@@ -0,0 +1,320 @@
+package converter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/normalizer"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+// The conformance suite normalizes a short agent conversation in every format, converts it to every
+// other format and normalizes the result again. Every part (or field) that does not survive the round
+// trip must be reported by DetectLoss, and nothing that survives may be reported.
+
+const (
+	conformancePNG = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk+M9QDwADhgGAWjR9awAAAABJRU5ErkJggg=="
+	conformancePDF = "JVBERi0xLjQKJcfsj6IKMSAwIG9iago8PD4+CmVuZG9iagp0cmFpbGVyCjw8Pj4KJSVFT0YK"
+)
+
+var conformanceFixtures = map[model.MessageFormat][]string{
+	model.FormatAcontext: {
+		`{"role": "user", "parts": [
+			{"type": "text", "text": "Check this", "meta": {"cache_control": {"type": "ephemeral"}}},
+			{"type": "image", "meta": {"type": "base64", "media_type": "image/png", "data": "` + conformancePNG + `"}},
+			{"type": "data", "meta": {"data_type": "json", "content": "{\"k\": 1}"}}
+		]}`,
+		`{"role": "assistant", "parts": [
+			{"type": "tool-call", "meta": {"id": "call_1", "name": "get_weather", "arguments": "{\"city\": \"SF\"}"}}
+		]}`,
+		`{"role": "user", "parts": [
+			{"type": "tool-result", "text": "Sunny", "meta": {"tool_call_id": "call_1", "is_error": true}}
+		]}`,
+	},
+	model.FormatOpenAI: {
+		`{"role": "user", "name": "alice", "content": [
+			{"type": "text", "text": "What is in this image?"},
+			{"type": "image_url", "image_url": {"url": "data:image/png;base64,` + conformancePNG + `"}}
+		]}`,
+		`{"role": "assistant", "content": "Let me check", "reasoning_content": "The user wants the weather",
+			"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\": \"SF\"}"}}]}`,
+		`{"role": "tool", "tool_call_id": "call_1", "content": "Sunny"}`,
+		`{"role": "user", "content": [
+			{"type": "text", "text": "Summarize"},
+			{"type": "file", "file": {"file_data": "data:application/pdf;base64,` + conformancePDF + `", "filename": "report.pdf"}}
+		]}`,
+	},
+	model.FormatAnthropic: {
+		`{"role": "user", "content": [
+			{"type": "text", "text": "Check this", "cache_control": {"type": "ephemeral"}},
+			{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "` + conformancePNG + `"}}
+		]}`,
+		`{"role": "assistant", "content": [
+			{"type": "thinking", "thinking": "Two tools needed", "signature": "sig_abc"},
+			{"type": "text", "text": "Calling tools"},
+			{"type": "tool_use", "id": "toolu_1", "name": "screenshot", "input": {}},
+			{"type": "tool_use", "id": "toolu_2", "name": "get_time", "input": {"tz": "UTC"}}
+		]}`,
+		`{"role": "user", "content": [
+			{"type": "tool_result", "tool_use_id": "toolu_1", "content": [
+				{"type": "text", "text": "Screenshot taken"},
+				{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "` + conformancePNG + `"}}
+			]},
+			{"type": "tool_result", "tool_use_id": "toolu_2", "content": "boom", "is_error": true}
+		]}`,
+		`{"role": "user", "content": [
+			{"type": "document", "source": {"type": "base64", "media_type": "application/pdf", "data": "` + conformancePDF + `"}}
+		]}`,
+	},
+	model.FormatGemini: {
+		`{"role": "user", "parts": [
+			{"text": "What is in this image?"},
+			{"inlineData": {"mimeType": "image/png", "data": "` + conformancePNG + `"}}
+		]}`,
+		`{"role": "model", "parts": [
+			{"text": "Looking at the weather", "thought": true},
+			{"functionCall": {"id": "call_g1", "name": "get_weather", "args": {"city": "SF"}}, "thoughtSignature": "c2lnbmF0dXJl"}
+		]}`,
+		`{"role": "user", "parts": [
+			{"functionResponse": {"id": "call_g1", "name": "get_weather", "response": {"temp": 72}}}
+		]}`,
+	},
+	model.FormatBedrock: {
+		`{"role": "user", "content": [
+			{"text": "Check this"},
+			{"cachePoint": {"type": "default"}},
+			{"image": {"format": "png", "source": {"bytes": "` + conformancePNG + `"}}}
+		]}`,
+		`{"role": "assistant", "content": [
+			{"reasoningContent": {"reasoningText": {"text": "Need the weather", "signature": "sig_b"}}},
+			{"toolUse": {"toolUseId": "tooluse_1", "name": "get_weather", "input": {"city": "SF"}}}
+		]}`,
+		`{"role": "user", "content": [
+			{"toolResult": {"toolUseId": "tooluse_1", "status": "error", "content": [
+				{"json": {"temp": 72}},
+				{"image": {"format": "png", "source": {"bytes": "` + conformancePNG + `"}}}
+			]}}
+		]}`,
+		`{"role": "user", "content": [
+			{"document": {"format": "pdf", "name": "report", "source": {"bytes": "` + conformancePDF + `"}}}
+		]}`,
+	},
+}
+
+var conformanceFormats = []model.MessageFormat{
+	model.FormatAcontext,
+	model.FormatOpenAI,
+	model.FormatAnthropic,
+	model.FormatGemini,
+	model.FormatBedrock,
+}
+
+func TestConversionConformance(t *testing.T) {
+	for _, source := range conformanceFormats {
+		messages := make([]model.Message, 0, len(conformanceFixtures[source]))
+		for _, raw := range conformanceFixtures[source] {
+			role, parts, meta, err := normalizeForConformance(source, json.RawMessage(raw))
+			require.NoError(t, err, "fixture of %s must normalize", source)
+			messages = append(messages, model.Message{
+				ID:    uuid.New(),
+				Role:  role,
+				Parts: partsFromPartIn(parts),
+				Meta:  datatypes.NewJSONType(meta),
+			})
+		}
+
+		for _, target := range conformanceFormats {
+			t.Run(fmt.Sprintf("%s_to_%s", source, target), func(t *testing.T) {
+				converted, err := ConvertMessages(ConvertMessagesInput{Messages: messages, Format: target})
+				require.NoError(t, err)
+
+				warnings, err := DetectConversionLoss(messages, target, nil)
+				require.NoError(t, err)
+				warned := map[string]bool{}
+				for _, w := range warnings {
+					warned[fmt.Sprintf("%s/%d", w.MessageID, w.PartIndex)] = true
+				}
+
+				survived := roundTripAtoms(t, target, converted)
+
+				for _, msg := range messages {
+					for i, atoms := range messageAtoms(msg) {
+						lost := false
+						for _, atom := range atoms {
+							if survived[atom] > 0 {
+								survived[atom]--
+							} else {
+								lost = true
+							}
+						}
+
+						key := fmt.Sprintf("%s/%d", msg.ID, i)
+						if lost {
+							require.True(t, warned[key], "%s -> %s: part %d of a %s message is lost without a warning: %v", source, target, i, msg.Role, atoms)
+						} else {
+							require.False(t, warned[key], "%s -> %s: part %d of a %s message survives but is reported as lost: %v", source, target, i, msg.Role, atoms)
+						}
+					}
+				}
+
+				// Strict mode fails exactly when something is lost
+				_, err = ConvertMessages(ConvertMessagesInput{Messages: messages, Format: target, Strict: true})
+				if len(warnings) > 0 {
+					var lossErr *LossError
+					require.ErrorAs(t, err, &lossErr)
+					require.Len(t, lossErr.Warnings, len(warnings))
+				} else {
+					require.NoError(t, err)
+				}
+			})
+		}
+	}
+}
+
+func normalizeForConformance(format model.MessageFormat, raw json.RawMessage) (string, []service.PartIn, map[string]interface{}, error) {
+	switch format {
+	case model.FormatAcontext:
+		return (&normalizer.AcontextNormalizer{}).NormalizeFromAcontextMessage(raw)
+	case model.FormatOpenAI:
+		return (&normalizer.OpenAINormalizer{}).NormalizeFromOpenAIMessage(raw)
+	case model.FormatAnthropic:
+		return (&normalizer.AnthropicNormalizer{}).NormalizeFromAnthropicMessage(raw)
+	case model.FormatGemini:
+		return (&normalizer.GeminiNormalizer{}).NormalizeFromGeminiMessage(raw)
+	case model.FormatBedrock:
+		return (&normalizer.BedrockNormalizer{}).NormalizeFromBedrockMessage(raw)
+	}
+	return "", nil, nil, fmt.Errorf("unsupported format: %s", format)
+}
+
+func partsFromPartIn(in []service.PartIn) []model.Part {
+	parts := make([]model.Part, 0, len(in))
+	for _, p := range in {
+		parts = append(parts, model.Part{
+			Type:    p.Type,
+			Text:    p.Text,
+			Meta:    p.Meta,
+			Content: partsFromPartIn(p.Content),
+		})
+	}
+	return parts
+}
+
+// roundTripAtoms normalizes converted messages again and counts the atoms that survived
+func roundTripAtoms(t *testing.T, format model.MessageFormat, converted interface{}) map[string]int {
+	data, err := json.Marshal(converted)
+	require.NoError(t, err)
+
+	var rawMessages []json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &rawMessages))
+
+	survived := map[string]int{}
+	for _, raw := range rawMessages {
+		role, parts, meta, err := normalizeForConformance(format, raw)
+		require.NoError(t, err, "converted %s message must normalize: %s", format, raw)
+		msg := model.Message{Role: role, Parts: partsFromPartIn(parts), Meta: datatypes.NewJSONType(meta)}
+		for _, atoms := range messageAtoms(msg) {
+			for _, atom := range atoms {
+				survived[atom]++
+			}
+		}
+	}
+	return survived
+}
+
+// messageAtoms returns the comparable facts of a message keyed by part index; -1 holds message-level facts.
+// Nested media of tool results are separate atoms, since some formats move them out of the tool result.
+func messageAtoms(msg model.Message) map[int][]string {
+	atoms := map[int][]string{}
+	if name, _ := msg.Meta.Data()["name"].(string); name != "" {
+		atoms[-1] = []string{"name|" + name}
+	}
+	for i, part := range msg.Parts {
+		atoms[i] = []string{partFingerprint(part)}
+		for _, nested := range part.Content {
+			if nested.Type == "image" || nested.Type == "file" {
+				atoms[i] = append(atoms[i], partFingerprint(nested))
+			}
+		}
+	}
+	return atoms
+}
+
+func partFingerprint(part model.Part) string {
+	fields := []string{part.Type}
+	switch part.Type {
+	case "text":
+		fields = append(fields, part.Text)
+	case "image", "file":
+		fields = append(fields, mediaKey(part))
+	case "tool-call":
+		arguments, _ := part.Meta["arguments"].(string)
+		fields = append(fields, fmt.Sprint(part.Meta["name"]), canonicalJSON(arguments))
+	case "tool-result":
+		fields = append(fields, toolResultText(part.Text))
+		if toolResultIsError(part) {
+			fields = append(fields, "error")
+		}
+	case "thinking":
+		fields = append(fields, part.Text, fmt.Sprint(part.Meta["source_format"]), fmt.Sprint(part.Meta["signature"]), fmt.Sprint(part.Meta["data"]))
+	default:
+		meta, _ := json.Marshal(part.Meta)
+		fields = append(fields, part.Text, string(meta))
+	}
+
+	if _, ok := part.Meta["cache_control"]; ok {
+		fields = append(fields, "cache_control")
+	}
+	if signature, ok := part.Meta["thought_signature"]; ok {
+		fields = append(fields, fmt.Sprintf("thought_signature=%v", signature))
+	}
+	return strings.Join(fields, "|")
+}
+
+// mediaKey identifies media by content, whichever way it is carried
+func mediaKey(part model.Part) string {
+	if sourceType, _ := part.Meta["type"].(string); sourceType == "base64" {
+		data, _ := part.Meta["data"].(string)
+		return "bytes:" + data
+	}
+	for _, key := range []string{"url", "file_data"} {
+		if value, _ := part.Meta[key].(string); value != "" {
+			if strings.HasPrefix(value, "data:") {
+				_, data, _ := strings.Cut(value, ",")
+				decoded, _ := base64.StdEncoding.DecodeString(data)
+				return "bytes:" + base64.StdEncoding.EncodeToString(decoded)
+			}
+			return "url:" + value
+		}
+	}
+	return "none"
+}
+
+// toolResultText compares tool results by content: JSON is canonicalized and the
+// {"output": ...} wrapper Gemini puts around plain-text results is removed
+func toolResultText(text string) string {
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &object); err != nil {
+		return text
+	}
+	if output, ok := object["output"].(string); ok && len(object) == 1 {
+		return output
+	}
+	return canonicalJSON(text)
+}
+
+func canonicalJSON(text string) string {
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return text
+	}
+	canonical, _ := json.Marshal(value)
+	return string(canonical)
+}
@@ -3,6 +3,8 @@ package converter
 import (
 	"fmt"
 
+	openai "github.com/openai/openai-go/v3"
+
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/service"
 )
@@ -12,47 +14,114 @@ type ConvertMessagesInput struct {
 	Messages   []model.Message
 	Format     model.MessageFormat
 	PublicURLs map[string]service.PublicURL
+	Strict     bool // fail with a *LossError instead of dropping information
 }
 
 // MessageConverter interface for extensible message conversion
 type MessageConverter interface {
 	Convert(messages []model.Message, publicURLs map[string]service.PublicURL) (interface{}, error)
+	// DetectLoss reports the parts and fields that Convert drops for this format
+	DetectLoss(messages []model.Message, publicURLs map[string]service.PublicURL) []ConversionWarning
+}
+
+// convertLossReporter is implemented by converters that find some losses only while converting,
+// such as media that cannot be downloaded
+type convertLossReporter interface {
+	// ConvertLoss reports what the last Convert dropped beyond what DetectLoss reports
+	ConvertLoss() []ConversionWarning
 }
 
 // ConvertMessages converts messages to the specified format
 func ConvertMessages(input ConvertMessagesInput) (interface{}, error) {
-	var converter MessageConverter
+	converted, _, err := convertMessages(input)
+	return converted, err
+}
+
+// convertMessages converts messages like ConvertMessages, also returning the losses that were only
+// found while converting. In strict mode these fail the conversion.
+func convertMessages(input ConvertMessagesInput) (interface{}, []ConversionWarning, error) {
+	converter, err := newMessageConverter(input.Format)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if input.Strict {
+		if warnings := converter.DetectLoss(input.Messages, input.PublicURLs); len(warnings) > 0 {
+			return nil, nil, &LossError{Format: input.Format, Warnings: warnings}
+		}
+	}
 
+	converted, err := converter.Convert(input.Messages, input.PublicURLs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var losses []ConversionWarning
+	if reporter, ok := converter.(convertLossReporter); ok {
+		losses = reporter.ConvertLoss()
+	}
+	if input.Strict && len(losses) > 0 {
+		return nil, nil, &LossError{Format: input.Format, Warnings: losses}
+	}
+	return converted, losses, nil
+}
+
+func newMessageConverter(format model.MessageFormat) (MessageConverter, error) {
 	// Default to Acontext format if not specified
-	format := input.Format
 	if format == "" {
 		format = model.FormatAcontext
 	}
 
 	switch format {
 	case model.FormatAcontext:
-		converter = &AcontextConverter{}
+		return &AcontextConverter{}, nil
 	case model.FormatOpenAI:
-		converter = &OpenAIConverter{}
+		return &OpenAIConverter{}, nil
 	case model.FormatAnthropic:
-		converter = &AnthropicConverter{}
+		return &AnthropicConverter{}, nil
 	case model.FormatGemini:
-		converter = &GeminiConverter{}
+		return &GeminiConverter{}, nil
+	case model.FormatBedrock:
+		return &BedrockConverter{}, nil
 	default:
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
+}
 
-	return converter.Convert(input.Messages, input.PublicURLs)
+// isThinkingFromFormat reports whether a thinking part was produced by the given provider format.
+// Thinking signatures and encrypted reasoning payloads are only valid for the provider that issued them,
+// so converters replay thinking parts in their own format and drop them for every other target.
+func isThinkingFromFormat(part model.Part, format model.MessageFormat) bool {
+	if part.Type != "thinking" || part.Meta == nil {
+		return false
+	}
+	sourceFormat, _ := part.Meta["source_format"].(string)
+	return sourceFormat == string(format)
+}
+
+// assetPublicURL looks up the presigned URL of an asset.
+// The session service keys public URLs by SHA256; S3 keys are accepted for callers that key by object.
+func assetPublicURL(asset *model.Asset, publicURLs map[string]service.PublicURL) string {
+	if asset == nil {
+		return ""
+	}
+	if publicURL, ok := publicURLs[asset.SHA256]; ok && asset.SHA256 != "" {
+		return publicURL.URL
+	}
+	if publicURL, ok := publicURLs[asset.S3Key]; ok {
+		return publicURL.URL
+	}
+	return ""
 }
 
 // ValidateFormat checks if the format is valid
 func ValidateFormat(format string) (model.MessageFormat, error) {
 	mf := model.MessageFormat(format)
 	switch mf {
-	case model.FormatAcontext, model.FormatOpenAI, model.FormatAnthropic, model.FormatGemini:
+	case model.FormatAcontext, model.FormatOpenAI, model.FormatAnthropic, model.FormatGemini, model.FormatBedrock:
 		return mf, nil
 	default:
-		return "", fmt.Errorf("invalid format: %s, supported formats: acontext, openai, anthropic, gemini", format)
+		return "", fmt.Errorf("invalid format: %s, supported formats: acontext, openai, anthropic, gemini, bedrock", format)
 	}
 }
 
@@ -65,11 +134,14 @@ func GetConvertedMessagesOutput(
 	hasMore bool,
 	thisTimeTokens int,
 	editAtMessageID string,
+	systemPrompt string,
+	strict bool,
 ) (map[string]interface{}, error) {
-	convertedData, err := ConvertMessages(ConvertMessagesInput{
+	convertedData, losses, err := convertMessages(ConvertMessagesInput{
 		Messages:   messages,
 		Format:     format,
 		PublicURLs: publicURLs,
+		Strict:     strict,
 	})
 	if err != nil {
 		return nil, err
@@ -97,6 +169,23 @@ func GetConvertedMessagesOutput(
 		result["edit_at_message_id"] = editAtMessageID
 	}
 
+	// Include the session system prompt where the target provider expects it
+	if systemPrompt != "" {
+		applySystemPrompt(result, format, systemPrompt)
+	}
+
+	// Report what the conversion dropped, per message (strict mode fails instead)
+	if !strict {
+		warnings, err := DetectConversionLoss(messages, format, publicURLs)
+		if err != nil {
+			return nil, err
+		}
+		warnings = mergeWarnings(warnings, losses)
+		if len(warnings) > 0 {
+			result["conversion_warnings"] = GroupWarningsByMessage(warnings)
+		}
+	}
+
 	// Include public_urls only if format is None (original format)
 	if format == model.FormatAcontext && len(publicURLs) > 0 {
 		result["public_urls"] = publicURLs
@@ -104,3 +193,24 @@ func GetConvertedMessagesOutput(
 
 	return result, nil
 }
+
+// applySystemPrompt places the session system prompt into the converted output.
+// OpenAI carries it as the first message; Anthropic, Gemini and Bedrock take it as a
+// separate request field, so it is returned next to the items under that provider's key.
+func applySystemPrompt(result map[string]interface{}, format model.MessageFormat, systemPrompt string) {
+	switch format {
+	case model.FormatOpenAI:
+		if items, ok := result["items"].([]openai.ChatCompletionMessageParamUnion); ok {
+			systemMsg := (&OpenAIConverter{}).ConvertSystemPrompt(systemPrompt)
+			result["items"] = append([]openai.ChatCompletionMessageParamUnion{systemMsg}, items...)
+		}
+	case model.FormatAnthropic:
+		result["system"] = (&AnthropicConverter{}).ConvertSystemPrompt(systemPrompt)
+	case model.FormatGemini:
+		result["system_instruction"] = (&GeminiConverter{}).ConvertSystemPrompt(systemPrompt)
+	case model.FormatBedrock:
+		result["system"] = (&BedrockConverter{}).ConvertSystemPrompt(systemPrompt)
+	default:
+		result["system_prompt"] = systemPrompt
+	}
+}
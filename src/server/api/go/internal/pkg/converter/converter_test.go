@@ -1,6 +1,9 @@
 package converter
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -79,6 +82,7 @@ func TestConvertMessages_AllFormats(t *testing.T) {
 		model.FormatOpenAI,
 		model.FormatAnthropic,
 		model.FormatGemini,
+		model.FormatBedrock,
 	}
 
 	for _, format := range formats {
@@ -126,6 +130,12 @@ func TestValidateFormat(t *testing.T) {
 			want:    model.FormatGemini,
 			wantErr: false,
 		},
+		{
+			name:    "valid bedrock",
+			format:  "bedrock",
+			want:    model.FormatBedrock,
+			wantErr: false,
+		},
 		{
 			name:    "invalid format",
 			format:  "invalid",
@@ -170,8 +180,10 @@ func TestGetConvertedMessagesOutput(t *testing.T) {
 		publicURLs,
 		"next_cursor_123",
 		true,
-		100, // thisTimeTokens
-		"",  // editAtMessageID
+		100,   // thisTimeTokens
+		"",    // editAtMessageID
+		"",    // systemPrompt
+		false, // strict
 	)
 
 	require.NoError(t, err)
@@ -202,8 +214,10 @@ func TestGetConvertedMessagesOutput_NonAcontextFormat(t *testing.T) {
 		publicURLs,
 		"",
 		false,
-		50, // thisTimeTokens
-		"", // editAtMessageID
+		50,    // thisTimeTokens
+		"",    // editAtMessageID
+		"",    // systemPrompt
+		false, // strict
 	)
 
 	require.NoError(t, err)
@@ -216,6 +230,61 @@ func TestGetConvertedMessagesOutput_NonAcontextFormat(t *testing.T) {
 	assert.Nil(t, result["public_urls"])
 }
 
+func TestGetConvertedMessagesOutput_ConversionWarnings(t *testing.T) {
+	messages := []model.Message{
+		createTestMessage("user", []model.Part{
+			{Type: "text", Text: "Test"},
+			{Type: "data", Meta: map[string]interface{}{"data_type": "json"}},
+		}, nil),
+	}
+
+	result, err := GetConvertedMessagesOutput(messages, model.FormatOpenAI, nil, "", false, 0, "", "", false)
+	require.NoError(t, err)
+
+	warnings, ok := result["conversion_warnings"].(map[string][]ConversionWarning)
+	require.True(t, ok)
+	require.Len(t, warnings[messages[0].ID.String()], 1)
+	assert.Equal(t, 1, warnings[messages[0].ID.String()][0].PartIndex)
+	assert.Equal(t, "data", warnings[messages[0].ID.String()][0].PartType)
+
+	// Lossless conversions carry no warnings
+	result, err = GetConvertedMessagesOutput(messages[:0], model.FormatOpenAI, nil, "", false, 0, "", "", false)
+	require.NoError(t, err)
+	assert.Nil(t, result["conversion_warnings"])
+
+	// Strict mode fails instead
+	_, err = GetConvertedMessagesOutput(messages, model.FormatOpenAI, nil, "", false, 0, "", "", true)
+	var lossErr *LossError
+	require.ErrorAs(t, err, &lossErr)
+	assert.Equal(t, model.FormatOpenAI, lossErr.Format)
+}
+
+func TestGetConvertedMessagesOutput_DownloadWarnings(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	messages := []model.Message{
+		createTestMessage("user", []model.Part{
+			{Type: "text", Text: "Describe this"},
+			{Type: "image", Asset: &model.Asset{SHA256: "abc", S3Key: "assets/abc.png", MIME: "image/png"}},
+		}, nil),
+	}
+	publicURLs := map[string]service.PublicURL{"abc": {URL: server.URL + "/abc.png"}}
+
+	// Media that could not be downloaded is reported with the other losses
+	result, err := GetConvertedMessagesOutput(messages, model.FormatBedrock, publicURLs, "", false, 0, "", "", false)
+	require.NoError(t, err)
+	warnings := result["conversion_warnings"].(map[string][]ConversionWarning)[messages[0].ID.String()]
+	require.Len(t, warnings, 1)
+	assert.Equal(t, 1, warnings[0].PartIndex)
+	assert.Equal(t, "image", warnings[0].PartType)
+
+	_, err = GetConvertedMessagesOutput(messages, model.FormatBedrock, publicURLs, "", false, 0, "", "", true)
+	var lossErr *LossError
+	require.ErrorAs(t, err, &lossErr)
+	assert.Equal(t, model.FormatBedrock, lossErr.Format)
+}
+
 func TestGetConvertedMessagesOutput_EmptyMessages(t *testing.T) {
 	// Test with empty message list
 	messages := []model.Message{}
@@ -226,8 +295,10 @@ func TestGetConvertedMessagesOutput_EmptyMessages(t *testing.T) {
 		nil,
 		"",
 		false,
-		0,  // thisTimeTokens
-		"", // editAtMessageID
+		0,     // thisTimeTokens
+		"",    // editAtMessageID
+		"",    // systemPrompt
+		false, // strict
 	)
 
 	require.NoError(t, err)
@@ -262,8 +333,10 @@ func TestGetConvertedMessagesOutput_SingleMessage(t *testing.T) {
 		nil,
 		"cursor-123",
 		true,
-		25, // thisTimeTokens
-		"", // editAtMessageID
+		25,    // thisTimeTokens
+		"",    // editAtMessageID
+		"",    // systemPrompt
+		false, // strict
 	)
 
 	require.NoError(t, err)
@@ -301,8 +374,10 @@ func TestGetConvertedMessagesOutput_IDOrderMatchesItemOrder(t *testing.T) {
 		nil,
 		"",
 		false,
-		75, // thisTimeTokens
-		"", // editAtMessageID
+		75,    // thisTimeTokens
+		"",    // editAtMessageID
+		"",    // systemPrompt
+		false, // strict
 	)
 
 	require.NoError(t, err)
@@ -332,8 +407,10 @@ func TestGetConvertedMessagesOutput_DifferentFormats(t *testing.T) {
 			nil,
 			"",
 			false,
-			30, // thisTimeTokens
-			"", // editAtMessageID
+			30,    // thisTimeTokens
+			"",    // editAtMessageID
+			"",    // systemPrompt
+			false, // strict
 		)
 
 		require.NoError(t, err, "format %s should not error", format)
@@ -365,8 +442,10 @@ func TestGetConvertedMessagesOutput_WithPublicURLs(t *testing.T) {
 		publicURLs,
 		"",
 		false,
-		42, // thisTimeTokens
-		"", // editAtMessageID
+		42,    // thisTimeTokens
+		"",    // editAtMessageID
+		"",    // systemPrompt
+		false, // strict
 	)
 
 	require.NoError(t, err)
@@ -380,3 +459,51 @@ func TestGetConvertedMessagesOutput_WithPublicURLs(t *testing.T) {
 
 	assert.Equal(t, 42, result["this_time_tokens"])
 }
+
+func TestGetConvertedMessagesOutput_SystemPrompt(t *testing.T) {
+	messages := []model.Message{
+		createTestMessage("user", []model.Part{
+			{Type: "text", Text: "Hello"},
+		}, nil),
+	}
+
+	tests := []struct {
+		format   model.MessageFormat
+		wantKey  string
+		wantJSON string
+	}{
+		{model.FormatAcontext, "system_prompt", `"Be concise."`},
+		{model.FormatAnthropic, "system", `[{"text":"Be concise.","type":"text"}]`},
+		{model.FormatGemini, "system_instruction", `{"parts":[{"text":"Be concise."}]}`},
+		{model.FormatBedrock, "system", `[{"text":"Be concise."}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			result, err := GetConvertedMessagesOutput(messages, tt.format, nil, "", false, 0, "", "Be concise.", false)
+			require.NoError(t, err)
+
+			raw, err := json.Marshal(result[tt.wantKey])
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.wantJSON, string(raw))
+		})
+	}
+
+	t.Run("openai", func(t *testing.T) {
+		result, err := GetConvertedMessagesOutput(messages, model.FormatOpenAI, nil, "", false, 0, "", "Be concise.", false)
+		require.NoError(t, err)
+
+		raw, err := json.Marshal(result["items"])
+		require.NoError(t, err)
+		assert.JSONEq(t, `[
+			{"role": "system", "content": "Be concise."},
+			{"role": "user", "content": "Hello"}
+		]`, string(raw))
+	})
+
+	t.Run("no system prompt", func(t *testing.T) {
+		result, err := GetConvertedMessagesOutput(messages, model.FormatAnthropic, nil, "", false, 0, "", "", false)
+		require.NoError(t, err)
+		assert.NotContains(t, result, "system")
+	})
+}
@@ -3,6 +3,7 @@ package converter
 import (
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -18,8 +19,24 @@ type GeminiConverter struct{}
 
 func (c *GeminiConverter) Convert(messages []model.Message, publicURLs map[string]service.PublicURL) (interface{}, error) {
 	// First pass: collect tool-call IDs and their function names
-	// This mapping is needed because Gemini FunctionResponse requires function name,
-	// but tool-result parts may only have tool_call_id
+	toolCallIDToName := c.collectToolCallNames(messages)
+
+	// Second pass: convert messages using the mapping
+	result := make([]*genai.Content, 0, len(messages))
+	for _, msg := range messages {
+		geminiContent := c.convertMessage(msg, publicURLs, toolCallIDToName)
+		if geminiContent != nil {
+			result = append(result, geminiContent)
+		}
+	}
+
+	return result, nil
+}
+
+// collectToolCallNames maps tool-call IDs to their function names.
+// This mapping is needed because Gemini FunctionResponse requires function name,
+// but tool-result parts may only have tool_call_id
+func (c *GeminiConverter) collectToolCallNames(messages []model.Message) map[string]string {
 	toolCallIDToName := make(map[string]string)
 	for _, msg := range messages {
 		if msg.Role == "assistant" {
@@ -34,17 +51,14 @@ func (c *GeminiConverter) Convert(messages []model.Message, publicURLs map[strin
 			}
 		}
 	}
+	return toolCallIDToName
+}
 
-	// Second pass: convert messages using the mapping
-	result := make([]*genai.Content, 0, len(messages))
-	for _, msg := range messages {
-		geminiContent := c.convertMessage(msg, publicURLs, toolCallIDToName)
-		if geminiContent != nil {
-			result = append(result, geminiContent)
-		}
+// ConvertSystemPrompt converts the session system prompt to a Gemini systemInstruction
+func (c *GeminiConverter) ConvertSystemPrompt(systemPrompt string) *genai.Content {
+	return &genai.Content{
+		Parts: []*genai.Part{{Text: systemPrompt}},
 	}
-
-	return result, nil
 }
 
 func (c *GeminiConverter) convertMessage(msg model.Message, publicURLs map[string]service.PublicURL, toolCallIDToName map[string]string) *genai.Content {
@@ -85,11 +99,22 @@ func (c *GeminiConverter) convertParts(parts []model.Part, publicURLs map[string
 		case "text":
 			if part.Text != "" {
 				geminiParts = append(geminiParts, &genai.Part{
-					Text: part.Text,
+					Text:             part.Text,
+					ThoughtSignature: c.thoughtSignature(part),
 				})
 			}
 
-		case "image":
+		case "thinking":
+			// Only Gemini-issued thoughts can be replayed; other providers' reasoning is dropped
+			if isThinkingFromFormat(part, model.FormatGemini) {
+				thoughtPart := c.convertThinkingPart(part)
+				if thoughtPart != nil {
+					geminiParts = append(geminiParts, thoughtPart)
+				}
+			}
+
+		case "image", "file":
+			// Gemini takes images and documents alike as inline data
 			imagePart := c.convertImagePart(part, publicURLs)
 			if imagePart != nil {
 				geminiParts = append(geminiParts, imagePart)
@@ -101,7 +126,8 @@ func (c *GeminiConverter) convertParts(parts []model.Part, publicURLs map[string
 				functionCall := c.convertToolCallPart(part)
 				if functionCall != nil {
 					geminiParts = append(geminiParts, &genai.Part{
-						FunctionCall: functionCall,
+						FunctionCall:     functionCall,
+						ThoughtSignature: c.thoughtSignature(part),
 					})
 				}
 			}
@@ -109,7 +135,7 @@ func (c *GeminiConverter) convertParts(parts []model.Part, publicURLs map[string
 		case "tool-result":
 			// UNIFIED FORMAT: Convert tool-result to Gemini FunctionResponse
 			if part.Meta != nil {
-				functionResponse := c.convertToolResultPart(part, toolCallIDToName)
+				functionResponse := c.convertToolResultPart(part, publicURLs, toolCallIDToName)
 				if functionResponse != nil {
 					geminiParts = append(geminiParts, &genai.Part{
 						FunctionResponse: functionResponse,
@@ -123,11 +149,29 @@ func (c *GeminiConverter) convertParts(parts []model.Part, publicURLs map[string
 }
 
 func (c *GeminiConverter) convertImagePart(part model.Part, publicURLs map[string]service.PublicURL) *genai.Part {
+	// Inline base64 image kept in meta
+	if part.Asset == nil && part.Meta != nil {
+		if sourceType, _ := part.Meta["type"].(string); sourceType == "base64" {
+			mimeType, _ := part.Meta["media_type"].(string)
+			data, _ := part.Meta["data"].(string)
+			if dataBytes, err := base64.StdEncoding.DecodeString(data); err == nil && len(dataBytes) > 0 {
+				return &genai.Part{
+					InlineData: &genai.Blob{
+						MIMEType: mimeType,
+						Data:     dataBytes,
+					},
+				}
+			}
+		}
+	}
+
 	// Try to get image URL from asset
 	imageURL := c.getAssetURL(part.Asset, publicURLs)
 	if imageURL == "" && part.Meta != nil {
 		if url, ok := part.Meta["url"].(string); ok {
 			imageURL = url
+		} else if fileData, ok := part.Meta["file_data"].(string); ok {
+			imageURL = fileData
 		}
 	}
 
@@ -173,6 +217,11 @@ func (c *GeminiConverter) convertImagePart(part model.Part, publicURLs map[strin
 		return nil
 	}
 
+	// The media type recorded at upload is more reliable than the download response header
+	if part.Asset != nil && part.Asset.MIME != "" {
+		mimeType = part.Asset.MIME
+	}
+
 	return &genai.Part{
 		InlineData: &genai.Blob{
 			MIMEType: mimeType,
@@ -181,6 +230,38 @@ func (c *GeminiConverter) convertImagePart(part model.Part, publicURLs map[strin
 	}
 }
 
+func (c *GeminiConverter) convertThinkingPart(part model.Part) *genai.Part {
+	thoughtPart := &genai.Part{
+		Text:    part.Text,
+		Thought: true,
+	}
+	if signature, ok := part.Meta["signature"].(string); ok && signature != "" {
+		if decoded, err := base64.StdEncoding.DecodeString(signature); err == nil {
+			thoughtPart.ThoughtSignature = decoded
+		}
+	}
+	if thoughtPart.Text == "" && len(thoughtPart.ThoughtSignature) == 0 {
+		return nil
+	}
+	return thoughtPart
+}
+
+// thoughtSignature returns the decoded Gemini thought signature stored on a text or tool-call part
+func (c *GeminiConverter) thoughtSignature(part model.Part) []byte {
+	if part.Meta == nil {
+		return nil
+	}
+	signature, ok := part.Meta["thought_signature"].(string)
+	if !ok || signature == "" {
+		return nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil
+	}
+	return decoded
+}
+
 func (c *GeminiConverter) convertToolCallPart(part model.Part) *genai.FunctionCall {
 	if part.Meta == nil {
 		return nil
@@ -218,7 +299,7 @@ func (c *GeminiConverter) convertToolCallPart(part model.Part) *genai.FunctionCa
 	return functionCall
 }
 
-func (c *GeminiConverter) convertToolResultPart(part model.Part, toolCallIDToName map[string]string) *genai.FunctionResponse {
+func (c *GeminiConverter) convertToolResultPart(part model.Part, publicURLs map[string]service.PublicURL, toolCallIDToName map[string]string) *genai.FunctionResponse {
 	if part.Meta == nil {
 		return nil
 	}
@@ -264,9 +345,52 @@ func (c *GeminiConverter) convertToolResultPart(part model.Part, toolCallIDToNam
 		functionResponse.ID = toolCallID
 	}
 
+	// Images and documents of a rich tool result become multimodal function response parts
+	for _, nested := range part.Content {
+		if nested.Type != "image" && nested.Type != "file" {
+			continue
+		}
+		if responsePart := c.convertFunctionResponseMedia(nested, publicURLs); responsePart != nil {
+			functionResponse.Parts = append(functionResponse.Parts, responsePart)
+		}
+	}
+
 	return functionResponse
 }
 
+// convertFunctionResponseMedia converts a nested image or file part into a FunctionResponsePart.
+// Remote URLs that are not stored assets are passed by reference; everything else is sent inline.
+func (c *GeminiConverter) convertFunctionResponseMedia(part model.Part, publicURLs map[string]service.PublicURL) *genai.FunctionResponsePart {
+	if part.Asset == nil && part.Meta != nil {
+		mediaType, _ := part.Meta["media_type"].(string)
+		switch sourceType, _ := part.Meta["type"].(string); sourceType {
+		case "base64":
+			data, _ := part.Meta["data"].(string)
+			if decoded, err := base64.StdEncoding.DecodeString(data); err == nil && len(decoded) > 0 {
+				return &genai.FunctionResponsePart{
+					InlineData: &genai.FunctionResponseBlob{MIMEType: mediaType, Data: decoded},
+				}
+			}
+		case "url":
+			if url, _ := part.Meta["url"].(string); url != "" && !strings.HasPrefix(url, "data:") {
+				return genai.NewFunctionResponsePartFromURI(url, mediaType)
+			}
+		}
+	}
+
+	inlinePart := c.convertImagePart(part, publicURLs)
+	if inlinePart == nil || inlinePart.InlineData == nil {
+		return nil
+	}
+	mimeType := inlinePart.InlineData.MIMEType
+	if part.Asset != nil && part.Asset.MIME != "" {
+		mimeType = part.Asset.MIME
+	}
+	return &genai.FunctionResponsePart{
+		InlineData: &genai.FunctionResponseBlob{MIMEType: mimeType, Data: inlinePart.InlineData.Data},
+	}
+}
+
 func (c *GeminiConverter) downloadImageAsBase64(imageURL string) (string, string, error) {
 	resp, err := http.Get(imageURL)
 	if err != nil {
@@ -296,12 +420,54 @@ func (c *GeminiConverter) downloadImageAsBase64(imageURL string) (string, string
 }
 
 func (c *GeminiConverter) getAssetURL(asset *model.Asset, publicURLs map[string]service.PublicURL) string {
-	if asset == nil {
-		return ""
+	return assetPublicURL(asset, publicURLs)
+}
+
+// DetectLoss reports the parts and fields that Convert drops for Gemini
+func (c *GeminiConverter) DetectLoss(messages []model.Message, publicURLs map[string]service.PublicURL) []ConversionWarning {
+	r := &lossReport{format: model.FormatGemini}
+	mediaLoss := func(part model.Part) string { return c.mediaLoss(part, publicURLs) }
+	toolCallIDToName := c.collectToolCallNames(messages)
+
+	for _, msg := range messages {
+		r.checkMessageName(msg)
+		for i, part := range msg.Parts {
+			if !r.checkCommon(msg, i, part) {
+				continue
+			}
+
+			switch part.Type {
+			case "image", "file":
+				if reason := mediaLoss(part); reason != "" {
+					r.dropPart(msg, i, part, reason)
+				}
+			case "audio":
+				r.dropPart(msg, i, part, "audio parts are not converted for gemini")
+			case "tool-call":
+				if name, _ := part.Meta["name"].(string); name == "" {
+					r.dropPart(msg, i, part, "tool call has no name")
+				}
+			case "tool-result":
+				name, _ := part.Meta["name"].(string)
+				if name == "" && toolCallIDToName[toolResultCallID(part)] == "" {
+					r.dropPart(msg, i, part, "tool result has no function name and no matching tool call")
+					continue
+				}
+				if toolResultIsError(part) {
+					r.dropField(msg, i, part.Type, "meta.is_error", "gemini function responses have no error flag")
+				}
+				r.checkNestedContent(msg, i, part, mediaLoss)
+			}
+		}
 	}
-	assetKey := asset.S3Key
-	if publicURL, ok := publicURLs[assetKey]; ok {
-		return publicURL.URL
+
+	return r.warnings
+}
+
+// mediaLoss returns why an image or file part cannot be converted, or "" if it can
+func (c *GeminiConverter) mediaLoss(part model.Part, publicURLs map[string]service.PublicURL) string {
+	if c.getAssetURL(part.Asset, publicURLs) != "" || hasInlineData(part) || remoteURL(part) != "" {
+		return ""
 	}
-	return ""
+	return fmt.Sprintf("%s has no URL or inline data", part.Type)
 }
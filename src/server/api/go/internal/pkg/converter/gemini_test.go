@@ -1,12 +1,14 @@
 package converter
 
 import (
+	"encoding/base64"
 	"testing"
 
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
 )
 
 func TestGeminiConverter_Convert_TextMessage(t *testing.T) {
@@ -150,3 +152,87 @@ func TestGeminiConverter_Convert_InvalidRole(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, result)
 }
+
+func TestGeminiConverter_Convert_ThoughtParts(t *testing.T) {
+	converter := &GeminiConverter{}
+
+	signature := base64.StdEncoding.EncodeToString([]byte("thought-signature"))
+	messages := []model.Message{
+		createTestMessage("assistant", []model.Part{
+			{Type: "thinking", Text: "Thinking", Meta: map[string]any{"source_format": "gemini"}},
+			{Type: "thinking", Text: "Foreign", Meta: map[string]any{"source_format": "anthropic", "signature": "sig"}},
+			{
+				Type: "tool-call",
+				Meta: map[string]any{
+					"id":                "call_1",
+					"name":              "get_weather",
+					"arguments":         "{}",
+					"thought_signature": signature,
+				},
+			},
+		}, nil),
+	}
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+
+	contents, ok := result.([]*genai.Content)
+	require.True(t, ok)
+	require.Len(t, contents, 1)
+	require.Len(t, contents[0].Parts, 2)
+
+	assert.True(t, contents[0].Parts[0].Thought)
+	assert.Equal(t, "Thinking", contents[0].Parts[0].Text)
+
+	assert.NotNil(t, contents[0].Parts[1].FunctionCall)
+	assert.Equal(t, []byte("thought-signature"), contents[0].Parts[1].ThoughtSignature)
+}
+
+func TestGeminiConverter_Convert_RichToolResult(t *testing.T) {
+	converter := &GeminiConverter{}
+
+	messages := []model.Message{
+		createTestMessage("user", []model.Part{
+			{
+				Type: "tool-result",
+				Text: `{"output":"ok"}`,
+				Meta: map[string]any{"tool_call_id": "call_123", "name": "take_screenshot"},
+				Content: []model.Part{
+					{Type: "text", Text: `{"output":"ok"}`},
+					{Type: "image", Meta: map[string]any{"type": "base64", "media_type": "image/png", "data": "aGVsbG8="}},
+					{Type: "file", Meta: map[string]any{"type": "url", "url": "gs://bucket/report.pdf", "media_type": "application/pdf"}},
+				},
+			},
+		}, nil),
+	}
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+
+	contents := result.([]*genai.Content)
+	functionResponse := contents[0].Parts[0].FunctionResponse
+	require.NotNil(t, functionResponse)
+	assert.Equal(t, "ok", functionResponse.Response["output"])
+	require.Len(t, functionResponse.Parts, 2)
+	assert.Equal(t, "image/png", functionResponse.Parts[0].InlineData.MIMEType)
+	assert.Equal(t, []byte("hello"), functionResponse.Parts[0].InlineData.Data)
+	assert.Equal(t, "gs://bucket/report.pdf", functionResponse.Parts[1].FileData.FileURI)
+}
+
+func TestGeminiConverter_Convert_InlineBase64Image(t *testing.T) {
+	converter := &GeminiConverter{}
+
+	messages := []model.Message{
+		createTestMessage("user", []model.Part{
+			{Type: "image", Meta: map[string]any{"type": "base64", "media_type": "image/png", "data": "aGVsbG8="}},
+		}, nil),
+	}
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+
+	contents := result.([]*genai.Content)
+	require.Len(t, contents[0].Parts, 1)
+	assert.Equal(t, "image/png", contents[0].Parts[0].InlineData.MIMEType)
+	assert.Equal(t, []byte("hello"), contents[0].Parts[0].InlineData.Data)
+}
@@ -0,0 +1,224 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+)
+
+// ConversionWarning describes information that is lost when a message is converted to another format
+type ConversionWarning struct {
+	MessageID string `json:"message_id"`
+	PartIndex int    `json:"part_index"` // index into the message parts, -1 for message-level fields
+	PartType  string `json:"part_type,omitempty"`
+	Field     string `json:"field,omitempty"` // lost field; empty if the whole part is dropped
+	Reason    string `json:"reason"`
+}
+
+// LossError is returned in strict mode when converting messages would lose information
+type LossError struct {
+	Format   model.MessageFormat
+	Warnings []ConversionWarning
+}
+
+func (e *LossError) Error() string {
+	first := e.Warnings[0]
+	return fmt.Sprintf("converting to %s would lose information in %d place(s); first: message %s part %d: %s",
+		e.Format, len(e.Warnings), first.MessageID, first.PartIndex, first.Reason)
+}
+
+// DetectConversionLoss reports the information that converting messages to format would drop
+func DetectConversionLoss(messages []model.Message, format model.MessageFormat, publicURLs map[string]service.PublicURL) ([]ConversionWarning, error) {
+	converter, err := newMessageConverter(format)
+	if err != nil {
+		return nil, err
+	}
+	return converter.DetectLoss(messages, publicURLs), nil
+}
+
+// GroupWarningsByMessage groups conversion warnings by message ID
+func GroupWarningsByMessage(warnings []ConversionWarning) map[string][]ConversionWarning {
+	grouped := make(map[string][]ConversionWarning)
+	for _, w := range warnings {
+		grouped[w.MessageID] = append(grouped[w.MessageID], w)
+	}
+	return grouped
+}
+
+// mergeWarnings adds to warnings the losses of parts and fields they do not already report
+func mergeWarnings(warnings []ConversionWarning, losses []ConversionWarning) []ConversionWarning {
+	type location struct {
+		messageID string
+		partIndex int
+		field     string
+	}
+	reported := make(map[location]bool, len(warnings))
+	for _, w := range warnings {
+		reported[location{w.MessageID, w.PartIndex, w.Field}] = true
+	}
+	for _, l := range losses {
+		if !reported[location{l.MessageID, l.PartIndex, l.Field}] {
+			warnings = append(warnings, l)
+		}
+	}
+	return warnings
+}
+
+// lossReport collects the conversion warnings of one conversion
+type lossReport struct {
+	format   model.MessageFormat
+	warnings []ConversionWarning
+}
+
+// dropPart records that a whole part is dropped
+func (r *lossReport) dropPart(msg model.Message, index int, part model.Part, reason string) {
+	r.warnings = append(r.warnings, ConversionWarning{
+		MessageID: msg.ID.String(),
+		PartIndex: index,
+		PartType:  part.Type,
+		Reason:    reason,
+	})
+}
+
+// dropField records that a field of a part (or of the message if index is -1) is dropped
+func (r *lossReport) dropField(msg model.Message, index int, partType string, field string, reason string) {
+	r.warnings = append(r.warnings, ConversionWarning{
+		MessageID: msg.ID.String(),
+		PartIndex: index,
+		PartType:  partType,
+		Field:     field,
+		Reason:    reason,
+	})
+}
+
+// checkCommon applies the rules shared by all provider formats.
+// It returns false if the part is dropped entirely and needs no further checks.
+func (r *lossReport) checkCommon(msg model.Message, index int, part model.Part) bool {
+	switch part.Type {
+	case "text", "image", "audio", "file", "tool-call", "tool-result":
+	case "thinking":
+		if !isThinkingFromFormat(part, r.format) {
+			r.dropPart(msg, index, part, fmt.Sprintf("thinking from another provider cannot be replayed to %s", r.format))
+			return false
+		}
+	default:
+		r.dropPart(msg, index, part, fmt.Sprintf("%s parts are not supported by %s", part.Type, r.format))
+		return false
+	}
+
+	if part.Meta == nil {
+		return true
+	}
+	if _, ok := part.Meta["cache_control"]; ok && !r.keepsCacheControl(part.Type) {
+		r.dropField(msg, index, part.Type, "meta.cache_control", fmt.Sprintf("%s has no cache control for %s parts", r.format, part.Type))
+	}
+	if _, ok := part.Meta["thought_signature"]; ok && r.format != model.FormatGemini {
+		r.dropField(msg, index, part.Type, "meta.thought_signature", "thought signatures are only valid for gemini")
+	}
+	return true
+}
+
+// checkMessageName reports the message-level name, which only OpenAI carries
+func (r *lossReport) checkMessageName(msg model.Message) {
+	if name, _ := msg.Meta.Data()["name"].(string); name != "" {
+		r.dropField(msg, -1, "", "meta.name", fmt.Sprintf("%s messages have no participant name", r.format))
+	}
+}
+
+func (r *lossReport) keepsCacheControl(partType string) bool {
+	switch r.format {
+	case model.FormatAnthropic:
+		return partType == "text"
+	case model.FormatBedrock:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkNestedContent checks the nested parts of a tool result with mediaLoss, the target's rule for images and files
+func (r *lossReport) checkNestedContent(msg model.Message, index int, part model.Part, mediaLoss func(model.Part) string) {
+	for i, nested := range part.Content {
+		if nested.Type != "image" && nested.Type != "file" {
+			continue
+		}
+		if reason := mediaLoss(nested); reason != "" {
+			r.dropField(msg, index, part.Type, fmt.Sprintf("content[%d]", i), reason)
+		}
+	}
+}
+
+// hasInlineData reports whether a part carries its media inline in meta
+func hasInlineData(part model.Part) bool {
+	if part.Meta == nil {
+		return false
+	}
+	if sourceType, _ := part.Meta["type"].(string); sourceType == "base64" {
+		data, _ := part.Meta["data"].(string)
+		return data != ""
+	}
+	for _, key := range []string{"url", "file_data"} {
+		if value, _ := part.Meta[key].(string); strings.HasPrefix(value, "data:") {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteURL returns the non-data URL a part points to, if any
+func remoteURL(part model.Part) string {
+	if part.Meta == nil {
+		return ""
+	}
+	if url, _ := part.Meta["url"].(string); url != "" && !strings.HasPrefix(url, "data:") {
+		return url
+	}
+	return ""
+}
+
+// partMediaType returns the known media type of an image or file part
+func partMediaType(part model.Part) string {
+	if part.Asset != nil && part.Asset.MIME != "" {
+		return part.Asset.MIME
+	}
+	if part.Meta != nil {
+		if mediaType, _ := part.Meta["media_type"].(string); mediaType != "" {
+			return mediaType
+		}
+		for _, key := range []string{"url", "file_data"} {
+			if value, _ := part.Meta[key].(string); strings.HasPrefix(value, "data:") {
+				header, _, _ := strings.Cut(strings.TrimPrefix(value, "data:"), ",")
+				mediaType, _, _ := strings.Cut(header, ";")
+				return mediaType
+			}
+		}
+	}
+	return ""
+}
+
+func hasToolCallIdentity(part model.Part) bool {
+	if part.Meta == nil {
+		return false
+	}
+	id, _ := part.Meta["id"].(string)
+	name, _ := part.Meta["name"].(string)
+	return id != "" && name != ""
+}
+
+func toolResultCallID(part model.Part) string {
+	if part.Meta == nil {
+		return ""
+	}
+	id, _ := part.Meta["tool_call_id"].(string)
+	return id
+}
+
+func toolResultIsError(part model.Part) bool {
+	if part.Meta == nil {
+		return false
+	}
+	isError, _ := part.Meta["is_error"].(bool)
+	return isError
+}
@@ -1,7 +1,11 @@
 package converter
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
 
 	openai "github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/packages/param"
@@ -16,13 +20,26 @@ type OpenAIConverter struct{}
 func (c *OpenAIConverter) Convert(messages []model.Message, publicURLs map[string]service.PublicURL) (interface{}, error) {
 	result := make([]openai.ChatCompletionMessageParamUnion, 0, len(messages))
 
+	// Tool messages only carry text, so images and files of rich tool results are sent
+	// in a user message after the run of tool messages they belong to
+	var toolMedia []openai.ChatCompletionContentPartUnionParam
+	flushToolMedia := func() {
+		if len(toolMedia) == 0 {
+			return
+		}
+		result = append(result, openai.UserMessage(toolMedia))
+		toolMedia = nil
+	}
+
 	for _, msg := range messages {
 		// Special handling: if user role contains only tool-result parts,
 		// convert to OpenAI's tool role
 		if msg.Role == "user" && c.isToolResultOnly(msg.Parts) {
 			toolMsg := c.convertToToolMessage(msg)
 			result = append(result, toolMsg)
+			toolMedia = append(toolMedia, c.extractToolResultMedia(msg.Parts, publicURLs)...)
 		} else {
+			flushToolMedia()
 			// Normal message conversion
 			switch msg.Role {
 			case "user":
@@ -38,10 +55,16 @@ func (c *OpenAIConverter) Convert(messages []model.Message, publicURLs map[strin
 			}
 		}
 	}
+	flushToolMedia()
 
 	return result, nil
 }
 
+// ConvertSystemPrompt converts the session system prompt to an OpenAI system message
+func (c *OpenAIConverter) ConvertSystemPrompt(systemPrompt string) openai.ChatCompletionMessageParamUnion {
+	return openai.SystemMessage(systemPrompt)
+}
+
 func (c *OpenAIConverter) convertToUserMessage(msg model.Message, publicURLs map[string]service.PublicURL) openai.ChatCompletionMessageParamUnion {
 	// Check if content should be string or array
 	if len(msg.Parts) == 1 && msg.Parts[0].Type == "text" {
@@ -71,19 +94,8 @@ func (c *OpenAIConverter) convertToUserMessage(msg model.Message, publicURLs map
 		case "text":
 			contentParts = append(contentParts, openai.TextContentPart(part.Text))
 		case "image":
-			imageURL := c.getAssetURL(part.Asset, publicURLs)
-			if imageURL != "" {
-				detail := ""
-				if part.Meta != nil {
-					if d, ok := part.Meta["detail"].(string); ok {
-						detail = d
-					}
-				}
-				imgParam := openai.ChatCompletionContentPartImageImageURLParam{
-					URL:    imageURL,
-					Detail: detail,
-				}
-				contentParts = append(contentParts, openai.ImageContentPart(imgParam))
+			if imagePart := c.convertImagePart(part, publicURLs); imagePart != nil {
+				contentParts = append(contentParts, *imagePart)
 			}
 		case "audio":
 			if part.Meta != nil {
@@ -102,31 +114,8 @@ func (c *OpenAIConverter) convertToUserMessage(msg model.Message, publicURLs map
 				contentParts = append(contentParts, openai.InputAudioContentPart(audioParam))
 			}
 		case "file":
-			if part.Meta != nil {
-				fileParam := openai.ChatCompletionContentPartFileFileParam{}
-				hasContent := false
-
-				// Add file_id if present
-				if fileID, ok := part.Meta["file_id"].(string); ok && fileID != "" {
-					fileParam.FileID = param.NewOpt(fileID)
-					hasContent = true
-				}
-
-				// Add base64 file_data if present
-				if fileData, ok := part.Meta["file_data"].(string); ok && fileData != "" {
-					fileParam.FileData = param.NewOpt(fileData)
-					hasContent = true
-				}
-
-				// Add filename if present
-				if filename, ok := part.Meta["filename"].(string); ok && filename != "" {
-					fileParam.Filename = param.NewOpt(filename)
-					hasContent = true
-				}
-
-				if hasContent {
-					contentParts = append(contentParts, openai.FileContentPart(fileParam))
-				}
+			if filePart := c.convertFilePart(part, publicURLs); filePart != nil {
+				contentParts = append(contentParts, *filePart)
 			}
 		}
 	}
@@ -149,15 +138,103 @@ func (c *OpenAIConverter) convertToUserMessage(msg model.Message, publicURLs map
 	}
 }
 
+func (c *OpenAIConverter) convertImagePart(part model.Part, publicURLs map[string]service.PublicURL) *openai.ChatCompletionContentPartUnionParam {
+	imageURL := c.getAssetURL(part.Asset, publicURLs)
+	detail := ""
+	if part.Meta != nil {
+		if imageURL == "" {
+			// Inline base64 or plain URL kept in meta
+			if sourceType, _ := part.Meta["type"].(string); sourceType == "base64" {
+				mediaType, _ := part.Meta["media_type"].(string)
+				data, _ := part.Meta["data"].(string)
+				if mediaType != "" && data != "" {
+					imageURL = "data:" + mediaType + ";base64," + data
+				}
+			} else if url, ok := part.Meta["url"].(string); ok {
+				imageURL = url
+			}
+		}
+		if d, ok := part.Meta["detail"].(string); ok {
+			detail = d
+		}
+	}
+	if imageURL == "" {
+		return nil
+	}
+
+	imgParam := openai.ChatCompletionContentPartImageImageURLParam{
+		URL:    imageURL,
+		Detail: detail,
+	}
+	contentPart := openai.ImageContentPart(imgParam)
+	return &contentPart
+}
+
+func (c *OpenAIConverter) convertFilePart(part model.Part, publicURLs map[string]service.PublicURL) *openai.ChatCompletionContentPartUnionParam {
+	fileParam := openai.ChatCompletionContentPartFileFileParam{}
+	hasContent := false
+
+	// OpenAI only accepts inline file data, so files stored as assets are downloaded
+	if assetURL := c.getAssetURL(part.Asset, publicURLs); assetURL != "" {
+		if data, _ := c.download(assetURL); len(data) > 0 {
+			mediaType := part.Asset.MIME
+			if mediaType == "" {
+				mediaType, _ = part.Meta["media_type"].(string)
+			}
+			fileParam.FileData = param.NewOpt("data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data))
+			hasContent = true
+		}
+	}
+
+	// Add file_id if present
+	if fileID, ok := part.Meta["file_id"].(string); ok && fileID != "" {
+		fileParam.FileID = param.NewOpt(fileID)
+		hasContent = true
+	}
+
+	// Add base64 file_data if present
+	if fileData, ok := part.Meta["file_data"].(string); ok && fileData != "" {
+		fileParam.FileData = param.NewOpt(fileData)
+		hasContent = true
+	} else if sourceType, _ := part.Meta["type"].(string); sourceType == "base64" {
+		// Inline base64 documents from other formats
+		mediaType, _ := part.Meta["media_type"].(string)
+		if data, _ := part.Meta["data"].(string); data != "" {
+			fileParam.FileData = param.NewOpt("data:" + mediaType + ";base64," + data)
+			hasContent = true
+		}
+	}
+
+	// Add filename if present
+	if filename, ok := part.Meta["filename"].(string); ok && filename != "" {
+		fileParam.Filename = param.NewOpt(filename)
+		hasContent = true
+	} else if part.Filename != "" {
+		fileParam.Filename = param.NewOpt(part.Filename)
+	}
+
+	if !hasContent {
+		return nil
+	}
+	contentPart := openai.FileContentPart(fileParam)
+	return &contentPart
+}
+
 func (c *OpenAIConverter) convertToAssistantMessage(msg model.Message) openai.ChatCompletionMessageParamUnion {
-	// Separate text content and tool calls
+	// Separate text content, reasoning and tool calls
 	var textContent string
+	var reasoningContent string
 	var toolCalls []openai.ChatCompletionMessageToolCallUnionParam
 
 	for _, part := range msg.Parts {
 		switch part.Type {
 		case "text":
 			textContent += part.Text
+		case "thinking":
+			// Only reasoning captured from OpenAI-compatible messages is replayed
+			if isThinkingFromFormat(part, model.FormatOpenAI) {
+				reasoningContent += part.Text
+			}
 		case "tool-call":
 			if part.Meta != nil {
 				toolCall := c.convertToToolCall(part)
@@ -181,6 +258,13 @@ func (c *OpenAIConverter) convertToAssistantMessage(msg model.Message) openai.Ch
 		assistantParam.ToolCalls = toolCalls
 	}
 
+	// The SDK has no reasoning field; send it back the way OpenAI-compatible providers accept it
+	if reasoningContent != "" {
+		assistantParam.SetExtraFields(map[string]any{
+			"reasoning_content": reasoningContent,
+		})
+	}
+
 	// Add name field from message meta if present
 	if metaData := msg.Meta.Data(); len(metaData) > 0 {
 		if name, ok := metaData["name"].(string); ok && name != "" {
@@ -205,6 +289,13 @@ func (c *OpenAIConverter) convertToToolMessage(msg model.Message) openai.ChatCom
 		},
 	}
 
+	// A single tool result with nested text parts is sent back as array content
+	if textParts := c.extractToolResultTextParts(msg.Parts); len(textParts) > 0 {
+		toolParam.Content = openai.ChatCompletionToolMessageParamContentUnion{
+			OfArrayOfContentParts: textParts,
+		}
+	}
+
 	return openai.ChatCompletionMessageParamUnion{
 		OfTool: &toolParam,
 	}
@@ -279,13 +370,135 @@ func (c *OpenAIConverter) extractToolResultContent(parts []model.Part) string {
 	return content
 }
 
+// extractToolResultTextParts returns the nested text parts of a message holding exactly one tool result
+func (c *OpenAIConverter) extractToolResultTextParts(parts []model.Part) []openai.ChatCompletionContentPartTextParam {
+	if len(parts) != 1 {
+		return nil
+	}
+	var textParts []openai.ChatCompletionContentPartTextParam
+	for _, nested := range parts[0].Content {
+		if nested.Type == "text" && nested.Text != "" {
+			textParts = append(textParts, openai.ChatCompletionContentPartTextParam{Text: nested.Text})
+		}
+	}
+	return textParts
+}
+
+// extractToolResultMedia collects the images and files nested in tool-result parts
+func (c *OpenAIConverter) extractToolResultMedia(parts []model.Part, publicURLs map[string]service.PublicURL) []openai.ChatCompletionContentPartUnionParam {
+	var media []openai.ChatCompletionContentPartUnionParam
+	for _, part := range parts {
+		if part.Type != "tool-result" {
+			continue
+		}
+		for _, nested := range part.Content {
+			switch nested.Type {
+			case "image":
+				if imagePart := c.convertImagePart(nested, publicURLs); imagePart != nil {
+					media = append(media, *imagePart)
+				}
+			case "file":
+				if filePart := c.convertFilePart(nested, publicURLs); filePart != nil {
+					media = append(media, *filePart)
+				}
+			}
+		}
+	}
+	return media
+}
+
+func (c *OpenAIConverter) download(url string) ([]byte, string) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, ""
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, ""
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, ""
+	}
+
+	return data, resp.Header.Get("Content-Type")
+}
+
 func (c *OpenAIConverter) getAssetURL(asset *model.Asset, publicURLs map[string]service.PublicURL) string {
-	if asset == nil {
+	return assetPublicURL(asset, publicURLs)
+}
+
+// DetectLoss reports the parts and fields that Convert drops for OpenAI
+func (c *OpenAIConverter) DetectLoss(messages []model.Message, publicURLs map[string]service.PublicURL) []ConversionWarning {
+	r := &lossReport{format: model.FormatOpenAI}
+	mediaLoss := func(part model.Part) string { return c.mediaLoss(part, publicURLs) }
+
+	for _, msg := range messages {
+		toolResultOnly := msg.Role == "user" && c.isToolResultOnly(msg.Parts)
+		for i, part := range msg.Parts {
+			if !r.checkCommon(msg, i, part) {
+				continue
+			}
+
+			switch {
+			case toolResultOnly:
+				// The whole message becomes one tool message
+				if len(msg.Parts) > 1 {
+					r.dropField(msg, i, part.Type, "text", "an OpenAI tool message holds one result; the results of this message are merged under the first tool_call_id")
+				}
+				if toolResultIsError(part) {
+					r.dropField(msg, i, part.Type, "meta.is_error", "OpenAI tool messages have no error flag")
+				}
+				r.checkNestedContent(msg, i, part, mediaLoss)
+
+			case msg.Role == "assistant":
+				switch part.Type {
+				case "text", "thinking":
+				case "tool-call":
+					if !hasToolCallIdentity(part) {
+						r.dropPart(msg, i, part, "tool call has no id or name")
+					}
+				default:
+					r.dropPart(msg, i, part, fmt.Sprintf("OpenAI assistant messages cannot carry %s parts", part.Type))
+				}
+
+			default:
+				switch part.Type {
+				case "text", "thinking":
+				case "image", "file":
+					if reason := mediaLoss(part); reason != "" {
+						r.dropPart(msg, i, part, reason)
+					}
+				case "audio":
+					if data, _ := part.Meta["data"].(string); data == "" {
+						r.dropPart(msg, i, part, "audio part has no inline data")
+					}
+				default:
+					r.dropPart(msg, i, part, fmt.Sprintf("OpenAI user messages cannot carry %s parts next to other content", part.Type))
+				}
+			}
+		}
+	}
+
+	return r.warnings
+}
+
+// mediaLoss returns why an image or file part cannot be converted, or "" if it can
+func (c *OpenAIConverter) mediaLoss(part model.Part, publicURLs map[string]service.PublicURL) string {
+	if part.Type == "image" {
+		if c.convertImagePart(part, publicURLs) == nil {
+			return "image has no URL or inline data"
+		}
+		return ""
+	}
+
+	if c.getAssetURL(part.Asset, publicURLs) != "" || hasInlineData(part) {
 		return ""
 	}
-	assetKey := asset.S3Key
-	if publicURL, ok := publicURLs[assetKey]; ok {
-		return publicURL.URL
+	if fileID, _ := part.Meta["file_id"].(string); fileID != "" {
+		return ""
 	}
-	return ""
+	return "OpenAI files need inline data or a file_id"
 }
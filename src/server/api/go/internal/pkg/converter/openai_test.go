@@ -1,9 +1,13 @@
 package converter
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -67,3 +71,102 @@ func TestOpenAIConverter_Convert_ToolResult(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, result)
 }
+
+func TestOpenAIConverter_Convert_Reasoning(t *testing.T) {
+	converter := &OpenAIConverter{}
+
+	messages := []model.Message{
+		createTestMessage("assistant", []model.Part{
+			{Type: "thinking", Text: "6 times 7", Meta: map[string]any{"source_format": "openai"}},
+			{Type: "thinking", Text: "Signed reasoning", Meta: map[string]any{"source_format": "anthropic", "signature": "sig"}},
+			{Type: "text", Text: "42"},
+		}, nil),
+	}
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"role": "assistant", "content": "42", "reasoning_content": "6 times 7"}]`, string(raw))
+}
+
+func TestOpenAIConverter_Convert_RichToolResult(t *testing.T) {
+	converter := &OpenAIConverter{}
+
+	toolResult := func(id string) model.Part {
+		return model.Part{
+			Type: "tool-result",
+			Text: "Screenshot taken",
+			Meta: map[string]any{"tool_call_id": id},
+			Content: []model.Part{
+				{Type: "text", Text: "Screenshot taken"},
+				{Type: "image", Meta: map[string]any{"type": "base64", "media_type": "image/png", "data": "aGVsbG8="}},
+			},
+		}
+	}
+	messages := []model.Message{
+		createTestMessage("user", []model.Part{toolResult("call_1")}, nil),
+		createTestMessage("user", []model.Part{toolResult("call_2")}, nil),
+		createTestMessage("assistant", []model.Part{{Type: "text", Text: "Done"}}, nil),
+	}
+
+	result, err := converter.Convert(messages, nil)
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(result)
+	require.NoError(t, err)
+
+	var decoded []map[string]any
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	require.Len(t, decoded, 4)
+
+	// Tool messages stay contiguous; their images follow in one user message
+	assert.Equal(t, "tool", decoded[0]["role"])
+	toolContent := decoded[0]["content"].([]any)
+	require.Len(t, toolContent, 1)
+	assert.Equal(t, "Screenshot taken", toolContent[0].(map[string]any)["text"])
+	assert.Equal(t, "tool", decoded[1]["role"])
+	assert.Equal(t, "user", decoded[2]["role"])
+	media := decoded[2]["content"].([]any)
+	require.Len(t, media, 2)
+	imageURL := media[0].(map[string]any)["image_url"].(map[string]any)["url"]
+	assert.Equal(t, "data:image/png;base64,aGVsbG8=", imageURL)
+	assert.Equal(t, "assistant", decoded[3]["role"])
+}
+
+func TestOpenAIConverter_Convert_FileAsset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	converter := &OpenAIConverter{}
+
+	messages := []model.Message{
+		createTestMessage("user", []model.Part{
+			{Type: "text", Text: "Summarize this"},
+			{
+				Type:     "file",
+				Filename: "report.pdf",
+				Meta:     map[string]any{"media_type": "application/pdf"},
+				Asset:    &model.Asset{SHA256: "abc", S3Key: "assets/abc.pdf", MIME: "application/pdf"},
+			},
+		}, nil),
+	}
+	publicURLs := map[string]service.PublicURL{
+		"abc": {URL: server.URL + "/abc.pdf"},
+	}
+
+	result, err := converter.Convert(messages, publicURLs)
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(result)
+	require.NoError(t, err)
+
+	var decoded []map[string]any
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	file := decoded[0]["content"].([]any)[1].(map[string]any)["file"].(map[string]any)
+	assert.Equal(t, "data:application/pdf;base64,aGVsbG8=", file["file_data"])
+	assert.Equal(t, "report.pdf", file["filename"])
+}
@@ -91,10 +91,11 @@ func (s *RemoveToolResultStrategy) Apply(messages []model.Message) ([]model.Mess
 		placeholder = "Done"
 	}
 
-	// Replace the text of the oldest tool-result parts
+	// Replace the text of the oldest tool-result parts; their nested content (e.g. screenshots) is dropped as well
 	for i := range numToReplace {
 		pos := toolResultPositions[i]
 		messages[pos.messageIdx].Parts[pos.partIdx].Text = placeholder
+		messages[pos.messageIdx].Parts[pos.partIdx].Content = nil
 	}
 
 	return messages, nil
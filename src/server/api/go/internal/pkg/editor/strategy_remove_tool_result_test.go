@@ -75,6 +75,38 @@ func TestRemoveToolResultStrategy_Apply(t *testing.T) {
 		assert.Equal(t, "tool-result", result[6].Parts[0].Type)
 	})
 
+	t.Run("drop nested content of replaced tool results", func(t *testing.T) {
+		messages := []model.Message{
+			{
+				Role: "user",
+				Parts: []model.Part{
+					{
+						Type: "tool-result",
+						Text: "Screenshot taken",
+						Meta: map[string]interface{}{"tool_call_id": "call1"},
+						Content: []model.Part{
+							{Type: "text", Text: "Screenshot taken"},
+							{Type: "image", Asset: &model.Asset{SHA256: "abc"}},
+						},
+					},
+				},
+			},
+			{
+				Role: "user",
+				Parts: []model.Part{
+					{Type: "tool-result", Text: "Current temp: 75°F", Meta: map[string]interface{}{"tool_call_id": "call2"}},
+				},
+			},
+		}
+
+		strategy := &RemoveToolResultStrategy{KeepRecentN: 1}
+		result, err := strategy.Apply(messages)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Done", result[0].Parts[0].Text)
+		assert.Nil(t, result[0].Parts[0].Content)
+	})
+
 	t.Run("keep all when KeepRecentN >= total", func(t *testing.T) {
 		messages := []model.Message{
 			{
@@ -0,0 +1,88 @@
+// Package grep finds matching lines in text and renders them the way ripgrep does.
+package grep
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Line is a matching or context line of a text
+type Line struct {
+	Number int    `json:"line_number"` // 1-based line number
+	Text   string `json:"text"`        // Line without its line ending
+	Match  bool   `json:"match"`       // False for context lines
+}
+
+// Options controls which lines are returned
+type Options struct {
+	Before   int // Context lines before each match (-B)
+	After    int // Context lines after each match (-A)
+	MaxCount int // Maximum matching lines to return, 0 means no limit (-m)
+}
+
+// Result holds the lines found in a text
+type Result struct {
+	Lines      []Line // Matching lines with their context, in order
+	MatchCount int    // Matching lines in the whole text, including those past MaxCount
+}
+
+// Search returns the lines of text matching re with the requested context
+func Search(re *regexp.Regexp, text string, opts Options) Result {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if text == "" {
+		lines = nil
+	}
+
+	var (
+		res     Result
+		shown   int
+		printed = -1 // Index of the last line added to the result
+	)
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if !re.MatchString(line) {
+			continue
+		}
+		res.MatchCount++
+		if opts.MaxCount > 0 && shown >= opts.MaxCount {
+			continue
+		}
+		shown++
+
+		// Context before, without repeating lines already added
+		for j := max(i-opts.Before, printed+1); j < i; j++ {
+			res.Lines = append(res.Lines, Line{Number: j + 1, Text: strings.TrimSuffix(lines[j], "\r")})
+		}
+		// Drop context lines that turn out to match; they are added as matches below
+		if printed >= i {
+			res.Lines = res.Lines[:len(res.Lines)-(printed-i+1)]
+		}
+		res.Lines = append(res.Lines, Line{Number: i + 1, Text: line, Match: true})
+		printed = i
+
+		// Context after is added up to the next match, which adds its own lines
+		for j := i + 1; j <= min(i+opts.After, len(lines)-1); j++ {
+			res.Lines = append(res.Lines, Line{Number: j + 1, Text: strings.TrimSuffix(lines[j], "\r")})
+			printed = j
+		}
+	}
+	return res
+}
+
+// Format renders lines of a file like `rg -n` with context: matches as path:line:text,
+// context as path-line-text and "--" between groups of lines that are not adjacent
+func Format(path string, lines []Line) string {
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 && line.Number > lines[i-1].Number+1 {
+			b.WriteString("--\n")
+		}
+		sep := "-"
+		if line.Match {
+			sep = ":"
+		}
+		fmt.Fprintf(&b, "%s%s%d%s%s\n", path, sep, line.Number, sep, line.Text)
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package grep
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sample = `package main
+
+import "fmt"
+
+// TODO: handle errors
+func main() {
+	fmt.Println("a")
+	// todo: remove
+	fmt.Println("b")
+}
+`
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		opts       Options
+		expected   []Line
+		matchCount int
+	}{
+		{
+			name:    "matches only",
+			pattern: `fmt\.Println`,
+			expected: []Line{
+				{Number: 7, Text: "\tfmt.Println(\"a\")", Match: true},
+				{Number: 9, Text: "\tfmt.Println(\"b\")", Match: true},
+			},
+			matchCount: 2,
+		},
+		{
+			name:    "case insensitive",
+			pattern: `(?i)todo`,
+			expected: []Line{
+				{Number: 5, Text: "// TODO: handle errors", Match: true},
+				{Number: 8, Text: "\t// todo: remove", Match: true},
+			},
+			matchCount: 2,
+		},
+		{
+			name:    "overlapping context is merged",
+			pattern: `fmt\.Println`,
+			opts:    Options{Before: 1, After: 1},
+			expected: []Line{
+				{Number: 6, Text: "func main() {"},
+				{Number: 7, Text: "\tfmt.Println(\"a\")", Match: true},
+				{Number: 8, Text: "\t// todo: remove"},
+				{Number: 9, Text: "\tfmt.Println(\"b\")", Match: true},
+				{Number: 10, Text: "}"},
+			},
+			matchCount: 2,
+		},
+		{
+			name:    "context at the edges of the text",
+			pattern: `^package|^}`,
+			opts:    Options{Before: 2, After: 2},
+			expected: []Line{
+				{Number: 1, Text: "package main", Match: true},
+				{Number: 2, Text: ""},
+				{Number: 3, Text: "import \"fmt\""},
+				{Number: 8, Text: "\t// todo: remove"},
+				{Number: 9, Text: "\tfmt.Println(\"b\")"},
+				{Number: 10, Text: "}", Match: true},
+			},
+			matchCount: 2,
+		},
+		{
+			name:    "max count still counts every match",
+			pattern: `fmt`,
+			opts:    Options{MaxCount: 1},
+			expected: []Line{
+				{Number: 3, Text: "import \"fmt\"", Match: true},
+			},
+			matchCount: 3,
+		},
+		{
+			name:       "no match",
+			pattern:    `nothing`,
+			matchCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Search(regexp.MustCompile(tt.pattern), sample, tt.opts)
+			assert.Equal(t, tt.expected, res.Lines)
+			assert.Equal(t, tt.matchCount, res.MatchCount)
+		})
+	}
+}
+
+func TestSearch_CRLF(t *testing.T) {
+	res := Search(regexp.MustCompile(`b$`), "a\r\nb\r\n", Options{Before: 1})
+	assert.Equal(t, []Line{{Number: 1, Text: "a"}, {Number: 2, Text: "b", Match: true}}, res.Lines)
+}
+
+func TestFormat(t *testing.T) {
+	lines := []Line{
+		{Number: 1, Text: "package main", Match: true},
+		{Number: 2, Text: ""},
+		{Number: 9, Text: "\tfmt.Println(\"b\")"},
+		{Number: 10, Text: "}", Match: true},
+	}
+
+	assert.Equal(t, "/main.go:1:package main\n/main.go-2-\n--\n/main.go-9-\tfmt.Println(\"b\")\n/main.go:10:}\n", Format("/main.go", lines))
+	assert.Empty(t, Format("/main.go", nil))
+}
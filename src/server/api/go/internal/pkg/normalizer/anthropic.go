@@ -62,26 +62,7 @@ func normalizeAnthropicContentBlock(blockUnion anthropic.ContentBlockParamUnion)
 
 		return part, nil
 	} else if blockUnion.OfImage != nil {
-		// Handle image source
-		meta := map[string]interface{}{}
-		if blockUnion.OfImage.Source.OfBase64 != nil {
-			meta["type"] = "base64"
-			meta["media_type"] = blockUnion.OfImage.Source.OfBase64.MediaType
-			meta["data"] = blockUnion.OfImage.Source.OfBase64.Data
-		} else if blockUnion.OfImage.Source.OfURL != nil {
-			meta["type"] = "url"
-			meta["url"] = blockUnion.OfImage.Source.OfURL.URL
-		}
-
-		// Extract cache_control if present
-		if blockUnion.OfImage.CacheControl.Type != "" {
-			meta["cache_control"] = ExtractAnthropicCacheControl(blockUnion.OfImage.CacheControl)
-		}
-
-		return service.PartIn{
-			Type: "image",
-			Meta: meta,
-		}, nil
+		return normalizeAnthropicImageBlock(*blockUnion.OfImage), nil
 	} else if blockUnion.OfToolUse != nil {
 		// Convert input to JSON string
 		argsBytes, err := json.Marshal(blockUnion.OfToolUse.Input)
@@ -107,13 +88,25 @@ func normalizeAnthropicContentBlock(blockUnion anthropic.ContentBlockParamUnion)
 			Meta: meta,
 		}, nil
 	} else if blockUnion.OfToolResult != nil {
-		// Handle tool result content
+		// Handle tool result content: text is concatenated, images and documents are kept as nested parts
 		var resultText string
+		var content []service.PartIn
+		hasMedia := false
 		for _, contentItem := range blockUnion.OfToolResult.Content {
 			if contentItem.OfText != nil {
 				resultText += contentItem.OfText.Text
+				content = append(content, service.PartIn{Type: "text", Text: contentItem.OfText.Text})
+			} else if contentItem.OfImage != nil {
+				content = append(content, normalizeAnthropicImageBlock(*contentItem.OfImage))
+				hasMedia = true
+			} else if contentItem.OfDocument != nil {
+				content = append(content, normalizeAnthropicDocumentBlock(*contentItem.OfDocument))
+				hasMedia = true
 			}
 		}
+		if !hasMedia {
+			content = nil
+		}
 
 		isError := false
 		if !param.IsOmitted(blockUnion.OfToolResult.IsError) {
@@ -132,36 +125,81 @@ func normalizeAnthropicContentBlock(blockUnion anthropic.ContentBlockParamUnion)
 		}
 
 		return service.PartIn{
-			Type: "tool-result",
-			Text: resultText,
-			Meta: meta,
+			Type:    "tool-result",
+			Text:    resultText,
+			Meta:    meta,
+			Content: content,
 		}, nil
 	} else if blockUnion.OfDocument != nil {
-		// Handle document block
-		meta := map[string]interface{}{}
-		if blockUnion.OfDocument.Source.OfBase64 != nil {
-			meta["type"] = "base64"
-			meta["media_type"] = blockUnion.OfDocument.Source.OfBase64.MediaType
-			meta["data"] = blockUnion.OfDocument.Source.OfBase64.Data
-		} else if blockUnion.OfDocument.Source.OfURL != nil {
-			meta["type"] = "url"
-			meta["url"] = blockUnion.OfDocument.Source.OfURL.URL
-		}
-
-		// Extract cache_control if present
-		if blockUnion.OfDocument.CacheControl.Type != "" {
-			meta["cache_control"] = ExtractAnthropicCacheControl(blockUnion.OfDocument.CacheControl)
-		}
-
+		return normalizeAnthropicDocumentBlock(*blockUnion.OfDocument), nil
+	} else if blockUnion.OfThinking != nil {
+		// Keep the signature: Anthropic requires thinking blocks to be sent back unmodified during tool use
 		return service.PartIn{
-			Type: "file",
-			Meta: meta,
+			Type: "thinking",
+			Text: blockUnion.OfThinking.Thinking,
+			Meta: map[string]interface{}{
+				"source_format": "anthropic",
+				"signature":     blockUnion.OfThinking.Signature,
+			},
+		}, nil
+	} else if blockUnion.OfRedactedThinking != nil {
+		return service.PartIn{
+			Type: "thinking",
+			Meta: map[string]interface{}{
+				"source_format": "anthropic",
+				"redacted":      true,
+				"data":          blockUnion.OfRedactedThinking.Data, // Encrypted payload
+			},
 		}, nil
 	}
 
 	return service.PartIn{}, fmt.Errorf("unsupported Anthropic content block type")
 }
 
+func normalizeAnthropicImageBlock(image anthropic.ImageBlockParam) service.PartIn {
+	meta := map[string]interface{}{}
+	if image.Source.OfBase64 != nil {
+		meta["type"] = "base64"
+		meta["media_type"] = string(image.Source.OfBase64.MediaType)
+		meta["data"] = image.Source.OfBase64.Data
+	} else if image.Source.OfURL != nil {
+		meta["type"] = "url"
+		meta["url"] = image.Source.OfURL.URL
+	}
+
+	// Extract cache_control if present
+	if image.CacheControl.Type != "" {
+		meta["cache_control"] = ExtractAnthropicCacheControl(image.CacheControl)
+	}
+
+	return service.PartIn{
+		Type: "image",
+		Meta: meta,
+	}
+}
+
+func normalizeAnthropicDocumentBlock(document anthropic.DocumentBlockParam) service.PartIn {
+	meta := map[string]interface{}{}
+	if document.Source.OfBase64 != nil {
+		meta["type"] = "base64"
+		meta["media_type"] = string(document.Source.OfBase64.MediaType)
+		meta["data"] = document.Source.OfBase64.Data
+	} else if document.Source.OfURL != nil {
+		meta["type"] = "url"
+		meta["url"] = document.Source.OfURL.URL
+	}
+
+	// Extract cache_control if present
+	if document.CacheControl.Type != "" {
+		meta["cache_control"] = ExtractAnthropicCacheControl(document.CacheControl)
+	}
+
+	return service.PartIn{
+		Type: "file",
+		Meta: meta,
+	}
+}
+
 // CacheControl represents cache control configuration
 type CacheControl struct {
 	Type string `json:"type"` // "ephemeral"
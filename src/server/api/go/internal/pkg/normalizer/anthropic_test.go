@@ -451,3 +451,79 @@ func TestBuildAnthropicCacheControl(t *testing.T) {
 		})
 	}
 }
+
+func TestAnthropicNormalizer_ThinkingBlocks(t *testing.T) {
+	normalizer := &AnthropicNormalizer{}
+
+	input := `{
+		"role": "assistant",
+		"content": [
+			{"type": "thinking", "thinking": "The user wants the weather.", "signature": "sig_abc"},
+			{"type": "redacted_thinking", "data": "encrypted_payload"},
+			{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": {"city": "Paris"}}
+		]
+	}`
+
+	role, parts, _, err := normalizer.NormalizeFromAnthropicMessage(json.RawMessage(input))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "assistant", role)
+	assert.Len(t, parts, 3)
+
+	assert.Equal(t, "thinking", parts[0].Type)
+	assert.Equal(t, "The user wants the weather.", parts[0].Text)
+	assert.Equal(t, "anthropic", parts[0].Meta["source_format"])
+	assert.Equal(t, "sig_abc", parts[0].Meta["signature"])
+
+	assert.Equal(t, "thinking", parts[1].Type)
+	assert.Empty(t, parts[1].Text)
+	assert.Equal(t, true, parts[1].Meta["redacted"])
+	assert.Equal(t, "encrypted_payload", parts[1].Meta["data"])
+
+	assert.Equal(t, "tool-call", parts[2].Type)
+}
+
+func TestAnthropicNormalizer_RichToolResult(t *testing.T) {
+	normalizer := &AnthropicNormalizer{}
+
+	input := `{
+		"role": "user",
+		"content": [
+			{
+				"type": "tool_result",
+				"tool_use_id": "toolu_123",
+				"content": [
+					{"type": "text", "text": "Screenshot taken"},
+					{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "aGVsbG8="}},
+					{"type": "document", "source": {"type": "url", "url": "https://example.com/report.pdf"}}
+				]
+			},
+			{
+				"type": "tool_result",
+				"tool_use_id": "toolu_456",
+				"content": [{"type": "text", "text": "plain"}]
+			}
+		]
+	}`
+
+	role, parts, _, err := normalizer.NormalizeFromAnthropicMessage(json.RawMessage(input))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user", role)
+	assert.Len(t, parts, 2)
+
+	// Text is still flattened for text-only consumers; media is kept as nested parts
+	assert.Equal(t, "Screenshot taken", parts[0].Text)
+	assert.Len(t, parts[0].Content, 3)
+	assert.Equal(t, "text", parts[0].Content[0].Type)
+	assert.Equal(t, "image", parts[0].Content[1].Type)
+	assert.Equal(t, "base64", parts[0].Content[1].Meta["type"])
+	assert.Equal(t, "image/png", parts[0].Content[1].Meta["media_type"])
+	assert.Equal(t, "file", parts[0].Content[2].Type)
+	assert.Equal(t, "https://example.com/report.pdf", parts[0].Content[2].Meta["url"])
+	assert.NoError(t, parts[0].Validate())
+
+	// Text-only results stay flat
+	assert.Equal(t, "plain", parts[1].Text)
+	assert.Nil(t, parts[1].Content)
+}
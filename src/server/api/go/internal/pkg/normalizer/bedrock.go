@@ -0,0 +1,349 @@
+package normalizer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/memodb-io/Acontext/internal/modules/service"
+)
+
+// Bedrock Converse API wire types.
+// The AWS SDK models content blocks as Go interfaces that do not round-trip through
+// encoding/json, so we mirror the JSON shape of the Converse API here instead.
+// Byte fields are base64 strings on the wire, which matches how encoding/json handles []byte.
+
+// BedrockMessage represents a Bedrock Converse Message
+type BedrockMessage struct {
+	Role    string                `json:"role"`
+	Content []BedrockContentBlock `json:"content"`
+}
+
+// BedrockContentBlock is a union; exactly one field is expected to be set
+type BedrockContentBlock struct {
+	Text             *string                       `json:"text,omitempty"`
+	Image            *BedrockImageBlock            `json:"image,omitempty"`
+	Document         *BedrockDocumentBlock         `json:"document,omitempty"`
+	ToolUse          *BedrockToolUseBlock          `json:"toolUse,omitempty"`
+	ToolResult       *BedrockToolResultBlock       `json:"toolResult,omitempty"`
+	CachePoint       *BedrockCachePointBlock       `json:"cachePoint,omitempty"`
+	ReasoningContent *BedrockReasoningContentBlock `json:"reasoningContent,omitempty"`
+}
+
+// BedrockSystemContentBlock is a block of the top-level system prompt of a Converse request
+type BedrockSystemContentBlock struct {
+	Text       *string                 `json:"text,omitempty"`
+	CachePoint *BedrockCachePointBlock `json:"cachePoint,omitempty"`
+}
+
+// BedrockImageBlock represents an image content block ("png" | "jpeg" | "gif" | "webp")
+type BedrockImageBlock struct {
+	Format string        `json:"format"`
+	Source BedrockSource `json:"source"`
+}
+
+// BedrockDocumentBlock represents a document content block ("pdf" | "csv" | "doc" | ...)
+type BedrockDocumentBlock struct {
+	Format string        `json:"format"`
+	Name   string        `json:"name"`
+	Source BedrockSource `json:"source"`
+}
+
+// BedrockSource holds either raw bytes or an S3 location
+type BedrockSource struct {
+	Bytes      []byte             `json:"bytes,omitempty"`
+	S3Location *BedrockS3Location `json:"s3Location,omitempty"`
+}
+
+// BedrockS3Location points to an object in S3
+type BedrockS3Location struct {
+	URI         string `json:"uri"`
+	BucketOwner string `json:"bucketOwner,omitempty"`
+}
+
+// BedrockToolUseBlock represents a tool use request from the model
+type BedrockToolUseBlock struct {
+	ToolUseID string `json:"toolUseId"`
+	Name      string `json:"name"`
+	Input     any    `json:"input"`
+}
+
+// BedrockToolResultBlock represents the result of a tool use
+type BedrockToolResultBlock struct {
+	ToolUseID string                          `json:"toolUseId"`
+	Content   []BedrockToolResultContentBlock `json:"content"`
+	Status    string                          `json:"status,omitempty"` // "success" | "error"
+}
+
+// BedrockToolResultContentBlock is a union of the content allowed inside a tool result
+type BedrockToolResultContentBlock struct {
+	Text     *string               `json:"text,omitempty"`
+	JSON     any                   `json:"json,omitempty"`
+	Image    *BedrockImageBlock    `json:"image,omitempty"`
+	Document *BedrockDocumentBlock `json:"document,omitempty"`
+}
+
+// BedrockCachePointBlock marks the end of a cacheable prompt prefix
+type BedrockCachePointBlock struct {
+	Type string `json:"type"` // "default"
+}
+
+// BedrockReasoningContentBlock holds either signed reasoning text or redacted (encrypted) reasoning
+type BedrockReasoningContentBlock struct {
+	ReasoningText   *BedrockReasoningTextBlock `json:"reasoningText,omitempty"`
+	RedactedContent []byte                     `json:"redactedContent,omitempty"`
+}
+
+// BedrockReasoningTextBlock is the model's reasoning with the signature required to replay it
+type BedrockReasoningTextBlock struct {
+	Text      string `json:"text"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// BedrockNormalizer normalizes AWS Bedrock Converse format to internal format
+type BedrockNormalizer struct{}
+
+// NormalizeFromBedrockMessage converts a Bedrock Converse Message to internal format
+// Returns: role, parts, messageMeta, error
+func (n *BedrockNormalizer) NormalizeFromBedrockMessage(messageJSON json.RawMessage) (string, []service.PartIn, map[string]interface{}, error) {
+	var message BedrockMessage
+	if err := json.Unmarshal(messageJSON, &message); err != nil {
+		return "", nil, nil, fmt.Errorf("failed to unmarshal Bedrock message: %w", err)
+	}
+
+	// Validate role (Bedrock only supports "user" and "assistant")
+	if message.Role != "user" && message.Role != "assistant" {
+		return "", nil, nil, fmt.Errorf("invalid Bedrock role: %s (only 'user' and 'assistant' are supported)", message.Role)
+	}
+
+	parts := []service.PartIn{}
+	for _, block := range message.Content {
+		// cachePoint is a standalone marker in Bedrock: everything before it is cached.
+		// Internally we express this as Anthropic-style cache_control on the preceding part.
+		if block.CachePoint != nil {
+			if len(parts) > 0 {
+				prev := &parts[len(parts)-1]
+				if prev.Meta == nil {
+					prev.Meta = map[string]interface{}{}
+				}
+				prev.Meta["cache_control"] = map[string]interface{}{"type": "ephemeral"}
+			}
+			continue
+		}
+
+		part, err := normalizeBedrockContentBlock(block)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		parts = append(parts, part)
+	}
+
+	// Extract message-level metadata
+	messageMeta := map[string]interface{}{
+		"source_format": "bedrock",
+	}
+
+	return message.Role, parts, messageMeta, nil
+}
+
+func normalizeBedrockContentBlock(block BedrockContentBlock) (service.PartIn, error) {
+	if block.Text != nil {
+		return service.PartIn{
+			Type: "text",
+			Text: *block.Text,
+		}, nil
+	} else if block.Image != nil {
+		return normalizeBedrockImageBlock(*block.Image)
+	} else if block.Document != nil {
+		return normalizeBedrockDocumentBlock(*block.Document)
+	} else if block.ToolUse != nil {
+		input := block.ToolUse.Input
+		if input == nil {
+			input = map[string]interface{}{}
+		}
+		argsBytes, err := json.Marshal(input)
+		if err != nil {
+			return service.PartIn{}, fmt.Errorf("failed to marshal tool input: %w", err)
+		}
+
+		// UNIFIED FORMAT: tool-call with unified field names
+		return service.PartIn{
+			Type: "tool-call",
+			Meta: map[string]interface{}{
+				"id":        block.ToolUse.ToolUseID,
+				"name":      block.ToolUse.Name,
+				"arguments": string(argsBytes),
+				"type":      "tool_use",
+			},
+		}, nil
+	} else if block.ToolResult != nil {
+		// Text and json content are joined into the result text; images and documents are kept as nested parts
+		var resultText string
+		var content []service.PartIn
+		hasMedia := false
+		for _, contentItem := range block.ToolResult.Content {
+			if contentItem.Text != nil {
+				resultText += *contentItem.Text
+				content = append(content, service.PartIn{Type: "text", Text: *contentItem.Text})
+			} else if contentItem.JSON != nil {
+				jsonBytes, err := json.Marshal(contentItem.JSON)
+				if err != nil {
+					return service.PartIn{}, fmt.Errorf("failed to marshal tool result json: %w", err)
+				}
+				resultText += string(jsonBytes)
+				content = append(content, service.PartIn{Type: "text", Text: string(jsonBytes)})
+			} else if contentItem.Image != nil {
+				part, err := normalizeBedrockImageBlock(*contentItem.Image)
+				if err != nil {
+					return service.PartIn{}, err
+				}
+				content = append(content, part)
+				hasMedia = true
+			} else if contentItem.Document != nil {
+				part, err := normalizeBedrockDocumentBlock(*contentItem.Document)
+				if err != nil {
+					return service.PartIn{}, err
+				}
+				content = append(content, part)
+				hasMedia = true
+			}
+		}
+		if !hasMedia {
+			content = nil
+		}
+
+		// UNIFIED FORMAT: tool_call_id instead of toolUseId
+		return service.PartIn{
+			Type: "tool-result",
+			Text: resultText,
+			Meta: map[string]interface{}{
+				"tool_call_id": block.ToolResult.ToolUseID,
+				"is_error":     block.ToolResult.Status == "error",
+			},
+			Content: content,
+		}, nil
+	} else if block.ReasoningContent != nil {
+		meta := map[string]interface{}{
+			"source_format": "bedrock",
+		}
+		if block.ReasoningContent.ReasoningText != nil {
+			if block.ReasoningContent.ReasoningText.Signature != "" {
+				meta["signature"] = block.ReasoningContent.ReasoningText.Signature
+			}
+			return service.PartIn{
+				Type: "thinking",
+				Text: block.ReasoningContent.ReasoningText.Text,
+				Meta: meta,
+			}, nil
+		} else if len(block.ReasoningContent.RedactedContent) > 0 {
+			meta["redacted"] = true
+			meta["data"] = base64.StdEncoding.EncodeToString(block.ReasoningContent.RedactedContent)
+			return service.PartIn{
+				Type: "thinking",
+				Meta: meta,
+			}, nil
+		}
+		return service.PartIn{}, fmt.Errorf("invalid Bedrock reasoningContent block: reasoningText or redactedContent is required")
+	}
+
+	return service.PartIn{}, fmt.Errorf("unsupported Bedrock content block type")
+}
+
+func normalizeBedrockImageBlock(image BedrockImageBlock) (service.PartIn, error) {
+	meta, err := normalizeBedrockSource(image.Source, BedrockImageMediaType(image.Format))
+	if err != nil {
+		return service.PartIn{}, fmt.Errorf("invalid Bedrock image block: %w", err)
+	}
+	return service.PartIn{
+		Type: "image",
+		Meta: meta,
+	}, nil
+}
+
+func normalizeBedrockDocumentBlock(document BedrockDocumentBlock) (service.PartIn, error) {
+	meta, err := normalizeBedrockSource(document.Source, BedrockDocumentMediaType(document.Format))
+	if err != nil {
+		return service.PartIn{}, fmt.Errorf("invalid Bedrock document block: %w", err)
+	}
+	if document.Name != "" {
+		meta["filename"] = document.Name
+	}
+	return service.PartIn{
+		Type: "file",
+		Meta: meta,
+	}, nil
+}
+
+func normalizeBedrockSource(source BedrockSource, mediaType string) (map[string]interface{}, error) {
+	meta := map[string]interface{}{}
+	if len(source.Bytes) > 0 {
+		meta["type"] = "base64"
+		meta["media_type"] = mediaType
+		meta["data"] = base64.StdEncoding.EncodeToString(source.Bytes)
+	} else if source.S3Location != nil && source.S3Location.URI != "" {
+		meta["type"] = "s3"
+		meta["media_type"] = mediaType
+		meta["s3_uri"] = source.S3Location.URI
+		if source.S3Location.BucketOwner != "" {
+			meta["bucket_owner"] = source.S3Location.BucketOwner
+		}
+	} else {
+		return nil, fmt.Errorf("source must contain bytes or s3Location")
+	}
+	return meta, nil
+}
+
+var bedrockDocumentMediaTypes = map[string]string{
+	"pdf":  "application/pdf",
+	"csv":  "text/csv",
+	"doc":  "application/msword",
+	"docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"xls":  "application/vnd.ms-excel",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"html": "text/html",
+	"txt":  "text/plain",
+	"md":   "text/markdown",
+}
+
+// BedrockImageMediaType maps a Bedrock image format to its MIME type
+func BedrockImageMediaType(format string) string {
+	return "image/" + format
+}
+
+// BedrockImageFormat maps an image MIME type to a Bedrock image format.
+// Returns "" if Bedrock does not support the type.
+func BedrockImageFormat(mediaType string) string {
+	switch strings.ToLower(mediaType) {
+	case "image/png":
+		return "png"
+	case "image/jpeg", "image/jpg":
+		return "jpeg"
+	case "image/gif":
+		return "gif"
+	case "image/webp":
+		return "webp"
+	default:
+		return ""
+	}
+}
+
+// BedrockDocumentMediaType maps a Bedrock document format to its MIME type
+func BedrockDocumentMediaType(format string) string {
+	if mediaType, ok := bedrockDocumentMediaTypes[format]; ok {
+		return mediaType
+	}
+	return "application/octet-stream"
+}
+
+// BedrockDocumentFormat maps a document MIME type to a Bedrock document format.
+// Returns "" if Bedrock does not support the type.
+func BedrockDocumentFormat(mediaType string) string {
+	mediaType = strings.ToLower(mediaType)
+	for format, mt := range bedrockDocumentMediaTypes {
+		if mt == mediaType {
+			return format
+		}
+	}
+	return ""
+}
@@ -0,0 +1,286 @@
+package normalizer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBedrockNormalizer_NormalizeFromBedrockMessage(t *testing.T) {
+	normalizer := &BedrockNormalizer{}
+
+	tests := []struct {
+		name        string
+		input       string
+		wantRole    string
+		wantPartCnt int
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name: "user message with text",
+			input: `{
+				"role": "user",
+				"content": [
+					{"text": "Hello, how are you?"}
+				]
+			}`,
+			wantRole:    "user",
+			wantPartCnt: 1,
+			wantErr:     false,
+		},
+		{
+			name: "user message with image bytes",
+			input: `{
+				"role": "user",
+				"content": [
+					{"text": "What's in this image?"},
+					{"image": {"format": "png", "source": {"bytes": "aGVsbG8="}}}
+				]
+			}`,
+			wantRole:    "user",
+			wantPartCnt: 2,
+			wantErr:     false,
+		},
+		{
+			name: "assistant message with tool use",
+			input: `{
+				"role": "assistant",
+				"content": [
+					{"toolUse": {"toolUseId": "tooluse_123", "name": "get_weather", "input": {"city": "Seattle"}}}
+				]
+			}`,
+			wantRole:    "assistant",
+			wantPartCnt: 1,
+			wantErr:     false,
+		},
+		{
+			name: "cache point does not create a part",
+			input: `{
+				"role": "user",
+				"content": [
+					{"text": "Long context"},
+					{"cachePoint": {"type": "default"}}
+				]
+			}`,
+			wantRole:    "user",
+			wantPartCnt: 1,
+			wantErr:     false,
+		},
+		{
+			name: "invalid role",
+			input: `{
+				"role": "system",
+				"content": [
+					{"text": "System message"}
+				]
+			}`,
+			wantErr:     true,
+			errContains: "invalid Bedrock role",
+		},
+		{
+			name: "unsupported block",
+			input: `{
+				"role": "user",
+				"content": [
+					{"video": {"format": "mp4", "source": {"bytes": "aGVsbG8="}}}
+				]
+			}`,
+			wantErr:     true,
+			errContains: "unsupported Bedrock content block type",
+		},
+		{
+			name: "image without source",
+			input: `{
+				"role": "user",
+				"content": [
+					{"image": {"format": "png", "source": {}}}
+				]
+			}`,
+			wantErr:     true,
+			errContains: "invalid Bedrock image block",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, parts, messageMeta, err := normalizer.NormalizeFromBedrockMessage(json.RawMessage(tt.input))
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.errContains != "" {
+					assert.Contains(t, err.Error(), tt.errContains)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantRole, role)
+				assert.Len(t, parts, tt.wantPartCnt)
+				assert.NotNil(t, messageMeta)
+				assert.Equal(t, "bedrock", messageMeta["source_format"])
+			}
+		})
+	}
+}
+
+func TestBedrockNormalizer_ToolUse(t *testing.T) {
+	normalizer := &BedrockNormalizer{}
+
+	input := `{
+		"role": "assistant",
+		"content": [
+			{"toolUse": {"toolUseId": "tooluse_abc", "name": "calculate", "input": {"x": 5, "y": 3}}}
+		]
+	}`
+
+	role, parts, _, err := normalizer.NormalizeFromBedrockMessage(json.RawMessage(input))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "assistant", role)
+	assert.Len(t, parts, 1)
+	assert.Equal(t, "tool-call", parts[0].Type)
+	assert.Equal(t, "tooluse_abc", parts[0].Meta["id"])
+	assert.Equal(t, "calculate", parts[0].Meta["name"])
+	assert.JSONEq(t, `{"x": 5, "y": 3}`, parts[0].Meta["arguments"].(string))
+	assert.Equal(t, "tool_use", parts[0].Meta["type"])
+}
+
+func TestBedrockNormalizer_ToolResult(t *testing.T) {
+	normalizer := &BedrockNormalizer{}
+
+	input := `{
+		"role": "user",
+		"content": [
+			{
+				"toolResult": {
+					"toolUseId": "tooluse_abc",
+					"content": [
+						{"text": "Result: "},
+						{"json": {"value": 8}}
+					],
+					"status": "error"
+				}
+			}
+		]
+	}`
+
+	role, parts, _, err := normalizer.NormalizeFromBedrockMessage(json.RawMessage(input))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user", role)
+	assert.Len(t, parts, 1)
+	assert.Equal(t, "tool-result", parts[0].Type)
+	assert.Equal(t, `Result: {"value":8}`, parts[0].Text)
+	assert.Equal(t, "tooluse_abc", parts[0].Meta["tool_call_id"])
+	assert.Equal(t, true, parts[0].Meta["is_error"])
+}
+
+func TestBedrockNormalizer_ImageAndDocument(t *testing.T) {
+	normalizer := &BedrockNormalizer{}
+
+	input := `{
+		"role": "user",
+		"content": [
+			{"image": {"format": "jpeg", "source": {"bytes": "aGVsbG8="}}},
+			{"document": {"format": "pdf", "name": "report", "source": {"bytes": "aGVsbG8="}}},
+			{"document": {"format": "txt", "name": "notes", "source": {"s3Location": {"uri": "s3://bucket/notes.txt"}}}}
+		]
+	}`
+
+	_, parts, _, err := normalizer.NormalizeFromBedrockMessage(json.RawMessage(input))
+
+	assert.NoError(t, err)
+	assert.Len(t, parts, 3)
+
+	assert.Equal(t, "image", parts[0].Type)
+	assert.Equal(t, "base64", parts[0].Meta["type"])
+	assert.Equal(t, "image/jpeg", parts[0].Meta["media_type"])
+	assert.Equal(t, "aGVsbG8=", parts[0].Meta["data"])
+
+	assert.Equal(t, "file", parts[1].Type)
+	assert.Equal(t, "application/pdf", parts[1].Meta["media_type"])
+	assert.Equal(t, "report", parts[1].Meta["filename"])
+
+	assert.Equal(t, "file", parts[2].Type)
+	assert.Equal(t, "s3", parts[2].Meta["type"])
+	assert.Equal(t, "s3://bucket/notes.txt", parts[2].Meta["s3_uri"])
+	assert.Equal(t, "text/plain", parts[2].Meta["media_type"])
+}
+
+func TestBedrockNormalizer_CachePoint(t *testing.T) {
+	normalizer := &BedrockNormalizer{}
+
+	input := `{
+		"role": "user",
+		"content": [
+			{"cachePoint": {"type": "default"}},
+			{"text": "System-like prefix"},
+			{"cachePoint": {"type": "default"}},
+			{"text": "Question"}
+		]
+	}`
+
+	_, parts, _, err := normalizer.NormalizeFromBedrockMessage(json.RawMessage(input))
+
+	assert.NoError(t, err)
+	assert.Len(t, parts, 2)
+	assert.Equal(t, map[string]interface{}{"type": "ephemeral"}, parts[0].Meta["cache_control"])
+	assert.Nil(t, parts[1].Meta)
+}
+
+func TestBedrockNormalizer_ReasoningContent(t *testing.T) {
+	normalizer := &BedrockNormalizer{}
+
+	input := `{
+		"role": "assistant",
+		"content": [
+			{"reasoningContent": {"reasoningText": {"text": "Let me check", "signature": "sig_1"}}},
+			{"reasoningContent": {"redactedContent": "aGVsbG8="}},
+			{"text": "Done"}
+		]
+	}`
+
+	_, parts, _, err := normalizer.NormalizeFromBedrockMessage(json.RawMessage(input))
+
+	assert.NoError(t, err)
+	assert.Len(t, parts, 3)
+
+	assert.Equal(t, "thinking", parts[0].Type)
+	assert.Equal(t, "Let me check", parts[0].Text)
+	assert.Equal(t, "bedrock", parts[0].Meta["source_format"])
+	assert.Equal(t, "sig_1", parts[0].Meta["signature"])
+
+	assert.Equal(t, "thinking", parts[1].Type)
+	assert.Equal(t, true, parts[1].Meta["redacted"])
+	assert.Equal(t, "aGVsbG8=", parts[1].Meta["data"])
+}
+
+func TestBedrockNormalizer_ToolResultWithImage(t *testing.T) {
+	normalizer := &BedrockNormalizer{}
+
+	input := `{
+		"role": "user",
+		"content": [
+			{
+				"toolResult": {
+					"toolUseId": "tooluse_abc",
+					"content": [
+						{"text": "Chart:"},
+						{"image": {"format": "png", "source": {"bytes": "aGVsbG8="}}}
+					]
+				}
+			}
+		]
+	}`
+
+	_, parts, _, err := normalizer.NormalizeFromBedrockMessage(json.RawMessage(input))
+
+	assert.NoError(t, err)
+	assert.Len(t, parts, 1)
+	assert.Equal(t, "Chart:", parts[0].Text)
+	assert.Len(t, parts[0].Content, 2)
+	assert.Equal(t, "text", parts[0].Content[0].Type)
+	assert.Equal(t, "image", parts[0].Content[1].Type)
+	assert.Equal(t, "image/png", parts[0].Content[1].Meta["media_type"])
+	assert.Equal(t, "aGVsbG8=", parts[0].Content[1].Meta["data"])
+}
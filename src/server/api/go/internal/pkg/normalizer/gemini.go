@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"google.golang.org/genai"
@@ -81,15 +82,36 @@ func normalizeGeminiPart(part *genai.Part) (service.PartIn, map[string]interface
 		return service.PartIn{}, nil, fmt.Errorf("nil part")
 	}
 
+	// Handle thought part (must be checked before plain text, thoughts carry their text in Text)
+	if part.Thought {
+		meta := map[string]interface{}{
+			"source_format": "gemini",
+		}
+		if len(part.ThoughtSignature) > 0 {
+			meta["signature"] = base64.StdEncoding.EncodeToString(part.ThoughtSignature)
+		}
+		return service.PartIn{
+			Type: "thinking",
+			Text: part.Text,
+			Meta: meta,
+		}, nil, nil
+	}
+
 	// Handle text part
 	if part.Text != "" {
-		return service.PartIn{
+		partIn := service.PartIn{
 			Type: "text",
 			Text: part.Text,
-		}, nil, nil
+		}
+		if len(part.ThoughtSignature) > 0 {
+			partIn.Meta = map[string]interface{}{
+				"thought_signature": base64.StdEncoding.EncodeToString(part.ThoughtSignature),
+			}
+		}
+		return partIn, nil, nil
 	}
 
-	// Handle image part (InlineData)
+	// Handle image and document parts (InlineData)
 	if part.InlineData != nil {
 		// Convert []byte to base64 string
 		dataBase64 := base64.StdEncoding.EncodeToString(part.InlineData.Data)
@@ -98,16 +120,24 @@ func normalizeGeminiPart(part *genai.Part) (service.PartIn, map[string]interface
 			"media_type": part.InlineData.MIMEType,
 			"data":       dataBase64,
 		}
+		partType := "image"
+		if part.InlineData.MIMEType != "" && !strings.HasPrefix(part.InlineData.MIMEType, "image/") {
+			partType = "file"
+		}
 		return service.PartIn{
-			Type: "image",
+			Type: partType,
 			Meta: meta,
 		}, nil, nil
 	}
 
 	// Handle function call part
 	if part.FunctionCall != nil {
-		// Convert args to JSON string
-		argsBytes, err := json.Marshal(part.FunctionCall.Args)
+		// Convert args to JSON string; empty args are omitted on the wire and mean {}
+		args := part.FunctionCall.Args
+		if args == nil {
+			args = map[string]interface{}{}
+		}
+		argsBytes, err := json.Marshal(args)
 		if err != nil {
 			return service.PartIn{}, nil, fmt.Errorf("failed to marshal function call args: %w", err)
 		}
@@ -137,6 +167,11 @@ func normalizeGeminiPart(part *genai.Part) (service.PartIn, map[string]interface
 			"type":      "function",
 		}
 
+		// Gemini requires the thought signature of a function call to be returned with it
+		if len(part.ThoughtSignature) > 0 {
+			meta["thought_signature"] = base64.StdEncoding.EncodeToString(part.ThoughtSignature)
+		}
+
 		return service.PartIn{
 			Type: "tool-call",
 			Meta: meta,
@@ -170,12 +205,54 @@ func normalizeGeminiPart(part *genai.Part) (service.PartIn, map[string]interface
 			meta["tool_call_id"] = part.FunctionResponse.ID
 		}
 
+		// Multimodal function responses carry media in Parts; keep them next to the response JSON
+		var content []service.PartIn
+		if len(part.FunctionResponse.Parts) > 0 {
+			content = append(content, service.PartIn{Type: "text", Text: responseText})
+			for _, responsePart := range part.FunctionResponse.Parts {
+				if responsePart == nil {
+					continue
+				}
+				content = append(content, normalizeGeminiFunctionResponsePart(responsePart))
+			}
+		}
+
 		return service.PartIn{
-			Type: "tool-result",
-			Text: responseText,
-			Meta: meta,
+			Type:    "tool-result",
+			Text:    responseText,
+			Meta:    meta,
+			Content: content,
 		}, nil, nil
 	}
 
 	return service.PartIn{}, nil, fmt.Errorf("unsupported Gemini part type")
 }
+
+// normalizeGeminiFunctionResponsePart converts a media part of a function response into an image or file part
+func normalizeGeminiFunctionResponsePart(part *genai.FunctionResponsePart) service.PartIn {
+	meta := map[string]interface{}{}
+	mimeType := ""
+	if part.InlineData != nil {
+		mimeType = part.InlineData.MIMEType
+		meta["type"] = "base64"
+		meta["media_type"] = mimeType
+		meta["data"] = base64.StdEncoding.EncodeToString(part.InlineData.Data)
+	} else if part.FileData != nil {
+		mimeType = part.FileData.MIMEType
+		meta["type"] = "url"
+		meta["url"] = part.FileData.FileURI
+		if mimeType != "" {
+			meta["media_type"] = mimeType
+		}
+	}
+
+	partType := "file"
+	if strings.HasPrefix(mimeType, "image/") {
+		partType = "image"
+	}
+
+	return service.PartIn{
+		Type: partType,
+		Meta: meta,
+	}
+}
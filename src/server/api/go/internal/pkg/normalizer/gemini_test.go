@@ -530,3 +530,72 @@ func TestGeminiNormalizer_MixedFunctionCalls(t *testing.T) {
 	assert.True(t, foundProvided, "provided_func should be in call info")
 	assert.True(t, foundGenerated, "generated_func should be in call info")
 }
+
+func TestGeminiNormalizer_ThoughtParts(t *testing.T) {
+	normalizer := &GeminiNormalizer{}
+
+	signature := []byte("thought-signature")
+	content := genai.Content{
+		Role: "model",
+		Parts: []*genai.Part{
+			{Text: "Thinking about the weather", Thought: true},
+			{
+				FunctionCall:     &genai.FunctionCall{ID: "call_1", Name: "get_weather", Args: map[string]any{"city": "Paris"}},
+				ThoughtSignature: signature,
+			},
+		},
+	}
+	input, err := json.Marshal(content)
+	assert.NoError(t, err)
+
+	role, parts, _, err := normalizer.NormalizeFromGeminiMessage(input)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "assistant", role)
+	assert.Len(t, parts, 2)
+
+	assert.Equal(t, "thinking", parts[0].Type)
+	assert.Equal(t, "Thinking about the weather", parts[0].Text)
+	assert.Equal(t, "gemini", parts[0].Meta["source_format"])
+
+	assert.Equal(t, "tool-call", parts[1].Type)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(signature), parts[1].Meta["thought_signature"])
+}
+
+func TestGeminiNormalizer_FunctionResponseWithParts(t *testing.T) {
+	normalizer := &GeminiNormalizer{}
+
+	input := `{
+		"role": "user",
+		"parts": [
+			{
+				"functionResponse": {
+					"id": "call_123",
+					"name": "take_screenshot",
+					"response": {"output": "ok"},
+					"parts": [
+						{"inlineData": {"mimeType": "image/png", "data": "aGVsbG8="}},
+						{"fileData": {"mimeType": "application/pdf", "fileUri": "gs://bucket/report.pdf"}}
+					]
+				}
+			}
+		]
+	}`
+
+	_, parts, _, err := normalizer.NormalizeFromGeminiMessage(json.RawMessage(input))
+
+	assert.NoError(t, err)
+	assert.Len(t, parts, 1)
+	assert.Len(t, parts[0].Content, 3)
+
+	assert.Equal(t, "text", parts[0].Content[0].Type)
+	assert.JSONEq(t, `{"output":"ok"}`, parts[0].Content[0].Text)
+
+	assert.Equal(t, "image", parts[0].Content[1].Type)
+	assert.Equal(t, "base64", parts[0].Content[1].Meta["type"])
+	assert.Equal(t, "aGVsbG8=", parts[0].Content[1].Meta["data"])
+
+	assert.Equal(t, "file", parts[0].Content[2].Type)
+	assert.Equal(t, "url", parts[0].Content[2].Meta["type"])
+	assert.Equal(t, "gs://bucket/report.pdf", parts[0].Content[2].Meta["url"])
+}
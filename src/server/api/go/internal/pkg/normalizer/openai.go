@@ -3,6 +3,7 @@ package normalizer
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	openai "github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/packages/param"
@@ -26,15 +27,15 @@ func (n *OpenAINormalizer) NormalizeFromOpenAIMessage(messageJSON json.RawMessag
 	if message.OfUser != nil {
 		return normalizeOpenAIUserMessage(*message.OfUser)
 	} else if message.OfAssistant != nil {
-		return normalizeOpenAIAssistantMessage(*message.OfAssistant)
+		return normalizeOpenAIAssistantMessage(*message.OfAssistant, extractOpenAIReasoning(messageJSON))
 	} else if message.OfSystem != nil {
-		return "", nil, nil, fmt.Errorf("system messages are not supported. Use session-level or skill-level configuration for system prompts")
+		return normalizeOpenAISystemMessage(message.OfSystem.Content.OfString, message.OfSystem.Content.OfArrayOfContentParts, "system")
 	} else if message.OfTool != nil {
 		return normalizeOpenAIToolMessage(*message.OfTool)
 	} else if message.OfFunction != nil {
 		return normalizeOpenAIFunctionMessage(*message.OfFunction)
 	} else if message.OfDeveloper != nil {
-		return "", nil, nil, fmt.Errorf("developer messages are not supported. Use session-level or skill-level configuration for system prompts")
+		return normalizeOpenAISystemMessage(message.OfDeveloper.Content.OfString, message.OfDeveloper.Content.OfArrayOfContentParts, "developer")
 	}
 
 	return "", nil, nil, fmt.Errorf("unknown OpenAI message type")
@@ -74,9 +75,20 @@ func normalizeOpenAIUserMessage(msg openai.ChatCompletionUserMessageParam) (stri
 	return "user", parts, messageMeta, nil
 }
 
-func normalizeOpenAIAssistantMessage(msg openai.ChatCompletionAssistantMessageParam) (string, []service.PartIn, map[string]interface{}, error) {
+func normalizeOpenAIAssistantMessage(msg openai.ChatCompletionAssistantMessageParam, reasoning string) (string, []service.PartIn, map[string]interface{}, error) {
 	parts := []service.PartIn{}
 
+	// Reasoning comes before the answer, like thinking blocks in other formats
+	if reasoning != "" {
+		parts = append(parts, service.PartIn{
+			Type: "thinking",
+			Text: reasoning,
+			Meta: map[string]interface{}{
+				"source_format": "openai",
+			},
+		})
+	}
+
 	// Handle content - can be string or array
 	if !param.IsOmitted(msg.Content.OfString) {
 		if msg.Content.OfString.Value != "" {
@@ -135,16 +147,41 @@ func normalizeOpenAIAssistantMessage(msg openai.ChatCompletionAssistantMessagePa
 	return "assistant", parts, messageMeta, nil
 }
 
+// extractOpenAIReasoning reads the reasoning text of an assistant message.
+// The official SDK has no field for it, so the OpenAI-compatible conventions
+// `reasoning_content` (DeepSeek, vLLM, ...) and `reasoning` (OpenRouter, ...) are read from the raw JSON.
+func extractOpenAIReasoning(messageJSON json.RawMessage) string {
+	var raw struct {
+		ReasoningContent string `json:"reasoning_content"`
+		Reasoning        any    `json:"reasoning"`
+	}
+	if err := json.Unmarshal(messageJSON, &raw); err != nil {
+		return ""
+	}
+	if raw.ReasoningContent != "" {
+		return raw.ReasoningContent
+	}
+	if reasoning, ok := raw.Reasoning.(string); ok {
+		return reasoning
+	}
+	return ""
+}
+
 func normalizeOpenAIToolMessage(msg openai.ChatCompletionToolMessageParam) (string, []service.PartIn, map[string]interface{}, error) {
 	parts := []service.PartIn{}
 
-	// Tool messages are converted to user messages with tool-result parts
+	// Tool messages are converted to user messages with tool-result parts.
+	// Array content is also kept as nested text parts so it can be sent back as an array.
 	var content string
+	var nested []service.PartIn
 	if !param.IsOmitted(msg.Content.OfString) {
 		content = msg.Content.OfString.Value
 	} else if len(msg.Content.OfArrayOfContentParts) > 0 {
 		for _, textPart := range msg.Content.OfArrayOfContentParts {
 			content += textPart.Text
+			if textPart.Text != "" {
+				nested = append(nested, service.PartIn{Type: "text", Text: textPart.Text})
+			}
 		}
 	}
 
@@ -154,6 +191,7 @@ func normalizeOpenAIToolMessage(msg openai.ChatCompletionToolMessageParam) (stri
 		Meta: map[string]interface{}{
 			"tool_call_id": msg.ToolCallID, // Keep as tool_call_id (unified format)
 		},
+		Content: nested,
 	})
 
 	// Extract message-level metadata
@@ -164,6 +202,40 @@ func normalizeOpenAIToolMessage(msg openai.ChatCompletionToolMessageParam) (stri
 	return "user", parts, messageMeta, nil
 }
 
+// normalizeOpenAISystemMessage normalizes system and developer messages.
+// They are not stored as session messages: the returned "system" role tells the caller
+// to store the text as the session system prompt instead.
+func normalizeOpenAISystemMessage(contentString param.Opt[string], contentParts []openai.ChatCompletionContentPartTextParam, originalRole string) (string, []service.PartIn, map[string]interface{}, error) {
+	var content string
+	if !param.IsOmitted(contentString) {
+		content = contentString.Value
+	} else {
+		texts := make([]string, 0, len(contentParts))
+		for _, textPart := range contentParts {
+			texts = append(texts, textPart.Text)
+		}
+		content = strings.Join(texts, "\n")
+	}
+
+	if content == "" {
+		return "", nil, nil, fmt.Errorf("%s message content cannot be empty", originalRole)
+	}
+
+	parts := []service.PartIn{
+		{
+			Type: "text",
+			Text: content,
+		},
+	}
+
+	messageMeta := map[string]interface{}{
+		"source_format": "openai",
+		"original_role": originalRole,
+	}
+
+	return "system", parts, messageMeta, nil
+}
+
 func normalizeOpenAIFunctionMessage(msg openai.ChatCompletionFunctionMessageParam) (string, []service.PartIn, map[string]interface{}, error) {
 	// Function messages are converted to user messages with tool-result parts
 	content := ""
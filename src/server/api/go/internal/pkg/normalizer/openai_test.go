@@ -115,33 +115,36 @@ func TestOpenAINormalizer_NormalizeFromOpenAIMessage(t *testing.T) {
 			wantErr:     false,
 		},
 		{
-			name: "system message (not supported)",
+			name: "system message",
 			input: `{
 				"role": "system",
 				"content": "You are a helpful assistant."
 			}`,
-			wantErr:     true,
-			errContains: "system messages are not supported",
+			wantRole:    "system",
+			wantPartCnt: 1,
+			wantErr:     false,
 		},
 		{
-			name: "system message with array content (not supported)",
+			name: "system message with array content",
 			input: `{
 				"role": "system",
 				"content": [
 					{"type": "text", "text": "You are a helpful assistant."}
 				]
 			}`,
-			wantErr:     true,
-			errContains: "system messages are not supported",
+			wantRole:    "system",
+			wantPartCnt: 1,
+			wantErr:     false,
 		},
 		{
-			name: "developer message (not supported)",
+			name: "developer message",
 			input: `{
 				"role": "developer",
 				"content": "This is a developer instruction."
 			}`,
-			wantErr:     true,
-			errContains: "developer messages are not supported",
+			wantRole:    "system",
+			wantPartCnt: 1,
+			wantErr:     false,
 		},
 		{
 			name: "tool message",
@@ -192,12 +195,12 @@ func TestOpenAINormalizer_NormalizeFromOpenAIMessage(t *testing.T) {
 			errContains: "must have content",
 		},
 		{
-			name: "system message without content (not supported)",
+			name: "system message without content",
 			input: `{
 				"role": "system"
 			}`,
 			wantErr:     true,
-			errContains: "system messages are not supported",
+			errContains: "system message content cannot be empty",
 		},
 	}
 
@@ -335,6 +338,22 @@ func TestOpenAINormalizer_ToolCallsAndResults(t *testing.T) {
 		assert.Equal(t, "openai", messageMeta["source_format"])
 	})
 
+	t.Run("tool result message with array content", func(t *testing.T) {
+		input := `{
+			"role": "tool",
+			"content": [{"type": "text", "text": "Line 1\n"}, {"type": "text", "text": "Line 2"}],
+			"tool_call_id": "call_123"
+		}`
+
+		_, parts, _, err := normalizer.NormalizeFromOpenAIMessage(json.RawMessage(input))
+
+		assert.NoError(t, err)
+		assert.Len(t, parts, 1)
+		assert.Equal(t, "Line 1\nLine 2", parts[0].Text)
+		assert.Len(t, parts[0].Content, 2)
+		assert.Equal(t, "Line 2", parts[0].Content[1].Text)
+	})
+
 	t.Run("deprecated function call", func(t *testing.T) {
 		input := `{
 			"role": "assistant",
@@ -406,3 +425,69 @@ func TestOpenAINormalizer_MessageWithName(t *testing.T) {
 	assert.Equal(t, "openai", messageMeta["source_format"])
 	assert.Equal(t, "Alice", messageMeta["name"])
 }
+
+func TestOpenAINormalizer_AssistantReasoning(t *testing.T) {
+	normalizer := &OpenAINormalizer{}
+
+	tests := []struct {
+		name          string
+		input         string
+		wantReasoning string
+		wantPartCnt   int
+	}{
+		{
+			name:          "reasoning_content field",
+			input:         `{"role": "assistant", "content": "42", "reasoning_content": "6 times 7"}`,
+			wantReasoning: "6 times 7",
+			wantPartCnt:   2,
+		},
+		{
+			name:          "reasoning field",
+			input:         `{"role": "assistant", "content": "42", "reasoning": "6 times 7"}`,
+			wantReasoning: "6 times 7",
+			wantPartCnt:   2,
+		},
+		{
+			name:        "no reasoning",
+			input:       `{"role": "assistant", "content": "42"}`,
+			wantPartCnt: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, parts, _, err := normalizer.NormalizeFromOpenAIMessage(json.RawMessage(tt.input))
+
+			assert.NoError(t, err)
+			assert.Equal(t, "assistant", role)
+			assert.Len(t, parts, tt.wantPartCnt)
+			if tt.wantReasoning != "" {
+				assert.Equal(t, "thinking", parts[0].Type)
+				assert.Equal(t, tt.wantReasoning, parts[0].Text)
+				assert.Equal(t, "openai", parts[0].Meta["source_format"])
+				assert.Equal(t, "text", parts[1].Type)
+			}
+		})
+	}
+}
+
+func TestOpenAINormalizer_SystemAndDeveloperMessages(t *testing.T) {
+	normalizer := &OpenAINormalizer{}
+
+	input := `{
+		"role": "developer",
+		"content": [
+			{"type": "text", "text": "You are a helpful assistant."},
+			{"type": "text", "text": "Answer briefly."}
+		]
+	}`
+
+	role, parts, messageMeta, err := normalizer.NormalizeFromOpenAIMessage(json.RawMessage(input))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "system", role)
+	assert.Len(t, parts, 1)
+	assert.Equal(t, "text", parts[0].Type)
+	assert.Equal(t, "You are a helpful assistant.\nAnswer briefly.", parts[0].Text)
+	assert.Equal(t, "developer", messageMeta["original_role"])
+}
@@ -0,0 +1,205 @@
+package textedit
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// hunk is one change of a unified diff, with lines stripped of their line endings
+type hunk struct {
+	oldStart  int
+	old       []string // Context and removed lines
+	new       []string // Context and added lines
+	oldNoEOL  bool     // The old text ends without newline after this hunk
+	newNoEOL  bool     // The new text ends without newline after this hunk
+	oldCount  int
+	newCount  int
+	lastKind  byte // Kind of the last line read, for "\ No newline at end of file"
+	headerPos int  // 1-based number of the hunk in the patch
+}
+
+// ApplyPatch applies a unified diff, as produced by diff -u or git diff, to text. File headers
+// are optional and ignored. A hunk whose context moved is searched for in the rest of the text,
+// but context lines must match exactly. The region spans all lines the patch touched.
+func ApplyPatch(text, patch string) (string, Region, error) {
+	hunks, err := parsePatch(patch)
+	if err != nil {
+		return "", Region{}, err
+	}
+
+	lines := strings.Split(text, "\n")
+	noEOL := text != "" && !strings.HasSuffix(text, "\n")
+	if !noEOL {
+		lines = lines[:len(lines)-1]
+	}
+
+	var (
+		out    []string
+		pos    int // Lines of text consumed
+		offset int // Shift of later hunks caused by earlier ones
+		region = Region{Start: -1}
+	)
+	for _, h := range hunks {
+		at, ok := findHunk(lines, h, pos, h.oldStart-1+offset)
+		if !ok {
+			return "", Region{}, fmt.Errorf("%w: hunk %d at line %d does not match the text", ErrPatchFailed, h.headerPos, h.oldStart)
+		}
+		out = append(out, lines[pos:at]...)
+		start := len(out) + 1
+		out = append(out, h.new...)
+		if region.Start < 0 {
+			region.Start = start
+		}
+		region.End = len(out)
+		if len(h.new) == 0 {
+			region.End = start - 1
+		}
+		pos = at + len(h.old)
+		offset += len(h.new) - len(h.old)
+
+		if pos == len(lines) {
+			if h.oldNoEOL != noEOL && len(h.old) > 0 {
+				return "", Region{}, fmt.Errorf("%w: hunk %d disagrees on the newline at end of file", ErrPatchFailed, h.headerPos)
+			}
+			if len(h.old) > 0 || h.newNoEOL {
+				noEOL = h.newNoEOL
+			}
+		}
+	}
+	out = append(out, lines[pos:]...)
+
+	result := strings.Join(out, "\n")
+	if len(out) > 0 && !noEOL {
+		result += "\n"
+	}
+	return result, region, nil
+}
+
+// findHunk returns where the old lines of h are in lines, searching from want outwards but
+// never before from
+func findHunk(lines []string, h *hunk, from, want int) (int, bool) {
+	matches := func(at int) bool {
+		if at < from || at+len(h.old) > len(lines) {
+			return false
+		}
+		for i, l := range h.old {
+			if lines[at+i] != l {
+				return false
+			}
+		}
+		return true
+	}
+
+	// A pure insertion has nothing to match, so it goes where the header says
+	if len(h.old) == 0 {
+		// The old start of an insertion names the line it goes after
+		at := want + 1
+		if h.oldStart == 0 {
+			at = 0
+		}
+		return at, at >= from && at <= len(lines)
+	}
+
+	for d := 0; d <= len(lines); d++ {
+		if matches(want - d) {
+			return want - d, true
+		}
+		if d > 0 && matches(want+d) {
+			return want + d, true
+		}
+	}
+	return 0, false
+}
+
+func parsePatch(patch string) ([]*hunk, error) {
+	var (
+		hunks []*hunk
+		cur   *hunk
+	)
+	for _, line := range strings.Split(patch, "\n") {
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			if cur != nil && (cur.oldCount > 0 || cur.newCount > 0) {
+				return nil, fmt.Errorf("%w: hunk %d is shorter than its header", ErrInvalidPatch, cur.headerPos)
+			}
+			cur = &hunk{
+				oldStart:  atoi(m[1]),
+				oldCount:  count(m[2]),
+				newCount:  count(m[4]),
+				headerPos: len(hunks) + 1,
+			}
+			hunks = append(hunks, cur)
+			continue
+		}
+		if cur == nil || (cur.oldCount == 0 && cur.newCount == 0) {
+			// File headers and anything between hunks, such as git extended headers
+			if strings.HasPrefix(line, `\`) && cur != nil {
+				cur.markNoEOL()
+			}
+			continue
+		}
+
+		kind, body := byte(' '), ""
+		if line != "" {
+			kind, body = line[0], line[1:]
+		}
+		switch kind {
+		case ' ':
+			cur.old = append(cur.old, body)
+			cur.new = append(cur.new, body)
+			cur.oldCount--
+			cur.newCount--
+		case '-':
+			cur.old = append(cur.old, body)
+			cur.oldCount--
+		case '+':
+			cur.new = append(cur.new, body)
+			cur.newCount--
+		case '\\':
+			cur.markNoEOL()
+			continue
+		default:
+			return nil, fmt.Errorf("%w: unexpected line %q in hunk %d", ErrInvalidPatch, line, cur.headerPos)
+		}
+		if cur.oldCount < 0 || cur.newCount < 0 {
+			return nil, fmt.Errorf("%w: hunk %d is longer than its header", ErrInvalidPatch, cur.headerPos)
+		}
+		cur.lastKind = kind
+	}
+
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("%w: no hunks found", ErrInvalidPatch)
+	}
+	if cur.oldCount > 0 || cur.newCount > 0 {
+		return nil, fmt.Errorf("%w: hunk %d is shorter than its header", ErrInvalidPatch, cur.headerPos)
+	}
+	return hunks, nil
+}
+
+// markNoEOL records a "\ No newline at end of file" marker for the line read before it
+func (h *hunk) markNoEOL() {
+	switch h.lastKind {
+	case ' ':
+		h.oldNoEOL, h.newNoEOL = true, true
+	case '-':
+		h.oldNoEOL = true
+	case '+':
+		h.newNoEOL = true
+	}
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// count returns the line count of a hunk header range, which is 1 when omitted
+func count(s string) int {
+	if s == "" {
+		return 1
+	}
+	return atoi(s)
+}
@@ -0,0 +1,95 @@
+// Package textedit applies small edits to text files: unique string replacement, line insertion
+// and deletion, and unified diff patches. Each edit reports the lines it changed.
+package textedit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrNoMatch         = errors.New("no match found")
+	ErrMultipleMatches = errors.New("multiple matches found")
+	ErrLineOutOfRange  = errors.New("line out of range")
+	ErrInvalidPatch    = errors.New("invalid patch")
+	ErrPatchFailed     = errors.New("patch does not apply")
+)
+
+// Region is the range of 1-based lines an edit produced in the new text. End is Start-1 when the
+// edit only removed lines, in which case Start is the line that followed them.
+type Region struct {
+	Start int `json:"start_line"`
+	End   int `json:"end_line"`
+}
+
+// StrReplace replaces old with new. old must occur exactly once in text.
+func StrReplace(text, old, new string) (string, Region, error) {
+	if old == "" {
+		return "", Region{}, fmt.Errorf("%w: old string is empty", ErrNoMatch)
+	}
+	switch n := strings.Count(text, old); n {
+	case 0:
+		return "", Region{}, ErrNoMatch
+	case 1:
+	default:
+		return "", Region{}, fmt.Errorf("%w: %d occurrences, add context to make the match unique", ErrMultipleMatches, n)
+	}
+
+	i := strings.Index(text, old)
+	start := strings.Count(text[:i], "\n") + 1
+	return text[:i] + new + text[i+len(old):], Region{Start: start, End: start + strings.Count(new, "\n")}, nil
+}
+
+// InsertAtLine inserts content after line, where 0 inserts at the beginning of the text.
+// content always ends up on lines of its own.
+func InsertAtLine(text string, line int, content string) (string, Region, error) {
+	lines := splitLines(text)
+	if line < 0 || line > len(lines) {
+		return "", Region{}, fmt.Errorf("%w: %d, the text has %d lines", ErrLineOutOfRange, line, len(lines))
+	}
+	if content == "" {
+		return text, Region{Start: line + 1, End: line}, nil
+	}
+
+	if line > 0 && !strings.HasSuffix(lines[line-1], "\n") {
+		lines[line-1] += "\n"
+	}
+	if line < len(lines) && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	inserted := splitLines(content)
+
+	out := make([]string, 0, len(lines)+len(inserted))
+	out = append(out, lines[:line]...)
+	out = append(out, inserted...)
+	out = append(out, lines[line:]...)
+	return strings.Join(out, ""), Region{Start: line + 1, End: line + len(inserted)}, nil
+}
+
+// DeleteLines deletes the lines from start to end, 1-based and inclusive
+func DeleteLines(text string, start, end int) (string, Region, error) {
+	lines := splitLines(text)
+	if start < 1 || end < start || end > len(lines) {
+		return "", Region{}, fmt.Errorf("%w: %d-%d, the text has %d lines", ErrLineOutOfRange, start, end, len(lines))
+	}
+
+	out := append(lines[:start-1:start-1], lines[end:]...)
+	// Deleting the last lines leaves the new last line without the newline it had before
+	if end == len(lines) && start > 1 && !strings.HasSuffix(lines[end-1], "\n") {
+		out[start-2] = strings.TrimSuffix(out[start-2], "\n")
+	}
+	return strings.Join(out, ""), Region{Start: start, End: start - 1}, nil
+}
+
+// splitLines splits a text into lines, keeping the line endings
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
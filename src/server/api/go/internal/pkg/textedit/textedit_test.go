@@ -0,0 +1,181 @@
+package textedit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrReplace(t *testing.T) {
+	text := "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"
+
+	out, region, err := StrReplace(text, "\tprintln(\"hi\")\n", "\tprintln(\"hello\")\n\tprintln(\"world\")\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "package main\n\nfunc main() {\n\tprintln(\"hello\")\n\tprintln(\"world\")\n}\n", out)
+	assert.Equal(t, Region{Start: 4, End: 6}, region)
+
+	_, _, err = StrReplace(text, "missing", "x")
+	assert.ErrorIs(t, err, ErrNoMatch)
+	_, _, err = StrReplace(text, "", "x")
+	assert.ErrorIs(t, err, ErrNoMatch)
+	_, _, err = StrReplace(text, "main", "x")
+	assert.ErrorIs(t, err, ErrMultipleMatches)
+	assert.Contains(t, err.Error(), "2 occurrences")
+}
+
+func TestInsertAtLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		line     int
+		content  string
+		expected string
+		region   Region
+		err      error
+	}{
+		{name: "beginning", text: "a\nb\n", line: 0, content: "x", expected: "x\na\nb\n", region: Region{Start: 1, End: 1}},
+		{name: "middle", text: "a\nb\n", line: 1, content: "x\ny\n", expected: "a\nx\ny\nb\n", region: Region{Start: 2, End: 3}},
+		{name: "end", text: "a\nb\n", line: 2, content: "c\n", expected: "a\nb\nc\n", region: Region{Start: 3, End: 3}},
+		{name: "end without newline", text: "a\nb", line: 2, content: "c", expected: "a\nb\nc", region: Region{Start: 3, End: 3}},
+		{name: "empty text", text: "", line: 0, content: "a\n", expected: "a\n", region: Region{Start: 1, End: 1}},
+		{name: "past the end", text: "a\n", line: 2, content: "x", err: ErrLineOutOfRange},
+		{name: "negative", text: "a\n", line: -1, content: "x", err: ErrLineOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, region, err := InsertAtLine(tt.text, tt.line, tt.content)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, out)
+			assert.Equal(t, tt.region, region)
+		})
+	}
+}
+
+func TestDeleteLines(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		start, end int
+		expected   string
+		err        error
+	}{
+		{name: "middle", text: "a\nb\nc\nd\n", start: 2, end: 3, expected: "a\nd\n"},
+		{name: "all", text: "a\nb\n", start: 1, end: 2, expected: ""},
+		{name: "last without newline", text: "a\nb\nc", start: 3, end: 3, expected: "a\nb"},
+		{name: "past the end", text: "a\nb\n", start: 2, end: 3, err: ErrLineOutOfRange},
+		{name: "reversed", text: "a\nb\n", start: 2, end: 1, err: ErrLineOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, region, err := DeleteLines(tt.text, tt.start, tt.end)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, out)
+			assert.Equal(t, Region{Start: tt.start, End: tt.start - 1}, region)
+		})
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	text := "one\ntwo\nthree\nfour\nfive\nsix\nseven\n"
+
+	tests := []struct {
+		name     string
+		text     string
+		patch    string
+		expected string
+		region   Region
+		err      error
+	}{
+		{
+			name: "git diff",
+			text: text,
+			patch: "diff --git a/n.txt b/n.txt\n--- a/n.txt\n+++ b/n.txt\n" +
+				"@@ -2,3 +2,3 @@\n two\n-three\n+THREE\n four\n",
+			expected: "one\ntwo\nTHREE\nfour\nfive\nsix\nseven\n",
+			region:   Region{Start: 2, End: 4},
+		},
+		{
+			name: "two hunks",
+			text: text,
+			patch: "@@ -1,2 +1,3 @@\n one\n+one and a half\n two\n" +
+				"@@ -6,2 +7,1 @@\n six\n-seven\n",
+			expected: "one\none and a half\ntwo\nthree\nfour\nfive\nsix\n",
+			region:   Region{Start: 1, End: 7},
+		},
+		{
+			name:     "moved context",
+			text:     "zero\n" + text,
+			patch:    "@@ -4,1 +4,1 @@\n-four\n+FOUR\n",
+			expected: "zero\none\ntwo\nthree\nFOUR\nfive\nsix\nseven\n",
+			region:   Region{Start: 5, End: 5},
+		},
+		{
+			name:     "pure insertion",
+			text:     text,
+			patch:    "@@ -3,0 +4,1 @@\n+three and a half\n",
+			expected: "one\ntwo\nthree\nthree and a half\nfour\nfive\nsix\nseven\n",
+			region:   Region{Start: 4, End: 4},
+		},
+		{
+			name:     "into empty text",
+			text:     "",
+			patch:    "@@ -0,0 +1,2 @@\n+a\n+b\n",
+			expected: "a\nb\n",
+			region:   Region{Start: 1, End: 2},
+		},
+		{
+			name:     "no newline at end of file",
+			text:     "a\nb",
+			patch:    "@@ -2 +2 @@\n-b\n\\ No newline at end of file\n+c\n",
+			expected: "a\nc\n",
+			region:   Region{Start: 2, End: 2},
+		},
+		{
+			name:  "context mismatch",
+			text:  text,
+			patch: "@@ -2,2 +2,2 @@\n two\n-3\n+THREE\n",
+			err:   ErrPatchFailed,
+		},
+		{
+			name:  "no hunks",
+			text:  text,
+			patch: "--- a/n.txt\n+++ b/n.txt\n",
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "truncated hunk",
+			text:  text,
+			patch: "@@ -2,3 +2,3 @@\n two\n-three\n",
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "garbage in hunk",
+			text:  text,
+			patch: "@@ -2,2 +2,2 @@\n two\n*three\n",
+			err:   ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, region, err := ApplyPatch(tt.text, tt.patch)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, out)
+			assert.Equal(t, tt.region, region)
+		})
+	}
+}
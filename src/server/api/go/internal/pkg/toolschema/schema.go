@@ -0,0 +1,462 @@
+package toolschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// maxRefDepth bounds $ref inlining, since Gemini schemas cannot reference definitions
+const maxRefDepth = 8
+
+// geminiKeys are the JSON schema keywords Gemini's OpenAPI subset understands as-is
+var geminiKeys = map[string]bool{
+	"description": true, "title": true, "default": true, "example": true, "pattern": true,
+	"minimum": true, "maximum": true, "minLength": true, "maxLength": true,
+	"minItems": true, "maxItems": true, "minProperties": true, "maxProperties": true,
+	"propertyOrdering": true,
+}
+
+// geminiFormats lists the formats Gemini accepts for each type
+var geminiFormats = map[string][]string{
+	"string":  {"enum", "date-time"},
+	"integer": {"int32", "int64"},
+	"number":  {"float", "double"},
+}
+
+// strictUnsupportedKeys are the keywords OpenAI strict mode rejects
+var strictUnsupportedKeys = []string{"allOf", "not", "if", "then", "else", "patternProperties", "dependentRequired", "dependentSchemas"}
+
+// objectSchema returns the schema of a tool's arguments, which every provider requires to be an object
+func objectSchema(schema map[string]interface{}) map[string]interface{} {
+	if len(schema) == 0 {
+		return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	if _, ok := schema["type"]; !ok {
+		if _, hasProperties := schema["properties"]; hasProperties {
+			out := copySchema(schema)
+			out["type"] = "object"
+			return out
+		}
+	}
+	return schema
+}
+
+// toGeminiSchema rewrites a JSON schema into Gemini's OpenAPI subset: $ref is inlined, type arrays
+// become nullable or anyOf, const becomes enum and unsupported keywords are dropped with a warning
+func toGeminiSchema(schema map[string]interface{}, path string, warnings *[]string) (*genai.Schema, error) {
+	defs := definitions(schema)
+	converted := geminiSchema(schema, defs, path, 0, warnings)
+
+	data, err := json.Marshal(converted)
+	if err != nil {
+		return nil, err
+	}
+	var out genai.Schema
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("invalid schema for gemini: %w", err)
+	}
+	return &out, nil
+}
+
+func geminiSchema(schema map[string]interface{}, defs map[string]interface{}, path string, depth int, warnings *[]string) map[string]interface{} {
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := resolveRef(ref, defs)
+		if err != nil || depth >= maxRefDepth {
+			if err == nil {
+				err = errors.New("too deeply nested, recursive schemas are not supported")
+			}
+			*warnings = append(*warnings, fmt.Sprintf("%s: $ref %s dropped: %v", path, ref, err))
+			return map[string]interface{}{}
+		}
+		merged := copySchema(target)
+		for k, v := range schema {
+			if k != "$ref" {
+				merged[k] = v
+			}
+		}
+		return geminiSchema(merged, defs, path, depth+1, warnings)
+	}
+
+	// allOf with a single member is common in generated schemas and merges losslessly
+	if allOf, ok := schema["allOf"].([]interface{}); ok && len(allOf) == 1 {
+		if member, ok := allOf[0].(map[string]interface{}); ok {
+			merged := copySchema(member)
+			for k, v := range schema {
+				if k != "allOf" {
+					merged[k] = v
+				}
+			}
+			return geminiSchema(merged, defs, path, depth, warnings)
+		}
+	}
+
+	out := map[string]interface{}{}
+	for _, key := range sortedKeys(schema) {
+		value := schema[key]
+		switch {
+		case geminiKeys[key]:
+			out[key] = value
+		case key == "type":
+			applyGeminiType(out, value, path, warnings)
+		case key == "format":
+			out[key] = value
+		case key == "nullable":
+			out[key] = value
+		case key == "const":
+			out["enum"] = []interface{}{value}
+		case key == "enum":
+			out["enum"] = value
+		case key == "required":
+			out[key] = value
+		case key == "properties":
+			properties, _ := value.(map[string]interface{})
+			converted := make(map[string]interface{}, len(properties))
+			for _, name := range sortedKeys(properties) {
+				if property, ok := properties[name].(map[string]interface{}); ok {
+					converted[name] = geminiSchema(property, defs, path+".properties."+name, depth, warnings)
+				}
+			}
+			out[key] = converted
+		case key == "items":
+			if items, ok := value.(map[string]interface{}); ok {
+				out[key] = geminiSchema(items, defs, path+".items", depth, warnings)
+			} else {
+				*warnings = append(*warnings, fmt.Sprintf("%s: tuple items are not supported by gemini", path))
+			}
+		case key == "anyOf" || key == "oneOf":
+			if key == "oneOf" {
+				*warnings = append(*warnings, fmt.Sprintf("%s: oneOf is relaxed to anyOf for gemini", path))
+			}
+			members, _ := value.([]interface{})
+			converted := make([]interface{}, 0, len(members))
+			for i, m := range members {
+				if member, ok := m.(map[string]interface{}); ok {
+					converted = append(converted, geminiSchema(member, defs, fmt.Sprintf("%s.%s[%d]", path, key, i), depth, warnings))
+				}
+			}
+			out["anyOf"] = converted
+		case key == "$schema" || key == "$defs" || key == "definitions" || key == "$id":
+			// Metadata and definitions are consumed while inlining $ref
+		default:
+			*warnings = append(*warnings, fmt.Sprintf("%s: %s is not supported by gemini and is dropped", path, key))
+		}
+	}
+
+	// Gemini only accepts string enums
+	if enum, ok := out["enum"].([]interface{}); ok {
+		values := make([]interface{}, 0, len(enum))
+		stringified := false
+		for _, v := range enum {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+				continue
+			}
+			stringified = true
+			data, _ := json.Marshal(v)
+			values = append(values, string(data))
+		}
+		if stringified {
+			*warnings = append(*warnings, fmt.Sprintf("%s: non-string enum values are converted to strings for gemini", path))
+			out["type"] = genai.TypeString
+		} else if _, ok := out["type"]; !ok {
+			out["type"] = genai.TypeString
+		}
+		out["enum"] = values
+	}
+
+	if format, ok := out["format"].(string); ok {
+		typeName := strings.ToLower(fmt.Sprint(out["type"]))
+		supported := false
+		for _, f := range geminiFormats[typeName] {
+			supported = supported || f == format
+		}
+		if !supported {
+			*warnings = append(*warnings, fmt.Sprintf("%s: format %s is not supported by gemini and is dropped", path, format))
+			delete(out, "format")
+		}
+	}
+	return out
+}
+
+// applyGeminiType maps JSON schema types to Gemini types. ["string", "null"] becomes a nullable
+// string and several non-null types become an anyOf.
+func applyGeminiType(out map[string]interface{}, value interface{}, path string, warnings *[]string) {
+	var types []string
+	switch v := value.(type) {
+	case string:
+		types = []string{v}
+	case []interface{}:
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+
+	var nonNull []string
+	for _, t := range types {
+		if t == "null" {
+			out["nullable"] = true
+		} else {
+			nonNull = append(nonNull, t)
+		}
+	}
+
+	switch len(nonNull) {
+	case 0:
+		if len(types) > 0 {
+			*warnings = append(*warnings, fmt.Sprintf("%s: null type is not supported by gemini", path))
+		}
+	case 1:
+		out["type"] = genai.Type(strings.ToUpper(nonNull[0]))
+	default:
+		anyOf := make([]interface{}, 0, len(nonNull))
+		for _, t := range nonNull {
+			anyOf = append(anyOf, map[string]interface{}{"type": genai.Type(strings.ToUpper(t))})
+		}
+		out["anyOf"] = anyOf
+	}
+}
+
+// fromGeminiSchema turns a Gemini schema back into JSON schema
+func fromGeminiSchema(schema map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	for key, value := range schema {
+		switch key {
+		case "type":
+			if t, ok := value.(string); ok && t != "" && t != string(genai.TypeUnspecified) {
+				out["type"] = strings.ToLower(t)
+			}
+		case "nullable", "propertyOrdering":
+			// Handled below / ordering hints have no JSON schema equivalent
+		case "properties":
+			properties, _ := value.(map[string]interface{})
+			converted := make(map[string]interface{}, len(properties))
+			for name, p := range properties {
+				if property, ok := p.(map[string]interface{}); ok {
+					converted[name] = fromGeminiSchema(property)
+				}
+			}
+			out[key] = converted
+		case "items":
+			if items, ok := value.(map[string]interface{}); ok {
+				out[key] = fromGeminiSchema(items)
+			}
+		case "anyOf":
+			members, _ := value.([]interface{})
+			converted := make([]interface{}, 0, len(members))
+			for _, m := range members {
+				if member, ok := m.(map[string]interface{}); ok {
+					converted = append(converted, fromGeminiSchema(member))
+				}
+			}
+			out[key] = converted
+		default:
+			out[key] = value
+		}
+	}
+
+	if nullable, _ := schema["nullable"].(bool); nullable {
+		if t, ok := out["type"].(string); ok {
+			out["type"] = []interface{}{t, "null"}
+		}
+	}
+	return out
+}
+
+// toStrictSchema rewrites a schema for OpenAI strict mode. Every object is closed with
+// additionalProperties=false and all its properties become required; properties that were
+// optional become nullable so callers can still omit a value by sending null.
+func toStrictSchema(schema map[string]interface{}, path string, warnings *[]string) (map[string]interface{}, error) {
+	if t, _ := schema["type"].(string); t != "object" {
+		return nil, fmt.Errorf("%s: strict mode requires an object schema", path)
+	}
+	return strictSchema(schema, path, warnings)
+}
+
+func strictSchema(schema map[string]interface{}, path string, warnings *[]string) (map[string]interface{}, error) {
+	for _, key := range strictUnsupportedKeys {
+		if _, ok := schema[key]; ok {
+			return nil, fmt.Errorf("%s: %s is not supported in strict mode", path, key)
+		}
+	}
+
+	out := copySchema(schema)
+	if defs, ok := schema["$defs"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{}, len(defs))
+		for _, name := range sortedKeys(defs) {
+			def, ok := defs[name].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			s, err := strictSchema(def, path+".$defs."+name, warnings)
+			if err != nil {
+				return nil, err
+			}
+			converted[name] = s
+		}
+		out["$defs"] = converted
+	}
+
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		s, err := strictSchema(items, path+".items", warnings)
+		if err != nil {
+			return nil, err
+		}
+		out["items"] = s
+	}
+
+	for _, key := range []string{"anyOf", "oneOf"} {
+		members, ok := schema[key].([]interface{})
+		if !ok {
+			continue
+		}
+		converted := make([]interface{}, 0, len(members))
+		for i, m := range members {
+			member, ok := m.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			s, err := strictSchema(member, fmt.Sprintf("%s.%s[%d]", path, key, i), warnings)
+			if err != nil {
+				return nil, err
+			}
+			converted = append(converted, s)
+		}
+		// Strict mode only supports anyOf
+		delete(out, "oneOf")
+		out["anyOf"] = converted
+	}
+
+	if !isObjectSchema(schema) {
+		return out, nil
+	}
+
+	switch additional := schema["additionalProperties"].(type) {
+	case nil:
+	case bool:
+		if additional {
+			return nil, fmt.Errorf("%s: additionalProperties must be false in strict mode", path)
+		}
+	default:
+		return nil, fmt.Errorf("%s: additionalProperties must be false in strict mode", path)
+	}
+	out["additionalProperties"] = false
+
+	required := map[string]bool{}
+	if list, ok := schema["required"].([]interface{}); ok {
+		for _, r := range list {
+			if name, ok := r.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	converted := make(map[string]interface{}, len(properties))
+	allRequired := make([]interface{}, 0, len(properties))
+	for _, name := range sortedKeys(properties) {
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		s, err := strictSchema(property, path+".properties."+name, warnings)
+		if err != nil {
+			return nil, err
+		}
+		if !required[name] {
+			s = nullableSchema(s)
+			*warnings = append(*warnings, fmt.Sprintf("%s.properties.%s: optional property is made required and nullable for strict mode", path, name))
+		}
+		converted[name] = s
+		allRequired = append(allRequired, name)
+	}
+	out["properties"] = converted
+	out["required"] = allRequired
+	return out, nil
+}
+
+// nullableSchema allows null in addition to what a schema accepts
+func nullableSchema(schema map[string]interface{}) map[string]interface{} {
+	out := copySchema(schema)
+	switch t := schema["type"].(type) {
+	case string:
+		if t != "null" {
+			out["type"] = []interface{}{t, "null"}
+		}
+		if enum, ok := schema["enum"].([]interface{}); ok {
+			out["enum"] = append(append([]interface{}{}, enum...), nil)
+		}
+	case []interface{}:
+		for _, v := range t {
+			if v == "null" {
+				return out
+			}
+		}
+		out["type"] = append(append([]interface{}{}, t...), "null")
+	default:
+		if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+			out["anyOf"] = append(append([]interface{}{}, anyOf...), map[string]interface{}{"type": "null"})
+		} else {
+			out = map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+		}
+	}
+	return out
+}
+
+func isObjectSchema(schema map[string]interface{}) bool {
+	switch t := schema["type"].(type) {
+	case string:
+		return t == "object"
+	case []interface{}:
+		for _, v := range t {
+			if v == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// definitions returns the schemas $ref can point to
+func definitions(schema map[string]interface{}) map[string]interface{} {
+	defs := map[string]interface{}{}
+	for _, key := range []string{"definitions", "$defs"} {
+		if d, ok := schema[key].(map[string]interface{}); ok {
+			for name, def := range d {
+				defs["#/"+key+"/"+name] = def
+			}
+		}
+	}
+	return defs
+}
+
+func resolveRef(ref string, defs map[string]interface{}) (map[string]interface{}, error) {
+	target, ok := defs[ref].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("only local references to $defs or definitions are supported")
+	}
+	return target, nil
+}
+
+func copySchema(schema map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(schema))
+	for k, v := range schema {
+		out[k] = v
+	}
+	return out
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,285 @@
+// Package toolschema converts tool (function) definitions between the provider formats.
+package toolschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"google.golang.org/genai"
+)
+
+// Tool is the provider-neutral definition of a tool
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters"` // JSON schema of the arguments
+	Strict      bool                   `json:"strict,omitempty"`
+}
+
+// Options controls how tools are rendered
+type Options struct {
+	// Strict rewrites the schemas to satisfy OpenAI strict mode: every object gets
+	// additionalProperties=false and optional properties become required but nullable
+	Strict bool
+}
+
+// Output holds rendered tool definitions and the notes about what had to change on the way
+type Output struct {
+	Tools    []interface{} `json:"tools"`
+	Warnings []string      `json:"warnings,omitempty"`
+}
+
+// FromToolReference builds a tool from a tool registered in a project
+func FromToolReference(ref model.ToolReference) Tool {
+	tool := Tool{Name: ref.Name, Parameters: ref.ArgumentsSchema}
+	if ref.Description != nil {
+		tool.Description = *ref.Description
+	}
+	return tool
+}
+
+// Convert parses tool definitions in one format and renders them in another
+func Convert(from model.MessageFormat, to model.MessageFormat, raw []json.RawMessage, opts Options) (*Output, error) {
+	tools, warnings, err := Parse(from, raw)
+	if err != nil {
+		return nil, err
+	}
+	out, err := Render(tools, to, opts)
+	if err != nil {
+		return nil, err
+	}
+	out.Warnings = append(warnings, out.Warnings...)
+	return out, nil
+}
+
+// Parse reads tool definitions in the given format
+func Parse(format model.MessageFormat, raw []json.RawMessage) ([]Tool, []string, error) {
+	var (
+		tools    []Tool
+		warnings []string
+	)
+	for i, item := range raw {
+		var (
+			parsed []Tool
+			err    error
+		)
+		switch format {
+		case model.FormatAcontext:
+			parsed, err = parseAcontextTool(item)
+		case model.FormatOpenAI:
+			parsed, err = parseOpenAITool(item)
+		case model.FormatAnthropic:
+			parsed, err = parseAnthropicTool(item)
+		case model.FormatGemini:
+			parsed, err = parseGeminiTool(item, fmt.Sprintf("tools[%d]", i), &warnings)
+		case model.FormatBedrock:
+			parsed, err = parseBedrockTool(item, fmt.Sprintf("tools[%d]", i), &warnings)
+		default:
+			return nil, nil, fmt.Errorf("unsupported format: %s", format)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("tools[%d]: %w", i, err)
+		}
+		tools = append(tools, parsed...)
+	}
+
+	for i := range tools {
+		if tools[i].Name == "" {
+			return nil, nil, fmt.Errorf("tools[%d]: name is required", i)
+		}
+	}
+	return tools, warnings, nil
+}
+
+// Render writes tools in the given format
+func Render(tools []Tool, format model.MessageFormat, opts Options) (*Output, error) {
+	out := &Output{Tools: make([]interface{}, 0, len(tools))}
+	var declarations []*genai.FunctionDeclaration
+
+	for _, tool := range tools {
+		params := objectSchema(tool.Parameters)
+		path := tool.Name + ".parameters"
+
+		if tool.Strict && format != model.FormatOpenAI && format != model.FormatAcontext {
+			out.Warnings = append(out.Warnings, fmt.Sprintf("%s: strict mode is only supported by openai", tool.Name))
+		}
+
+		switch format {
+		case model.FormatAcontext:
+			out.Tools = append(out.Tools, acontextTool{Name: tool.Name, Description: tool.Description, ArgumentsSchema: params})
+		case model.FormatOpenAI:
+			strict := tool.Strict || opts.Strict
+			if strict {
+				var err error
+				if params, err = toStrictSchema(params, path, &out.Warnings); err != nil {
+					return nil, fmt.Errorf("%s: %w", tool.Name, err)
+				}
+			}
+			out.Tools = append(out.Tools, openAITool{
+				Type:     "function",
+				Function: openAIFunction{Name: tool.Name, Description: tool.Description, Parameters: params, Strict: strict},
+			})
+		case model.FormatAnthropic:
+			out.Tools = append(out.Tools, anthropicTool{Name: tool.Name, Description: tool.Description, InputSchema: params})
+		case model.FormatGemini:
+			schema, err := toGeminiSchema(params, path, &out.Warnings)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", tool.Name, err)
+			}
+			declarations = append(declarations, &genai.FunctionDeclaration{Name: tool.Name, Description: tool.Description, Parameters: schema})
+		case model.FormatBedrock:
+			out.Tools = append(out.Tools, bedrockTool{ToolSpec: bedrockToolSpec{
+				Name:        tool.Name,
+				Description: tool.Description,
+				InputSchema: bedrockInputSchema{JSON: params},
+			}})
+		default:
+			return nil, fmt.Errorf("unsupported format: %s", format)
+		}
+	}
+
+	// Gemini groups all function declarations in a single tool
+	if len(declarations) > 0 {
+		out.Tools = append(out.Tools, &genai.Tool{FunctionDeclarations: declarations})
+	}
+	return out, nil
+}
+
+type acontextTool struct {
+	Name            string                 `json:"name"`
+	Description     string                 `json:"description,omitempty"`
+	ArgumentsSchema map[string]interface{} `json:"arguments_schema"`
+}
+
+type openAITool struct {
+	Type     string         `json:"type"`
+	Function openAIFunction `json:"function"`
+}
+
+type openAIFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters"`
+	Strict      bool                   `json:"strict,omitempty"`
+}
+
+type anthropicTool struct {
+	Type        string                 `json:"type,omitempty"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type bedrockTool struct {
+	ToolSpec bedrockToolSpec `json:"toolSpec"`
+}
+
+type bedrockToolSpec struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	InputSchema bedrockInputSchema `json:"inputSchema"`
+}
+
+type bedrockInputSchema struct {
+	JSON map[string]interface{} `json:"json"`
+}
+
+func parseAcontextTool(raw json.RawMessage) ([]Tool, error) {
+	var t acontextTool
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return nil, err
+	}
+	return []Tool{{Name: t.Name, Description: t.Description, Parameters: t.ArgumentsSchema}}, nil
+}
+
+// parseOpenAITool accepts both the Chat Completions shape ({"type": "function", "function": {...}})
+// and the flat Responses API shape ({"type": "function", "name": ...})
+func parseOpenAITool(raw json.RawMessage) ([]Tool, error) {
+	var t struct {
+		Type     string          `json:"type"`
+		Function *openAIFunction `json:"function"`
+		openAIFunction
+	}
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return nil, err
+	}
+	if t.Type != "" && t.Type != "function" {
+		return nil, fmt.Errorf("unsupported openai tool type: %s", t.Type)
+	}
+	fn := t.openAIFunction
+	if t.Function != nil {
+		fn = *t.Function
+	}
+	return []Tool{{Name: fn.Name, Description: fn.Description, Parameters: fn.Parameters, Strict: fn.Strict}}, nil
+}
+
+func parseAnthropicTool(raw json.RawMessage) ([]Tool, error) {
+	var t anthropicTool
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return nil, err
+	}
+	// Server and client tools such as bash_20250124 have no schema to convert
+	if t.Type != "" && t.Type != "custom" {
+		return nil, fmt.Errorf("unsupported anthropic tool type: %s", t.Type)
+	}
+	return []Tool{{Name: t.Name, Description: t.Description, Parameters: t.InputSchema}}, nil
+}
+
+// parseGeminiTool accepts a tool holding functionDeclarations or a single function declaration
+func parseGeminiTool(raw json.RawMessage, path string, warnings *[]string) ([]Tool, error) {
+	type declaration struct {
+		Name                 string                 `json:"name"`
+		Description          string                 `json:"description"`
+		Parameters           map[string]interface{} `json:"parameters"`
+		ParametersJSONSchema map[string]interface{} `json:"parametersJsonSchema"`
+	}
+	var t struct {
+		FunctionDeclarations []declaration `json:"functionDeclarations"`
+		declaration
+	}
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return nil, err
+	}
+
+	declarations := t.FunctionDeclarations
+	if t.Name != "" {
+		declarations = append(declarations, t.declaration)
+	}
+	if len(declarations) == 0 {
+		return nil, errors.New("gemini tool has no function declarations (built-in tools cannot be converted)")
+	}
+
+	tools := make([]Tool, 0, len(declarations))
+	for _, d := range declarations {
+		params := d.ParametersJSONSchema
+		if params == nil && d.Parameters != nil {
+			params = fromGeminiSchema(d.Parameters)
+		}
+		if d.Parameters != nil && d.ParametersJSONSchema != nil {
+			*warnings = append(*warnings, fmt.Sprintf("%s.%s: both parameters and parametersJsonSchema are set, using parametersJsonSchema", path, d.Name))
+		}
+		tools = append(tools, Tool{Name: d.Name, Description: d.Description, Parameters: params})
+	}
+	return tools, nil
+}
+
+func parseBedrockTool(raw json.RawMessage, path string, warnings *[]string) ([]Tool, error) {
+	var t struct {
+		ToolSpec   *bedrockToolSpec       `json:"toolSpec"`
+		CachePoint map[string]interface{} `json:"cachePoint"`
+	}
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return nil, err
+	}
+	if t.ToolSpec == nil {
+		if t.CachePoint != nil {
+			*warnings = append(*warnings, fmt.Sprintf("%s: cache points are dropped", path))
+			return nil, nil
+		}
+		return nil, errors.New("bedrock tool has no toolSpec")
+	}
+	spec := t.ToolSpec
+	return []Tool{{Name: spec.Name, Description: spec.Description, Parameters: spec.InputSchema.JSON}}, nil
+}
@@ -0,0 +1,300 @@
+package toolschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+const weatherSchema = `{
+	"type": "object",
+	"properties": {
+		"city": {"type": "string", "description": "City name"},
+		"unit": {"type": ["string", "null"], "enum": ["c", "f"]},
+		"days": {"$ref": "#/$defs/days"}
+	},
+	"required": ["city"],
+	"additionalProperties": false,
+	"$defs": {"days": {"type": "integer", "minimum": 1}}
+}`
+
+func rawTools(t *testing.T, tools ...string) []json.RawMessage {
+	t.Helper()
+	out := make([]json.RawMessage, 0, len(tools))
+	for _, tool := range tools {
+		require.True(t, json.Valid([]byte(tool)), tool)
+		out = append(out, json.RawMessage(tool))
+	}
+	return out
+}
+
+func toJSONMap(t *testing.T, v interface{}) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &out))
+	return out
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format model.MessageFormat
+		tool   string
+		want   []string
+	}{
+		{
+			name:   "openai chat completions",
+			format: model.FormatOpenAI,
+			tool:   `{"type": "function", "function": {"name": "get_weather", "description": "Get weather", "parameters": ` + weatherSchema + `, "strict": true}}`,
+			want:   []string{"get_weather"},
+		},
+		{
+			name:   "openai responses",
+			format: model.FormatOpenAI,
+			tool:   `{"type": "function", "name": "get_weather", "parameters": ` + weatherSchema + `}`,
+			want:   []string{"get_weather"},
+		},
+		{
+			name:   "anthropic",
+			format: model.FormatAnthropic,
+			tool:   `{"name": "get_weather", "description": "Get weather", "input_schema": ` + weatherSchema + `}`,
+			want:   []string{"get_weather"},
+		},
+		{
+			name:   "gemini tool",
+			format: model.FormatGemini,
+			tool:   `{"functionDeclarations": [{"name": "get_weather", "parameters": {"type": "OBJECT", "properties": {"city": {"type": "STRING"}}}}, {"name": "get_time"}]}`,
+			want:   []string{"get_weather", "get_time"},
+		},
+		{
+			name:   "bedrock",
+			format: model.FormatBedrock,
+			tool:   `{"toolSpec": {"name": "get_weather", "inputSchema": {"json": ` + weatherSchema + `}}}`,
+			want:   []string{"get_weather"},
+		},
+		{
+			name:   "acontext",
+			format: model.FormatAcontext,
+			tool:   `{"name": "get_weather", "arguments_schema": ` + weatherSchema + `}`,
+			want:   []string{"get_weather"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tools, _, err := Parse(tt.format, rawTools(t, tt.tool))
+			require.NoError(t, err)
+
+			names := make([]string, 0, len(tools))
+			for _, tool := range tools {
+				names = append(names, tool.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format model.MessageFormat
+		tool   string
+	}{
+		{name: "openai non-function tool", format: model.FormatOpenAI, tool: `{"type": "web_search"}`},
+		{name: "anthropic server tool", format: model.FormatAnthropic, tool: `{"type": "bash_20250124", "name": "bash"}`},
+		{name: "gemini built-in tool", format: model.FormatGemini, tool: `{"googleSearch": {}}`},
+		{name: "bedrock without tool spec", format: model.FormatBedrock, tool: `{}`},
+		{name: "missing name", format: model.FormatAnthropic, tool: `{"input_schema": {"type": "object"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Parse(tt.format, rawTools(t, tt.tool))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParse_GeminiSchemaToJSONSchema(t *testing.T) {
+	tools, _, err := Parse(model.FormatGemini, rawTools(t,
+		`{"name": "f", "parameters": {"type": "OBJECT", "properties": {"note": {"type": "STRING", "nullable": true}}, "propertyOrdering": ["note"]}}`,
+	))
+	require.NoError(t, err)
+	require.Len(t, tools, 1)
+
+	assert.Equal(t, map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"note": map[string]interface{}{"type": []interface{}{"string", "null"}},
+		},
+	}, tools[0].Parameters)
+}
+
+func TestConvert_ToGemini(t *testing.T) {
+	out, err := Convert(model.FormatOpenAI, model.FormatGemini, rawTools(t,
+		`{"type": "function", "function": {"name": "get_weather", "parameters": `+weatherSchema+`}}`,
+		`{"type": "function", "function": {"name": "ping"}}`,
+	), Options{})
+	require.NoError(t, err)
+
+	// All declarations are grouped in one Gemini tool
+	require.Len(t, out.Tools, 1)
+	tool, ok := out.Tools[0].(*genai.Tool)
+	require.True(t, ok)
+	require.Len(t, tool.FunctionDeclarations, 2)
+
+	params := tool.FunctionDeclarations[0].Parameters
+	assert.Equal(t, genai.TypeObject, params.Type)
+	assert.Equal(t, []string{"city"}, params.Required)
+	assert.Equal(t, genai.TypeString, params.Properties["city"].Type)
+	assert.Equal(t, "City name", params.Properties["city"].Description)
+
+	// Type arrays with null become nullable
+	unit := params.Properties["unit"]
+	assert.Equal(t, genai.TypeString, unit.Type)
+	require.NotNil(t, unit.Nullable)
+	assert.True(t, *unit.Nullable)
+	assert.Equal(t, []string{"c", "f"}, unit.Enum)
+
+	// $ref is inlined
+	days := params.Properties["days"]
+	assert.Equal(t, genai.TypeInteger, days.Type)
+	require.NotNil(t, days.Minimum)
+	assert.Equal(t, float64(1), *days.Minimum)
+
+	// Tools without parameters still get an object schema
+	assert.Equal(t, genai.TypeObject, tool.FunctionDeclarations[1].Parameters.Type)
+
+	assert.Contains(t, out.Warnings, "get_weather.parameters: additionalProperties is not supported by gemini and is dropped")
+}
+
+func TestConvert_ToGemini_Keywords(t *testing.T) {
+	out, err := Convert(model.FormatAnthropic, model.FormatGemini, rawTools(t, `{"name": "f", "input_schema": {
+		"type": "object",
+		"properties": {
+			"mode": {"const": "fast"},
+			"level": {"type": "integer", "enum": [1, 2]},
+			"value": {"oneOf": [{"type": "string", "format": "email"}, {"type": "number"}]},
+			"id": {"type": ["string", "integer"]}
+		}
+	}}`), Options{})
+	require.NoError(t, err)
+
+	params := out.Tools[0].(*genai.Tool).FunctionDeclarations[0].Parameters
+	assert.Equal(t, []string{"fast"}, params.Properties["mode"].Enum)
+	assert.Equal(t, genai.TypeString, params.Properties["mode"].Type)
+	assert.Equal(t, []string{"1", "2"}, params.Properties["level"].Enum)
+	assert.Equal(t, genai.TypeString, params.Properties["level"].Type)
+	require.Len(t, params.Properties["value"].AnyOf, 2)
+	assert.Empty(t, params.Properties["value"].AnyOf[0].Format)
+	require.Len(t, params.Properties["id"].AnyOf, 2)
+	assert.Equal(t, genai.TypeInteger, params.Properties["id"].AnyOf[1].Type)
+
+	assert.ElementsMatch(t, []string{
+		"f.parameters.properties.level: non-string enum values are converted to strings for gemini",
+		"f.parameters.properties.value: oneOf is relaxed to anyOf for gemini",
+		"f.parameters.properties.value.oneOf[0]: format email is not supported by gemini and is dropped",
+	}, out.Warnings)
+}
+
+func TestConvert_ToOpenAIStrict(t *testing.T) {
+	out, err := Convert(model.FormatAnthropic, model.FormatOpenAI, rawTools(t, `{"name": "search", "input_schema": {
+		"type": "object",
+		"properties": {
+			"query": {"type": "string"},
+			"limit": {"type": "integer"},
+			"filter": {"type": "object", "properties": {"lang": {"type": "string", "enum": ["en", "de"]}}, "required": ["lang"]}
+		},
+		"required": ["query", "filter"]
+	}}`), Options{Strict: true})
+	require.NoError(t, err)
+	require.Len(t, out.Tools, 1)
+
+	tool := toJSONMap(t, out.Tools[0])
+	function := tool["function"].(map[string]interface{})
+	assert.Equal(t, true, function["strict"])
+
+	params := function["parameters"].(map[string]interface{})
+	assert.Equal(t, false, params["additionalProperties"])
+	assert.Equal(t, []interface{}{"filter", "limit", "query"}, params["required"])
+
+	properties := params["properties"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"integer", "null"}, properties["limit"].(map[string]interface{})["type"])
+	assert.Equal(t, "string", properties["query"].(map[string]interface{})["type"])
+
+	filter := properties["filter"].(map[string]interface{})
+	assert.Equal(t, false, filter["additionalProperties"])
+	assert.Equal(t, []interface{}{"lang"}, filter["required"])
+
+	assert.Equal(t, []string{"search.parameters.properties.limit: optional property is made required and nullable for strict mode"}, out.Warnings)
+}
+
+func TestConvert_ToOpenAIStrict_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{name: "open object", schema: `{"type": "object", "properties": {}, "additionalProperties": true}`},
+		{name: "map of values", schema: `{"type": "object", "additionalProperties": {"type": "string"}}`},
+		{name: "allOf", schema: `{"type": "object", "properties": {"a": {"allOf": [{"type": "string"}, {"minLength": 1}]}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Convert(model.FormatAnthropic, model.FormatOpenAI, rawTools(t, `{"name": "f", "input_schema": `+tt.schema+`}`), Options{Strict: true})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestConvert_RoundTrip(t *testing.T) {
+	formats := []model.MessageFormat{model.FormatAcontext, model.FormatOpenAI, model.FormatAnthropic, model.FormatBedrock}
+	source := rawTools(t, `{"name": "get_weather", "description": "Get weather", "input_schema": `+weatherSchema+`}`)
+
+	want, _, err := Parse(model.FormatAnthropic, source)
+	require.NoError(t, err)
+
+	// Formats that take plain JSON schema keep the definition unchanged
+	for _, format := range formats {
+		t.Run(string(format), func(t *testing.T) {
+			out, err := Convert(model.FormatAnthropic, format, source, Options{})
+			require.NoError(t, err)
+			assert.Empty(t, out.Warnings)
+
+			data, err := json.Marshal(out.Tools)
+			require.NoError(t, err)
+			var raw []json.RawMessage
+			require.NoError(t, json.Unmarshal(data, &raw))
+
+			got, _, err := Parse(format, raw)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestRender_StrictWarningForOtherFormats(t *testing.T) {
+	out, err := Render([]Tool{{Name: "f", Strict: true}}, model.FormatAnthropic, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"f: strict mode is only supported by openai"}, out.Warnings)
+}
+
+func TestFromToolReference(t *testing.T) {
+	description := "Get weather"
+	tool := FromToolReference(model.ToolReference{
+		Name:            "get_weather",
+		Description:     &description,
+		ArgumentsSchema: map[string]interface{}{"type": "object"},
+	})
+	assert.Equal(t, Tool{Name: "get_weather", Description: description, Parameters: map[string]interface{}{"type": "object"}}, tool)
+
+	tool = FromToolReference(model.ToolReference{Name: "ping"})
+	assert.Empty(t, tool.Description)
+	assert.Nil(t, tool.Parameters)
+}
@@ -0,0 +1,511 @@
+package fileparser
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Document parsers extract the text of binary documents. Pages, slides and sheets are separated
+// by marker lines such as "--- Page 2 ---", so that line-range reads and grep results can be
+// placed in the document.
+
+// maxDocumentText bounds the text extracted from a document, protecting against zip bombs
+const maxDocumentText = 64 << 20
+
+var errDocumentTooLarge = errors.New("document text is too large")
+
+const (
+	mimePDF  = "application/pdf"
+	mimeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	mimePPTX = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	mimeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+func isDocument(filename string, mimeType string, ext string, docMime string) bool {
+	return strings.ToLower(filepath.Ext(filename)) == ext || strings.HasPrefix(mimeType, docMime)
+}
+
+// textBuilder collects extracted text up to maxDocumentText
+type textBuilder struct {
+	b strings.Builder
+}
+
+func (t *textBuilder) WriteString(s string) error {
+	if t.b.Len()+len(s) > maxDocumentText {
+		return errDocumentTooLarge
+	}
+	t.b.WriteString(s)
+	return nil
+}
+
+// marker writes a separator line, preceded by a blank line unless it starts the text
+func (t *textBuilder) marker(format string, args ...interface{}) error {
+	if t.b.Len() > 0 {
+		if err := t.WriteString("\n"); err != nil {
+			return err
+		}
+	}
+	return t.WriteString(fmt.Sprintf("--- "+format+" ---\n", args...))
+}
+
+// String returns the text, valid UTF-8 without NUL bytes so that it can be stored in Postgres
+func (t *textBuilder) String() string {
+	return strings.ReplaceAll(strings.ToValidUTF8(t.b.String(), ""), "\x00", "")
+}
+
+// PDFParser extracts the text of PDF files, page by page
+type PDFParser struct{}
+
+func (p *PDFParser) CanParse(filename string, mimeType string) bool {
+	return isDocument(filename, mimeType, ".pdf", mimePDF)
+}
+
+func (p *PDFParser) Parse(content []byte) (fc *FileContent, err error) {
+	// The PDF reader panics on some malformed files
+	defer func() {
+		if r := recover(); r != nil {
+			fc, err = nil, fmt.Errorf("failed to parse PDF: %v", r)
+		}
+	}()
+
+	r, err := pdf.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PDF: %w", err)
+	}
+
+	var text textBuilder
+	fonts := make(map[string]*pdf.Font)
+	for i := 1; i <= r.NumPage(); i++ {
+		page := r.Page(i)
+		for _, name := range page.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				f := page.Font(name)
+				fonts[name] = &f
+			}
+		}
+		pageText, err := page.GetPlainText(fonts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PDF page %d: %w", i, err)
+		}
+
+		if err := text.marker("Page %d", i); err != nil {
+			return nil, err
+		}
+		if err := text.WriteString(cleanLines(pageText)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &FileContent{Type: "pdf", Raw: text.String()}, nil
+}
+
+// cleanLines trims trailing spaces and drops blank lines, ending each line with a newline
+func cleanLines(s string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			continue
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// DOCXParser extracts the text of Word documents, one paragraph per line
+type DOCXParser struct{}
+
+func (p *DOCXParser) CanParse(filename string, mimeType string) bool {
+	return isDocument(filename, mimeType, ".docx", mimeDOCX)
+}
+
+func (p *DOCXParser) Parse(content []byte) (*FileContent, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DOCX: %w", err)
+	}
+	f := zipEntry(zr, "word/document.xml")
+	if f == nil {
+		return nil, errors.New("failed to parse DOCX: word/document.xml not found")
+	}
+
+	var text textBuilder
+	if err := extractParagraphs(f, &text); err != nil {
+		return nil, fmt.Errorf("failed to parse DOCX: %w", err)
+	}
+	return &FileContent{Type: "docx", Raw: text.String()}, nil
+}
+
+// PPTXParser extracts the text of PowerPoint presentations, slide by slide
+type PPTXParser struct{}
+
+func (p *PPTXParser) CanParse(filename string, mimeType string) bool {
+	return isDocument(filename, mimeType, ".pptx", mimePPTX)
+}
+
+func (p *PPTXParser) Parse(content []byte) (*FileContent, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PPTX: %w", err)
+	}
+
+	// Slides are named slide1.xml, slide2.xml and so on, in presentation order
+	type slide struct {
+		num  int
+		file *zip.File
+	}
+	var slides []slide
+	for _, f := range zr.File {
+		name := strings.TrimPrefix(f.Name, "ppt/slides/slide")
+		if name == f.Name || strings.Contains(name, "/") || !strings.HasSuffix(name, ".xml") {
+			continue
+		}
+		if num, err := strconv.Atoi(strings.TrimSuffix(name, ".xml")); err == nil {
+			slides = append(slides, slide{num: num, file: f})
+		}
+	}
+	sort.Slice(slides, func(i, j int) bool { return slides[i].num < slides[j].num })
+
+	var text textBuilder
+	for _, s := range slides {
+		if err := text.marker("Slide %d", s.num); err != nil {
+			return nil, err
+		}
+		if err := extractParagraphs(s.file, &text); err != nil {
+			return nil, fmt.Errorf("failed to parse PPTX slide %d: %w", s.num, err)
+		}
+	}
+	return &FileContent{Type: "pptx", Raw: text.String()}, nil
+}
+
+// extractParagraphs writes the text runs of a WordprocessingML or DrawingML part, one paragraph
+// per line. Empty paragraphs are skipped.
+func extractParagraphs(f *zip.File, text *textBuilder) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	var (
+		para   strings.Builder
+		inText bool
+	)
+	d := xml.NewDecoder(rc)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				para.WriteByte('\t')
+			case "br", "cr":
+				para.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				if line := strings.TrimRight(para.String(), " \t\n"); line != "" {
+					if err := text.WriteString(line + "\n"); err != nil {
+						return err
+					}
+				}
+				para.Reset()
+			}
+		case xml.CharData:
+			if inText {
+				if para.Len()+len(t) > maxDocumentText {
+					return errDocumentTooLarge
+				}
+				para.Write(t)
+			}
+		}
+	}
+}
+
+// XLSXParser extracts the cells of Excel workbooks, sheet by sheet, one row per line with
+// tab separated cells
+type XLSXParser struct{}
+
+func (p *XLSXParser) CanParse(filename string, mimeType string) bool {
+	return isDocument(filename, mimeType, ".xlsx", mimeXLSX)
+}
+
+func (p *XLSXParser) Parse(content []byte) (*FileContent, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XLSX: %w", err)
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeZipXML(zr, "xl/workbook.xml", &workbook); err != nil {
+		return nil, fmt.Errorf("failed to parse XLSX: %w", err)
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeZipXML(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, fmt.Errorf("failed to parse XLSX: %w", err)
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, r := range rels.Relationships {
+		// Targets are relative to xl/, or absolute within the package
+		if strings.HasPrefix(r.Target, "/") {
+			targets[r.ID] = strings.TrimPrefix(r.Target, "/")
+		} else {
+			targets[r.ID] = path.Join("xl", r.Target)
+		}
+	}
+
+	shared, err := readSharedStrings(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XLSX: %w", err)
+	}
+
+	var text textBuilder
+	for _, sheet := range workbook.Sheets {
+		f := zipEntry(zr, targets[sheet.RID])
+		if f == nil {
+			continue
+		}
+		if err := text.marker("Sheet: %s", sheet.Name); err != nil {
+			return nil, err
+		}
+		if err := extractRows(f, shared, &text); err != nil {
+			return nil, fmt.Errorf("failed to parse XLSX sheet %q: %w", sheet.Name, err)
+		}
+	}
+	return &FileContent{Type: "xlsx", Raw: text.String()}, nil
+}
+
+// readSharedStrings returns the shared string table of a workbook, which cells of type s index
+func readSharedStrings(zr *zip.Reader) ([]string, error) {
+	f := zipEntry(zr, "xl/sharedStrings.xml")
+	if f == nil {
+		return nil, nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var (
+		strs   []string
+		cur    strings.Builder
+		inText bool
+		size   int
+	)
+	d := xml.NewDecoder(rc)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return strs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				cur.Reset()
+			case "t":
+				inText = true
+			case "rPh":
+				// Phonetic hints repeat the text of East Asian strings
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "si":
+				strs = append(strs, cur.String())
+			}
+		case xml.CharData:
+			if inText {
+				if size += len(t); size > maxDocumentText {
+					return nil, errDocumentTooLarge
+				}
+				cur.Write(t)
+			}
+		}
+	}
+}
+
+// extractRows writes the rows of a worksheet. Cells are placed in their columns, so that empty
+// cells keep the columns of a row aligned.
+func extractRows(f *zip.File, shared []string, text *textBuilder) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	var (
+		row      []string
+		cellType string
+		col      int
+		value    strings.Builder
+		inValue  bool
+	)
+	d := xml.NewDecoder(rc)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				row = row[:0]
+			case "c":
+				cellType, col = "", len(row)
+				for _, a := range t.Attr {
+					switch a.Name.Local {
+					case "t":
+						cellType = a.Value
+					case "r":
+						if c, ok := columnIndex(a.Value); ok && c >= len(row) {
+							col = c
+						}
+					}
+				}
+				value.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				v := value.String()
+				switch cellType {
+				case "s":
+					if i, err := strconv.Atoi(v); err == nil && i >= 0 && i < len(shared) {
+						v = shared[i]
+					}
+				case "b":
+					v = map[string]string{"0": "FALSE", "1": "TRUE"}[v]
+				}
+				if v == "" || col > 16384 {
+					continue
+				}
+				for len(row) < col {
+					row = append(row, "")
+				}
+				row = append(row, strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ").Replace(v))
+			case "row":
+				if len(row) > 0 {
+					if err := text.WriteString(strings.Join(row, "\t") + "\n"); err != nil {
+						return err
+					}
+				}
+			}
+		case xml.CharData:
+			if inValue {
+				if value.Len()+len(t) > maxDocumentText {
+					return errDocumentTooLarge
+				}
+				value.Write(t)
+			}
+		}
+	}
+}
+
+// columnIndex returns the 0-based column of a cell reference such as B7
+func columnIndex(ref string) (int, bool) {
+	col := 0
+	for i, r := range ref {
+		if r >= 'A' && r <= 'Z' {
+			col = col*26 + int(r-'A'+1)
+			continue
+		}
+		return col - 1, i > 0
+	}
+	return 0, false
+}
+
+func zipEntry(zr *zip.Reader, name string) *zip.File {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func decodeZipXML(zr *zip.Reader, name string, v interface{}) error {
+	f := zipEntry(zr, name)
+	if f == nil {
+		return fmt.Errorf("%s not found", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(io.LimitReader(rc, maxDocumentText)).Decode(v)
+}
+
+// DocumentType returns the content type of a document whose text is extracted, such as pdf,
+// or "" for files whose content is text itself
+func (fp *FileParser) DocumentType(filename string, mimeType string) string {
+	for _, parser := range fp.parsers {
+		if !parser.CanParse(filename, mimeType) {
+			continue
+		}
+		switch parser.(type) {
+		case *PDFParser:
+			return "pdf"
+		case *DOCXParser:
+			return "docx"
+		case *PPTXParser:
+			return "pptx"
+		case *XLSXParser:
+			return "xlsx"
+		}
+		return ""
+	}
+	return ""
+}
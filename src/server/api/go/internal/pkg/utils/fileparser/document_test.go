@@ -0,0 +1,153 @@
+package fileparser
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildPDF writes a PDF with one page per text, using a standard font
+func buildPDF(pages ...string) []byte {
+	var objs []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objs = append(objs,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	)
+	for i, text := range pages {
+		var stream strings.Builder
+		for j, line := range strings.Split(text, "\n") {
+			fmt.Fprintf(&stream, "BT /F1 12 Tf 72 %d Td (%s) Tj ET\n", 720-14*j, line)
+		}
+		objs = append(objs,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R /Resources << /Font << /F1 3 0 R >> >> >>", 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", stream.Len(), stream.String()),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, obj := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return buf.Bytes()
+}
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestPDFParser(t *testing.T) {
+	parser := NewFileParser()
+	content, err := parser.ParseFile("report.pdf", "application/pdf", buildPDF("Quarterly report\nRevenue grew", "Appendix"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "pdf", content.Type)
+	assert.Equal(t, "--- Page 1 ---\nQuarterly report\nRevenue grew\n\n--- Page 2 ---\nAppendix\n", content.Raw)
+	assert.Equal(t, 6, content.TotalLines)
+
+	_, err = parser.ParseFile("broken.pdf", "application/pdf", []byte("%PDF-1.4 not really"))
+	assert.Error(t, err)
+}
+
+func TestDOCXParser(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Project </w:t></w:r><w:r><w:t>plan</w:t></w:r></w:p>
+<w:p></w:p>
+<w:p><w:r><w:t>Step</w:t><w:tab/><w:t>Owner</w:t></w:r></w:p>
+<w:p><w:r><w:delText>removed</w:delText><w:t>Ship &amp; review</w:t></w:r></w:p>
+</w:body></w:document>`
+	data := buildZip(t, map[string]string{"word/document.xml": doc, "[Content_Types].xml": "<Types/>"})
+
+	content, err := NewFileParser().ParseFile("plan.docx", "", data)
+	require.NoError(t, err)
+	assert.Equal(t, "docx", content.Type)
+	assert.Equal(t, "Project plan\nStep\tOwner\nShip & review\n", content.Raw)
+
+	_, err = NewFileParser().ParseFile("empty.docx", "", buildZip(t, map[string]string{"a.txt": "x"}))
+	assert.Error(t, err)
+}
+
+func TestPPTXParser(t *testing.T) {
+	slide := func(texts ...string) string {
+		var b strings.Builder
+		b.WriteString(`<p:sld xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"><p:cSld><p:spTree>`)
+		for _, text := range texts {
+			fmt.Fprintf(&b, `<p:sp><p:txBody><a:p><a:r><a:t>%s</a:t></a:r></a:p></p:txBody></p:sp>`, text)
+		}
+		b.WriteString(`</p:spTree></p:cSld></p:sld>`)
+		return b.String()
+	}
+	data := buildZip(t, map[string]string{
+		"ppt/slides/slide1.xml":            slide("Roadmap", "2025"),
+		"ppt/slides/slide2.xml":            slide("Q1"),
+		"ppt/slides/slide10.xml":           slide("Thanks"),
+		"ppt/slides/_rels/slide1.xml.rels": "<Relationships/>",
+	})
+
+	content, err := NewFileParser().ParseFile("deck.pptx", mimePPTX, data)
+	require.NoError(t, err)
+	assert.Equal(t, "pptx", content.Type)
+	assert.Equal(t, "--- Slide 1 ---\nRoadmap\n2025\n\n--- Slide 2 ---\nQ1\n\n--- Slide 10 ---\nThanks\n", content.Raw)
+}
+
+func TestXLSXParser(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Sales" sheetId="1" r:id="rId1"/><sheet name="Notes" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>Region</t></si><si><t>Total</t></si><si><r><t>No</t></r><r><t>rth</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="C2"><v>1250.5</v></c></row>
+<row r="3"><c r="B3" t="b"><v>1</v></c></row>
+</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="inlineStr"><is><t>Check Q3</t></is></c></row>
+</sheetData></worksheet>`,
+	})
+
+	content, err := NewFileParser().ParseFile("sales.xlsx", "application/octet-stream", data)
+	require.NoError(t, err)
+	assert.Equal(t, "xlsx", content.Type)
+	assert.Equal(t, "--- Sheet: Sales ---\nRegion\tTotal\nNorth\t\t1250.5\n\tTRUE\n\n--- Sheet: Notes ---\nCheck Q3\n", content.Raw)
+}
+
+func TestDocumentType(t *testing.T) {
+	parser := NewFileParser()
+	assert.Equal(t, "pdf", parser.DocumentType("a.PDF", ""))
+	assert.Equal(t, "docx", parser.DocumentType("upload", mimeDOCX))
+	assert.Equal(t, "xlsx", parser.DocumentType("a.xlsx", ""))
+	assert.Equal(t, "", parser.DocumentType("a.md", "text/markdown"))
+	assert.Equal(t, "", parser.DocumentType("a.png", "image/png"))
+	assert.True(t, parser.CanParseFile("a.pptx", ""))
+}
@@ -13,8 +13,12 @@ import (
 
 // FileContent represents the parsed content of a file
 type FileContent struct {
-	Type string `json:"type"` // "text", "json", "csv", "code"
-	Raw  string `json:"raw"`  // Raw text content
+	Type       string `json:"type"`                 // "text", "json", "csv", "code", or "pdf", "docx", "pptx", "xlsx" for extracted text
+	Raw        string `json:"raw"`                  // Raw text content
+	TotalLines int    `json:"total_lines"`          // Number of lines in the whole file
+	Offset     int    `json:"offset,omitempty"`     // Lines skipped before Raw when a line window is read
+	LineCount  int    `json:"line_count,omitempty"` // Number of lines in Raw when a line window is read
+	HasMore    bool   `json:"has_more,omitempty"`   // Whether lines remain after the window
 }
 
 // Parser interface for different file types
@@ -159,6 +163,10 @@ func NewFileParser() *FileParser {
 		parsers: []Parser{
 			&JSONParser{},
 			&CSVParser{},
+			&PDFParser{},
+			&DOCXParser{},
+			&PPTXParser{},
+			&XLSXParser{},
 			&CodeParser{},
 			&TextParser{}, // Text parser should be last as it's the fallback
 		},
@@ -180,7 +188,12 @@ func (fp *FileParser) ParseFile(filename string, mimeType string, content []byte
 	// Try each parser in order
 	for _, parser := range fp.parsers {
 		if parser.CanParse(filename, mimeType) {
-			return parser.Parse(content)
+			fileContent, err := parser.Parse(content)
+			if err != nil {
+				return nil, err
+			}
+			fileContent.TotalLines = CountLines(fileContent.Raw)
+			return fileContent, nil
 		}
 	}
 
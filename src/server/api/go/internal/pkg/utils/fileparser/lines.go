@@ -0,0 +1,70 @@
+package fileparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LineWindow selects a range of lines from parsed content
+type LineWindow struct {
+	Offset      int  // Number of lines to skip from the start
+	Limit       int  // Maximum number of lines to return, 0 means all remaining lines
+	LineNumbers bool // Prefix each line with its 1-based line number and a tab
+}
+
+// IsZero reports whether the window returns the content unchanged
+func (w LineWindow) IsZero() bool {
+	return w.Offset == 0 && w.Limit == 0 && !w.LineNumbers
+}
+
+// CountLines counts the lines of a text. A trailing newline does not start a new line.
+func CountLines(text string) int {
+	if text == "" {
+		return 0
+	}
+	n := strings.Count(text, "\n")
+	if !strings.HasSuffix(text, "\n") {
+		n++
+	}
+	return n
+}
+
+// splitLines splits a text into lines, keeping the line endings
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Window returns a copy of the content holding only the lines selected by w
+func (c *FileContent) Window(w LineWindow) *FileContent {
+	lines := splitLines(c.Raw)
+	out := &FileContent{Type: c.Type, TotalLines: len(lines), Offset: w.Offset}
+
+	start := min(max(w.Offset, 0), len(lines))
+	end := len(lines)
+	if w.Limit > 0 {
+		end = min(start+w.Limit, len(lines))
+	}
+	selected := lines[start:end]
+
+	var b strings.Builder
+	// Pad line numbers to the widest one in the window so the text stays aligned
+	width := len(fmt.Sprint(end))
+	for i, line := range selected {
+		if w.LineNumbers {
+			fmt.Fprintf(&b, "%*d\t", width, start+i+1)
+		}
+		b.WriteString(line)
+	}
+
+	out.Raw = b.String()
+	out.LineCount = len(selected)
+	out.HasMore = end < len(lines)
+	return out
+}
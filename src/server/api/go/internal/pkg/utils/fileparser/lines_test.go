@@ -0,0 +1,116 @@
+package fileparser
+
+import (
+	"testing"
+)
+
+func TestCountLines(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"", 0},
+		{"one", 1},
+		{"one\n", 1},
+		{"one\ntwo", 2},
+		{"one\ntwo\n", 2},
+		{"\n\n", 2},
+		{"one\r\ntwo\r\n", 2},
+	}
+
+	for _, tt := range tests {
+		if got := CountLines(tt.text); got != tt.expected {
+			t.Errorf("CountLines(%q) = %d, want %d", tt.text, got, tt.expected)
+		}
+	}
+}
+
+func TestFileContentWindow(t *testing.T) {
+	content := &FileContent{Type: "code", Raw: "l1\nl2\nl3\nl4\nl5\nl6\nl7\nl8\nl9\nl10\nl11\n"}
+
+	tests := []struct {
+		name      string
+		window    LineWindow
+		raw       string
+		lineCount int
+		hasMore   bool
+	}{
+		{
+			name:      "first lines",
+			window:    LineWindow{Limit: 2},
+			raw:       "l1\nl2\n",
+			lineCount: 2,
+			hasMore:   true,
+		},
+		{
+			name:      "middle lines",
+			window:    LineWindow{Offset: 4, Limit: 3},
+			raw:       "l5\nl6\nl7\n",
+			lineCount: 3,
+			hasMore:   true,
+		},
+		{
+			name:      "limit past the end",
+			window:    LineWindow{Offset: 9, Limit: 10},
+			raw:       "l10\nl11\n",
+			lineCount: 2,
+		},
+		{
+			name:   "offset past the end",
+			window: LineWindow{Offset: 20},
+			raw:    "",
+		},
+		{
+			name:      "all lines from offset",
+			window:    LineWindow{Offset: 10},
+			raw:       "l11\n",
+			lineCount: 1,
+		},
+		{
+			name:      "line numbers are padded to the widest number",
+			window:    LineWindow{Offset: 8, Limit: 2, LineNumbers: true},
+			raw:       " 9\tl9\n10\tl10\n",
+			lineCount: 2,
+			hasMore:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := content.Window(tt.window)
+
+			if got.Raw != tt.raw {
+				t.Errorf("Window() raw = %q, want %q", got.Raw, tt.raw)
+			}
+			if got.TotalLines != 11 {
+				t.Errorf("Window() total lines = %d, want 11", got.TotalLines)
+			}
+			if got.LineCount != tt.lineCount {
+				t.Errorf("Window() line count = %d, want %d", got.LineCount, tt.lineCount)
+			}
+			if got.HasMore != tt.hasMore {
+				t.Errorf("Window() has more = %v, want %v", got.HasMore, tt.hasMore)
+			}
+			if got.Type != "code" {
+				t.Errorf("Window() type = %v, want code", got.Type)
+			}
+		})
+	}
+
+	// The original content is left untouched
+	if content.Offset != 0 || content.LineCount != 0 {
+		t.Error("Window() should not modify the original content")
+	}
+}
+
+func TestFileContentWindow_NoTrailingNewline(t *testing.T) {
+	content := &FileContent{Type: "csv", Raw: "name,age\nJohn,25\nJane,30"}
+
+	got := content.Window(LineWindow{Offset: 1, LineNumbers: true})
+	if got.Raw != "2\tJohn,25\n3\tJane,30" {
+		t.Errorf("Window() raw = %q", got.Raw)
+	}
+	if got.TotalLines != 3 || got.LineCount != 2 || got.HasMore {
+		t.Errorf("Window() = %+v", got)
+	}
+}
@@ -1,6 +1,8 @@
 package mime
 
 import (
+	"bytes"
+	"io"
 	"path/filepath"
 	"strings"
 
@@ -62,3 +64,18 @@ func DetectMimeType(content []byte, filename string) string {
 	}
 	return contentType
 }
+
+// sniffLen is how much content DetectMimeTypeReader reads, the same as the mimetype library
+const sniffLen = 3072
+
+// DetectMimeTypeReader detects the MIME type like DetectMimeType from the first bytes of r.
+// It returns a reader of the whole content, including the bytes read for detection.
+func DetectMimeTypeReader(r io.Reader, filename string) (string, io.Reader, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, err
+	}
+	head = head[:n]
+	return DetectMimeType(head, filename), io.MultiReader(bytes.NewReader(head), r), nil
+}
@@ -0,0 +1,185 @@
+package path
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidGlob = errors.New("glob pattern is invalid")
+
+// Glob is a compiled glob pattern matched against full file paths such as /docs/report.md.
+//
+//   - * and ? match within one path segment
+//   - ** matches across directories; /**/ also matches a single /
+//   - [abc], [a-z] and [!abc] match one character of a segment
+//   - {a,b} matches either alternative, alternatives may contain other globs
+//   - \ escapes the next character
+//
+// Patterns without a leading / are relative to the root directory.
+type Glob struct {
+	pattern  string
+	regex    string
+	re       *regexp.Regexp
+	dir      string
+	exactDir bool
+}
+
+// CompileGlob parses a glob pattern
+func CompileGlob(pattern string) (*Glob, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, ErrInvalidGlob
+	}
+	if !strings.HasPrefix(pattern, "/") {
+		pattern = "/" + pattern
+	}
+
+	regex, err := globToRegex(pattern)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(regex)
+	if err != nil {
+		return nil, ErrInvalidGlob
+	}
+
+	// The literal part of the pattern up to its last / is a directory every match lives under
+	literal := pattern
+	if i := strings.IndexAny(pattern, `*?[{\`); i >= 0 {
+		literal = pattern[:i]
+	}
+	dir := literal[:strings.LastIndex(literal, "/")+1]
+	rest := pattern[len(dir):]
+
+	return &Glob{
+		pattern:  pattern,
+		regex:    regex,
+		re:       re,
+		dir:      dir,
+		exactDir: !strings.Contains(rest, "/") && !strings.Contains(rest, "**"),
+	}, nil
+}
+
+// String returns the normalized pattern
+func (g *Glob) String() string { return g.pattern }
+
+// Regex returns the anchored regular expression of the pattern. It only uses syntax shared
+// by Go and PostgreSQL regular expressions, so it can be matched in SQL with ~.
+func (g *Glob) Regex() string { return g.regex }
+
+// Dir returns the directory, ending with /, that every match lives in or below
+func (g *Glob) Dir() string { return g.dir }
+
+// ExactDir reports whether every match lives directly in Dir
+func (g *Glob) ExactDir() bool { return g.exactDir }
+
+// Match reports whether a full file path matches the pattern
+func (g *Glob) Match(filePath string) bool {
+	return g.re.MatchString(filePath)
+}
+
+// MatchDir reports whether a directory path matches the pattern. The path may end with /.
+func (g *Glob) MatchDir(dirPath string) bool {
+	dirPath = strings.TrimSuffix(dirPath, "/")
+	return dirPath != "" && g.re.MatchString(dirPath)
+}
+
+func globToRegex(pattern string) (string, error) {
+	var (
+		b     strings.Builder
+		depth int // Open {} groups
+	)
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '\\':
+			if i+1 == len(pattern) {
+				return "", ErrInvalidGlob
+			}
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				// /**/ matches any number of directories, including none
+				if pattern[i-2] == '/' && i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					b.WriteString("(?:[^/]+/)*")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			class, n, ok := globClass(pattern[i:])
+			if !ok {
+				b.WriteString(`\[`)
+				continue
+			}
+			b.WriteString(class)
+			i += n - 1
+		case '{':
+			depth++
+			b.WriteString("(?:")
+		case ',':
+			if depth > 0 {
+				b.WriteString("|")
+			} else {
+				b.WriteString(",")
+			}
+		case '}':
+			if depth > 0 {
+				depth--
+				b.WriteString(")")
+			} else {
+				b.WriteString(`\}`)
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	if depth > 0 {
+		return "", ErrInvalidGlob
+	}
+	b.WriteString("$")
+	return b.String(), nil
+}
+
+// globClass translates the character class at the start of s. It returns the regex class,
+// the length of the glob class and whether s starts with a complete class.
+func globClass(s string) (string, int, bool) {
+	i := 1
+	negate := false
+	if i < len(s) && (s[i] == '!' || s[i] == '^') {
+		negate = true
+		i++
+	}
+
+	var b strings.Builder
+	b.WriteString("[")
+	if negate {
+		// A negated class still stays inside one path segment
+		b.WriteString("^/")
+	}
+	for first := true; i < len(s); i, first = i+1, false {
+		c := s[i]
+		if c == ']' && !first {
+			b.WriteString("]")
+			return b.String(), i + 1, true
+		}
+		switch c {
+		case '\\', '[', ']', '^':
+			b.WriteByte('\\')
+		case '/':
+			// Classes never match the separator
+			return "", 0, false
+		}
+		b.WriteByte(c)
+	}
+	return "", 0, false
+}
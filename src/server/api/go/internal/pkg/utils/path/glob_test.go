@@ -0,0 +1,145 @@
+package path
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileGlob_Match(t *testing.T) {
+	tests := []struct {
+		pattern string
+		match   []string
+		noMatch []string
+	}{
+		{
+			pattern: "*.py",
+			match:   []string{"/main.py", "/.py"},
+			noMatch: []string{"/src/main.py", "/main.pyc"},
+		},
+		{
+			pattern: "/src/*.py",
+			match:   []string{"/src/main.py"},
+			noMatch: []string{"/src/pkg/main.py", "/main.py"},
+		},
+		{
+			pattern: "**/*.py",
+			match:   []string{"/main.py", "/src/main.py", "/src/pkg/deep/main.py"},
+			noMatch: []string{"/src/main.go"},
+		},
+		{
+			pattern: "/src/**/test_*.py",
+			match:   []string{"/src/test_a.py", "/src/pkg/test_a.py"},
+			noMatch: []string{"/test_a.py", "/srcx/test_a.py"},
+		},
+		{
+			pattern: "/docs/**",
+			match:   []string{"/docs/a.md", "/docs/x/y/b.md"},
+			noMatch: []string{"/docs", "/doc/a.md"},
+		},
+		{
+			pattern: "/file?.txt",
+			match:   []string{"/file1.txt", "/fileA.txt"},
+			noMatch: []string{"/file10.txt", "/file.txt"},
+		},
+		{
+			pattern: "/*.{md,txt}",
+			match:   []string{"/a.md", "/b.txt"},
+			noMatch: []string{"/c.mdx", "/d.go"},
+		},
+		{
+			pattern: "/{src,test}/**/*.go",
+			match:   []string{"/src/a.go", "/test/x/b.go"},
+			noMatch: []string{"/lib/a.go"},
+		},
+		{
+			pattern: "/log[0-9].txt",
+			match:   []string{"/log1.txt"},
+			noMatch: []string{"/logx.txt", "/log10.txt"},
+		},
+		{
+			pattern: "/[!a]*.txt",
+			match:   []string{"/b.txt"},
+			noMatch: []string{"/a.txt"},
+		},
+		{
+			// Characters that are wildcards in SQL LIKE or regex are literal
+			pattern: "/100%_done (v1.0)+.txt",
+			match:   []string{"/100%_done (v1.0)+.txt"},
+			noMatch: []string{"/100x_done (v1.0)+.txt", "/100%xdone (v1.0)+.txt", "/100%_done (v1x0)+.txt"},
+		},
+		{
+			pattern: `/\*.txt`,
+			match:   []string{"/*.txt"},
+			noMatch: []string{"/a.txt"},
+		},
+		{
+			pattern: "/a[b",
+			match:   []string{"/a[b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			g, err := CompileGlob(tt.pattern)
+			require.NoError(t, err)
+			for _, p := range tt.match {
+				assert.True(t, g.Match(p), "%s should match %s", tt.pattern, p)
+			}
+			for _, p := range tt.noMatch {
+				assert.False(t, g.Match(p), "%s should not match %s", tt.pattern, p)
+			}
+		})
+	}
+}
+
+func TestCompileGlob_Dir(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		dir      string
+		exactDir bool
+	}{
+		{pattern: "*.py", dir: "/", exactDir: true},
+		{pattern: "/src/*.py", dir: "/src/", exactDir: true},
+		{pattern: "/src/main.py", dir: "/src/", exactDir: true},
+		{pattern: "/src/**/*.py", dir: "/src/", exactDir: false},
+		{pattern: "/src/pkg*/*.py", dir: "/src/", exactDir: false},
+		{pattern: "/src/{a,b/c}.py", dir: "/src/", exactDir: false},
+		{pattern: "/src/**", dir: "/src/", exactDir: false},
+		{pattern: "**/*.py", dir: "/", exactDir: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			g, err := CompileGlob(tt.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tt.dir, g.Dir())
+			assert.Equal(t, tt.exactDir, g.ExactDir())
+		})
+	}
+}
+
+func TestCompileGlob_MatchDir(t *testing.T) {
+	g, err := CompileGlob("/src/*")
+	require.NoError(t, err)
+
+	assert.True(t, g.MatchDir("/src/pkg/"))
+	assert.True(t, g.MatchDir("/src/pkg"))
+	assert.False(t, g.MatchDir("/src/"))
+	assert.False(t, g.MatchDir("/src/pkg/sub/"))
+	assert.False(t, g.MatchDir("/"))
+}
+
+func TestCompileGlob_Invalid(t *testing.T) {
+	for _, pattern := range []string{"", "  ", "/{a,b", `/a\`} {
+		_, err := CompileGlob(pattern)
+		assert.ErrorIs(t, err, ErrInvalidGlob, pattern)
+	}
+}
+
+func TestCompileGlob_Regex(t *testing.T) {
+	g, err := CompileGlob("/src/**/*.{go,py}")
+	require.NoError(t, err)
+	assert.Equal(t, `^/src/(?:[^/]+/)*[^/]*\.(?:go|py)$`, g.Regex())
+}
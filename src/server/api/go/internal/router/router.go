@@ -30,6 +30,10 @@ type RouterDeps struct {
 	AgentSkillsHandler *handler.AgentSkillsHandler
 	UserHandler        *handler.UserHandler
 	SandboxHandler     *handler.SandboxHandler
+	StorageHandler     *handler.StorageHandler
+	ShareHandler       *handler.ShareHandler
+	AdminHandler       *handler.AdminHandler
+	BlobHandler        *handler.BlobHandler
 }
 
 func NewRouter(d RouterDeps) *gin.Engine {
@@ -57,6 +61,28 @@ func NewRouter(d RouterDeps) *gin.Engine {
 	})
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// share links carry their own token, so they are served outside ProjectAuth
+	share := r.Group("/api/v1/share")
+	{
+		share.GET("/:token", d.ShareHandler.BrowseShare)
+		share.GET("/:token/file", d.ShareHandler.DownloadShare)
+	}
+
+	// presigned URLs of the local blob store carry their own signature
+	blobs := r.Group("/api/v1/blob")
+	{
+		blobs.GET("/*key", d.BlobHandler.GetBlob)
+		blobs.PUT("/*key", d.BlobHandler.PutBlob)
+	}
+
+	// admin routes act on every project and take the root API token
+	admin := r.Group("/api/v1/admin")
+	{
+		admin.Use(middleware.RootAuth(d.Config))
+
+		admin.GET("/gc/assets", d.AdminHandler.AssetGCDryRun)
+	}
+
 	v1 := r.Group("/api/v1")
 	{
 		v1.Use(middleware.ProjectAuth(d.Config, d.DB))
@@ -105,6 +131,10 @@ func NewRouter(d RouterDeps) *gin.Engine {
 
 			session.POST("/:session_id/connect_to_space", d.SessionHandler.ConnectToSpace)
 
+			session.PUT("/:session_id/system_prompt", d.SessionHandler.SetSystemPrompt)
+			session.GET("/:session_id/system_prompt", d.SessionHandler.GetSystemPrompt)
+			session.GET("/:session_id/system_prompt/versions", d.SessionHandler.ListSystemPromptVersions)
+
 			session.POST("/:session_id/messages", d.SessionHandler.StoreMessage)
 			session.GET("/:session_id/messages", d.SessionHandler.GetMessages)
 
@@ -125,7 +155,17 @@ func NewRouter(d RouterDeps) *gin.Engine {
 		{
 			disk.GET("", d.DiskHandler.ListDisks)
 			disk.POST("", d.DiskHandler.CreateDisk)
+			disk.PUT("/:disk_id", d.DiskHandler.UpdateDisk)
 			disk.DELETE("/:disk_id", d.DiskHandler.DeleteDisk)
+			disk.POST("/:disk_id/snapshot", d.DiskHandler.CreateDiskSnapshot)
+			disk.GET("/:disk_id/snapshot", d.DiskHandler.ListDiskSnapshots)
+			disk.DELETE("/:disk_id/snapshot/:snapshot_id", d.DiskHandler.DeleteDiskSnapshot)
+			disk.POST("/:disk_id/restore", d.DiskHandler.RestoreDiskSnapshot)
+			disk.POST("/:disk_id/clone", d.DiskHandler.CloneDisk)
+			disk.GET("/:disk_id/changes", d.DiskHandler.ListDiskChanges)
+			disk.POST("/:disk_id/share", d.ShareHandler.CreateShare)
+			disk.GET("/:disk_id/share", d.ShareHandler.ListShares)
+			disk.DELETE("/:disk_id/share/:share_id", d.ShareHandler.RevokeShare)
 
 			artifact := disk.Group("/:disk_id/artifact")
 			{
@@ -134,9 +174,22 @@ func NewRouter(d RouterDeps) *gin.Engine {
 				artifact.PUT("", d.ArtifactHandler.UpdateArtifact)
 				artifact.DELETE("", d.ArtifactHandler.DeleteArtifact)
 				artifact.GET("/ls", d.ArtifactHandler.ListArtifacts)
+				artifact.GET("/versions", d.ArtifactHandler.ListArtifactVersions)
+				artifact.GET("/version", d.ArtifactHandler.GetArtifactVersion)
+				artifact.GET("/diff", d.ArtifactHandler.DiffArtifactVersions)
+				artifact.POST("/restore", d.ArtifactHandler.RestoreArtifactVersion)
+				artifact.POST("/edit", d.ArtifactHandler.EditArtifact)
+				artifact.POST("/move", d.ArtifactHandler.MoveArtifacts)
+				artifact.POST("/copy", d.ArtifactHandler.CopyArtifacts)
+				artifact.GET("/archive", d.ArtifactHandler.ArchiveArtifacts)
+				artifact.POST("/extract", d.ArtifactHandler.ExtractArchive)
+				artifact.POST("/upload_url", d.ArtifactHandler.CreateUploadURL)
+				artifact.POST("/finalize", d.ArtifactHandler.FinalizeUpload)
 
 				artifact.GET("/grep", d.ArtifactHandler.GrepArtifacts)
+				artifact.GET("/grep/matches", d.ArtifactHandler.GrepArtifactMatches)
 				artifact.GET("/glob", d.ArtifactHandler.GlobArtifacts)
+				artifact.GET("/glob/entries", d.ArtifactHandler.GlobArtifactEntries)
 				artifact.POST("/download_to_sandbox", d.ArtifactHandler.DownloadToSandbox)
 				artifact.POST("/upload_from_sandbox", d.ArtifactHandler.UploadFromSandbox)
 			}
@@ -146,6 +199,8 @@ func NewRouter(d RouterDeps) *gin.Engine {
 		{
 			tool.PUT("/name", d.ToolHandler.RenameToolName)
 			tool.GET("/name", d.ToolHandler.GetToolName)
+			tool.POST("/convert", d.ToolHandler.ConvertTools)
+			tool.GET("/schemas", d.ToolHandler.GetToolSchemas)
 		}
 
 		agentSkills := v1.Group("/agent_skills")
@@ -171,6 +226,13 @@ func NewRouter(d RouterDeps) *gin.Engine {
 			sandbox.POST("/:sandbox_id/exec", d.SandboxHandler.ExecCommand)
 			sandbox.DELETE("/:sandbox_id", d.SandboxHandler.KillSandbox)
 		}
+
+		storage := v1.Group("/storage")
+		{
+			storage.GET("/usage", d.StorageHandler.GetStorageUsage)
+			storage.GET("/quota", d.StorageHandler.ListStorageQuotas)
+			storage.PUT("/quota", d.StorageHandler.SetStorageQuota)
+		}
 	}
 	return r
 }